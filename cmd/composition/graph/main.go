@@ -0,0 +1,52 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/aboglioli/big-brother/composition"
+	infrEvents "github.com/aboglioli/big-brother/infrastructure/events"
+)
+
+// Prints the graph of compositions, or the subgraph reachable from one of
+// them, in JSON, DOT or Mermaid format:
+//
+//	graph -format dot -composition 9dc9c429b9aa2a3c82801007 | dot -Tsvg > bread.svg
+func main() {
+	compID := flag.String("composition", "", "Composition ID. All compositions if empty.")
+	direction := flag.String("direction", composition.DirectionDown, "Direction from the composition: down (dependencies), up (uses) or both.")
+	format := flag.String("format", composition.FormatDOT, "Output format: json, dot or mermaid.")
+	flag.Parse()
+
+	// Dendencies resolution
+	compositionOutbox, err := infrEvents.NewOutbox("Composition")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	compositionRepository, err := composition.NewRepository(compositionOutbox)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	comps, err := compositionRepository.FindAll()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	g := composition.NewGraph(comps)
+	if *compID != "" {
+		if g, err = g.Subgraph(*compID, *direction); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	out, err := g.Export(*format)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Fprintln(os.Stdout, string(out))
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/aboglioli/big-brother/composition"
 	infrComp "github.com/aboglioli/big-brother/infrastructure/composition"
 	infrEvents "github.com/aboglioli/big-brother/infrastructure/events"
+	infrUnits "github.com/aboglioli/big-brother/infrastructure/units"
 )
 
 func main() {
@@ -16,13 +17,23 @@ func main() {
 		return
 	}
 
-	compositionRepository, err := composition.NewRepository()
+	if _, err := infrUnits.UseRegistry(); err != nil {
+		log.Fatal(err)
+		return
+	}
+
+	compositionOutbox, err := infrEvents.NewOutbox("Composition")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	compositionRepository, err := composition.NewRepository(compositionOutbox)
 	if err != nil {
 		log.Fatal(err)
 		return
 	}
 
-	compositionService := composition.NewService(compositionRepository, eventMgr)
+	compositionService := composition.NewService(compositionRepository)
 
 	infrComp.StartREST(eventMgr, compositionService)
 }
@@ -6,22 +6,22 @@ import (
 
 	"github.com/aboglioli/big-brother/composition"
 	infrEvents "github.com/aboglioli/big-brother/infrastructure/events"
+	infrUnits "github.com/aboglioli/big-brother/infrastructure/units"
 	"github.com/aboglioli/big-brother/pkg/errors"
 	"github.com/aboglioli/big-brother/pkg/events"
 )
 
 type Context struct {
-	eventMgr events.Manager
-	repo     composition.Repository
-	serv     composition.Service
+	repo composition.Repository
+	serv composition.Service
 }
 
-func (c *Context) UpdateUses(comp *composition.Composition) error {
+func (c *Context) UpdateUses(comp *composition.Composition, cause events.Event) error {
 	path := "cmd/uses/main.Context.UpdateUses"
 
 	fmt.Printf("# Updating uses of %s (%s): ", comp.Name, comp.ID.Hex())
 
-	uses, err := c.serv.UpdateUses(comp)
+	uses, err := c.serv.UpdateUses(comp, cause)
 	if err != nil {
 		return errors.NewInternal("UPDATE_USES").SetPath(path).SetRef(err)
 	}
@@ -33,13 +33,14 @@ func (c *Context) UpdateUses(comp *composition.Composition) error {
 
 	// Update composition to set UsesUpdatedSinceLastChange
 	comp.UsesUpdatedSinceLastChange = true
-	if err := c.repo.Update(comp); err != nil {
-		return errors.NewInternal("UPDATE_UsesUpdatedSinceLastChange").SetPath(path).SetRef(err)
+	event, opts := composition.NewCompositionUsesUpdatedSinceLastChangeEvent(comp, cause)
+	entry, err := events.NewEntry(comp.ID.Hex(), event, opts)
+	if err != nil {
+		return errors.NewInternal("ENCODE_CompositionUsesUpdatedSinceLastChange").SetPath(path).SetRef(err)
 	}
 
-	event, opts := composition.NewCompositionUsesUpdatedSinceLastChangeEvent(comp)
-	if err := c.eventMgr.Publish(event, opts); err != nil {
-		return errors.NewInternal("PUBLISH_CompositionUsesUpdatedSinceLastChange").SetPath(path).SetRef(err)
+	if err := c.repo.Update(comp, entry); err != nil {
+		return errors.NewInternal("UPDATE_UsesUpdatedSinceLastChange").SetPath(path).SetRef(err)
 	}
 
 	return nil
@@ -52,17 +53,25 @@ func main() {
 		log.Fatal(err)
 	}
 
-	compositionRepository, err := composition.NewRepository()
+	if _, err := infrUnits.UseRegistry(); err != nil {
+		log.Fatal(err)
+	}
+
+	compositionOutbox, err := infrEvents.NewOutbox("Composition")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	compositionRepository, err := composition.NewRepository(compositionOutbox)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	compositionService := composition.NewService(compositionRepository, eventMgr)
+	compositionService := composition.NewService(compositionRepository)
 
 	ctx := &Context{
-		eventMgr: eventMgr,
-		repo:     compositionRepository,
-		serv:     compositionService,
+		repo: compositionRepository,
+		serv: compositionService,
 	}
 
 	forever := make(chan bool)
@@ -85,7 +94,7 @@ func main() {
 				}
 				comp := event.Composition
 
-				if err := ctx.UpdateUses(comp); err != nil {
+				if err := ctx.UpdateUses(comp, event.Event); err != nil {
 					fmt.Println(comp.ID.Hex(), err)
 				}
 			}
@@ -31,6 +31,11 @@ func main() {
 
 			fmt.Printf("# NEW EVENT: %s\n", msg.Type())
 
+			var envelope events.Event
+			if err := msg.Decode(&envelope); err == nil {
+				fmt.Printf("- Event: %s (producer: %s, correlation: %s, causation: %s)\n", envelope.ID, envelope.Producer, envelope.CorrelationID, envelope.CausationID)
+			}
+
 			switch eventType {
 			case "CompositionCreated", "CompositionUpdatedManually", "CompositionUsesUpdatedSinceLastChange":
 				var event composition.CompositionChangedEvent
@@ -51,6 +56,18 @@ func main() {
 				for _, c := range comps {
 					fmt.Printf("-- %s (%s)\n", c.Name, c.ID.Hex())
 				}
+			case "StockBelowReorderPoint":
+				var event composition.StockBelowReorderPointEvent
+				if err := msg.Decode(&event); err != nil {
+					fmt.Println(err)
+					continue
+				}
+				comp := event.Composition
+				fmt.Printf("- Composition: %s (%s)\n", comp.Name, comp.ID.Hex())
+				fmt.Printf("- Stock: %.2f %s (reorder point: %.2f %s)\n", comp.Stock.Quantity, comp.Stock.Unit, comp.ReorderPoint.Quantity, comp.ReorderPoint.Unit)
+				if event.BelowMinimumStock {
+					fmt.Println("- Below minimum stock")
+				}
 			default:
 				fmt.Println("- Unknown event")
 			}
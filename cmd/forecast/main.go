@@ -0,0 +1,85 @@
+package main
+
+import (
+	"log"
+
+	"github.com/aboglioli/big-brother/composition"
+	"github.com/aboglioli/big-brother/forecast"
+	infrEvents "github.com/aboglioli/big-brother/infrastructure/events"
+	infrForecast "github.com/aboglioli/big-brother/infrastructure/forecast"
+	infrUnits "github.com/aboglioli/big-brother/infrastructure/units"
+	"github.com/aboglioli/big-brother/pkg/config"
+	"github.com/aboglioli/big-brother/pricing"
+	"github.com/aboglioli/big-brother/sales"
+	"github.com/aboglioli/big-brother/stock"
+)
+
+func main() {
+	// Dendencies resolution
+	eventMgr, err := infrEvents.GetManager()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if _, err := infrUnits.UseRegistry(); err != nil {
+		log.Fatal(err)
+	}
+
+	compositionOutbox, err := infrEvents.NewOutbox("Composition")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	compositionRepository, err := composition.NewRepository(compositionOutbox)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	stockOutbox, err := infrEvents.NewOutbox("Stock")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	lotRepository, err := stock.NewLotRepository(stockOutbox)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	movementRepository, err := stock.NewMovementRepository(stockOutbox)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	countRepository, err := stock.NewCountRepository()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	priceListRepository, err := pricing.NewRepository()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	customerRepository, err := sales.NewCustomerRepository()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	salesOutbox, err := infrEvents.NewOutbox("Sales")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	orderRepository, err := sales.NewOrderRepository(salesOutbox)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	compositionService := composition.NewService(compositionRepository)
+	stockService := stock.NewService(lotRepository, movementRepository, countRepository, compositionService)
+	pricingService := pricing.NewService(priceListRepository, compositionService, eventMgr, config.Get().MarginFloor)
+	salesService := sales.NewService(customerRepository, orderRepository, compositionService, stockService, pricingService)
+	forecastService := forecast.NewService(compositionService, salesService)
+
+	infrForecast.StartREST(eventMgr, forecastService)
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/aboglioli/big-brother/composition"
+	infrEvents "github.com/aboglioli/big-brother/infrastructure/events"
+	infrUnits "github.com/aboglioli/big-brother/infrastructure/units"
+	"github.com/aboglioli/big-brother/invoice"
+	"github.com/aboglioli/big-brother/pkg/config"
+	"github.com/aboglioli/big-brother/pkg/errors"
+	"github.com/aboglioli/big-brother/pkg/events"
+	"github.com/aboglioli/big-brother/pricing"
+	"github.com/aboglioli/big-brother/sales"
+	"github.com/aboglioli/big-brother/stock"
+)
+
+type Context struct {
+	serv invoice.Service
+}
+
+func (c *Context) CreateInvoice(o *sales.Order) error {
+	path := "cmd/invoice/delivered/main.Context.CreateInvoice"
+
+	fmt.Printf("# Invoicing order %s: ", o.ID.Hex())
+
+	inv, err := c.serv.CreateFromOrder(o.ID.Hex())
+	if err != nil {
+		return errors.NewInternal("CREATE_FROM_ORDER").SetPath(path).SetRef(err)
+	}
+	fmt.Printf("draft invoice %s, total %.2f\n", inv.ID.Hex(), inv.Total)
+
+	return nil
+}
+
+func main() {
+	// Dendencies resolution
+	eventMgr, err := infrEvents.GetManager()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if _, err := infrUnits.UseRegistry(); err != nil {
+		log.Fatal(err)
+	}
+
+	compositionOutbox, err := infrEvents.NewOutbox("Composition")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	compositionRepository, err := composition.NewRepository(compositionOutbox)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	stockOutbox, err := infrEvents.NewOutbox("Stock")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	lotRepository, err := stock.NewLotRepository(stockOutbox)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	movementRepository, err := stock.NewMovementRepository(stockOutbox)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	countRepository, err := stock.NewCountRepository()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	priceListRepository, err := pricing.NewRepository()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	customerRepository, err := sales.NewCustomerRepository()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	salesOutbox, err := infrEvents.NewOutbox("Sales")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	orderRepository, err := sales.NewOrderRepository(salesOutbox)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	invoiceOutbox, err := infrEvents.NewOutbox("Invoice")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	invoiceRepository, err := invoice.NewRepository(invoiceOutbox)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	compositionService := composition.NewService(compositionRepository)
+	stockService := stock.NewService(lotRepository, movementRepository, countRepository, compositionService)
+	pricingService := pricing.NewService(priceListRepository, compositionService, eventMgr, config.Get().MarginFloor)
+	salesService := sales.NewService(customerRepository, orderRepository, compositionService, stockService, pricingService)
+
+	ctx := &Context{
+		serv: invoice.NewService(invoiceRepository, salesService, compositionService, config.Get().TaxRates),
+	}
+
+	forever := make(chan bool)
+
+	go func() {
+		opts := &events.Options{"sales", "topic", "sales.order.delivered", "invoicing"}
+		msgs, err := eventMgr.Consume(opts)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+
+		fmt.Println("[Listening for delivered sales orders]")
+		for msg := range msgs {
+			if msg.Type() == "SalesOrderDelivered" {
+				var event sales.OrderChangedEvent
+				if err := msg.Decode(&event); err != nil {
+					fmt.Println(err)
+					continue
+				}
+
+				if err := ctx.CreateInvoice(event.Order); err != nil {
+					fmt.Println(err)
+				}
+			}
+			msg.Ack()
+		}
+	}()
+
+	<-forever
+}
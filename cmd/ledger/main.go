@@ -0,0 +1,40 @@
+package main
+
+import (
+	"log"
+
+	infrEvents "github.com/aboglioli/big-brother/infrastructure/events"
+	infrLedger "github.com/aboglioli/big-brother/infrastructure/ledger"
+	infrUnits "github.com/aboglioli/big-brother/infrastructure/units"
+	"github.com/aboglioli/big-brother/ledger"
+)
+
+func main() {
+	// Dendencies resolution
+	eventMgr, err := infrEvents.GetManager()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if _, err := infrUnits.UseRegistry(); err != nil {
+		log.Fatal(err)
+	}
+
+	accountRepository, err := ledger.NewAccountRepository()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	entryRepository, err := ledger.NewEntryRepository()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ledgerService := ledger.NewService(accountRepository, entryRepository, eventMgr)
+
+	if _, err := ledgerService.SetupChart(); err != nil {
+		log.Fatal(err)
+	}
+
+	infrLedger.StartREST(eventMgr, ledgerService)
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	infrEvents "github.com/aboglioli/big-brother/infrastructure/events"
+	infrUnits "github.com/aboglioli/big-brother/infrastructure/units"
+	"github.com/aboglioli/big-brother/invoice"
+	"github.com/aboglioli/big-brother/ledger"
+	"github.com/aboglioli/big-brother/pkg/events"
+	"github.com/aboglioli/big-brother/purchase"
+	"github.com/aboglioli/big-brother/stock"
+)
+
+type Context struct {
+	eventMgr events.Manager
+	serv     ledger.Service
+}
+
+// Consume posts journal entries for the messages of a queue. Messages already
+// posted are acknowledged and ignored.
+func (c *Context) Consume(opts *events.Options, post func(msg events.Message) (*ledger.Entry, error)) {
+	msgs, err := c.eventMgr.Consume(opts)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	fmt.Printf("[Listening for %s]\n", opts.Key)
+	for msg := range msgs {
+		e, err := post(msg)
+		if err != nil {
+			fmt.Println(msg.Type(), err)
+		} else if e != nil {
+			fmt.Printf("# Posted %s (%s): %s\n", e.ID.Hex(), e.Source, e.Description)
+		}
+		msg.Ack()
+	}
+}
+
+func main() {
+	// Dendencies resolution
+	eventMgr, err := infrEvents.GetManager()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if _, err := infrUnits.UseRegistry(); err != nil {
+		log.Fatal(err)
+	}
+
+	accountRepository, err := ledger.NewAccountRepository()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	entryRepository, err := ledger.NewEntryRepository()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ledgerService := ledger.NewService(accountRepository, entryRepository, eventMgr)
+
+	if _, err := ledgerService.SetupChart(); err != nil {
+		log.Fatal(err)
+	}
+
+	ctx := &Context{
+		eventMgr: eventMgr,
+		serv:     ledgerService,
+	}
+
+	forever := make(chan bool)
+
+	go ctx.Consume(&events.Options{"stock", "topic", "stock.movement", "ledger-stock"}, func(msg events.Message) (*ledger.Entry, error) {
+		if msg.Type() != "StockMovementPosted" {
+			return nil, nil
+		}
+
+		var event stock.MovementPostedEvent
+		if err := msg.Decode(&event); err != nil {
+			return nil, err
+		}
+
+		return ctx.serv.PostMovement(event.Movement)
+	})
+
+	go ctx.Consume(&events.Options{"purchase", "topic", "purchase.order.received", "ledger-purchase"}, func(msg events.Message) (*ledger.Entry, error) {
+		if msg.Type() != "PurchaseOrderReceived" && msg.Type() != "PurchaseOrderPartiallyReceived" {
+			return nil, nil
+		}
+
+		var event purchase.OrderChangedEvent
+		if err := msg.Decode(&event); err != nil {
+			return nil, err
+		}
+
+		return ctx.serv.PostReceipt(event.Order)
+	})
+
+	go ctx.Consume(&events.Options{"invoice", "topic", "invoice.issued", "ledger-invoice"}, func(msg events.Message) (*ledger.Entry, error) {
+		if msg.Type() != "InvoiceIssued" {
+			return nil, nil
+		}
+
+		var event invoice.InvoiceIssuedEvent
+		if err := msg.Decode(&event); err != nil {
+			return nil, err
+		}
+
+		return ctx.serv.PostInvoice(event.Invoice)
+	})
+
+	<-forever
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"log"
+
+	"github.com/aboglioli/big-brother/composition"
+	"github.com/aboglioli/big-brother/forecast"
+	infrEvents "github.com/aboglioli/big-brother/infrastructure/events"
+	infrMrp "github.com/aboglioli/big-brother/infrastructure/mrp"
+	infrUnits "github.com/aboglioli/big-brother/infrastructure/units"
+	"github.com/aboglioli/big-brother/mrp"
+	"github.com/aboglioli/big-brother/pkg/config"
+	"github.com/aboglioli/big-brother/pricing"
+	"github.com/aboglioli/big-brother/production"
+	"github.com/aboglioli/big-brother/purchase"
+	"github.com/aboglioli/big-brother/routing"
+	"github.com/aboglioli/big-brother/sales"
+	"github.com/aboglioli/big-brother/stock"
+	"github.com/aboglioli/big-brother/supplier"
+)
+
+func main() {
+	// Dendencies resolution
+	eventMgr, err := infrEvents.GetManager()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if _, err := infrUnits.UseRegistry(); err != nil {
+		log.Fatal(err)
+	}
+
+	compositionOutbox, err := infrEvents.NewOutbox("Composition")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	compositionRepository, err := composition.NewRepository(compositionOutbox)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	stockOutbox, err := infrEvents.NewOutbox("Stock")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	lotRepository, err := stock.NewLotRepository(stockOutbox)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	movementRepository, err := stock.NewMovementRepository(stockOutbox)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	countRepository, err := stock.NewCountRepository()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	supplierRepository, err := supplier.NewRepository()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	priceListRepository, err := pricing.NewRepository()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	customerRepository, err := sales.NewCustomerRepository()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	salesOutbox, err := infrEvents.NewOutbox("Sales")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	orderRepository, err := sales.NewOrderRepository(salesOutbox)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	purchaseOutbox, err := infrEvents.NewOutbox("Purchase")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	purchaseRepository, err := purchase.NewRepository(purchaseOutbox)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	workCenterRepository, err := routing.NewWorkCenterRepository()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	routingRepository, err := routing.NewRoutingRepository()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	productionRepository, err := production.NewRepository()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	compositionService := composition.NewService(compositionRepository)
+	stockService := stock.NewService(lotRepository, movementRepository, countRepository, compositionService)
+	supplierService := supplier.NewService(supplierRepository, compositionService, eventMgr)
+	pricingService := pricing.NewService(priceListRepository, compositionService, eventMgr, config.Get().MarginFloor)
+	salesService := sales.NewService(customerRepository, orderRepository, compositionService, stockService, pricingService)
+	purchaseService := purchase.NewService(purchaseRepository, compositionService, supplierService, stockService)
+	routingService := routing.NewService(workCenterRepository, routingRepository, compositionService)
+	productionService := production.NewService(productionRepository, compositionService, stockService, routingService, eventMgr)
+	forecastService := forecast.NewService(compositionService, salesService)
+	mrpService := mrp.NewService(compositionService, salesService, purchaseService, productionService, supplierService, forecastService)
+
+	infrMrp.StartREST(eventMgr, mrpService)
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"log"
+
+	"github.com/aboglioli/big-brother/composition"
+	infrEvents "github.com/aboglioli/big-brother/infrastructure/events"
+	infrPricing "github.com/aboglioli/big-brother/infrastructure/pricing"
+	infrUnits "github.com/aboglioli/big-brother/infrastructure/units"
+	"github.com/aboglioli/big-brother/pkg/config"
+	"github.com/aboglioli/big-brother/pricing"
+)
+
+func main() {
+	// Dendencies resolution
+	eventMgr, err := infrEvents.GetManager()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if _, err := infrUnits.UseRegistry(); err != nil {
+		log.Fatal(err)
+	}
+
+	compositionOutbox, err := infrEvents.NewOutbox("Composition")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	compositionRepository, err := composition.NewRepository(compositionOutbox)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	priceListRepository, err := pricing.NewRepository()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	compositionService := composition.NewService(compositionRepository)
+	pricingService := pricing.NewService(priceListRepository, compositionService, eventMgr, config.Get().MarginFloor)
+
+	infrPricing.StartREST(eventMgr, pricingService)
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/aboglioli/big-brother/composition"
+	infrEvents "github.com/aboglioli/big-brother/infrastructure/events"
+	infrUnits "github.com/aboglioli/big-brother/infrastructure/units"
+	"github.com/aboglioli/big-brother/pkg/config"
+	"github.com/aboglioli/big-brother/pkg/errors"
+	"github.com/aboglioli/big-brother/pkg/events"
+	"github.com/aboglioli/big-brother/pricing"
+)
+
+type Context struct {
+	serv pricing.Service
+}
+
+func (c *Context) CheckMargins(comps []*composition.Composition) error {
+	path := "cmd/pricing/margins/main.Context.CheckMargins"
+
+	fmt.Printf("# Checking margins of %d compositions: ", len(comps))
+
+	flagged, err := c.serv.CheckMargins(comps)
+	if err != nil {
+		return errors.NewInternal("CHECK_MARGINS").SetPath(path).SetRef(err)
+	}
+	fmt.Printf("flagged %d entries\n", len(flagged))
+
+	for _, a := range flagged {
+		fmt.Printf("- entry %s of %s (%s): margin %.3f\n", a.Entry.Hex(), a.PriceList.Hex(), a.Composition.Hex(), a.Margin)
+	}
+
+	return nil
+}
+
+func main() {
+	// Dendencies resolution
+	eventMgr, err := infrEvents.GetManager()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if _, err := infrUnits.UseRegistry(); err != nil {
+		log.Fatal(err)
+	}
+
+	compositionOutbox, err := infrEvents.NewOutbox("Composition")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	compositionRepository, err := composition.NewRepository(compositionOutbox)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	priceListRepository, err := pricing.NewRepository()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	compositionService := composition.NewService(compositionRepository)
+
+	ctx := &Context{
+		serv: pricing.NewService(priceListRepository, compositionService, eventMgr, config.Get().MarginFloor),
+	}
+
+	forever := make(chan bool)
+
+	go func() {
+		opts := &events.Options{"composition", "topic", "composition.updated", "margins"}
+		msgs, err := eventMgr.Consume(opts)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+
+		fmt.Println("[Listening for composition cost updates]")
+		for msg := range msgs {
+			if msg.Type() == "CompositionsUpdatedAutomatically" {
+				var event composition.CompositionsUpdatedAutomaticallyEvent
+				if err := msg.Decode(&event); err != nil {
+					fmt.Println(err)
+					continue
+				}
+
+				if err := ctx.CheckMargins(event.Compositions); err != nil {
+					fmt.Println(err)
+				}
+			}
+			msg.Ack()
+		}
+	}()
+
+	<-forever
+}
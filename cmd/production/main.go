@@ -0,0 +1,77 @@
+package main
+
+import (
+	"log"
+
+	"github.com/aboglioli/big-brother/composition"
+	infrEvents "github.com/aboglioli/big-brother/infrastructure/events"
+	infrProduction "github.com/aboglioli/big-brother/infrastructure/production"
+	infrUnits "github.com/aboglioli/big-brother/infrastructure/units"
+	"github.com/aboglioli/big-brother/production"
+	"github.com/aboglioli/big-brother/routing"
+	"github.com/aboglioli/big-brother/stock"
+)
+
+func main() {
+	// Dendencies resolution
+	eventMgr, err := infrEvents.GetManager()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if _, err := infrUnits.UseRegistry(); err != nil {
+		log.Fatal(err)
+	}
+
+	compositionOutbox, err := infrEvents.NewOutbox("Composition")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	compositionRepository, err := composition.NewRepository(compositionOutbox)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	stockOutbox, err := infrEvents.NewOutbox("Stock")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	lotRepository, err := stock.NewLotRepository(stockOutbox)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	movementRepository, err := stock.NewMovementRepository(stockOutbox)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	countRepository, err := stock.NewCountRepository()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	workCenterRepository, err := routing.NewWorkCenterRepository()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	routingRepository, err := routing.NewRoutingRepository()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	productionRepository, err := production.NewRepository()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	compositionService := composition.NewService(compositionRepository)
+	stockService := stock.NewService(lotRepository, movementRepository, countRepository, compositionService)
+	routingService := routing.NewService(workCenterRepository, routingRepository, compositionService)
+	productionService := production.NewService(productionRepository, compositionService, stockService, routingService, eventMgr)
+
+	infrProduction.StartREST(eventMgr, productionService)
+}
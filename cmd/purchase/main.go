@@ -0,0 +1,77 @@
+package main
+
+import (
+	"log"
+
+	"github.com/aboglioli/big-brother/composition"
+	infrEvents "github.com/aboglioli/big-brother/infrastructure/events"
+	infrPurchase "github.com/aboglioli/big-brother/infrastructure/purchase"
+	infrUnits "github.com/aboglioli/big-brother/infrastructure/units"
+	"github.com/aboglioli/big-brother/purchase"
+	"github.com/aboglioli/big-brother/stock"
+	"github.com/aboglioli/big-brother/supplier"
+)
+
+func main() {
+	// Dendencies resolution
+	eventMgr, err := infrEvents.GetManager()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if _, err := infrUnits.UseRegistry(); err != nil {
+		log.Fatal(err)
+	}
+
+	compositionOutbox, err := infrEvents.NewOutbox("Composition")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	compositionRepository, err := composition.NewRepository(compositionOutbox)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	supplierRepository, err := supplier.NewRepository()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	stockOutbox, err := infrEvents.NewOutbox("Stock")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	lotRepository, err := stock.NewLotRepository(stockOutbox)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	movementRepository, err := stock.NewMovementRepository(stockOutbox)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	countRepository, err := stock.NewCountRepository()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	purchaseOutbox, err := infrEvents.NewOutbox("Purchase")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	purchaseRepository, err := purchase.NewRepository(purchaseOutbox)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	compositionService := composition.NewService(compositionRepository)
+	supplierService := supplier.NewService(supplierRepository, compositionService, eventMgr)
+	stockService := stock.NewService(lotRepository, movementRepository, countRepository, compositionService)
+	purchaseService := purchase.NewService(purchaseRepository, compositionService, supplierService, stockService)
+
+	infrPurchase.StartREST(eventMgr, purchaseService)
+}
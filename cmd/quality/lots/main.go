@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/aboglioli/big-brother/composition"
+	infrEvents "github.com/aboglioli/big-brother/infrastructure/events"
+	infrUnits "github.com/aboglioli/big-brother/infrastructure/units"
+	"github.com/aboglioli/big-brother/pkg/errors"
+	"github.com/aboglioli/big-brother/pkg/events"
+	"github.com/aboglioli/big-brother/quality"
+	"github.com/aboglioli/big-brother/stock"
+)
+
+type Context struct {
+	serv quality.Service
+}
+
+// Inspect opens an inspection of a new lot if its composition has a plan for
+// the stage the lot comes from.
+func (c *Context) Inspect(lot *stock.Lot) error {
+	path := "cmd/quality/lots/main.Context.Inspect"
+
+	i, err := c.serv.OpenForLot(lot)
+	if err != nil {
+		return errors.NewInternal("OPEN_INSPECTION").SetPath(path).SetRef(err)
+	}
+
+	if i != nil {
+		fmt.Printf("# Inspection %s opened for lot %s (%s)\n", i.ID.Hex(), lot.ID.Hex(), i.Stage)
+	}
+
+	return nil
+}
+
+func main() {
+	// Dendencies resolution
+	eventMgr, err := infrEvents.GetManager()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if _, err := infrUnits.UseRegistry(); err != nil {
+		log.Fatal(err)
+	}
+
+	compositionOutbox, err := infrEvents.NewOutbox("Composition")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	compositionRepository, err := composition.NewRepository(compositionOutbox)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	stockOutbox, err := infrEvents.NewOutbox("Stock")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	lotRepository, err := stock.NewLotRepository(stockOutbox)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	movementRepository, err := stock.NewMovementRepository(stockOutbox)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	countRepository, err := stock.NewCountRepository()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	planRepository, err := quality.NewPlanRepository()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	inspectionRepository, err := quality.NewInspectionRepository()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	compositionService := composition.NewService(compositionRepository)
+	stockService := stock.NewService(lotRepository, movementRepository, countRepository, compositionService)
+
+	ctx := &Context{
+		serv: quality.NewService(planRepository, inspectionRepository, compositionService, stockService, eventMgr),
+	}
+
+	forever := make(chan bool)
+
+	go func() {
+		opts := &events.Options{"stock", "topic", "stock.lot.created", "quality"}
+		msgs, err := eventMgr.Consume(opts)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+
+		fmt.Println("[Listening for new lots]")
+		for msg := range msgs {
+			if msg.Type() == "LotCreated" {
+				var event stock.LotChangedEvent
+				if err := msg.Decode(&event); err != nil {
+					fmt.Println(err)
+					continue
+				}
+
+				if err := ctx.Inspect(event.Lot); err != nil {
+					fmt.Println(err)
+				}
+			}
+			msg.Ack()
+		}
+	}()
+
+	<-forever
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/aboglioli/big-brother/composition"
+	infrEvents "github.com/aboglioli/big-brother/infrastructure/events"
+	infrUnits "github.com/aboglioli/big-brother/infrastructure/units"
+	"github.com/aboglioli/big-brother/pkg/errors"
+	"github.com/aboglioli/big-brother/pkg/events"
+	"github.com/aboglioli/big-brother/routing"
+)
+
+type Context struct {
+	serv routing.Service
+}
+
+// UpdateCost recalculates the routing cost of a composition, which depends on
+// its unit and lot size.
+func (c *Context) UpdateCost(comp *composition.Composition) error {
+	path := "cmd/routing/costs/main.Context.UpdateCost"
+
+	updated, err := c.serv.UpdateCompositionCost(comp.ID.Hex())
+	if err != nil {
+		return errors.NewInternal("UPDATE_COMPOSITION_COST").SetPath(path).SetRef(err)
+	}
+
+	if updated.RoutingCost != comp.RoutingCost {
+		fmt.Printf("# Routing cost of %s: %.3f -> %.3f\n", comp.ID.Hex(), comp.RoutingCost, updated.RoutingCost)
+	}
+
+	return nil
+}
+
+func main() {
+	// Dendencies resolution
+	eventMgr, err := infrEvents.GetManager()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if _, err := infrUnits.UseRegistry(); err != nil {
+		log.Fatal(err)
+	}
+
+	compositionOutbox, err := infrEvents.NewOutbox("Composition")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	compositionRepository, err := composition.NewRepository(compositionOutbox)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	workCenterRepository, err := routing.NewWorkCenterRepository()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	routingRepository, err := routing.NewRoutingRepository()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	compositionService := composition.NewService(compositionRepository)
+
+	ctx := &Context{
+		serv: routing.NewService(workCenterRepository, routingRepository, compositionService),
+	}
+
+	forever := make(chan bool)
+
+	go func() {
+		opts := &events.Options{"composition", "topic", "composition.updated", "routing-costs"}
+		msgs, err := eventMgr.Consume(opts)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+
+		fmt.Println("[Listening for composition updates]")
+		for msg := range msgs {
+			if msg.Type() == "CompositionUpdatedManually" {
+				var event composition.CompositionChangedEvent
+				if err := msg.Decode(&event); err != nil {
+					fmt.Println(err)
+					continue
+				}
+
+				if err := ctx.UpdateCost(event.Composition); err != nil {
+					fmt.Println(err)
+				}
+			}
+			msg.Ack()
+		}
+	}()
+
+	<-forever
+}
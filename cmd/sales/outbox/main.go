@@ -0,0 +1,29 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	infrEvents "github.com/aboglioli/big-brother/infrastructure/events"
+	"github.com/aboglioli/big-brother/pkg/events"
+)
+
+// Publishes the sales events stored in the outbox.
+func main() {
+	// Dendencies resolution
+	eventMgr, err := infrEvents.GetManager()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	outbox, err := infrEvents.NewOutbox("Sales")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	relay := events.NewRelay(outbox, eventMgr)
+
+	fmt.Println("[Relaying sales events]")
+	relay.Run(time.Second, nil)
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"log"
+
+	"github.com/aboglioli/big-brother/composition"
+	infrEvents "github.com/aboglioli/big-brother/infrastructure/events"
+	infrUnits "github.com/aboglioli/big-brother/infrastructure/units"
+	"github.com/aboglioli/big-brother/units"
+)
+
+func main() {
+	// Dendencies resolution
+	eventMgr, err := infrEvents.GetManager()
+	if err != nil {
+		log.Fatal(err)
+		return
+	}
+
+	registry, err := infrUnits.UseRegistry()
+	if err != nil {
+		log.Fatal(err)
+		return
+	}
+
+	unitRepository, err := units.NewRepository()
+	if err != nil {
+		log.Fatal(err)
+		return
+	}
+
+	compositionOutbox, err := infrEvents.NewOutbox("Composition")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	compositionRepository, err := composition.NewRepository(compositionOutbox)
+	if err != nil {
+		log.Fatal(err)
+		return
+	}
+
+	compositionService := composition.NewService(compositionRepository)
+	unitsService := units.NewService(unitRepository, registry, compositionService)
+
+	infrUnits.StartREST(eventMgr, unitsService)
+}
@@ -0,0 +1,162 @@
+package composition
+
+import (
+	"math"
+	"sort"
+
+	"github.com/aboglioli/big-brother/pkg/errors"
+	"github.com/aboglioli/big-brother/pkg/quantity"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	InputMaterial = "MATERIAL"
+	InputRouting  = "ROUTING"
+)
+
+// maxDepth limits the levels of dependencies walked, so cycles are detected.
+const maxDepth = 64
+
+// CostInput is a leaf material of a composition, or the routing cost of one
+// of its intermediates, with its contribution to the cost of a unit through
+// all paths. Leaves are compositions without dependencies or whose cost is
+// not updated from them.
+//
+// Quantity is the material used by unit. Share is the part of the cost
+// contributed by the input and CumulativeShare the part contributed by it and
+// the inputs ranked above. Sensitivity is how much the cost moves per 1%
+// change in the cost of the input.
+type CostInput struct {
+	Composition     primitive.ObjectID `json:"composition"`
+	Name            string             `json:"name"`
+	Type            string             `json:"type"`
+	Quantity        *quantity.Quantity `json:"quantity"`
+	Cost            float64            `json:"cost"`
+	Share           float64            `json:"share"`
+	CumulativeShare float64            `json:"cumulativeShare"`
+	Sensitivity     float64            `json:"sensitivity"`
+}
+
+// CostAnalysis is the breakdown of the cost of a unit of a composition in its
+// inputs, ranked by contribution.
+type CostAnalysis struct {
+	Composition primitive.ObjectID `json:"composition"`
+	Cost        float64            `json:"cost"`
+	Inputs      []CostInput        `json:"inputs"`
+}
+
+// AnalyzeCost breaks down the cost of c in its leaf materials and routing
+// costs. Dependencies are got by getByID.
+func AnalyzeCost(c *Composition, getByID func(id string) (*Composition, error)) (*CostAnalysis, error) {
+	a := &analyzer{
+		getByID: getByID,
+		inputs:  make(map[string]*CostInput),
+	}
+
+	if err := a.walk(c, 1, 0); err != nil {
+		return nil, err
+	}
+
+	inputs := make([]CostInput, 0, len(a.inputs))
+	total := 0.0
+	for _, in := range a.inputs {
+		inputs = append(inputs, *in)
+		total += in.Cost
+	}
+
+	sort.SliceStable(inputs, func(i, j int) bool {
+		if inputs[i].Cost != inputs[j].Cost {
+			return inputs[i].Cost > inputs[j].Cost
+		}
+		return inputs[i].Name < inputs[j].Name
+	})
+
+	cumulative := 0.0
+	for i := range inputs {
+		in := &inputs[i]
+		if total > 0 {
+			in.Share = in.Cost / total
+		}
+		cumulative += in.Share
+
+		in.CumulativeShare = round(cumulative)
+		in.Share = round(in.Share)
+		in.Sensitivity = round(in.Cost / 100)
+		in.Cost = round(in.Cost)
+		if in.Quantity != nil {
+			in.Quantity.Quantity = round(in.Quantity.Quantity)
+		}
+	}
+
+	return &CostAnalysis{
+		Composition: c.ID,
+		Cost:        round(total),
+		Inputs:      inputs,
+	}, nil
+}
+
+type analyzer struct {
+	getByID func(id string) (*Composition, error)
+	inputs  map[string]*CostInput
+}
+
+// walk adds the inputs of factor units of c.
+func (a *analyzer) walk(c *Composition, factor float64, depth int) error {
+	if depth > maxDepth {
+		return errors.NewStatus("CYCLIC_DEPENDENCIES").SetPath("composition/analysis.walk").SetMessage("%s", c.ID.Hex())
+	}
+
+	if !c.AutoupdateCost || len(c.Dependencies) == 0 {
+		in := a.input(c, InputMaterial)
+		in.Cost += factor * c.Cost
+		in.Quantity.Quantity += factor * c.Unit.Quantity
+		return nil
+	}
+
+	if c.RoutingCost > 0 {
+		a.input(c, InputRouting).Cost += factor * c.RoutingCost
+	}
+
+	for _, d := range c.Dependencies {
+		dep, err := a.getByID(d.On.Hex())
+		if err != nil {
+			return err
+		}
+
+		q, err := dep.ToUnit(d.Quantity)
+		if err != nil {
+			return errors.NewStatus("INCOMPATIBLE_DEPENDENCY_QUANTITY").SetPath("composition/analysis.walk").SetMessage("%s: %v != %v", dep.ID.Hex(), d.Quantity, dep.Unit).SetRef(err)
+		}
+		if dep.Unit.Quantity == 0 {
+			return errors.NewStatus("INVALID_DEPENDENCY_UNIT").SetPath("composition/analysis.walk").SetMessage("%s: %v", dep.ID.Hex(), dep.Unit)
+		}
+		units := q.Quantity / dep.Unit.Quantity
+
+		if err := a.walk(dep, factor*units, depth+1); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (a *analyzer) input(c *Composition, t string) *CostInput {
+	key := t + c.ID.Hex()
+	in, ok := a.inputs[key]
+	if !ok {
+		in = &CostInput{
+			Composition: c.ID,
+			Name:        c.Name,
+			Type:        t,
+		}
+		if t == InputMaterial {
+			in.Quantity = &quantity.Quantity{0, c.Unit.Unit}
+		}
+		a.inputs[key] = in
+	}
+	return in
+}
+
+func round(v float64) float64 {
+	return math.Round(v*1000) / 1000
+}
@@ -0,0 +1,121 @@
+package composition
+
+import (
+	"testing"
+
+	"github.com/aboglioli/big-brother/pkg/errors"
+	"github.com/aboglioli/big-brother/pkg/quantity"
+	"github.com/aboglioli/big-brother/pkg/tests/assert"
+)
+
+func TestAnalyzeCost(t *testing.T) {
+	comps := make(map[string]*Composition)
+	add := func(name string, unit quantity.Quantity, cost float64, deps ...Dependency) *Composition {
+		c := newComposition()
+		c.Name = name
+		c.Unit = unit
+		c.Cost = cost
+		c.Dependencies = deps
+		comps[c.ID.Hex()] = c
+		return c
+	}
+	getByID := func(id string) (*Composition, error) {
+		if c, ok := comps[id]; ok {
+			return c, nil
+		}
+		return nil, errors.NewInternal("NOT_FOUND")
+	}
+
+	flour := add("Flour", quantity.Quantity{1, "kg"}, 10)
+	butter := add("Butter", quantity.Quantity{250, "g"}, 5)
+	sugar := add("Sugar", quantity.Quantity{1, "kg"}, 2)
+	dough := add("Dough", quantity.Quantity{1, "kg"}, 12,
+		Dependency{On: flour.ID, Quantity: quantity.Quantity{600, "g"}},
+		Dependency{On: butter.ID, Quantity: quantity.Quantity{0.25, "kg"}},
+	)
+	dough.RoutingCost = 1
+	bread := add("Bread", quantity.Quantity{1, "u"}, 7.1,
+		Dependency{On: dough.ID, Quantity: quantity.Quantity{500, "g"}},
+		Dependency{On: flour.ID, Quantity: quantity.Quantity{100, "g"}},
+		Dependency{On: sugar.ID, Quantity: quantity.Quantity{50, "g"}},
+	)
+
+	a, err := AnalyzeCost(bread, getByID)
+	assert.Ok(t, err)
+	assert.Equal(t, a.Cost, 7.1)
+	assert.Equal(t, len(a.Inputs), 4)
+
+	// Flour through all paths: 0.5 * 0.6 * 10 + 0.1 * 10
+	in := a.Inputs[0]
+	assert.Equal(t, in.Composition, flour.ID)
+	assert.Equal(t, in.Type, InputMaterial)
+	assert.Equal(t, in.Cost, 4.0)
+	assert.Equal(t, in.Share, 0.563)
+	assert.Equal(t, in.Sensitivity, 0.04)
+	assert.Equal(t, *in.Quantity, quantity.Quantity{0.4, "kg"})
+
+	in = a.Inputs[1]
+	assert.Equal(t, in.Composition, butter.ID)
+	assert.Equal(t, in.Cost, 2.5)
+	assert.Equal(t, *in.Quantity, quantity.Quantity{125, "g"})
+	assert.Equal(t, in.CumulativeShare, 0.915)
+
+	in = a.Inputs[2]
+	assert.Equal(t, in.Composition, dough.ID)
+	assert.Equal(t, in.Type, InputRouting)
+	assert.Equal(t, in.Cost, 0.5)
+	assert.Nil(t, in.Quantity)
+
+	assert.Equal(t, a.Inputs[3].Composition, sugar.ID)
+	assert.Equal(t, a.Inputs[3].CumulativeShare, 1.0)
+
+	t.Run("Fixed cost intermediates are leaves", func(t *testing.T) {
+		dough.AutoupdateCost = false
+		defer func() { dough.AutoupdateCost = true }()
+
+		a, err := AnalyzeCost(bread, getByID)
+		assert.Ok(t, err)
+		assert.Equal(t, len(a.Inputs), 3)
+		assert.Equal(t, a.Inputs[0].Composition, dough.ID)
+		assert.Equal(t, a.Inputs[0].Cost, 6.0)
+	})
+
+	t.Run("Dependency converted by the composition", func(t *testing.T) {
+		sugar.Density = 0.8
+		bread.Dependencies[2].Quantity = quantity.Quantity{62.5, "ml"}
+		defer func() {
+			sugar.Density = 0
+			bread.Dependencies[2].Quantity = quantity.Quantity{50, "g"}
+		}()
+
+		a, err := AnalyzeCost(bread, getByID)
+		assert.Ok(t, err)
+		assert.Equal(t, a.Cost, 7.1)
+		assert.Equal(t, a.Inputs[3].Composition, sugar.ID)
+		assert.Equal(t, *a.Inputs[3].Quantity, quantity.Quantity{0.05, "kg"})
+	})
+
+	t.Run("Incompatible dependency", func(t *testing.T) {
+		bread.Dependencies[2].Quantity = quantity.Quantity{2, "u"}
+		defer func() { bread.Dependencies[2].Quantity = quantity.Quantity{50, "g"} }()
+
+		_, err := AnalyzeCost(bread, getByID)
+		assert.ErrCode(t, err, "INCOMPATIBLE_DEPENDENCY_QUANTITY")
+	})
+
+	t.Run("Zero unit dependency", func(t *testing.T) {
+		sugar.Unit = quantity.Quantity{0, "kg"}
+		defer func() { sugar.Unit = quantity.Quantity{1, "kg"} }()
+
+		_, err := AnalyzeCost(bread, getByID)
+		assert.ErrCode(t, err, "INVALID_DEPENDENCY_UNIT")
+	})
+
+	t.Run("Cyclic dependencies", func(t *testing.T) {
+		flour.Dependencies = []Dependency{Dependency{On: bread.ID, Quantity: quantity.Quantity{1, "u"}}}
+		defer func() { flour.Dependencies = nil }()
+
+		_, err := AnalyzeCost(bread, getByID)
+		assert.ErrCode(t, err, "CYCLIC_DEPENDENCIES")
+	})
+}
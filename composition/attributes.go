@@ -0,0 +1,129 @@
+package composition
+
+import (
+	"math"
+	"sort"
+
+	"github.com/aboglioli/big-brother/pkg/errors"
+)
+
+// Attributes are characteristics of a composition by unit. Values are numeric
+// (net weight, calories, protein) and they are added up through dependencies
+// in proportion to the dependency quantity, as cost. Flags are boolean
+// attributes meaning "contains" (gluten): a composition has a flag if any of
+// its dependencies has it. Sets are lists of items (allergens) joined through
+// dependencies.
+type Attributes struct {
+	Values map[string]float64  `json:"values" bson:"values"`
+	Flags  map[string]bool     `json:"flags" bson:"flags"`
+	Sets   map[string][]string `json:"sets" bson:"sets"`
+}
+
+// IsEmpty returns true if there are no attributes.
+func (a Attributes) IsEmpty() bool {
+	return len(a.Values) == 0 && len(a.Flags) == 0 && len(a.Sets) == 0
+}
+
+// Scale returns the attributes with values multiplied by factor. Flags and
+// sets don't depend on quantities.
+func (a Attributes) Scale(factor float64) Attributes {
+	scaled := Attributes{}
+	for name, v := range a.Values {
+		scaled.setValue(name, math.Round(v*factor*1000)/1000)
+	}
+	for name, f := range a.Flags {
+		scaled.setFlag(name, f)
+	}
+	for name, items := range a.Sets {
+		scaled.addItems(name, items)
+	}
+	return scaled
+}
+
+// Add returns the attributes resulting of adding b to a: values are added,
+// flags set in any of them are set, and sets are joined.
+func (a Attributes) Add(b Attributes) Attributes {
+	sum := a.Scale(1)
+	for name, v := range b.Values {
+		sum.setValue(name, math.Round((sum.Values[name]+v)*1000)/1000)
+	}
+	for name, f := range b.Flags {
+		sum.setFlag(name, sum.Flags[name] || f)
+	}
+	for name, items := range b.Sets {
+		sum.addItems(name, items)
+	}
+	return sum
+}
+
+// Override returns the attributes of a with those declared in b replacing
+// them. Sets are joined, so items can be added but not removed.
+func (a Attributes) Override(b Attributes) Attributes {
+	res := a.Scale(1)
+	for name, v := range b.Values {
+		res.setValue(name, v)
+	}
+	for name, f := range b.Flags {
+		res.setFlag(name, f)
+	}
+	for name, items := range b.Sets {
+		res.addItems(name, items)
+	}
+	return res
+}
+
+func (a Attributes) validate(err *errors.Validation) {
+	for name, v := range a.Values {
+		if name == "" || math.IsNaN(v) || math.IsInf(v, 0) {
+			err.AddWithMessage("attributes", "INVALID_VALUE", "%s", name)
+		}
+	}
+	for name := range a.Flags {
+		if name == "" {
+			err.Add("attributes", "INVALID_FLAG")
+		}
+	}
+	for name, items := range a.Sets {
+		if name == "" {
+			err.Add("attributes", "INVALID_SET")
+		}
+		for _, item := range items {
+			if item == "" {
+				err.AddWithMessage("attributes", "INVALID_SET", "%s", name)
+			}
+		}
+	}
+}
+
+func (a *Attributes) setValue(name string, v float64) {
+	if a.Values == nil {
+		a.Values = make(map[string]float64)
+	}
+	a.Values[name] = v
+}
+
+func (a *Attributes) setFlag(name string, f bool) {
+	if a.Flags == nil {
+		a.Flags = make(map[string]bool)
+	}
+	a.Flags[name] = f
+}
+
+// addItems adds items to a set, keeping it sorted and without duplicates.
+func (a *Attributes) addItems(name string, items []string) {
+	if a.Sets == nil {
+		a.Sets = make(map[string][]string)
+	}
+
+	set := append(make([]string, 0, len(a.Sets[name])+len(items)), a.Sets[name]...)
+	for _, item := range items {
+		i := sort.SearchStrings(set, item)
+		if i < len(set) && set[i] == item {
+			continue
+		}
+		set = append(set, "")
+		copy(set[i+1:], set[i:])
+		set[i] = item
+	}
+	a.Sets[name] = set
+}
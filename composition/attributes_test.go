@@ -0,0 +1,43 @@
+package composition
+
+import (
+	"testing"
+
+	"github.com/aboglioli/big-brother/pkg/tests/assert"
+)
+
+func TestAddAndOverrideAttributes(t *testing.T) {
+	flour := Attributes{
+		Values: map[string]float64{"calories": 364, "protein": 10},
+		Flags:  map[string]bool{"gluten": true},
+		Sets:   map[string][]string{"allergens": []string{"gluten"}},
+	}
+	butter := Attributes{
+		Values: map[string]float64{"calories": 717},
+		Flags:  map[string]bool{"gluten": false},
+		Sets:   map[string][]string{"allergens": []string{"milk", "gluten"}},
+	}
+
+	sum := flour.Scale(2).Add(butter.Scale(0.5))
+	assert.Equal(t, sum.Values["calories"], 1086.5)
+	assert.Equal(t, sum.Values["protein"], 20.0)
+	assert.Assert(t, sum.Flags["gluten"], "Flags set in any of them are set")
+	assert.Equal(t, len(sum.Sets["allergens"]), 2)
+	assert.Equal(t, sum.Sets["allergens"][0], "gluten")
+	assert.Equal(t, sum.Sets["allergens"][1], "milk")
+
+	res := sum.Override(Attributes{
+		Values: map[string]float64{"weight": 450, "protein": 18},
+		Flags:  map[string]bool{"gluten": false},
+		Sets:   map[string][]string{"allergens": []string{"eggs"}},
+	})
+	assert.Equal(t, res.Values["calories"], 1086.5)
+	assert.Equal(t, res.Values["protein"], 18.0)
+	assert.Equal(t, res.Values["weight"], 450.0)
+	assert.Assert(t, !res.Flags["gluten"], "Declared flags override computed ones")
+	assert.Equal(t, len(res.Sets["allergens"]), 3)
+	assert.Equal(t, res.Sets["allergens"][0], "eggs")
+
+	assert.Equal(t, flour.Values["calories"], 364.0, "Attributes are not modified")
+	assert.Equal(t, len(flour.Sets["allergens"]), 1, "Attributes are not modified")
+}
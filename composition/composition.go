@@ -12,11 +12,46 @@ import (
 type Composition struct {
 	ID           primitive.ObjectID `json:"id" bson:"_id"`
 	Name         string             `json:"name" bson:"name"`
+	Category     string             `json:"category" bson:"category"`
 	Cost         float64            `json:"cost" bson:"cost"`
 	Unit         quantity.Quantity  `json:"unit" bson:"unit"`
 	Stock        quantity.Quantity  `json:"stock" bson:"stock"`
+	Reserved     quantity.Quantity  `json:"reserved" bson:"reserved"`
+	Blocked      quantity.Quantity  `json:"blocked" bson:"blocked"`
 	Dependencies []Dependency       `json:"dependencies" bson:"dependencies"`
 
+	// Conversions convert quantities of the composition between unit types
+	// and packaging units (1 box = 12 u, 1 u = 300 g). Quantities in any
+	// convertible unit are accepted and converted to Unit.
+	Conversions []quantity.Conversion `json:"conversions" bson:"conversions"`
+
+	// Density, in kg/l (g/ml), converts between mass and volume quantities
+	// of liquids. Zero means it's not defined.
+	Density quantity.Density `json:"density" bson:"density"`
+
+	MinimumStock    quantity.Quantity `json:"minimumStock" bson:"minimumStock"`
+	ReorderPoint    quantity.Quantity `json:"reorderPoint" bson:"reorderPoint"`
+	ReorderQuantity quantity.Quantity `json:"reorderQuantity" bson:"reorderQuantity"`
+
+	// LeadTime is the number of days needed to purchase or produce the
+	// composition. Planned orders are rounded up to multiples of LotSize.
+	LeadTime int               `json:"leadTime" bson:"leadTime"`
+	LotSize  quantity.Quantity `json:"lotSize" bson:"lotSize"`
+
+	// RoutingCost is the labor cost of the operations needed to produce a unit.
+	// It is added to the dependency subvalues when the cost is calculated.
+	RoutingCost float64 `json:"routingCost" bson:"routingCost"`
+
+	// Attributes are declared by unit. ComputedAttributes are rolled up from
+	// the dependencies, with declared attributes overriding them (a baked
+	// product weighs less than its ingredients).
+	Attributes         Attributes `json:"attributes" bson:"attributes"`
+	ComputedAttributes Attributes `json:"computedAttributes" bson:"computedAttributes"`
+
+	// Version is incremented on every update. An update fails if the
+	// composition changed since it was read.
+	Version int `json:"version" bson:"version"`
+
 	AutoupdateCost             bool      `json:"autoupdateCost" bson:"autoupdateCost"`
 	Enabled                    bool      `json:"-" bson:"enabled" `
 	Validated                  bool      `json:"-" bson:"validated"`
@@ -37,20 +72,152 @@ func NewComposition() *Composition {
 	}
 }
 
-func (c *Composition) CostFromQuantity(q quantity.Quantity) float64 {
-	nQuantity := q.Normalize()
-	nUnit := c.Unit.Normalize()
+// ToUnit converts a quantity of the composition to its unit.
+func (c *Composition) ToUnit(q quantity.Quantity) (quantity.Quantity, error) {
+	return quantity.Convert(q, c.Unit.Unit, c.conversions())
+}
+
+// conversions returns the declared conversions and the density conversion,
+// if the density is defined.
+func (c *Composition) conversions() []quantity.Conversion {
+	if c.Density <= 0 {
+		return c.Conversions
+	}
+
+	conversions := make([]quantity.Conversion, 0, len(c.Conversions)+1)
+	conversions = append(conversions, c.Conversions...)
+	return append(conversions, c.Density.Conversion())
+}
+
+// Compatible returns true if the quantity is valid and can be converted to
+// the unit of the composition.
+func (c *Composition) Compatible(q quantity.Quantity) bool {
+	if !q.IsValid() {
+		return false
+	}
+
+	_, err := c.ToUnit(q)
+	return err == nil
+}
+
+// toUnit converts q to the unit of the composition if it's of another unit
+// type. If it can't be converted, q is returned, so validation fails.
+func (c *Composition) toUnit(q quantity.Quantity) quantity.Quantity {
+	if q.Compatible(c.Unit) {
+		return q
+	}
+
+	converted, err := c.ToUnit(q)
+	if err != nil {
+		return q
+	}
+	return converted
+}
+
+// units returns the number of composition units in q. It's zero if the unit
+// quantity is zero.
+func (c *Composition) units(q quantity.Quantity) (float64, error) {
+	converted, err := c.ToUnit(q)
+	if err != nil {
+		return 0, errors.NewStatus("INCOMPATIBLE_QUANTITY").SetPath("composition/composition.units").SetMessage("%v != %v", q, c.Unit).SetRef(err)
+	}
+	if c.Unit.Quantity == 0 {
+		return 0, nil
+	}
+
+	return converted.Quantity / c.Unit.Quantity, nil
+}
+
+// CostFromQuantity returns the cost of a quantity of the composition. It
+// fails if the quantity can't be converted to the composition unit.
+func (c *Composition) CostFromQuantity(q quantity.Quantity) (float64, error) {
+	units, err := c.units(q)
+	if err != nil {
+		return 0, err
+	}
+	return units * c.Cost, nil
+}
+
+// AttributesFromQuantity returns the computed attributes of a quantity of the
+// composition.
+func (c *Composition) AttributesFromQuantity(q quantity.Quantity) (Attributes, error) {
+	units, err := c.units(q)
+	if err != nil {
+		return Attributes{}, err
+	}
+	return c.ComputedAttributes.Scale(units), nil
+}
+
+// UsesUnit returns true if any quantity of the composition is in the unit.
+func (c *Composition) UsesUnit(unit string) bool {
+	quantities := []quantity.Quantity{c.Unit, c.Stock, c.Reserved, c.Blocked, c.MinimumStock, c.ReorderPoint, c.ReorderQuantity, c.LotSize}
+	for _, d := range c.Dependencies {
+		quantities = append(quantities, d.Quantity)
+	}
+	for _, conv := range c.Conversions {
+		quantities = append(quantities, conv.From, conv.To)
+	}
+
+	for _, q := range quantities {
+		if q.Unit == unit {
+			return true
+		}
+	}
+	return false
+}
+
+// Available returns the stock not reserved nor blocked.
+func (c *Composition) Available() quantity.Quantity {
+	available := c.Stock
+	for _, q := range []quantity.Quantity{c.Reserved, c.Blocked} {
+		if q.IsEmpty() {
+			continue
+		}
+
+		if rest, err := available.Subtract(q); err == nil {
+			available = rest
+		}
+	}
+
+	return available
+}
+
+// BelowMinimumStock returns true if a minimum stock is defined and current
+// stock is lower than it.
+func (c *Composition) BelowMinimumStock() bool {
+	if c.MinimumStock.IsEmpty() {
+		return false
+	}
+
+	minimum, err := c.ToUnit(c.MinimumStock)
+	if err != nil {
+		return false
+	}
+
+	less, err := c.Stock.Less(minimum)
+	return err == nil && less
+}
+
+// BelowReorderPoint returns true if current stock reached the reorder point.
+// If there is no reorder point defined, minimum stock is used instead.
+func (c *Composition) BelowReorderPoint() bool {
+	if c.ReorderPoint.IsEmpty() {
+		return c.BelowMinimumStock()
+	}
 
-	if nUnit == 0 {
-		return 0
+	reorderPoint, err := c.ToUnit(c.ReorderPoint)
+	if err != nil {
+		return false
 	}
 
-	return nQuantity * c.Cost / nUnit
+	greater, err := c.Stock.Greater(reorderPoint)
+	return err == nil && !greater
 }
 
 func (c *Composition) SetDependencies(deps []Dependency) {
 	c.Dependencies = deps
 	c.calculateCostFromDependencies()
+	c.calculateAttributesFromDependencies()
 }
 
 func (c *Composition) FindDependencyByID(id string) *Dependency {
@@ -77,6 +244,7 @@ func (c *Composition) UpsertDependency(d Dependency) {
 	}
 
 	c.calculateCostFromDependencies()
+	c.calculateAttributesFromDependencies()
 }
 
 func (c *Composition) RemoveDependency(depID string) error {
@@ -96,6 +264,7 @@ func (c *Composition) RemoveDependency(depID string) error {
 	}
 
 	c.calculateCostFromDependencies()
+	c.calculateAttributesFromDependencies()
 
 	return nil
 }
@@ -135,6 +304,57 @@ func (c *Composition) ValidateSchema() error {
 		err.Add("stock", "INCOMPATIBLE_STOCK_AND_UNIT")
 	}
 
+	if !c.Reserved.IsEmpty() && (!c.Reserved.IsValid() || !c.Reserved.Compatible(c.Unit)) {
+		err.Add("reserved", "INVALID")
+	}
+	if !c.Blocked.IsEmpty() && (!c.Blocked.IsValid() || !c.Blocked.Compatible(c.Unit)) {
+		err.Add("blocked", "INVALID")
+	}
+
+	if !c.MinimumStock.IsEmpty() && !c.Compatible(c.MinimumStock) {
+		err.Add("minimumStock", "INVALID")
+	}
+	if !c.ReorderPoint.IsEmpty() && !c.Compatible(c.ReorderPoint) {
+		err.Add("reorderPoint", "INVALID")
+	}
+	if !c.ReorderQuantity.IsEmpty() && !c.Compatible(c.ReorderQuantity) {
+		err.Add("reorderQuantity", "INVALID")
+	}
+
+	if c.RoutingCost < 0 {
+		err.Add("routingCost", "INVALID")
+	}
+
+	c.Attributes.validate(err)
+
+	if c.Density < 0 {
+		err.Add("density", "INVALID")
+	}
+
+	for i, conv := range c.Conversions {
+		if !conv.IsValid() {
+			err.AddWithMessage("conversions", "INVALID", "conversion %d", i)
+			continue
+		}
+
+		// Conversions between the same unit types must agree
+		if q, convErr := quantity.Convert(conv.From, conv.To.Unit, c.conversions()); convErr == nil && math.Abs(q.Quantity-conv.To.Quantity) > 1e-9*conv.To.Quantity {
+			err.AddWithMessage("conversions", "INCONSISTENT", "conversion %d: %v = %v", i, conv.From, q)
+		}
+
+		if c.Unit.IsValid() && !c.Compatible(conv.From) {
+			err.AddWithMessage("conversions", "UNREACHABLE", "conversion %d: %s", i, conv.From.Unit)
+		}
+	}
+
+	if c.LeadTime < 0 {
+		err.Add("leadTime", "INVALID")
+	}
+
+	if !c.LotSize.IsEmpty() && (c.LotSize.Quantity == 0 || !c.Compatible(c.LotSize)) {
+		err.Add("lotSize", "INVALID")
+	}
+
 	for i, d := range c.Dependencies {
 		if !d.Quantity.IsValid() {
 			err.AddWithMessage("dependency", "INVALID_QUANTITY", "dependency %d", i)
@@ -150,7 +370,7 @@ func (c *Composition) ValidateSchema() error {
 
 func (c *Composition) calculateCostFromDependencies() {
 	if c.AutoupdateCost && len(c.Dependencies) > 0 {
-		var cost float64
+		cost := c.RoutingCost
 		for _, d := range c.Dependencies {
 			cost += d.Subvalue
 		}
@@ -158,6 +378,14 @@ func (c *Composition) calculateCostFromDependencies() {
 	}
 }
 
+func (c *Composition) calculateAttributesFromDependencies() {
+	attrs := Attributes{}
+	for _, d := range c.Dependencies {
+		attrs = attrs.Add(d.Attributes)
+	}
+	c.ComputedAttributes = attrs.Override(c.Attributes)
+}
+
 func isDependencyInArray(d Dependency, dependencies []Dependency) bool {
 	for _, dep := range dependencies {
 		if d.Equals(dep) {
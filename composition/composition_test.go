@@ -22,6 +22,10 @@ func TestCalculateCostFromSubvalue(t *testing.T) {
 	)
 	c.calculateCostFromDependencies()
 	assert.Equal(t, c.Cost, 350.0, "Cost should be 350")
+
+	c.RoutingCost = 12.5
+	c.calculateCostFromDependencies()
+	assert.Equal(t, c.Cost, 362.5, "Cost should include routing cost")
 }
 
 func TestCalculateCostByQuantity(t *testing.T) {
@@ -29,13 +33,22 @@ func TestCalculateCostByQuantity(t *testing.T) {
 	comp.Cost = 50
 	comp.Unit = quantity.Quantity{2, "kg"}
 
-	assert.Equal(t, comp.CostFromQuantity(quantity.Quantity{1000, "g"}), 25.0, "Cost should be 25")
-	assert.Equal(t, comp.CostFromQuantity(quantity.Quantity{500, "g"}), 12.5, "Cost should be 12.5")
-	assert.Equal(t, comp.CostFromQuantity(quantity.Quantity{3, "kg"}), 3.0*50/2, "Cost should be 75")
+	cost, err := comp.CostFromQuantity(quantity.Quantity{1000, "g"})
+	assert.Ok(t, err)
+	assert.Equal(t, cost, 25.0, "Cost should be 25")
+	cost, _ = comp.CostFromQuantity(quantity.Quantity{500, "g"})
+	assert.Equal(t, cost, 12.5, "Cost should be 12.5")
+	cost, _ = comp.CostFromQuantity(quantity.Quantity{3, "kg"})
+	assert.Equal(t, cost, 3.0*50/2, "Cost should be 75")
+
+	_, err = comp.CostFromQuantity(quantity.Quantity{1, "l"})
+	assert.ErrCode(t, err, "INCOMPATIBLE_QUANTITY")
 
 	comp.Unit = quantity.Quantity{0, "kg"}
 
-	assert.Equal(t, comp.CostFromQuantity(quantity.Quantity{1, "kg"}), 0.0, "Division by zero")
+	cost, err = comp.CostFromQuantity(quantity.Quantity{1, "kg"})
+	assert.Ok(t, err)
+	assert.Equal(t, cost, 0.0, "Division by zero")
 }
 
 func TestAddAndRemoveCompositionDependencies(t *testing.T) {
@@ -244,3 +257,59 @@ func TestValidateSchema(t *testing.T) {
 		assert.Ok(t, comp.ValidateSchema(), "Should be created")
 	})
 }
+
+func TestReorderPoint(t *testing.T) {
+	comp := newComposition()
+	comp.Unit = quantity.Quantity{1, "kg"}
+	comp.Stock = quantity.Quantity{800, "g"}
+
+	t.Run("Without reorder point nor minimum stock", func(t *testing.T) {
+		assert.Assert(t, !comp.BelowReorderPoint(), "Should not be below reorder point")
+		assert.Assert(t, !comp.BelowMinimumStock(), "Should not be below minimum stock")
+	})
+
+	t.Run("Minimum stock used as reorder point", func(t *testing.T) {
+		comp.MinimumStock = quantity.Quantity{1, "kg"}
+		assert.Assert(t, comp.BelowMinimumStock())
+		assert.Assert(t, comp.BelowReorderPoint())
+	})
+
+	t.Run("Reorder point in another unit", func(t *testing.T) {
+		comp.MinimumStock = quantity.Quantity{500, "g"}
+		comp.ReorderPoint = quantity.Quantity{0.8, "kg"}
+		assert.Assert(t, !comp.BelowMinimumStock())
+		assert.Assert(t, comp.BelowReorderPoint(), "Stock equal to reorder point")
+
+		comp.Stock = quantity.Quantity{801, "g"}
+		assert.Assert(t, !comp.BelowReorderPoint())
+	})
+
+	t.Run("Invalid reorder quantities", func(t *testing.T) {
+		comp.MinimumStock = quantity.Quantity{1, "l"}
+		comp.ReorderPoint = quantity.Quantity{-1, "kg"}
+		comp.ReorderQuantity = quantity.Quantity{1, "asd"}
+		comp.LeadTime = -1
+		comp.LotSize = quantity.Quantity{0, "kg"}
+
+		err := comp.ValidateSchema()
+		assert.ErrValidation(t, err, "minimumStock", "INVALID")
+		assert.ErrValidation(t, err, "reorderPoint", "INVALID")
+		assert.ErrValidation(t, err, "reorderQuantity", "INVALID")
+		assert.ErrValidation(t, err, "leadTime", "INVALID")
+		assert.ErrValidation(t, err, "lotSize", "INVALID")
+	})
+
+	t.Run("Reorder quantities converted by the composition", func(t *testing.T) {
+		comp.Stock = quantity.Quantity{800, "g"}
+		comp.Density = 0.5
+		comp.MinimumStock = quantity.Quantity{1, "l"}
+		comp.ReorderPoint = quantity.Quantity{2, "l"}
+		comp.ReorderQuantity = quantity.Quantity{4, "l"}
+		comp.LeadTime = 0
+		comp.LotSize = quantity.Quantity{1, "l"}
+
+		assert.Ok(t, comp.ValidateSchema())
+		assert.Assert(t, !comp.BelowMinimumStock())
+		assert.Assert(t, comp.BelowReorderPoint())
+	})
+}
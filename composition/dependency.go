@@ -1,6 +1,8 @@
 package composition
 
 import (
+	"math"
+
 	"github.com/aboglioli/big-brother/pkg/quantity"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
@@ -9,8 +11,29 @@ type Dependency struct {
 	On       primitive.ObjectID `bson:"on" json:"on" binding:"required"`
 	Quantity quantity.Quantity  `bson:"quantity" json:"quantity" binding:"required"`
 	Subvalue float64            `bson:"subvalue" json:"subvalue"`
+
+	// Attributes are the computed attributes of the dependency quantity.
+	Attributes Attributes `bson:"attributes" json:"attributes"`
 }
 
 func (d1 Dependency) Equals(d2 Dependency) bool {
 	return d1.On.Hex() == d2.On.Hex() && d1.Quantity.Equals(d2.Quantity)
 }
+
+// value sets the subvalue and the attributes of the dependency from the
+// composition it depends on.
+func (d *Dependency) value(on *Composition) error {
+	subvalue, err := on.CostFromQuantity(d.Quantity)
+	if err != nil {
+		return err
+	}
+
+	attributes, err := on.AttributesFromQuantity(d.Quantity)
+	if err != nil {
+		return err
+	}
+
+	d.Subvalue = math.Round(subvalue*1000) / 1000
+	d.Attributes = attributes
+	return nil
+}
@@ -19,32 +19,90 @@ type CompositionsUpdatedAutomaticallyEvent struct {
 	Compositions []*Composition `json:"compositions"`
 }
 
+// StockBelowReorderPointEvent is published when the stock of a composition
+// reaches its reorder point
+type StockBelowReorderPointEvent struct {
+	events.Event
+	Composition       *Composition `json:"composition"`
+	BelowMinimumStock bool         `json:"belowMinimumStock"`
+}
+
 func NewCompositionCreatedEvent(c *Composition) (*CompositionChangedEvent, *events.Options) {
-	event := &CompositionChangedEvent{events.Event{"CompositionCreated"}, c}
+	event := &CompositionChangedEvent{events.NewEvent("CompositionCreated", c.ID.Hex()), c}
 	opts := &events.Options{"composition", "topic", "composition.created", ""}
 	return event, opts
 }
 
 func NewCompositionUpdatedManuallyEvent(c *Composition) (*CompositionChangedEvent, *events.Options) {
-	event := &CompositionChangedEvent{events.Event{"CompositionUpdatedManually"}, c}
+	event := &CompositionChangedEvent{events.NewEvent("CompositionUpdatedManually", c.ID.Hex()), c}
 	opts := &events.Options{"composition", "topic", "composition.updated", ""}
 	return event, opts
 }
 
 func NewCompositionDeletedEvent(c *Composition) (*CompositionChangedEvent, *events.Options) {
-	event := &CompositionChangedEvent{events.Event{"CompositionDeleted"}, c}
+	event := &CompositionChangedEvent{events.NewEvent("CompositionDeleted", c.ID.Hex()), c}
 	opts := &events.Options{"composition", "topic", "composition.deleted", ""}
 	return event, opts
 }
 
-func NewCompositionsUpdatedAutomaticallyEvent(comps []*Composition) (*CompositionsUpdatedAutomaticallyEvent, *events.Options) {
-	event := &CompositionsUpdatedAutomaticallyEvent{events.Event{"CompositionsUpdatedAutomatically"}, comps}
+// NewCompositionsUpdatedAutomaticallyEvent returns the event of the uses
+// updated after a change of c, caused by the cause event.
+func NewCompositionsUpdatedAutomaticallyEvent(c *Composition, comps []*Composition, cause events.Event) (*CompositionsUpdatedAutomaticallyEvent, *events.Options) {
+	event := &CompositionsUpdatedAutomaticallyEvent{events.NewEvent("CompositionsUpdatedAutomatically", c.ID.Hex()), comps}
+	event.CausedBy(cause)
 	opts := &events.Options{"composition", "topic", "composition.updated", ""}
 	return event, opts
 }
 
-func NewCompositionUsesUpdatedSinceLastChangeEvent(c *Composition) (*CompositionChangedEvent, *events.Options) {
-	event := &CompositionChangedEvent{events.Event{"CompositionUsesUpdatedSinceLastChange"}, c}
+func NewCompositionUsesUpdatedSinceLastChangeEvent(c *Composition, cause events.Event) (*CompositionChangedEvent, *events.Options) {
+	event := &CompositionChangedEvent{events.NewEvent("CompositionUsesUpdatedSinceLastChange", c.ID.Hex()), c}
+	event.CausedBy(cause)
 	opts := &events.Options{"composition", "topic", "composition.updated", ""}
 	return event, opts
 }
+
+func NewStockBelowReorderPointEvent(c *Composition) (*StockBelowReorderPointEvent, *events.Options) {
+	event := &StockBelowReorderPointEvent{events.NewEvent("StockBelowReorderPoint", c.ID.Hex()), c, c.BelowMinimumStock()}
+	opts := &events.Options{"composition", "topic", "composition.stock", ""}
+	return event, opts
+}
+
+// newEntries returns the outbox entries of an event of c followed, if
+// checkReorderPoint is set, by its stock entries.
+func newEntries(c *Composition, event *CompositionChangedEvent, opts *events.Options, checkReorderPoint bool) ([]*events.Entry, error) {
+	entry, err := events.NewEntry(c.ID.Hex(), event, opts)
+	if err != nil {
+		return nil, err
+	}
+	entries := []*events.Entry{entry}
+
+	if checkReorderPoint {
+		stockEntries, err := newStockEntries(c, &event.Event)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, stockEntries...)
+	}
+
+	return entries, nil
+}
+
+// newStockEntries returns a StockBelowReorderPoint entry, caused by cause if
+// it's not nil, when the stock of c reached its reorder point.
+func newStockEntries(c *Composition, cause *events.Event) ([]*events.Entry, error) {
+	if !c.BelowReorderPoint() {
+		return nil, nil
+	}
+
+	event, opts := NewStockBelowReorderPointEvent(c)
+	if cause != nil {
+		event.CausedBy(*cause)
+	}
+
+	entry, err := events.NewEntry(c.ID.Hex(), event, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return []*events.Entry{entry}, nil
+}
@@ -0,0 +1,203 @@
+package composition
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aboglioli/big-brother/pkg/errors"
+	"github.com/aboglioli/big-brother/pkg/quantity"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	DirectionDown = "down"
+	DirectionUp   = "up"
+	DirectionBoth = "both"
+
+	FormatJSON    = "json"
+	FormatDOT     = "dot"
+	FormatMermaid = "mermaid"
+)
+
+type Node struct {
+	ID   primitive.ObjectID `json:"id"`
+	Name string             `json:"name"`
+	Cost float64            `json:"cost"`
+	Unit quantity.Quantity  `json:"unit"`
+}
+
+// Edge goes from a composition to one of its dependencies.
+type Edge struct {
+	From     primitive.ObjectID `json:"from"`
+	To       primitive.ObjectID `json:"to"`
+	Quantity quantity.Quantity  `json:"quantity"`
+	Subvalue float64            `json:"subvalue"`
+}
+
+// Graph is the graph of compositions and their dependencies.
+type Graph struct {
+	Nodes []Node `json:"nodes"`
+	Edges []Edge `json:"edges"`
+}
+
+// NewGraph returns the graph of the enabled compositions. Nodes are sorted by
+// name and edges follow the order of nodes and dependencies.
+func NewGraph(comps []*Composition) *Graph {
+	enabled := make([]*Composition, 0, len(comps))
+	ids := make(map[string]bool)
+	for _, c := range comps {
+		if c.Enabled {
+			enabled = append(enabled, c)
+			ids[c.ID.Hex()] = true
+		}
+	}
+
+	sort.SliceStable(enabled, func(i, j int) bool {
+		if enabled[i].Name != enabled[j].Name {
+			return enabled[i].Name < enabled[j].Name
+		}
+		return enabled[i].ID.Hex() < enabled[j].ID.Hex()
+	})
+
+	g := &Graph{
+		Nodes: make([]Node, 0, len(enabled)),
+		Edges: make([]Edge, 0),
+	}
+	for _, c := range enabled {
+		g.Nodes = append(g.Nodes, Node{c.ID, c.Name, c.Cost, c.Unit})
+		for _, d := range c.Dependencies {
+			if ids[d.On.Hex()] {
+				g.Edges = append(g.Edges, Edge{c.ID, d.On, d.Quantity, d.Subvalue})
+			}
+		}
+	}
+
+	return g
+}
+
+// Subgraph returns the part of the graph reachable from a composition through
+// its dependencies (down), its uses (up) or both.
+func (g *Graph) Subgraph(id string, direction string) (*Graph, error) {
+	path := "composition/graph.Subgraph"
+
+	if direction != DirectionDown && direction != DirectionUp && direction != DirectionBoth {
+		return nil, errors.NewStatus("INVALID_DIRECTION").SetPath(path).SetStatus(400).SetMessage("%s", direction)
+	}
+
+	found := false
+	for _, n := range g.Nodes {
+		if n.ID.Hex() == id {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil, errors.NewStatus("COMPOSITION_NOT_FOUND").SetPath(path).SetStatus(404).SetMessage("%s", id)
+	}
+
+	nodes := map[string]bool{id: true}
+	down, up := make(map[int]bool), make(map[int]bool)
+	if direction != DirectionUp {
+		g.walk(id, nodes, down, false)
+	}
+	if direction != DirectionDown {
+		g.walk(id, nodes, up, true)
+	}
+
+	sub := &Graph{
+		Nodes: make([]Node, 0, len(nodes)),
+		Edges: make([]Edge, 0),
+	}
+	for _, n := range g.Nodes {
+		if nodes[n.ID.Hex()] {
+			sub.Nodes = append(sub.Nodes, n)
+		}
+	}
+	for i, e := range g.Edges {
+		if down[i] || up[i] {
+			sub.Edges = append(sub.Edges, e)
+		}
+	}
+
+	return sub, nil
+}
+
+// walk marks nodes and edges reachable from id, following edges backwards
+// (to uses) if up is true.
+func (g *Graph) walk(id string, nodes map[string]bool, edges map[int]bool, up bool) {
+	for i, e := range g.Edges {
+		from, to := e.From.Hex(), e.To.Hex()
+		if up {
+			from, to = to, from
+		}
+		if from != id || edges[i] {
+			continue
+		}
+
+		edges[i] = true
+		nodes[to] = true
+		g.walk(to, nodes, edges, up)
+	}
+}
+
+// Export returns the graph in JSON, Graphviz DOT or Mermaid format.
+func (g *Graph) Export(format string) ([]byte, error) {
+	switch format {
+	case FormatJSON:
+		return json.MarshalIndent(g, "", "  ")
+	case FormatDOT:
+		return []byte(g.DOT()), nil
+	case FormatMermaid:
+		return []byte(g.Mermaid()), nil
+	}
+
+	return nil, errors.NewStatus("INVALID_FORMAT").SetPath("composition/graph.Export").SetStatus(400).SetMessage("%s", format)
+}
+
+// DOT returns the graph in Graphviz DOT format. Nodes are labeled with name
+// and cost by unit, and edges with quantity and subvalue.
+func (g *Graph) DOT() string {
+	var b strings.Builder
+
+	b.WriteString("digraph compositions {\n")
+	b.WriteString("  node [shape=box];\n")
+	for _, n := range g.Nodes {
+		fmt.Fprintf(&b, "  %q [label=%s];\n", n.ID.Hex(), strconv.Quote(n.label("\n")))
+	}
+	for _, e := range g.Edges {
+		fmt.Fprintf(&b, "  %q -> %q [label=%s];\n", e.From.Hex(), e.To.Hex(), strconv.Quote(e.label("\n")))
+	}
+	b.WriteString("}\n")
+
+	return b.String()
+}
+
+// Mermaid returns the graph as a Mermaid flowchart.
+func (g *Graph) Mermaid() string {
+	var b strings.Builder
+
+	b.WriteString("graph TD\n")
+	for _, n := range g.Nodes {
+		fmt.Fprintf(&b, "  n%s[\"%s\"]\n", n.ID.Hex(), mermaidEscape(n.label("<br/>")))
+	}
+	for _, e := range g.Edges {
+		fmt.Fprintf(&b, "  n%s -->|\"%s\"| n%s\n", e.From.Hex(), mermaidEscape(e.label("<br/>")), e.To.Hex())
+	}
+
+	return b.String()
+}
+
+func (n Node) label(sep string) string {
+	return fmt.Sprintf("%s%s%g / %g %s", n.Name, sep, n.Cost, n.Unit.Quantity, n.Unit.Unit)
+}
+
+func (e Edge) label(sep string) string {
+	return fmt.Sprintf("%g %s%s%g", e.Quantity.Quantity, e.Quantity.Unit, sep, e.Subvalue)
+}
+
+func mermaidEscape(s string) string {
+	return strings.Replace(s, "\"", "#quot;", -1)
+}
@@ -0,0 +1,93 @@
+package composition
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/aboglioli/big-brother/pkg/quantity"
+	"github.com/aboglioli/big-brother/pkg/tests/assert"
+)
+
+func TestGraph(t *testing.T) {
+	comps := make([]*Composition, 0)
+	add := func(name string, cost float64, deps ...Dependency) *Composition {
+		c := newComposition()
+		c.Name = name
+		c.Cost = cost
+		c.Unit = quantity.Quantity{1, "kg"}
+		c.Dependencies = deps
+		comps = append(comps, c)
+		return c
+	}
+
+	flour := add("Flour", 10)
+	butter := add("Butter", 20)
+	sugar := add("Sugar", 2)
+	dough := add("Dough", 11,
+		Dependency{On: flour.ID, Quantity: quantity.Quantity{600, "g"}, Subvalue: 6},
+		Dependency{On: butter.ID, Quantity: quantity.Quantity{250, "g"}, Subvalue: 5},
+	)
+	bread := add("Bread", 6.6,
+		Dependency{On: dough.ID, Quantity: quantity.Quantity{500, "g"}, Subvalue: 5.5},
+		Dependency{On: sugar.ID, Quantity: quantity.Quantity{50, "g"}, Subvalue: 0.1},
+		Dependency{On: flour.ID, Quantity: quantity.Quantity{100, "g"}, Subvalue: 1},
+	)
+	cake := add("Cake", 0.4, Dependency{On: sugar.ID, Quantity: quantity.Quantity{200, "g"}, Subvalue: 0.4})
+	disabled := add("Disabled", 0, Dependency{On: flour.ID, Quantity: quantity.Quantity{1, "kg"}, Subvalue: 10})
+	disabled.Enabled = false
+
+	g := NewGraph(comps)
+	assert.Equal(t, len(g.Nodes), 6)
+	assert.Equal(t, g.Nodes[0].ID, bread.ID)
+	assert.Equal(t, g.Nodes[5].ID, sugar.ID)
+	assert.Equal(t, len(g.Edges), 6)
+	assert.Equal(t, g.Edges[0].From, bread.ID)
+	assert.Equal(t, g.Edges[0].To, dough.ID)
+
+	t.Run("Subgraphs", func(t *testing.T) {
+		sub, err := g.Subgraph(dough.ID.Hex(), DirectionDown)
+		assert.Ok(t, err)
+		assert.Equal(t, len(sub.Nodes), 3)
+		assert.Equal(t, len(sub.Edges), 2)
+
+		sub, err = g.Subgraph(sugar.ID.Hex(), DirectionUp)
+		assert.Ok(t, err)
+		assert.Equal(t, len(sub.Nodes), 3)
+		assert.Equal(t, sub.Nodes[1].ID, cake.ID)
+		assert.Equal(t, len(sub.Edges), 2)
+
+		sub, err = g.Subgraph(dough.ID.Hex(), DirectionBoth)
+		assert.Ok(t, err)
+		assert.Equal(t, len(sub.Nodes), 4)
+		assert.Equal(t, len(sub.Edges), 3)
+
+		_, err = g.Subgraph(dough.ID.Hex(), "sideways")
+		assert.ErrCode(t, err, "INVALID_DIRECTION")
+
+		_, err = g.Subgraph(disabled.ID.Hex(), DirectionDown)
+		assert.ErrCode(t, err, "COMPOSITION_NOT_FOUND")
+	})
+
+	t.Run("Formats", func(t *testing.T) {
+		sub, _ := g.Subgraph(cake.ID.Hex(), DirectionDown)
+
+		dot, err := sub.Export(FormatDOT)
+		assert.Ok(t, err)
+		assert.Assert(t, strings.HasPrefix(string(dot), "digraph compositions {\n"))
+		assert.Assert(t, strings.Contains(string(dot), fmt.Sprintf(`"%s" [label="Cake\n0.4 / 1 kg"];`, cake.ID.Hex())))
+		assert.Assert(t, strings.Contains(string(dot), fmt.Sprintf(`"%s" -> "%s" [label="200 g\n0.4"];`, cake.ID.Hex(), sugar.ID.Hex())))
+
+		mermaid, err := sub.Export(FormatMermaid)
+		assert.Ok(t, err)
+		assert.Assert(t, strings.HasPrefix(string(mermaid), "graph TD\n"))
+		assert.Assert(t, strings.Contains(string(mermaid), fmt.Sprintf(`n%s -->|"200 g<br/>0.4"| n%s`, cake.ID.Hex(), sugar.ID.Hex())))
+
+		js, err := sub.Export(FormatJSON)
+		assert.Ok(t, err)
+		assert.Assert(t, strings.Contains(string(js), `"subvalue": 0.4`))
+
+		_, err = sub.Export("svg")
+		assert.ErrCode(t, err, "INVALID_FORMAT")
+	})
+}
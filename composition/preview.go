@@ -0,0 +1,136 @@
+package composition
+
+import (
+	"encoding/json"
+	"math"
+	"reflect"
+	"sort"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Updates changing the cost of a composition by at least confirmCostChange
+// (relative) and the cost of at least confirmUses compositions using it
+// require confirmation.
+const (
+	confirmCostChange = 0.1
+	confirmUses       = 3
+)
+
+// Change is a field changed by an update, with values as in JSON.
+type Change struct {
+	Field string      `json:"field"`
+	Old   interface{} `json:"old"`
+	New   interface{} `json:"new"`
+}
+
+// CostImpact is the cost change of a composition using an updated one.
+type CostImpact struct {
+	Composition primitive.ObjectID `json:"composition"`
+	Name        string             `json:"name"`
+	OldCost     float64            `json:"oldCost"`
+	NewCost     float64            `json:"newCost"`
+	Difference  float64            `json:"difference"`
+	Percent     float64            `json:"percent"`
+}
+
+// Preview is the result of an update not yet saved. Impacts are sorted by
+// absolute difference.
+type Preview struct {
+	Composition          *Composition `json:"composition"`
+	Changes              []Change     `json:"changes"`
+	Impacts              []CostImpact `json:"impacts"`
+	RequiresConfirmation bool         `json:"requiresConfirmation"`
+}
+
+func newPreview(old, c *Composition, uses []*Composition, saved map[string]*Composition) *Preview {
+	p := &Preview{
+		Composition: c,
+		Changes:     diff(old, c),
+		Impacts:     make([]CostImpact, 0),
+	}
+
+	for _, u := range uses {
+		s, ok := saved[u.ID.Hex()]
+		if !ok || s.Cost == u.Cost {
+			continue
+		}
+
+		p.Impacts = append(p.Impacts, CostImpact{
+			Composition: u.ID,
+			Name:        u.Name,
+			OldCost:     s.Cost,
+			NewCost:     u.Cost,
+			Difference:  round(u.Cost - s.Cost),
+			Percent:     round(relativeChange(s.Cost, u.Cost) * 100),
+		})
+	}
+
+	sort.SliceStable(p.Impacts, func(i, j int) bool {
+		return math.Abs(p.Impacts[i].Difference) > math.Abs(p.Impacts[j].Difference)
+	})
+
+	p.RequiresConfirmation = math.Abs(relativeChange(old.Cost, c.Cost)) >= confirmCostChange && len(p.Impacts) >= confirmUses
+
+	return p
+}
+
+// diff returns the fields changed from old to c, sorted by name. Timestamps
+// and flags set by the service are ignored.
+func diff(old, c *Composition) []Change {
+	before, after := toMap(old), toMap(c)
+	for _, f := range []string{"createdAt", "updatedAt", "usesUpdatedSinceLastChange"} {
+		delete(before, f)
+		delete(after, f)
+	}
+
+	changes := make([]Change, 0)
+	for f, v := range after {
+		if !equal(before[f], v) {
+			changes = append(changes, Change{f, before[f], v})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Field < changes[j].Field
+	})
+
+	return changes
+}
+
+// equal compares JSON values. Null and empty arrays or objects are equal.
+func equal(a, b interface{}) bool {
+	return reflect.DeepEqual(a, b) || isEmpty(a) && isEmpty(b)
+}
+
+func isEmpty(v interface{}) bool {
+	switch v := v.(type) {
+	case nil:
+		return true
+	case []interface{}:
+		return len(v) == 0
+	case map[string]interface{}:
+		return len(v) == 0
+	}
+	return false
+}
+
+func toMap(c *Composition) map[string]interface{} {
+	m := make(map[string]interface{})
+	b, err := json.Marshal(c)
+	if err != nil {
+		return m
+	}
+	json.Unmarshal(b, &m)
+	return m
+}
+
+func relativeChange(old, new float64) float64 {
+	if old == new {
+		return 0
+	}
+	if old == 0 {
+		return math.Inf(1)
+	}
+	return (new - old) / old
+}
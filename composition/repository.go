@@ -6,6 +6,7 @@ import (
 
 	"github.com/aboglioli/big-brother/infrastructure/db"
 	"github.com/aboglioli/big-brother/pkg/errors"
+	"github.com/aboglioli/big-brother/pkg/events"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -17,17 +18,24 @@ type Repository interface {
 	FindUses(id string) ([]*Composition, error)
 	FindByUsesUpdatedSinceLastChange(usesUpdated bool) ([]*Composition, error)
 
-	Insert(*Composition) error
+	// Insert, Update and Delete store the outbox entries of the change in the
+	// same transaction. Update and UpdateMany fail with VERSION_CONFLICT if a
+	// composition changed since it was read.
+	Insert(c *Composition, entries ...*events.Entry) error
 	InsertMany([]*Composition) error
-	Update(*Composition) error
-	Delete(id string) error
+	Update(c *Composition, entries ...*events.Entry) error
+	UpdateMany(comps []*Composition, entries ...*events.Entry) error
+	Delete(id string, entries ...*events.Entry) error
 }
 
 type repository struct {
 	collection *mongo.Collection
+	outbox     events.Outbox
 }
 
-func NewRepository() (Repository, error) {
+// NewRepository returns the repository of the "Composition" database. The
+// outbox must be of the same database.
+func NewRepository(outbox events.Outbox) (Repository, error) {
 	db, err := db.Get("Composition")
 
 	if err != nil {
@@ -36,6 +44,7 @@ func NewRepository() (Repository, error) {
 
 	return &repository{
 		collection: db.Collection("composition"),
+		outbox:     outbox,
 	}, nil
 }
 
@@ -102,7 +111,7 @@ func (r *repository) FindUses(id string) ([]*Composition, error) {
 		return nil, errors.NewInternal("OBJECTID_FROM_HEX").SetPath(path).SetRef(err)
 	}
 	filter := bson.M{
-		"dependencies.of": objID,
+		"dependencies.on": objID,
 	}
 
 	cur, err := r.collection.Find(ctx, filter)
@@ -160,15 +169,15 @@ func (r *repository) FindByUsesUpdatedSinceLastChange(usesUpdated bool) ([]*Comp
 	return comps, nil
 }
 
-func (r *repository) Insert(c *Composition) error {
-	ctx := context.Background()
-
-	_, err := r.collection.InsertOne(ctx, c)
-	if err != nil {
-		return errors.NewInternal("INSERT_ONE").SetPath("composition/repository.Insert").SetRef(err)
-	}
+func (r *repository) Insert(c *Composition, entries ...*events.Entry) error {
+	return r.outbox.Write(func(ctx context.Context) error {
+		_, err := r.collection.InsertOne(ctx, c)
+		if err != nil {
+			return errors.NewInternal("INSERT_ONE").SetPath("composition/repository.Insert").SetRef(err)
+		}
 
-	return nil
+		return nil
+	}, entries...)
 }
 
 func (r *repository) InsertMany(comps []*Composition) error {
@@ -189,36 +198,66 @@ func (r *repository) InsertMany(comps []*Composition) error {
 	return nil
 }
 
-func (r *repository) Update(c *Composition) error {
-	path := "composition/repository.Update"
-	ctx := context.Background()
-
-	if c.ID.IsZero() {
-		return errors.NewInternal("INVALID_OBJECTID").SetPath(path)
-	}
+func (r *repository) Update(c *Composition, entries ...*events.Entry) error {
+	return r.UpdateMany([]*Composition{c}, entries...)
+}
 
-	c.UpdatedAt = time.Now()
+// UpdateMany updates all the compositions and stores the entries in the same
+// transaction. Nothing is updated if any of them changed since it was read.
+func (r *repository) UpdateMany(comps []*Composition, entries ...*events.Entry) error {
+	path := "composition/repository.UpdateMany"
 
-	filter := bson.M{
-		"_id": c.ID,
+	for _, c := range comps {
+		if c.ID.IsZero() {
+			return errors.NewInternal("INVALID_OBJECTID").SetPath(path)
+		}
+		c.UpdatedAt = time.Now()
 	}
 
-	update := bson.D{
-		{"$set", c},
-	}
+	err := r.outbox.Write(func(ctx context.Context) error {
+		for _, c := range comps {
+			// Compositions saved before versioning have no version
+			var version interface{} = c.Version
+			if c.Version == 0 {
+				version = bson.M{"$in": bson.A{0, nil}}
+			}
+
+			filter := bson.M{
+				"_id":     c.ID,
+				"version": version,
+			}
+
+			saved := *c
+			saved.Version++
+			update := bson.D{
+				{"$set", &saved},
+			}
+
+			res, err := r.collection.UpdateOne(ctx, filter, update)
+			if err != nil {
+				return errors.NewInternal("UPDATE_ONE").SetPath(path).SetRef(err)
+			}
+			if res.MatchedCount == 0 {
+				return errors.NewInternal("VERSION_CONFLICT").SetPath(path).SetMessage("%s changed since it was read", c.ID.Hex())
+			}
+		}
 
-	_, err := r.collection.UpdateOne(ctx, filter, update)
+		return nil
+	}, entries...)
 	if err != nil {
-		return errors.NewInternal("UPDATE_ONE").SetPath(path).SetRef(err)
+		return err
+	}
+
+	for _, c := range comps {
+		c.Version++
 	}
 
 	return nil
 }
 
-func (r *repository) Delete(id string) error {
+func (r *repository) Delete(id string, entries ...*events.Entry) error {
 	path := "composition/repository.Delete"
 
-	ctx := context.Background()
 	objID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
 		return errors.NewInternal("OBJECTID_FROM_HEX").SetPath(path).SetRef(err)
@@ -235,10 +274,12 @@ func (r *repository) Delete(id string) error {
 		}},
 	}
 
-	_, err = r.collection.UpdateOne(ctx, filter, update)
-	if err != nil {
-		return errors.NewInternal("UPDATE_ONE").SetPath(path).SetRef(err)
-	}
+	return r.outbox.Write(func(ctx context.Context) error {
+		_, err := r.collection.UpdateOne(ctx, filter, update)
+		if err != nil {
+			return errors.NewInternal("UPDATE_ONE").SetPath(path).SetRef(err)
+		}
 
-	return nil
+		return nil
+	}, entries...)
 }
@@ -4,25 +4,43 @@ import (
 	"time"
 
 	"github.com/aboglioli/big-brother/pkg/errors"
+	"github.com/aboglioli/big-brother/pkg/events"
 	"github.com/aboglioli/big-brother/pkg/tests/mock"
 )
 
-type mockRepository struct {
+// MockRepository is an in-memory Repository recording its calls and the
+// outbox entries stored with the changes.
+type MockRepository struct {
 	mock.Mock
 	compositions []*Composition
+	entries      []*events.Entry
 }
 
-func newMockRepository() *mockRepository {
-	return &mockRepository{}
+// NewMockRepository returns an empty MockRepository. It is exported to be
+// used by other packages' tests.
+func NewMockRepository() *MockRepository {
+	return &MockRepository{}
 }
 
 // Helpers
-func (r *mockRepository) Clean() {
+func (r *MockRepository) Clean() {
 	r.compositions = make([]*Composition, 0)
+	r.entries = make([]*events.Entry, 0)
+}
+
+// Reset clears the recorded calls and outbox entries.
+func (r *MockRepository) Reset() {
+	r.Mock.Reset()
+	r.entries = make([]*events.Entry, 0)
+}
+
+// Entries returns the outbox entries stored with the changes.
+func (r *MockRepository) Entries() []*events.Entry {
+	return r.entries
 }
 
 // Implementation
-func (r *mockRepository) FindAll() ([]*Composition, error) {
+func (r *MockRepository) FindAll() ([]*Composition, error) {
 	r.Called("FindAll")
 
 	comps := make([]*Composition, 0)
@@ -35,7 +53,7 @@ func (r *mockRepository) FindAll() ([]*Composition, error) {
 	return comps, nil
 }
 
-func (r *mockRepository) FindByID(id string) (*Composition, error) {
+func (r *MockRepository) FindByID(id string) (*Composition, error) {
 	r.Called("FindByID", id)
 
 	for _, c := range r.compositions {
@@ -47,7 +65,7 @@ func (r *mockRepository) FindByID(id string) (*Composition, error) {
 	return nil, errors.NewInternal("NOT_FOUND").SetPath("composition/repository_mock.FindById")
 }
 
-func (r *mockRepository) FindUses(id string) ([]*Composition, error) {
+func (r *MockRepository) FindUses(id string) ([]*Composition, error) {
 	r.Called("FindUses", id)
 
 	comps := make([]*Composition, 0)
@@ -63,7 +81,7 @@ func (r *mockRepository) FindUses(id string) ([]*Composition, error) {
 	return comps, nil
 }
 
-func (r *mockRepository) FindByUsesUpdatedSinceLastChange(usesUpdated bool) ([]*Composition, error) {
+func (r *MockRepository) FindByUsesUpdatedSinceLastChange(usesUpdated bool) ([]*Composition, error) {
 	r.Called("FindByUsesUpdatedSinceLastChange", usesUpdated)
 
 	comps := make([]*Composition, 0)
@@ -76,8 +94,9 @@ func (r *mockRepository) FindByUsesUpdatedSinceLastChange(usesUpdated bool) ([]*
 	return comps, nil
 }
 
-func (r *mockRepository) Insert(c *Composition) error {
+func (r *MockRepository) Insert(c *Composition, entries ...*events.Entry) error {
 	r.Called("Insert", c)
+	r.entries = append(r.entries, entries...)
 
 	c.UpdatedAt = time.Now()
 	r.compositions = append(r.compositions, copyComposition(c))
@@ -85,7 +104,7 @@ func (r *mockRepository) Insert(c *Composition) error {
 	return nil
 }
 
-func (r *mockRepository) InsertMany(comps []*Composition) error {
+func (r *MockRepository) InsertMany(comps []*Composition) error {
 	r.Called("InsertMany", comps)
 
 	newComps := make([]*Composition, len(comps))
@@ -98,22 +117,44 @@ func (r *mockRepository) InsertMany(comps []*Composition) error {
 	return nil
 }
 
-func (r *mockRepository) Update(c *Composition) error {
+func (r *MockRepository) Update(c *Composition, entries ...*events.Entry) error {
 	r.Called("Update", c)
+	return r.update([]*Composition{c}, entries)
+}
 
-	for _, comp := range r.compositions {
-		if comp.ID.Hex() == c.ID.Hex() {
-			*comp = *copyComposition(c)
-			comp.UpdatedAt = time.Now()
-			break
+func (r *MockRepository) UpdateMany(comps []*Composition, entries ...*events.Entry) error {
+	r.Called("UpdateMany", comps)
+	return r.update(comps, entries)
+}
+
+// update checks the versions of all the compositions before updating them.
+func (r *MockRepository) update(comps []*Composition, entries []*events.Entry) error {
+	for _, c := range comps {
+		for _, comp := range r.compositions {
+			if comp.ID.Hex() == c.ID.Hex() && comp.Version != c.Version {
+				return errors.NewInternal("VERSION_CONFLICT").SetPath("composition/repository_mock.update")
+			}
+		}
+	}
+
+	r.entries = append(r.entries, entries...)
+	for _, c := range comps {
+		c.Version++
+		c.UpdatedAt = time.Now()
+		for _, comp := range r.compositions {
+			if comp.ID.Hex() == c.ID.Hex() {
+				*comp = *copyComposition(c)
+				break
+			}
 		}
 	}
 
 	return nil
 }
 
-func (r *mockRepository) Delete(id string) error {
+func (r *MockRepository) Delete(id string, entries ...*events.Entry) error {
 	r.Called("Delete", id)
+	r.entries = append(r.entries, entries...)
 
 	for _, comp := range r.compositions {
 		if comp.ID.Hex() == id {
@@ -126,7 +167,7 @@ func (r *mockRepository) Delete(id string) error {
 	return errors.NewInternal("NOT_FOUND").SetPath("composition/repository_mock.Delete")
 }
 
-func (r *mockRepository) Count() (int, int) {
+func (r *MockRepository) Count() (int, int) {
 	totalCount, enabledCount := 0, 0
 	for _, c := range r.compositions {
 		totalCount++
@@ -140,5 +181,6 @@ func (r *mockRepository) Count() (int, int) {
 
 func copyComposition(c *Composition) *Composition {
 	copy := *c
+	copy.Dependencies = append([]Dependency(nil), c.Dependencies...)
 	return &copy
 }
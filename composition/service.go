@@ -2,7 +2,6 @@ package composition
 
 import (
 	"fmt"
-	"math"
 
 	"github.com/aboglioli/big-brother/pkg/errors"
 	"github.com/aboglioli/big-brother/pkg/events"
@@ -16,19 +15,30 @@ type Service interface {
 	Update(compID string, req *UpdateRequest) (*Composition, error)
 	Delete(id string) error
 
-	UpdateUses(c *Composition) ([]*Composition, error)
+	AddStock(id string, q quantity.Quantity) (*Composition, error)
+	SubtractStock(id string, q quantity.Quantity) (*Composition, error)
+	SubtractReserved(id string, q quantity.Quantity) (*Composition, error)
+	Reserve(id string, q quantity.Quantity) (*Composition, error)
+	Release(id string, q quantity.Quantity) (*Composition, error)
+	Block(id string, q quantity.Quantity) (*Composition, error)
+	FindBelowReorderPoint() ([]*Composition, error)
+	FindByUnit(unit string) ([]*Composition, error)
+	AnalyzeCost(id string) (*CostAnalysis, error)
+	GetGraph(id string, direction string) (*Graph, error)
+
+	PreviewUpdate(id string, req *UpdateRequest) (*Preview, error)
+
+	UpdateUses(c *Composition, cause events.Event) ([]*Composition, error)
 	Validate(id string) error
 }
 
 type service struct {
 	repository Repository
-	eventMgr   events.Manager
 }
 
-func NewService(r Repository, e events.Manager) Service {
+func NewService(r Repository) Service {
 	return &service{
 		repository: r,
-		eventMgr:   e,
 	}
 }
 
@@ -37,12 +47,24 @@ func (s *service) GetByID(id string) (*Composition, error) {
 }
 
 type CreateRequest struct {
-	ID           *string            `json:"id"`
-	Name         string             `json:"name"`
-	Cost         float64            `json:"cost"`
-	Unit         quantity.Quantity  `json:"unit" binding:"required"`
-	Stock        *quantity.Quantity `json:"stock"`
-	Dependencies []Dependency       `json:"dependencies"`
+	ID           *string               `json:"id"`
+	Name         string                `json:"name"`
+	Category     string                `json:"category"`
+	Cost         float64               `json:"cost"`
+	Unit         quantity.Quantity     `json:"unit" binding:"required"`
+	Stock        *quantity.Quantity    `json:"stock"`
+	Dependencies []Dependency          `json:"dependencies"`
+	Conversions  []quantity.Conversion `json:"conversions"`
+	Density      *quantity.Density     `json:"density"`
+
+	MinimumStock    *quantity.Quantity `json:"minimumStock"`
+	ReorderPoint    *quantity.Quantity `json:"reorderPoint"`
+	ReorderQuantity *quantity.Quantity `json:"reorderQuantity"`
+
+	LeadTime *int               `json:"leadTime"`
+	LotSize  *quantity.Quantity `json:"lotSize"`
+
+	Attributes *Attributes `json:"attributes"`
 
 	AutoupdateCost *bool `json:"autoupdateCost"`
 }
@@ -78,13 +100,36 @@ func (s *service) Create(req *CreateRequest) (*Composition, error) {
 	}
 
 	c.Name = req.Name
+	c.Category = req.Category
 	c.Cost = req.Cost
 	c.Unit = req.Unit
+	c.Conversions = req.Conversions
+	if req.Density != nil {
+		c.Density = *req.Density
+	}
 	if req.Stock != nil {
-		c.Stock = *req.Stock
+		c.Stock = c.toUnit(*req.Stock)
 	} else {
 		c.Stock = quantity.Quantity{0, c.Unit.Unit}
 	}
+	if req.MinimumStock != nil {
+		c.MinimumStock = c.toUnit(*req.MinimumStock)
+	}
+	if req.ReorderPoint != nil {
+		c.ReorderPoint = c.toUnit(*req.ReorderPoint)
+	}
+	if req.ReorderQuantity != nil {
+		c.ReorderQuantity = c.toUnit(*req.ReorderQuantity)
+	}
+	if req.LeadTime != nil {
+		c.LeadTime = *req.LeadTime
+	}
+	if req.LotSize != nil {
+		c.LotSize = c.toUnit(*req.LotSize)
+	}
+	if req.Attributes != nil {
+		c.Attributes = *req.Attributes
+	}
 	if req.AutoupdateCost != nil {
 		c.AutoupdateCost = *req.AutoupdateCost
 	}
@@ -95,28 +140,47 @@ func (s *service) Create(req *CreateRequest) (*Composition, error) {
 		return nil, err
 	}
 
-	if err := s.repository.Insert(c); err != nil {
-		return nil, errors.NewStatus("INSERT").SetPath(path).SetRef(err)
+	// Publish event through the outbox: composition.created
+	event, opts := NewCompositionCreatedEvent(c)
+	entries, err := newEntries(c, event, opts, true)
+	if err != nil {
+		return nil, errors.NewStatus("ENCODE_EVENT").SetPath(path).SetRef(err)
 	}
 
-	// Publish event: composition.created
-	event, opts := NewCompositionCreatedEvent(c)
-	if err := s.eventMgr.Publish(event, opts); err != nil {
-		return nil, err
+	if err := s.repository.Insert(c, entries...); err != nil {
+		return nil, errors.NewStatus("INSERT").SetPath(path).SetRef(err)
 	}
 
 	return c, nil
 }
 
 type UpdateRequest struct {
-	ID           *string            `json:"id"`
-	Name         *string            `json:"name"`
-	Cost         *float64           `json:"cost"`
-	Unit         *quantity.Quantity `json:"unit"`
-	Stock        *quantity.Quantity `json:"stock"`
-	Dependencies []Dependency       `json:"dependencies"`
+	ID           *string               `json:"id"`
+	Name         *string               `json:"name"`
+	Category     *string               `json:"category"`
+	Cost         *float64              `json:"cost"`
+	Unit         *quantity.Quantity    `json:"unit"`
+	Stock        *quantity.Quantity    `json:"stock"`
+	Dependencies []Dependency          `json:"dependencies"`
+	Conversions  []quantity.Conversion `json:"conversions"`
+	Density      *quantity.Density     `json:"density"`
+
+	MinimumStock    *quantity.Quantity `json:"minimumStock"`
+	ReorderPoint    *quantity.Quantity `json:"reorderPoint"`
+	ReorderQuantity *quantity.Quantity `json:"reorderQuantity"`
+
+	LeadTime *int               `json:"leadTime"`
+	LotSize  *quantity.Quantity `json:"lotSize"`
+
+	Attributes *Attributes `json:"attributes"`
+
+	RoutingCost *float64 `json:"routingCost"`
 
 	AutoupdateCost *bool `json:"autoupdateCost"`
+
+	// Confirm must be set to apply updates that require confirmation: large
+	// cost changes of compositions used by many others.
+	Confirm bool `json:"confirm"`
 }
 
 // Update updates an existing Composition.
@@ -137,41 +201,129 @@ type UpdateRequest struct {
 * }
  */
 func (s *service) Update(id string, req *UpdateRequest) (*Composition, error) {
+	return retry(func() (*Composition, error) {
+		return s.update(id, req)
+	})
+}
+
+func (s *service) update(id string, req *UpdateRequest) (*Composition, error) {
 	path := "composition/service.Update"
 
-	if req.ID != nil && *req.ID != id {
-		return nil, errors.NewStatus("ID_DOES_NOT_MATCH").SetPath(path).SetMessage(fmt.Sprintf("%s != %s", *req.ID, id))
+	old, c, err := s.applyUpdate(id, req)
+	if err != nil {
+		return nil, err
 	}
 
-	c, err := s.findByID(id)
+	if !req.Confirm && c.Cost != old.Cost {
+		p, err := s.preview(old, c)
+		if err != nil {
+			return nil, err
+		}
+
+		if p.RequiresConfirmation {
+			return nil, errors.NewStatus("CONFIRMATION_REQUIRED").SetPath(path).SetStatus(409).SetMessage("Cost changes from %g to %g and %d compositions are affected", old.Cost, c.Cost, len(p.Impacts))
+		}
+	}
+
+	c.UsesUpdatedSinceLastChange = false
+
+	// Publish event through the outbox: composition.updated
+	event, opts := NewCompositionUpdatedManuallyEvent(c)
+	entries, err := newEntries(c, event, opts, req.Stock != nil || req.MinimumStock != nil || req.ReorderPoint != nil)
+	if err != nil {
+		return nil, errors.NewStatus("ENCODE_EVENT").SetPath(path).SetRef(err)
+	}
+
+	if err := s.repository.Update(c, entries...); err != nil {
+		return nil, errors.NewStatus("UPDATE").SetRef(err)
+	}
+
+	return c, nil
+}
+
+// PreviewUpdate applies an update without saving it. It returns the changes
+// and the compositions whose cost would change once uses are updated.
+func (s *service) PreviewUpdate(id string, req *UpdateRequest) (*Preview, error) {
+	old, c, err := s.applyUpdate(id, req)
 	if err != nil {
 		return nil, err
 	}
 
+	return s.preview(old, c)
+}
+
+// applyUpdate returns the saved composition and a copy with the update
+// applied.
+func (s *service) applyUpdate(id string, req *UpdateRequest) (*Composition, *Composition, error) {
+	path := "composition/service.Update"
+
+	if req.ID != nil && *req.ID != id {
+		return nil, nil, errors.NewStatus("ID_DOES_NOT_MATCH").SetPath(path).SetMessage(fmt.Sprintf("%s != %s", *req.ID, id))
+	}
+
+	old, err := s.findByID(id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	c := &Composition{}
+	*c = *old
+	c.Dependencies = append([]Dependency(nil), old.Dependencies...)
+
 	savedUnit := c.Unit
 
 	if req.Name != nil {
 		c.Name = *req.Name
 	}
+	if req.Category != nil {
+		c.Category = *req.Category
+	}
 	if req.Cost != nil {
 		c.Cost = *req.Cost
 	}
 	if req.Unit != nil {
 		c.Unit = *req.Unit
 	}
+	if req.Conversions != nil {
+		c.Conversions = req.Conversions
+	}
+	if req.Density != nil {
+		c.Density = *req.Density
+	}
 	if req.Stock != nil {
-		c.Stock = *req.Stock
+		c.Stock = c.toUnit(*req.Stock)
+	}
+	if req.MinimumStock != nil {
+		c.MinimumStock = c.toUnit(*req.MinimumStock)
+	}
+	if req.ReorderPoint != nil {
+		c.ReorderPoint = c.toUnit(*req.ReorderPoint)
+	}
+	if req.ReorderQuantity != nil {
+		c.ReorderQuantity = c.toUnit(*req.ReorderQuantity)
+	}
+	if req.LeadTime != nil {
+		c.LeadTime = *req.LeadTime
+	}
+	if req.LotSize != nil {
+		c.LotSize = c.toUnit(*req.LotSize)
+	}
+	if req.RoutingCost != nil {
+		c.RoutingCost = *req.RoutingCost
+	}
+	if req.Attributes != nil {
+		c.Attributes = *req.Attributes
 	}
 	if req.AutoupdateCost != nil {
 		c.AutoupdateCost = *req.AutoupdateCost
 	}
 
 	if err := s.validateSchema(c); err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	if !savedUnit.Compatible(c.Unit) {
-		return nil, errors.NewStatus("CANNOT_CHANGE_UNIT_TYPE").SetPath(path).SetMessage(fmt.Sprintf("%v != %v", c.Unit, req.Unit))
+		return nil, nil, errors.NewStatus("CANNOT_CHANGE_UNIT_TYPE").SetPath(path).SetMessage(fmt.Sprintf("%v != %v", c.Unit, req.Unit))
 	}
 
 	removed, _, added := c.CompareDependencies(req.Dependencies)
@@ -189,37 +341,32 @@ func (s *service) Update(id string, req *UpdateRequest) (*Composition, error) {
 		for i, dep := range added {
 			depComp, err := s.repository.FindByID(dep.On.Hex())
 			if err != nil {
-				return nil, errors.NewStatus("DEPENDENCY_DOES_NOT_EXIST").SetPath(path).SetRef(err)
+				return nil, nil, errors.NewStatus("DEPENDENCY_DOES_NOT_EXIST").SetPath(path).SetRef(err)
 			}
 
 			if !dep.Quantity.IsValid() {
-				return nil, errors.NewStatus("INVALID_DEPENDENCY_QUANTITY").SetPath(path).SetMessage("Dependency nro %d: %s", i, dep.On.Hex())
+				return nil, nil, errors.NewStatus("INVALID_DEPENDENCY_QUANTITY").SetPath(path).SetMessage("Dependency nro %d: %s", i, dep.On.Hex())
 			}
 
+			converted, err := depComp.ToUnit(dep.Quantity)
+			if err != nil {
+				return nil, nil, errors.NewStatus("INCOMPATIBLE_DEPENDENCY_QUANTITY").SetPath(path).SetMessage("Dependency nro %d (%s): %v != %v", i, dep.On.Hex(), dep.Quantity, depComp.Unit).SetRef(err)
+			}
 			if !dep.Quantity.Compatible(depComp.Unit) {
-				return nil, errors.NewStatus("INCOMPATIBLE_DEPENDENCY_QUANTITY").SetPath(path).SetMessage("Dependency nro %d (%s): %v != %v", i, dep.On.Hex(), dep.Quantity, depComp.Unit)
+				dep.Quantity = converted
 			}
 
-			subvalue := depComp.CostFromQuantity(dep.Quantity)
-			dep.Subvalue = math.Round(subvalue*1000) / 1000
+			if err := dep.value(depComp); err != nil {
+				return nil, nil, errors.NewStatus("INCOMPATIBLE_DEPENDENCY_QUANTITY").SetPath(path).SetMessage("Dependency nro %d: %s", i, dep.On.Hex()).SetRef(err)
+			}
 
 			c.UpsertDependency(dep)
 		}
 	}
 
-	c.UsesUpdatedSinceLastChange = false
-
-	if err := s.repository.Update(c); err != nil {
-		return nil, errors.NewStatus("UPDATE").SetRef(err)
-	}
-
-	// Publish event: composition.updated
-	event, opts := NewCompositionUpdatedManuallyEvent(c)
-	if err := s.eventMgr.Publish(event, opts); err != nil {
-		return nil, errors.NewStatus("FAILED_TO_PUBLISH").SetRef(err)
-	}
+	c.calculateAttributesFromDependencies()
 
-	return c, nil
+	return old, c, nil
 }
 
 // Delete deletes an existing Composition.
@@ -249,20 +396,311 @@ func (s *service) Delete(id string) error {
 		return errors.NewStatus("COMPOSITION_USED_AS_DEPENDENCY").SetPath(path).SetMessage("Composition used as dependecy in %d compositions", len(uses))
 	}
 
-	if err := s.repository.Delete(id); err != nil {
-		return errors.NewStatus("DELETE").SetPath(path).SetRef(err)
+	// Publish event through the outbox
+	event, opts := NewCompositionDeletedEvent(c)
+	entries, err := newEntries(c, event, opts, false)
+	if err != nil {
+		return errors.NewStatus("ENCODE_EVENT").SetPath(path).SetRef(err)
 	}
 
-	// Publish event
-	event, opts := NewCompositionDeletedEvent(c)
-	if err := s.eventMgr.Publish(event, opts); err != nil {
-		return errors.NewStatus("PUBLISH").SetPath(path).SetRef(err)
+	if err := s.repository.Delete(id, entries...); err != nil {
+		return errors.NewStatus("DELETE").SetPath(path).SetRef(err)
 	}
 
 	return nil
 }
 
-// Update updates uses of an updated composition.
+// AddStock increases the stock of a composition. Quantity can be expressed in
+// any unit compatible with the composition unit.
+func (s *service) AddStock(id string, q quantity.Quantity) (*Composition, error) {
+	return retry(func() (*Composition, error) {
+		return s.addStock(id, q)
+	})
+}
+
+func (s *service) addStock(id string, q quantity.Quantity) (*Composition, error) {
+	path := "composition/service.AddStock"
+
+	c, err := s.findByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	converted, err := c.ToUnit(q)
+	if err != nil || q.Quantity < 0 {
+		return nil, errors.NewStatus("INVALID_QUANTITY").SetPath(path).SetMessage("%v", q).SetRef(err)
+	}
+	q = converted
+
+	stock, err := c.Stock.Add(q)
+	if err != nil {
+		return nil, errors.NewStatus("INVALID_QUANTITY").SetPath(path).SetMessage("%v", q).SetRef(err)
+	}
+
+	return s.updateStock(c, stock)
+}
+
+// SubtractStock decreases the stock of a composition. Only available stock
+// can be subtracted: reserved and blocked stock are kept.
+func (s *service) SubtractStock(id string, q quantity.Quantity) (*Composition, error) {
+	return retry(func() (*Composition, error) {
+		return s.subtractStock(id, q)
+	})
+}
+
+func (s *service) subtractStock(id string, q quantity.Quantity) (*Composition, error) {
+	path := "composition/service.SubtractStock"
+
+	c, err := s.findByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	converted, err := c.ToUnit(q)
+	if err != nil || q.Quantity < 0 {
+		return nil, errors.NewStatus("INVALID_QUANTITY").SetPath(path).SetMessage("%v", q).SetRef(err)
+	}
+	q = converted
+
+	available := c.Available()
+	if less, err := available.Less(q); err != nil || less {
+		return nil, errors.NewStatus("INSUFFICIENT_AVAILABLE_STOCK").SetPath(path).SetMessage("%v < %v", available, q)
+	}
+
+	stock, err := c.Stock.Subtract(q)
+	if err != nil {
+		return nil, errors.NewStatus("INVALID_QUANTITY").SetPath(path).SetMessage("%v", q).SetRef(err)
+	}
+
+	return s.updateStock(c, stock)
+}
+
+// SubtractReserved decreases the stock of a composition issuing reserved
+// stock: the reservation is released in the same update.
+func (s *service) SubtractReserved(id string, q quantity.Quantity) (*Composition, error) {
+	return retry(func() (*Composition, error) {
+		return s.subtractReserved(id, q)
+	})
+}
+
+func (s *service) subtractReserved(id string, q quantity.Quantity) (*Composition, error) {
+	path := "composition/service.SubtractReserved"
+
+	c, err := s.findByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	converted, err := c.ToUnit(q)
+	if err != nil || q.Quantity < 0 {
+		return nil, errors.NewStatus("INVALID_QUANTITY").SetPath(path).SetMessage("%v", q).SetRef(err)
+	}
+	q = converted
+
+	if less, err := c.Reserved.Less(q); c.Reserved.IsEmpty() || err != nil || less {
+		return nil, errors.NewStatus("INSUFFICIENT_RESERVED_STOCK").SetPath(path).SetMessage("%v < %v", c.Reserved, q)
+	}
+
+	stock, err := c.Stock.Subtract(q)
+	if err != nil {
+		return nil, errors.NewStatus("INVALID_QUANTITY").SetPath(path).SetMessage("%v", q).SetRef(err)
+	}
+	if stock.Quantity < 0 {
+		return nil, errors.NewStatus("INSUFFICIENT_STOCK").SetPath(path).SetMessage("%v < %v", c.Stock, q)
+	}
+
+	c.Reserved, _ = c.Reserved.Subtract(q)
+
+	return s.updateStock(c, stock)
+}
+
+// Reserve reserves stock of a composition. Reserved stock is still part of
+// the stock until it is issued, but it is not available for new reservations.
+func (s *service) Reserve(id string, q quantity.Quantity) (*Composition, error) {
+	return retry(func() (*Composition, error) {
+		return s.reserve(id, q)
+	})
+}
+
+func (s *service) reserve(id string, q quantity.Quantity) (*Composition, error) {
+	path := "composition/service.Reserve"
+
+	c, err := s.findByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	converted, err := c.ToUnit(q)
+	if err != nil || q.Quantity < 0 {
+		return nil, errors.NewStatus("INVALID_QUANTITY").SetPath(path).SetMessage("%v", q).SetRef(err)
+	}
+	q = converted
+
+	available := c.Available()
+	if less, err := available.Less(q); err != nil || less {
+		return nil, errors.NewStatus("INSUFFICIENT_AVAILABLE_STOCK").SetPath(path).SetMessage("%v < %v", available, q)
+	}
+
+	reserved := quantity.Quantity{Quantity: 0, Unit: c.Stock.Unit}
+	if !c.Reserved.IsEmpty() {
+		reserved = c.Reserved
+	}
+	c.Reserved, _ = reserved.Add(q)
+
+	if err := s.repository.Update(c); err != nil {
+		return nil, errors.NewStatus("UPDATE").SetPath(path).SetRef(err)
+	}
+
+	return c, nil
+}
+
+// Release releases reserved stock of a composition. Releasing more than the
+// reserved quantity releases everything.
+func (s *service) Release(id string, q quantity.Quantity) (*Composition, error) {
+	return retry(func() (*Composition, error) {
+		return s.release(id, q)
+	})
+}
+
+func (s *service) release(id string, q quantity.Quantity) (*Composition, error) {
+	path := "composition/service.Release"
+
+	c, err := s.findByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	converted, err := c.ToUnit(q)
+	if err != nil || q.Quantity < 0 {
+		return nil, errors.NewStatus("INVALID_QUANTITY").SetPath(path).SetMessage("%v", q).SetRef(err)
+	}
+	q = converted
+
+	if c.Reserved.IsEmpty() {
+		return c, nil
+	}
+
+	c.Reserved, _ = c.Reserved.Subtract(q)
+	if c.Reserved.Quantity < 0 {
+		c.Reserved.Quantity = 0
+	}
+
+	if err := s.repository.Update(c); err != nil {
+		return nil, errors.NewStatus("UPDATE").SetPath(path).SetRef(err)
+	}
+
+	return c, nil
+}
+
+// Block excludes stock of a composition from the available stock, e.g. the
+// remaining quantity of a quarantined lot. Blocked stock is still part of the
+// stock, but it can't be reserved.
+func (s *service) Block(id string, q quantity.Quantity) (*Composition, error) {
+	return retry(func() (*Composition, error) {
+		return s.block(id, q)
+	})
+}
+
+func (s *service) block(id string, q quantity.Quantity) (*Composition, error) {
+	path := "composition/service.Block"
+
+	c, err := s.findByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	converted, err := c.ToUnit(q)
+	if err != nil || q.Quantity < 0 {
+		return nil, errors.NewStatus("INVALID_QUANTITY").SetPath(path).SetMessage("%v", q).SetRef(err)
+	}
+	q = converted
+
+	blocked := quantity.Quantity{Quantity: 0, Unit: c.Stock.Unit}
+	if !c.Blocked.IsEmpty() {
+		blocked = c.Blocked
+	}
+	c.Blocked, _ = blocked.Add(q)
+
+	if err := s.repository.Update(c); err != nil {
+		return nil, errors.NewStatus("UPDATE").SetPath(path).SetRef(err)
+	}
+
+	return c, nil
+}
+
+// FindBelowReorderPoint returns all enabled compositions whose stock reached
+// their reorder point (or minimum stock if there is no reorder point).
+func (s *service) FindBelowReorderPoint() ([]*Composition, error) {
+	path := "composition/service.FindBelowReorderPoint"
+
+	comps, err := s.repository.FindAll()
+	if err != nil {
+		return nil, errors.NewStatus("FIND_ALL").SetPath(path).SetRef(err)
+	}
+
+	below := make([]*Composition, 0)
+	for _, c := range comps {
+		if c.Enabled && c.BelowReorderPoint() {
+			below = append(below, c)
+		}
+	}
+
+	return below, nil
+}
+
+// FindByUnit returns all compositions, including disabled ones, with
+// quantities in the unit.
+func (s *service) FindByUnit(unit string) ([]*Composition, error) {
+	comps, err := s.repository.FindAll()
+	if err != nil {
+		return nil, errors.NewStatus("FIND_ALL").SetPath("composition/service.FindByUnit").SetRef(err)
+	}
+
+	found := make([]*Composition, 0)
+	for _, c := range comps {
+		if c.UsesUnit(unit) {
+			found = append(found, c)
+		}
+	}
+
+	return found, nil
+}
+
+// AnalyzeCost ranks the leaf materials and routing costs of a composition by
+// their contribution to its cost.
+func (s *service) AnalyzeCost(id string) (*CostAnalysis, error) {
+	c, err := s.findByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return AnalyzeCost(c, func(depID string) (*Composition, error) {
+		dep, err := s.repository.FindByID(depID)
+		if err != nil {
+			return nil, errors.NewStatus("DEPENDENCY_DOES_NOT_EXIST").SetPath("composition/service.AnalyzeCost").SetRef(err)
+		}
+		return dep, nil
+	})
+}
+
+// GetGraph returns the graph of all compositions or, if id is not empty, the
+// subgraph reachable from it in a direction: down, up or both.
+func (s *service) GetGraph(id string, direction string) (*Graph, error) {
+	comps, err := s.repository.FindAll()
+	if err != nil {
+		return nil, errors.NewStatus("FIND_ALL").SetPath("composition/service.GetGraph").SetRef(err)
+	}
+
+	g := NewGraph(comps)
+	if id == "" {
+		return g, nil
+	}
+
+	return g.Subgraph(id, direction)
+}
+
+// UpdateUses updates uses of an updated composition. The published event is
+// caused by the cause event, the change of c.
 /**
 * @api {topic} composition.updated composition.updated
 * @apiName CompositionUpdatedAutomatically
@@ -271,15 +709,33 @@ func (s *service) Delete(id string) error {
 * @apiDescription Emits a new event when an existing composition is updated.
 * This event can be of type "CompositionUpdatedManually" or
 * "CompositionUpdatedAutomatically". The last one is published once a
-* composition is updated due to a dependency change.
+* composition is updated due to a dependency change, and shares the
+* correlationId of the manual change that caused it.
 *
 * @apiSuccessExample {json} Body
 * {
+* 	"id": "5d7d3bb7f2a9e2b9c0c0a2f1",
 * 	"type": "CompositionsUpdatedAutomatically",
-* 	"payload": list of compositions
+* 	"version": 1,
+* 	"occurredAt": "2019-09-14T18:30:00Z",
+* 	"aggregateId": "5d7d3b9ff2a9e2b9c0c0a2e8",
+* 	"producer": "uses",
+* 	"correlationId": "5d7d3ba8f2a9e2b9c0c0a2ec",
+* 	"causationId": "5d7d3ba8f2a9e2b9c0c0a2ec",
+* 	"compositions": list of compositions
 * }
  */
-func (s *service) UpdateUses(c *Composition) ([]*Composition, error) {
+func (s *service) UpdateUses(c *Composition, cause events.Event) ([]*Composition, error) {
+	var comps []*Composition
+	_, err := retry(func() (*Composition, error) {
+		var err error
+		comps, err = s.updateUsesOf(c, cause)
+		return nil, err
+	})
+	return comps, err
+}
+
+func (s *service) updateUsesOf(c *Composition, cause events.Event) ([]*Composition, error) {
 	path := "composition/service.UpdateUses"
 
 	cache := make(map[string]*Composition)
@@ -291,17 +747,21 @@ func (s *service) UpdateUses(c *Composition) ([]*Composition, error) {
 
 	comps := make([]*Composition, 0)
 	for _, u := range cache {
-		if err := s.repository.Update(u); err != nil {
-			return nil, errors.NewStatus("UPDATE_USES").SetPath(path).SetRef(err)
-		}
 		comps = append(comps, u)
 	}
+	if len(comps) == 0 {
+		return comps, nil
+	}
 
-	if len(comps) > 0 {
-		event, opts := NewCompositionsUpdatedAutomaticallyEvent(comps)
-		if err := s.eventMgr.Publish(event, opts); err != nil {
-			return nil, err
-		}
+	// Publish event through the outbox: composition.updated
+	event, opts := NewCompositionsUpdatedAutomaticallyEvent(c, comps, cause)
+	entry, err := events.NewEntry(c.ID.Hex(), event, opts)
+	if err != nil {
+		return nil, errors.NewStatus("ENCODE_EVENT").SetPath(path).SetRef(err)
+	}
+
+	if err := s.repository.UpdateMany(comps, entry); err != nil {
+		return nil, errors.NewStatus("UPDATE_USES").SetPath(path).SetRef(err)
 	}
 
 	return comps, nil
@@ -322,6 +782,39 @@ func (s *service) Validate(compID string) error {
 	return nil
 }
 
+// maxRetries is the number of times an update is retried when a composition
+// changed since it was read, e.g. by concurrent stock movements.
+const maxRetries = 5
+
+// retry runs fn again while it fails because a composition changed since it
+// was read.
+func retry(fn func() (*Composition, error)) (*Composition, error) {
+	for i := 0; ; i++ {
+		c, err := fn()
+		if err == nil || i == maxRetries || !isConflict(err) {
+			return c, err
+		}
+	}
+}
+
+// isConflict returns true if err, or an error it references, is a
+// VERSION_CONFLICT of the repository.
+func isConflict(err error) bool {
+	for err != nil {
+		if c, ok := err.(errors.Code); ok && c.Code() == "VERSION_CONFLICT" {
+			return true
+		}
+
+		r, ok := err.(errors.Reference)
+		if !ok {
+			return false
+		}
+		err = r.Reference()
+	}
+
+	return false
+}
+
 func (s *service) findByID(compID string) (*Composition, error) {
 	comp, err := s.repository.FindByID(compID)
 	if err != nil || !comp.Enabled {
@@ -333,6 +826,63 @@ func (s *service) findByID(compID string) (*Composition, error) {
 	return comp, nil
 }
 
+// updateStock saves the stock of a composition, with a StockBelowReorderPoint
+// event if it reached its reorder point.
+/**
+* @api {topic} composition.stock composition.stock
+* @apiName StockBelowReorderPoint
+* @apiGroup RabbitMQ
+*
+* @apiDescription Emits a new event when the stock of a composition is
+* changed and it is below its reorder point (or minimum stock).
+*
+* @apiSuccessExample {json} Body
+* {
+* 	"type": "StockBelowReorderPoint",
+* 	"composition": composition data,
+* 	"belowMinimumStock": false
+* }
+ */
+func (s *service) updateStock(c *Composition, stock quantity.Quantity) (*Composition, error) {
+	path := "composition/service.updateStock"
+
+	c.Stock = stock
+
+	// Publish event through the outbox: composition.stock
+	entries, err := newStockEntries(c, nil)
+	if err != nil {
+		return nil, errors.NewStatus("ENCODE_EVENT").SetPath(path).SetRef(err)
+	}
+
+	if err := s.repository.Update(c, entries...); err != nil {
+		return nil, errors.NewStatus("UPDATE").SetPath(path).SetRef(err)
+	}
+
+	return c, nil
+}
+
+// preview updates the uses of c in memory and compares them with the saved
+// ones.
+func (s *service) preview(old, c *Composition) (*Preview, error) {
+	cache := make(map[string]*Composition)
+	if err := s.updateUses(c, cache); err != nil {
+		return nil, errors.NewStatus("PREVIEW").SetPath("composition/service.preview").SetRef(err)
+	}
+
+	uses := make([]*Composition, 0, len(cache))
+	saved := make(map[string]*Composition)
+	for id, u := range cache {
+		savedUse, err := s.repository.FindByID(id)
+		if err != nil {
+			return nil, errors.NewStatus("PREVIEW").SetPath("composition/service.preview").SetRef(err)
+		}
+		uses = append(uses, u)
+		saved[id] = savedUse
+	}
+
+	return newPreview(old, c, uses, saved), nil
+}
+
 func (s *service) updateUses(c *Composition, cache map[string]*Composition) error {
 	path := "composition/service.updateUses"
 
@@ -346,8 +896,9 @@ func (s *service) updateUses(c *Composition, cache map[string]*Composition) erro
 
 		dep := u.FindDependencyByID(c.ID.Hex())
 
-		subvalue := c.CostFromQuantity(dep.Quantity)
-		dep.Subvalue = math.Round(subvalue*1000) / 1000
+		if err := dep.value(c); err != nil {
+			return errors.NewStatus("INCOMPATIBLE_DEPENDENCY_QUANTITY").SetPath(path).SetMessage("%s uses %s", u.ID.Hex(), c.ID.Hex()).SetRef(err)
+		}
 
 		u.UpsertDependency(*dep)
 
@@ -376,12 +927,17 @@ func (s *service) validateSchema(c *Composition) error {
 			return errors.NewStatus("DEPENDENCY_DOES_NOT_EXIST").SetPath(path).SetRef(err)
 		}
 
+		converted, err := comp.ToUnit(dep.Quantity)
+		if err != nil {
+			return errors.NewStatus("INCOMPATIBLE_DEPENDENCY_QUANTITY").SetPath(path).SetMessage("Dependency %d: %v != %v", i, dep.Quantity, comp.Unit).SetRef(err)
+		}
 		if !dep.Quantity.Compatible(comp.Unit) {
-			return errors.NewStatus("INCOMPATIBLE_DEPENDENCY_QUANTITY").SetPath(path).SetMessage("Dependency %d: %v != %v", i, dep.Quantity, comp.Unit)
+			dep.Quantity = converted
 		}
 
-		subvalue := comp.CostFromQuantity(dep.Quantity)
-		dep.Subvalue = math.Round(subvalue*1000) / 1000
+		if err := dep.value(comp); err != nil {
+			return errors.NewStatus("INCOMPATIBLE_DEPENDENCY_QUANTITY").SetPath(path).SetMessage("Dependency %d: %s", i, dep.On.Hex()).SetRef(err)
+		}
 		newDependencies[i] = dep
 	}
 	c.SetDependencies(newDependencies)
@@ -4,6 +4,7 @@ import (
 	"math"
 	"testing"
 
+	"github.com/aboglioli/big-brother/pkg/errors"
 	"github.com/aboglioli/big-brother/pkg/events"
 	"github.com/aboglioli/big-brother/pkg/quantity"
 	"github.com/aboglioli/big-brother/pkg/tests/assert"
@@ -49,8 +50,8 @@ func compToUpdateRequest(c *Composition) *UpdateRequest {
 }
 
 func TestGetByID(t *testing.T) {
-	repo, eventMgr := newMockRepository(), events.GetMockManager()
-	serv := NewService(repo, eventMgr)
+	repo := NewMockRepository()
+	serv := NewService(repo)
 
 	// Errors
 	t.Run("Not existing", func(t *testing.T) {
@@ -89,8 +90,8 @@ func TestGetByID(t *testing.T) {
 }
 
 func TestCreateComposition(t *testing.T) {
-	repo, eventMgr := newMockRepository(), events.GetMockManager()
-	serv := NewService(repo, eventMgr)
+	repo := NewMockRepository()
+	serv := NewService(repo)
 
 	// Errors
 	t.Run("Invalid ID", func(t *testing.T) {
@@ -161,15 +162,12 @@ func TestCreateComposition(t *testing.T) {
 	t.Run("Default values with valid units and raise event 'CompositionCreated'", func(t *testing.T) {
 		repo.Clean()
 		repo.Reset()
-		eventMgr.Clean()
-		eventMgr.Reset()
 		comp := newComposition()
 
 		_, err := serv.Create(compToCreateRequest(comp))
 		assert.Ok(t, err)
 		total, _ := repo.Count()
 		assert.Equal(t, total, 1)
-		assert.Equal(t, eventMgr.Count(), 1)
 
 		repo.Assert(t, []mock.Call{
 			mock.Call{"FindByID", []interface{}{comp.ID.Hex()}},
@@ -181,12 +179,10 @@ func TestCreateComposition(t *testing.T) {
 		assert.Equal(t, savedComp.Enabled, true)
 		assert.Equal(t, savedComp.Validated, false)
 
-		eventMgr.Assert(t, []mock.Call{
-			mock.Call{"Publish", []interface{}{mock.NotNil, mock.NotNil}},
-		})
-		msgs := eventMgr.Messages()
-		msg := msgs[0]
-		assert.Equal(t, msg.Type(), "CompositionCreated")
+		entries := repo.Entries()
+		assert.Equal(t, len(entries), 1)
+		assert.Equal(t, entries[0].Type(), "CompositionCreated")
+		assert.Equal(t, entries[0].Aggregate, comp.ID.Hex())
 	})
 
 	t.Run("Assign stock automatically from unit", func(t *testing.T) {
@@ -237,7 +233,6 @@ func TestCreateComposition(t *testing.T) {
 
 	t.Run("Calculate cost on creating and raise event", func(t *testing.T) {
 		repo.Clean()
-		eventMgr.Clean()
 		dep, comp := newComposition(), newComposition()
 		dep.Cost = 100
 		dep.Unit = quantity.Quantity{
@@ -254,37 +249,26 @@ func TestCreateComposition(t *testing.T) {
 			},
 		}
 		repo.Insert(dep)
-		eventMgr.Reset()
 
 		c, err := serv.Create(compToCreateRequest(comp))
 
 		assert.Ok(t, err)
 		assert.NotNil(t, c)
 		assert.Equal(t, c.Cost, 37.5, "Cost not calculated")
-		assert.Equal(t, eventMgr.Count(), 1, "Should raise an event")
-
-		eventMgr.Assert(t, []mock.Call{
-			mock.Call{"Publish", []interface{}{mock.NotNil, mock.NotNil}},
-		})
-		actualEvent, ok := eventMgr.Calls[0].Args[0].(*CompositionChangedEvent)
-		assert.Assert(t, ok)
-		assert.Equal(t, actualEvent.Type, "CompositionCreated")
-		assert.NotNil(t, actualEvent.Composition)
-		assert.Equal(t, actualEvent.Composition.ID.Hex(), comp.ID.Hex())
+		assert.Equal(t, len(repo.Entries()), 1, "Should raise an event")
 
-		msgs := eventMgr.Messages()
-		msg := msgs[0]
-		assert.Assert(t, msg.Type() == "CompositionCreated" && msg.Key == "composition.created", "Wrong event")
+		entry := repo.Entries()[0]
+		assert.Assert(t, entry.Type() == "CompositionCreated" && entry.Key == "composition.created", "Wrong event")
 
 		var evt CompositionChangedEvent
-		assert.Ok(t, msg.Decode(&evt), "Decode")
+		assert.Ok(t, entry.Decode(&evt), "Decode")
 		assert.Assert(t, evt.Composition.Cost == c.Cost && evt.Composition.ID.Hex() == c.ID.Hex(), "Composition from event is not the expected one")
 	})
 }
 
 func TestUpdateComposition(t *testing.T) {
-	repo, eventMgr := newMockRepository(), events.GetMockManager()
-	serv := NewService(repo, eventMgr)
+	repo := NewMockRepository()
+	serv := NewService(repo)
 
 	// Errors
 	t.Run("Wrong ID", func(t *testing.T) {
@@ -310,7 +294,6 @@ func TestUpdateComposition(t *testing.T) {
 
 	t.Run("Composition disabled and not validated", func(t *testing.T) {
 		repo.Clean()
-		eventMgr.Clean()
 		comp := newComposition()
 
 		comp.Enabled = false
@@ -396,8 +379,6 @@ func TestUpdateComposition(t *testing.T) {
 
 	t.Run("Update dependency and raise events", func(t *testing.T) {
 		repo.Clean()
-		eventMgr.Clean()
-		eventMgr.Reset()
 
 		comps := makeMockedCompositions()
 		repo.InsertMany(comps)
@@ -415,9 +396,17 @@ func TestUpdateComposition(t *testing.T) {
 			Unit:     "g",
 		}
 
-		c, err := serv.Update(c.ID.Hex(), compToUpdateRequest(c))
+		req := compToUpdateRequest(c)
+		_, err := serv.Update(c.ID.Hex(), req)
+		assert.ErrCode(t, err, "CONFIRMATION_REQUIRED")
+
+		req.Confirm = true
+		c, err = serv.Update(c.ID.Hex(), req)
 		assert.Ok(t, err)
-		updatedUses, err := serv.UpdateUses(c)
+
+		var cause CompositionChangedEvent
+		assert.Ok(t, repo.Entries()[0].Decode(&cause))
+		updatedUses, err := serv.UpdateUses(c, cause.Event)
 		assert.Ok(t, err)
 
 		assert.Equal(t, len(updatedUses), 4)
@@ -442,24 +431,23 @@ func TestUpdateComposition(t *testing.T) {
 		checkCompCost(t, comps, 5, c6)
 		checkCompCost(t, comps, 6, c7)
 
-		// Check events
-		eventMgr.Assert(t, []mock.Call{
-			mock.Call{"Publish", []interface{}{mock.NotNil, mock.NotNil}},
-			mock.Call{"Publish", []interface{}{mock.NotNil, mock.NotNil}},
-		})
-		assert.Equal(t, eventMgr.Count(), 2, "Should raise events")
-
-		msgs := eventMgr.Messages()
-		assert.Assert(t, msgs[0].Type() == "CompositionUpdatedManually" && msgs[0].Key == "composition.updated", "Wrong event")
+		// Check events: both are published through the outbox
+		entries := repo.Entries()
+		assert.Equal(t, len(entries), 2, "Should store events")
+		assert.Assert(t, entries[0].Type() == "CompositionUpdatedManually" && entries[0].Key == "composition.updated", "Wrong event")
 
 		var compUpdatedManuallyEvent CompositionChangedEvent
-		assert.Ok(t, msgs[0].Decode(&compUpdatedManuallyEvent), "Error")
+		assert.Ok(t, entries[0].Decode(&compUpdatedManuallyEvent), "Error")
 		assert.Equal(t, compUpdatedManuallyEvent.Composition.ID.Hex(), c.ID.Hex(), "Different composition")
-		assert.Assert(t, msgs[1].Type() == "CompositionsUpdatedAutomatically" && msgs[0].Key == "composition.updated", "Wrong event")
+
+		assert.Assert(t, entries[1].Type() == "CompositionsUpdatedAutomatically" && entries[1].Key == "composition.updated", "Wrong event")
 
 		var compsUpdatedAutomaticallyEvent CompositionsUpdatedAutomaticallyEvent
-		assert.Ok(t, msgs[1].Decode(&compsUpdatedAutomaticallyEvent))
+		assert.Ok(t, entries[1].Decode(&compsUpdatedAutomaticallyEvent))
 		assert.Equal(t, len(compsUpdatedAutomaticallyEvent.Compositions), 4, "Update automatically")
+		assert.Equal(t, compsUpdatedAutomaticallyEvent.AggregateID, c.ID.Hex())
+		assert.Equal(t, compsUpdatedAutomaticallyEvent.CausationID, compUpdatedManuallyEvent.ID, "Caused by the manual update")
+		assert.Equal(t, compsUpdatedAutomaticallyEvent.CorrelationID, compUpdatedManuallyEvent.CorrelationID)
 	})
 
 	t.Run("Creating, validating and updating", func(t *testing.T) {
@@ -491,8 +479,8 @@ func TestUpdateComposition(t *testing.T) {
 }
 
 func TestCreateAndUpdateDependencies(t *testing.T) {
-	repo, eventMgr := newMockRepository(), events.GetMockManager()
-	serv := NewService(repo, eventMgr)
+	repo := NewMockRepository()
+	serv := NewService(repo)
 
 	repo.Clean()
 	comp, dep1, dep2, dep3 := newComposition(), newComposition(), newComposition(), newComposition()
@@ -503,9 +491,9 @@ func TestCreateAndUpdateDependencies(t *testing.T) {
 	dep3.Cost = 75
 	dep3.Unit = quantity.Quantity{0.6, "kg"}
 	comp.Dependencies = []Dependency{
-		Dependency{dep1.ID, quantity.Quantity{500, "g"}, 0}, // 50
-		Dependency{dep2.ID, quantity.Quantity{1, "kg"}, 0},  // 50
-		Dependency{dep3.ID, quantity.Quantity{200, "g"}, 0}, // 25
+		Dependency{On: dep1.ID, Quantity: quantity.Quantity{500, "g"}}, // 50
+		Dependency{On: dep2.ID, Quantity: quantity.Quantity{1, "kg"}},  // 50
+		Dependency{On: dep3.ID, Quantity: quantity.Quantity{200, "g"}}, // 25
 	}
 
 	repo.InsertMany([]*Composition{dep1, dep2, dep3})
@@ -527,7 +515,7 @@ func TestCreateAndUpdateDependencies(t *testing.T) {
 		repo.Insert(dep4)
 
 		q := quantity.Quantity{1, "u"}
-		comp.Dependencies = append(comp.Dependencies, Dependency{dep4.ID, q, 0}) // 50
+		comp.Dependencies = append(comp.Dependencies, Dependency{On: dep4.ID, Quantity: q}) // 50
 
 		updateReq := compToUpdateRequest(comp)
 		comp, err := serv.Update(comp.ID.Hex(), updateReq)
@@ -558,8 +546,8 @@ func TestCreateAndUpdateDependencies(t *testing.T) {
 }
 
 func TestDeleteComposition(t *testing.T) {
-	repo, eventMgr := newMockRepository(), events.GetMockManager()
-	serv := NewService(repo, eventMgr)
+	repo := NewMockRepository()
+	serv := NewService(repo)
 
 	comp, dep := newComposition(), newComposition()
 	dep.Cost = 10
@@ -579,7 +567,6 @@ func TestDeleteComposition(t *testing.T) {
 	assert.Equal(t, total, enabled, "Used dependency cannot be deleted")
 
 	repo.Reset()
-	eventMgr.Reset()
 	assert.Ok(t, serv.Delete(comp.ID.Hex()), "Not used composition should be deleted")
 	total, enabled = repo.Count()
 	assert.Equal(t, total, 2, "Used dependency cannot be deleted")
@@ -591,13 +578,12 @@ func TestDeleteComposition(t *testing.T) {
 		mock.Call{"Delete", []interface{}{comp.ID.Hex()}},
 	})
 
-	eventMgr.Assert(t, []mock.Call{
-		mock.Call{"Publish", []interface{}{mock.NotNil, mock.NotNil}},
-	})
+	entries := repo.Entries()
+	assert.Equal(t, len(entries), 1, "Should store event")
+	assert.Equal(t, entries[0].Type(), "CompositionDeleted")
 
 	t.Run("Composition disabled and not validated", func(t *testing.T) {
 		repo.Clean()
-		eventMgr.Clean()
 
 		comp := newComposition()
 		comp.Enabled = false
@@ -615,8 +601,8 @@ func TestDeleteComposition(t *testing.T) {
 }
 
 func TestCalculateDependenciesSubvalues(t *testing.T) {
-	repo, eventMgr := newMockRepository(), events.GetMockManager()
-	serv := NewService(repo, eventMgr)
+	repo := NewMockRepository()
+	serv := NewService(repo)
 
 	comps := makeMockedCompositions()
 	repo.InsertMany(comps)
@@ -644,3 +630,432 @@ func TestCalculateDependenciesSubvalues(t *testing.T) {
 	checkCompCost(t, comps, 5, c6)
 	checkCompCost(t, comps, 6, c7)
 }
+
+func TestReorderPointEvaluation(t *testing.T) {
+	repo := NewMockRepository()
+	serv := NewService(repo)
+
+	comp := newComposition()
+	comp.Unit = quantity.Quantity{1, "kg"}
+	comp.Stock = quantity.Quantity{5, "kg"}
+	comp.ReorderPoint = quantity.Quantity{2, "kg"}
+	comp.ReorderQuantity = quantity.Quantity{10, "kg"}
+	repo.Insert(comp)
+
+	t.Run("Stock above reorder point", func(t *testing.T) {
+		repo.Reset()
+		updateReq := compToUpdateRequest(comp)
+		updateReq.Stock = &quantity.Quantity{2500, "g"}
+
+		_, err := serv.Update(comp.ID.Hex(), updateReq)
+		assert.Ok(t, err)
+		assert.Equal(t, len(repo.Entries()), 1, "Only CompositionUpdatedManually should be stored")
+
+		below, err := serv.FindBelowReorderPoint()
+		assert.Ok(t, err)
+		assert.Equal(t, len(below), 0)
+	})
+
+	t.Run("Stock reaches reorder point", func(t *testing.T) {
+		repo.Reset()
+		updateReq := compToUpdateRequest(comp)
+		updateReq.Stock = &quantity.Quantity{1500, "g"}
+
+		_, err := serv.Update(comp.ID.Hex(), updateReq)
+		assert.Ok(t, err)
+
+		entries := repo.Entries()
+		assert.Equal(t, len(entries), 2)
+		assert.Assert(t, entries[1].Type() == "StockBelowReorderPoint" && entries[1].Key == "composition.stock", "Wrong event")
+
+		var event StockBelowReorderPointEvent
+		assert.Ok(t, entries[1].Decode(&event))
+		assert.Equal(t, event.Composition.ID.Hex(), comp.ID.Hex())
+		assert.Equal(t, event.BelowMinimumStock, false)
+
+		var cause CompositionChangedEvent
+		assert.Ok(t, entries[0].Decode(&cause))
+		assert.Equal(t, event.CausationID, cause.ID, "Caused by the update")
+
+		below, err := serv.FindBelowReorderPoint()
+		assert.Ok(t, err)
+		assert.Equal(t, len(below), 1)
+		assert.Equal(t, below[0].ID.Hex(), comp.ID.Hex())
+	})
+
+	t.Run("Stock movement below reorder point", func(t *testing.T) {
+		repo.Reset()
+
+		_, err := serv.SubtractStock(comp.ID.Hex(), quantity.Quantity{500, "g"})
+		assert.Ok(t, err)
+
+		entries := repo.Entries()
+		assert.Equal(t, len(entries), 1)
+		assert.Equal(t, entries[0].Type(), "StockBelowReorderPoint")
+	})
+}
+
+func TestReserveStock(t *testing.T) {
+	repo := NewMockRepository()
+	serv := NewService(repo)
+
+	comp := newComposition()
+	comp.Unit = quantity.Quantity{1, "kg"}
+	comp.Stock = quantity.Quantity{5, "kg"}
+	repo.Insert(comp)
+
+	t.Run("Invalid quantity", func(t *testing.T) {
+		_, err := serv.Reserve(comp.ID.Hex(), quantity.Quantity{1, "l"})
+		assert.ErrCode(t, err, "INVALID_QUANTITY")
+	})
+
+	t.Run("Reserve", func(t *testing.T) {
+		c, err := serv.Reserve(comp.ID.Hex(), quantity.Quantity{3, "kg"})
+		assert.Ok(t, err)
+		assert.Assert(t, c.Available().Equals(quantity.Quantity{2, "kg"}))
+
+		_, err = serv.Reserve(comp.ID.Hex(), quantity.Quantity{2500, "g"})
+		assert.ErrCode(t, err, "INSUFFICIENT_AVAILABLE_STOCK")
+
+		c, err = serv.Reserve(comp.ID.Hex(), quantity.Quantity{2000, "g"})
+		assert.Ok(t, err)
+		assert.Assert(t, c.Reserved.Equals(quantity.Quantity{5, "kg"}))
+		assert.Assert(t, c.Stock.Equals(quantity.Quantity{5, "kg"}), "Stock should not change")
+	})
+
+	t.Run("Release", func(t *testing.T) {
+		c, err := serv.Release(comp.ID.Hex(), quantity.Quantity{1, "kg"})
+		assert.Ok(t, err)
+		assert.Assert(t, c.Available().Equals(quantity.Quantity{1, "kg"}))
+
+		c, err = serv.Release(comp.ID.Hex(), quantity.Quantity{10, "kg"})
+		assert.Ok(t, err)
+		assert.Assert(t, c.Reserved.Equals(quantity.Quantity{0, "kg"}))
+	})
+}
+
+// racingRepository reserves stock of a composition, like a concurrent
+// request, the first time it is read.
+type racingRepository struct {
+	*MockRepository
+	raced bool
+}
+
+func (r *racingRepository) FindByID(id string) (*Composition, error) {
+	c, err := r.MockRepository.FindByID(id)
+	if err == nil && !r.raced {
+		r.raced = true
+		concurrent := copyComposition(c)
+		concurrent.Reserved = quantity.Quantity{4, "kg"}
+		r.MockRepository.Update(concurrent)
+	}
+	return c, err
+}
+
+func TestConcurrentStockChanges(t *testing.T) {
+	repo := &racingRepository{MockRepository: NewMockRepository()}
+	serv := NewService(repo)
+
+	comp := newComposition()
+	comp.Unit = quantity.Quantity{1, "kg"}
+	comp.Stock = quantity.Quantity{5, "kg"}
+	repo.Insert(comp)
+
+	t.Run("Stale composition is read again", func(t *testing.T) {
+		_, err := serv.Reserve(comp.ID.Hex(), quantity.Quantity{3, "kg"})
+		assert.ErrCode(t, err, "INSUFFICIENT_AVAILABLE_STOCK", "Should see the concurrent reservation")
+
+		c, err := serv.AddStock(comp.ID.Hex(), quantity.Quantity{2, "kg"})
+		assert.Ok(t, err)
+		assert.Assert(t, c.Stock.Equals(quantity.Quantity{7, "kg"}))
+		assert.Assert(t, c.Reserved.Equals(quantity.Quantity{4, "kg"}), "Concurrent reservation should be kept")
+		assert.Equal(t, c.Version, 2)
+	})
+
+	t.Run("Stale composition is not saved", func(t *testing.T) {
+		stale, err := repo.FindByID(comp.ID.Hex())
+		assert.Ok(t, err)
+
+		_, err = serv.SubtractStock(comp.ID.Hex(), quantity.Quantity{1, "kg"})
+		assert.Ok(t, err)
+
+		stale.Stock = quantity.Quantity{100, "kg"}
+		err = repo.Update(stale)
+		assert.ErrCode(t, err, "VERSION_CONFLICT")
+
+		c, err := serv.GetByID(comp.ID.Hex())
+		assert.Ok(t, err)
+		assert.Assert(t, c.Stock.Equals(quantity.Quantity{6, "kg"}))
+	})
+}
+
+func TestAttributesRollup(t *testing.T) {
+	repo := NewMockRepository()
+	serv := NewService(repo)
+
+	create := func(req *CreateRequest) *Composition {
+		c, err := serv.Create(req)
+		assert.Ok(t, err)
+		assert.Ok(t, serv.Validate(c.ID.Hex()))
+		return c
+	}
+
+	flour := create(&CreateRequest{
+		Unit: quantity.Quantity{1, "kg"},
+		Attributes: &Attributes{
+			Values: map[string]float64{"calories": 3640, "protein": 100},
+			Flags:  map[string]bool{"gluten": true},
+			Sets:   map[string][]string{"allergens": []string{"gluten"}},
+		},
+	})
+	butter := create(&CreateRequest{
+		Unit: quantity.Quantity{250, "g"},
+		Attributes: &Attributes{
+			Values: map[string]float64{"calories": 1790},
+			Sets:   map[string][]string{"allergens": []string{"milk"}},
+		},
+	})
+	dough := create(&CreateRequest{
+		Unit: quantity.Quantity{1, "kg"},
+		Dependencies: []Dependency{
+			Dependency{On: flour.ID, Quantity: quantity.Quantity{600, "g"}},
+			Dependency{On: butter.ID, Quantity: quantity.Quantity{0.25, "kg"}},
+		},
+	})
+	bread := create(&CreateRequest{
+		Unit: quantity.Quantity{1, "u"},
+		Dependencies: []Dependency{
+			Dependency{On: dough.ID, Quantity: quantity.Quantity{500, "g"}},
+		},
+		Attributes: &Attributes{
+			Values: map[string]float64{"weight": 450},
+			Sets:   map[string][]string{"allergens": []string{"sesame"}},
+		},
+	})
+
+	assert.Equal(t, flour.ComputedAttributes.Values["calories"], 3640.0)
+	assert.Equal(t, dough.ComputedAttributes.Values["calories"], 3974.0)
+	assert.Equal(t, dough.ComputedAttributes.Values["protein"], 60.0)
+	assert.Assert(t, dough.ComputedAttributes.Flags["gluten"])
+
+	assert.Equal(t, bread.ComputedAttributes.Values["calories"], 1987.0)
+	assert.Equal(t, bread.ComputedAttributes.Values["weight"], 450.0)
+	allergens := bread.ComputedAttributes.Sets["allergens"]
+	assert.Equal(t, len(allergens), 3)
+	assert.Assert(t, allergens[0] == "gluten" && allergens[1] == "milk" && allergens[2] == "sesame")
+
+	t.Run("Ingredient change updates uses", func(t *testing.T) {
+		flour, err := serv.Update(flour.ID.Hex(), &UpdateRequest{
+			Attributes: &Attributes{
+				Values: map[string]float64{"calories": 3500, "protein": 100},
+				Flags:  map[string]bool{"gluten": false},
+			},
+		})
+		assert.Ok(t, err)
+		assert.Equal(t, flour.ComputedAttributes.Values["calories"], 3500.0)
+
+		_, err = serv.UpdateUses(flour, events.NewEvent("CompositionUpdatedManually", flour.ID.Hex()))
+		assert.Ok(t, err)
+
+		dough, _ := repo.FindByID(dough.ID.Hex())
+		assert.Equal(t, dough.ComputedAttributes.Values["calories"], 3890.0)
+		assert.Assert(t, !dough.ComputedAttributes.Flags["gluten"])
+		assert.Equal(t, len(dough.ComputedAttributes.Sets["allergens"]), 1)
+
+		bread, _ := repo.FindByID(bread.ID.Hex())
+		assert.Equal(t, bread.ComputedAttributes.Values["calories"], 1945.0)
+		assert.Equal(t, len(bread.ComputedAttributes.Sets["allergens"]), 2)
+	})
+
+	t.Run("Invalid attributes", func(t *testing.T) {
+		_, err := serv.Create(&CreateRequest{
+			Unit:       quantity.Quantity{1, "kg"},
+			Attributes: &Attributes{Values: map[string]float64{"": 1}},
+		})
+		assert.ErrValidation(t, err, "attributes", "INVALID_VALUE")
+	})
+}
+
+func TestPreviewUpdate(t *testing.T) {
+	repo := NewMockRepository()
+	serv := NewService(repo)
+
+	comps := makeMockedCompositions()
+	repo.InsertMany(comps)
+	for _, c := range comps {
+		servImpl := serv.(*service)
+		assert.Ok(t, servImpl.validateSchema(c))
+		assert.Ok(t, repo.Update(c))
+	}
+
+	c := comps[0]
+	cost, unit := 300.0, quantity.Quantity{2500, "g"}
+
+	p, err := serv.PreviewUpdate(c.ID.Hex(), &UpdateRequest{Cost: &cost, Unit: &unit})
+	assert.Ok(t, err)
+	assert.Equal(t, p.Composition.Cost, 300.0)
+	assert.Equal(t, len(p.Changes), 2)
+	assert.Equal(t, p.Changes[0].Field, "cost")
+	assert.Equal(t, p.Changes[0].Old, 200.0)
+	assert.Equal(t, p.Changes[0].New, 300.0)
+	assert.Equal(t, p.Changes[1].Field, "unit")
+	assert.Assert(t, p.RequiresConfirmation)
+
+	assert.Equal(t, len(p.Impacts), 4)
+	top := p.Impacts[0]
+	assert.Equal(t, top.Composition, comps[6].ID)
+	assert.Equal(t, top.OldCost, 475.75)
+	assert.Equal(t, top.NewCost, 492.15)
+	assert.Equal(t, top.Difference, 16.4)
+
+	saved, _ := repo.FindByID(c.ID.Hex())
+	assert.Equal(t, saved.Cost, 200.0, "Preview should not be saved")
+	saved, _ = repo.FindByID(comps[6].ID.Hex())
+	assert.Equal(t, saved.Cost, 475.75, "Preview should not update uses")
+
+	t.Run("Small changes don't require confirmation", func(t *testing.T) {
+		cost := 205.0
+		p, err := serv.PreviewUpdate(c.ID.Hex(), &UpdateRequest{Cost: &cost})
+		assert.Ok(t, err)
+		assert.Assert(t, !p.RequiresConfirmation)
+
+		updated, err := serv.Update(c.ID.Hex(), &UpdateRequest{Cost: &cost})
+		assert.Ok(t, err)
+		assert.Equal(t, updated.Cost, 205.0)
+	})
+}
+
+func TestUnitConversions(t *testing.T) {
+	repo := NewMockRepository()
+	serv := NewService(repo)
+
+	stock := quantity.Quantity{2, "box"}
+	beer, err := serv.Create(&CreateRequest{
+		Name:  "Beer",
+		Cost:  2,
+		Unit:  quantity.Quantity{1, "u"},
+		Stock: &stock,
+		Conversions: []quantity.Conversion{
+			quantity.Conversion{From: quantity.Quantity{1, "case"}, To: quantity.Quantity{4, "box"}},
+			quantity.Conversion{From: quantity.Quantity{1, "box"}, To: quantity.Quantity{12, "u"}},
+			quantity.Conversion{From: quantity.Quantity{1, "u"}, To: quantity.Quantity{330, "ml"}},
+		},
+	})
+	assert.Ok(t, err)
+	assert.Ok(t, serv.Validate(beer.ID.Hex()))
+	assert.Equal(t, beer.Stock, quantity.Quantity{24, "u"})
+
+	cost, err := beer.CostFromQuantity(quantity.Quantity{2, "box"})
+	assert.Ok(t, err)
+	assert.Equal(t, cost, 48.0)
+	cost, _ = beer.CostFromQuantity(quantity.Quantity{1.98, "l"})
+	assert.Equal(t, cost, 12.0)
+	_, err = beer.CostFromQuantity(quantity.Quantity{1, "kg"})
+	assert.ErrCode(t, err, "INCOMPATIBLE_QUANTITY", "Without conversion")
+
+	beer, err = serv.AddStock(beer.ID.Hex(), quantity.Quantity{1, "case"})
+	assert.Ok(t, err)
+	assert.Equal(t, beer.Stock, quantity.Quantity{72, "u"})
+
+	_, err = serv.SubtractStock(beer.ID.Hex(), quantity.Quantity{1, "kg"})
+	assert.ErrCode(t, err, "INVALID_QUANTITY")
+
+	t.Run("Dependencies in packaging units", func(t *testing.T) {
+		pack, err := serv.Create(&CreateRequest{
+			Name: "Party pack",
+			Unit: quantity.Quantity{1, "u"},
+			Dependencies: []Dependency{
+				Dependency{On: beer.ID, Quantity: quantity.Quantity{1, "box"}},
+			},
+		})
+		assert.Ok(t, err)
+		assert.Equal(t, pack.Dependencies[0].Quantity, quantity.Quantity{12, "u"})
+		assert.Equal(t, pack.Cost, 24.0)
+
+		_, err = serv.Create(&CreateRequest{
+			Unit: quantity.Quantity{1, "u"},
+			Dependencies: []Dependency{
+				Dependency{On: beer.ID, Quantity: quantity.Quantity{1, "kg"}},
+			},
+		})
+		assert.ErrCode(t, err, "INCOMPATIBLE_DEPENDENCY_QUANTITY")
+	})
+
+	t.Run("Invalid conversions", func(t *testing.T) {
+		_, err := serv.Create(&CreateRequest{
+			Unit: quantity.Quantity{1, "u"},
+			Conversions: []quantity.Conversion{
+				quantity.Conversion{From: quantity.Quantity{1, "kg"}, To: quantity.Quantity{1000, "g"}},
+				quantity.Conversion{From: quantity.Quantity{1, "pallet"}, To: quantity.Quantity{500, "kg"}},
+				quantity.Conversion{From: quantity.Quantity{1, "box"}, To: quantity.Quantity{12, "u"}},
+				quantity.Conversion{From: quantity.Quantity{1, "box"}, To: quantity.Quantity{10, "u"}},
+			},
+		})
+		assert.ErrValidation(t, err, "conversions", "INVALID")
+		assert.ErrValidation(t, err, "conversions", "UNREACHABLE")
+		assert.ErrValidation(t, err, "conversions", "INCONSISTENT")
+	})
+}
+
+func TestDensity(t *testing.T) {
+	repo := NewMockRepository()
+	serv := NewService(repo)
+
+	density := quantity.Density(0.92)
+	oil, err := serv.Create(&CreateRequest{
+		Name:    "Oil",
+		Cost:    4,
+		Unit:    quantity.Quantity{1, "kg"},
+		Density: &density,
+	})
+	assert.Ok(t, err)
+	assert.Ok(t, serv.Validate(oil.ID.Hex()))
+	assert.Assert(t, oil.Compatible(quantity.Quantity{1, "l"}))
+
+	oil, err = serv.AddStock(oil.ID.Hex(), quantity.Quantity{2, "l"})
+	assert.Ok(t, err)
+	assert.Equal(t, oil.Stock, quantity.Quantity{1.84, "kg"})
+
+	flour, err := serv.Create(&CreateRequest{
+		Name: "Flour",
+		Cost: 1,
+		Unit: quantity.Quantity{1, "kg"},
+	})
+	assert.Ok(t, err)
+	assert.Ok(t, serv.Validate(flour.ID.Hex()))
+
+	dough, err := serv.Create(&CreateRequest{
+		Name: "Dough",
+		Unit: quantity.Quantity{1, "u"},
+		Dependencies: []Dependency{
+			Dependency{On: oil.ID, Quantity: quantity.Quantity{500, "ml"}},
+			Dependency{On: flour.ID, Quantity: quantity.Quantity{500, "g"}},
+		},
+	})
+	assert.Ok(t, err)
+	assert.Equal(t, dough.Dependencies[0].Quantity, quantity.Quantity{0.46, "kg"})
+	assert.Equal(t, dough.Cost, 2.34)
+
+	t.Run("Without density", func(t *testing.T) {
+		_, err := serv.Create(&CreateRequest{
+			Unit: quantity.Quantity{1, "u"},
+			Dependencies: []Dependency{
+				Dependency{On: flour.ID, Quantity: quantity.Quantity{500, "ml"}},
+			},
+		})
+		assert.ErrCode(t, err, "INCOMPATIBLE_DEPENDENCY_QUANTITY")
+		assert.ErrCode(t, err.(*errors.Status).Reference(), "DENSITY_REQUIRED")
+
+		_, err = serv.AddStock(flour.ID.Hex(), quantity.Quantity{1, "l"})
+		assert.ErrCode(t, err, "INVALID_QUANTITY")
+	})
+
+	t.Run("Invalid density", func(t *testing.T) {
+		density := quantity.Density(-1)
+		_, err := serv.Create(&CreateRequest{
+			Unit:    quantity.Quantity{1, "l"},
+			Density: &density,
+		})
+		assert.ErrValidation(t, err, "density", "INVALID")
+	})
+}
@@ -0,0 +1,166 @@
+package forecast
+
+import (
+	"math"
+)
+
+const (
+	MethodMovingAverage        = "MOVING_AVERAGE"
+	MethodExponentialSmoothing = "EXPONENTIAL_SMOOTHING"
+	MethodSeasonal             = "SEASONAL"
+)
+
+// Method forecasts the demand of the next periods from the demand of the
+// previous ones, oldest first.
+type Method interface {
+	Forecast(history []float64, periods int) []float64
+}
+
+// MovingAverage forecasts the average of the last Window periods.
+type MovingAverage struct {
+	Window int
+}
+
+func (m MovingAverage) Forecast(history []float64, periods int) []float64 {
+	from := len(history) - m.Window
+	if from < 0 || m.Window <= 0 {
+		from = 0
+	}
+
+	return flat(mean(history[from:]), periods)
+}
+
+// ExponentialSmoothing forecasts the smoothed level of the history. Alpha, in
+// (0, 1], is the weight of the most recent period.
+type ExponentialSmoothing struct {
+	Alpha float64
+}
+
+func (m ExponentialSmoothing) Forecast(history []float64, periods int) []float64 {
+	if len(history) == 0 {
+		return flat(0, periods)
+	}
+
+	level := history[0]
+	for _, h := range history[1:] {
+		level = m.Alpha*h + (1-m.Alpha)*level
+	}
+
+	return flat(level, periods)
+}
+
+// Seasonal forecasts the deseasonalized average of the last season scaled by
+// the seasonal index of each period. Indexes are the average demand of each
+// position in the season over the average demand. Histories shorter than a
+// season are forecasted with a moving average.
+type Seasonal struct {
+	Length int
+}
+
+func (m Seasonal) Forecast(history []float64, periods int) []float64 {
+	if m.Length <= 1 || len(history) < m.Length {
+		return MovingAverage{m.Length}.Forecast(history, periods)
+	}
+
+	avg := mean(history)
+	if avg == 0 {
+		return flat(0, periods)
+	}
+
+	// Positions are aligned with the end of the history, so the next period is
+	// position 0.
+	offset := len(history) % m.Length
+	sums := make([]float64, m.Length)
+	counts := make([]float64, m.Length)
+	for i, h := range history {
+		pos := (i - offset + m.Length) % m.Length
+		sums[pos] += h
+		counts[pos]++
+	}
+
+	indexes := make([]float64, m.Length)
+	for pos := range indexes {
+		indexes[pos] = sums[pos] / counts[pos] / avg
+	}
+
+	var level float64
+	last := history[len(history)-m.Length:]
+	for i, h := range last {
+		if index := indexes[i]; index > 0 {
+			level += h / index
+		}
+	}
+	level /= float64(m.Length)
+
+	forecast := make([]float64, periods)
+	for i := range forecast {
+		forecast[i] = level * indexes[i%m.Length]
+	}
+
+	return forecast
+}
+
+// Accuracy measures the one-step-ahead forecasts of a method against the
+// actual demand. MAPE is a percentage and ignores periods without demand.
+type Accuracy struct {
+	MAE  float64 `json:"mae"`
+	MAPE float64 `json:"mape"`
+	RMSE float64 `json:"rmse"`
+}
+
+// Backtest forecasts each period of the history, from the second on, with the
+// previous periods, and returns the forecasts and their accuracy.
+func Backtest(m Method, history []float64) ([]float64, Accuracy) {
+	fitted := make([]float64, 0, len(history))
+	var abs, pct, sq float64
+	var n, nPct int
+	for t := 1; t < len(history); t++ {
+		f := m.Forecast(history[:t], 1)[0]
+		fitted = append(fitted, f)
+
+		e := history[t] - f
+		abs += math.Abs(e)
+		sq += e * e
+		n++
+
+		if history[t] != 0 {
+			pct += math.Abs(e / history[t])
+			nPct++
+		}
+	}
+
+	var acc Accuracy
+	if n > 0 {
+		acc.MAE = round(abs / float64(n))
+		acc.RMSE = round(math.Sqrt(sq / float64(n)))
+	}
+	if nPct > 0 {
+		acc.MAPE = round(pct / float64(nPct) * 100)
+	}
+
+	return fitted, acc
+}
+
+func mean(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+func flat(v float64, periods int) []float64 {
+	forecast := make([]float64, periods)
+	for i := range forecast {
+		forecast[i] = v
+	}
+	return forecast
+}
+
+func round(v float64) float64 {
+	return math.Round(v*1000) / 1000
+}
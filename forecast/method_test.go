@@ -0,0 +1,58 @@
+package forecast
+
+import (
+	"testing"
+
+	"github.com/aboglioli/big-brother/pkg/tests/assert"
+)
+
+func TestMethods(t *testing.T) {
+	history := []float64{10, 20, 30, 40, 50, 60}
+
+	t.Run("Moving average", func(t *testing.T) {
+		f := MovingAverage{3}.Forecast(history, 2)
+		assert.Equal(t, f[0], 50.0)
+		assert.Equal(t, f[1], 50.0)
+
+		f = MovingAverage{10}.Forecast(history, 1)
+		assert.Equal(t, f[0], 35.0)
+	})
+
+	t.Run("Exponential smoothing", func(t *testing.T) {
+		f := ExponentialSmoothing{0.5}.Forecast(history, 1)
+		assert.Equal(t, round(f[0]), 50.313)
+
+		f = ExponentialSmoothing{1}.Forecast(history, 1)
+		assert.Equal(t, f[0], 60.0)
+	})
+
+	t.Run("Seasonal", func(t *testing.T) {
+		f := Seasonal{4}.Forecast([]float64{10, 20, 30, 40, 10, 20, 30, 40}, 5)
+		assert.Equal(t, round(f[0]), 10.0)
+		assert.Equal(t, round(f[1]), 20.0)
+		assert.Equal(t, round(f[2]), 30.0)
+		assert.Equal(t, round(f[3]), 40.0)
+		assert.Equal(t, round(f[4]), 10.0)
+
+		// Misaligned season: the next period is the fourth one
+		f = Seasonal{4}.Forecast([]float64{40, 10, 20, 30, 40, 10, 20, 30}, 1)
+		assert.Equal(t, round(f[0]), 40.0)
+
+		// Shorter than a season
+		f = Seasonal{12}.Forecast(history, 1)
+		assert.Equal(t, f[0], 35.0)
+	})
+}
+
+func TestBacktest(t *testing.T) {
+	fitted, acc := Backtest(MovingAverage{3}, []float64{10, 20, 30, 40, 50, 60})
+	assert.Equal(t, len(fitted), 5)
+	assert.Equal(t, fitted[0], 10.0)
+	assert.Equal(t, fitted[4], 40.0)
+	assert.Equal(t, acc.MAE, 17.0)
+	assert.Equal(t, acc.MAPE, 44.667)
+	assert.Equal(t, acc.RMSE, 17.464)
+
+	_, acc = Backtest(MovingAverage{3}, []float64{10})
+	assert.Equal(t, acc, Accuracy{})
+}
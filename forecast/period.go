@@ -0,0 +1,64 @@
+package forecast
+
+import (
+	"time"
+
+	"github.com/aboglioli/big-brother/pkg/quantity"
+)
+
+const (
+	PeriodDay   = "DAY"
+	PeriodWeek  = "WEEK"
+	PeriodMonth = "MONTH"
+)
+
+// Period is the demand of a composition from Start, inclusive, to the start of
+// the next period.
+type Period struct {
+	Start    time.Time         `json:"start"`
+	Quantity quantity.Quantity `json:"quantity"`
+}
+
+func isValidPeriod(period string) bool {
+	return period == PeriodDay || period == PeriodWeek || period == PeriodMonth
+}
+
+// periodStart returns the start of the period containing t: the day, the
+// Monday of the week or the first day of the month.
+func periodStart(period string, t time.Time) time.Time {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+
+	switch period {
+	case PeriodWeek:
+		return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+	case PeriodMonth:
+		return day.AddDate(0, 0, 1-day.Day())
+	}
+
+	return day
+}
+
+// addPeriods adds n periods to the start of a period.
+func addPeriods(period string, start time.Time, n int) time.Time {
+	switch period {
+	case PeriodWeek:
+		return start.AddDate(0, 0, 7*n)
+	case PeriodMonth:
+		return start.AddDate(0, n, 0)
+	}
+
+	return start.AddDate(0, 0, n)
+}
+
+// days returns the average number of days of a period.
+func days(period string) float64 {
+	switch period {
+	case PeriodWeek:
+		return 7
+	case PeriodMonth:
+		return 365.0 / 12
+	}
+
+	return 1
+}
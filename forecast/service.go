@@ -0,0 +1,278 @@
+package forecast
+
+import (
+	"math"
+	"time"
+
+	"github.com/aboglioli/big-brother/composition"
+	"github.com/aboglioli/big-brother/pkg/errors"
+	"github.com/aboglioli/big-brother/pkg/quantity"
+	"github.com/aboglioli/big-brother/sales"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Service interface {
+	Forecast(compID string, req *Request) (*Forecast, error)
+	UpdateReorderPoint(compID string, req *Request) (*composition.Composition, error)
+}
+
+type service struct {
+	compositionService composition.Service
+	salesService       sales.Service
+}
+
+func NewService(compServ composition.Service, salesServ sales.Service) Service {
+	return &service{
+		compositionService: compServ,
+		salesService:       salesServ,
+	}
+}
+
+// Request configures a forecast. History is the number of complete periods
+// before Date used as history and Periods is the number of periods to
+// forecast, starting with the period containing Date. Window is used by
+// moving averages, Alpha by exponential smoothing and SeasonLength, in
+// periods, by seasonal forecasts.
+type Request struct {
+	Method       string     `json:"method"`
+	Period       string     `json:"period"`
+	History      int        `json:"history"`
+	Periods      int        `json:"periods"`
+	Window       int        `json:"window"`
+	Alpha        float64    `json:"alpha"`
+	SeasonLength int        `json:"seasonLength"`
+	Date         *time.Time `json:"date"`
+}
+
+func (r *Request) setDefaults() {
+	if r.Method == "" {
+		r.Method = MethodMovingAverage
+	}
+	if r.Period == "" {
+		r.Period = PeriodMonth
+	}
+	if r.History == 0 {
+		r.History = 12
+	}
+	if r.Periods == 0 {
+		r.Periods = 3
+	}
+	if r.Window == 0 {
+		r.Window = 3
+	}
+	if r.Alpha == 0 {
+		r.Alpha = 0.3
+	}
+	if r.SeasonLength == 0 {
+		switch r.Period {
+		case PeriodDay:
+			r.SeasonLength = 7
+		case PeriodWeek:
+			r.SeasonLength = 52
+		default:
+			r.SeasonLength = 12
+		}
+	}
+}
+
+func (r *Request) validate() error {
+	err := errors.NewValidation("VALIDATE_SCHEMA").SetPath("forecast/service.Request.validate")
+
+	if r.Method != MethodMovingAverage && r.Method != MethodExponentialSmoothing && r.Method != MethodSeasonal {
+		err.Add("method", "INVALID")
+	}
+	if !isValidPeriod(r.Period) {
+		err.Add("period", "INVALID")
+	}
+	if r.History < 1 {
+		err.Add("history", "INVALID")
+	}
+	if r.Periods < 1 {
+		err.Add("periods", "INVALID")
+	}
+	if r.Window < 1 {
+		err.Add("window", "INVALID")
+	}
+	if r.Alpha <= 0 || r.Alpha > 1 {
+		err.Add("alpha", "INVALID")
+	}
+	if r.SeasonLength < 2 {
+		err.Add("seasonLength", "INVALID")
+	}
+
+	if err.Size() > 0 {
+		return err
+	}
+
+	return nil
+}
+
+func (r *Request) method() Method {
+	switch r.Method {
+	case MethodExponentialSmoothing:
+		return ExponentialSmoothing{r.Alpha}
+	case MethodSeasonal:
+		return Seasonal{r.SeasonLength}
+	}
+	return MovingAverage{r.Window}
+}
+
+// Forecast is the demand forecast of a composition. Fitted are the
+// one-step-ahead forecasts of the history, from its second period on, used to
+// measure the accuracy.
+type Forecast struct {
+	Composition primitive.ObjectID `json:"composition"`
+	Method      string             `json:"method"`
+	Period      string             `json:"period"`
+	History     []Period           `json:"history"`
+	Fitted      []Period           `json:"fitted"`
+	Forecast    []Period           `json:"forecast"`
+	Accuracy    Accuracy           `json:"accuracy"`
+}
+
+// Forecast forecasts the demand of a composition from the quantities of its
+// delivered sales order lines.
+func (s *service) Forecast(compID string, req *Request) (*Forecast, error) {
+	req.setDefaults()
+	if err := req.validate(); err != nil {
+		return nil, err
+	}
+
+	comp, err := s.compositionService.GetByID(compID)
+	if err != nil {
+		return nil, err
+	}
+
+	date := time.Now()
+	if req.Date != nil {
+		date = *req.Date
+	}
+
+	end := periodStart(req.Period, date)
+	start := addPeriods(req.Period, end, -req.History)
+
+	history, err := s.history(comp, req.Period, start, req.History)
+	if err != nil {
+		return nil, err
+	}
+
+	m := req.method()
+	fitted, acc := Backtest(m, history)
+
+	f := &Forecast{
+		Composition: comp.ID,
+		Method:      req.Method,
+		Period:      req.Period,
+		History:     make([]Period, len(history)),
+		Fitted:      make([]Period, len(fitted)),
+		Forecast:    make([]Period, req.Periods),
+		Accuracy:    acc,
+	}
+
+	for i, h := range history {
+		f.History[i] = Period{addPeriods(req.Period, start, i), toUnit(h, comp.Unit)}
+	}
+	for i, h := range fitted {
+		f.Fitted[i] = Period{addPeriods(req.Period, start, i+1), toUnit(h, comp.Unit)}
+	}
+	for i, h := range m.Forecast(history, req.Periods) {
+		f.Forecast[i] = Period{addPeriods(req.Period, end, i), toUnit(h, comp.Unit)}
+	}
+
+	return f, nil
+}
+
+// UpdateReorderPoint sets the reorder point of a composition to its minimum
+// stock plus the forecasted demand during its lead time.
+func (s *service) UpdateReorderPoint(compID string, req *Request) (*composition.Composition, error) {
+	f, err := s.Forecast(compID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	comp, err := s.compositionService.GetByID(compID)
+	if err != nil {
+		return nil, err
+	}
+
+	var demand float64
+	for _, p := range f.Forecast {
+		demand += p.Quantity.Quantity
+	}
+	daily := demand / float64(len(f.Forecast)) / days(f.Period)
+
+	point := daily * float64(comp.LeadTime)
+	if !comp.MinimumStock.IsEmpty() {
+		minimum, err := comp.ToUnit(comp.MinimumStock)
+		if err != nil {
+			return nil, errors.NewStatus("INCOMPATIBLE_QUANTITY").SetPath("forecast/service.UpdateReorderPoint").SetRef(err)
+		}
+		point += minimum.Quantity
+	}
+
+	reorderPoint := quantity.Quantity{round(point), comp.Unit.Unit}
+
+	return s.compositionService.Update(compID, &composition.UpdateRequest{
+		ReorderPoint: &reorderPoint,
+		Dependencies: comp.Dependencies,
+	})
+}
+
+// history returns the quantity delivered in each period, in the unit of the
+// composition.
+func (s *service) history(comp *composition.Composition, period string, start time.Time, n int) ([]float64, error) {
+	end := addPeriods(period, start, n)
+	orders, err := s.salesService.FindDeliveredOrders(comp.ID.Hex(), start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	history := make([]float64, n)
+	for _, o := range orders {
+		if o.DeliveredAt == nil {
+			continue
+		}
+
+		i := periodIndex(period, start, *o.DeliveredAt)
+		if i < 0 || i >= n {
+			continue
+		}
+
+		for _, l := range o.Lines {
+			if l.Composition != comp.ID {
+				continue
+			}
+
+			q, err := comp.ToUnit(l.Quantity)
+			if err != nil {
+				return nil, errors.NewStatus("INCOMPATIBLE_QUANTITY").SetPath("forecast/service.history").SetMessage("order %s: %v", o.ID.Hex(), l.Quantity).SetRef(err)
+			}
+			history[i] += q.Quantity
+		}
+	}
+
+	return history, nil
+}
+
+// periodIndex returns the number of periods from start to the period
+// containing t.
+func periodIndex(period string, start, t time.Time) int {
+	t = periodStart(period, t)
+
+	switch period {
+	case PeriodMonth:
+		return (t.Year()-start.Year())*12 + int(t.Month()) - int(start.Month())
+	case PeriodWeek:
+		return int(math.Round(t.Sub(start).Hours() / 24 / 7))
+	}
+
+	return int(math.Round(t.Sub(start).Hours() / 24))
+}
+
+// toUnit returns a rounded quantity in the unit of a composition.
+func toUnit(n float64, unit quantity.Quantity) quantity.Quantity {
+	return quantity.Quantity{
+		Quantity: round(n),
+		Unit:     unit.Unit,
+	}
+}
@@ -0,0 +1,93 @@
+package forecast
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aboglioli/big-brother/composition"
+	"github.com/aboglioli/big-brother/pkg/events"
+	"github.com/aboglioli/big-brother/pkg/quantity"
+	"github.com/aboglioli/big-brother/pkg/tests/assert"
+	"github.com/aboglioli/big-brother/pricing"
+	"github.com/aboglioli/big-brother/sales"
+	"github.com/aboglioli/big-brother/stock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestForecast(t *testing.T) {
+	compRepo, orderRepo, eventMgr := composition.NewMockRepository(), sales.NewMockOrderRepository(), events.GetMockManager()
+	compServ := composition.NewService(compRepo)
+	stockServ := stock.NewService(stock.NewMockLotRepository(), stock.NewMockMovementRepository(), stock.NewMockCountRepository(), compServ)
+	pricingServ := pricing.NewService(pricing.NewMockRepository(), compServ, eventMgr, 0.2)
+	salesServ := sales.NewService(sales.NewMockCustomerRepository(), orderRepo, compServ, stockServ, pricingServ)
+	serv := NewService(compServ, salesServ)
+
+	bread := composition.NewComposition()
+	bread.Unit = quantity.Quantity{1, "u"}
+	bread.Stock = quantity.Quantity{0, "u"}
+	bread.MinimumStock = quantity.Quantity{5, "u"}
+	bread.LeadTime = 10
+	bread.Validated = true
+	compRepo.Insert(bread)
+
+	deliver := func(q quantity.Quantity, date time.Time) {
+		o := sales.NewOrder(primitive.NewObjectID())
+		o.AddLine(bread.ID, q, 1)
+		o.Status = sales.OrderDelivered
+		o.DeliveredAt = &date
+		orderRepo.Insert(o)
+	}
+
+	// 10, 20, 30, 40, 50 and 60 units from January to June
+	deliver(quantity.Quantity{3, "u"}, time.Date(2018, 12, 20, 0, 0, 0, 0, time.UTC))
+	for i := 1; i <= 6; i++ {
+		deliver(quantity.Quantity{float64(i * 5), "u"}, time.Date(2019, time.Month(i), 3, 0, 0, 0, 0, time.UTC))
+		deliver(quantity.Quantity{float64(i * 5), "u"}, time.Date(2019, time.Month(i), 25, 0, 0, 0, 0, time.UTC))
+	}
+	deliver(quantity.Quantity{7, "u"}, time.Date(2019, 7, 1, 0, 0, 0, 0, time.UTC))
+
+	date := time.Date(2019, 7, 15, 0, 0, 0, 0, time.UTC)
+
+	t.Run("Invalid request", func(t *testing.T) {
+		_, err := serv.Forecast(bread.ID.Hex(), &Request{Method: "NAIVE", Period: "YEAR", Alpha: 2})
+		assert.ErrValidation(t, err, "method", "INVALID")
+		assert.ErrValidation(t, err, "period", "INVALID")
+		assert.ErrValidation(t, err, "alpha", "INVALID")
+	})
+
+	t.Run("Moving average", func(t *testing.T) {
+		f, err := serv.Forecast(bread.ID.Hex(), &Request{Method: MethodMovingAverage, Period: PeriodMonth, History: 6, Date: &date})
+		assert.Ok(t, err)
+
+		assert.Equal(t, len(f.History), 6)
+		assert.Equal(t, f.History[0].Start, time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC))
+		assert.Assert(t, f.History[0].Quantity.Equals(quantity.Quantity{10, "u"}))
+		assert.Assert(t, f.History[5].Quantity.Equals(quantity.Quantity{60, "u"}))
+
+		assert.Equal(t, len(f.Forecast), 3)
+		assert.Equal(t, f.Forecast[0].Start, time.Date(2019, 7, 1, 0, 0, 0, 0, time.UTC))
+		assert.Equal(t, f.Forecast[2].Start, time.Date(2019, 9, 1, 0, 0, 0, 0, time.UTC))
+		assert.Assert(t, f.Forecast[0].Quantity.Equals(quantity.Quantity{50, "u"}))
+
+		assert.Equal(t, len(f.Fitted), 5)
+		assert.Equal(t, f.Fitted[0].Start, time.Date(2019, 2, 1, 0, 0, 0, 0, time.UTC))
+		assert.Equal(t, f.Accuracy, Accuracy{MAE: 17, MAPE: 44.667, RMSE: 17.464})
+	})
+
+	t.Run("Weekly periods", func(t *testing.T) {
+		f, err := serv.Forecast(bread.ID.Hex(), &Request{Period: PeriodWeek, History: 2, Date: &date})
+		assert.Ok(t, err)
+
+		// 2019-07-15 is a Monday
+		assert.Equal(t, f.History[0].Start, time.Date(2019, 7, 1, 0, 0, 0, 0, time.UTC))
+		assert.Assert(t, f.History[0].Quantity.Equals(quantity.Quantity{7, "u"}))
+		assert.Assert(t, f.History[1].Quantity.Equals(quantity.Quantity{0, "u"}))
+	})
+
+	t.Run("Reorder point from forecast", func(t *testing.T) {
+		// 50u per month during 10 days plus 5u of minimum stock
+		comp, err := serv.UpdateReorderPoint(bread.ID.Hex(), &Request{History: 6, Date: &date})
+		assert.Ok(t, err)
+		assert.Assert(t, comp.ReorderPoint.Equals(quantity.Quantity{21.438, "u"}))
+	})
+}
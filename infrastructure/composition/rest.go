@@ -3,6 +3,7 @@ package composition
 import (
 	"fmt"
 	"net/http"
+	"strconv"
 
 	"github.com/aboglioli/big-brother/composition"
 	"github.com/aboglioli/big-brother/infrastructure/errors"
@@ -38,6 +39,10 @@ func StartREST(eventMgr events.Manager, serv composition.Service) {
 	server.PUT("/v1/composition/:compositionId", rest.Put)
 	server.DELETE("/v1/composition/:compositionId", rest.Delete)
 
+	server.GET("/v1/reports/below-reorder-point", rest.BelowReorderPoint)
+	server.GET("/v1/reports/cost-analysis/:compositionId", rest.CostAnalysis)
+	server.GET("/v1/reports/graph", rest.Graph)
+
 	server.Run(fmt.Sprintf(":%d", conf.Composition.Port))
 }
 
@@ -62,6 +67,7 @@ type RESTContext struct {
 *   "composition": {
 *     "id": "9dc9c429b9aa2a3c82801007",
 *     "name": "Comp 7",
+*     "category": "",
 *     "cost": 475.75,
 *     "unit": {
 *       "quantity": 3,
@@ -71,6 +77,14 @@ type RESTContext struct {
 *       "quantity": 1,
 *       "unit": "u"
 *     },
+*     "reserved": {
+*       "quantity": 0,
+*       "unit": "u"
+*     },
+*     "blocked": {
+*       "quantity": 0,
+*       "unit": "u"
+*     },
 *     "dependencies": [
 *       {
 *         "of": "9dc9c429b9aa2a3c82801005",
@@ -89,6 +103,25 @@ type RESTContext struct {
 *         "subvalue": 393.75
 *       }
 *     ],
+*     "attributes": {
+*       "values": {
+*         "weight": 450
+*       },
+*       "flags": null,
+*       "sets": null
+*     },
+*     "computedAttributes": {
+*       "values": {
+*         "calories": 1987,
+*         "weight": 450
+*       },
+*       "flags": {
+*         "gluten": true
+*       },
+*       "sets": {
+*         "allergens": ["gluten", "milk"]
+*       }
+*     },
 *     "autoupdateCost": true,
 *     "enabled": true,
 *     "validated": true,
@@ -126,10 +159,19 @@ func (r *RESTContext) GetByID(c *gin.Context) {
 * @apiGroup Composition
 *
 * @apiParam {String} [name=""] Name
+* @apiParam {String} [category=""] Category. Defines the tax rate.
 * @apiParam {String} [cost=0] Initial cost
-* @apiParam {Quantity} unit Composition base unit
+* @apiParam {Quantity} unit Composition base unit. Quantities are objects, {"quantity": 1.5, "unit": "kg"}, or strings, "1.5 kg".
 * @apiParam {Quantity} [stock] Stock quantity. Same units as "unit".
 * @apiParam {[]Dependency} [dependencies] Dependencies: foreign key "of", and "quantity".
+* @apiParam {[]Conversion} [conversions] Conversions between unit types and packaging units ("box", "case", "pallet"): {"from": {"quantity": 1, "unit": "box"}, "to": {"quantity": 12, "unit": "u"}}. Stock and dependency quantities can be in any convertible unit.
+* @apiParam {Number} [density] Density in kg/l (g/ml). Converts between mass and volume quantities of liquids.
+* @apiParam {Quantity} [minimumStock] Minimum stock. Compatible with "unit".
+* @apiParam {Quantity} [reorderPoint] Stock level that triggers a reorder. Compatible with "unit".
+* @apiParam {Quantity} [reorderQuantity] Quantity to reorder. Compatible with "unit".
+* @apiParam {Number} [leadTime] Days to purchase or produce it.
+* @apiParam {Quantity} [lotSize] Planned orders are multiples of it. Compatible with "unit".
+* @apiParam {Attributes} [attributes] Attributes by unit: numeric "values", boolean "flags" and "sets". They override the attributes rolled up from dependencies.
 * @apiParam {Boolean} [autoupdateCost=true] Auto update cost based on dependencies.
 *
 * @apiDescription Creates a new Composition. "id" is optional but it can be
@@ -257,11 +299,24 @@ func (r *RESTContext) Post(c *gin.Context) {
 * @apiParam {String} compositionId Composition ID
 *
 * @apiParam {String} [name] Name
+* @apiParam {String} [category] Category. Defines the tax rate.
 * @apiParam {String} [cost] Initial cost
 * @apiParam {Quantity} [unit] Composition base unit. Cannot be changed.
 * @apiParam {Quantity} [stock] Stock quantity. Same units as "unit".
 * @apiParam {[]Dependency} [dependencies] Dependencies: foreign key "of", and "quantity".
+* @apiParam {[]Conversion} [conversions] Conversions between unit types and packaging units ("box", "case", "pallet"): {"from": {"quantity": 1, "unit": "box"}, "to": {"quantity": 12, "unit": "u"}}. Stock and dependency quantities can be in any convertible unit.
+* @apiParam {Number} [density] Density in kg/l (g/ml). Converts between mass and volume quantities of liquids.
+* @apiParam {Quantity} [minimumStock] Minimum stock. Compatible with "unit".
+* @apiParam {Quantity} [reorderPoint] Stock level that triggers a reorder. Compatible with "unit".
+* @apiParam {Quantity} [reorderQuantity] Quantity to reorder. Compatible with "unit".
+* @apiParam {Number} [leadTime] Days to purchase or produce it.
+* @apiParam {Quantity} [lotSize] Planned orders are multiples of it. Compatible with "unit".
+* @apiParam {Attributes} [attributes] Attributes by unit: numeric "values", boolean "flags" and "sets". They override the attributes rolled up from dependencies.
+* @apiParam {Number} [routingCost] Labor cost per unit. Usually set from its routing.
 * @apiParam {Boolean} [autoupdateCost] Auto update cost based on dependencies.
+* @apiParam {Boolean} [confirm] Confirms large cost changes.
+* @apiParam {Boolean} [preview] Query parameter. Returns the preview without
+* saving the update.
 *
 * @apiDescription Updates an existing Composition based on its ID. "cost" can
 * be set to any value greater than 0 (default: 0). If "dependencies" are added,
@@ -269,6 +324,11 @@ func (r *RESTContext) Post(c *gin.Context) {
 * "autoupdateCost" is set to "false". All fields are optional. "unit" unit
 * cannot be changed of type.
 *
+* With "?preview=true", the update is not saved. The response contains the
+* changed fields and the compositions whose cost would change, as "preview".
+* Changing the cost by 10% or more when it affects 3 or more compositions
+* fails with CONFIRMATION_REQUIRED (409) unless "confirm" is true.
+*
 * @apiExample {json} Body
 * {
 *   "composition": {
@@ -358,6 +418,20 @@ func (r *RESTContext) Put(c *gin.Context) {
 		return
 	}
 
+	if c.Query("preview") == "true" {
+		preview, err := r.compositionService.PreviewUpdate(compID, &body)
+		if err != nil {
+			errors.Handle(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"status":  "PREVIEW",
+			"preview": preview,
+		})
+		return
+	}
+
 	comp, err := r.compositionService.Update(compID, &body)
 	if err != nil {
 		errors.Handle(c, err)
@@ -408,3 +482,228 @@ func (r *RESTContext) Delete(c *gin.Context) {
 		"status": "DELETED",
 	})
 }
+
+// BelowReorderPoint lists compositions below their reorder point
+/**
+* @api {get} /v1/reports/below-reorder-point BelowReorderPoint
+* @apiName Below reorder point
+* @apiGroup Reports
+*
+* @apiDescription Lists all compositions whose stock is below their reorder
+* point. Compositions without a reorder point are compared against their
+* minimum stock. Compositions without both are never listed.
+*
+* @apiSuccessExample {json} Response
+* HTTP/1.1 200 OK
+* {
+*   "compositions": [
+*     {
+*       "id": "9dc9c429b9aa2a3c82801001",
+*       "name": "Comp 1",
+*       "cost": 200,
+*       "unit": {
+*         "quantity": 2,
+*         "unit": "kg"
+*       },
+*       "stock": {
+*         "quantity": 1,
+*         "unit": "kg"
+*       },
+*       "minimumStock": {
+*         "quantity": 500,
+*         "unit": "g"
+*       },
+*       "reorderPoint": {
+*         "quantity": 2,
+*         "unit": "kg"
+*       },
+*       "reorderQuantity": {
+*         "quantity": 10,
+*         "unit": "kg"
+*       },
+*       ...
+*     }
+*   ]
+* }
+ */
+func (r *RESTContext) BelowReorderPoint(c *gin.Context) {
+	if r.conf.AuthEnabled {
+		if err := validateAuthAndPermission(c, "composition"); err != nil {
+			errors.Handle(c, err)
+			return
+		}
+	}
+
+	comps, err := r.compositionService.FindBelowReorderPoint()
+	if err != nil {
+		errors.Handle(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"compositions": comps,
+	})
+}
+
+// CostAnalysis ranks the inputs of a composition by their share of its cost
+/**
+* @api {get} /v1/reports/cost-analysis/:compositionId CostAnalysis
+* @apiName Cost analysis
+* @apiGroup Reports
+*
+* @apiParam {String} compositionId Composition ID
+* @apiParam {Number} [top] Only the first inputs
+*
+* @apiDescription Breaks down the cost of a unit in leaf materials, through
+* all paths of dependencies, and routing costs of intermediates. Inputs are
+* ranked by cost (Pareto). "sensitivity" is how much the cost moves per 1%
+* change in the cost of the input.
+*
+* @apiSuccessExample {json} Response
+* HTTP/1.1 200 OK
+* {
+*   "analysis": {
+*     "composition": "9dc9c429b9aa2a3c82801007",
+*     "cost": 7.1,
+*     "inputs": [
+*       {
+*         "composition": "9dc9c429b9aa2a3c82801001",
+*         "name": "Flour",
+*         "type": "MATERIAL",
+*         "quantity": {
+*           "quantity": 0.4,
+*           "unit": "kg"
+*         },
+*         "cost": 4,
+*         "share": 0.563,
+*         "cumulativeShare": 0.563,
+*         "sensitivity": 0.04
+*       },
+*       {
+*         "composition": "9dc9c429b9aa2a3c82801004",
+*         "name": "Dough",
+*         "type": "ROUTING",
+*         "quantity": null,
+*         "cost": 0.5,
+*         "share": 0.07,
+*         "cumulativeShare": 0.985,
+*         "sensitivity": 0.005
+*       },
+*       ...
+*     ]
+*   }
+* }
+ */
+func (r *RESTContext) CostAnalysis(c *gin.Context) {
+	if r.conf.AuthEnabled {
+		if err := validateAuthAndPermission(c, "composition"); err != nil {
+			errors.Handle(c, err)
+			return
+		}
+	}
+
+	analysis, err := r.compositionService.AnalyzeCost(c.Param("compositionId"))
+	if err != nil {
+		errors.Handle(c, err)
+		return
+	}
+
+	if top := c.Query("top"); top != "" {
+		n, err := strconv.Atoi(top)
+		if err != nil || n < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "invalid top",
+			})
+			return
+		}
+		if n < len(analysis.Inputs) {
+			analysis.Inputs = analysis.Inputs[:n]
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"analysis": analysis,
+	})
+}
+
+// Graph exports the graph of compositions
+/**
+* @api {get} /v1/reports/graph Graph
+* @apiName Composition graph
+* @apiGroup Reports
+*
+* @apiParam {String} [composition] Composition ID. All compositions if empty.
+* @apiParam {String} [direction=down] From the composition: "down"
+* (dependencies), "up" (uses) or "both".
+* @apiParam {String} [format=json] "json", "dot" (Graphviz) or "mermaid".
+*
+* @apiDescription Nodes are labeled with cost by unit and edges, from a
+* composition to its dependencies, with quantity and subvalue. DOT and Mermaid
+* are returned as text.
+*
+* @apiSuccessExample {json} Response
+* HTTP/1.1 200 OK
+* {
+*   "graph": {
+*     "nodes": [
+*       {
+*         "id": "9dc9c429b9aa2a3c82801007",
+*         "name": "Bread",
+*         "cost": 6.6,
+*         "unit": {
+*           "quantity": 1,
+*           "unit": "u"
+*         }
+*       },
+*       ...
+*     ],
+*     "edges": [
+*       {
+*         "from": "9dc9c429b9aa2a3c82801007",
+*         "to": "9dc9c429b9aa2a3c82801004",
+*         "quantity": {
+*           "quantity": 500,
+*           "unit": "g"
+*         },
+*         "subvalue": 5.5
+*       },
+*       ...
+*     ]
+*   }
+* }
+ */
+func (r *RESTContext) Graph(c *gin.Context) {
+	if r.conf.AuthEnabled {
+		if err := validateAuthAndPermission(c, "composition"); err != nil {
+			errors.Handle(c, err)
+			return
+		}
+	}
+
+	g, err := r.compositionService.GetGraph(c.Query("composition"), c.DefaultQuery("direction", composition.DirectionDown))
+	if err != nil {
+		errors.Handle(c, err)
+		return
+	}
+
+	format := c.DefaultQuery("format", composition.FormatJSON)
+	if format == composition.FormatJSON {
+		c.JSON(http.StatusOK, gin.H{
+			"graph": g,
+		})
+		return
+	}
+
+	out, err := g.Export(format)
+	if err != nil {
+		errors.Handle(c, err)
+		return
+	}
+
+	contentType := "text/plain; charset=utf-8"
+	if format == composition.FormatDOT {
+		contentType = "text/vnd.graphviz; charset=utf-8"
+	}
+
+	c.Data(http.StatusOK, contentType, out)
+}
@@ -0,0 +1,152 @@
+package events
+
+import (
+	"context"
+
+	"github.com/aboglioli/big-brother/infrastructure/db"
+	"github.com/aboglioli/big-brother/pkg/errors"
+	"github.com/aboglioli/big-brother/pkg/events"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// outbox is the "outbox" collection of a database. Aggregates of the
+// database are written with their events in a transaction, which needs
+// MongoDB to run as a replica set (see docker-compose.yml).
+type outbox struct {
+	database   *mongo.Database
+	collection *mongo.Collection
+}
+
+// NewOutbox fails if the server doesn't support transactions, because
+// events could be lost if they were stored after the changes.
+func NewOutbox(database string) (events.Outbox, error) {
+	path := "infrastructure/events/NewOutbox"
+
+	db, err := db.Get(database)
+	if err != nil {
+		return nil, err
+	}
+
+	if !supportsTransactions(db) {
+		return nil, errors.NewInternal("TRANSACTIONS_NOT_SUPPORTED").SetPath(path).SetMessage("MongoDB must run as a replica set")
+	}
+
+	return &outbox{
+		database:   db,
+		collection: db.Collection("outbox"),
+	}, nil
+}
+
+// Write runs fn, which changes an aggregate, and stores the entries in the
+// same transaction. Without entries, fn is run without transaction.
+func (o *outbox) Write(fn func(ctx context.Context) error, entries ...*events.Entry) error {
+	path := "infrastructure/events/outbox.Write"
+	ctx := context.Background()
+
+	if len(entries) == 0 {
+		return fn(ctx)
+	}
+
+	docs := make([]interface{}, len(entries))
+	for i, e := range entries {
+		docs[i] = e
+	}
+
+	session, err := o.database.Client().StartSession()
+	if err != nil {
+		return errors.NewInternal("START_SESSION").SetPath(path).SetRef(err)
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		if err := fn(sessCtx); err != nil {
+			return nil, err
+		}
+
+		if _, err := o.collection.InsertMany(sessCtx, docs); err != nil {
+			return nil, errors.NewInternal("INSERT_MANY").SetPath(path).SetRef(err)
+		}
+
+		return nil, nil
+	})
+
+	return err
+}
+
+func (o *outbox) FindPending(limit int, exclude []string) ([]*events.Entry, error) {
+	path := "infrastructure/events/outbox.FindPending"
+	ctx := context.Background()
+
+	opts := options.Find().SetSort(bson.D{{"createdAt", 1}, {"_id", 1}}).SetLimit(int64(limit))
+	if exclude == nil {
+		exclude = []string{}
+	}
+	filter := bson.M{
+		"publishedAt": nil,
+		"aggregate":   bson.M{"$nin": exclude},
+	}
+
+	cur, err := o.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, errors.NewInternal("FIND").SetPath(path).SetRef(err)
+	}
+	defer cur.Close(ctx)
+
+	var entries []*events.Entry
+	for cur.Next(ctx) {
+		var e events.Entry
+
+		if err := cur.Decode(&e); err != nil {
+			return nil, errors.NewInternal("CUR_DECODE").SetPath(path).SetRef(err)
+		}
+
+		entries = append(entries, &e)
+	}
+
+	if err := cur.Err(); err != nil {
+		return nil, errors.NewInternal("CUR").SetPath(path).SetRef(err)
+	}
+
+	return entries, nil
+}
+
+func (o *outbox) MarkPublished(e *events.Entry) error {
+	return o.update(e, "infrastructure/events/outbox.MarkPublished")
+}
+
+func (o *outbox) MarkFailed(e *events.Entry) error {
+	return o.update(e, "infrastructure/events/outbox.MarkFailed")
+}
+
+func (o *outbox) update(e *events.Entry, path string) error {
+	ctx := context.Background()
+
+	update := bson.M{
+		"$set": bson.M{
+			"attempts":      e.Attempts,
+			"lastError":     e.LastError,
+			"nextAttemptAt": e.NextAttemptAt,
+			"publishedAt":   e.PublishedAt,
+		},
+	}
+
+	if _, err := o.collection.UpdateOne(ctx, bson.M{"_id": e.ID}, update); err != nil {
+		return errors.NewInternal("UPDATE_ONE").SetPath(path).SetRef(err)
+	}
+
+	return nil
+}
+
+// supportsTransactions returns true if the server is a replica set member or
+// a mongos.
+func supportsTransactions(db *mongo.Database) bool {
+	var res bson.M
+	if err := db.RunCommand(context.Background(), bson.D{{"isMaster", 1}}).Decode(&res); err != nil {
+		return false
+	}
+
+	_, replicaSet := res["setName"]
+	return replicaSet || res["msg"] == "isdbgrid"
+}
@@ -115,9 +115,7 @@ func (m *manager) Publish(body interface{}, opts *events.Options) error {
 		opts.Key,
 		false,
 		false,
-		amqp.Publishing{
-			Body: b,
-		},
+		m.publishing(b),
 	); err != nil {
 		return errors.NewInternal("FAILED_TO_PUBLISH_MESSAGE").SetPath("infrastructure/events/manager.Publish").SetRef(err)
 	}
@@ -125,6 +123,36 @@ func (m *manager) Publish(body interface{}, opts *events.Options) error {
 	return nil
 }
 
+// publishing carries the event envelope of an encoded event as message
+// properties, so it can be traced without decoding the body.
+func (m *manager) publishing(b []byte) amqp.Publishing {
+	var e events.Event
+	if err := m.converter.Decode(b, &e); err != nil {
+		return amqp.Publishing{Body: b}
+	}
+
+	headers := amqp.Table{
+		"version": int32(e.Version),
+	}
+	if e.AggregateID != "" {
+		headers["aggregateId"] = e.AggregateID
+	}
+	if e.CausationID != "" {
+		headers["causationId"] = e.CausationID
+	}
+
+	return amqp.Publishing{
+		Headers:       headers,
+		ContentType:   "application/json",
+		CorrelationId: e.CorrelationID,
+		MessageId:     e.ID,
+		Timestamp:     e.OccurredAt,
+		Type:          e.Type,
+		AppId:         e.Producer,
+		Body:          b,
+	}
+}
+
 func (m *manager) Consume(opts *events.Options) (<-chan events.Message, error) {
 	path := "infrastructure/events/manager.Consume"
 
@@ -0,0 +1,172 @@
+package forecast
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/aboglioli/big-brother/forecast"
+	"github.com/aboglioli/big-brother/infrastructure/errors"
+	"github.com/aboglioli/big-brother/pkg/config"
+	"github.com/aboglioli/big-brother/pkg/events"
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+)
+
+// StartREST starts API server
+func StartREST(eventMgr events.Manager, serv forecast.Service) {
+	// Start Gin server
+	conf := config.Get()
+	server := gin.Default()
+
+	// CORS
+	corsConfig := cors.DefaultConfig()
+	corsConfig.AllowAllOrigins = true
+	server.Use(cors.New(corsConfig))
+
+	// Create context and define router
+	rest := &RESTContext{
+		forecastService: serv,
+		conf:            conf,
+	}
+
+	server.POST("/v1/forecast/:compositionId", rest.Forecast)
+	server.POST("/v1/forecast/:compositionId/reorder-point", rest.ReorderPoint)
+
+	server.Run(fmt.Sprintf(":%d", conf.Forecast.Port))
+}
+
+type RESTContext struct {
+	forecastService forecast.Service
+	conf            config.Configuration
+}
+
+func (r *RESTContext) authorize(c *gin.Context) bool {
+	if r.conf.AuthEnabled {
+		if err := validateAuthAndPermission(c, "forecast"); err != nil {
+			errors.Handle(c, err)
+			return false
+		}
+	}
+	return true
+}
+
+// Forecast forecasts the demand of a composition
+/**
+* @api {post} /v1/forecast/:compositionId Forecast
+* @apiName Forecast demand
+* @apiGroup Forecast
+*
+* @apiParam {String} compositionId Composition ID
+* @apiParam {String="MOVING_AVERAGE","EXPONENTIAL_SMOOTHING","SEASONAL"} [method=MOVING_AVERAGE] Forecasting method
+* @apiParam {String="DAY","WEEK","MONTH"} [period=MONTH] Period length
+* @apiParam {Number} [history=12] Complete periods used as history
+* @apiParam {Number} [periods=3] Periods to forecast
+* @apiParam {Number} [window=3] Periods averaged by moving averages
+* @apiParam {Number} [alpha=0.3] Weight of the last period in exponential smoothing
+* @apiParam {Number} [seasonLength] Periods per season: 7 days, 52 weeks or 12 months
+* @apiParam {Date} [date=now] Forecasts start at the period containing it
+*
+* @apiDescription The history is the quantity of delivered sales orders by
+* period. Accuracy compares the one-step-ahead forecasts ("fitted") of each
+* period of the history with the actual demand: mean absolute error (MAE),
+* mean absolute percentage error (MAPE) and root mean squared error (RMSE).
+*
+* @apiSuccessExample {json} Response
+* HTTP/1.1 200 OK
+* {
+*   "forecast": {
+*     "composition": "9dc9c429b9aa2a3c82801002",
+*     "method": "MOVING_AVERAGE",
+*     "period": "MONTH",
+*     "history": [
+*       {
+*         "start": "2019-09-01T00:00:00Z",
+*         "quantity": {
+*           "quantity": 40,
+*           "unit": "u"
+*         }
+*       }
+*     ],
+*     "fitted": [period data],
+*     "forecast": [
+*       {
+*         "start": "2019-12-01T00:00:00Z",
+*         "quantity": {
+*           "quantity": 50,
+*           "unit": "u"
+*         }
+*       }
+*     ],
+*     "accuracy": {
+*       "mae": 17,
+*       "mape": 44.667,
+*       "rmse": 17.464
+*     }
+*   }
+* }
+ */
+func (r *RESTContext) Forecast(c *gin.Context) {
+	if !r.authorize(c) {
+		return
+	}
+
+	var body forecast.Request
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	f, err := r.forecastService.Forecast(c.Param("compositionId"), &body)
+	if err != nil {
+		errors.Handle(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"forecast": f,
+	})
+}
+
+// ReorderPoint sets the reorder point of a composition from its forecast
+/**
+* @api {post} /v1/forecast/:compositionId/reorder-point ReorderPoint
+* @apiName Update reorder point from forecast
+* @apiGroup Forecast
+*
+* @apiParam {String} compositionId Composition ID
+*
+* @apiDescription Accepts the same parameters as Forecast. Sets the reorder
+* point to the minimum stock plus the average forecasted demand during the
+* lead time of the composition.
+*
+* @apiSuccessExample {json} Response
+* HTTP/1.1 200 OK
+* {
+*   "composition": composition data
+* }
+ */
+func (r *RESTContext) ReorderPoint(c *gin.Context) {
+	if !r.authorize(c) {
+		return
+	}
+
+	var body forecast.Request
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	comp, err := r.forecastService.UpdateReorderPoint(c.Param("compositionId"), &body)
+	if err != nil {
+		errors.Handle(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"composition": comp,
+	})
+}
@@ -0,0 +1,312 @@
+package invoice
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/aboglioli/big-brother/infrastructure/errors"
+	"github.com/aboglioli/big-brother/invoice"
+	"github.com/aboglioli/big-brother/pkg/config"
+	"github.com/aboglioli/big-brother/pkg/events"
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+)
+
+// StartREST starts API server
+func StartREST(eventMgr events.Manager, serv invoice.Service) {
+	// Start Gin server
+	conf := config.Get()
+	server := gin.Default()
+
+	// CORS
+	corsConfig := cors.DefaultConfig()
+	corsConfig.AllowAllOrigins = true
+	server.Use(cors.New(corsConfig))
+
+	// Create context and define router
+	rest := &RESTContext{
+		invoiceService: serv,
+		conf:           conf,
+	}
+
+	server.GET("/v1/invoice", rest.GetAll)
+	server.GET("/v1/invoice/:invoiceId", rest.GetByID)
+	server.POST("/v1/invoice", rest.Post)
+	server.DELETE("/v1/invoice/:invoiceId", rest.Delete)
+
+	server.POST("/v1/invoice/:invoiceId/issue", rest.Issue)
+	server.POST("/v1/invoice/:invoiceId/credit-note", rest.CreditNote)
+
+	server.Run(fmt.Sprintf(":%d", conf.Invoice.Port))
+}
+
+type RESTContext struct {
+	invoiceService invoice.Service
+	conf           config.Configuration
+}
+
+func (r *RESTContext) authorize(c *gin.Context) bool {
+	if r.conf.AuthEnabled {
+		if err := validateAuthAndPermission(c, "invoice"); err != nil {
+			errors.Handle(c, err)
+			return false
+		}
+	}
+	return true
+}
+
+// GetAll lists invoices
+/**
+* @api {get} /v1/invoice GetAll
+* @apiName List invoices
+* @apiGroup Invoice
+*
+* @apiParam {String} [customer] Customer ID. If empty, draft invoices are listed.
+*
+* @apiSuccessExample {json} Response
+* HTTP/1.1 200 OK
+* {
+*   "invoices": [invoice data]
+* }
+ */
+func (r *RESTContext) GetAll(c *gin.Context) {
+	if !r.authorize(c) {
+		return
+	}
+
+	var invoices []*invoice.Invoice
+	var err error
+	if customerID := c.Query("customer"); customerID != "" {
+		invoices, err = r.invoiceService.FindByCustomer(customerID)
+	} else {
+		invoices, err = r.invoiceService.FindDrafts()
+	}
+	if err != nil {
+		errors.Handle(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"invoices": invoices,
+	})
+}
+
+// GetByID finds an invoice by ID
+/**
+* @api {get} /v1/invoice/:invoiceId GetByID
+* @apiName Find invoice by ID
+* @apiGroup Invoice
+*
+* @apiParam {String} invoiceId Invoice ID
+*
+* @apiSuccessExample {json} Response
+* HTTP/1.1 200 OK
+* {
+*   "invoice": {
+*     "id": "5dd2a1f8b5b1a1f5d1e0f201",
+*     "type": "INVOICE",
+*     "status": "ISSUED",
+*     "number": 42,
+*     "order": "5dd2a1f8b5b1a1f5d1e0f0a1",
+*     "customer": "5dd2a1f8b5b1a1f5d1e0f0c1",
+*     "invoice": null,
+*     "reason": "",
+*     "lines": [
+*       {
+*         "composition": "9dc9c429b9aa2a3c82801001",
+*         "name": "Cheese",
+*         "category": "food",
+*         "quantity": {
+*           "quantity": 2,
+*           "unit": "kg"
+*         },
+*         "price": 300,
+*         "taxRate": 0.105,
+*         "tax": 31.5
+*       }
+*     ],
+*     "subtotal": 300,
+*     "tax": 31.5,
+*     "total": 331.5,
+*     "issuedAt": "2019-11-18T14:02:11.120Z",
+*     "createdAt": "2019-11-18T14:02:11.120Z",
+*     "updatedAt": "2019-11-18T14:02:11.120Z"
+*   }
+* }
+ */
+func (r *RESTContext) GetByID(c *gin.Context) {
+	if !r.authorize(c) {
+		return
+	}
+
+	inv, err := r.invoiceService.GetByID(c.Param("invoiceId"))
+	if err != nil {
+		errors.Handle(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"invoice": inv,
+	})
+}
+
+type postRequest struct {
+	Order string `json:"order" binding:"required"`
+}
+
+// Post creates a draft invoice from a delivered sales order
+/**
+* @api {post} /v1/invoice Post
+* @apiName Create invoice
+* @apiGroup Invoice
+*
+* @apiParam {String} order Delivered sales order ID
+*
+* @apiDescription Creates a draft invoice. Taxes are computed from the
+* composition categories. Numbers are assigned on issue.
+*
+* @apiSuccessExample {json} Response
+* HTTP/1.1 200 OK
+* {
+*   "invoice": invoice data,
+*   "status": "CREATED"
+* }
+ */
+func (r *RESTContext) Post(c *gin.Context) {
+	if !r.authorize(c) {
+		return
+	}
+
+	var body postRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	inv, err := r.invoiceService.CreateFromOrder(body.Order)
+	if err != nil {
+		errors.Handle(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "CREATED",
+		"invoice": inv,
+	})
+}
+
+// Delete discards a draft invoice
+/**
+* @api {delete} /v1/invoice/:invoiceId Delete
+* @apiName Discard invoice
+* @apiGroup Invoice
+*
+* @apiParam {String} invoiceId Invoice ID
+*
+* @apiDescription Only draft invoices can be discarded.
+*
+* @apiSuccessExample {json} Response
+* HTTP/1.1 200 OK
+* {
+*   "id": "5dd2a1f8b5b1a1f5d1e0f201",
+*   "status": "DELETED"
+* }
+ */
+func (r *RESTContext) Delete(c *gin.Context) {
+	if !r.authorize(c) {
+		return
+	}
+
+	id := c.Param("invoiceId")
+	if err := r.invoiceService.Discard(id); err != nil {
+		errors.Handle(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "DELETED",
+		"id":     id,
+	})
+}
+
+// Issue issues a draft invoice
+/**
+* @api {post} /v1/invoice/:invoiceId/issue Issue
+* @apiName Issue invoice
+* @apiGroup Invoice
+*
+* @apiParam {String} invoiceId Invoice ID
+*
+* @apiDescription Assigns the next number of the invoice type. Issued invoices
+* cannot be modified.
+*
+* @apiSuccessExample {json} Response
+* HTTP/1.1 200 OK
+* {
+*   "invoice": invoice data,
+*   "status": "ISSUED"
+* }
+ */
+func (r *RESTContext) Issue(c *gin.Context) {
+	if !r.authorize(c) {
+		return
+	}
+
+	inv, err := r.invoiceService.Issue(c.Param("invoiceId"))
+	if err != nil {
+		errors.Handle(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "ISSUED",
+		"invoice": inv,
+	})
+}
+
+// CreditNote creates a draft credit note of an issued invoice
+/**
+* @api {post} /v1/invoice/:invoiceId/credit-note CreditNote
+* @apiName Create credit note
+* @apiGroup Invoice
+*
+* @apiParam {String} invoiceId Issued invoice ID
+* @apiParam {Object[]} [lines] Credited compositions. If empty, the pending
+* quantity of every line is credited.
+* @apiParam {String} lines.composition Composition ID
+* @apiParam {Quantity} lines.quantity Quantity compatible with the invoiced one
+* @apiParam {String} [reason] Reason
+*
+* @apiSuccessExample {json} Response
+* HTTP/1.1 200 OK
+* {
+*   "invoice": credit note data,
+*   "status": "CREATED"
+* }
+ */
+func (r *RESTContext) CreditNote(c *gin.Context) {
+	if !r.authorize(c) {
+		return
+	}
+
+	var body invoice.CreditNoteRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	cn, err := r.invoiceService.CreateCreditNote(c.Param("invoiceId"), &body)
+	if err != nil {
+		errors.Handle(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "CREATED",
+		"invoice": cn,
+	})
+}
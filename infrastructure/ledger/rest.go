@@ -0,0 +1,496 @@
+package ledger
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/aboglioli/big-brother/infrastructure/errors"
+	"github.com/aboglioli/big-brother/ledger"
+	"github.com/aboglioli/big-brother/pkg/config"
+	"github.com/aboglioli/big-brother/pkg/events"
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+)
+
+// StartREST starts API server
+func StartREST(eventMgr events.Manager, serv ledger.Service) {
+	// Start Gin server
+	conf := config.Get()
+	server := gin.Default()
+
+	// CORS
+	corsConfig := cors.DefaultConfig()
+	corsConfig.AllowAllOrigins = true
+	server.Use(cors.New(corsConfig))
+
+	// Create context and define router
+	rest := &RESTContext{
+		ledgerService: serv,
+		conf:          conf,
+	}
+
+	server.GET("/v1/account", rest.GetAccounts)
+	server.GET("/v1/account/:code", rest.GetAccount)
+	server.POST("/v1/account", rest.PostAccount)
+	server.PUT("/v1/account/:code", rest.PutAccount)
+	server.GET("/v1/account/:code/statement", rest.Statement)
+
+	server.GET("/v1/journal", rest.GetEntries)
+	server.GET("/v1/journal/:entryId", rest.GetEntry)
+	server.POST("/v1/journal", rest.PostEntry)
+
+	server.GET("/v1/trial-balance", rest.TrialBalance)
+
+	server.Run(fmt.Sprintf(":%d", conf.Ledger.Port))
+}
+
+type RESTContext struct {
+	ledgerService ledger.Service
+	conf          config.Configuration
+}
+
+func (r *RESTContext) authorize(c *gin.Context) bool {
+	if r.conf.AuthEnabled {
+		if err := validateAuthAndPermission(c, "ledger"); err != nil {
+			errors.Handle(c, err)
+			return false
+		}
+	}
+	return true
+}
+
+// GetAccounts lists the chart of accounts
+/**
+* @api {get} /v1/account GetAccounts
+* @apiName List accounts
+* @apiGroup Ledger
+*
+* @apiSuccessExample {json} Response
+* HTTP/1.1 200 OK
+* {
+*   "accounts": [
+*     {
+*       "id": "5dd2a1f8b5b1a1f5d1e0f301",
+*       "code": "1100",
+*       "name": "Inventory",
+*       "type": "ASSET",
+*       "createdAt": "2019-11-18T14:02:11.120Z",
+*       "updatedAt": "2019-11-18T14:02:11.120Z"
+*     }
+*   ]
+* }
+ */
+func (r *RESTContext) GetAccounts(c *gin.Context) {
+	if !r.authorize(c) {
+		return
+	}
+
+	accounts, err := r.ledgerService.GetAccounts()
+	if err != nil {
+		errors.Handle(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"accounts": accounts,
+	})
+}
+
+// GetAccount finds an account by code
+/**
+* @api {get} /v1/account/:code GetAccount
+* @apiName Find account by code
+* @apiGroup Ledger
+*
+* @apiParam {String} code Account code
+*
+* @apiSuccessExample {json} Response
+* HTTP/1.1 200 OK
+* {
+*   "account": account data
+* }
+ */
+func (r *RESTContext) GetAccount(c *gin.Context) {
+	if !r.authorize(c) {
+		return
+	}
+
+	a, err := r.ledgerService.GetAccount(c.Param("code"))
+	if err != nil {
+		errors.Handle(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"account": a,
+	})
+}
+
+// PostAccount creates a new account
+/**
+* @api {post} /v1/account PostAccount
+* @apiName Create account
+* @apiGroup Ledger
+*
+* @apiParam {String} code Unique code
+* @apiParam {String} name Name
+* @apiParam {String="ASSET","LIABILITY","EQUITY","INCOME","EXPENSE"} type Type
+*
+* @apiSuccessExample {json} Response
+* HTTP/1.1 200 OK
+* {
+*   "account": account data,
+*   "status": "CREATED"
+* }
+ */
+func (r *RESTContext) PostAccount(c *gin.Context) {
+	if !r.authorize(c) {
+		return
+	}
+
+	var body ledger.CreateAccountRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	a, err := r.ledgerService.CreateAccount(&body)
+	if err != nil {
+		errors.Handle(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "CREATED",
+		"account": a,
+	})
+}
+
+// PutAccount renames an account
+/**
+* @api {put} /v1/account/:code PutAccount
+* @apiName Update account
+* @apiGroup Ledger
+*
+* @apiParam {String} code Account code
+* @apiParam {String} [name] Name
+*
+* @apiSuccessExample {json} Response
+* HTTP/1.1 200 OK
+* {
+*   "account": account data,
+*   "status": "UPDATED"
+* }
+ */
+func (r *RESTContext) PutAccount(c *gin.Context) {
+	if !r.authorize(c) {
+		return
+	}
+
+	var body ledger.UpdateAccountRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	a, err := r.ledgerService.UpdateAccount(c.Param("code"), &body)
+	if err != nil {
+		errors.Handle(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "UPDATED",
+		"account": a,
+	})
+}
+
+// Statement returns the entries of an account
+/**
+* @api {get} /v1/account/:code/statement Statement
+* @apiName Account statement
+* @apiGroup Ledger
+*
+* @apiParam {String} code Account code
+* @apiParam {Date} [from] Start date (RFC 3339 or YYYY-MM-DD)
+* @apiParam {Date} [to] End date (RFC 3339 or YYYY-MM-DD)
+*
+* @apiDescription Balances are positive in the normal side of the account:
+* debit for assets and expenses, credit for the rest.
+*
+* @apiSuccessExample {json} Response
+* HTTP/1.1 200 OK
+* {
+*   "statement": {
+*     "account": account data,
+*     "from": "2020-01-01T00:00:00Z",
+*     "to": null,
+*     "opening": 100,
+*     "lines": [
+*       {
+*         "entry": "5dd2a1f8b5b1a1f5d1e0f401",
+*         "date": "2020-01-02T00:00:00Z",
+*         "description": "Stock issue of 9dc9c429b9aa2a3c82801001",
+*         "debit": 0,
+*         "credit": 50,
+*         "balance": 50
+*       }
+*     ],
+*     "debit": 0,
+*     "credit": 50,
+*     "closing": 50
+*   }
+* }
+ */
+func (r *RESTContext) Statement(c *gin.Context) {
+	if !r.authorize(c) {
+		return
+	}
+
+	from, to, ok := dateRange(c)
+	if !ok {
+		return
+	}
+
+	st, err := r.ledgerService.Statement(c.Param("code"), from, to)
+	if err != nil {
+		errors.Handle(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"statement": st,
+	})
+}
+
+// GetEntries lists journal entries
+/**
+* @api {get} /v1/journal GetEntries
+* @apiName List journal entries
+* @apiGroup Ledger
+*
+* @apiParam {Date} [from] Start date (RFC 3339 or YYYY-MM-DD)
+* @apiParam {Date} [to] End date (RFC 3339 or YYYY-MM-DD)
+*
+* @apiSuccessExample {json} Response
+* HTTP/1.1 200 OK
+* {
+*   "entries": [entry data]
+* }
+ */
+func (r *RESTContext) GetEntries(c *gin.Context) {
+	if !r.authorize(c) {
+		return
+	}
+
+	from, to, ok := dateRange(c)
+	if !ok {
+		return
+	}
+
+	entries, err := r.ledgerService.FindEntries(from, to)
+	if err != nil {
+		errors.Handle(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"entries": entries,
+	})
+}
+
+// GetEntry finds a journal entry by ID
+/**
+* @api {get} /v1/journal/:entryId GetEntry
+* @apiName Find journal entry by ID
+* @apiGroup Ledger
+*
+* @apiParam {String} entryId Entry ID
+*
+* @apiSuccessExample {json} Response
+* HTTP/1.1 200 OK
+* {
+*   "entry": {
+*     "id": "5dd2a1f8b5b1a1f5d1e0f401",
+*     "date": "2020-01-02T00:00:00Z",
+*     "description": "Invoice 42",
+*     "source": "invoice",
+*     "reference": "5dd2a1f8b5b1a1f5d1e0f201",
+*     "lines": [
+*       {
+*         "account": "1200",
+*         "debit": 121,
+*         "credit": 0
+*       },
+*       {
+*         "account": "4100",
+*         "debit": 0,
+*         "credit": 100
+*       },
+*       {
+*         "account": "2300",
+*         "debit": 0,
+*         "credit": 21
+*       }
+*     ],
+*     "createdAt": "2020-01-02T00:00:00Z"
+*   }
+* }
+ */
+func (r *RESTContext) GetEntry(c *gin.Context) {
+	if !r.authorize(c) {
+		return
+	}
+
+	e, err := r.ledgerService.GetEntry(c.Param("entryId"))
+	if err != nil {
+		errors.Handle(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"entry": e,
+	})
+}
+
+// PostEntry posts a manual journal entry
+/**
+* @api {post} /v1/journal PostEntry
+* @apiName Post journal entry
+* @apiGroup Ledger
+*
+* @apiParam {Date} [date] Date. Defaults to now.
+* @apiParam {String} description Description
+* @apiParam {Object[]} lines Lines. Debits and credits must balance.
+* @apiParam {String} lines.account Account code
+* @apiParam {Number} [lines.debit] Debit
+* @apiParam {Number} [lines.credit] Credit
+*
+* @apiSuccessExample {json} Response
+* HTTP/1.1 200 OK
+* {
+*   "entry": entry data,
+*   "status": "CREATED"
+* }
+ */
+func (r *RESTContext) PostEntry(c *gin.Context) {
+	if !r.authorize(c) {
+		return
+	}
+
+	var body ledger.PostRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	e, err := r.ledgerService.Post(&body)
+	if err != nil {
+		errors.Handle(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "CREATED",
+		"entry":  e,
+	})
+}
+
+// TrialBalance returns the balances of all accounts
+/**
+* @api {get} /v1/trial-balance TrialBalance
+* @apiName Trial balance
+* @apiGroup Ledger
+*
+* @apiParam {Date} [to] Date (RFC 3339 or YYYY-MM-DD). Defaults to all entries.
+*
+* @apiSuccessExample {json} Response
+* HTTP/1.1 200 OK
+* {
+*   "trialBalance": {
+*     "date": null,
+*     "accounts": [
+*       {
+*         "account": "1100",
+*         "name": "Inventory",
+*         "type": "ASSET",
+*         "debit": 160,
+*         "credit": 115,
+*         "balance": 45
+*       }
+*     ],
+*     "debit": 507.6,
+*     "credit": 507.6
+*   }
+* }
+ */
+func (r *RESTContext) TrialBalance(c *gin.Context) {
+	if !r.authorize(c) {
+		return
+	}
+
+	_, to, ok := dateRange(c)
+	if !ok {
+		return
+	}
+
+	tb, err := r.ledgerService.TrialBalance(to)
+	if err != nil {
+		errors.Handle(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"trialBalance": tb,
+	})
+}
+
+// dateRange parses the optional "from" and "to" query parameters. Dates
+// without time include the whole "to" day. It responds with 400 on invalid
+// dates.
+func dateRange(c *gin.Context) (*time.Time, *time.Time, bool) {
+	from, err := parseDate(c.Query("from"), false)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return nil, nil, false
+	}
+
+	to, err := parseDate(c.Query("to"), true)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return nil, nil, false
+	}
+
+	return from, to, true
+}
+
+func parseDate(value string, endOfDay bool) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil
+	}
+
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return nil, err
+	}
+	if endOfDay {
+		t = t.Add(24*time.Hour - time.Nanosecond)
+	}
+
+	return &t, nil
+}
@@ -0,0 +1,151 @@
+package mrp
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/aboglioli/big-brother/infrastructure/errors"
+	"github.com/aboglioli/big-brother/mrp"
+	"github.com/aboglioli/big-brother/pkg/config"
+	"github.com/aboglioli/big-brother/pkg/events"
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+)
+
+// StartREST starts API server
+func StartREST(eventMgr events.Manager, serv mrp.Service) {
+	// Start Gin server
+	conf := config.Get()
+	server := gin.Default()
+
+	// CORS
+	corsConfig := cors.DefaultConfig()
+	corsConfig.AllowAllOrigins = true
+	server.Use(cors.New(corsConfig))
+
+	// Create context and define router
+	rest := &RESTContext{
+		mrpService: serv,
+		conf:       conf,
+	}
+
+	server.POST("/v1/mrp/run", rest.Run)
+
+	server.Run(fmt.Sprintf(":%d", conf.Mrp.Port))
+}
+
+type RESTContext struct {
+	mrpService mrp.Service
+	conf       config.Configuration
+}
+
+func (r *RESTContext) authorize(c *gin.Context) bool {
+	if r.conf.AuthEnabled {
+		if err := validateAuthAndPermission(c, "mrp"); err != nil {
+			errors.Handle(c, err)
+			return false
+		}
+	}
+	return true
+}
+
+// Run computes a material requirements plan
+/**
+* @api {post} /v1/mrp/run Run
+* @apiName Run MRP
+* @apiGroup MRP
+*
+* @apiParam {Date} [date=now] Plan date
+* @apiParam {Object[]} [demand] Forecast demand
+* @apiParam {String} demand.composition Composition ID
+* @apiParam {Quantity} demand.quantity Demanded quantity
+* @apiParam {Date} [demand.date=date] Date of the demand
+* @apiParam {Object[]} [forecasts] Compositions whose forecast is demanded
+* @apiParam {String} forecasts.composition Composition ID
+* @apiParam {String} [forecasts.method] Other forecast parameters, see Forecast
+* @apiParam {Boolean} [ignoreSalesOrders=false] Exclude open sales orders from demand
+*
+* @apiDescription Nets the demand against stock, safety stock (minimum stock)
+* and open purchase and production orders, level by level through the
+* composition dependencies. Planned orders are rounded up to the lot size and
+* released lead time days before they are due. Compositions with dependencies
+* are produced and the rest are purchased from their best supplier. Nothing is
+* persisted.
+*
+* @apiSuccessExample {json} Response
+* HTTP/1.1 200 OK
+* {
+*   "plan": {
+*     "date": "2019-12-02T00:00:00Z",
+*     "requirements": [
+*       {
+*         "composition": "9dc9c429b9aa2a3c82801002",
+*         "name": "Bread",
+*         "level": 0,
+*         "gross": {
+*           "quantity": 15,
+*           "unit": "u"
+*         },
+*         "onHand": {
+*           "quantity": 5,
+*           "unit": "u"
+*         },
+*         "scheduled": {
+*           "quantity": 4,
+*           "unit": "u"
+*         },
+*         "safetyStock": {
+*           "quantity": 2,
+*           "unit": "u"
+*         },
+*         "net": {
+*           "quantity": 8,
+*           "unit": "u"
+*         },
+*         "planned": {
+*           "quantity": 10,
+*           "unit": "u"
+*         }
+*       }
+*     ],
+*     "orders": [
+*       {
+*         "type": "PRODUCTION",
+*         "composition": "9dc9c429b9aa2a3c82801002",
+*         "supplier": null,
+*         "quantity": {
+*           "quantity": 10,
+*           "unit": "u"
+*         },
+*         "releaseDate": "2019-12-06T00:00:00Z",
+*         "dueDate": "2019-12-07T00:00:00Z",
+*         "level": 0,
+*         "late": false
+*       }
+*     ]
+*   }
+* }
+ */
+func (r *RESTContext) Run(c *gin.Context) {
+	if !r.authorize(c) {
+		return
+	}
+
+	var body mrp.RunRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	plan, err := r.mrpService.Run(&body)
+	if err != nil {
+		errors.Handle(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"plan": plan,
+	})
+}
@@ -0,0 +1,480 @@
+package pricing
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/aboglioli/big-brother/infrastructure/errors"
+	"github.com/aboglioli/big-brother/pkg/config"
+	"github.com/aboglioli/big-brother/pkg/events"
+	"github.com/aboglioli/big-brother/pricing"
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+)
+
+// StartREST starts API server
+func StartREST(eventMgr events.Manager, serv pricing.Service) {
+	// Start Gin server
+	conf := config.Get()
+	server := gin.Default()
+
+	// CORS
+	corsConfig := cors.DefaultConfig()
+	corsConfig.AllowAllOrigins = true
+	server.Use(cors.New(corsConfig))
+
+	// Create context and define router
+	rest := &RESTContext{
+		pricingService: serv,
+		conf:           conf,
+	}
+
+	server.GET("/v1/price-list", rest.GetAll)
+	server.GET("/v1/price-list/:priceListId", rest.GetByID)
+	server.POST("/v1/price-list", rest.Post)
+	server.PUT("/v1/price-list/:priceListId", rest.Put)
+	server.DELETE("/v1/price-list/:priceListId", rest.Delete)
+
+	server.PUT("/v1/price-list/:priceListId/entry", rest.SetEntry)
+	server.DELETE("/v1/price-list/:priceListId/entry/:entryId", rest.RemoveEntry)
+	server.GET("/v1/price-list/:priceListId/analysis", rest.Analyze)
+
+	server.GET("/v1/flagged-prices", rest.Flagged)
+	server.POST("/v1/quote", rest.Quote)
+
+	server.Run(fmt.Sprintf(":%d", conf.Pricing.Port))
+}
+
+type RESTContext struct {
+	pricingService pricing.Service
+	conf           config.Configuration
+}
+
+func (r *RESTContext) authorize(c *gin.Context) bool {
+	if r.conf.AuthEnabled {
+		if err := validateAuthAndPermission(c, "pricing"); err != nil {
+			errors.Handle(c, err)
+			return false
+		}
+	}
+	return true
+}
+
+// GetAll lists all price lists
+/**
+* @api {get} /v1/price-list GetAll
+* @apiName List price lists
+* @apiGroup Pricing
+*
+* @apiSuccessExample {json} Response
+* HTTP/1.1 200 OK
+* {
+*   "priceLists": [price list data]
+* }
+ */
+func (r *RESTContext) GetAll(c *gin.Context) {
+	if !r.authorize(c) {
+		return
+	}
+
+	priceLists, err := r.pricingService.GetAll()
+	if err != nil {
+		errors.Handle(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"priceLists": priceLists,
+	})
+}
+
+// GetByID finds a PriceList by ID
+/**
+* @api {get} /v1/price-list/:priceListId GetByID
+* @apiName Find price list by ID
+* @apiGroup Pricing
+*
+* @apiParam {String} priceListId Price list ID
+*
+* @apiSuccessExample {json} Response
+* HTTP/1.1 200 OK
+* {
+*   "priceList": {
+*     "id": "5dd2a1f8b5b1a1f5d1e0f101",
+*     "name": "Restaurants 2020",
+*     "group": "restaurants",
+*     "validFrom": "2020-01-01T00:00:00Z",
+*     "validTo": null,
+*     "entries": [
+*       {
+*         "id": "5dd2a1f8b5b1a1f5d1e0f102",
+*         "composition": "9dc9c429b9aa2a3c82801001",
+*         "quantity": {
+*           "quantity": 1,
+*           "unit": "kg"
+*         },
+*         "minQuantity": {
+*           "quantity": 10,
+*           "unit": "kg"
+*         },
+*         "price": 150,
+*         "lastCost": 95,
+*         "flagged": false,
+*         "flaggedAt": null
+*       }
+*     ],
+*     "createdAt": "2019-11-18T14:02:11.120Z",
+*     "updatedAt": "2019-11-18T14:02:11.120Z"
+*   }
+* }
+ */
+func (r *RESTContext) GetByID(c *gin.Context) {
+	if !r.authorize(c) {
+		return
+	}
+
+	pl, err := r.pricingService.GetByID(c.Param("priceListId"))
+	if err != nil {
+		errors.Handle(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"priceList": pl,
+	})
+}
+
+// Post creates a new PriceList
+/**
+* @api {post} /v1/price-list Post
+* @apiName Create price list
+* @apiGroup Pricing
+*
+* @apiParam {String} name Name
+* @apiParam {String} [group] Customer group. Empty for every customer.
+* @apiParam {Date} [validFrom] Start of validity
+* @apiParam {Date} [validTo] End of validity
+*
+* @apiSuccessExample {json} Response
+* HTTP/1.1 200 OK
+* {
+*   "priceList": price list data,
+*   "status": "CREATED"
+* }
+ */
+func (r *RESTContext) Post(c *gin.Context) {
+	if !r.authorize(c) {
+		return
+	}
+
+	var body pricing.CreateRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	pl, err := r.pricingService.Create(&body)
+	if err != nil {
+		errors.Handle(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":    "CREATED",
+		"priceList": pl,
+	})
+}
+
+// Put updates a PriceList
+/**
+* @api {put} /v1/price-list/:priceListId Put
+* @apiName Update price list
+* @apiGroup Pricing
+*
+* @apiParam {String} priceListId Price list ID
+* @apiParam {String} [name] Name
+* @apiParam {String} [group] Customer group
+* @apiParam {Date} [validFrom] Start of validity
+* @apiParam {Date} [validTo] End of validity
+*
+* @apiSuccessExample {json} Response
+* HTTP/1.1 200 OK
+* {
+*   "priceList": price list data,
+*   "status": "UPDATED"
+* }
+ */
+func (r *RESTContext) Put(c *gin.Context) {
+	if !r.authorize(c) {
+		return
+	}
+
+	var body pricing.UpdateRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	pl, err := r.pricingService.Update(c.Param("priceListId"), &body)
+	if err != nil {
+		errors.Handle(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":    "UPDATED",
+		"priceList": pl,
+	})
+}
+
+// Delete deletes a PriceList
+/**
+* @api {delete} /v1/price-list/:priceListId Delete
+* @apiName Delete price list
+* @apiGroup Pricing
+*
+* @apiParam {String} priceListId Price list ID
+*
+* @apiSuccessExample {json} Response
+* HTTP/1.1 200 OK
+* {
+*   "id": "5dd2a1f8b5b1a1f5d1e0f101",
+*   "status": "DELETED"
+* }
+ */
+func (r *RESTContext) Delete(c *gin.Context) {
+	if !r.authorize(c) {
+		return
+	}
+
+	id := c.Param("priceListId")
+	if err := r.pricingService.Delete(id); err != nil {
+		errors.Handle(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "DELETED",
+		"id":     id,
+	})
+}
+
+// SetEntry sets the price of a composition
+/**
+* @api {put} /v1/price-list/:priceListId/entry SetEntry
+* @apiName Set entry
+* @apiGroup Pricing
+*
+* @apiParam {String} priceListId Price list ID
+* @apiParam {String} composition Composition ID
+* @apiParam {Quantity} quantity Quantity the price refers to
+* @apiParam {Quantity} [minQuantity] Quantity break
+* @apiParam {Number} price Price for the given quantity
+*
+* @apiDescription Adds or replaces the entry of a composition with the same
+* quantity break. The entry is unflagged.
+*
+* @apiSuccessExample {json} Response
+* HTTP/1.1 200 OK
+* {
+*   "priceList": price list data,
+*   "status": "UPDATED"
+* }
+ */
+func (r *RESTContext) SetEntry(c *gin.Context) {
+	if !r.authorize(c) {
+		return
+	}
+
+	var body pricing.EntryRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	pl, err := r.pricingService.SetEntry(c.Param("priceListId"), &body)
+	if err != nil {
+		errors.Handle(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":    "UPDATED",
+		"priceList": pl,
+	})
+}
+
+// RemoveEntry removes an entry
+/**
+* @api {delete} /v1/price-list/:priceListId/entry/:entryId RemoveEntry
+* @apiName Remove entry
+* @apiGroup Pricing
+*
+* @apiParam {String} priceListId Price list ID
+* @apiParam {String} entryId Entry ID
+*
+* @apiSuccessExample {json} Response
+* HTTP/1.1 200 OK
+* {
+*   "priceList": price list data,
+*   "status": "UPDATED"
+* }
+ */
+func (r *RESTContext) RemoveEntry(c *gin.Context) {
+	if !r.authorize(c) {
+		return
+	}
+
+	pl, err := r.pricingService.RemoveEntry(c.Param("priceListId"), c.Param("entryId"))
+	if err != nil {
+		errors.Handle(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":    "UPDATED",
+		"priceList": pl,
+	})
+}
+
+// Analyze computes margin and markup of a price list
+/**
+* @api {get} /v1/price-list/:priceListId/analysis Analyze
+* @apiName Analyze price list
+* @apiGroup Pricing
+*
+* @apiParam {String} priceListId Price list ID
+*
+* @apiDescription Computes margin and markup of each entry against the
+* current composition cost.
+*
+* @apiSuccessExample {json} Response
+* HTTP/1.1 200 OK
+* {
+*   "analysis": [
+*     {
+*       "priceList": "5dd2a1f8b5b1a1f5d1e0f101",
+*       "entry": "5dd2a1f8b5b1a1f5d1e0f102",
+*       "composition": "9dc9c429b9aa2a3c82801001",
+*       "quantity": {
+*         "quantity": 1,
+*         "unit": "kg"
+*       },
+*       "minQuantity": {
+*         "quantity": 10,
+*         "unit": "kg"
+*       },
+*       "price": 150,
+*       "cost": 95,
+*       "margin": 0.367,
+*       "markup": 0.579,
+*       "flagged": false
+*     }
+*   ]
+* }
+ */
+func (r *RESTContext) Analyze(c *gin.Context) {
+	if !r.authorize(c) {
+		return
+	}
+
+	analysis, err := r.pricingService.Analyze(c.Param("priceListId"))
+	if err != nil {
+		errors.Handle(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"analysis": analysis,
+	})
+}
+
+// Flagged lists flagged entries
+/**
+* @api {get} /v1/flagged-prices Flagged
+* @apiName Flagged prices
+* @apiGroup Pricing
+*
+* @apiDescription Lists entries whose margin fell below the configured floor
+* after a cost increase.
+*
+* @apiSuccessExample {json} Response
+* HTTP/1.1 200 OK
+* {
+*   "analysis": [analysis data]
+* }
+ */
+func (r *RESTContext) Flagged(c *gin.Context) {
+	if !r.authorize(c) {
+		return
+	}
+
+	analysis, err := r.pricingService.FindFlagged()
+	if err != nil {
+		errors.Handle(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"analysis": analysis,
+	})
+}
+
+// Quote prices a quantity of a composition
+/**
+* @api {post} /v1/quote Quote
+* @apiName Quote
+* @apiGroup Pricing
+*
+* @apiParam {String} composition Composition ID
+* @apiParam {Quantity} quantity Quantity
+* @apiParam {String} [group] Customer group
+* @apiParam {Date} [date] Date. Defaults to now.
+*
+* @apiSuccessExample {json} Response
+* HTTP/1.1 200 OK
+* {
+*   "quote": {
+*     "priceList": "5dd2a1f8b5b1a1f5d1e0f101",
+*     "entry": "5dd2a1f8b5b1a1f5d1e0f102",
+*     "composition": "9dc9c429b9aa2a3c82801001",
+*     "quantity": {
+*       "quantity": 12,
+*       "unit": "kg"
+*     },
+*     "price": 1800,
+*     "cost": 1140,
+*     "margin": 0.367,
+*     "markup": 0.579
+*   }
+* }
+ */
+func (r *RESTContext) Quote(c *gin.Context) {
+	if !r.authorize(c) {
+		return
+	}
+
+	var body pricing.QuoteRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	quote, err := r.pricingService.Quote(&body)
+	if err != nil {
+		errors.Handle(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"quote": quote,
+	})
+}
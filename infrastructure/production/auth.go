@@ -0,0 +1,9 @@
+package production
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+func validateAuthAndPermission(c *gin.Context, perm string) error {
+	return nil
+}
@@ -0,0 +1,349 @@
+package production
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/aboglioli/big-brother/infrastructure/errors"
+	"github.com/aboglioli/big-brother/pkg/config"
+	"github.com/aboglioli/big-brother/pkg/events"
+	"github.com/aboglioli/big-brother/production"
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+)
+
+// StartREST starts API server
+func StartREST(eventMgr events.Manager, serv production.Service) {
+	// Start Gin server
+	conf := config.Get()
+	server := gin.Default()
+
+	// CORS
+	corsConfig := cors.DefaultConfig()
+	corsConfig.AllowAllOrigins = true
+	server.Use(cors.New(corsConfig))
+
+	// Create context and define router
+	rest := &RESTContext{
+		productionService: serv,
+		conf:              conf,
+	}
+
+	server.GET("/v1/production", rest.GetAll)
+	server.GET("/v1/production/:orderId", rest.GetByID)
+	server.POST("/v1/production", rest.Post)
+	server.POST("/v1/production/:orderId/release", rest.Release)
+	server.POST("/v1/production/:orderId/complete", rest.Complete)
+	server.POST("/v1/production/:orderId/cancel", rest.Cancel)
+
+	server.GET("/v1/work-center-load/:workCenterId", rest.Load)
+
+	server.Run(fmt.Sprintf(":%d", conf.Production.Port))
+}
+
+type RESTContext struct {
+	productionService production.Service
+	conf              config.Configuration
+}
+
+func (r *RESTContext) authorize(c *gin.Context) bool {
+	if r.conf.AuthEnabled {
+		if err := validateAuthAndPermission(c, "production"); err != nil {
+			errors.Handle(c, err)
+			return false
+		}
+	}
+	return true
+}
+
+// GetAll lists production orders
+/**
+* @api {get} /v1/production GetAll
+* @apiName List production orders
+* @apiGroup Production
+*
+* @apiParam {String} [composition] Composition ID. If empty, open orders are listed.
+*
+* @apiSuccessExample {json} Response
+* HTTP/1.1 200 OK
+* {
+*   "orders": [order data]
+* }
+ */
+func (r *RESTContext) GetAll(c *gin.Context) {
+	if !r.authorize(c) {
+		return
+	}
+
+	var orders []*production.Order
+	var err error
+	if compID := c.Query("composition"); compID != "" {
+		orders, err = r.productionService.FindByComposition(compID)
+	} else {
+		orders, err = r.productionService.FindOpen()
+	}
+	if err != nil {
+		errors.Handle(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"orders": orders,
+	})
+}
+
+// GetByID finds a production order by ID
+/**
+* @api {get} /v1/production/:orderId GetByID
+* @apiName Find production order by ID
+* @apiGroup Production
+*
+* @apiParam {String} orderId Order ID
+*
+* @apiSuccessExample {json} Response
+* HTTP/1.1 200 OK
+* {
+*   "order": {
+*     "id": "5de4f0a1b5b1a1f5d1e0f201",
+*     "composition": "9dc9c429b9aa2a3c82801002",
+*     "quantity": {
+*       "quantity": 10,
+*       "unit": "u"
+*     },
+*     "status": "COMPLETED",
+*     "dueDate": "2019-12-06T00:00:00Z",
+*     "lot": "5de4f0a1b5b1a1f5d1e0e010",
+*     "reference": "MRP 2019-12-02",
+*     "operations": [
+*       {
+*         "name": "Bake",
+*         "workCenter": "5de6a1c0b5b1a1f5d1e0f301",
+*         "hours": 2,
+*         "start": "2019-12-05T00:00:00Z",
+*         "end": "2019-12-05T00:00:00Z",
+*         "loads": [
+*           {
+*             "date": "2019-12-05T00:00:00Z",
+*             "hours": 2
+*           }
+*         ]
+*       }
+*     ],
+*     "releasedAt": "2019-12-05T08:00:12.021Z",
+*     "completedAt": "2019-12-05T17:31:40.110Z",
+*     "createdAt": "2019-12-02T10:21:03.511Z",
+*     "updatedAt": "2019-12-05T17:31:40.110Z"
+*   }
+* }
+ */
+func (r *RESTContext) GetByID(c *gin.Context) {
+	if !r.authorize(c) {
+		return
+	}
+
+	o, err := r.productionService.GetByID(c.Param("orderId"))
+	if err != nil {
+		errors.Handle(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"order": o,
+	})
+}
+
+// Post creates a production order
+/**
+* @api {post} /v1/production Post
+* @apiName Create production order
+* @apiGroup Production
+*
+* @apiParam {String} composition Composition ID. It must have dependencies.
+* @apiParam {Quantity} quantity Quantity to produce
+* @apiParam {Date} [startDate=now] Date from which operations are scheduled
+* @apiParam {Date} [dueDate] Due date
+* @apiParam {String} [reference] External reference
+*
+* @apiDescription If the composition has a routing, its operations are
+* scheduled in sequence using the daily capacity of each work center left by
+* the other open orders.
+*
+* @apiSuccessExample {json} Response
+* HTTP/1.1 200 OK
+* {
+*   "order": order data,
+*   "status": "CREATED"
+* }
+ */
+func (r *RESTContext) Post(c *gin.Context) {
+	if !r.authorize(c) {
+		return
+	}
+
+	var body production.CreateRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	o, err := r.productionService.Create(&body)
+	if err != nil {
+		errors.Handle(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "CREATED",
+		"order":  o,
+	})
+}
+
+// Release releases a planned production order
+/**
+* @api {post} /v1/production/:orderId/release Release
+* @apiName Release production order
+* @apiGroup Production
+*
+* @apiParam {String} orderId Order ID
+*
+* @apiSuccessExample {json} Response
+* HTTP/1.1 200 OK
+* {
+*   "order": order data,
+*   "status": "RELEASED"
+* }
+ */
+func (r *RESTContext) Release(c *gin.Context) {
+	if !r.authorize(c) {
+		return
+	}
+
+	o, err := r.productionService.Release(c.Param("orderId"))
+	if err != nil {
+		errors.Handle(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": o.Status,
+		"order":  o,
+	})
+}
+
+// Complete completes a released production order
+/**
+* @api {post} /v1/production/:orderId/complete Complete
+* @apiName Complete production order
+* @apiGroup Production
+*
+* @apiParam {String} orderId Order ID
+* @apiParam {String} [code] Lot code
+* @apiParam {Date} [expiresAt] Lot expiration date
+*
+* @apiDescription Produces a stock lot of the ordered quantity, consuming the
+* lots of the composition dependencies.
+*
+* @apiSuccessExample {json} Response
+* HTTP/1.1 200 OK
+* {
+*   "order": order data,
+*   "status": "COMPLETED"
+* }
+ */
+func (r *RESTContext) Complete(c *gin.Context) {
+	if !r.authorize(c) {
+		return
+	}
+
+	var body production.CompleteRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	o, err := r.productionService.Complete(c.Param("orderId"), &body)
+	if err != nil {
+		errors.Handle(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": o.Status,
+		"order":  o,
+	})
+}
+
+// Cancel cancels a production order
+/**
+* @api {post} /v1/production/:orderId/cancel Cancel
+* @apiName Cancel production order
+* @apiGroup Production
+*
+* @apiParam {String} orderId Order ID
+*
+* @apiDescription Only open orders can be cancelled.
+*
+* @apiSuccessExample {json} Response
+* HTTP/1.1 200 OK
+* {
+*   "order": order data,
+*   "status": "CANCELLED"
+* }
+ */
+func (r *RESTContext) Cancel(c *gin.Context) {
+	if !r.authorize(c) {
+		return
+	}
+
+	o, err := r.productionService.Cancel(c.Param("orderId"))
+	if err != nil {
+		errors.Handle(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": o.Status,
+		"order":  o,
+	})
+}
+
+// Load lists the hours scheduled in a work center
+/**
+* @api {get} /v1/work-center-load/:workCenterId Load
+* @apiName Work center load
+* @apiGroup Production
+*
+* @apiParam {String} workCenterId Work center ID
+*
+* @apiDescription Lists the hours scheduled by day by open orders.
+*
+* @apiSuccessExample {json} Response
+* HTTP/1.1 200 OK
+* {
+*   "loads": [
+*     {
+*       "date": "2019-12-05T00:00:00Z",
+*       "hours": 8
+*     }
+*   ]
+* }
+ */
+func (r *RESTContext) Load(c *gin.Context) {
+	if !r.authorize(c) {
+		return
+	}
+
+	loads, err := r.productionService.FindLoad(c.Param("workCenterId"))
+	if err != nil {
+		errors.Handle(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"loads": loads,
+	})
+}
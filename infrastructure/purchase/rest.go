@@ -0,0 +1,356 @@
+package purchase
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/aboglioli/big-brother/infrastructure/errors"
+	"github.com/aboglioli/big-brother/pkg/config"
+	"github.com/aboglioli/big-brother/pkg/events"
+	"github.com/aboglioli/big-brother/purchase"
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+)
+
+// StartREST starts API server
+func StartREST(eventMgr events.Manager, serv purchase.Service) {
+	// Start Gin server
+	conf := config.Get()
+	server := gin.Default()
+
+	// CORS
+	corsConfig := cors.DefaultConfig()
+	corsConfig.AllowAllOrigins = true
+	server.Use(cors.New(corsConfig))
+
+	// Create context and define router
+	rest := &RESTContext{
+		purchaseService: serv,
+		conf:            conf,
+	}
+
+	server.GET("/v1/purchase", rest.GetAll)
+	server.GET("/v1/purchase/:orderId", rest.GetByID)
+	server.POST("/v1/purchase", rest.Post)
+	server.POST("/v1/purchase/:orderId/send", rest.Send)
+	server.POST("/v1/purchase/:orderId/cancel", rest.Cancel)
+	server.POST("/v1/purchase/:orderId/receipt", rest.Receive)
+
+	server.POST("/v1/suggested-purchases", rest.Suggest)
+
+	server.Run(fmt.Sprintf(":%d", conf.Purchase.Port))
+}
+
+type RESTContext struct {
+	purchaseService purchase.Service
+	conf            config.Configuration
+}
+
+func (r *RESTContext) authorize(c *gin.Context) bool {
+	if r.conf.AuthEnabled {
+		if err := validateAuthAndPermission(c, "purchase"); err != nil {
+			errors.Handle(c, err)
+			return false
+		}
+	}
+	return true
+}
+
+// GetAll lists purchase orders
+/**
+* @api {get} /v1/purchase GetAll
+* @apiName List purchase orders
+* @apiGroup Purchase
+*
+* @apiParam {String} [supplier] Supplier ID. If empty, open orders are listed.
+*
+* @apiSuccessExample {json} Response
+* HTTP/1.1 200 OK
+* {
+*   "orders": [order data]
+* }
+ */
+func (r *RESTContext) GetAll(c *gin.Context) {
+	if !r.authorize(c) {
+		return
+	}
+
+	var orders []*purchase.Order
+	var err error
+	if supplierID := c.Query("supplier"); supplierID != "" {
+		orders, err = r.purchaseService.FindBySupplier(supplierID)
+	} else {
+		orders, err = r.purchaseService.FindOpen()
+	}
+	if err != nil {
+		errors.Handle(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"orders": orders,
+	})
+}
+
+// GetByID finds a purchase order by ID
+/**
+* @api {get} /v1/purchase/:orderId GetByID
+* @apiName Find purchase order by ID
+* @apiGroup Purchase
+*
+* @apiParam {String} orderId Order ID
+*
+* @apiSuccessExample {json} Response
+* HTTP/1.1 200 OK
+* {
+*   "order": {
+*     "id": "5dd3b0c2b5b1a1f5d1e0f101",
+*     "supplier": "5dd2a1f8b5b1a1f5d1e0f001",
+*     "status": "PARTIALLY_RECEIVED",
+*     "lines": [
+*       {
+*         "composition": "9dc9c429b9aa2a3c82801001",
+*         "quantity": {
+*           "quantity": 50,
+*           "unit": "kg"
+*         },
+*         "price": 900,
+*         "received": {
+*           "quantity": 20,
+*           "unit": "kg"
+*         }
+*       }
+*     ],
+*     "receipts": [
+*       {
+*         "id": "5dd3b0c2b5b1a1f5d1e0f102",
+*         "date": "2019-11-19T10:12:40.511Z",
+*         "lines": [
+*           {
+*             "composition": "9dc9c429b9aa2a3c82801001",
+*             "quantity": {
+*               "quantity": 20,
+*               "unit": "kg"
+*             },
+*             "lot": "5dd3b0c2b5b1a1f5d1e0e003"
+*           }
+*         ]
+*       }
+*     ],
+*     "reference": "",
+*     "suggested": false,
+*     "sentAt": "2019-11-18T16:40:02.101Z",
+*     "receivedAt": null,
+*     "createdAt": "2019-11-18T16:38:51.937Z",
+*     "updatedAt": "2019-11-19T10:12:40.511Z"
+*   }
+* }
+ */
+func (r *RESTContext) GetByID(c *gin.Context) {
+	if !r.authorize(c) {
+		return
+	}
+
+	o, err := r.purchaseService.GetByID(c.Param("orderId"))
+	if err != nil {
+		errors.Handle(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"order": o,
+	})
+}
+
+// Post creates a purchase order
+/**
+* @api {post} /v1/purchase Post
+* @apiName Create purchase order
+* @apiGroup Purchase
+*
+* @apiParam {String} supplier Supplier ID
+* @apiParam {Object[]} lines Ordered compositions
+* @apiParam {String} lines.composition Composition ID
+* @apiParam {Quantity} lines.quantity Ordered quantity
+* @apiParam {Number} [lines.price] Price of the ordered quantity. Defaults to supplier price list.
+* @apiParam {String} [reference] External reference
+*
+* @apiSuccessExample {json} Response
+* HTTP/1.1 200 OK
+* {
+*   "order": order data,
+*   "status": "CREATED"
+* }
+ */
+func (r *RESTContext) Post(c *gin.Context) {
+	if !r.authorize(c) {
+		return
+	}
+
+	var body purchase.CreateRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	o, err := r.purchaseService.Create(&body)
+	if err != nil {
+		errors.Handle(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "CREATED",
+		"order":  o,
+	})
+}
+
+// Send marks a purchase order as sent
+/**
+* @api {post} /v1/purchase/:orderId/send Send
+* @apiName Send purchase order
+* @apiGroup Purchase
+*
+* @apiParam {String} orderId Order ID
+*
+* @apiSuccessExample {json} Response
+* HTTP/1.1 200 OK
+* {
+*   "order": order data,
+*   "status": "SENT"
+* }
+ */
+func (r *RESTContext) Send(c *gin.Context) {
+	if !r.authorize(c) {
+		return
+	}
+
+	o, err := r.purchaseService.Send(c.Param("orderId"))
+	if err != nil {
+		errors.Handle(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": o.Status,
+		"order":  o,
+	})
+}
+
+// Cancel cancels a purchase order
+/**
+* @api {post} /v1/purchase/:orderId/cancel Cancel
+* @apiName Cancel purchase order
+* @apiGroup Purchase
+*
+* @apiParam {String} orderId Order ID
+*
+* @apiDescription Only orders without receipts can be cancelled.
+*
+* @apiSuccessExample {json} Response
+* HTTP/1.1 200 OK
+* {
+*   "order": order data,
+*   "status": "CANCELLED"
+* }
+ */
+func (r *RESTContext) Cancel(c *gin.Context) {
+	if !r.authorize(c) {
+		return
+	}
+
+	o, err := r.purchaseService.Cancel(c.Param("orderId"))
+	if err != nil {
+		errors.Handle(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": o.Status,
+		"order":  o,
+	})
+}
+
+// Receive records a goods receipt
+/**
+* @api {post} /v1/purchase/:orderId/receipt Receive
+* @apiName Receive purchase order
+* @apiGroup Purchase
+*
+* @apiParam {String} orderId Order ID
+* @apiParam {Date} [date=now] Receipt date
+* @apiParam {Object[]} lines Received compositions
+* @apiParam {String} lines.composition Composition ID
+* @apiParam {Quantity} lines.quantity Received quantity. Cannot exceed pending quantity.
+* @apiParam {String} [lines.code] Lot code
+* @apiParam {Date} [lines.expiresAt] Lot expiration date
+*
+* @apiDescription Creates a stock lot for each received line. The purchase
+* price becomes the cost of raw materials. Lines of previous receipts whose lot
+* couldn't be created are stocked first: send empty lines to only retry them.
+*
+* @apiSuccessExample {json} Response
+* HTTP/1.1 200 OK
+* {
+*   "order": order data,
+*   "status": "PARTIALLY_RECEIVED"
+* }
+ */
+func (r *RESTContext) Receive(c *gin.Context) {
+	if !r.authorize(c) {
+		return
+	}
+
+	var body purchase.ReceiveRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	o, err := r.purchaseService.Receive(c.Param("orderId"), &body)
+	if err != nil {
+		errors.Handle(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": o.Status,
+		"order":  o,
+	})
+}
+
+// Suggest creates purchase orders for compositions below reorder point
+/**
+* @api {post} /v1/suggested-purchases Suggest
+* @apiName Suggest purchase orders
+* @apiGroup Purchase
+*
+* @apiDescription Creates draft purchase orders, grouped by supplier, for
+* compositions below their reorder point. Compositions already in open orders
+* or without suppliers are skipped.
+*
+* @apiSuccessExample {json} Response
+* HTTP/1.1 200 OK
+* {
+*   "orders": [order data]
+* }
+ */
+func (r *RESTContext) Suggest(c *gin.Context) {
+	if !r.authorize(c) {
+		return
+	}
+
+	orders, err := r.purchaseService.Suggest()
+	if err != nil {
+		errors.Handle(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"orders": orders,
+	})
+}
@@ -0,0 +1,374 @@
+package quality
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/aboglioli/big-brother/infrastructure/errors"
+	"github.com/aboglioli/big-brother/pkg/config"
+	"github.com/aboglioli/big-brother/pkg/events"
+	"github.com/aboglioli/big-brother/quality"
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+)
+
+// StartREST starts API server
+func StartREST(eventMgr events.Manager, serv quality.Service) {
+	// Start Gin server
+	conf := config.Get()
+	server := gin.Default()
+
+	// CORS
+	corsConfig := cors.DefaultConfig()
+	corsConfig.AllowAllOrigins = true
+	server.Use(cors.New(corsConfig))
+
+	// Create context and define router
+	rest := &RESTContext{
+		qualityService: serv,
+		conf:           conf,
+	}
+
+	server.GET("/v1/quality-plan/:compositionId", rest.GetPlan)
+	server.PUT("/v1/quality-plan/:compositionId", rest.PutPlan)
+	server.DELETE("/v1/quality-plan/:compositionId", rest.DeletePlan)
+
+	server.GET("/v1/inspection", rest.GetInspections)
+	server.GET("/v1/inspection/:inspectionId", rest.GetInspection)
+	server.POST("/v1/inspection", rest.PostInspection)
+	server.POST("/v1/inspection/:inspectionId/results", rest.PostResults)
+
+	server.Run(fmt.Sprintf(":%d", conf.Quality.Port))
+}
+
+type RESTContext struct {
+	qualityService quality.Service
+	conf           config.Configuration
+}
+
+func (r *RESTContext) authorize(c *gin.Context) bool {
+	if r.conf.AuthEnabled {
+		if err := validateAuthAndPermission(c, "quality"); err != nil {
+			errors.Handle(c, err)
+			return false
+		}
+	}
+	return true
+}
+
+// GetPlan finds the quality control plan of a composition
+/**
+* @api {get} /v1/quality-plan/:compositionId GetPlan
+* @apiName Find quality plan by composition
+* @apiGroup Quality
+*
+* @apiParam {String} compositionId Composition ID
+*
+* @apiSuccessExample {json} Response
+* HTTP/1.1 200 OK
+* {
+*   "plan": {
+*     "id": "5de7c2a0b5b1a1f5d1e0f401",
+*     "composition": "9dc9c429b9aa2a3c82801001",
+*     "stages": ["RECEIPT"],
+*     "characteristics": [
+*       {
+*         "name": "moisture",
+*         "type": "MEASURE",
+*         "min": null,
+*         "max": {
+*           "quantity": 150,
+*           "unit": "g"
+*         }
+*       },
+*       {
+*         "name": "sealed",
+*         "type": "CHECK",
+*         "min": null,
+*         "max": null
+*       }
+*     ],
+*     "createdAt": "2019-12-04T10:02:11.340Z",
+*     "updatedAt": "2019-12-04T10:02:11.340Z"
+*   }
+* }
+ */
+func (r *RESTContext) GetPlan(c *gin.Context) {
+	if !r.authorize(c) {
+		return
+	}
+
+	p, err := r.qualityService.GetPlan(c.Param("compositionId"))
+	if err != nil {
+		errors.Handle(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"plan": p,
+	})
+}
+
+// PutPlan sets the quality control plan of a composition
+/**
+* @api {put} /v1/quality-plan/:compositionId PutPlan
+* @apiName Set quality plan of composition
+* @apiGroup Quality
+*
+* @apiParam {String} compositionId Composition ID
+* @apiParam {String[]} stages Inspected stages: RECEIPT, PRODUCTION
+* @apiParam {Object[]} characteristics Characteristics
+* @apiParam {String} characteristics.name Name
+* @apiParam {String} characteristics.type MEASURE or CHECK
+* @apiParam {Object} [characteristics.min] Lower limit of measures
+* @apiParam {Object} [characteristics.max] Upper limit of measures
+*
+* @apiDescription Creates or replaces the plan. Lots received or produced in
+* the inspected stages are inspected.
+*
+* @apiSuccessExample {json} Response
+* HTTP/1.1 200 OK
+* {
+*   "plan": plan data,
+*   "status": "UPDATED"
+* }
+ */
+func (r *RESTContext) PutPlan(c *gin.Context) {
+	if !r.authorize(c) {
+		return
+	}
+
+	var body quality.PlanRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	p, err := r.qualityService.SetPlan(c.Param("compositionId"), &body)
+	if err != nil {
+		errors.Handle(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "UPDATED",
+		"plan":   p,
+	})
+}
+
+// DeletePlan deletes the quality control plan of a composition
+/**
+* @api {delete} /v1/quality-plan/:compositionId DeletePlan
+* @apiName Delete quality plan of composition
+* @apiGroup Quality
+*
+* @apiParam {String} compositionId Composition ID
+*
+* @apiSuccessExample {json} Response
+* HTTP/1.1 200 OK
+* {
+*   "status": "DELETED"
+* }
+ */
+func (r *RESTContext) DeletePlan(c *gin.Context) {
+	if !r.authorize(c) {
+		return
+	}
+
+	if err := r.qualityService.DeletePlan(c.Param("compositionId")); err != nil {
+		errors.Handle(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "DELETED",
+	})
+}
+
+// GetInspections lists pending inspections or the inspections of a lot
+/**
+* @api {get} /v1/inspection GetInspections
+* @apiName List inspections
+* @apiGroup Quality
+*
+* @apiParam {String} [lot] Lot ID. Without it, pending inspections are listed.
+*
+* @apiSuccessExample {json} Response
+* HTTP/1.1 200 OK
+* {
+*   "inspections": [inspection data]
+* }
+ */
+func (r *RESTContext) GetInspections(c *gin.Context) {
+	if !r.authorize(c) {
+		return
+	}
+
+	var (
+		inspections []*quality.Inspection
+		err         error
+	)
+	if lotID := c.Query("lot"); lotID != "" {
+		inspections, err = r.qualityService.FindByLot(lotID)
+	} else {
+		inspections, err = r.qualityService.FindPending()
+	}
+	if err != nil {
+		errors.Handle(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"inspections": inspections,
+	})
+}
+
+// GetInspection finds an inspection by ID
+/**
+* @api {get} /v1/inspection/:inspectionId GetInspection
+* @apiName Find inspection by ID
+* @apiGroup Quality
+*
+* @apiParam {String} inspectionId Inspection ID
+*
+* @apiSuccessExample {json} Response
+* HTTP/1.1 200 OK
+* {
+*   "inspection": {
+*     "id": "5de7c2a0b5b1a1f5d1e0f410",
+*     "plan": "5de7c2a0b5b1a1f5d1e0f401",
+*     "composition": "9dc9c429b9aa2a3c82801001",
+*     "lot": "5de7c1f0b5b1a1f5d1e0f3f0",
+*     "stage": "RECEIPT",
+*     "status": "FAILED",
+*     "characteristics": [characteristic data],
+*     "results": [
+*       {
+*         "characteristic": "moisture",
+*         "value": {
+*           "quantity": 0.2,
+*           "unit": "kg"
+*         },
+*         "passed": null,
+*         "accepted": false
+*       }
+*     ],
+*     "notes": "",
+*     "inspectedAt": "2019-12-04T11:30:02.120Z",
+*     "createdAt": "2019-12-04T10:15:43.901Z",
+*     "updatedAt": "2019-12-04T11:30:02.120Z"
+*   }
+* }
+ */
+func (r *RESTContext) GetInspection(c *gin.Context) {
+	if !r.authorize(c) {
+		return
+	}
+
+	i, err := r.qualityService.GetInspection(c.Param("inspectionId"))
+	if err != nil {
+		errors.Handle(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"inspection": i,
+	})
+}
+
+type inspectionRequest struct {
+	Lot string `json:"lot" binding:"required"`
+}
+
+// PostInspection opens an inspection of a lot
+/**
+* @api {post} /v1/inspection PostInspection
+* @apiName Open inspection
+* @apiGroup Quality
+*
+* @apiParam {String} lot Lot ID
+*
+* @apiDescription Lots are inspected automatically when received or
+* produced. This opens an inspection manually, at the stage of the lot origin.
+*
+* @apiSuccessExample {json} Response
+* HTTP/1.1 200 OK
+* {
+*   "inspection": inspection data,
+*   "status": "CREATED"
+* }
+ */
+func (r *RESTContext) PostInspection(c *gin.Context) {
+	if !r.authorize(c) {
+		return
+	}
+
+	var body inspectionRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	i, err := r.qualityService.Open(body.Lot)
+	if err != nil {
+		errors.Handle(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":     "CREATED",
+		"inspection": i,
+	})
+}
+
+// PostResults records the results of an inspection
+/**
+* @api {post} /v1/inspection/:inspectionId/results PostResults
+* @apiName Record inspection results
+* @apiGroup Quality
+*
+* @apiParam {String} inspectionId Inspection ID
+* @apiParam {Object[]} results A result by characteristic
+* @apiParam {String} results.characteristic Characteristic name
+* @apiParam {Object} [results.value] Measured quantity
+* @apiParam {Boolean} [results.passed] Check result
+* @apiParam {String} [notes] Notes
+*
+* @apiDescription If any result is out of tolerance, the inspection fails and
+* the lot is blocked.
+*
+* @apiSuccessExample {json} Response
+* HTTP/1.1 200 OK
+* {
+*   "inspection": inspection data,
+*   "status": "RECORDED"
+* }
+ */
+func (r *RESTContext) PostResults(c *gin.Context) {
+	if !r.authorize(c) {
+		return
+	}
+
+	var body quality.RecordRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	i, err := r.qualityService.Record(c.Param("inspectionId"), &body)
+	if err != nil {
+		errors.Handle(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":     "RECORDED",
+		"inspection": i,
+	})
+}
@@ -0,0 +1,369 @@
+package routing
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/aboglioli/big-brother/infrastructure/errors"
+	"github.com/aboglioli/big-brother/pkg/config"
+	"github.com/aboglioli/big-brother/pkg/events"
+	"github.com/aboglioli/big-brother/routing"
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+)
+
+// StartREST starts API server
+func StartREST(eventMgr events.Manager, serv routing.Service) {
+	// Start Gin server
+	conf := config.Get()
+	server := gin.Default()
+
+	// CORS
+	corsConfig := cors.DefaultConfig()
+	corsConfig.AllowAllOrigins = true
+	server.Use(cors.New(corsConfig))
+
+	// Create context and define router
+	rest := &RESTContext{
+		routingService: serv,
+		conf:           conf,
+	}
+
+	server.GET("/v1/work-center", rest.GetWorkCenters)
+	server.GET("/v1/work-center/:workCenterId", rest.GetWorkCenter)
+	server.POST("/v1/work-center", rest.PostWorkCenter)
+	server.PUT("/v1/work-center/:workCenterId", rest.PutWorkCenter)
+	server.DELETE("/v1/work-center/:workCenterId", rest.DeleteWorkCenter)
+
+	server.GET("/v1/routing/:compositionId", rest.GetRouting)
+	server.PUT("/v1/routing/:compositionId", rest.PutRouting)
+	server.DELETE("/v1/routing/:compositionId", rest.DeleteRouting)
+
+	server.Run(fmt.Sprintf(":%d", conf.Routing.Port))
+}
+
+type RESTContext struct {
+	routingService routing.Service
+	conf           config.Configuration
+}
+
+func (r *RESTContext) authorize(c *gin.Context) bool {
+	if r.conf.AuthEnabled {
+		if err := validateAuthAndPermission(c, "routing"); err != nil {
+			errors.Handle(c, err)
+			return false
+		}
+	}
+	return true
+}
+
+// GetWorkCenters lists work centers
+/**
+* @api {get} /v1/work-center GetWorkCenters
+* @apiName List work centers
+* @apiGroup Routing
+*
+* @apiSuccessExample {json} Response
+* HTTP/1.1 200 OK
+* {
+*   "workCenters": [work center data]
+* }
+ */
+func (r *RESTContext) GetWorkCenters(c *gin.Context) {
+	if !r.authorize(c) {
+		return
+	}
+
+	workCenters, err := r.routingService.FindWorkCenters()
+	if err != nil {
+		errors.Handle(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"workCenters": workCenters,
+	})
+}
+
+// GetWorkCenter finds a work center by ID
+/**
+* @api {get} /v1/work-center/:workCenterId GetWorkCenter
+* @apiName Find work center by ID
+* @apiGroup Routing
+*
+* @apiParam {String} workCenterId Work center ID
+*
+* @apiSuccessExample {json} Response
+* HTTP/1.1 200 OK
+* {
+*   "workCenter": {
+*     "id": "5de6a1c0b5b1a1f5d1e0f301",
+*     "name": "Oven",
+*     "hourlyRate": 30,
+*     "capacity": 8,
+*     "createdAt": "2019-12-03T09:12:40.511Z",
+*     "updatedAt": "2019-12-03T09:12:40.511Z"
+*   }
+* }
+ */
+func (r *RESTContext) GetWorkCenter(c *gin.Context) {
+	if !r.authorize(c) {
+		return
+	}
+
+	w, err := r.routingService.GetWorkCenter(c.Param("workCenterId"))
+	if err != nil {
+		errors.Handle(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"workCenter": w,
+	})
+}
+
+// PostWorkCenter creates a work center
+/**
+* @api {post} /v1/work-center PostWorkCenter
+* @apiName Create work center
+* @apiGroup Routing
+*
+* @apiParam {String} name Name
+* @apiParam {Number} [hourlyRate] Labor cost per hour
+* @apiParam {Number} capacity Available hours per day (up to 24)
+*
+* @apiSuccessExample {json} Response
+* HTTP/1.1 200 OK
+* {
+*   "workCenter": work center data,
+*   "status": "CREATED"
+* }
+ */
+func (r *RESTContext) PostWorkCenter(c *gin.Context) {
+	if !r.authorize(c) {
+		return
+	}
+
+	var body routing.CreateWorkCenterRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	w, err := r.routingService.CreateWorkCenter(&body)
+	if err != nil {
+		errors.Handle(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":     "CREATED",
+		"workCenter": w,
+	})
+}
+
+// PutWorkCenter updates a work center
+/**
+* @api {put} /v1/work-center/:workCenterId PutWorkCenter
+* @apiName Update work center
+* @apiGroup Routing
+*
+* @apiParam {String} workCenterId Work center ID
+* @apiParam {String} [name] Name
+* @apiParam {Number} [hourlyRate] Labor cost per hour
+* @apiParam {Number} [capacity] Available hours per day (up to 24)
+*
+* @apiDescription If the hourly rate changes, the routing cost of the
+* compositions produced in the work center is updated.
+*
+* @apiSuccessExample {json} Response
+* HTTP/1.1 200 OK
+* {
+*   "workCenter": work center data,
+*   "status": "UPDATED"
+* }
+ */
+func (r *RESTContext) PutWorkCenter(c *gin.Context) {
+	if !r.authorize(c) {
+		return
+	}
+
+	var body routing.UpdateWorkCenterRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	w, err := r.routingService.UpdateWorkCenter(c.Param("workCenterId"), &body)
+	if err != nil {
+		errors.Handle(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":     "UPDATED",
+		"workCenter": w,
+	})
+}
+
+// DeleteWorkCenter deletes a work center
+/**
+* @api {delete} /v1/work-center/:workCenterId DeleteWorkCenter
+* @apiName Delete work center
+* @apiGroup Routing
+*
+* @apiParam {String} workCenterId Work center ID
+*
+* @apiDescription Work centers used by routings cannot be deleted.
+*
+* @apiSuccessExample {json} Response
+* HTTP/1.1 200 OK
+* {
+*   "status": "DELETED"
+* }
+ */
+func (r *RESTContext) DeleteWorkCenter(c *gin.Context) {
+	if !r.authorize(c) {
+		return
+	}
+
+	if err := r.routingService.DeleteWorkCenter(c.Param("workCenterId")); err != nil {
+		errors.Handle(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "DELETED",
+	})
+}
+
+// GetRouting finds the routing of a composition
+/**
+* @api {get} /v1/routing/:compositionId GetRouting
+* @apiName Find routing by composition
+* @apiGroup Routing
+*
+* @apiParam {String} compositionId Composition ID
+*
+* @apiSuccessExample {json} Response
+* HTTP/1.1 200 OK
+* {
+*   "routing": {
+*     "id": "5de6a1c0b5b1a1f5d1e0f310",
+*     "composition": "9dc9c429b9aa2a3c82801002",
+*     "operations": [
+*       {
+*         "name": "Mix",
+*         "workCenter": "5de6a1c0b5b1a1f5d1e0f302",
+*         "setup": 30,
+*         "run": 6
+*       },
+*       {
+*         "name": "Bake",
+*         "workCenter": "5de6a1c0b5b1a1f5d1e0f301",
+*         "setup": 0,
+*         "run": 12
+*       }
+*     ],
+*     "createdAt": "2019-12-03T09:20:11.104Z",
+*     "updatedAt": "2019-12-03T09:20:11.104Z"
+*   }
+* }
+ */
+func (r *RESTContext) GetRouting(c *gin.Context) {
+	if !r.authorize(c) {
+		return
+	}
+
+	route, err := r.routingService.GetRouting(c.Param("compositionId"))
+	if err != nil {
+		errors.Handle(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"routing": route,
+	})
+}
+
+// PutRouting sets the routing of a composition
+/**
+* @api {put} /v1/routing/:compositionId PutRouting
+* @apiName Set routing of composition
+* @apiGroup Routing
+*
+* @apiParam {String} compositionId Composition ID. It must have dependencies.
+* @apiParam {Object[]} operations Operations in order
+* @apiParam {String} operations.name Name
+* @apiParam {String} operations.workCenter Work center ID
+* @apiParam {Number} [operations.setup] Setup time in minutes, once per order
+* @apiParam {Number} [operations.run] Run time in minutes per unit
+*
+* @apiDescription Creates or replaces the routing. The labor cost per unit,
+* with setup time spread over the lot size, is set as the routing cost of the
+* composition and added to its cost.
+*
+* @apiSuccessExample {json} Response
+* HTTP/1.1 200 OK
+* {
+*   "routing": routing data,
+*   "status": "UPDATED"
+* }
+ */
+func (r *RESTContext) PutRouting(c *gin.Context) {
+	if !r.authorize(c) {
+		return
+	}
+
+	var body routing.RoutingRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	route, err := r.routingService.SetRouting(c.Param("compositionId"), &body)
+	if err != nil {
+		errors.Handle(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "UPDATED",
+		"routing": route,
+	})
+}
+
+// DeleteRouting deletes the routing of a composition
+/**
+* @api {delete} /v1/routing/:compositionId DeleteRouting
+* @apiName Delete routing of composition
+* @apiGroup Routing
+*
+* @apiParam {String} compositionId Composition ID
+*
+* @apiSuccessExample {json} Response
+* HTTP/1.1 200 OK
+* {
+*   "status": "DELETED"
+* }
+ */
+func (r *RESTContext) DeleteRouting(c *gin.Context) {
+	if !r.authorize(c) {
+		return
+	}
+
+	if err := r.routingService.DeleteRouting(c.Param("compositionId")); err != nil {
+		errors.Handle(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "DELETED",
+	})
+}
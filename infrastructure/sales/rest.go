@@ -0,0 +1,508 @@
+package sales
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/aboglioli/big-brother/infrastructure/errors"
+	"github.com/aboglioli/big-brother/pkg/config"
+	"github.com/aboglioli/big-brother/pkg/events"
+	"github.com/aboglioli/big-brother/sales"
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+)
+
+// StartREST starts API server
+func StartREST(eventMgr events.Manager, serv sales.Service) {
+	// Start Gin server
+	conf := config.Get()
+	server := gin.Default()
+
+	// CORS
+	corsConfig := cors.DefaultConfig()
+	corsConfig.AllowAllOrigins = true
+	server.Use(cors.New(corsConfig))
+
+	// Create context and define router
+	rest := &RESTContext{
+		salesService: serv,
+		conf:         conf,
+	}
+
+	server.GET("/v1/customer", rest.GetCustomers)
+	server.GET("/v1/customer/:customerId", rest.GetCustomer)
+	server.POST("/v1/customer", rest.PostCustomer)
+	server.PUT("/v1/customer/:customerId", rest.PutCustomer)
+	server.DELETE("/v1/customer/:customerId", rest.DeleteCustomer)
+
+	server.GET("/v1/sales", rest.GetOrders)
+	server.GET("/v1/sales/:orderId", rest.GetOrder)
+	server.POST("/v1/sales", rest.PostOrder)
+	server.POST("/v1/sales/:orderId/confirm", rest.ConfirmOrder)
+	server.POST("/v1/sales/:orderId/deliver", rest.DeliverOrder)
+	server.POST("/v1/sales/:orderId/cancel", rest.CancelOrder)
+
+	server.Run(fmt.Sprintf(":%d", conf.Sales.Port))
+}
+
+type RESTContext struct {
+	salesService sales.Service
+	conf         config.Configuration
+}
+
+func (r *RESTContext) authorize(c *gin.Context) bool {
+	if r.conf.AuthEnabled {
+		if err := validateAuthAndPermission(c, "sales"); err != nil {
+			errors.Handle(c, err)
+			return false
+		}
+	}
+	return true
+}
+
+// GetCustomers lists all customers
+/**
+* @api {get} /v1/customer GetCustomers
+* @apiName List customers
+* @apiGroup Sales
+*
+* @apiSuccessExample {json} Response
+* HTTP/1.1 200 OK
+* {
+*   "customers": [customer data]
+* }
+ */
+func (r *RESTContext) GetCustomers(c *gin.Context) {
+	if !r.authorize(c) {
+		return
+	}
+
+	customers, err := r.salesService.FindCustomers()
+	if err != nil {
+		errors.Handle(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"customers": customers,
+	})
+}
+
+// GetCustomer finds a Customer by ID
+/**
+* @api {get} /v1/customer/:customerId GetCustomer
+* @apiName Find customer by ID
+* @apiGroup Sales
+*
+* @apiParam {String} customerId Customer ID
+*
+* @apiSuccessExample {json} Response
+* HTTP/1.1 200 OK
+* {
+*   "customer": {
+*     "id": "5dd4c3a0b5b1a1f5d1e0f201",
+*     "name": "Restaurant",
+*     "group": "restaurants",
+*     "address": {
+*       "address": "Street 456",
+*       "country": "Argentina",
+*       "state": "Mendoza",
+*       "zipCode": "5500",
+*       "lat": 0,
+*       "lng": 0
+*     },
+*     "contact": {
+*       "email": "orders@restaurant.com",
+*       "mobile": "",
+*       "phone": ""
+*     },
+*     "social": {
+*       "facebook": "",
+*       "twitter": "",
+*       "instagram": ""
+*     },
+*     "createdAt": "2019-11-20T09:30:12.402Z",
+*     "updatedAt": "2019-11-20T09:30:12.402Z"
+*   }
+* }
+ */
+func (r *RESTContext) GetCustomer(c *gin.Context) {
+	if !r.authorize(c) {
+		return
+	}
+
+	customer, err := r.salesService.GetCustomer(c.Param("customerId"))
+	if err != nil {
+		errors.Handle(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"customer": customer,
+	})
+}
+
+// PostCustomer creates a new Customer
+/**
+* @api {post} /v1/customer PostCustomer
+* @apiName Create customer
+* @apiGroup Sales
+*
+* @apiParam {String} name Name
+* @apiParam {String} [group] Customer group for price lists
+* @apiParam {Address} [address] Address
+* @apiParam {Contact} [contact] Contact
+* @apiParam {Social} [social] Social networks
+*
+* @apiSuccessExample {json} Response
+* HTTP/1.1 200 OK
+* {
+*   "customer": customer data,
+*   "status": "CREATED"
+* }
+ */
+func (r *RESTContext) PostCustomer(c *gin.Context) {
+	if !r.authorize(c) {
+		return
+	}
+
+	var body sales.CreateCustomerRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	customer, err := r.salesService.CreateCustomer(&body)
+	if err != nil {
+		errors.Handle(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":   "CREATED",
+		"customer": customer,
+	})
+}
+
+// PutCustomer updates a Customer
+/**
+* @api {put} /v1/customer/:customerId PutCustomer
+* @apiName Update customer
+* @apiGroup Sales
+*
+* @apiParam {String} customerId Customer ID
+* @apiParam {String} [name] Name
+* @apiParam {String} [group] Customer group for price lists
+* @apiParam {Address} [address] Address
+* @apiParam {Contact} [contact] Contact
+* @apiParam {Social} [social] Social networks
+*
+* @apiSuccessExample {json} Response
+* HTTP/1.1 200 OK
+* {
+*   "customer": customer data,
+*   "status": "UPDATED"
+* }
+ */
+func (r *RESTContext) PutCustomer(c *gin.Context) {
+	if !r.authorize(c) {
+		return
+	}
+
+	var body sales.UpdateCustomerRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	customer, err := r.salesService.UpdateCustomer(c.Param("customerId"), &body)
+	if err != nil {
+		errors.Handle(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":   "UPDATED",
+		"customer": customer,
+	})
+}
+
+// DeleteCustomer deletes a Customer
+/**
+* @api {delete} /v1/customer/:customerId DeleteCustomer
+* @apiName Delete customer
+* @apiGroup Sales
+*
+* @apiParam {String} customerId Customer ID
+*
+* @apiSuccessExample {json} Response
+* HTTP/1.1 200 OK
+* {
+*   "id": "5dd4c3a0b5b1a1f5d1e0f201",
+*   "status": "DELETED"
+* }
+ */
+func (r *RESTContext) DeleteCustomer(c *gin.Context) {
+	if !r.authorize(c) {
+		return
+	}
+
+	id := c.Param("customerId")
+	if err := r.salesService.DeleteCustomer(id); err != nil {
+		errors.Handle(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "DELETED",
+		"id":     id,
+	})
+}
+
+// GetOrders lists sales orders
+/**
+* @api {get} /v1/sales GetOrders
+* @apiName List sales orders
+* @apiGroup Sales
+*
+* @apiParam {String} [customer] Customer ID. If empty, open orders are listed.
+*
+* @apiSuccessExample {json} Response
+* HTTP/1.1 200 OK
+* {
+*   "orders": [order data]
+* }
+ */
+func (r *RESTContext) GetOrders(c *gin.Context) {
+	if !r.authorize(c) {
+		return
+	}
+
+	var orders []*sales.Order
+	var err error
+	if customerID := c.Query("customer"); customerID != "" {
+		orders, err = r.salesService.FindOrdersByCustomer(customerID)
+	} else {
+		orders, err = r.salesService.FindOpenOrders()
+	}
+	if err != nil {
+		errors.Handle(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"orders": orders,
+	})
+}
+
+// GetOrder finds a sales order by ID
+/**
+* @api {get} /v1/sales/:orderId GetOrder
+* @apiName Find sales order by ID
+* @apiGroup Sales
+*
+* @apiParam {String} orderId Order ID
+*
+* @apiSuccessExample {json} Response
+* HTTP/1.1 200 OK
+* {
+*   "order": {
+*     "id": "5dd4c8e1b5b1a1f5d1e0f301",
+*     "customer": "5dd4c3a0b5b1a1f5d1e0f201",
+*     "status": "DELIVERED",
+*     "lines": [
+*       {
+*         "composition": "9dc9c429b9aa2a3c82801005",
+*         "quantity": {
+*           "quantity": 3000,
+*           "unit": "g"
+*         },
+*         "price": 300,
+*         "lots": [
+*           {
+*             "lot": "5dd3b0c2b5b1a1f5d1e0e009",
+*             "composition": "9dc9c429b9aa2a3c82801005",
+*             "quantity": {
+*               "quantity": 3000,
+*               "unit": "g"
+*             }
+*           }
+*         ]
+*       }
+*     ],
+*     "reference": "",
+*     "confirmedAt": "2019-11-20T09:45:10.112Z",
+*     "deliveredAt": "2019-11-20T15:02:44.870Z",
+*     "createdAt": "2019-11-20T09:40:01.003Z",
+*     "updatedAt": "2019-11-20T15:02:44.870Z"
+*   }
+* }
+ */
+func (r *RESTContext) GetOrder(c *gin.Context) {
+	if !r.authorize(c) {
+		return
+	}
+
+	o, err := r.salesService.GetOrder(c.Param("orderId"))
+	if err != nil {
+		errors.Handle(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"order": o,
+	})
+}
+
+// PostOrder creates a sales order
+/**
+* @api {post} /v1/sales PostOrder
+* @apiName Create sales order
+* @apiGroup Sales
+*
+* @apiParam {String} customer Customer ID
+* @apiParam {Object[]} lines Sold compositions
+* @apiParam {String} lines.composition Composition ID
+* @apiParam {Quantity} lines.quantity Quantity in any unit compatible with composition unit
+* @apiParam {Number} [lines.price] Price of the quantity. Defaults to the
+* customer group price lists.
+* @apiParam {String} [reference] External reference
+*
+* @apiSuccessExample {json} Response
+* HTTP/1.1 200 OK
+* {
+*   "order": order data,
+*   "status": "CREATED"
+* }
+ */
+func (r *RESTContext) PostOrder(c *gin.Context) {
+	if !r.authorize(c) {
+		return
+	}
+
+	var body sales.CreateOrderRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	o, err := r.salesService.CreateOrder(&body)
+	if err != nil {
+		errors.Handle(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "CREATED",
+		"order":  o,
+	})
+}
+
+// ConfirmOrder confirms a sales order
+/**
+* @api {post} /v1/sales/:orderId/confirm ConfirmOrder
+* @apiName Confirm sales order
+* @apiGroup Sales
+*
+* @apiParam {String} orderId Order ID
+*
+* @apiDescription Reserves stock for each line of the order.
+*
+* @apiSuccessExample {json} Response
+* HTTP/1.1 200 OK
+* {
+*   "order": order data,
+*   "status": "CONFIRMED"
+* }
+ */
+func (r *RESTContext) ConfirmOrder(c *gin.Context) {
+	if !r.authorize(c) {
+		return
+	}
+
+	o, err := r.salesService.ConfirmOrder(c.Param("orderId"))
+	if err != nil {
+		errors.Handle(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": o.Status,
+		"order":  o,
+	})
+}
+
+// DeliverOrder delivers a sales order
+/**
+* @api {post} /v1/sales/:orderId/deliver DeliverOrder
+* @apiName Deliver sales order
+* @apiGroup Sales
+*
+* @apiParam {String} orderId Order ID
+*
+* @apiDescription Issues the reserved stock first-expired-first-out.
+*
+* @apiSuccessExample {json} Response
+* HTTP/1.1 200 OK
+* {
+*   "order": order data,
+*   "status": "DELIVERED"
+* }
+ */
+func (r *RESTContext) DeliverOrder(c *gin.Context) {
+	if !r.authorize(c) {
+		return
+	}
+
+	o, err := r.salesService.DeliverOrder(c.Param("orderId"))
+	if err != nil {
+		errors.Handle(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": o.Status,
+		"order":  o,
+	})
+}
+
+// CancelOrder cancels a sales order
+/**
+* @api {post} /v1/sales/:orderId/cancel CancelOrder
+* @apiName Cancel sales order
+* @apiGroup Sales
+*
+* @apiParam {String} orderId Order ID
+*
+* @apiDescription Cancels a draft or confirmed order. Reserved stock is
+* released.
+*
+* @apiSuccessExample {json} Response
+* HTTP/1.1 200 OK
+* {
+*   "order": order data,
+*   "status": "CANCELLED"
+* }
+ */
+func (r *RESTContext) CancelOrder(c *gin.Context) {
+	if !r.authorize(c) {
+		return
+	}
+
+	o, err := r.salesService.CancelOrder(c.Param("orderId"))
+	if err != nil {
+		errors.Handle(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": o.Status,
+		"order":  o,
+	})
+}
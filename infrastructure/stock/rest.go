@@ -0,0 +1,723 @@
+package stock
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/aboglioli/big-brother/infrastructure/errors"
+	"github.com/aboglioli/big-brother/pkg/config"
+	"github.com/aboglioli/big-brother/pkg/events"
+	"github.com/aboglioli/big-brother/stock"
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+)
+
+// StartREST starts API server
+func StartREST(eventMgr events.Manager, serv stock.Service) {
+	// Start Gin server
+	conf := config.Get()
+	server := gin.Default()
+
+	// CORS
+	corsConfig := cors.DefaultConfig()
+	corsConfig.AllowAllOrigins = true
+	server.Use(cors.New(corsConfig))
+
+	// Create context and define router
+	rest := &RESTContext{
+		stockService: serv,
+		conf:         conf,
+	}
+
+	server.GET("/v1/lot/:lotId", rest.GetLot)
+	server.GET("/v1/lot/:lotId/components", rest.LotComponents)
+	server.GET("/v1/lot/:lotId/contained-in", rest.LotsContaining)
+	server.POST("/v1/lot/:lotId/block", rest.BlockLot)
+
+	server.GET("/v1/stock/:compositionId/lots", rest.LotsByComposition)
+	server.GET("/v1/stock/:compositionId/movements", rest.MovementsByComposition)
+	server.POST("/v1/stock/receipt", rest.Receive)
+	server.POST("/v1/stock/production", rest.Produce)
+	server.POST("/v1/stock/issue", rest.Issue)
+	server.POST("/v1/stock/block-expired", rest.BlockExpired)
+
+	server.GET("/v1/count", rest.OpenCounts)
+	server.GET("/v1/count/:countId", rest.GetCount)
+	server.POST("/v1/count", rest.OpenCount)
+	server.POST("/v1/count/:countId/record", rest.RecordCount)
+	server.POST("/v1/count/:countId/approve", rest.ApproveCount)
+	server.POST("/v1/count/:countId/cancel", rest.CancelCount)
+
+	server.Run(fmt.Sprintf(":%d", conf.Stock.Port))
+}
+
+type RESTContext struct {
+	stockService stock.Service
+	conf         config.Configuration
+}
+
+func (r *RESTContext) authorize(c *gin.Context) bool {
+	if r.conf.AuthEnabled {
+		if err := validateAuthAndPermission(c, "stock"); err != nil {
+			errors.Handle(c, err)
+			return false
+		}
+	}
+	return true
+}
+
+// GetLot finds a Lot by ID
+/**
+* @api {get} /v1/lot/:lotId GetLot
+* @apiName Find lot by ID
+* @apiGroup Stock
+*
+* @apiParam {String} lotId Lot ID
+*
+* @apiSuccessExample {json} Response
+* HTTP/1.1 200 OK
+* {
+*   "lot": {
+*     "id": "5dd0c1b6b5b1a1f5d1e0e001",
+*     "code": "F-001",
+*     "composition": "9dc9c429b9aa2a3c82801001",
+*     "origin": "RECEIPT",
+*     "quantity": {
+*       "quantity": 25,
+*       "unit": "kg"
+*     },
+*     "remaining": {
+*       "quantity": 12.5,
+*       "unit": "kg"
+*     },
+*     "components": [],
+*     "date": "2019-11-11T22:15:59.301Z",
+*     "expiresAt": "2019-12-11T00:00:00.000Z",
+*     "blocked": false,
+*     "createdAt": "2019-11-11T22:15:59.301Z",
+*     "updatedAt": "2019-11-15T01:35:19.024Z"
+*   }
+* }
+ */
+func (r *RESTContext) GetLot(c *gin.Context) {
+	if !r.authorize(c) {
+		return
+	}
+
+	lot, err := r.stockService.GetLot(c.Param("lotId"))
+	if err != nil {
+		errors.Handle(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"lot": lot,
+	})
+}
+
+// LotComponents lists all lots consumed to produce a lot
+/**
+* @api {get} /v1/lot/:lotId/components LotComponents
+* @apiName Lot components
+* @apiGroup Stock
+*
+* @apiParam {String} lotId Lot ID
+*
+* @apiDescription Backward traceability: lists all lots (raw materials and
+* intermediate products) consumed to produce the lot.
+*
+* @apiSuccessExample {json} Response
+* HTTP/1.1 200 OK
+* {
+*   "lots": [lot data]
+* }
+ */
+func (r *RESTContext) LotComponents(c *gin.Context) {
+	if !r.authorize(c) {
+		return
+	}
+
+	lots, err := r.stockService.FindLotComponents(c.Param("lotId"))
+	if err != nil {
+		errors.Handle(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"lots": lots,
+	})
+}
+
+// LotsContaining lists all lots produced using a lot
+/**
+* @api {get} /v1/lot/:lotId/contained-in LotsContaining
+* @apiName Lots containing
+* @apiGroup Stock
+*
+* @apiParam {String} lotId Lot ID
+*
+* @apiDescription Forward traceability: lists all lots (intermediate and
+* finished products) that contain the lot.
+*
+* @apiSuccessExample {json} Response
+* HTTP/1.1 200 OK
+* {
+*   "lots": [lot data]
+* }
+ */
+func (r *RESTContext) LotsContaining(c *gin.Context) {
+	if !r.authorize(c) {
+		return
+	}
+
+	lots, err := r.stockService.FindLotsContaining(c.Param("lotId"))
+	if err != nil {
+		errors.Handle(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"lots": lots,
+	})
+}
+
+// BlockLot blocks a lot
+/**
+* @api {post} /v1/lot/:lotId/block BlockLot
+* @apiName Block lot
+* @apiGroup Stock
+*
+* @apiParam {String} lotId Lot ID
+*
+* @apiDescription Blocks a lot. Blocked lots cannot be consumed.
+*
+* @apiSuccessExample {json} Response
+* HTTP/1.1 200 OK
+* {
+*   "lot": lot data,
+*   "status": "BLOCKED"
+* }
+ */
+func (r *RESTContext) BlockLot(c *gin.Context) {
+	if !r.authorize(c) {
+		return
+	}
+
+	lot, err := r.stockService.BlockLot(c.Param("lotId"))
+	if err != nil {
+		errors.Handle(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "BLOCKED",
+		"lot":    lot,
+	})
+}
+
+// LotsByComposition lists lots of a composition
+/**
+* @api {get} /v1/stock/:compositionId/lots LotsByComposition
+* @apiName Lots by composition
+* @apiGroup Stock
+*
+* @apiParam {String} compositionId Composition ID
+*
+* @apiDescription Lists all lots of a composition sorted first-expired-first-out.
+*
+* @apiSuccessExample {json} Response
+* HTTP/1.1 200 OK
+* {
+*   "lots": [lot data]
+* }
+ */
+func (r *RESTContext) LotsByComposition(c *gin.Context) {
+	if !r.authorize(c) {
+		return
+	}
+
+	lots, err := r.stockService.FindLotsByComposition(c.Param("compositionId"))
+	if err != nil {
+		errors.Handle(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"lots": lots,
+	})
+}
+
+// MovementsByComposition lists stock movements of a composition
+/**
+* @api {get} /v1/stock/:compositionId/movements MovementsByComposition
+* @apiName Movements by composition
+* @apiGroup Stock
+*
+* @apiParam {String} compositionId Composition ID
+*
+* @apiSuccessExample {json} Response
+* HTTP/1.1 200 OK
+* {
+*   "movements": [
+*     {
+*       "id": "5dd0c1b6b5b1a1f5d1e0e101",
+*       "type": "RECEIPT",
+*       "composition": "9dc9c429b9aa2a3c82801001",
+*       "lot": "5dd0c1b6b5b1a1f5d1e0e001",
+*       "quantity": {
+*         "quantity": 25,
+*         "unit": "kg"
+*       },
+*       "incoming": true,
+*       "cost": 2500,
+*       "reference": "",
+*       "createdAt": "2019-11-11T22:15:59.301Z"
+*     }
+*   ]
+* }
+ */
+func (r *RESTContext) MovementsByComposition(c *gin.Context) {
+	if !r.authorize(c) {
+		return
+	}
+
+	movements, err := r.stockService.FindMovementsByComposition(c.Param("compositionId"))
+	if err != nil {
+		errors.Handle(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"movements": movements,
+	})
+}
+
+// Receive creates a lot from a receipt
+/**
+* @api {post} /v1/stock/receipt Receive
+* @apiName Receive
+* @apiGroup Stock
+*
+* @apiParam {String} composition Composition ID
+* @apiParam {String} [code] Lot code
+* @apiParam {Quantity} quantity Received quantity. Any unit convertible to the composition unit.
+* @apiParam {Date} [date=now] Receipt date
+* @apiParam {Date} [expiresAt] Expiration date
+* @apiParam {String} [reference] External reference
+*
+* @apiSuccessExample {json} Response
+* HTTP/1.1 200 OK
+* {
+*   "lot": lot data,
+*   "status": "CREATED"
+* }
+ */
+func (r *RESTContext) Receive(c *gin.Context) {
+	if !r.authorize(c) {
+		return
+	}
+
+	var body stock.ReceiveRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	lot, err := r.stockService.Receive(&body)
+	if err != nil {
+		errors.Handle(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "CREATED",
+		"lot":    lot,
+	})
+}
+
+// Produce creates a lot consuming dependency lots
+/**
+* @api {post} /v1/stock/production Produce
+* @apiName Produce
+* @apiGroup Stock
+*
+* @apiParam {String} composition Composition ID
+* @apiParam {String} [code] Lot code
+* @apiParam {Quantity} quantity Produced quantity. Compatible with composition unit.
+* @apiParam {Date} [expiresAt] Expiration date
+* @apiParam {String} [reference] External reference
+*
+* @apiDescription Produces a new lot. Lots of every dependency are consumed
+* first-expired-first-out. Blocked and expired lots are never consumed.
+*
+* @apiSuccessExample {json} Response
+* HTTP/1.1 200 OK
+* {
+*   "lot": lot data,
+*   "status": "CREATED"
+* }
+ */
+func (r *RESTContext) Produce(c *gin.Context) {
+	if !r.authorize(c) {
+		return
+	}
+
+	var body stock.ProduceRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	lot, err := r.stockService.Produce(&body)
+	if err != nil {
+		errors.Handle(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "CREATED",
+		"lot":    lot,
+	})
+}
+
+// Issue takes stock out of a composition
+/**
+* @api {post} /v1/stock/issue Issue
+* @apiName Issue
+* @apiGroup Stock
+*
+* @apiParam {String} composition Composition ID
+* @apiParam {Quantity} quantity Issued quantity. Compatible with composition unit.
+* @apiParam {String} [reference] External reference
+*
+* @apiSuccessExample {json} Response
+* HTTP/1.1 200 OK
+* {
+*   "lots": [
+*     {
+*       "lot": "5dd0c1b6b5b1a1f5d1e0e001",
+*       "composition": "9dc9c429b9aa2a3c82801001",
+*       "quantity": {
+*         "quantity": 2,
+*         "unit": "kg"
+*       }
+*     }
+*   ],
+*   "status": "ISSUED"
+* }
+ */
+func (r *RESTContext) Issue(c *gin.Context) {
+	if !r.authorize(c) {
+		return
+	}
+
+	var body stock.IssueRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	lots, err := r.stockService.Issue(&body)
+	if err != nil {
+		errors.Handle(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "ISSUED",
+		"lots":   lots,
+	})
+}
+
+// BlockExpired blocks all expired lots
+/**
+* @api {post} /v1/stock/block-expired BlockExpired
+* @apiName Block expired
+* @apiGroup Stock
+*
+* @apiSuccessExample {json} Response
+* HTTP/1.1 200 OK
+* {
+*   "lots": [lot data],
+*   "status": "BLOCKED"
+* }
+ */
+func (r *RESTContext) BlockExpired(c *gin.Context) {
+	if !r.authorize(c) {
+		return
+	}
+
+	lots, err := r.stockService.BlockExpired()
+	if err != nil {
+		errors.Handle(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "BLOCKED",
+		"lots":   lots,
+	})
+}
+
+// OpenCounts lists open stock counts
+/**
+* @api {get} /v1/count OpenCounts
+* @apiName Open counts
+* @apiGroup Stock
+*
+* @apiSuccessExample {json} Response
+* HTTP/1.1 200 OK
+* {
+*   "counts": [count data]
+* }
+ */
+func (r *RESTContext) OpenCounts(c *gin.Context) {
+	if !r.authorize(c) {
+		return
+	}
+
+	counts, err := r.stockService.FindOpenCounts()
+	if err != nil {
+		errors.Handle(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"counts": counts,
+	})
+}
+
+// GetCount finds a stock count by ID
+/**
+* @api {get} /v1/count/:countId GetCount
+* @apiName Find count by ID
+* @apiGroup Stock
+*
+* @apiParam {String} countId Count ID
+*
+* @apiSuccessExample {json} Response
+* HTTP/1.1 200 OK
+* {
+*   "count": {
+*     "id": "5dd0c1b6b5b1a1f5d1e0e201",
+*     "status": "OPEN",
+*     "locations": ["A", "B"],
+*     "lines": [
+*       {
+*         "composition": "9dc9c429b9aa2a3c82801001",
+*         "system": {
+*           "quantity": 10,
+*           "unit": "kg"
+*         },
+*         "counts": [
+*           {
+*             "location": "A",
+*             "quantity": {
+*               "quantity": 4,
+*               "unit": "kg"
+*             }
+*           },
+*           {
+*             "location": "B",
+*             "quantity": {
+*               "quantity": 5500,
+*               "unit": "g"
+*             }
+*           }
+*         ],
+*         "counted": {
+*           "quantity": 9.5,
+*           "unit": "kg"
+*         },
+*         "variance": {
+*           "quantity": -0.5,
+*           "unit": "kg"
+*         },
+*         "cost": -50,
+*         "movement": null
+*       }
+*     ],
+*     "approvedAt": null,
+*     "createdAt": "2019-11-11T22:15:59.301Z",
+*     "updatedAt": "2019-11-15T01:35:19.024Z"
+*   }
+* }
+ */
+func (r *RESTContext) GetCount(c *gin.Context) {
+	if !r.authorize(c) {
+		return
+	}
+
+	count, err := r.stockService.GetCount(c.Param("countId"))
+	if err != nil {
+		errors.Handle(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count": count,
+	})
+}
+
+// OpenCount opens a stock count session
+/**
+* @api {post} /v1/count OpenCount
+* @apiName Open count
+* @apiGroup Stock
+*
+* @apiParam {[]String} compositions Composition IDs to be counted
+* @apiParam {[]String} [locations] Locations to be counted
+*
+* @apiDescription Opens a stock count session. System stock of every
+* composition is taken when the session is opened.
+*
+* @apiSuccessExample {json} Response
+* HTTP/1.1 200 OK
+* {
+*   "count": count data,
+*   "status": "CREATED"
+* }
+ */
+func (r *RESTContext) OpenCount(c *gin.Context) {
+	if !r.authorize(c) {
+		return
+	}
+
+	var body stock.OpenCountRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	count, err := r.stockService.OpenCount(&body)
+	if err != nil {
+		errors.Handle(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "CREATED",
+		"count":  count,
+	})
+}
+
+// RecordCount records a counted quantity
+/**
+* @api {post} /v1/count/:countId/record RecordCount
+* @apiName Record count
+* @apiGroup Stock
+*
+* @apiParam {String} countId Count ID
+* @apiParam {String} composition Composition ID
+* @apiParam {String} [location] Location
+* @apiParam {Quantity} quantity Counted quantity. Any unit compatible with composition unit.
+*
+* @apiDescription Records the counted quantity of a composition in a location.
+* Counting the same location again replaces the previous quantity.
+*
+* @apiSuccessExample {json} Response
+* HTTP/1.1 200 OK
+* {
+*   "count": count data,
+*   "status": "RECORDED"
+* }
+ */
+func (r *RESTContext) RecordCount(c *gin.Context) {
+	if !r.authorize(c) {
+		return
+	}
+
+	var body stock.RecordCountRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	count, err := r.stockService.RecordCount(c.Param("countId"), &body)
+	if err != nil {
+		errors.Handle(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "RECORDED",
+		"count":  count,
+	})
+}
+
+// ApproveCount approves a count and posts its variances
+/**
+* @api {post} /v1/count/:countId/approve ApproveCount
+* @apiName Approve count
+* @apiGroup Stock
+*
+* @apiParam {String} countId Count ID
+*
+* @apiDescription Posts the variance of every counted composition as an
+* adjustment movement valued at the composition cost.
+*
+* @apiSuccessExample {json} Response
+* HTTP/1.1 200 OK
+* {
+*   "count": count data,
+*   "status": "APPROVED"
+* }
+ */
+func (r *RESTContext) ApproveCount(c *gin.Context) {
+	if !r.authorize(c) {
+		return
+	}
+
+	count, err := r.stockService.ApproveCount(c.Param("countId"))
+	if err != nil {
+		errors.Handle(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "APPROVED",
+		"count":  count,
+	})
+}
+
+// CancelCount cancels an open count
+/**
+* @api {post} /v1/count/:countId/cancel CancelCount
+* @apiName Cancel count
+* @apiGroup Stock
+*
+* @apiParam {String} countId Count ID
+*
+* @apiSuccessExample {json} Response
+* HTTP/1.1 200 OK
+* {
+*   "count": count data,
+*   "status": "CANCELLED"
+* }
+ */
+func (r *RESTContext) CancelCount(c *gin.Context) {
+	if !r.authorize(c) {
+		return
+	}
+
+	count, err := r.stockService.CancelCount(c.Param("countId"))
+	if err != nil {
+		errors.Handle(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "CANCELLED",
+		"count":  count,
+	})
+}
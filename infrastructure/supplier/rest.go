@@ -0,0 +1,436 @@
+package supplier
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/aboglioli/big-brother/infrastructure/errors"
+	"github.com/aboglioli/big-brother/pkg/config"
+	"github.com/aboglioli/big-brother/pkg/events"
+	"github.com/aboglioli/big-brother/supplier"
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+)
+
+// StartREST starts API server
+func StartREST(eventMgr events.Manager, serv supplier.Service) {
+	// Start Gin server
+	conf := config.Get()
+	server := gin.Default()
+
+	// CORS
+	corsConfig := cors.DefaultConfig()
+	corsConfig.AllowAllOrigins = true
+	server.Use(cors.New(corsConfig))
+
+	// Create context and define router
+	rest := &RESTContext{
+		supplierService: serv,
+		conf:            conf,
+	}
+
+	server.GET("/v1/supplier", rest.GetAll)
+	server.GET("/v1/supplier/:supplierId", rest.GetByID)
+	server.POST("/v1/supplier", rest.Post)
+	server.PUT("/v1/supplier/:supplierId", rest.Put)
+	server.DELETE("/v1/supplier/:supplierId", rest.Delete)
+
+	server.PUT("/v1/supplier/:supplierId/price", rest.SetPrice)
+	server.DELETE("/v1/supplier/:supplierId/price/:compositionId", rest.RemovePrice)
+
+	server.GET("/v1/prices/:compositionId", rest.PricesByComposition)
+	server.POST("/v1/prices/:compositionId/update-cost", rest.UpdateCompositionCost)
+
+	server.Run(fmt.Sprintf(":%d", conf.Supplier.Port))
+}
+
+type RESTContext struct {
+	supplierService supplier.Service
+	conf            config.Configuration
+}
+
+func (r *RESTContext) authorize(c *gin.Context) bool {
+	if r.conf.AuthEnabled {
+		if err := validateAuthAndPermission(c, "supplier"); err != nil {
+			errors.Handle(c, err)
+			return false
+		}
+	}
+	return true
+}
+
+// GetAll lists all suppliers
+/**
+* @api {get} /v1/supplier GetAll
+* @apiName List suppliers
+* @apiGroup Supplier
+*
+* @apiSuccessExample {json} Response
+* HTTP/1.1 200 OK
+* {
+*   "suppliers": [supplier data]
+* }
+ */
+func (r *RESTContext) GetAll(c *gin.Context) {
+	if !r.authorize(c) {
+		return
+	}
+
+	suppliers, err := r.supplierService.GetAll()
+	if err != nil {
+		errors.Handle(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"suppliers": suppliers,
+	})
+}
+
+// GetByID finds a Supplier by ID
+/**
+* @api {get} /v1/supplier/:supplierId GetByID
+* @apiName Find supplier by ID
+* @apiGroup Supplier
+*
+* @apiParam {String} supplierId Supplier ID
+*
+* @apiSuccessExample {json} Response
+* HTTP/1.1 200 OK
+* {
+*   "supplier": {
+*     "id": "5dd2a1f8b5b1a1f5d1e0f001",
+*     "name": "Mill",
+*     "prices": [
+*       {
+*         "composition": "9dc9c429b9aa2a3c82801001",
+*         "price": 450,
+*         "quantity": {
+*           "quantity": 25,
+*           "unit": "kg"
+*         },
+*         "preferred": true,
+*         "updatedAt": "2019-11-18T14:02:11.120Z"
+*       }
+*     ],
+*     "address": {
+*       "address": "Street 123",
+*       "country": "Argentina",
+*       "state": "Mendoza",
+*       "zipCode": "5500",
+*       "lat": 0,
+*       "lng": 0
+*     },
+*     "contact": {
+*       "email": "sales@mill.com",
+*       "mobile": "",
+*       "phone": ""
+*     },
+*     "social": {
+*       "facebook": "",
+*       "twitter": "",
+*       "instagram": ""
+*     },
+*     "createdAt": "2019-11-18T14:02:11.120Z",
+*     "updatedAt": "2019-11-18T14:02:11.120Z"
+*   }
+* }
+ */
+func (r *RESTContext) GetByID(c *gin.Context) {
+	if !r.authorize(c) {
+		return
+	}
+
+	s, err := r.supplierService.GetByID(c.Param("supplierId"))
+	if err != nil {
+		errors.Handle(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"supplier": s,
+	})
+}
+
+// Post creates a new Supplier
+/**
+* @api {post} /v1/supplier Post
+* @apiName Create supplier
+* @apiGroup Supplier
+*
+* @apiParam {String} name Name
+* @apiParam {Address} [address] Address
+* @apiParam {Contact} [contact] Contact
+* @apiParam {Social} [social] Social networks
+*
+* @apiSuccessExample {json} Response
+* HTTP/1.1 200 OK
+* {
+*   "supplier": supplier data,
+*   "status": "CREATED"
+* }
+ */
+func (r *RESTContext) Post(c *gin.Context) {
+	if !r.authorize(c) {
+		return
+	}
+
+	var body supplier.CreateRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	s, err := r.supplierService.Create(&body)
+	if err != nil {
+		errors.Handle(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":   "CREATED",
+		"supplier": s,
+	})
+}
+
+// Put updates a Supplier
+/**
+* @api {put} /v1/supplier/:supplierId Put
+* @apiName Update supplier
+* @apiGroup Supplier
+*
+* @apiParam {String} supplierId Supplier ID
+* @apiParam {String} [name] Name
+* @apiParam {Address} [address] Address
+* @apiParam {Contact} [contact] Contact
+* @apiParam {Social} [social] Social networks
+*
+* @apiSuccessExample {json} Response
+* HTTP/1.1 200 OK
+* {
+*   "supplier": supplier data,
+*   "status": "UPDATED"
+* }
+ */
+func (r *RESTContext) Put(c *gin.Context) {
+	if !r.authorize(c) {
+		return
+	}
+
+	var body supplier.UpdateRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	s, err := r.supplierService.Update(c.Param("supplierId"), &body)
+	if err != nil {
+		errors.Handle(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":   "UPDATED",
+		"supplier": s,
+	})
+}
+
+// Delete deletes a Supplier
+/**
+* @api {delete} /v1/supplier/:supplierId Delete
+* @apiName Delete supplier
+* @apiGroup Supplier
+*
+* @apiParam {String} supplierId Supplier ID
+*
+* @apiDescription Disables a supplier. Raw material costs are recalculated
+* from the remaining suppliers.
+*
+* @apiSuccessExample {json} Response
+* HTTP/1.1 200 OK
+* {
+*   "id": "5dd2a1f8b5b1a1f5d1e0f001",
+*   "status": "DELETED"
+* }
+ */
+func (r *RESTContext) Delete(c *gin.Context) {
+	if !r.authorize(c) {
+		return
+	}
+
+	id := c.Param("supplierId")
+	if err := r.supplierService.Delete(id); err != nil {
+		errors.Handle(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "DELETED",
+		"id":     id,
+	})
+}
+
+// SetPrice sets the price of a composition
+/**
+* @api {put} /v1/supplier/:supplierId/price SetPrice
+* @apiName Set price
+* @apiGroup Supplier
+*
+* @apiParam {String} supplierId Supplier ID
+* @apiParam {String} composition Composition ID
+* @apiParam {Number} price Price for the given quantity
+* @apiParam {Quantity} quantity Purchase unit. Any unit convertible to the composition unit (liquids can be priced by weight using the composition density).
+* @apiParam {Boolean} [preferred=false] Preferred supplier for the composition
+*
+* @apiDescription Adds or replaces a price in the supplier price list. The
+* cost of raw materials is updated from the preferred or cheapest price.
+*
+* @apiSuccessExample {json} Response
+* HTTP/1.1 200 OK
+* {
+*   "supplier": supplier data,
+*   "status": "UPDATED"
+* }
+ */
+func (r *RESTContext) SetPrice(c *gin.Context) {
+	if !r.authorize(c) {
+		return
+	}
+
+	var body supplier.PriceRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	s, err := r.supplierService.SetPrice(c.Param("supplierId"), &body)
+	if err != nil {
+		errors.Handle(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":   "UPDATED",
+		"supplier": s,
+	})
+}
+
+// RemovePrice removes the price of a composition
+/**
+* @api {delete} /v1/supplier/:supplierId/price/:compositionId RemovePrice
+* @apiName Remove price
+* @apiGroup Supplier
+*
+* @apiParam {String} supplierId Supplier ID
+* @apiParam {String} compositionId Composition ID
+*
+* @apiSuccessExample {json} Response
+* HTTP/1.1 200 OK
+* {
+*   "supplier": supplier data,
+*   "status": "UPDATED"
+* }
+ */
+func (r *RESTContext) RemovePrice(c *gin.Context) {
+	if !r.authorize(c) {
+		return
+	}
+
+	s, err := r.supplierService.RemovePrice(c.Param("supplierId"), c.Param("compositionId"))
+	if err != nil {
+		errors.Handle(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":   "UPDATED",
+		"supplier": s,
+	})
+}
+
+// PricesByComposition lists suppliers of a composition
+/**
+* @api {get} /v1/prices/:compositionId PricesByComposition
+* @apiName Prices by composition
+* @apiGroup Supplier
+*
+* @apiParam {String} compositionId Composition ID
+*
+* @apiDescription Lists the suppliers of a composition and the best price
+* (preferred or cheapest) if any.
+*
+* @apiSuccessExample {json} Response
+* HTTP/1.1 200 OK
+* {
+*   "suppliers": [supplier data],
+*   "best": {
+*     "supplier": "5dd2a1f8b5b1a1f5d1e0f001",
+*     "price": price data
+*   }
+* }
+ */
+func (r *RESTContext) PricesByComposition(c *gin.Context) {
+	if !r.authorize(c) {
+		return
+	}
+
+	compID := c.Param("compositionId")
+	suppliers, err := r.supplierService.FindByComposition(compID)
+	if err != nil {
+		errors.Handle(c, err)
+		return
+	}
+
+	res := gin.H{
+		"suppliers": suppliers,
+		"best":      nil,
+	}
+
+	if s, p, err := r.supplierService.BestPrice(compID); err == nil {
+		res["best"] = gin.H{
+			"supplier": s.ID,
+			"price":    p,
+		}
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+// UpdateCompositionCost updates the cost of a raw material
+/**
+* @api {post} /v1/prices/:compositionId/update-cost UpdateCompositionCost
+* @apiName Update composition cost
+* @apiGroup Supplier
+*
+* @apiParam {String} compositionId Composition ID
+*
+* @apiDescription Recalculates the cost of a raw material from its supplier
+* prices. Compositions with dependencies are not modified.
+*
+* @apiSuccessExample {json} Response
+* HTTP/1.1 200 OK
+* {
+*   "composition": composition data
+* }
+ */
+func (r *RESTContext) UpdateCompositionCost(c *gin.Context) {
+	if !r.authorize(c) {
+		return
+	}
+
+	comp, err := r.supplierService.UpdateCompositionCost(c.Param("compositionId"))
+	if err != nil {
+		errors.Handle(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"composition": comp,
+	})
+}
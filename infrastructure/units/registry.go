@@ -0,0 +1,26 @@
+package units
+
+import (
+	"time"
+
+	"github.com/aboglioli/big-brother/pkg/config"
+	"github.com/aboglioli/big-brother/pkg/unit"
+	"github.com/aboglioli/big-brother/units"
+)
+
+// UseRegistry makes quantities use the default units and the units stored in
+// the database, cached for the configured time.
+func UseRegistry() (*unit.Registry, error) {
+	repo, err := units.NewRepository()
+	if err != nil {
+		return nil, err
+	}
+
+	registry, err := unit.NewRegistry(repo, time.Duration(config.Get().UnitsTTL)*time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	unit.SetRepository(registry)
+	return registry, nil
+}
@@ -0,0 +1,191 @@
+package units
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/aboglioli/big-brother/infrastructure/errors"
+	"github.com/aboglioli/big-brother/pkg/config"
+	"github.com/aboglioli/big-brother/pkg/events"
+	"github.com/aboglioli/big-brother/units"
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+)
+
+// StartREST starts API server
+func StartREST(eventMgr events.Manager, serv units.Service) {
+	// Start Gin server
+	conf := config.Get()
+	server := gin.Default()
+
+	// CORS
+	corsConfig := cors.DefaultConfig()
+	corsConfig.AllowAllOrigins = true
+	server.Use(cors.New(corsConfig))
+
+	// Create context and define router
+	rest := &RESTContext{
+		unitsService: serv,
+		conf:         conf,
+	}
+
+	server.GET("/v1/unit", rest.GetAll)
+	server.GET("/v1/unit-type", rest.GetTypes)
+	server.POST("/v1/unit", rest.Post)
+	server.DELETE("/v1/unit/:unit", rest.Delete)
+
+	server.Run(fmt.Sprintf(":%d", conf.Units.Port))
+}
+
+type RESTContext struct {
+	unitsService units.Service
+	conf         config.Configuration
+}
+
+func (r *RESTContext) authorize(c *gin.Context) bool {
+	if r.conf.AuthEnabled {
+		if err := validateAuthAndPermission(c, "units"); err != nil {
+			errors.Handle(c, err)
+			return false
+		}
+	}
+	return true
+}
+
+// GetAll lists units
+/**
+* @api {get} /v1/unit GetAll
+* @apiName List units
+* @apiGroup Units
+*
+* @apiParam {String} [type] Unit type. Without it, all units are listed.
+*
+* @apiSuccessExample {json} Response
+* HTTP/1.1 200 OK
+* {
+*   "units": [
+*     {
+*       "type": "time",
+*       "name": "min",
+*       "modifier": 60
+*     },
+*     {
+*       "type": "time",
+*       "name": "h",
+*       "modifier": 3600
+*     }
+*   ]
+* }
+ */
+func (r *RESTContext) GetAll(c *gin.Context) {
+	if !r.authorize(c) {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"units": r.unitsService.FindAll(c.Query("type")),
+	})
+}
+
+// GetTypes lists unit types
+/**
+* @api {get} /v1/unit-type GetTypes
+* @apiName List unit types
+* @apiGroup Units
+*
+* @apiSuccessExample {json} Response
+* HTTP/1.1 200 OK
+* {
+*   "types": ["box", "case", "length", "mass", "pack", "pallet", "time", "unit", "volume"]
+* }
+ */
+func (r *RESTContext) GetTypes(c *gin.Context) {
+	if !r.authorize(c) {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"types": r.unitsService.FindTypes(),
+	})
+}
+
+// Post adds a unit
+/**
+* @api {post} /v1/unit Post
+* @apiName Add unit
+* @apiGroup Units
+*
+* @apiParam {String} type Unit type. A new type is created if it doesn't exist (time, area, energy).
+* @apiParam {String} name Unit name, used in quantities
+* @apiParam {Number} modifier Value relative to the other units of the type
+*
+* @apiDescription Adds a unit. Services see it without restarting: units are
+* reloaded when a unit isn't found.
+*
+* @apiSuccessExample {json} Response
+* HTTP/1.1 200 OK
+* {
+*   "unit": {
+*     "type": "time",
+*     "name": "h",
+*     "modifier": 3600
+*   },
+*   "status": "CREATED"
+* }
+ */
+func (r *RESTContext) Post(c *gin.Context) {
+	if !r.authorize(c) {
+		return
+	}
+
+	var body units.CreateRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	u, err := r.unitsService.Create(&body)
+	if err != nil {
+		errors.Handle(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "CREATED",
+		"unit":   u,
+	})
+}
+
+// Delete deletes a unit
+/**
+* @api {delete} /v1/unit/:unit Delete
+* @apiName Delete unit
+* @apiGroup Units
+*
+* @apiParam {String} unit Unit name
+*
+* @apiDescription Deletes a unit not used by any composition. Default units
+* can't be deleted.
+*
+* @apiSuccessExample {json} Response
+* HTTP/1.1 200 OK
+* {
+*   "status": "DELETED"
+* }
+ */
+func (r *RESTContext) Delete(c *gin.Context) {
+	if !r.authorize(c) {
+		return
+	}
+
+	if err := r.unitsService.Delete(c.Param("unit")); err != nil {
+		errors.Handle(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "DELETED",
+	})
+}
@@ -0,0 +1,22 @@
+package invoice
+
+import (
+	"github.com/aboglioli/big-brother/pkg/events"
+)
+
+// InvoiceIssuedEvent is published when an invoice or a credit note is issued
+type InvoiceIssuedEvent struct {
+	events.Event
+	Invoice *Invoice `json:"invoice"`
+}
+
+func NewInvoiceIssuedEvent(inv *Invoice) (*InvoiceIssuedEvent, *events.Options) {
+	event := &InvoiceIssuedEvent{events.NewEvent("InvoiceIssued", inv.ID.Hex()), inv}
+	opts := &events.Options{"invoice", "topic", "invoice.issued", ""}
+	return event, opts
+}
+
+// newInvoiceEntry returns the outbox entry of an invoice event.
+func newInvoiceEntry(event *InvoiceIssuedEvent, opts *events.Options) (*events.Entry, error) {
+	return events.NewEntry(event.Invoice.ID.Hex(), event, opts)
+}
@@ -0,0 +1,181 @@
+package invoice
+
+import (
+	"math"
+	"time"
+
+	"github.com/aboglioli/big-brother/pkg/errors"
+	"github.com/aboglioli/big-brother/pkg/quantity"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	TypeInvoice    = "INVOICE"
+	TypeCreditNote = "CREDIT_NOTE"
+
+	StatusDraft  = "DRAFT"
+	StatusIssued = "ISSUED"
+
+	epsilon = 1e-9
+)
+
+// Line is an invoiced composition. Price is the net price of the whole
+// quantity and Tax is computed from the tax rate of the composition category.
+type Line struct {
+	Composition primitive.ObjectID `json:"composition" bson:"composition"`
+	Name        string             `json:"name" bson:"name"`
+	Category    string             `json:"category" bson:"category"`
+	Quantity    quantity.Quantity  `json:"quantity" bson:"quantity"`
+	Price       float64            `json:"price" bson:"price"`
+	TaxRate     float64            `json:"taxRate" bson:"taxRate"`
+	Tax         float64            `json:"tax" bson:"tax"`
+}
+
+// Total returns price plus tax.
+func (l *Line) Total() float64 {
+	return round(l.Price + l.Tax)
+}
+
+// Invoice is an invoice or a credit note of a delivered sales order. Numbers
+// are assigned on issue, without gaps and with a sequence per type. Issued
+// invoices cannot be modified: corrections are made with credit notes.
+// Amounts of credit notes are positive.
+type Invoice struct {
+	ID       primitive.ObjectID  `json:"id" bson:"_id"`
+	Type     string              `json:"type" bson:"type"`
+	Status   string              `json:"status" bson:"status"`
+	Number   int                 `json:"number" bson:"number"`
+	Order    primitive.ObjectID  `json:"order" bson:"order"`
+	Customer primitive.ObjectID  `json:"customer" bson:"customer"`
+	Invoice  *primitive.ObjectID `json:"invoice" bson:"invoice"`
+	Reason   string              `json:"reason" bson:"reason"`
+	Lines    []Line              `json:"lines" bson:"lines"`
+
+	Subtotal float64 `json:"subtotal" bson:"subtotal"`
+	Tax      float64 `json:"tax" bson:"tax"`
+	Total    float64 `json:"total" bson:"total"`
+
+	IssuedAt  *time.Time `json:"issuedAt" bson:"issuedAt"`
+	CreatedAt time.Time  `json:"createdAt" bson:"createdAt"`
+	UpdatedAt time.Time  `json:"updatedAt" bson:"updatedAt"`
+}
+
+func NewInvoice(orderID, customerID primitive.ObjectID) *Invoice {
+	return &Invoice{
+		ID:        primitive.NewObjectID(),
+		Type:      TypeInvoice,
+		Status:    StatusDraft,
+		Order:     orderID,
+		Customer:  customerID,
+		Lines:     make([]Line, 0),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+}
+
+// NewCreditNote creates a draft credit note of an invoice.
+func NewCreditNote(inv *Invoice, reason string) *Invoice {
+	cn := NewInvoice(inv.Order, inv.Customer)
+	cn.Type = TypeCreditNote
+	cn.Invoice = &inv.ID
+	cn.Reason = reason
+	return cn
+}
+
+func (inv *Invoice) IsIssued() bool {
+	return inv.Status == StatusIssued
+}
+
+func (inv *Invoice) FindLine(compID string) *Line {
+	for i := range inv.Lines {
+		if inv.Lines[i].Composition.Hex() == compID {
+			return &inv.Lines[i]
+		}
+	}
+	return nil
+}
+
+// AddLine adds a line to a draft invoice and recalculates totals.
+func (inv *Invoice) AddLine(l Line) error {
+	path := "invoice/invoice.AddLine"
+
+	if inv.IsIssued() {
+		return errors.NewStatus("INVOICE_ALREADY_ISSUED").SetPath(path)
+	}
+
+	if !l.Quantity.IsValid() || l.Quantity.Quantity <= 0 {
+		return errors.NewStatus("INVALID_QUANTITY").SetPath(path).SetMessage("%v", l.Quantity)
+	}
+
+	l.Price = round(l.Price)
+	l.Tax = round(l.Price * l.TaxRate)
+	inv.Lines = append(inv.Lines, l)
+
+	inv.calculateTotals()
+
+	return nil
+}
+
+// Issue assigns the number to a draft invoice.
+func (inv *Invoice) Issue(number int) error {
+	path := "invoice/invoice.Issue"
+
+	if inv.IsIssued() {
+		return errors.NewStatus("INVOICE_ALREADY_ISSUED").SetPath(path)
+	}
+
+	if len(inv.Lines) == 0 {
+		return errors.NewStatus("EMPTY_INVOICE").SetPath(path)
+	}
+
+	now := time.Now()
+	inv.Number = number
+	inv.Status = StatusIssued
+	inv.IssuedAt = &now
+
+	return nil
+}
+
+func (inv *Invoice) ValidateSchema() error {
+	err := errors.NewValidation("VALIDATE_SCHEMA").SetPath("invoice/invoice.ValidateSchema")
+
+	if inv.Type != TypeInvoice && inv.Type != TypeCreditNote {
+		err.Add("type", "INVALID")
+	}
+
+	if inv.Type == TypeCreditNote && inv.Invoice == nil {
+		err.Add("invoice", "REQUIRED")
+	}
+
+	for i, l := range inv.Lines {
+		if l.Price < 0 {
+			err.AddWithMessage("lines", "INVALID_PRICE", "line %d", i)
+		}
+		if l.TaxRate < 0 {
+			err.AddWithMessage("lines", "INVALID_TAX_RATE", "line %d", i)
+		}
+	}
+
+	if err.Size() > 0 {
+		return err
+	}
+
+	return nil
+}
+
+func (inv *Invoice) calculateTotals() {
+	subtotal, tax := 0.0, 0.0
+	for _, l := range inv.Lines {
+		subtotal += l.Price
+		tax += l.Tax
+	}
+
+	inv.Subtotal = round(subtotal)
+	inv.Tax = round(tax)
+	inv.Total = round(subtotal + tax)
+}
+
+// round rounds amounts to cents.
+func round(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,43 @@
+package invoice
+
+import (
+	"testing"
+
+	"github.com/aboglioli/big-brother/pkg/quantity"
+	"github.com/aboglioli/big-brother/pkg/tests/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestInvoiceTotals(t *testing.T) {
+	inv := NewInvoice(primitive.NewObjectID(), primitive.NewObjectID())
+
+	err := inv.AddLine(Line{Composition: primitive.NewObjectID(), Quantity: quantity.Quantity{2, "kg"}, Price: 100, TaxRate: 0.21})
+	assert.Ok(t, err)
+	err = inv.AddLine(Line{Composition: primitive.NewObjectID(), Quantity: quantity.Quantity{3, "u"}, Price: 33.333, TaxRate: 0.105})
+	assert.Ok(t, err)
+
+	assert.Equal(t, inv.Lines[0].Tax, 21.0)
+	assert.Equal(t, inv.Lines[1].Price, 33.33)
+	assert.Equal(t, inv.Lines[1].Tax, 3.5)
+	assert.Equal(t, inv.Subtotal, 133.33)
+	assert.Equal(t, inv.Tax, 24.5)
+	assert.Equal(t, inv.Total, 157.83)
+
+	err = inv.AddLine(Line{Composition: primitive.NewObjectID(), Quantity: quantity.Quantity{-1, "u"}})
+	assert.ErrCode(t, err, "INVALID_QUANTITY")
+}
+
+func TestIssueInvoice(t *testing.T) {
+	inv := NewInvoice(primitive.NewObjectID(), primitive.NewObjectID())
+	assert.ErrCode(t, inv.Issue(1), "EMPTY_INVOICE")
+
+	inv.AddLine(Line{Composition: primitive.NewObjectID(), Quantity: quantity.Quantity{1, "u"}, Price: 10})
+	assert.Ok(t, inv.Issue(1))
+	assert.Equal(t, inv.Number, 1)
+	assert.Assert(t, inv.IsIssued())
+	assert.NotNil(t, inv.IssuedAt)
+
+	assert.ErrCode(t, inv.Issue(2), "INVOICE_ALREADY_ISSUED")
+	err := inv.AddLine(Line{Composition: primitive.NewObjectID(), Quantity: quantity.Quantity{1, "u"}, Price: 10})
+	assert.ErrCode(t, err, "INVOICE_ALREADY_ISSUED")
+}
@@ -0,0 +1,282 @@
+package invoice
+
+import (
+	"context"
+	"time"
+
+	"github.com/aboglioli/big-brother/infrastructure/db"
+	"github.com/aboglioli/big-brother/pkg/errors"
+	"github.com/aboglioli/big-brother/pkg/events"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Repository stores invoices. Update only modifies drafts, so issued invoices
+// are immutable. When a number is already used by another issued invoice of
+// the same type, Update returns DUPLICATE_NUMBER.
+type Repository interface {
+	FindByID(id string) (*Invoice, error)
+	FindByStatus(status ...string) ([]*Invoice, error)
+	FindByOrder(orderID string) ([]*Invoice, error)
+	FindByCustomer(customerID string) ([]*Invoice, error)
+	FindCreditNotes(invoiceID string) ([]*Invoice, error)
+	LastNumber(invoiceType string) (int, error)
+
+	Insert(*Invoice) error
+
+	// Update stores the outbox entries of the change in the same
+	// transaction.
+	Update(inv *Invoice, entries ...*events.Entry) error
+	Delete(id string) error
+}
+
+type repository struct {
+	collection *mongo.Collection
+	outbox     events.Outbox
+}
+
+// NewRepository returns the invoice repository of the "Invoice" database.
+// The outbox must be of the same database.
+func NewRepository(outbox events.Outbox) (Repository, error) {
+	path := "invoice/repository.NewRepository"
+
+	db, err := db.Get("Invoice")
+
+	if err != nil {
+		return nil, err
+	}
+
+	collection := db.Collection("invoice")
+
+	// Numbers are unique per type between issued invoices
+	index := mongo.IndexModel{
+		Keys: bson.D{{"type", 1}, {"number", 1}},
+		Options: options.Index().SetUnique(true).SetPartialFilterExpression(bson.M{
+			"status": StatusIssued,
+		}),
+	}
+	if _, err := collection.Indexes().CreateOne(context.Background(), index); err != nil {
+		return nil, errors.NewInternal("CREATE_INDEX").SetPath(path).SetRef(err)
+	}
+
+	return &repository{
+		collection: collection,
+		outbox:     outbox,
+	}, nil
+}
+
+func (r *repository) FindByID(id string) (*Invoice, error) {
+	path := "invoice/repository.FindByID"
+	ctx := context.Background()
+
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errors.NewInternal("OBJECTID_FROM_HEX").SetPath(path).SetRef(err)
+	}
+
+	filter := bson.M{
+		"_id": objID,
+	}
+
+	res := r.collection.FindOne(ctx, filter)
+	if res.Err() != nil {
+		return nil, errors.NewInternal("FIND_ONE").SetPath(path).SetRef(res.Err())
+	}
+
+	var inv Invoice
+	if err := res.Decode(&inv); err != nil {
+		return nil, errors.NewInternal("DECODE").SetPath(path).SetRef(err)
+	}
+
+	return &inv, nil
+}
+
+func (r *repository) FindByStatus(status ...string) ([]*Invoice, error) {
+	return r.find("invoice/repository.FindByStatus", bson.M{
+		"status": bson.M{
+			"$in": status,
+		},
+	})
+}
+
+func (r *repository) FindByOrder(orderID string) ([]*Invoice, error) {
+	path := "invoice/repository.FindByOrder"
+
+	objID, err := primitive.ObjectIDFromHex(orderID)
+	if err != nil {
+		return nil, errors.NewInternal("OBJECTID_FROM_HEX").SetPath(path).SetRef(err)
+	}
+
+	return r.find(path, bson.M{
+		"order": objID,
+	})
+}
+
+func (r *repository) FindByCustomer(customerID string) ([]*Invoice, error) {
+	path := "invoice/repository.FindByCustomer"
+
+	objID, err := primitive.ObjectIDFromHex(customerID)
+	if err != nil {
+		return nil, errors.NewInternal("OBJECTID_FROM_HEX").SetPath(path).SetRef(err)
+	}
+
+	return r.find(path, bson.M{
+		"customer": objID,
+	})
+}
+
+func (r *repository) FindCreditNotes(invoiceID string) ([]*Invoice, error) {
+	path := "invoice/repository.FindCreditNotes"
+
+	objID, err := primitive.ObjectIDFromHex(invoiceID)
+	if err != nil {
+		return nil, errors.NewInternal("OBJECTID_FROM_HEX").SetPath(path).SetRef(err)
+	}
+
+	return r.find(path, bson.M{
+		"type":    TypeCreditNote,
+		"invoice": objID,
+	})
+}
+
+// LastNumber returns the number of the last issued invoice of a type, or 0 if
+// there is none.
+func (r *repository) LastNumber(invoiceType string) (int, error) {
+	path := "invoice/repository.LastNumber"
+	ctx := context.Background()
+
+	filter := bson.M{
+		"type":   invoiceType,
+		"status": StatusIssued,
+	}
+
+	opts := options.FindOne().SetSort(bson.M{"number": -1})
+
+	res := r.collection.FindOne(ctx, filter, opts)
+	if res.Err() == mongo.ErrNoDocuments {
+		return 0, nil
+	}
+	if res.Err() != nil {
+		return 0, errors.NewInternal("FIND_ONE").SetPath(path).SetRef(res.Err())
+	}
+
+	var inv Invoice
+	if err := res.Decode(&inv); err != nil {
+		return 0, errors.NewInternal("DECODE").SetPath(path).SetRef(err)
+	}
+
+	return inv.Number, nil
+}
+
+func (r *repository) Insert(inv *Invoice) error {
+	ctx := context.Background()
+
+	_, err := r.collection.InsertOne(ctx, inv)
+	if err != nil {
+		return errors.NewInternal("INSERT_ONE").SetPath("invoice/repository.Insert").SetRef(err)
+	}
+
+	return nil
+}
+
+func (r *repository) Update(inv *Invoice, entries ...*events.Entry) error {
+	path := "invoice/repository.Update"
+
+	if inv.ID.IsZero() {
+		return errors.NewInternal("INVALID_OBJECTID").SetPath(path)
+	}
+
+	inv.UpdatedAt = time.Now()
+
+	filter := bson.M{
+		"_id":    inv.ID,
+		"status": StatusDraft,
+	}
+
+	update := bson.M{
+		"$set": inv,
+	}
+
+	return r.outbox.Write(func(ctx context.Context) error {
+		res, err := r.collection.UpdateOne(ctx, filter, update)
+		if isDuplicateKey(err) {
+			return errors.NewInternal("DUPLICATE_NUMBER").SetPath(path).SetRef(err)
+		}
+		if err != nil {
+			return errors.NewInternal("UPDATE_ONE").SetPath(path).SetRef(err)
+		}
+		if res.MatchedCount == 0 {
+			return errors.NewInternal("INVOICE_NOT_DRAFT").SetPath(path)
+		}
+
+		return nil
+	}, entries...)
+}
+
+// Delete removes a draft invoice.
+func (r *repository) Delete(id string) error {
+	path := "invoice/repository.Delete"
+	ctx := context.Background()
+
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return errors.NewInternal("OBJECTID_FROM_HEX").SetPath(path).SetRef(err)
+	}
+
+	filter := bson.M{
+		"_id":    objID,
+		"status": StatusDraft,
+	}
+
+	res, err := r.collection.DeleteOne(ctx, filter)
+	if err != nil {
+		return errors.NewInternal("DELETE_ONE").SetPath(path).SetRef(err)
+	}
+	if res.DeletedCount == 0 {
+		return errors.NewInternal("INVOICE_NOT_DRAFT").SetPath(path)
+	}
+
+	return nil
+}
+
+func (r *repository) find(path string, filter bson.M) ([]*Invoice, error) {
+	ctx := context.Background()
+
+	opts := options.Find().SetSort(bson.M{"createdAt": 1})
+
+	cur, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, errors.NewInternal("FIND").SetPath(path).SetRef(err)
+	}
+	defer cur.Close(ctx)
+
+	invoices := make([]*Invoice, 0)
+	for cur.Next(ctx) {
+		var inv Invoice
+
+		if err := cur.Decode(&inv); err != nil {
+			return nil, errors.NewInternal("CUR_DECODE").SetPath(path).SetRef(err)
+		}
+
+		invoices = append(invoices, &inv)
+	}
+
+	if err := cur.Err(); err != nil {
+		return nil, errors.NewInternal("CUR").SetPath(path).SetRef(err)
+	}
+
+	return invoices, nil
+}
+
+func isDuplicateKey(err error) bool {
+	if e, ok := err.(mongo.WriteException); ok {
+		for _, we := range e.WriteErrors {
+			if we.Code == 11000 {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,159 @@
+package invoice
+
+import (
+	"time"
+
+	"github.com/aboglioli/big-brother/pkg/errors"
+	"github.com/aboglioli/big-brother/pkg/events"
+	"github.com/aboglioli/big-brother/pkg/tests/mock"
+)
+
+type mockRepository struct {
+	mock.Mock
+	invoices []*Invoice
+	entries  []*events.Entry
+}
+
+// NewMockRepository returns an in-memory Repository. It is exported to be
+// used by other packages' tests.
+func NewMockRepository() *mockRepository {
+	return &mockRepository{}
+}
+
+// Helpers
+func (r *mockRepository) Clean() {
+	r.invoices = make([]*Invoice, 0)
+	r.entries = make([]*events.Entry, 0)
+}
+
+// Entries returns the outbox entries stored with the changes.
+func (r *mockRepository) Entries() []*events.Entry {
+	return r.entries
+}
+
+// Implementation
+func (r *mockRepository) FindByID(id string) (*Invoice, error) {
+	r.Called("FindByID", id)
+
+	for _, inv := range r.invoices {
+		if inv.ID.Hex() == id {
+			return copyInvoice(inv), nil
+		}
+	}
+
+	return nil, errors.NewInternal("NOT_FOUND").SetPath("invoice/repository_mock.FindByID")
+}
+
+func (r *mockRepository) FindByStatus(status ...string) ([]*Invoice, error) {
+	r.Called("FindByStatus", status)
+
+	return r.filter(func(inv *Invoice) bool {
+		for _, s := range status {
+			if inv.Status == s {
+				return true
+			}
+		}
+		return false
+	}), nil
+}
+
+func (r *mockRepository) FindByOrder(orderID string) ([]*Invoice, error) {
+	r.Called("FindByOrder", orderID)
+
+	return r.filter(func(inv *Invoice) bool {
+		return inv.Order.Hex() == orderID
+	}), nil
+}
+
+func (r *mockRepository) FindByCustomer(customerID string) ([]*Invoice, error) {
+	r.Called("FindByCustomer", customerID)
+
+	return r.filter(func(inv *Invoice) bool {
+		return inv.Customer.Hex() == customerID
+	}), nil
+}
+
+func (r *mockRepository) FindCreditNotes(invoiceID string) ([]*Invoice, error) {
+	r.Called("FindCreditNotes", invoiceID)
+
+	return r.filter(func(inv *Invoice) bool {
+		return inv.Type == TypeCreditNote && inv.Invoice != nil && inv.Invoice.Hex() == invoiceID
+	}), nil
+}
+
+func (r *mockRepository) LastNumber(invoiceType string) (int, error) {
+	r.Called("LastNumber", invoiceType)
+
+	last := 0
+	for _, inv := range r.invoices {
+		if inv.Type == invoiceType && inv.IsIssued() && inv.Number > last {
+			last = inv.Number
+		}
+	}
+
+	return last, nil
+}
+
+func (r *mockRepository) Insert(inv *Invoice) error {
+	r.Called("Insert", inv)
+
+	r.invoices = append(r.invoices, copyInvoice(inv))
+
+	return nil
+}
+
+func (r *mockRepository) Update(inv *Invoice, entries ...*events.Entry) error {
+	r.Called("Update", inv)
+	path := "invoice/repository_mock.Update"
+
+	if inv.IsIssued() {
+		for _, i := range r.invoices {
+			if i.Type == inv.Type && i.IsIssued() && i.Number == inv.Number {
+				return errors.NewInternal("DUPLICATE_NUMBER").SetPath(path)
+			}
+		}
+	}
+
+	for _, i := range r.invoices {
+		if i.ID.Hex() == inv.ID.Hex() {
+			if i.IsIssued() {
+				return errors.NewInternal("INVOICE_NOT_DRAFT").SetPath(path)
+			}
+			*i = *copyInvoice(inv)
+			i.UpdatedAt = time.Now()
+			r.entries = append(r.entries, entries...)
+			return nil
+		}
+	}
+
+	return errors.NewInternal("INVOICE_NOT_DRAFT").SetPath(path)
+}
+
+func (r *mockRepository) Delete(id string) error {
+	r.Called("Delete", id)
+
+	for i, inv := range r.invoices {
+		if inv.ID.Hex() == id && !inv.IsIssued() {
+			r.invoices = append(r.invoices[:i], r.invoices[i+1:]...)
+			return nil
+		}
+	}
+
+	return errors.NewInternal("INVOICE_NOT_DRAFT").SetPath("invoice/repository_mock.Delete")
+}
+
+func (r *mockRepository) filter(f func(*Invoice) bool) []*Invoice {
+	invoices := make([]*Invoice, 0)
+	for _, inv := range r.invoices {
+		if f(inv) {
+			invoices = append(invoices, copyInvoice(inv))
+		}
+	}
+	return invoices
+}
+
+func copyInvoice(inv *Invoice) *Invoice {
+	copy := *inv
+	copy.Lines = append([]Line(nil), inv.Lines...)
+	return &copy
+}
@@ -0,0 +1,324 @@
+package invoice
+
+import (
+	"github.com/aboglioli/big-brother/composition"
+	"github.com/aboglioli/big-brother/pkg/errors"
+	"github.com/aboglioli/big-brother/pkg/quantity"
+	"github.com/aboglioli/big-brother/sales"
+)
+
+// DefaultCategory is the tax rate key used for compositions whose category
+// has no tax rate.
+const DefaultCategory = "default"
+
+// issueAttempts is the number of times a number is requested when another
+// invoice was issued concurrently with the same number.
+const issueAttempts = 5
+
+type Service interface {
+	GetByID(id string) (*Invoice, error)
+	FindDrafts() ([]*Invoice, error)
+	FindByCustomer(customerID string) ([]*Invoice, error)
+
+	CreateFromOrder(orderID string) (*Invoice, error)
+	CreateCreditNote(invoiceID string, req *CreditNoteRequest) (*Invoice, error)
+	Issue(id string) (*Invoice, error)
+	Discard(id string) error
+}
+
+type service struct {
+	repository         Repository
+	salesService       sales.Service
+	compositionService composition.Service
+	taxRates           map[string]float64
+}
+
+// NewService creates the invoice service. taxRates maps composition
+// categories to tax rates (e.g. 0.21).
+func NewService(repo Repository, salesServ sales.Service, compServ composition.Service, taxRates map[string]float64) Service {
+	return &service{
+		repository:         repo,
+		salesService:       salesServ,
+		compositionService: compServ,
+		taxRates:           taxRates,
+	}
+}
+
+func (s *service) GetByID(id string) (*Invoice, error) {
+	inv, err := s.repository.FindByID(id)
+	if err != nil {
+		return nil, errors.NewStatus("INVOICE_NOT_FOUND").SetPath("invoice/service.GetByID").SetStatus(404).SetRef(err)
+	}
+	return inv, nil
+}
+
+func (s *service) FindDrafts() ([]*Invoice, error) {
+	invoices, err := s.repository.FindByStatus(StatusDraft)
+	if err != nil {
+		return nil, errors.NewStatus("FIND_INVOICES").SetPath("invoice/service.FindDrafts").SetRef(err)
+	}
+	return invoices, nil
+}
+
+func (s *service) FindByCustomer(customerID string) ([]*Invoice, error) {
+	invoices, err := s.repository.FindByCustomer(customerID)
+	if err != nil {
+		return nil, errors.NewStatus("FIND_INVOICES").SetPath("invoice/service.FindByCustomer").SetRef(err)
+	}
+	return invoices, nil
+}
+
+// CreateFromOrder creates a draft invoice of a delivered sales order. An
+// order can be invoiced only once.
+func (s *service) CreateFromOrder(orderID string) (*Invoice, error) {
+	path := "invoice/service.CreateFromOrder"
+
+	o, err := s.salesService.GetOrder(orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	if o.Status != sales.OrderDelivered {
+		return nil, errors.NewStatus("ORDER_NOT_DELIVERED").SetPath(path)
+	}
+
+	existing, err := s.repository.FindByOrder(orderID)
+	if err != nil {
+		return nil, errors.NewStatus("FIND_INVOICES").SetPath(path).SetRef(err)
+	}
+	for _, inv := range existing {
+		if inv.Type == TypeInvoice {
+			return nil, errors.NewStatus("ORDER_ALREADY_INVOICED").SetPath(path).SetMessage("%s", inv.ID.Hex())
+		}
+	}
+
+	inv := NewInvoice(o.ID, o.Customer)
+	for _, l := range o.Lines {
+		comp, err := s.compositionService.GetByID(l.Composition.Hex())
+		if err != nil {
+			return nil, err
+		}
+
+		if err := inv.AddLine(Line{
+			Composition: comp.ID,
+			Name:        comp.Name,
+			Category:    comp.Category,
+			Quantity:    l.Quantity,
+			Price:       l.Price,
+			TaxRate:     s.taxRate(comp.Category),
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := inv.ValidateSchema(); err != nil {
+		return nil, err
+	}
+
+	if err := s.repository.Insert(inv); err != nil {
+		return nil, errors.NewStatus("INSERT").SetPath(path).SetRef(err)
+	}
+
+	return inv, nil
+}
+
+type CreditLineRequest struct {
+	Composition string            `json:"composition" binding:"required"`
+	Quantity    quantity.Quantity `json:"quantity" binding:"required"`
+}
+
+type CreditNoteRequest struct {
+	Lines  []CreditLineRequest `json:"lines"`
+	Reason string              `json:"reason"`
+}
+
+// CreateCreditNote creates a draft credit note of an issued invoice. Without
+// lines, the whole pending quantity of the invoice is credited. Prices and
+// taxes are proportional to the invoiced ones, and the credited quantities of
+// all credit notes cannot exceed the invoiced quantities.
+func (s *service) CreateCreditNote(invoiceID string, req *CreditNoteRequest) (*Invoice, error) {
+	path := "invoice/service.CreateCreditNote"
+
+	inv, err := s.GetByID(invoiceID)
+	if err != nil {
+		return nil, err
+	}
+
+	if inv.Type != TypeInvoice || !inv.IsIssued() {
+		return nil, errors.NewStatus("INVOICE_NOT_ISSUED").SetPath(path)
+	}
+
+	creditNotes, err := s.repository.FindCreditNotes(invoiceID)
+	if err != nil {
+		return nil, errors.NewStatus("FIND_INVOICES").SetPath(path).SetRef(err)
+	}
+
+	// Quantities not credited yet
+	pending := make(map[string]quantity.Quantity)
+	for _, l := range inv.Lines {
+		id := l.Composition.Hex()
+		if p, ok := pending[id]; ok {
+			if pending[id], err = p.Add(l.Quantity); err != nil {
+				return nil, errors.NewStatus("INCOMPATIBLE_QUANTITY").SetPath(path).SetRef(err)
+			}
+			continue
+		}
+		pending[id] = l.Quantity
+	}
+	for _, cn := range creditNotes {
+		for _, l := range cn.Lines {
+			id := l.Composition.Hex()
+			if pending[id], err = pending[id].Subtract(l.Quantity); err != nil {
+				return nil, errors.NewStatus("INCOMPATIBLE_QUANTITY").SetPath(path).SetRef(err)
+			}
+		}
+	}
+
+	lines := req.Lines
+	if len(lines) == 0 {
+		for _, l := range inv.Lines {
+			q := l.Quantity
+			if remaining := pending[l.Composition.Hex()]; remaining.Unit != "" {
+				if less, _ := remaining.Less(q); less {
+					q, _ = remaining.ConvertTo(q.Unit)
+				}
+			}
+			if q.Quantity > epsilon {
+				lines = append(lines, CreditLineRequest{Composition: l.Composition.Hex(), Quantity: q})
+			}
+		}
+	}
+
+	cn := NewCreditNote(inv, req.Reason)
+	for i, l := range lines {
+		invLine := inv.FindLine(l.Composition)
+		if invLine == nil {
+			return nil, errors.NewStatus("COMPOSITION_NOT_IN_INVOICE").SetPath(path).SetMessage("Line %d: %s", i, l.Composition)
+		}
+
+		if !l.Quantity.IsValid() || !l.Quantity.Compatible(invLine.Quantity) {
+			return nil, errors.NewStatus("INCOMPATIBLE_QUANTITY").SetPath(path).SetMessage("Line %d: %v != %v", i, l.Quantity, invLine.Quantity)
+		}
+
+		if exceeds, _ := l.Quantity.Greater(pending[l.Composition]); exceeds {
+			return nil, errors.NewStatus("EXCEEDS_INVOICED_QUANTITY").SetPath(path).SetMessage("Line %d: %s", i, l.Composition)
+		}
+		pending[l.Composition], _ = pending[l.Composition].Subtract(l.Quantity)
+
+		ratio, err := l.Quantity.Ratio(invLine.Quantity)
+		if err != nil {
+			return nil, errors.NewStatus("INCOMPATIBLE_QUANTITY").SetPath(path).SetMessage("Line %d: %v != %v", i, l.Quantity, invLine.Quantity).SetRef(err)
+		}
+
+		if err := cn.AddLine(Line{
+			Composition: invLine.Composition,
+			Name:        invLine.Name,
+			Category:    invLine.Category,
+			Quantity:    l.Quantity,
+			Price:       invLine.Price * ratio,
+			TaxRate:     invLine.TaxRate,
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(cn.Lines) == 0 {
+		return nil, errors.NewStatus("NOTHING_TO_CREDIT").SetPath(path)
+	}
+
+	if err := cn.ValidateSchema(); err != nil {
+		return nil, err
+	}
+
+	if err := s.repository.Insert(cn); err != nil {
+		return nil, errors.NewStatus("INSERT").SetPath(path).SetRef(err)
+	}
+
+	return cn, nil
+}
+
+// Issue assigns the next number of the invoice type and makes the invoice
+// immutable.
+/**
+* @api {topic} invoice.issued invoice.issued
+* @apiName InvoiceIssued
+* @apiGroup RabbitMQ
+*
+* @apiDescription Emits a new event when an invoice or a credit note is
+* issued. The invoice type is "INVOICE" or "CREDIT_NOTE".
+*
+* @apiSuccessExample {json} Body
+* {
+* 	"type": "InvoiceIssued",
+* 	"invoice": invoice data
+* }
+ */
+func (s *service) Issue(id string) (*Invoice, error) {
+	path := "invoice/service.Issue"
+
+	var inv *Invoice
+	for attempt := 0; ; attempt++ {
+		var err error
+		inv, err = s.GetByID(id)
+		if err != nil {
+			return nil, err
+		}
+
+		last, err := s.repository.LastNumber(inv.Type)
+		if err != nil {
+			return nil, errors.NewStatus("LAST_NUMBER").SetPath(path).SetRef(err)
+		}
+
+		if err := inv.Issue(last + 1); err != nil {
+			return nil, err
+		}
+
+		// Publish event through the outbox: invoice.issued
+		entry, err := newInvoiceEntry(NewInvoiceIssuedEvent(inv))
+		if err != nil {
+			return nil, errors.NewStatus("ENCODE_EVENT").SetPath(path).SetRef(err)
+		}
+
+		err = s.repository.Update(inv, entry)
+		if err == nil {
+			break
+		}
+		if !hasCode(err, "DUPLICATE_NUMBER") || attempt+1 >= issueAttempts {
+			return nil, errors.NewStatus("UPDATE").SetPath(path).SetRef(err)
+		}
+	}
+
+	return inv, nil
+}
+
+// Discard deletes a draft invoice.
+func (s *service) Discard(id string) error {
+	path := "invoice/service.Discard"
+
+	inv, err := s.GetByID(id)
+	if err != nil {
+		return err
+	}
+
+	if inv.IsIssued() {
+		return errors.NewStatus("INVOICE_ALREADY_ISSUED").SetPath(path)
+	}
+
+	if err := s.repository.Delete(id); err != nil {
+		return errors.NewStatus("DELETE").SetPath(path).SetRef(err)
+	}
+
+	return nil
+}
+
+func (s *service) taxRate(category string) float64 {
+	if rate, ok := s.taxRates[category]; ok {
+		return rate
+	}
+	return s.taxRates[DefaultCategory]
+}
+
+func hasCode(err error, code string) bool {
+	c, ok := err.(errors.Code)
+	return ok && c.Code() == code
+}
@@ -0,0 +1,166 @@
+package invoice
+
+import (
+	"testing"
+
+	"github.com/aboglioli/big-brother/composition"
+	"github.com/aboglioli/big-brother/pkg/events"
+	"github.com/aboglioli/big-brother/pkg/quantity"
+	"github.com/aboglioli/big-brother/pkg/tests/assert"
+	"github.com/aboglioli/big-brother/pricing"
+	"github.com/aboglioli/big-brother/sales"
+	"github.com/aboglioli/big-brother/stock"
+)
+
+func newComposition(name, category string, unit quantity.Quantity) *composition.Composition {
+	comp := composition.NewComposition()
+	comp.Name = name
+	comp.Category = category
+	comp.Unit = unit
+	comp.Stock = quantity.Quantity{0, unit.Unit}
+	comp.Validated = true
+	return comp
+}
+
+func price(p float64) *float64 {
+	return &p
+}
+
+func TestInvoicing(t *testing.T) {
+	compRepo, eventMgr := composition.NewMockRepository(), events.GetMockManager()
+	compServ := composition.NewService(compRepo)
+	stockServ := stock.NewService(stock.NewMockLotRepository(), stock.NewMockMovementRepository(), stock.NewMockCountRepository(), compServ)
+	pricingServ := pricing.NewService(pricing.NewMockRepository(), compServ, eventMgr, 0.2)
+	salesServ := sales.NewService(sales.NewMockCustomerRepository(), sales.NewMockOrderRepository(), compServ, stockServ, pricingServ)
+	repo := NewMockRepository()
+	serv := NewService(repo, salesServ, compServ, map[string]float64{
+		DefaultCategory: 0.21,
+		"food":          0.105,
+	})
+
+	cheese, box := newComposition("Cheese", "food", quantity.Quantity{1, "kg"}), newComposition("Box", "", quantity.Quantity{1, "u"})
+	for _, comp := range []*composition.Composition{cheese, box} {
+		compRepo.Insert(comp)
+		_, err := stockServ.Receive(&stock.ReceiveRequest{Composition: comp.ID.Hex(), Quantity: quantity.Quantity{100, comp.Unit.Unit}})
+		assert.Ok(t, err)
+	}
+
+	customer, err := salesServ.CreateCustomer(&sales.CreateCustomerRequest{Name: "Restaurant"})
+	assert.Ok(t, err)
+
+	newOrder := func(deliver bool) *sales.Order {
+		o, err := salesServ.CreateOrder(&sales.CreateOrderRequest{
+			Customer: customer.ID.Hex(),
+			Lines: []sales.LineRequest{
+				sales.LineRequest{Composition: cheese.ID.Hex(), Quantity: quantity.Quantity{2, "kg"}, Price: price(300)},
+				sales.LineRequest{Composition: box.ID.Hex(), Quantity: quantity.Quantity{4, "u"}, Price: price(40)},
+			},
+		})
+		assert.Ok(t, err)
+		if deliver {
+			_, err = salesServ.ConfirmOrder(o.ID.Hex())
+			assert.Ok(t, err)
+			o, err = salesServ.DeliverOrder(o.ID.Hex())
+			assert.Ok(t, err)
+		}
+		return o
+	}
+
+	t.Run("Order not delivered", func(t *testing.T) {
+		o := newOrder(false)
+		_, err := serv.CreateFromOrder(o.ID.Hex())
+		assert.ErrCode(t, err, "ORDER_NOT_DELIVERED")
+	})
+
+	o1, o2 := newOrder(true), newOrder(true)
+	var inv1 *Invoice
+
+	t.Run("Create from order", func(t *testing.T) {
+		inv, err := serv.CreateFromOrder(o1.ID.Hex())
+		assert.Ok(t, err)
+		assert.Equal(t, inv.Status, StatusDraft)
+		assert.Equal(t, inv.Number, 0)
+		assert.Equal(t, len(inv.Lines), 2)
+		assert.Equal(t, inv.Lines[0].TaxRate, 0.105)
+		assert.Equal(t, inv.Lines[1].TaxRate, 0.21)
+		assert.Equal(t, inv.Subtotal, 340.0)
+		assert.Equal(t, inv.Tax, 39.9)
+		assert.Equal(t, inv.Total, 379.9)
+
+		_, err = serv.CreateFromOrder(o1.ID.Hex())
+		assert.ErrCode(t, err, "ORDER_ALREADY_INVOICED")
+
+		inv1 = inv
+	})
+
+	t.Run("Numbering without gaps", func(t *testing.T) {
+		inv2, err := serv.CreateFromOrder(o2.ID.Hex())
+		assert.Ok(t, err)
+
+		// Discarded drafts do not consume numbers
+		assert.Ok(t, serv.Discard(inv2.ID.Hex()))
+		inv2, err = serv.CreateFromOrder(o2.ID.Hex())
+		assert.Ok(t, err)
+
+		inv, err := serv.Issue(inv2.ID.Hex())
+		assert.Ok(t, err)
+		assert.Equal(t, inv.Number, 1)
+
+		inv, err = serv.Issue(inv1.ID.Hex())
+		assert.Ok(t, err)
+		assert.Equal(t, inv.Number, 2)
+		inv1 = inv
+
+		entries := repo.Entries()
+		assert.Equal(t, len(entries), 2, "Events should be stored with the invoices")
+		assert.Equal(t, entries[0].Type(), "InvoiceIssued")
+		assert.Equal(t, entries[0].Key, "invoice.issued")
+		assert.Equal(t, entries[0].Aggregate, inv2.ID.Hex())
+	})
+
+	t.Run("Issued invoices are immutable", func(t *testing.T) {
+		_, err := serv.Issue(inv1.ID.Hex())
+		assert.ErrCode(t, err, "INVOICE_ALREADY_ISSUED")
+
+		assert.ErrCode(t, serv.Discard(inv1.ID.Hex()), "INVOICE_ALREADY_ISSUED")
+
+		inv1.Total = 0
+		assert.Err(t, repo.Update(inv1))
+		inv, _ := serv.GetByID(inv1.ID.Hex())
+		assert.Equal(t, inv.Total, 379.9)
+	})
+
+	t.Run("Credit notes", func(t *testing.T) {
+		cn, err := serv.CreateCreditNote(inv1.ID.Hex(), &CreditNoteRequest{
+			Lines:  []CreditLineRequest{CreditLineRequest{Composition: cheese.ID.Hex(), Quantity: quantity.Quantity{500, "g"}}},
+			Reason: "Damaged",
+		})
+		assert.Ok(t, err)
+		assert.Equal(t, cn.Type, TypeCreditNote)
+		assert.Equal(t, *cn.Invoice, inv1.ID)
+		assert.Equal(t, cn.Subtotal, 75.0)
+		assert.Equal(t, cn.Tax, 7.88)
+
+		_, err = serv.CreateCreditNote(inv1.ID.Hex(), &CreditNoteRequest{
+			Lines: []CreditLineRequest{CreditLineRequest{Composition: cheese.ID.Hex(), Quantity: quantity.Quantity{2, "kg"}}},
+		})
+		assert.ErrCode(t, err, "EXCEEDS_INVOICED_QUANTITY")
+
+		cn, err = serv.Issue(cn.ID.Hex())
+		assert.Ok(t, err)
+		assert.Equal(t, cn.Number, 1)
+
+		// Credits the rest of the invoice
+		cn, err = serv.CreateCreditNote(inv1.ID.Hex(), &CreditNoteRequest{})
+		assert.Ok(t, err)
+		assert.Equal(t, len(cn.Lines), 2)
+		assert.Assert(t, cn.Lines[0].Quantity.Equals(quantity.Quantity{1.5, "kg"}))
+		assert.Equal(t, cn.Subtotal, 265.0)
+
+		_, err = serv.CreateCreditNote(inv1.ID.Hex(), &CreditNoteRequest{})
+		assert.ErrCode(t, err, "NOTHING_TO_CREDIT")
+
+		_, err = serv.CreateCreditNote(cn.ID.Hex(), &CreditNoteRequest{})
+		assert.ErrCode(t, err, "INVOICE_NOT_ISSUED")
+	})
+}
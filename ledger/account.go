@@ -0,0 +1,111 @@
+package ledger
+
+import (
+	"time"
+
+	"github.com/aboglioli/big-brother/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	AccountAsset     = "ASSET"
+	AccountLiability = "LIABILITY"
+	AccountEquity    = "EQUITY"
+	AccountIncome    = "INCOME"
+	AccountExpense   = "EXPENSE"
+)
+
+// Accounts used by automatic postings. They are created by
+// Service.SetupChart.
+const (
+	AccountInventory         = "1100"
+	AccountReceivable        = "1200"
+	AccountWorkInProgress    = "1300"
+	AccountPayable           = "2100"
+	AccountPurchasesClearing = "2200"
+	AccountTaxesPayable      = "2300"
+	AccountOwnersEquity      = "3100"
+	AccountSales             = "4100"
+	AccountInventoryGains    = "4200"
+	AccountCostOfGoodsSold   = "5100"
+	AccountInventoryLosses   = "5200"
+)
+
+// Account is an account of the chart of accounts. Code is the account key
+// referenced by journal entry lines.
+type Account struct {
+	ID   primitive.ObjectID `json:"id" bson:"_id"`
+	Code string             `json:"code" bson:"code"`
+	Name string             `json:"name" bson:"name"`
+	Type string             `json:"type" bson:"type"`
+
+	CreatedAt time.Time `json:"createdAt" bson:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt" bson:"updatedAt"`
+}
+
+func NewAccount(code, name, accountType string) *Account {
+	return &Account{
+		ID:        primitive.NewObjectID(),
+		Code:      code,
+		Name:      name,
+		Type:      accountType,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+}
+
+// DefaultChart returns the accounts used by automatic postings.
+func DefaultChart() []*Account {
+	return []*Account{
+		NewAccount(AccountInventory, "Inventory", AccountAsset),
+		NewAccount(AccountReceivable, "Accounts receivable", AccountAsset),
+		NewAccount(AccountWorkInProgress, "Work in progress", AccountAsset),
+		NewAccount(AccountPayable, "Accounts payable", AccountLiability),
+		NewAccount(AccountPurchasesClearing, "Purchases clearing", AccountLiability),
+		NewAccount(AccountTaxesPayable, "Taxes payable", AccountLiability),
+		NewAccount(AccountOwnersEquity, "Owner's equity", AccountEquity),
+		NewAccount(AccountSales, "Sales", AccountIncome),
+		NewAccount(AccountInventoryGains, "Inventory gains", AccountIncome),
+		NewAccount(AccountCostOfGoodsSold, "Cost of goods sold", AccountExpense),
+		NewAccount(AccountInventoryLosses, "Inventory losses", AccountExpense),
+	}
+}
+
+// IsDebitNormal returns true for accounts whose balance increases with
+// debits: assets and expenses.
+func (a *Account) IsDebitNormal() bool {
+	return a.Type == AccountAsset || a.Type == AccountExpense
+}
+
+// Balance returns the balance of the account for the given debit and credit
+// totals, positive in the normal side of the account.
+func (a *Account) Balance(debit, credit float64) float64 {
+	if a.IsDebitNormal() {
+		return round(debit - credit)
+	}
+	return round(credit - debit)
+}
+
+func (a *Account) ValidateSchema() error {
+	err := errors.NewValidation("VALIDATE_SCHEMA").SetPath("ledger/account.ValidateSchema")
+
+	if len(a.Code) < 1 || len(a.Code) > 32 {
+		err.AddWithMessage("code", "INVALID_LENGTH", "%d", len(a.Code))
+	}
+
+	if len(a.Name) < 1 || len(a.Name) > 128 {
+		err.AddWithMessage("name", "INVALID_LENGTH", "%d", len(a.Name))
+	}
+
+	switch a.Type {
+	case AccountAsset, AccountLiability, AccountEquity, AccountIncome, AccountExpense:
+	default:
+		err.Add("type", "INVALID")
+	}
+
+	if err.Size() > 0 {
+		return err
+	}
+
+	return nil
+}
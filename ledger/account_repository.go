@@ -0,0 +1,124 @@
+package ledger
+
+import (
+	"context"
+	"time"
+
+	"github.com/aboglioli/big-brother/infrastructure/db"
+	"github.com/aboglioli/big-brother/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type AccountRepository interface {
+	FindAll() ([]*Account, error)
+	FindByCode(code string) (*Account, error)
+
+	Insert(*Account) error
+	Update(*Account) error
+}
+
+type accountRepository struct {
+	collection *mongo.Collection
+}
+
+func NewAccountRepository() (AccountRepository, error) {
+	db, err := db.Get("Ledger")
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &accountRepository{
+		collection: db.Collection("account"),
+	}, nil
+}
+
+func (r *accountRepository) FindAll() ([]*Account, error) {
+	path := "ledger/account_repository.FindAll"
+	ctx := context.Background()
+
+	opts := options.Find().SetSort(bson.M{"code": 1})
+
+	cur, err := r.collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, errors.NewInternal("FIND").SetPath(path).SetRef(err)
+	}
+	defer cur.Close(ctx)
+
+	accounts := make([]*Account, 0)
+	for cur.Next(ctx) {
+		var a Account
+
+		if err := cur.Decode(&a); err != nil {
+			return nil, errors.NewInternal("CUR_DECODE").SetPath(path).SetRef(err)
+		}
+
+		accounts = append(accounts, &a)
+	}
+
+	if err := cur.Err(); err != nil {
+		return nil, errors.NewInternal("CUR").SetPath(path).SetRef(err)
+	}
+
+	return accounts, nil
+}
+
+func (r *accountRepository) FindByCode(code string) (*Account, error) {
+	path := "ledger/account_repository.FindByCode"
+	ctx := context.Background()
+
+	filter := bson.M{
+		"code": code,
+	}
+
+	res := r.collection.FindOne(ctx, filter)
+	if res.Err() != nil {
+		return nil, errors.NewInternal("FIND_ONE").SetPath(path).SetRef(res.Err())
+	}
+
+	var a Account
+	if err := res.Decode(&a); err != nil {
+		return nil, errors.NewInternal("DECODE").SetPath(path).SetRef(err)
+	}
+
+	return &a, nil
+}
+
+func (r *accountRepository) Insert(a *Account) error {
+	ctx := context.Background()
+
+	_, err := r.collection.InsertOne(ctx, a)
+	if err != nil {
+		return errors.NewInternal("INSERT_ONE").SetPath("ledger/account_repository.Insert").SetRef(err)
+	}
+
+	return nil
+}
+
+func (r *accountRepository) Update(a *Account) error {
+	path := "ledger/account_repository.Update"
+	ctx := context.Background()
+
+	if a.ID.IsZero() {
+		return errors.NewInternal("INVALID_OBJECTID").SetPath(path)
+	}
+
+	a.UpdatedAt = time.Now()
+
+	filter := bson.M{
+		"_id": a.ID,
+	}
+
+	update := bson.M{
+		"$set": a,
+	}
+
+	_, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return errors.NewInternal("UPDATE_ONE").SetPath(path).SetRef(err)
+	}
+
+	return nil
+}
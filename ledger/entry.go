@@ -0,0 +1,96 @@
+package ledger
+
+import (
+	"math"
+	"time"
+
+	"github.com/aboglioli/big-brother/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Line is a debit or a credit to an account.
+type Line struct {
+	Account string  `json:"account" bson:"account"`
+	Debit   float64 `json:"debit" bson:"debit"`
+	Credit  float64 `json:"credit" bson:"credit"`
+}
+
+// Entry is a balanced journal entry. Source and Reference identify the
+// document or event that originated the entry (e.g. "stock.movement" and the
+// movement ID), so it is not posted twice. Entries cannot be modified once
+// posted.
+type Entry struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id"`
+	Date        time.Time          `json:"date" bson:"date"`
+	Description string             `json:"description" bson:"description"`
+	Source      string             `json:"source" bson:"source"`
+	Reference   string             `json:"reference" bson:"reference"`
+	Lines       []Line             `json:"lines" bson:"lines"`
+	CreatedAt   time.Time          `json:"createdAt" bson:"createdAt"`
+}
+
+func NewEntry(date time.Time, description string) *Entry {
+	return &Entry{
+		ID:          primitive.NewObjectID(),
+		Date:        date,
+		Description: description,
+		Lines:       make([]Line, 0),
+		CreatedAt:   time.Now(),
+	}
+}
+
+// Debit adds a debit line. Zero amounts are ignored.
+func (e *Entry) Debit(account string, amount float64) {
+	if amount = round(amount); amount != 0 {
+		e.Lines = append(e.Lines, Line{Account: account, Debit: amount})
+	}
+}
+
+// Credit adds a credit line. Zero amounts are ignored.
+func (e *Entry) Credit(account string, amount float64) {
+	if amount = round(amount); amount != 0 {
+		e.Lines = append(e.Lines, Line{Account: account, Credit: amount})
+	}
+}
+
+// Totals returns the sum of debits and credits.
+func (e *Entry) Totals() (float64, float64) {
+	debit, credit := 0.0, 0.0
+	for _, l := range e.Lines {
+		debit += l.Debit
+		credit += l.Credit
+	}
+	return round(debit), round(credit)
+}
+
+func (e *Entry) ValidateSchema() error {
+	err := errors.NewValidation("VALIDATE_SCHEMA").SetPath("ledger/entry.ValidateSchema")
+
+	if len(e.Lines) < 2 {
+		err.AddWithMessage("lines", "INVALID_LENGTH", "%d", len(e.Lines))
+	}
+
+	for i, l := range e.Lines {
+		if l.Account == "" {
+			err.AddWithMessage("lines", "ACCOUNT_REQUIRED", "line %d", i)
+		}
+		if l.Debit < 0 || l.Credit < 0 || (l.Debit == 0) == (l.Credit == 0) {
+			err.AddWithMessage("lines", "INVALID_AMOUNT", "line %d", i)
+		}
+	}
+
+	if debit, credit := e.Totals(); debit != credit {
+		err.AddWithMessage("lines", "UNBALANCED", "debit %.2f != credit %.2f", debit, credit)
+	}
+
+	if err.Size() > 0 {
+		return err
+	}
+
+	return nil
+}
+
+// round rounds amounts to cents.
+func round(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,144 @@
+package ledger
+
+import (
+	"context"
+	"time"
+
+	"github.com/aboglioli/big-brother/infrastructure/db"
+	"github.com/aboglioli/big-brother/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// EntryRepository stores journal entries. Entries are never updated nor
+// deleted. Queries return entries sorted by date.
+type EntryRepository interface {
+	FindByID(id string) (*Entry, error)
+	FindBySource(source, reference string) ([]*Entry, error)
+	FindByDate(from, to *time.Time) ([]*Entry, error)
+	FindByAccount(code string, to *time.Time) ([]*Entry, error)
+
+	Insert(*Entry) error
+}
+
+type entryRepository struct {
+	collection *mongo.Collection
+}
+
+func NewEntryRepository() (EntryRepository, error) {
+	db, err := db.Get("Ledger")
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &entryRepository{
+		collection: db.Collection("entry"),
+	}, nil
+}
+
+func (r *entryRepository) FindByID(id string) (*Entry, error) {
+	path := "ledger/entry_repository.FindByID"
+	ctx := context.Background()
+
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errors.NewInternal("OBJECTID_FROM_HEX").SetPath(path).SetRef(err)
+	}
+
+	filter := bson.M{
+		"_id": objID,
+	}
+
+	res := r.collection.FindOne(ctx, filter)
+	if res.Err() != nil {
+		return nil, errors.NewInternal("FIND_ONE").SetPath(path).SetRef(res.Err())
+	}
+
+	var e Entry
+	if err := res.Decode(&e); err != nil {
+		return nil, errors.NewInternal("DECODE").SetPath(path).SetRef(err)
+	}
+
+	return &e, nil
+}
+
+func (r *entryRepository) FindBySource(source, reference string) ([]*Entry, error) {
+	return r.find("ledger/entry_repository.FindBySource", bson.M{
+		"source":    source,
+		"reference": reference,
+	})
+}
+
+func (r *entryRepository) FindByDate(from, to *time.Time) ([]*Entry, error) {
+	filter := bson.M{}
+	if date := dateFilter(from, to); len(date) > 0 {
+		filter["date"] = date
+	}
+
+	return r.find("ledger/entry_repository.FindByDate", filter)
+}
+
+func (r *entryRepository) FindByAccount(code string, to *time.Time) ([]*Entry, error) {
+	filter := bson.M{
+		"lines.account": code,
+	}
+	if date := dateFilter(nil, to); len(date) > 0 {
+		filter["date"] = date
+	}
+
+	return r.find("ledger/entry_repository.FindByAccount", filter)
+}
+
+func (r *entryRepository) Insert(e *Entry) error {
+	ctx := context.Background()
+
+	_, err := r.collection.InsertOne(ctx, e)
+	if err != nil {
+		return errors.NewInternal("INSERT_ONE").SetPath("ledger/entry_repository.Insert").SetRef(err)
+	}
+
+	return nil
+}
+
+func (r *entryRepository) find(path string, filter bson.M) ([]*Entry, error) {
+	ctx := context.Background()
+
+	opts := options.Find().SetSort(bson.D{{"date", 1}, {"createdAt", 1}})
+
+	cur, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, errors.NewInternal("FIND").SetPath(path).SetRef(err)
+	}
+	defer cur.Close(ctx)
+
+	entries := make([]*Entry, 0)
+	for cur.Next(ctx) {
+		var e Entry
+
+		if err := cur.Decode(&e); err != nil {
+			return nil, errors.NewInternal("CUR_DECODE").SetPath(path).SetRef(err)
+		}
+
+		entries = append(entries, &e)
+	}
+
+	if err := cur.Err(); err != nil {
+		return nil, errors.NewInternal("CUR").SetPath(path).SetRef(err)
+	}
+
+	return entries, nil
+}
+
+func dateFilter(from, to *time.Time) bson.M {
+	filter := bson.M{}
+	if from != nil {
+		filter["$gte"] = *from
+	}
+	if to != nil {
+		filter["$lte"] = *to
+	}
+	return filter
+}
@@ -0,0 +1,26 @@
+package ledger
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aboglioli/big-brother/pkg/tests/assert"
+)
+
+func TestEntryValidation(t *testing.T) {
+	e := NewEntry(time.Now(), "Test")
+	e.Debit(AccountInventory, 100)
+	assert.ErrValidation(t, e.ValidateSchema(), "lines", "INVALID_LENGTH")
+
+	e.Credit(AccountPayable, 90)
+	assert.ErrValidation(t, e.ValidateSchema(), "lines", "UNBALANCED")
+
+	e.Credit(AccountTaxesPayable, 10.001)
+	assert.Ok(t, e.ValidateSchema())
+
+	e.Credit(AccountSales, 0)
+	assert.Equal(t, len(e.Lines), 3)
+
+	e.Lines = append(e.Lines, Line{Account: AccountSales, Debit: 5, Credit: 5})
+	assert.ErrValidation(t, e.ValidateSchema(), "lines", "INVALID_AMOUNT")
+}
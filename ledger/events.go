@@ -0,0 +1,17 @@
+package ledger
+
+import (
+	"github.com/aboglioli/big-brother/pkg/events"
+)
+
+// EntryPostedEvent is published for each journal entry
+type EntryPostedEvent struct {
+	events.Event
+	Entry *Entry `json:"entry"`
+}
+
+func NewEntryPostedEvent(e *Entry) (*EntryPostedEvent, *events.Options) {
+	event := &EntryPostedEvent{events.NewEvent("JournalEntryPosted", e.ID.Hex()), e}
+	opts := &events.Options{"ledger", "topic", "ledger.entry.posted", ""}
+	return event, opts
+}
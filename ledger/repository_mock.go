@@ -0,0 +1,174 @@
+package ledger
+
+import (
+	"sort"
+	"time"
+
+	"github.com/aboglioli/big-brother/pkg/errors"
+	"github.com/aboglioli/big-brother/pkg/tests/mock"
+)
+
+type mockAccountRepository struct {
+	mock.Mock
+	accounts []*Account
+}
+
+func newMockAccountRepository() *mockAccountRepository {
+	return &mockAccountRepository{}
+}
+
+// Helpers
+func (r *mockAccountRepository) Clean() {
+	r.accounts = make([]*Account, 0)
+}
+
+// Implementation
+func (r *mockAccountRepository) FindAll() ([]*Account, error) {
+	r.Called("FindAll")
+
+	accounts := make([]*Account, 0, len(r.accounts))
+	for _, a := range r.accounts {
+		copy := *a
+		accounts = append(accounts, &copy)
+	}
+
+	sort.Slice(accounts, func(i, j int) bool {
+		return accounts[i].Code < accounts[j].Code
+	})
+
+	return accounts, nil
+}
+
+func (r *mockAccountRepository) FindByCode(code string) (*Account, error) {
+	r.Called("FindByCode", code)
+
+	for _, a := range r.accounts {
+		if a.Code == code {
+			copy := *a
+			return &copy, nil
+		}
+	}
+
+	return nil, errors.NewInternal("NOT_FOUND").SetPath("ledger/repository_mock.FindByCode")
+}
+
+func (r *mockAccountRepository) Insert(a *Account) error {
+	r.Called("Insert", a)
+
+	copy := *a
+	r.accounts = append(r.accounts, &copy)
+
+	return nil
+}
+
+func (r *mockAccountRepository) Update(a *Account) error {
+	r.Called("Update", a)
+
+	for _, account := range r.accounts {
+		if account.ID.Hex() == a.ID.Hex() {
+			*account = *a
+			account.UpdatedAt = time.Now()
+			break
+		}
+	}
+
+	return nil
+}
+
+type mockEntryRepository struct {
+	mock.Mock
+	entries []*Entry
+}
+
+func newMockEntryRepository() *mockEntryRepository {
+	return &mockEntryRepository{}
+}
+
+// Helpers
+func (r *mockEntryRepository) Clean() {
+	r.entries = make([]*Entry, 0)
+}
+
+// Implementation
+func (r *mockEntryRepository) FindByID(id string) (*Entry, error) {
+	r.Called("FindByID", id)
+
+	for _, e := range r.entries {
+		if e.ID.Hex() == id {
+			return copyEntry(e), nil
+		}
+	}
+
+	return nil, errors.NewInternal("NOT_FOUND").SetPath("ledger/repository_mock.FindByID")
+}
+
+func (r *mockEntryRepository) FindBySource(source, reference string) ([]*Entry, error) {
+	r.Called("FindBySource", source, reference)
+
+	return r.filter(func(e *Entry) bool {
+		return e.Source == source && e.Reference == reference
+	}), nil
+}
+
+func (r *mockEntryRepository) FindByDate(from, to *time.Time) ([]*Entry, error) {
+	r.Called("FindByDate", from, to)
+
+	return r.filter(func(e *Entry) bool {
+		return inRange(e.Date, from, to)
+	}), nil
+}
+
+func (r *mockEntryRepository) FindByAccount(code string, to *time.Time) ([]*Entry, error) {
+	r.Called("FindByAccount", code, to)
+
+	return r.filter(func(e *Entry) bool {
+		if !inRange(e.Date, nil, to) {
+			return false
+		}
+		for _, l := range e.Lines {
+			if l.Account == code {
+				return true
+			}
+		}
+		return false
+	}), nil
+}
+
+func (r *mockEntryRepository) Insert(e *Entry) error {
+	r.Called("Insert", e)
+
+	r.entries = append(r.entries, copyEntry(e))
+
+	return nil
+}
+
+func (r *mockEntryRepository) filter(f func(*Entry) bool) []*Entry {
+	entries := make([]*Entry, 0)
+	for _, e := range r.entries {
+		if f(e) {
+			entries = append(entries, copyEntry(e))
+		}
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Date.Before(entries[j].Date)
+	})
+
+	return entries
+}
+
+func inRange(date time.Time, from, to *time.Time) bool {
+	if from != nil && date.Before(*from) {
+		return false
+	}
+	if to != nil && date.After(*to) {
+		return false
+	}
+	return true
+}
+
+func copyEntry(e *Entry) *Entry {
+	copy := *e
+	copy.Lines = append([]Line(nil), e.Lines...)
+	return &copy
+}
@@ -0,0 +1,473 @@
+package ledger
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aboglioli/big-brother/invoice"
+	"github.com/aboglioli/big-brother/pkg/errors"
+	"github.com/aboglioli/big-brother/pkg/events"
+	"github.com/aboglioli/big-brother/purchase"
+	"github.com/aboglioli/big-brother/stock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Sources of journal entries
+const (
+	SourceManual        = "manual"
+	SourceStockMovement = "stock.movement"
+	SourceGoodsReceipt  = "purchase.receipt"
+	SourceInvoice       = "invoice"
+)
+
+// Balance is the debit, credit and balance of an account.
+type Balance struct {
+	Account string  `json:"account"`
+	Name    string  `json:"name"`
+	Type    string  `json:"type"`
+	Debit   float64 `json:"debit"`
+	Credit  float64 `json:"credit"`
+	Balance float64 `json:"balance"`
+}
+
+// TrialBalance lists the balances of all accounts at a date. Total debits
+// and credits are equal.
+type TrialBalance struct {
+	Date     *time.Time `json:"date"`
+	Accounts []*Balance `json:"accounts"`
+	Debit    float64    `json:"debit"`
+	Credit   float64    `json:"credit"`
+}
+
+// StatementLine is an entry of an account statement with the running
+// balance.
+type StatementLine struct {
+	Entry       primitive.ObjectID `json:"entry"`
+	Date        time.Time          `json:"date"`
+	Description string             `json:"description"`
+	Debit       float64            `json:"debit"`
+	Credit      float64            `json:"credit"`
+	Balance     float64            `json:"balance"`
+}
+
+// Statement lists the entries of an account in a period.
+type Statement struct {
+	Account *Account         `json:"account"`
+	From    *time.Time       `json:"from"`
+	To      *time.Time       `json:"to"`
+	Opening float64          `json:"opening"`
+	Lines   []*StatementLine `json:"lines"`
+	Debit   float64          `json:"debit"`
+	Credit  float64          `json:"credit"`
+	Closing float64          `json:"closing"`
+}
+
+type Service interface {
+	GetAccounts() ([]*Account, error)
+	GetAccount(code string) (*Account, error)
+	CreateAccount(req *CreateAccountRequest) (*Account, error)
+	UpdateAccount(code string, req *UpdateAccountRequest) (*Account, error)
+	SetupChart() ([]*Account, error)
+
+	GetEntry(id string) (*Entry, error)
+	FindEntries(from, to *time.Time) ([]*Entry, error)
+	Post(req *PostRequest) (*Entry, error)
+	PostMovement(m *stock.Movement) (*Entry, error)
+	PostReceipt(o *purchase.Order) (*Entry, error)
+	PostInvoice(inv *invoice.Invoice) (*Entry, error)
+
+	TrialBalance(to *time.Time) (*TrialBalance, error)
+	Statement(code string, from, to *time.Time) (*Statement, error)
+}
+
+type service struct {
+	accountRepository AccountRepository
+	entryRepository   EntryRepository
+	eventMgr          events.Manager
+}
+
+func NewService(accountRepo AccountRepository, entryRepo EntryRepository, e events.Manager) Service {
+	return &service{
+		accountRepository: accountRepo,
+		entryRepository:   entryRepo,
+		eventMgr:          e,
+	}
+}
+
+func (s *service) GetAccounts() ([]*Account, error) {
+	accounts, err := s.accountRepository.FindAll()
+	if err != nil {
+		return nil, errors.NewStatus("FIND_ALL").SetPath("ledger/service.GetAccounts").SetRef(err)
+	}
+	return accounts, nil
+}
+
+func (s *service) GetAccount(code string) (*Account, error) {
+	a, err := s.accountRepository.FindByCode(code)
+	if err != nil {
+		return nil, errors.NewStatus("ACCOUNT_NOT_FOUND").SetPath("ledger/service.GetAccount").SetStatus(404).SetRef(err).SetMessage("%s", code)
+	}
+	return a, nil
+}
+
+type CreateAccountRequest struct {
+	Code string `json:"code" binding:"required"`
+	Name string `json:"name" binding:"required"`
+	Type string `json:"type" binding:"required"`
+}
+
+func (s *service) CreateAccount(req *CreateAccountRequest) (*Account, error) {
+	path := "ledger/service.CreateAccount"
+
+	if _, err := s.accountRepository.FindByCode(req.Code); err == nil {
+		return nil, errors.NewStatus("ACCOUNT_ALREADY_EXISTS").SetPath(path).SetMessage("%s", req.Code)
+	}
+
+	a := NewAccount(req.Code, req.Name, req.Type)
+	if err := a.ValidateSchema(); err != nil {
+		return nil, err
+	}
+
+	if err := s.accountRepository.Insert(a); err != nil {
+		return nil, errors.NewStatus("INSERT").SetPath(path).SetRef(err)
+	}
+
+	return a, nil
+}
+
+// UpdateAccountRequest only allows renaming accounts: codes are referenced by
+// entries and types define their balances.
+type UpdateAccountRequest struct {
+	Name *string `json:"name"`
+}
+
+func (s *service) UpdateAccount(code string, req *UpdateAccountRequest) (*Account, error) {
+	a, err := s.GetAccount(code)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		a.Name = *req.Name
+	}
+
+	if err := a.ValidateSchema(); err != nil {
+		return nil, err
+	}
+
+	if err := s.accountRepository.Update(a); err != nil {
+		return nil, errors.NewStatus("UPDATE").SetPath("ledger/service.UpdateAccount").SetRef(err)
+	}
+
+	return a, nil
+}
+
+// SetupChart creates the missing accounts of the default chart and returns
+// the created ones.
+func (s *service) SetupChart() ([]*Account, error) {
+	created := make([]*Account, 0)
+	for _, a := range DefaultChart() {
+		if _, err := s.accountRepository.FindByCode(a.Code); err == nil {
+			continue
+		}
+
+		if err := s.accountRepository.Insert(a); err != nil {
+			return nil, errors.NewStatus("INSERT").SetPath("ledger/service.SetupChart").SetRef(err)
+		}
+		created = append(created, a)
+	}
+
+	return created, nil
+}
+
+func (s *service) GetEntry(id string) (*Entry, error) {
+	e, err := s.entryRepository.FindByID(id)
+	if err != nil {
+		return nil, errors.NewStatus("ENTRY_NOT_FOUND").SetPath("ledger/service.GetEntry").SetStatus(404).SetRef(err)
+	}
+	return e, nil
+}
+
+func (s *service) FindEntries(from, to *time.Time) ([]*Entry, error) {
+	entries, err := s.entryRepository.FindByDate(from, to)
+	if err != nil {
+		return nil, errors.NewStatus("FIND_ENTRIES").SetPath("ledger/service.FindEntries").SetRef(err)
+	}
+	return entries, nil
+}
+
+type PostRequest struct {
+	Date        *time.Time `json:"date"`
+	Description string     `json:"description" binding:"required"`
+	Lines       []Line     `json:"lines" binding:"required"`
+}
+
+// Post posts a manual journal entry.
+func (s *service) Post(req *PostRequest) (*Entry, error) {
+	date := time.Now()
+	if req.Date != nil {
+		date = *req.Date
+	}
+
+	e := NewEntry(date, req.Description)
+	e.Source = SourceManual
+	for _, l := range req.Lines {
+		e.Lines = append(e.Lines, Line{Account: l.Account, Debit: round(l.Debit), Credit: round(l.Credit)})
+	}
+
+	return s.post(e)
+}
+
+// PostMovement posts the inventory valuation of a stock movement at
+// composition cost. Movements without cost are not posted and nil is
+// returned.
+func (s *service) PostMovement(m *stock.Movement) (*Entry, error) {
+	if round(m.Cost) == 0 {
+		return nil, nil
+	}
+
+	var debit, credit, description string
+	switch m.Type {
+	case stock.MovementReceipt:
+		debit, credit, description = AccountInventory, AccountPurchasesClearing, "Stock receipt"
+	case stock.MovementProduction:
+		debit, credit, description = AccountInventory, AccountWorkInProgress, "Production completed"
+	case stock.MovementConsumption:
+		debit, credit, description = AccountWorkInProgress, AccountInventory, "Production consumption"
+	case stock.MovementIssue:
+		debit, credit, description = AccountCostOfGoodsSold, AccountInventory, "Stock issue"
+	case stock.MovementAdjustment:
+		if m.Incoming {
+			debit, credit = AccountInventory, AccountInventoryGains
+		} else {
+			debit, credit = AccountInventoryLosses, AccountInventory
+		}
+		description = "Stock adjustment"
+	default:
+		return nil, errors.NewStatus("UNKNOWN_MOVEMENT_TYPE").SetPath("ledger/service.PostMovement").SetMessage("%s", m.Type)
+	}
+
+	e := NewEntry(m.CreatedAt, fmt.Sprintf("%s of %s", description, m.Composition.Hex()))
+	e.Source = SourceStockMovement
+	e.Reference = m.ID.Hex()
+	e.Debit(debit, m.Cost)
+	e.Credit(credit, m.Cost)
+
+	return s.post(e)
+}
+
+// PostReceipt posts the last goods receipt of a purchase order at purchase
+// price: the payable to the supplier against the purchases clearing account,
+// where the inventory receipt was posted at composition cost.
+func (s *service) PostReceipt(o *purchase.Order) (*Entry, error) {
+	path := "ledger/service.PostReceipt"
+
+	if len(o.Receipts) == 0 {
+		return nil, errors.NewStatus("EMPTY_RECEIPT").SetPath(path)
+	}
+	r := o.Receipts[len(o.Receipts)-1]
+
+	amount := 0.0
+	for _, rl := range r.Lines {
+		l := o.FindLine(rl.Composition.Hex())
+		if l == nil {
+			return nil, errors.NewStatus("COMPOSITION_NOT_IN_ORDER").SetPath(path).SetMessage("%s", rl.Composition.Hex())
+		}
+		amount += l.PriceFor(rl.Quantity)
+	}
+
+	e := NewEntry(r.Date, fmt.Sprintf("Goods receipt of purchase order %s", o.ID.Hex()))
+	e.Source = SourceGoodsReceipt
+	e.Reference = r.ID.Hex()
+	e.Debit(AccountPurchasesClearing, amount)
+	e.Credit(AccountPayable, amount)
+
+	return s.post(e)
+}
+
+// PostInvoice posts an issued invoice: receivable against sales and taxes
+// payable. Credit notes are posted reversed.
+func (s *service) PostInvoice(inv *invoice.Invoice) (*Entry, error) {
+	path := "ledger/service.PostInvoice"
+
+	if !inv.IsIssued() {
+		return nil, errors.NewStatus("INVOICE_NOT_ISSUED").SetPath(path)
+	}
+
+	date := inv.CreatedAt
+	if inv.IssuedAt != nil {
+		date = *inv.IssuedAt
+	}
+
+	var e *Entry
+	if inv.Type == invoice.TypeCreditNote {
+		e = NewEntry(date, fmt.Sprintf("Credit note %d", inv.Number))
+		e.Debit(AccountSales, inv.Subtotal)
+		e.Debit(AccountTaxesPayable, inv.Tax)
+		e.Credit(AccountReceivable, inv.Total)
+	} else {
+		e = NewEntry(date, fmt.Sprintf("Invoice %d", inv.Number))
+		e.Debit(AccountReceivable, inv.Total)
+		e.Credit(AccountSales, inv.Subtotal)
+		e.Credit(AccountTaxesPayable, inv.Tax)
+	}
+	e.Source = SourceInvoice
+	e.Reference = inv.ID.Hex()
+
+	return s.post(e)
+}
+
+// TrialBalance returns the balances of all accounts at a date. If to is nil,
+// all entries are included.
+func (s *service) TrialBalance(to *time.Time) (*TrialBalance, error) {
+	accounts, err := s.GetAccounts()
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := s.FindEntries(nil, to)
+	if err != nil {
+		return nil, err
+	}
+
+	balances := make(map[string]*Balance)
+	tb := &TrialBalance{
+		Date:     to,
+		Accounts: make([]*Balance, 0, len(accounts)),
+	}
+	for _, a := range accounts {
+		b := &Balance{Account: a.Code, Name: a.Name, Type: a.Type}
+		balances[a.Code] = b
+		tb.Accounts = append(tb.Accounts, b)
+	}
+
+	for _, e := range entries {
+		for _, l := range e.Lines {
+			b, ok := balances[l.Account]
+			if !ok {
+				b = &Balance{Account: l.Account}
+				balances[l.Account] = b
+				tb.Accounts = append(tb.Accounts, b)
+			}
+			b.Debit += l.Debit
+			b.Credit += l.Credit
+		}
+	}
+
+	for _, a := range accounts {
+		b := balances[a.Code]
+		b.Debit, b.Credit = round(b.Debit), round(b.Credit)
+		b.Balance = a.Balance(b.Debit, b.Credit)
+	}
+	for _, b := range tb.Accounts {
+		tb.Debit += b.Debit
+		tb.Credit += b.Credit
+	}
+	tb.Debit, tb.Credit = round(tb.Debit), round(tb.Credit)
+
+	return tb, nil
+}
+
+// Statement returns the entries of an account in a period with running
+// balances. The opening balance includes all entries before from.
+func (s *service) Statement(code string, from, to *time.Time) (*Statement, error) {
+	a, err := s.GetAccount(code)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := s.entryRepository.FindByAccount(code, to)
+	if err != nil {
+		return nil, errors.NewStatus("FIND_ENTRIES").SetPath("ledger/service.Statement").SetRef(err)
+	}
+
+	st := &Statement{
+		Account: a,
+		From:    from,
+		To:      to,
+		Lines:   make([]*StatementLine, 0),
+	}
+
+	balance := 0.0
+	for _, e := range entries {
+		debit, credit := 0.0, 0.0
+		for _, l := range e.Lines {
+			if l.Account == code {
+				debit += l.Debit
+				credit += l.Credit
+			}
+		}
+		balance += a.Balance(debit, credit)
+
+		if from != nil && e.Date.Before(*from) {
+			st.Opening = round(balance)
+			continue
+		}
+
+		st.Debit += debit
+		st.Credit += credit
+		st.Lines = append(st.Lines, &StatementLine{
+			Entry:       e.ID,
+			Date:        e.Date,
+			Description: e.Description,
+			Debit:       round(debit),
+			Credit:      round(credit),
+			Balance:     round(balance),
+		})
+	}
+
+	st.Debit, st.Credit = round(st.Debit), round(st.Credit)
+	st.Closing = round(balance)
+
+	return st, nil
+}
+
+// post validates and inserts an entry. Entries with a source reference
+// already posted return ENTRY_ALREADY_POSTED, so events can be redelivered.
+/**
+* @api {topic} ledger.entry.posted ledger.entry.posted
+* @apiName JournalEntryPosted
+* @apiGroup RabbitMQ
+*
+* @apiDescription Emits a new event for each posted journal entry.
+*
+* @apiSuccessExample {json} Body
+* {
+* 	"type": "JournalEntryPosted",
+* 	"entry": entry data
+* }
+ */
+func (s *service) post(e *Entry) (*Entry, error) {
+	path := "ledger/service.post"
+
+	if err := e.ValidateSchema(); err != nil {
+		return nil, err
+	}
+
+	for _, l := range e.Lines {
+		if _, err := s.GetAccount(l.Account); err != nil {
+			return nil, err
+		}
+	}
+
+	if e.Reference != "" {
+		posted, err := s.entryRepository.FindBySource(e.Source, e.Reference)
+		if err != nil {
+			return nil, errors.NewStatus("FIND_ENTRIES").SetPath(path).SetRef(err)
+		}
+		if len(posted) > 0 {
+			return nil, errors.NewStatus("ENTRY_ALREADY_POSTED").SetPath(path).SetMessage("%s %s", e.Source, e.Reference)
+		}
+	}
+
+	if err := s.entryRepository.Insert(e); err != nil {
+		return nil, errors.NewStatus("INSERT").SetPath(path).SetRef(err)
+	}
+
+	event, opts := NewEntryPostedEvent(e)
+	if err := s.eventMgr.Publish(event, opts); err != nil {
+		return nil, errors.NewStatus("FAILED_TO_PUBLISH").SetPath(path).SetRef(err)
+	}
+
+	return e, nil
+}
@@ -0,0 +1,151 @@
+package ledger
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aboglioli/big-brother/invoice"
+	"github.com/aboglioli/big-brother/pkg/events"
+	"github.com/aboglioli/big-brother/pkg/quantity"
+	"github.com/aboglioli/big-brother/pkg/tests/assert"
+	"github.com/aboglioli/big-brother/purchase"
+	"github.com/aboglioli/big-brother/stock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func newMovement(mType string, cost float64, incoming bool, date time.Time) *stock.Movement {
+	m := stock.NewMovement(mType, primitive.NewObjectID(), quantity.Quantity{1, "kg"}, incoming)
+	m.Cost = cost
+	m.CreatedAt = date
+	return m
+}
+
+func TestPostings(t *testing.T) {
+	eventMgr := events.GetMockManager()
+	serv := NewService(newMockAccountRepository(), newMockEntryRepository(), eventMgr)
+
+	created, err := serv.SetupChart()
+	assert.Ok(t, err)
+	assert.Equal(t, len(created), len(DefaultChart()))
+	created, err = serv.SetupChart()
+	assert.Ok(t, err)
+	assert.Equal(t, len(created), 0)
+
+	day1 := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	day2 := day1.AddDate(0, 0, 1)
+
+	t.Run("Stock movements", func(t *testing.T) {
+		receipt := newMovement(stock.MovementReceipt, 100, true, day1)
+		e, err := serv.PostMovement(receipt)
+		assert.Ok(t, err)
+		assert.Equal(t, e.Lines[0], Line{Account: AccountInventory, Debit: 100})
+		assert.Equal(t, e.Lines[1], Line{Account: AccountPurchasesClearing, Credit: 100})
+
+		_, err = serv.PostMovement(receipt)
+		assert.ErrCode(t, err, "ENTRY_ALREADY_POSTED")
+
+		e, err = serv.PostMovement(newMovement(stock.MovementIssue, 0, false, day1))
+		assert.Ok(t, err)
+		assert.Nil(t, e)
+
+		for _, m := range []*stock.Movement{
+			newMovement(stock.MovementConsumption, 60, false, day2),
+			newMovement(stock.MovementProduction, 60, true, day2),
+			newMovement(stock.MovementIssue, 50, false, day2),
+			newMovement(stock.MovementAdjustment, 5, false, day2),
+		} {
+			_, err := serv.PostMovement(m)
+			assert.Ok(t, err)
+		}
+	})
+
+	t.Run("Goods receipt", func(t *testing.T) {
+		o := purchase.NewOrder(primitive.NewObjectID())
+		compID := primitive.NewObjectID()
+		o.Lines = append(o.Lines, purchase.Line{Composition: compID, Quantity: quantity.Quantity{2, "kg"}, Price: 210})
+		o.Receipts = append(o.Receipts, purchase.Receipt{
+			ID:    primitive.NewObjectID(),
+			Date:  day1,
+			Lines: []purchase.ReceiptLine{purchase.ReceiptLine{Composition: compID, Quantity: quantity.Quantity{1, "kg"}}},
+		})
+
+		e, err := serv.PostReceipt(o)
+		assert.Ok(t, err)
+		assert.Equal(t, e.Lines[0], Line{Account: AccountPurchasesClearing, Debit: 105})
+		assert.Equal(t, e.Lines[1], Line{Account: AccountPayable, Credit: 105})
+	})
+
+	t.Run("Invoices", func(t *testing.T) {
+		inv := invoice.NewInvoice(primitive.NewObjectID(), primitive.NewObjectID())
+		inv.AddLine(invoice.Line{Composition: primitive.NewObjectID(), Quantity: quantity.Quantity{1, "kg"}, Price: 80, TaxRate: 0.21})
+
+		_, err := serv.PostInvoice(inv)
+		assert.ErrCode(t, err, "INVOICE_NOT_ISSUED")
+
+		inv.Issue(1)
+		inv.IssuedAt = &day2
+		e, err := serv.PostInvoice(inv)
+		assert.Ok(t, err)
+		assert.Equal(t, len(e.Lines), 3)
+
+		cn := invoice.NewCreditNote(inv, "")
+		cn.AddLine(invoice.Line{Composition: primitive.NewObjectID(), Quantity: quantity.Quantity{1, "kg"}, Price: 20, TaxRate: 0.21})
+		cn.Issue(1)
+		cn.IssuedAt = &day2
+		e, err = serv.PostInvoice(cn)
+		assert.Ok(t, err)
+		assert.Equal(t, e.Lines[0], Line{Account: AccountSales, Debit: 20})
+	})
+
+	t.Run("Manual entries", func(t *testing.T) {
+		_, err := serv.Post(&PostRequest{
+			Description: "Unknown account",
+			Lines:       []Line{Line{Account: "9999", Debit: 1}, Line{Account: AccountOwnersEquity, Credit: 1}},
+		})
+		assert.ErrCode(t, err, "ACCOUNT_NOT_FOUND")
+
+		_, err = serv.Post(&PostRequest{
+			Description: "Unbalanced",
+			Lines:       []Line{Line{Account: AccountInventory, Debit: 1}, Line{Account: AccountOwnersEquity, Credit: 2}},
+		})
+		assert.ErrValidation(t, err, "lines", "UNBALANCED")
+	})
+
+	t.Run("Trial balance", func(t *testing.T) {
+		tb, err := serv.TrialBalance(nil)
+		assert.Ok(t, err)
+		assert.Equal(t, tb.Debit, tb.Credit)
+
+		balances := make(map[string]float64)
+		for _, b := range tb.Accounts {
+			balances[b.Account] = b.Balance
+		}
+		assert.Equal(t, balances[AccountInventory], 45.0)
+		assert.Equal(t, balances[AccountWorkInProgress], 0.0)
+		assert.Equal(t, balances[AccountPurchasesClearing], -5.0)
+		assert.Equal(t, balances[AccountPayable], 105.0)
+		assert.Equal(t, balances[AccountReceivable], 72.6)
+		assert.Equal(t, balances[AccountSales], 60.0)
+		assert.Equal(t, balances[AccountTaxesPayable], 12.6)
+		assert.Equal(t, balances[AccountCostOfGoodsSold], 50.0)
+		assert.Equal(t, balances[AccountInventoryLosses], 5.0)
+
+		tb, err = serv.TrialBalance(&day1)
+		assert.Ok(t, err)
+		assert.Equal(t, tb.Debit, 205.0)
+	})
+
+	t.Run("Statement", func(t *testing.T) {
+		st, err := serv.Statement(AccountInventory, &day2, nil)
+		assert.Ok(t, err)
+		assert.Equal(t, st.Opening, 100.0)
+		assert.Equal(t, len(st.Lines), 4)
+		assert.Equal(t, st.Lines[0].Balance, 40.0)
+		assert.Equal(t, st.Debit, 60.0)
+		assert.Equal(t, st.Credit, 115.0)
+		assert.Equal(t, st.Closing, 45.0)
+
+		_, err = serv.Statement("9999", nil, nil)
+		assert.ErrCode(t, err, "ACCOUNT_NOT_FOUND")
+	})
+}
@@ -0,0 +1,351 @@
+package mrp
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/aboglioli/big-brother/composition"
+	"github.com/aboglioli/big-brother/pkg/errors"
+	"github.com/aboglioli/big-brother/pkg/quantity"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	OrderPurchase   = "PURCHASE"
+	OrderProduction = "PRODUCTION"
+
+	// maxLevel limits the depth of the dependency graph to detect cycles.
+	maxLevel = 64
+
+	epsilon = 1e-9
+)
+
+// Demand is a gross requirement of a composition at a date. Source describes
+// its origin: "sales:<order id>", "manual" or "forecast:<method>".
+type Demand struct {
+	Composition primitive.ObjectID `json:"composition"`
+	Quantity    quantity.Quantity  `json:"quantity"`
+	Date        time.Time          `json:"date"`
+	Source      string             `json:"source"`
+}
+
+// Receipt is a scheduled receipt of an open purchase or production order.
+type Receipt struct {
+	Composition primitive.ObjectID `json:"composition"`
+	Quantity    quantity.Quantity  `json:"quantity"`
+	Date        time.Time          `json:"date"`
+	Source      string             `json:"source"`
+}
+
+// PlannedOrder is an order suggested by the plan. Compositions with
+// dependencies are produced and the rest are purchased. Late is set when the
+// order should have been released before the plan date.
+type PlannedOrder struct {
+	Type        string              `json:"type"`
+	Composition primitive.ObjectID  `json:"composition"`
+	Supplier    *primitive.ObjectID `json:"supplier"`
+	Quantity    quantity.Quantity   `json:"quantity"`
+	ReleaseDate time.Time           `json:"releaseDate"`
+	DueDate     time.Time           `json:"dueDate"`
+	Level       int                 `json:"level"`
+	Late        bool                `json:"late"`
+}
+
+// Requirement summarizes the netting of a composition. Level is the low-level
+// code: the deepest level where the composition appears in the dependency
+// graph of the demanded compositions.
+type Requirement struct {
+	Composition primitive.ObjectID `json:"composition"`
+	Name        string             `json:"name"`
+	Level       int                `json:"level"`
+	Gross       quantity.Quantity  `json:"gross"`
+	OnHand      quantity.Quantity  `json:"onHand"`
+	Scheduled   quantity.Quantity  `json:"scheduled"`
+	SafetyStock quantity.Quantity  `json:"safetyStock"`
+	Net         quantity.Quantity  `json:"net"`
+	Planned     quantity.Quantity  `json:"planned"`
+}
+
+type Plan struct {
+	Date         time.Time       `json:"date"`
+	Requirements []*Requirement  `json:"requirements"`
+	Orders       []*PlannedOrder `json:"orders"`
+}
+
+// planner computes a plan level by level. Quantities are in the unit of their
+// composition.
+type planner struct {
+	date         time.Time
+	getByID      func(id string) (*composition.Composition, error)
+	compositions map[string]*composition.Composition
+	levels       map[string]int
+	demands      map[string][]*event
+	receipts     map[string][]*event
+}
+
+type event struct {
+	quantity float64
+	date     time.Time
+}
+
+func newPlanner(date time.Time, getByID func(id string) (*composition.Composition, error)) *planner {
+	return &planner{
+		date:         date,
+		getByID:      getByID,
+		compositions: make(map[string]*composition.Composition),
+		levels:       make(map[string]int),
+		demands:      make(map[string][]*event),
+		receipts:     make(map[string][]*event),
+	}
+}
+
+// Calculate nets the demands of the compositions against stock, safety stock
+// and scheduled receipts, from top level compositions to raw materials.
+// Planned orders are due at the date of the requirement, released lead time
+// days before and rounded up to lot size. Dependencies of planned production
+// orders are demanded at their release date.
+func Calculate(date time.Time, demands []*Demand, receipts []*Receipt, getByID func(id string) (*composition.Composition, error)) (*Plan, error) {
+	p := newPlanner(date, getByID)
+
+	for _, d := range demands {
+		if err := p.load(d.Composition.Hex(), 0); err != nil {
+			return nil, err
+		}
+
+		if !d.Quantity.IsValid() {
+			return nil, errors.NewStatus("INCOMPATIBLE_QUANTITY").SetPath("mrp/plan.Calculate").SetMessage("%v", d.Quantity)
+		}
+		if err := p.addDemand(d.Composition.Hex(), d.Quantity, d.Date); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, r := range receipts {
+		id := r.Composition.Hex()
+		if _, ok := p.compositions[id]; !ok {
+			continue
+		}
+
+		q, err := p.toUnit(id, r.Quantity)
+		if err != nil {
+			return nil, err
+		}
+		p.receipts[id] = append(p.receipts[id], &event{q, r.Date})
+	}
+
+	return p.plan()
+}
+
+// load assigns the low-level code of a composition and its dependencies.
+func (p *planner) load(id string, level int) error {
+	if level > maxLevel {
+		return errors.NewStatus("CYCLIC_DEPENDENCIES").SetPath("mrp/plan.load").SetMessage("%s", id)
+	}
+
+	comp, ok := p.compositions[id]
+	if !ok {
+		var err error
+		comp, err = p.getByID(id)
+		if err != nil {
+			return err
+		}
+		p.compositions[id] = comp
+	} else if p.levels[id] >= level {
+		return nil
+	}
+	p.levels[id] = level
+
+	for _, dep := range comp.Dependencies {
+		if err := p.load(dep.On.Hex(), level+1); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (p *planner) addDemand(id string, q quantity.Quantity, date time.Time) error {
+	n, err := p.toUnit(id, q)
+	if err != nil {
+		return err
+	}
+
+	p.demands[id] = append(p.demands[id], &event{n, date})
+	return nil
+}
+
+// toUnit converts q to the unit of a loaded composition.
+func (p *planner) toUnit(id string, q quantity.Quantity) (float64, error) {
+	comp := p.compositions[id]
+	converted, err := comp.ToUnit(q)
+	if err != nil {
+		return 0, errors.NewStatus("INCOMPATIBLE_QUANTITY").SetPath("mrp/plan.toUnit").SetMessage("%v != %v", q, comp.Unit).SetRef(err)
+	}
+	return converted.Quantity, nil
+}
+
+func (p *planner) plan() (*Plan, error) {
+	ids := make([]string, 0, len(p.compositions))
+	for id := range p.compositions {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if p.levels[ids[i]] != p.levels[ids[j]] {
+			return p.levels[ids[i]] < p.levels[ids[j]]
+		}
+		return ids[i] < ids[j]
+	})
+
+	plan := &Plan{
+		Date:         p.date,
+		Requirements: make([]*Requirement, 0, len(ids)),
+		Orders:       make([]*PlannedOrder, 0),
+	}
+
+	// Dependencies always have a higher level, so their dependent demand is
+	// complete when they are netted.
+	for _, id := range ids {
+		req, orders, err := p.net(id)
+		if err != nil {
+			return nil, err
+		}
+		plan.Requirements = append(plan.Requirements, req)
+		plan.Orders = append(plan.Orders, orders...)
+	}
+
+	return plan, nil
+}
+
+func (p *planner) net(id string) (*Requirement, []*PlannedOrder, error) {
+	comp := p.compositions[id]
+	level := p.levels[id]
+
+	demands := append([]*event{&event{0, p.date}}, p.demands[id]...)
+	receipts := p.receipts[id]
+	sort.SliceStable(demands, func(i, j int) bool { return demands[i].date.Before(demands[j].date) })
+	sort.SliceStable(receipts, func(i, j int) bool { return receipts[i].date.Before(receipts[j].date) })
+
+	// Blocked stock can't be used. Reserved stock is kept because its sales
+	// orders are demands of the plan.
+	onHand, err := p.toUnit(id, comp.Stock)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !comp.Blocked.IsEmpty() {
+		blocked, err := p.toUnit(id, comp.Blocked)
+		if err != nil {
+			return nil, nil, err
+		}
+		onHand -= blocked
+	}
+	safety := 0.0
+	if !comp.MinimumStock.IsEmpty() {
+		if safety, err = p.toUnit(id, comp.MinimumStock); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	gross, scheduled, net, planned := 0.0, 0.0, 0.0, 0.0
+	for _, r := range receipts {
+		scheduled += r.quantity
+	}
+
+	orders := make([]*PlannedOrder, 0)
+	projected := onHand
+	next := 0
+	for _, d := range demands {
+		for next < len(receipts) && !receipts[next].date.After(d.date) {
+			projected += receipts[next].quantity
+			next++
+		}
+
+		gross += d.quantity
+		projected -= d.quantity
+		if projected >= safety-epsilon {
+			continue
+		}
+
+		shortage := safety - projected
+		q, err := p.lotSize(comp, shortage)
+		if err != nil {
+			return nil, nil, err
+		}
+		net += shortage
+		planned += q
+		projected += q
+
+		o := &PlannedOrder{
+			Type:        OrderPurchase,
+			Composition: comp.ID,
+			Quantity:    round(q, comp.Unit),
+			ReleaseDate: d.date.AddDate(0, 0, -comp.LeadTime),
+			DueDate:     d.date,
+			Level:       level,
+		}
+		o.Late = o.ReleaseDate.Before(p.date)
+		if len(comp.Dependencies) > 0 {
+			o.Type = OrderProduction
+			if err := p.explode(comp, q, o.ReleaseDate); err != nil {
+				return nil, nil, err
+			}
+		}
+		orders = append(orders, o)
+	}
+
+	req := &Requirement{
+		Composition: comp.ID,
+		Name:        comp.Name,
+		Level:       level,
+		Gross:       round(gross, comp.Unit),
+		OnHand:      round(onHand, comp.Unit),
+		Scheduled:   round(scheduled, comp.Unit),
+		SafetyStock: round(safety, comp.Unit),
+		Net:         round(net, comp.Unit),
+		Planned:     round(planned, comp.Unit),
+	}
+
+	return req, orders, nil
+}
+
+// explode demands the dependencies of a planned production order.
+func (p *planner) explode(comp *composition.Composition, q float64, date time.Time) error {
+	factor, err := quantity.Quantity{Quantity: q, Unit: comp.Unit.Unit}.Ratio(comp.Unit)
+	if err != nil {
+		return errors.NewStatus("INVALID_UNIT").SetPath("mrp/plan.explode").SetMessage("%v", comp.Unit).SetRef(err)
+	}
+
+	for _, dep := range comp.Dependencies {
+		if err := p.addDemand(dep.On.Hex(), dep.Quantity.Multiply(factor), date); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// lotSize rounds a quantity up to a multiple of the composition lot size.
+func (p *planner) lotSize(comp *composition.Composition, q float64) (float64, error) {
+	if comp.LotSize.IsEmpty() {
+		return q, nil
+	}
+
+	lot, err := p.toUnit(comp.ID.Hex(), comp.LotSize)
+	if err != nil || lot <= 0 {
+		return q, err
+	}
+
+	lots, err := quantity.Quantity{Quantity: q, Unit: comp.Unit.Unit}.Ratio(quantity.Quantity{Quantity: lot, Unit: comp.Unit.Unit})
+	if err != nil {
+		return q, err
+	}
+
+	return math.Ceil(lots-epsilon) * lot, nil
+}
+
+// round rounds a quantity in the unit of a composition.
+func round(n float64, unit quantity.Quantity) quantity.Quantity {
+	return quantity.Quantity{
+		Quantity: math.Round(n*1000) / 1000,
+		Unit:     unit.Unit,
+	}
+}
@@ -0,0 +1,207 @@
+package mrp
+
+import (
+	"time"
+
+	"github.com/aboglioli/big-brother/composition"
+	"github.com/aboglioli/big-brother/forecast"
+	"github.com/aboglioli/big-brother/pkg/errors"
+	"github.com/aboglioli/big-brother/pkg/quantity"
+	"github.com/aboglioli/big-brother/production"
+	"github.com/aboglioli/big-brother/purchase"
+	"github.com/aboglioli/big-brother/sales"
+	"github.com/aboglioli/big-brother/supplier"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Service interface {
+	Run(req *RunRequest) (*Plan, error)
+}
+
+type service struct {
+	compositionService composition.Service
+	salesService       sales.Service
+	purchaseService    purchase.Service
+	productionService  production.Service
+	supplierService    supplier.Service
+	forecastService    forecast.Service
+}
+
+func NewService(compServ composition.Service, salesServ sales.Service, purchaseServ purchase.Service, productionServ production.Service, supplierServ supplier.Service, forecastServ forecast.Service) Service {
+	return &service{
+		compositionService: compServ,
+		salesService:       salesServ,
+		purchaseService:    purchaseServ,
+		productionService:  productionServ,
+		supplierService:    supplierServ,
+		forecastService:    forecastServ,
+	}
+}
+
+type DemandRequest struct {
+	Composition string            `json:"composition" binding:"required"`
+	Quantity    quantity.Quantity `json:"quantity" binding:"required"`
+	Date        *time.Time        `json:"date"`
+}
+
+// ForecastRequest demands the forecast of a composition. Each forecasted
+// period is due at its start.
+type ForecastRequest struct {
+	Composition string `json:"composition" binding:"required"`
+	forecast.Request
+}
+
+type RunRequest struct {
+	Date              *time.Time        `json:"date"`
+	Demand            []DemandRequest   `json:"demand"`
+	Forecasts         []ForecastRequest `json:"forecasts"`
+	IgnoreSalesOrders bool              `json:"ignoreSalesOrders"`
+}
+
+// Run computes a material requirements plan. Demand comes from open sales
+// orders, due at the plan date, from the requested demand and from the
+// forecasts of the requested compositions. Open purchase and production
+// orders are scheduled receipts. Nothing is persisted: planned orders are
+// suggestions to be created by the user.
+func (s *service) Run(req *RunRequest) (*Plan, error) {
+	path := "mrp/service.Run"
+
+	date := time.Now()
+	if req.Date != nil {
+		date = *req.Date
+	}
+
+	demands := make([]*Demand, 0)
+	if !req.IgnoreSalesOrders {
+		orders, err := s.salesService.FindOpenOrders()
+		if err != nil {
+			return nil, err
+		}
+
+		// Issued stock is no longer on hand, so only the quantity still to be
+		// issued of orders being delivered is demanded
+		for _, o := range orders {
+			for _, l := range o.Lines {
+				if l.IsIssued() {
+					continue
+				}
+
+				q := l.Quantity
+				if len(l.Lots) > 0 {
+					comp, err := s.compositionService.GetByID(l.Composition.Hex())
+					if err != nil {
+						return nil, err
+					}
+					if q, err = l.Remaining(comp); err != nil {
+						return nil, errors.NewStatus("INCOMPATIBLE_QUANTITY").SetPath(path).SetMessage("%v != %v", l.Quantity, comp.Unit).SetRef(err)
+					}
+				}
+
+				demands = append(demands, &Demand{
+					Composition: l.Composition,
+					Quantity:    q,
+					Date:        date,
+					Source:      "sales:" + o.ID.Hex(),
+				})
+			}
+		}
+	}
+
+	for _, d := range req.Demand {
+		compID, err := primitive.ObjectIDFromHex(d.Composition)
+		if err != nil {
+			return nil, errors.NewStatus("INVALID_COMPOSITION").SetPath(path).SetRef(err)
+		}
+
+		dueDate := date
+		if d.Date != nil {
+			dueDate = *d.Date
+		}
+
+		demands = append(demands, &Demand{
+			Composition: compID,
+			Quantity:    d.Quantity,
+			Date:        dueDate,
+			Source:      "manual",
+		})
+	}
+
+	for _, r := range req.Forecasts {
+		if r.Date == nil {
+			r.Date = &date
+		}
+
+		f, err := s.forecastService.Forecast(r.Composition, &r.Request)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, p := range f.Forecast {
+			dueDate := p.Start
+			if dueDate.Before(date) {
+				dueDate = date
+			}
+
+			demands = append(demands, &Demand{
+				Composition: f.Composition,
+				Quantity:    p.Quantity,
+				Date:        dueDate,
+				Source:      "forecast:" + f.Method,
+			})
+		}
+	}
+
+	receipts := make([]*Receipt, 0)
+
+	purchaseOrders, err := s.purchaseService.FindOpen()
+	if err != nil {
+		return nil, err
+	}
+	for _, o := range purchaseOrders {
+		for _, l := range o.Lines {
+			receipts = append(receipts, &Receipt{
+				Composition: l.Composition,
+				Quantity:    l.Pending(),
+				Date:        date,
+				Source:      "purchase:" + o.ID.Hex(),
+			})
+		}
+	}
+
+	productionOrders, err := s.productionService.FindOpen()
+	if err != nil {
+		return nil, err
+	}
+	for _, o := range productionOrders {
+		dueDate := date
+		if o.DueDate != nil && o.DueDate.After(date) {
+			dueDate = *o.DueDate
+		}
+
+		receipts = append(receipts, &Receipt{
+			Composition: o.Composition,
+			Quantity:    o.Quantity,
+			Date:        dueDate,
+			Source:      "production:" + o.ID.Hex(),
+		})
+	}
+
+	plan, err := Calculate(date, demands, receipts, s.compositionService.GetByID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, o := range plan.Orders {
+		if o.Type != OrderPurchase {
+			continue
+		}
+
+		sup, _, err := s.supplierService.BestPrice(o.Composition.Hex())
+		if err != nil {
+			continue
+		}
+		o.Supplier = &sup.ID
+	}
+
+	return plan, nil
+}
@@ -0,0 +1,206 @@
+package mrp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aboglioli/big-brother/composition"
+	"github.com/aboglioli/big-brother/forecast"
+	"github.com/aboglioli/big-brother/pkg/events"
+	"github.com/aboglioli/big-brother/pkg/quantity"
+	"github.com/aboglioli/big-brother/pkg/tests/assert"
+	"github.com/aboglioli/big-brother/pricing"
+	"github.com/aboglioli/big-brother/production"
+	"github.com/aboglioli/big-brother/purchase"
+	"github.com/aboglioli/big-brother/routing"
+	"github.com/aboglioli/big-brother/sales"
+	"github.com/aboglioli/big-brother/stock"
+	"github.com/aboglioli/big-brother/supplier"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func newComposition(unit, stock quantity.Quantity, deps ...composition.Dependency) *composition.Composition {
+	comp := composition.NewComposition()
+	comp.Cost = 10
+	comp.Unit = unit
+	comp.Stock = stock
+	comp.Dependencies = deps
+	comp.Validated = true
+	return comp
+}
+
+func price(p float64) *float64 {
+	return &p
+}
+
+func TestRun(t *testing.T) {
+	compRepo, eventMgr := composition.NewMockRepository(), events.GetMockManager()
+	compServ := composition.NewService(compRepo)
+	stockServ := stock.NewService(stock.NewMockLotRepository(), stock.NewMockMovementRepository(), stock.NewMockCountRepository(), compServ)
+	supplierServ := supplier.NewService(supplier.NewMockRepository(), compServ, eventMgr)
+	pricingServ := pricing.NewService(pricing.NewMockRepository(), compServ, eventMgr, 0.2)
+	orderRepo := sales.NewMockOrderRepository()
+	salesServ := sales.NewService(sales.NewMockCustomerRepository(), orderRepo, compServ, stockServ, pricingServ)
+	purchaseServ := purchase.NewService(purchase.NewMockRepository(), compServ, supplierServ, stockServ)
+	routingServ := routing.NewService(routing.NewMockWorkCenterRepository(), routing.NewMockRoutingRepository(), compServ)
+	productionServ := production.NewService(production.NewMockRepository(), compServ, stockServ, routingServ, eventMgr)
+	serv := NewService(compServ, salesServ, purchaseServ, productionServ, supplierServ, forecast.NewService(compServ, salesServ))
+
+	flour := newComposition(quantity.Quantity{1, "kg"}, quantity.Quantity{2, "kg"})
+	flour.LotSize = quantity.Quantity{25, "kg"}
+	flour.LeadTime = 3
+	compRepo.Insert(flour)
+
+	bread := newComposition(quantity.Quantity{1, "u"}, quantity.Quantity{5, "u"}, composition.Dependency{On: flour.ID, Quantity: quantity.Quantity{1, "kg"}})
+	bread.MinimumStock = quantity.Quantity{2, "u"}
+	bread.LotSize = quantity.Quantity{10, "u"}
+	bread.LeadTime = 1
+	compRepo.Insert(bread)
+
+	mill, _ := supplierServ.Create(&supplier.CreateRequest{Name: "Mill"})
+	supplierServ.SetPrice(mill.ID.Hex(), &supplier.PriceRequest{Composition: flour.ID.Hex(), Price: 450, Quantity: quantity.Quantity{25, "kg"}})
+
+	customer, _ := salesServ.CreateCustomer(&sales.CreateCustomerRequest{Name: "Bakery"})
+	_, err := salesServ.CreateOrder(&sales.CreateOrderRequest{
+		Customer: customer.ID.Hex(),
+		Lines:    []sales.LineRequest{sales.LineRequest{Composition: bread.ID.Hex(), Quantity: quantity.Quantity{3, "u"}, Price: price(30)}},
+	})
+	assert.Ok(t, err)
+	_, err = purchaseServ.Create(&purchase.CreateRequest{
+		Supplier: mill.ID.Hex(),
+		Lines:    []purchase.LineRequest{purchase.LineRequest{Composition: flour.ID.Hex(), Quantity: quantity.Quantity{5000, "g"}}},
+	})
+	assert.Ok(t, err)
+	_, err = productionServ.Create(&production.CreateRequest{Composition: bread.ID.Hex(), Quantity: quantity.Quantity{4, "u"}})
+	assert.Ok(t, err)
+
+	date := time.Date(2019, 12, 2, 0, 0, 0, 0, time.UTC)
+
+	t.Run("Incompatible demand", func(t *testing.T) {
+		_, err := serv.Run(&RunRequest{
+			Date:   &date,
+			Demand: []DemandRequest{DemandRequest{Composition: bread.ID.Hex(), Quantity: quantity.Quantity{1, "kg"}}},
+		})
+		assert.ErrCode(t, err, "INCOMPATIBLE_QUANTITY")
+	})
+
+	t.Run("Net requirements level by level", func(t *testing.T) {
+		dueDate := date.AddDate(0, 0, 5)
+		plan, err := serv.Run(&RunRequest{
+			Date:   &date,
+			Demand: []DemandRequest{DemandRequest{Composition: bread.ID.Hex(), Quantity: quantity.Quantity{12, "u"}, Date: &dueDate}},
+		})
+		assert.Ok(t, err)
+		assert.Equal(t, len(plan.Requirements), 2)
+		assert.Equal(t, len(plan.Orders), 2)
+
+		// 5u on hand + 4u in production - 3u sold - 12u forecast < 2u safety stock
+		req := plan.Requirements[0]
+		assert.Equal(t, req.Composition, bread.ID)
+		assert.Equal(t, req.Level, 0)
+		assert.Assert(t, req.Gross.Equals(quantity.Quantity{15, "u"}))
+		assert.Assert(t, req.Scheduled.Equals(quantity.Quantity{4, "u"}))
+		assert.Assert(t, req.Net.Equals(quantity.Quantity{8, "u"}))
+		assert.Assert(t, req.Planned.Equals(quantity.Quantity{10, "u"}))
+
+		o := plan.Orders[0]
+		assert.Equal(t, o.Type, OrderProduction)
+		assert.Assert(t, o.Quantity.Equals(quantity.Quantity{10, "u"}))
+		assert.Equal(t, o.DueDate, dueDate)
+		assert.Equal(t, o.ReleaseDate, date.AddDate(0, 0, 4))
+		assert.Equal(t, o.Late, false)
+		assert.Nil(t, o.Supplier)
+
+		// 2kg on hand + 5kg ordered - 10kg for planned bread
+		req = plan.Requirements[1]
+		assert.Equal(t, req.Composition, flour.ID)
+		assert.Equal(t, req.Level, 1)
+		assert.Assert(t, req.Gross.Equals(quantity.Quantity{10, "kg"}))
+		assert.Assert(t, req.Net.Equals(quantity.Quantity{3, "kg"}))
+		assert.Assert(t, req.Planned.Equals(quantity.Quantity{25, "kg"}))
+
+		o = plan.Orders[1]
+		assert.Equal(t, o.Type, OrderPurchase)
+		assert.Assert(t, o.Quantity.Equals(quantity.Quantity{25, "kg"}))
+		assert.Equal(t, o.DueDate, date.AddDate(0, 0, 4))
+		assert.Equal(t, o.ReleaseDate, date.AddDate(0, 0, 1))
+		assert.Equal(t, *o.Supplier, mill.ID)
+	})
+
+	t.Run("Late orders", func(t *testing.T) {
+		plan, err := serv.Run(&RunRequest{
+			Date:              &date,
+			IgnoreSalesOrders: true,
+			Demand:            []DemandRequest{DemandRequest{Composition: bread.ID.Hex(), Quantity: quantity.Quantity{10, "u"}}},
+		})
+		assert.Ok(t, err)
+		assert.Equal(t, len(plan.Orders), 2)
+		assert.Assert(t, plan.Orders[0].Late)
+		assert.Assert(t, plan.Orders[1].Late)
+	})
+
+	t.Run("Forecast demand", func(t *testing.T) {
+		delivered := date.AddDate(0, -1, 0)
+		o := sales.NewOrder(primitive.NewObjectID())
+		o.AddLine(bread.ID, quantity.Quantity{30, "u"}, 300)
+		o.Status = sales.OrderDelivered
+		o.DeliveredAt = &delivered
+		orderRepo.Insert(o)
+
+		// 5u on hand + 4u in production - 30u forecasted for December
+		plan, err := serv.Run(&RunRequest{
+			Date:              &date,
+			IgnoreSalesOrders: true,
+			Forecasts: []ForecastRequest{ForecastRequest{
+				Composition: bread.ID.Hex(),
+				Request:     forecast.Request{Period: forecast.PeriodMonth, History: 1, Periods: 1},
+			}},
+		})
+		assert.Ok(t, err)
+		assert.Assert(t, plan.Requirements[0].Gross.Equals(quantity.Quantity{30, "u"}))
+		assert.Assert(t, plan.Requirements[0].Planned.Equals(quantity.Quantity{30, "u"}))
+		assert.Equal(t, plan.Orders[0].DueDate, date)
+	})
+
+	t.Run("Blocked stock is not on hand", func(t *testing.T) {
+		req := &RunRequest{
+			Date:              &date,
+			IgnoreSalesOrders: true,
+			Demand:            []DemandRequest{DemandRequest{Composition: bread.ID.Hex(), Quantity: quantity.Quantity{6, "u"}}},
+		}
+
+		plan, err := serv.Run(req)
+		assert.Ok(t, err)
+		assert.Assert(t, plan.Requirements[0].Net.Equals(quantity.Quantity{0, "u"}))
+
+		comp, _ := compRepo.FindByID(bread.ID.Hex())
+		comp.Blocked = quantity.Quantity{2, "u"}
+		comp.Reserved = quantity.Quantity{3, "u"}
+		compRepo.Update(comp)
+
+		// 5u on hand - 2u blocked + 4u in production - 6u demand < 2u safety
+		// stock. Reserved stock is still on hand.
+		plan, err = serv.Run(req)
+		assert.Ok(t, err)
+		assert.Assert(t, plan.Requirements[0].OnHand.Equals(quantity.Quantity{3, "u"}))
+		assert.Assert(t, plan.Requirements[0].Net.Equals(quantity.Quantity{1, "u"}))
+	})
+
+	t.Run("Issued lines are not demanded", func(t *testing.T) {
+		partial, issued := sales.NewOrder(customer.ID), sales.NewOrder(customer.ID)
+		partial.AddLine(bread.ID, quantity.Quantity{4, "u"}, 40)
+		partial.Lines[0].Lots = []stock.LotComponent{{Lot: primitive.NewObjectID(), Composition: bread.ID, Quantity: quantity.Quantity{3, "u"}}}
+		issued.AddLine(bread.ID, quantity.Quantity{2, "u"}, 20)
+		issued.Lines[0].Lots = []stock.LotComponent{{Lot: primitive.NewObjectID(), Composition: bread.ID, Quantity: quantity.Quantity{2, "u"}}}
+		issued.Lines[0].Issued = true
+		for _, o := range []*sales.Order{partial, issued} {
+			o.Status = sales.OrderConfirmed
+			orderRepo.Insert(o)
+		}
+
+		// 3u of the draft order + 1u still to be issued
+		plan, err := serv.Run(&RunRequest{Date: &date})
+		assert.Ok(t, err)
+		assert.Assert(t, plan.Requirements[0].Gross.Equals(quantity.Quantity{4, "u"}))
+	})
+}
@@ -11,6 +11,19 @@ type serviceConfiguration struct {
 
 type Configuration struct {
 	Composition serviceConfiguration `json:"composition"`
+	Stock       serviceConfiguration `json:"stock"`
+	Supplier    serviceConfiguration `json:"supplier"`
+	Purchase    serviceConfiguration `json:"purchase"`
+	Sales       serviceConfiguration `json:"sales"`
+	Pricing     serviceConfiguration `json:"pricing"`
+	Invoice     serviceConfiguration `json:"invoice"`
+	Ledger      serviceConfiguration `json:"ledger"`
+	Production  serviceConfiguration `json:"production"`
+	Mrp         serviceConfiguration `json:"mrp"`
+	Routing     serviceConfiguration `json:"routing"`
+	Forecast    serviceConfiguration `json:"forecast"`
+	Quality     serviceConfiguration `json:"quality"`
+	Units       serviceConfiguration `json:"units"`
 
 	MongoURL        string `json:"mongoUrl"`
 	MongoAuthSource string `json:"mongoAuthSource"`
@@ -25,6 +38,18 @@ type Configuration struct {
 
 	AuthEnabled bool   `json:"authEnabled"`
 	AuthURL     string `json:"authUrl"`
+
+	// MarginFloor is the minimum margin of selling prices. Entries below it
+	// after a cost increase are flagged.
+	MarginFloor float64 `json:"marginFloor"`
+
+	// TaxRates maps composition categories to tax rates. The "default" rate
+	// applies to categories without rate.
+	TaxRates map[string]float64 `json:"taxRates"`
+
+	// UnitsTTL is the number of seconds units are cached before reloading
+	// them from the database.
+	UnitsTTL int `json:"unitsTtl"`
 }
 
 var config *Configuration
@@ -35,6 +60,45 @@ func Get() Configuration {
 			Composition: serviceConfiguration{
 				Port: 3344,
 			},
+			Stock: serviceConfiguration{
+				Port: 3345,
+			},
+			Supplier: serviceConfiguration{
+				Port: 3346,
+			},
+			Purchase: serviceConfiguration{
+				Port: 3347,
+			},
+			Sales: serviceConfiguration{
+				Port: 3348,
+			},
+			Pricing: serviceConfiguration{
+				Port: 3349,
+			},
+			Invoice: serviceConfiguration{
+				Port: 3350,
+			},
+			Ledger: serviceConfiguration{
+				Port: 3351,
+			},
+			Production: serviceConfiguration{
+				Port: 3352,
+			},
+			Mrp: serviceConfiguration{
+				Port: 3353,
+			},
+			Routing: serviceConfiguration{
+				Port: 3354,
+			},
+			Forecast: serviceConfiguration{
+				Port: 3355,
+			},
+			Quality: serviceConfiguration{
+				Port: 3356,
+			},
+			Units: serviceConfiguration{
+				Port: 3357,
+			},
 
 			MongoURL:        "mongodb://localhost:27017",
 			MongoAuthSource: "admin",
@@ -49,6 +113,14 @@ func Get() Configuration {
 
 			AuthEnabled: false,
 			AuthURL:     "http://localhost:3000/v1/users/current",
+
+			MarginFloor: 0.2,
+
+			TaxRates: map[string]float64{
+				"default": 0.21,
+			},
+
+			UnitsTTL: 60,
 		}
 
 		file, err := os.Open("config.json")
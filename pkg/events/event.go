@@ -1,5 +1,59 @@
 package events
 
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Version is the schema version of events created by NewEvent. Events whose
+// payload changes incompatibly set their own version.
+const Version = 1
+
+var producer = filepath.Base(os.Args[0])
+
+// SetProducer sets the name of the service producing events. It defaults to
+// the executable name.
+func SetProducer(name string) {
+	producer = name
+}
+
+// Event is the envelope embedded in every event. Events of the same flow
+// share the correlation ID of the event that started it, and CausationID is
+// the ID of the event that directly caused it: a CompositionsUpdatedAutomatically
+// event is caused by a CompositionUpdatedManually event.
 type Event struct {
-	Type string `json:"type"`
+	ID            string    `json:"id"`
+	Type          string    `json:"type"`
+	Version       int       `json:"version"`
+	OccurredAt    time.Time `json:"occurredAt"`
+	AggregateID   string    `json:"aggregateId,omitempty"`
+	Producer      string    `json:"producer"`
+	CorrelationID string    `json:"correlationId"`
+	CausationID   string    `json:"causationId,omitempty"`
+}
+
+// NewEvent returns an event starting a new flow: it's its own correlation.
+func NewEvent(eventType string, aggregateID string) Event {
+	id := primitive.NewObjectID().Hex()
+	return Event{
+		ID:            id,
+		Type:          eventType,
+		Version:       Version,
+		OccurredAt:    time.Now(),
+		AggregateID:   aggregateID,
+		Producer:      producer,
+		CorrelationID: id,
+	}
+}
+
+// CausedBy links the event to the event that caused it.
+func (e *Event) CausedBy(cause Event) {
+	e.CausationID = cause.ID
+	e.CorrelationID = cause.CorrelationID
+	if e.CorrelationID == "" {
+		e.CorrelationID = cause.ID
+	}
 }
@@ -12,7 +12,7 @@ type customEvent struct {
 }
 
 func TestCustomEventEncoding(t *testing.T) {
-	cEvt := &customEvent{Event{"Custom"}, "This is data"}
+	cEvt := &customEvent{NewEvent("Custom", "1"), "This is data"}
 	conv := DefaultConverter()
 
 	src, err := conv.Encode(cEvt)
@@ -22,4 +22,27 @@ func TestCustomEventEncoding(t *testing.T) {
 	assert.Ok(t, conv.Decode(src, &dst))
 
 	assert.Assert(t, dst.Type == cEvt.Type && dst.Data == cEvt.Data)
+	assert.Assert(t, dst.ID == cEvt.ID && dst.OccurredAt.Equal(cEvt.OccurredAt))
+}
+
+func TestEventEnvelope(t *testing.T) {
+	SetProducer("tests")
+	root := NewEvent("Created", "1")
+	assert.Assert(t, root.ID != "", "Should have ID")
+	assert.Equal(t, root.Version, Version)
+	assert.Equal(t, root.AggregateID, "1")
+	assert.Equal(t, root.Producer, "tests")
+	assert.Equal(t, root.CorrelationID, root.ID, "Should start a flow")
+	assert.Equal(t, root.CausationID, "")
+
+	child := NewEvent("Updated", "2")
+	child.CausedBy(root)
+	assert.Assert(t, child.ID != root.ID)
+	assert.Equal(t, child.CausationID, root.ID)
+	assert.Equal(t, child.CorrelationID, root.ID)
+
+	grandchild := NewEvent("Flagged", "")
+	grandchild.CausedBy(child)
+	assert.Equal(t, grandchild.CausationID, child.ID)
+	assert.Equal(t, grandchild.CorrelationID, root.ID, "Should share the flow correlation")
 }
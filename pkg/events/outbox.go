@@ -0,0 +1,189 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"math"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Entry is an event stored in an outbox, in the same transaction as the
+// changes of its aggregate, to be published later by a Relay. Body is the
+// encoded event.
+type Entry struct {
+	ID           primitive.ObjectID `bson:"_id" json:"id"`
+	Aggregate    string             `bson:"aggregate" json:"aggregate"`
+	Exchange     string             `bson:"exchange" json:"exchange"`
+	ExchangeType string             `bson:"exchangeType" json:"exchangeType"`
+	Key          string             `bson:"key" json:"key"`
+	Body         string             `bson:"body" json:"body"`
+
+	Attempts      int        `bson:"attempts" json:"attempts"`
+	LastError     string     `bson:"lastError" json:"lastError"`
+	NextAttemptAt time.Time  `bson:"nextAttemptAt" json:"nextAttemptAt"`
+	PublishedAt   *time.Time `bson:"publishedAt" json:"publishedAt"`
+	CreatedAt     time.Time  `bson:"createdAt" json:"createdAt"`
+}
+
+// NewEntry encodes an event of an aggregate to store it in an outbox.
+func NewEntry(aggregate string, body interface{}, opts *Options) (*Entry, error) {
+	b, err := DefaultConverter().Encode(body)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	return &Entry{
+		ID:            primitive.NewObjectID(),
+		Aggregate:     aggregate,
+		Exchange:      opts.Exchange,
+		ExchangeType:  opts.ExchangeType,
+		Key:           opts.Key,
+		Body:          string(b),
+		NextAttemptAt: now,
+		CreatedAt:     now,
+	}, nil
+}
+
+// Options returns the options the entry is published with.
+func (e *Entry) Options() *Options {
+	return &Options{e.Exchange, e.ExchangeType, e.Key, ""}
+}
+
+// Type returns the type of the stored event.
+func (e *Entry) Type() string {
+	var evt Event
+	if err := e.Decode(&evt); err != nil {
+		return ""
+	}
+	return evt.Type
+}
+
+// Decode decodes the stored event into dst.
+func (e *Entry) Decode(dst interface{}) error {
+	return DefaultConverter().Decode([]byte(e.Body), dst)
+}
+
+// Outbox stores the events of the changes of aggregates until they are
+// published.
+type Outbox interface {
+	// Write runs fn, which changes an aggregate, and stores the entries
+	// atomically with the change.
+	Write(fn func(ctx context.Context) error, entries ...*Entry) error
+
+	// FindPending returns unpublished entries, except those of the excluded
+	// aggregates, in the order they were stored.
+	FindPending(limit int, exclude []string) ([]*Entry, error)
+	MarkPublished(e *Entry) error
+	MarkFailed(e *Entry) error
+}
+
+// Relay publishes the pending entries of an outbox. Entries of the same
+// aggregate are published in order: if one fails, the following ones wait
+// for it to be retried.
+type Relay struct {
+	outbox   Outbox
+	eventMgr Manager
+
+	// BatchSize is the maximum number of entries read from the outbox on
+	// each pass. MaxBackoff limits the exponential time between retries.
+	BatchSize  int
+	MaxBackoff time.Duration
+}
+
+func NewRelay(outbox Outbox, eventMgr Manager) *Relay {
+	return &Relay{
+		outbox:     outbox,
+		eventMgr:   eventMgr,
+		BatchSize:  100,
+		MaxBackoff: 5 * time.Minute,
+	}
+}
+
+// RelayPending publishes the pending entries. Aggregates whose first pending
+// entry fails, or waits to be retried, are excluded from the following
+// batches, so they don't hold back the other aggregates. It returns the
+// number of published entries.
+func (r *Relay) RelayPending() (int, error) {
+	now := time.Now()
+	blocked := make(map[string]bool)
+	exclude := make([]string, 0)
+	block := func(aggregate string) {
+		blocked[aggregate] = true
+		exclude = append(exclude, aggregate)
+	}
+
+	published := 0
+	for {
+		entries, err := r.outbox.FindPending(r.BatchSize, exclude)
+		if err != nil {
+			return published, err
+		}
+
+		// Every entry of the batch is published or belongs to a blocked
+		// aggregate, so the next batch only has new entries.
+		for _, e := range entries {
+			if blocked[e.Aggregate] {
+				continue
+			}
+			if e.NextAttemptAt.After(now) {
+				block(e.Aggregate)
+				continue
+			}
+
+			if err := r.eventMgr.Publish(json.RawMessage(e.Body), e.Options()); err != nil {
+				block(e.Aggregate)
+
+				e.Attempts++
+				e.LastError = err.Error()
+				e.NextAttemptAt = now.Add(r.backoff(e.Attempts))
+				if err := r.outbox.MarkFailed(e); err != nil {
+					return published, err
+				}
+				continue
+			}
+
+			e.PublishedAt = &now
+			if err := r.outbox.MarkPublished(e); err != nil {
+				return published, err
+			}
+			published++
+		}
+
+		if len(entries) < r.BatchSize {
+			return published, nil
+		}
+	}
+}
+
+// Run relays pending entries every interval until stop is closed. Errors
+// (e.g. the outbox or the broker being unavailable) are logged and the
+// entries are retried on the next tick.
+func (r *Relay) Run(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := r.RelayPending(); err != nil {
+			log.Println("[relay]", err)
+		}
+
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// backoff returns the time to wait before retrying an entry: 1s, 2s, 4s...
+func (r *Relay) backoff(attempts int) time.Duration {
+	d := time.Duration(math.Pow(2, float64(attempts-1))) * time.Second
+	if d > r.MaxBackoff || d <= 0 {
+		return r.MaxBackoff
+	}
+	return d
+}
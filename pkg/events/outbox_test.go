@@ -0,0 +1,181 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aboglioli/big-brother/pkg/tests/assert"
+)
+
+type memoryOutbox struct {
+	entries   []*Entry
+	failures  int         // FindPending calls failing before it works
+	published chan *Entry // notified of published entries
+}
+
+func (o *memoryOutbox) Write(fn func(ctx context.Context) error, entries ...*Entry) error {
+	if err := fn(context.Background()); err != nil {
+		return err
+	}
+	o.entries = append(o.entries, entries...)
+	return nil
+}
+
+func (o *memoryOutbox) FindPending(limit int, exclude []string) ([]*Entry, error) {
+	if o.failures > 0 {
+		o.failures--
+		return nil, fmt.Errorf("outbox unavailable")
+	}
+
+	excluded := make(map[string]bool)
+	for _, aggregate := range exclude {
+		excluded[aggregate] = true
+	}
+
+	pending := make([]*Entry, 0)
+	for _, e := range o.entries {
+		if e.PublishedAt == nil && !excluded[e.Aggregate] && len(pending) < limit {
+			pending = append(pending, e)
+		}
+	}
+	return pending, nil
+}
+
+func (o *memoryOutbox) MarkPublished(e *Entry) error {
+	if o.published != nil {
+		o.published <- e
+	}
+	return nil
+}
+
+func (o *memoryOutbox) MarkFailed(e *Entry) error { return nil }
+
+// failingManager fails to publish to the exchanges in fail.
+type failingManager struct {
+	fail      map[string]bool
+	published []string
+}
+
+func (m *failingManager) Publish(body interface{}, opts *Options) error {
+	if m.fail[opts.Exchange] {
+		return fmt.Errorf("%s unavailable", opts.Exchange)
+	}
+
+	b, err := DefaultConverter().Encode(body)
+	if err != nil {
+		return err
+	}
+
+	var evt Event
+	if err := DefaultConverter().Decode(b, &evt); err != nil {
+		return err
+	}
+	m.published = append(m.published, evt.Type)
+	return nil
+}
+
+func (m *failingManager) Consume(opts *Options) (<-chan Message, error) {
+	return nil, nil
+}
+
+func newEntry(t *testing.T, aggregate, eventType, exchange string) *Entry {
+	evt := NewEvent(eventType, aggregate)
+	e, err := NewEntry(aggregate, &evt, &Options{exchange, "topic", "key", ""})
+	assert.Ok(t, err)
+	return e
+}
+
+func TestRelay(t *testing.T) {
+	outbox := &memoryOutbox{
+		entries: []*Entry{
+			newEntry(t, "a", "Created", "down"),
+			newEntry(t, "b", "Created", "up"),
+			newEntry(t, "a", "Updated", "up"),
+			newEntry(t, "b", "Updated", "up"),
+		},
+	}
+	eventMgr := &failingManager{fail: map[string]bool{"down": true}}
+	relay := NewRelay(outbox, eventMgr)
+
+	t.Run("Failed entry blocks its aggregate", func(t *testing.T) {
+		n, err := relay.RelayPending()
+		assert.Ok(t, err)
+		assert.Equal(t, n, 2)
+		assert.Equal(t, len(eventMgr.published), 2)
+
+		failed := outbox.entries[0]
+		assert.Equal(t, failed.Attempts, 1)
+		assert.Equal(t, failed.LastError, "down unavailable")
+		assert.Nil(t, failed.PublishedAt)
+		assert.Nil(t, outbox.entries[2].PublishedAt, "Should wait for the failed entry")
+		assert.NotNil(t, outbox.entries[3].PublishedAt)
+	})
+
+	t.Run("Retry after backoff", func(t *testing.T) {
+		eventMgr.fail = nil
+
+		n, err := relay.RelayPending()
+		assert.Ok(t, err)
+		assert.Equal(t, n, 0, "Backoff not elapsed")
+
+		outbox.entries[0].NextAttemptAt = time.Now()
+		n, err = relay.RelayPending()
+		assert.Ok(t, err)
+		assert.Equal(t, n, 2)
+		assert.Equal(t, eventMgr.published[2], "Created")
+		assert.Equal(t, eventMgr.published[3], "Updated")
+	})
+
+	t.Run("Failed aggregate does not hold back the others", func(t *testing.T) {
+		outbox := &memoryOutbox{}
+		for i := 0; i < 5; i++ {
+			outbox.entries = append(outbox.entries, newEntry(t, "a", "Updated", "down"))
+		}
+		outbox.entries = append(outbox.entries, newEntry(t, "b", "Updated", "up"))
+
+		eventMgr := &failingManager{fail: map[string]bool{"down": true}}
+		relay := NewRelay(outbox, eventMgr)
+		relay.BatchSize = 2
+
+		n, err := relay.RelayPending()
+		assert.Ok(t, err)
+		assert.Equal(t, n, 1)
+		assert.NotNil(t, outbox.entries[5].PublishedAt)
+		assert.Equal(t, outbox.entries[0].Attempts, 1)
+		assert.Equal(t, outbox.entries[1].Attempts, 0, "Should wait for the failed entry")
+	})
+
+	t.Run("Run keeps relaying after errors", func(t *testing.T) {
+		outbox := &memoryOutbox{
+			entries:   []*Entry{newEntry(t, "a", "Created", "up")},
+			failures:  2,
+			published: make(chan *Entry, 1),
+		}
+		relay := NewRelay(outbox, &failingManager{})
+
+		stop := make(chan struct{})
+		done := make(chan struct{})
+		go func() {
+			relay.Run(time.Millisecond, stop)
+			close(done)
+		}()
+
+		select {
+		case e := <-outbox.published:
+			assert.Equal(t, e.Type(), "Created")
+		case <-time.After(time.Second):
+			t.Error("Should be published after the failures")
+		}
+		close(stop)
+		<-done
+	})
+
+	t.Run("Backoff", func(t *testing.T) {
+		assert.Equal(t, relay.backoff(1), time.Second)
+		assert.Equal(t, relay.backoff(4), 8*time.Second)
+		assert.Equal(t, relay.backoff(20), relay.MaxBackoff)
+		assert.Equal(t, relay.backoff(100), relay.MaxBackoff)
+	})
+}
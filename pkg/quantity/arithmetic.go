@@ -0,0 +1,114 @@
+package quantity
+
+import (
+	"math/big"
+
+	"github.com/aboglioli/big-brother/pkg/errors"
+	"github.com/aboglioli/big-brother/pkg/unit"
+)
+
+// Error codes returned by quantity operations.
+const (
+	ErrUnitDoesNotExist  = "UNIT_DOES_NOT_EXIST"
+	ErrIncompatibleUnits = "INCOMPATIBLE_UNITS"
+	ErrDivisionByZero    = "DIVISION_BY_ZERO"
+)
+
+// Multiply multiplies the quantity by a scalar.
+func (q Quantity) Multiply(f float64) Quantity {
+	r := new(big.Rat).Mul(unit.Rat(q.Quantity), unit.Rat(f))
+	return Quantity{toFloat(r), q.Unit}
+}
+
+// Divide divides the quantity by a scalar.
+func (q Quantity) Divide(f float64) (Quantity, error) {
+	if f == 0 {
+		return Quantity{}, errors.NewStatus(ErrDivisionByZero).SetPath("quantity/arithmetic.Divide").SetMessage("%v / 0", q)
+	}
+
+	r := new(big.Rat).Quo(unit.Rat(q.Quantity), unit.Rat(f))
+	return Quantity{toFloat(r), q.Unit}, nil
+}
+
+// Ratio returns q1 / q2, such as the number of composition units in a
+// quantity: 1.5 kg / 500 g = 3.
+func (q1 Quantity) Ratio(q2 Quantity) (float64, error) {
+	if _, err := referenceUnit(q1, q2); err != nil {
+		return 0, err
+	}
+
+	n2 := q2.normalized()
+	if n2.Sign() == 0 {
+		return 0, errors.NewStatus(ErrDivisionByZero).SetPath("quantity/arithmetic.Ratio").SetMessage("%v / %v", q1, q2)
+	}
+
+	return toFloat(n2.Quo(q1.normalized(), n2)), nil
+}
+
+// Compare returns -1 if q1 is less than q2, 0 if they are equal (see Equals)
+// and 1 if q1 is greater than q2.
+func (q1 Quantity) Compare(q2 Quantity) (int, error) {
+	if _, err := referenceUnit(q1, q2); err != nil {
+		return 0, err
+	}
+
+	if q1.Equals(q2) {
+		return 0, nil
+	}
+	return q1.normalized().Cmp(q2.normalized()), nil
+}
+
+// Less returns true if q1 is less than q2.
+func (q1 Quantity) Less(q2 Quantity) (bool, error) {
+	c, err := q1.Compare(q2)
+	return c < 0, err
+}
+
+// Greater returns true if q1 is greater than q2.
+func (q1 Quantity) Greater(q2 Quantity) (bool, error) {
+	c, err := q1.Compare(q2)
+	return c > 0, err
+}
+
+// EqualsWithin returns true if q1 and q2 differ at most by tolerance.
+func (q1 Quantity) EqualsWithin(q2, tolerance Quantity) (bool, error) {
+	diff, err := q1.Subtract(q2)
+	if err != nil {
+		return false, err
+	}
+	if diff.Quantity < 0 {
+		diff.Quantity = -diff.Quantity
+	}
+
+	greater, err := diff.Greater(tolerance)
+	return !greater, err
+}
+
+// ConvertTo converts the quantity to another unit of the same type.
+func (q Quantity) ConvertTo(to string) (Quantity, error) {
+	return Convert(q, to, nil)
+}
+
+// Min returns the smallest quantity.
+func Min(q1, q2 Quantity) (Quantity, error) {
+	less, err := q2.Less(q1)
+	if err != nil {
+		return Quantity{}, err
+	}
+	if less {
+		return q2, nil
+	}
+	return q1, nil
+}
+
+// Max returns the greatest quantity.
+func Max(q1, q2 Quantity) (Quantity, error) {
+	greater, err := q2.Greater(q1)
+	if err != nil {
+		return Quantity{}, err
+	}
+	if greater {
+		return q2, nil
+	}
+	return q1, nil
+}
@@ -0,0 +1,101 @@
+package quantity
+
+import (
+	"math/big"
+
+	"github.com/aboglioli/big-brother/pkg/errors"
+	"github.com/aboglioli/big-brother/pkg/unit"
+)
+
+// Conversion states that From equals To for a specific item, so quantities
+// can be converted between unit types: 1 box = 12 u, 1 u = 300 g.
+type Conversion struct {
+	From Quantity `bson:"from" json:"from"`
+	To   Quantity `bson:"to" json:"to"`
+}
+
+// IsValid returns true if both quantities are valid, greater than zero and of
+// different unit types.
+func (c Conversion) IsValid() bool {
+	return c.From.IsValid() && c.To.IsValid() &&
+		c.From.Quantity > 0 && c.To.Quantity > 0 &&
+		!c.From.Compatible(c.To)
+}
+
+// Convert converts q to another unit. Units of different types are converted
+// through conversions, chaining them if needed (box -> u -> g).
+func Convert(q Quantity, to string, conversions []Conversion) (Quantity, error) {
+	path := "quantity/conversion.Convert"
+	repo := unit.GetRepository()
+
+	from, target := repo.FindByName(q.Unit), repo.FindByName(to)
+	if from == nil || target == nil {
+		return Quantity{}, errors.NewStatus(ErrUnitDoesNotExist).SetPath(path)
+	}
+
+	// factors[type] is the normalized quantity of the type equal to a
+	// normalized unit of the source type.
+	factors := map[string]float64{from.Type: 1}
+	pending := []string{from.Type}
+	for len(pending) > 0 {
+		if _, ok := factors[target.Type]; ok {
+			break
+		}
+
+		t := pending[0]
+		pending = pending[1:]
+
+		for _, c := range conversions {
+			if !c.IsValid() {
+				continue
+			}
+
+			fromType, toType := repo.FindByName(c.From.Unit).Type, repo.FindByName(c.To.Unit).Type
+			nFrom, nTo := c.From.Normalize(), c.To.Normalize()
+			if toType == t {
+				fromType, toType = toType, fromType
+				nFrom, nTo = nTo, nFrom
+			} else if fromType != t {
+				continue
+			}
+
+			if _, ok := factors[toType]; !ok {
+				factors[toType] = factors[t] * nTo / nFrom
+				pending = append(pending, toType)
+			}
+		}
+	}
+
+	factor, ok := factors[target.Type]
+	if !ok {
+		if isMassAndVolume(from, target) {
+			return Quantity{}, errors.NewStatus("DENSITY_REQUIRED").SetPath(path).SetMessage("Density needed to convert %s to %s", q.Unit, to)
+		}
+		return Quantity{}, errors.NewStatus(ErrIncompatibleUnits).SetPath(path).SetMessage("%s -> %s", q.Unit, to)
+	}
+
+	n := q.normalized()
+	n.Mul(n, new(big.Rat).SetFloat64(factor))
+	n.Quo(n, target.Factor())
+
+	return Quantity{
+		Quantity: toFloat(n),
+		Unit:     to,
+	}, nil
+}
+
+// Density is the mass of a substance by volume in kg/l (g/ml). It converts
+// mass and volume quantities of the substance.
+type Density float64
+
+// Conversion returns the conversion between liters and kilograms.
+func (d Density) Conversion() Conversion {
+	return Conversion{
+		From: Quantity{1, "l"},
+		To:   Quantity{float64(d), "kg"},
+	}
+}
+
+func isMassAndVolume(u1, u2 *unit.Unit) bool {
+	return u1.Type == "mass" && u2.Type == "volume" || u1.Type == "volume" && u2.Type == "mass"
+}
@@ -0,0 +1,231 @@
+package quantity
+
+import (
+	"encoding/json"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/aboglioli/big-brother/pkg/errors"
+	"github.com/aboglioli/big-brother/pkg/unit"
+)
+
+// Locale defines the separators used to format numbers.
+type Locale struct {
+	Decimal   string
+	Thousands string
+}
+
+// Locales maps language codes to locales. Unknown languages use "en".
+var Locales = map[string]Locale{
+	"en": Locale{".", ","},
+	"es": Locale{",", "."},
+	"pt": Locale{",", "."},
+	"it": Locale{",", "."},
+	"de": Locale{",", "."},
+	"fr": Locale{",", " "},
+}
+
+// GetLocale returns the locale of a language tag ("es-AR" uses "es").
+func GetLocale(tag string) Locale {
+	lang := strings.ToLower(tag)
+	if i := strings.IndexAny(lang, "-_"); i >= 0 {
+		lang = lang[:i]
+	}
+	if l, ok := Locales[lang]; ok {
+		return l
+	}
+	return Locales["en"]
+}
+
+// Parse parses a quantity written as a number followed by a unit, with or
+// without space: "1.5 kg", "250g", "2 fl oz".
+func Parse(s string) (Quantity, error) {
+	return ParseLocale(s, "en")
+}
+
+// MustParse is like Parse but panics if the quantity can't be parsed. It
+// simplifies the initialization of quantities in code and tests.
+func MustParse(s string) Quantity {
+	q, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return q
+}
+
+// ParseLocale parses a quantity with the number separators of a locale:
+// "1.500,5 kg" in "es".
+func ParseLocale(s string, tag string) (Quantity, error) {
+	path := "quantity/format.Parse"
+	l := GetLocale(tag)
+
+	s = strings.TrimSpace(s)
+
+	var number strings.Builder
+	i := 0
+scan:
+	for ; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c >= '0' && c <= '9', i == 0 && (c == '-' || c == '+'):
+			number.WriteByte(c)
+		case strings.HasPrefix(s[i:], l.Decimal):
+			number.WriteByte('.')
+			i += len(l.Decimal) - 1
+		case strings.HasPrefix(s[i:], l.Thousands) && i+len(l.Thousands) < len(s) && isDigit(s[i+len(l.Thousands)]):
+			i += len(l.Thousands) - 1
+		default:
+			break scan
+		}
+	}
+
+	n, err := strconv.ParseFloat(number.String(), 64)
+	if err != nil {
+		return Quantity{}, errors.NewStatus("INVALID_QUANTITY").SetPath(path).SetMessage("%q", s).SetRef(err)
+	}
+
+	name := strings.Join(strings.Fields(s[i:]), " ")
+	if name == "" {
+		return Quantity{}, errors.NewStatus("INVALID_QUANTITY").SetPath(path).SetMessage("%q: unit required", s)
+	}
+	if !unit.GetRepository().Exists(name) {
+		return Quantity{}, errors.NewStatus(ErrUnitDoesNotExist).SetPath(path).SetMessage("%q", name)
+	}
+
+	return Quantity{n, name}, nil
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// String returns the quantity as it's parsed: "1.5 kg".
+func (q Quantity) String() string {
+	return strconv.FormatFloat(q.Quantity, 'f', -1, 64) + " " + q.Unit
+}
+
+// Format returns the quantity rounded to three decimals, with the separators
+// of a locale: "1,500.25 g" in "en", "1.500,25 g" in "es".
+func (q Quantity) Format(tag string) string {
+	l := GetLocale(tag)
+
+	n := strconv.FormatFloat(math.Abs(math.Round(q.Quantity*1000)/1000), 'f', -1, 64)
+	integer, decimals := n, ""
+	if i := strings.Index(n, "."); i >= 0 {
+		integer, decimals = n[:i], n[i+1:]
+	}
+
+	var b strings.Builder
+	if q.Quantity < 0 && n != "0" {
+		b.WriteByte('-')
+	}
+	for i, c := range integer {
+		if i > 0 && (len(integer)-i)%3 == 0 {
+			b.WriteString(l.Thousands)
+		}
+		b.WriteRune(c)
+	}
+	if decimals != "" {
+		b.WriteString(l.Decimal)
+		b.WriteString(decimals)
+	}
+
+	return b.String() + " " + q.Unit
+}
+
+// Best returns the quantity in the largest unit of its type in which it is
+// at least 1, or the smallest unit if there is none: 1500 g is 1.5 kg and
+// 0.25 kg is 250 g. Decimal units, whose modifiers are powers of ten, and
+// other units (imperial) aren't mixed. Invalid quantities are returned as
+// they are.
+func (q Quantity) Best() Quantity {
+	u := unit.GetRepository().FindByName(q.Unit)
+	if u == nil || q.Quantity == 0 {
+		return q
+	}
+
+	n := q.normalized()
+	abs := new(big.Rat).Abs(n)
+
+	var best, smallest *unit.Unit
+	for _, c := range unit.GetRepository().FindByType(u.Type) {
+		if isDecimal(c) != isDecimal(u) {
+			continue
+		}
+
+		if smallest == nil || c.Modifier < smallest.Modifier {
+			smallest = c
+		}
+		if abs.Cmp(c.Factor()) >= 0 && (best == nil || c.Modifier > best.Modifier) {
+			best = c
+		}
+	}
+	if best == nil {
+		best = smallest
+	}
+
+	return Quantity{
+		Quantity: toFloat(n.Quo(n, best.Factor())),
+		Unit:     best.Name,
+	}
+}
+
+// isDecimal returns true if the modifier of the unit is a power of ten.
+func isDecimal(u *unit.Unit) bool {
+	f := u.Factor()
+	return isPowerOfTen(f.Num()) && isPowerOfTen(f.Denom())
+}
+
+func isPowerOfTen(n *big.Int) bool {
+	ten := big.NewInt(10)
+	n = new(big.Int).Set(n)
+	for n.Cmp(ten) >= 0 {
+		var r big.Int
+		n.QuoRem(n, ten, &r)
+		if r.Sign() != 0 {
+			return false
+		}
+	}
+	return n.Cmp(big.NewInt(1)) == 0
+}
+
+// MarshalText marshals the quantity as a string: "1.5 kg".
+func (q Quantity) MarshalText() ([]byte, error) {
+	return []byte(q.String()), nil
+}
+
+// UnmarshalText parses a quantity (see Parse), so quantities can be used in
+// query params and CSV files.
+func (q *Quantity) UnmarshalText(text []byte) error {
+	parsed, err := Parse(string(text))
+	if err != nil {
+		return err
+	}
+	*q = parsed
+	return nil
+}
+
+// plain has the fields of Quantity without its methods, to encode it as an
+// object.
+type plain Quantity
+
+// MarshalJSON keeps the object form of quantities, {"quantity": 1.5, "unit":
+// "kg"}, which would be replaced by the text form otherwise.
+func (q Quantity) MarshalJSON() ([]byte, error) {
+	return json.Marshal(plain(q))
+}
+
+// UnmarshalJSON accepts the object form and the text form: "1.5 kg".
+func (q *Quantity) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		return q.UnmarshalText([]byte(s))
+	}
+
+	return json.Unmarshal(data, (*plain)(q))
+}
@@ -1,47 +1,53 @@
 package quantity
 
 import (
+	"math"
+	"math/big"
+
 	"github.com/aboglioli/big-brother/pkg/errors"
 	"github.com/aboglioli/big-brother/pkg/unit"
 )
 
-// Quantity defines quantity with unit from International System of Units
+// Quantity defines quantity with unit from International System of Units or
+// imperial and US customary units
 type Quantity struct {
 	Quantity float64 `bson:"quantity" json:"quantity"`
 	Unit     string  `bson:"unit" json:"unit"`
 }
 
+// epsilon is the relative tolerance of Equals.
+const epsilon = 1e-9
+
+// Add adds q2 in the unit of q1. Units are converted with exact rationals, so
+// mixing systems (1 lb + 16 oz = 2 lb) doesn't accumulate rounding errors.
 func (q1 Quantity) Add(q2 Quantity) (Quantity, error) {
 	u1, err := referenceUnit(q1, q2)
 	if err != nil {
 		return Quantity{}, err
 	}
 
-	nQ1 := q1.Normalize()
-	nQ2 := q2.Normalize()
-	total := nQ1 + nQ2
-	total = total / u1.Modifier
+	total := new(big.Rat).Add(q1.normalized(), q2.normalized())
+	total.Quo(total, u1.Factor())
 
 	return Quantity{
 		Unit:     q1.Unit,
-		Quantity: total,
+		Quantity: toFloat(total),
 	}, nil
 }
 
+// Subtract subtracts q2 in the unit of q1 (see Add).
 func (q1 Quantity) Subtract(q2 Quantity) (Quantity, error) {
 	u1, err := referenceUnit(q1, q2)
 	if err != nil {
 		return Quantity{}, err
 	}
 
-	nQ1 := q1.Normalize()
-	nQ2 := q2.Normalize()
-	total := nQ1 - nQ2
-	total = total / u1.Modifier
+	total := new(big.Rat).Sub(q1.normalized(), q2.normalized())
+	total.Quo(total, u1.Factor())
 
 	return Quantity{
 		Unit:     q1.Unit,
-		Quantity: total,
+		Quantity: toFloat(total),
 	}, nil
 }
 
@@ -54,10 +60,13 @@ func (q1 Quantity) Equals(q2 Quantity) bool {
 		return false
 	}
 
-	n1 := q1.Normalize()
-	n2 := q2.Normalize()
+	if !u1.SameType(u2) {
+		return false
+	}
 
-	return u1.SameType(u2) && n1 == n2
+	// Quantities are floats, so they are equal within a relative tolerance
+	n1, n2 := q1.Normalize(), q2.Normalize()
+	return math.Abs(n1-n2) <= epsilon*math.Max(math.Abs(n1), math.Abs(n2))
 }
 
 func (q1 Quantity) Compatible(q2 Quantity) bool {
@@ -72,9 +81,23 @@ func (q1 Quantity) Compatible(q2 Quantity) bool {
 }
 
 func (q Quantity) Normalize() float64 {
+	return toFloat(q.normalized())
+}
+
+// normalized returns the exact normalized quantity, or zero if the unit
+// doesn't exist.
+func (q Quantity) normalized() *big.Rat {
 	repo := unit.GetRepository()
 	u := repo.FindByName(q.Unit)
-	return q.Quantity * u.Modifier
+	if u == nil {
+		return new(big.Rat)
+	}
+	return new(big.Rat).Mul(unit.Rat(q.Quantity), u.Factor())
+}
+
+func toFloat(r *big.Rat) float64 {
+	f, _ := r.Float64()
+	return f
 }
 
 func (q Quantity) IsValid() bool {
@@ -95,16 +118,16 @@ func referenceUnit(q1, q2 Quantity) (*unit.Unit, error) {
 
 	u1 := repo.FindByName(q1.Unit)
 	if u1 == nil {
-		return nil, errors.NewStatus("UNIT_DOES_NOT_EXIST").SetPath(path)
+		return nil, errors.NewStatus(ErrUnitDoesNotExist).SetPath(path)
 	}
 
 	u2 := repo.FindByName(q2.Unit)
 	if u2 == nil {
-		return nil, errors.NewStatus("UNIT_DOES_NOT_EXIST").SetPath(path)
+		return nil, errors.NewStatus(ErrUnitDoesNotExist).SetPath(path)
 	}
 
 	if !u1.SameType(u2) {
-		return nil, errors.NewStatus("INCOMPATIBLE_UNITS").SetPath(path)
+		return nil, errors.NewStatus(ErrIncompatibleUnits).SetPath(path)
 	}
 
 	return u1, nil
@@ -1,6 +1,7 @@
 package quantity
 
 import (
+	"encoding/json"
 	"testing"
 
 	"github.com/aboglioli/big-brother/pkg/tests/assert"
@@ -50,3 +51,201 @@ func TestSubtract(t *testing.T) {
 		assert.Err(t, err)
 	})
 }
+
+func TestImperialUnits(t *testing.T) {
+	q, err := Quantity{1, "lb"}.Add(Quantity{16, "oz"})
+	assert.Ok(t, err)
+	assert.Equal(t, q, Quantity{2, "lb"})
+
+	q, err = Quantity{1, "gal"}.Subtract(Quantity{3, "qt"})
+	assert.Ok(t, err)
+	assert.Equal(t, q, Quantity{0.25, "gal"})
+
+	q, err = Quantity{1, "yd"}.Subtract(Quantity{1, "ft"})
+	assert.Ok(t, err)
+	assert.Equal(t, q, Quantity{0.6666666666666666, "yd"})
+
+	q, err = Quantity{1, "kg"}.Add(Quantity{1, "lb"})
+	assert.Ok(t, err)
+	assert.Equal(t, q, Quantity{1.45359237, "kg"})
+
+	q, err = Convert(Quantity{30, "in"}, "ft", nil)
+	assert.Ok(t, err)
+	assert.Equal(t, q, Quantity{2.5, "ft"})
+
+	assert.Assert(t, Quantity{1, "gal"}.Equals(Quantity{128, "fl oz"}))
+	assert.Assert(t, Quantity{1, "ft"}.Equals(Quantity{30.48, "cm"}))
+	assert.Assert(t, Quantity{0.1 + 0.2, "kg"}.Equals(Quantity{300, "g"}))
+	assert.Assert(t, !Quantity{1, "lb"}.Equals(Quantity{453.6, "g"}))
+	assert.Assert(t, !Quantity{1, "lb"}.Equals(Quantity{1, "gal"}))
+}
+
+func TestConvert(t *testing.T) {
+	conversions := []Conversion{
+		Conversion{Quantity{1, "pallet"}, Quantity{40, "case"}},
+		Conversion{Quantity{1, "case"}, Quantity{4, "box"}},
+		Conversion{Quantity{1, "box"}, Quantity{12, "u"}},
+		Conversion{Quantity{1, "u"}, Quantity{300, "g"}},
+	}
+
+	q, err := Convert(Quantity{2, "box"}, "u", conversions)
+	assert.Ok(t, err)
+	assert.Equal(t, q, Quantity{24, "u"})
+
+	q, err = Convert(Quantity{1, "box"}, "kg", conversions)
+	assert.Ok(t, err)
+	assert.Equal(t, q, Quantity{3.6, "kg"})
+
+	q, err = Convert(Quantity{7.2, "kg"}, "box", conversions)
+	assert.Ok(t, err)
+	assert.Equal(t, q, Quantity{2, "box"})
+
+	q, err = Convert(Quantity{1, "pallet"}, "u", conversions)
+	assert.Ok(t, err)
+	assert.Equal(t, q, Quantity{1920, "u"})
+
+	q, err = Convert(Quantity{500, "g"}, "kg", nil)
+	assert.Ok(t, err)
+	assert.Equal(t, q, Quantity{0.5, "kg"})
+
+	_, err = Convert(Quantity{1, "box"}, "l", conversions)
+	assert.ErrCode(t, err, "INCOMPATIBLE_UNITS")
+
+	_, err = Convert(Quantity{1, "bag"}, "u", conversions)
+	assert.ErrCode(t, err, "UNIT_DOES_NOT_EXIST")
+
+	assert.Assert(t, !Conversion{Quantity{1, "kg"}, Quantity{1000, "g"}}.IsValid(), "Same unit type")
+	assert.Assert(t, !Conversion{Quantity{0, "box"}, Quantity{12, "u"}}.IsValid(), "Zero quantity")
+}
+
+func TestDensity(t *testing.T) {
+	oil := Density(0.92)
+
+	q, err := Convert(Quantity{100, "ml"}, "g", []Conversion{oil.Conversion()})
+	assert.Ok(t, err)
+	assert.Assert(t, q.Equals(Quantity{92, "g"}))
+
+	q, err = Convert(Quantity{460, "g"}, "l", []Conversion{oil.Conversion()})
+	assert.Ok(t, err)
+	assert.Assert(t, q.Equals(Quantity{0.5, "l"}))
+
+	_, err = Convert(Quantity{500, "ml"}, "kg", nil)
+	assert.ErrCode(t, err, "DENSITY_REQUIRED")
+}
+
+func TestParse(t *testing.T) {
+	q, err := Parse("1.5 kg")
+	assert.Ok(t, err)
+	assert.Equal(t, q, Quantity{1.5, "kg"})
+
+	assert.Equal(t, MustParse("250g"), Quantity{250, "g"})
+	assert.Equal(t, MustParse(" 2  fl oz "), Quantity{2, "fl oz"})
+	assert.Equal(t, MustParse("1,500 g"), Quantity{1500, "g"})
+
+	q, err = ParseLocale("1.500,5 kg", "es-AR")
+	assert.Ok(t, err)
+	assert.Equal(t, q, Quantity{1500.5, "kg"})
+
+	_, err = Parse("kg")
+	assert.ErrCode(t, err, "INVALID_QUANTITY")
+	_, err = Parse("12")
+	assert.ErrCode(t, err, "INVALID_QUANTITY")
+	_, err = Parse("12 stones")
+	assert.ErrCode(t, err, "UNIT_DOES_NOT_EXIST")
+}
+
+func TestFormat(t *testing.T) {
+	q := Quantity{1234567.8912, "g"}
+	assert.Equal(t, q.String(), "1234567.8912 g")
+	assert.Equal(t, q.Format("en"), "1,234,567.891 g")
+	assert.Equal(t, q.Format("es"), "1.234.567,891 g")
+	assert.Equal(t, q.Format("fr-FR"), "1 234 567,891 g")
+	assert.Equal(t, Quantity{-0.5, "l"}.Format("xx"), "-0.5 l")
+
+	assert.Equal(t, Quantity{1500, "g"}.Best(), Quantity{1.5, "kg"})
+	assert.Equal(t, Quantity{0.25, "kg"}.Best(), Quantity{250, "g"})
+	assert.Equal(t, Quantity{0.5, "mg"}.Best(), Quantity{0.5, "mg"})
+	assert.Equal(t, Quantity{48, "oz"}.Best(), Quantity{3, "lb"})
+	assert.Equal(t, Quantity{36, "in"}.Best(), Quantity{1, "yd"})
+	assert.Equal(t, Quantity{1500, "g"}.Best().Format("es"), "1,5 kg")
+}
+
+func TestMarshal(t *testing.T) {
+	var v struct {
+		Object Quantity `json:"object"`
+		Text   Quantity `json:"text"`
+	}
+	assert.Ok(t, json.Unmarshal([]byte(`{"object": {"quantity": 2, "unit": "l"}, "text": "1.5 kg"}`), &v))
+	assert.Equal(t, v.Object, Quantity{2, "l"})
+	assert.Equal(t, v.Text, Quantity{1.5, "kg"})
+
+	data, err := json.Marshal(v)
+	assert.Ok(t, err)
+	assert.Equal(t, string(data), `{"object":{"quantity":2,"unit":"l"},"text":{"quantity":1.5,"unit":"kg"}}`)
+
+	text, err := Quantity{1.5, "kg"}.MarshalText()
+	assert.Ok(t, err)
+	assert.Equal(t, string(text), "1.5 kg")
+
+	assert.Err(t, json.Unmarshal([]byte(`{"object": "1.5 stones"}`), &v))
+}
+
+func TestArithmetic(t *testing.T) {
+	assert.Equal(t, Quantity{1.1, "kg"}.Multiply(3), Quantity{3.3, "kg"})
+
+	q, err := Quantity{1, "l"}.Divide(3)
+	assert.Ok(t, err)
+	assert.Equal(t, q, Quantity{0.3333333333333333, "l"})
+	_, err = Quantity{1, "l"}.Divide(0)
+	assert.ErrCode(t, err, ErrDivisionByZero)
+
+	r, err := Quantity{1.5, "kg"}.Ratio(Quantity{500, "g"})
+	assert.Ok(t, err)
+	assert.Equal(t, r, 3.0)
+	_, err = Quantity{1.5, "kg"}.Ratio(Quantity{0, "g"})
+	assert.ErrCode(t, err, ErrDivisionByZero)
+	_, err = Quantity{1.5, "kg"}.Ratio(Quantity{1, "l"})
+	assert.ErrCode(t, err, ErrIncompatibleUnits)
+
+	c, err := Quantity{1, "kg"}.Compare(Quantity{999, "g"})
+	assert.Ok(t, err)
+	assert.Equal(t, c, 1)
+	c, err = Quantity{0.1 + 0.2, "kg"}.Compare(Quantity{300, "g"})
+	assert.Ok(t, err)
+	assert.Equal(t, c, 0)
+	_, err = Quantity{1, "kg"}.Compare(Quantity{1, "stone"})
+	assert.ErrCode(t, err, ErrUnitDoesNotExist)
+
+	less, err := Quantity{1, "ft"}.Less(Quantity{1, "m"})
+	assert.Ok(t, err)
+	assert.Assert(t, less)
+	greater, err := Quantity{1, "ft"}.Greater(Quantity{1, "m"})
+	assert.Ok(t, err)
+	assert.Assert(t, !greater)
+	_, err = Quantity{1, "ft"}.Less(Quantity{1, "kg"})
+	assert.ErrCode(t, err, ErrIncompatibleUnits)
+
+	eq, err := Quantity{1, "kg"}.EqualsWithin(Quantity{1005, "g"}, Quantity{10, "g"})
+	assert.Ok(t, err)
+	assert.Assert(t, eq)
+	eq, err = Quantity{1, "kg"}.EqualsWithin(Quantity{1005, "g"}, Quantity{1, "g"})
+	assert.Ok(t, err)
+	assert.Assert(t, !eq)
+	_, err = Quantity{1, "kg"}.EqualsWithin(Quantity{1005, "g"}, Quantity{1, "l"})
+	assert.ErrCode(t, err, ErrIncompatibleUnits)
+
+	q, err = Quantity{2, "lb"}.ConvertTo("oz")
+	assert.Ok(t, err)
+	assert.Equal(t, q, Quantity{32, "oz"})
+	_, err = Quantity{2, "lb"}.ConvertTo("l")
+	assert.ErrCode(t, err, "DENSITY_REQUIRED")
+
+	q, err = Min(Quantity{1, "kg"}, Quantity{2, "lb"})
+	assert.Ok(t, err)
+	assert.Equal(t, q, Quantity{2, "lb"})
+	q, err = Max(Quantity{1, "kg"}, Quantity{2, "lb"})
+	assert.Ok(t, err)
+	assert.Equal(t, q, Quantity{1, "kg"})
+	_, err = Max(Quantity{1, "kg"}, Quantity{2, "m"})
+	assert.ErrCode(t, err, ErrIncompatibleUnits)
+}
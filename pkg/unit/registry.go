@@ -0,0 +1,131 @@
+package unit
+
+import (
+	"sync"
+	"time"
+)
+
+// Store persists the units added to the default ones.
+type Store interface {
+	FindAll() ([]*Unit, error)
+}
+
+// Registry is a Repository of the default units and the units of a Store. The
+// units are cached and reloaded when they expire, or when a unit isn't found
+// and the last reload is older than a second, so units added by other
+// processes are seen without restarting. Concurrent callers share a single
+// reload.
+type Registry struct {
+	store      Store
+	ttl        time.Duration
+	mutex      sync.RWMutex
+	reloading  sync.Mutex // serializes reloads of get and reloadMissing
+	units      Repository
+	reloadedAt time.Time // last reload attempt
+}
+
+// minReload is the minimum time between reloads caused by missing units.
+const minReload = time.Second
+
+func NewRegistry(store Store, ttl time.Duration) (*Registry, error) {
+	r := &Registry{
+		store: store,
+		ttl:   ttl,
+	}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Reload loads the units from the store. Stored units can't replace default
+// units. If it fails, the loaded units are kept.
+func (r *Registry) Reload() error {
+	stored, err := r.store.FindAll()
+	if err != nil {
+		r.mutex.Lock()
+		r.reloadedAt = time.Now()
+		r.mutex.Unlock()
+		return err
+	}
+
+	units := Defaults()
+	for _, u := range stored {
+		if !IsDefault(u.Name) {
+			units = append(units, u)
+		}
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.units = NewRepository(units)
+	r.reloadedAt = time.Now()
+
+	return nil
+}
+
+func (r *Registry) FindAll() []*Unit {
+	return r.get().FindAll()
+}
+
+func (r *Registry) FindByName(n string) *Unit {
+	u := r.get().FindByName(n)
+	if u == nil && r.reloadMissing() {
+		u = r.get().FindByName(n)
+	}
+	return u
+}
+
+func (r *Registry) FindByType(t string) []*Unit {
+	return r.get().FindByType(t)
+}
+
+func (r *Registry) Exists(u string) bool {
+	return r.FindByName(u) != nil
+}
+
+// get returns the cached units, reloading them if they expired. If reloading
+// fails, the expired units are used.
+func (r *Registry) get() Repository {
+	if r.expired() {
+		r.reloading.Lock()
+		// Reloaded by another caller while waiting
+		if r.expired() {
+			r.Reload()
+		}
+		r.reloading.Unlock()
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return r.units
+}
+
+// reloadMissing reloads the units unless they were reloaded recently. It
+// returns true if they were reloaded, by this or another caller.
+func (r *Registry) reloadMissing() bool {
+	since := r.reloadedSince()
+	if since < minReload {
+		return false
+	}
+
+	r.reloading.Lock()
+	defer r.reloading.Unlock()
+
+	// Reloaded by another caller while waiting
+	if since > r.reloadedSince() {
+		return true
+	}
+
+	return r.Reload() == nil
+}
+
+func (r *Registry) expired() bool {
+	return r.reloadedSince() > r.ttl
+}
+
+func (r *Registry) reloadedSince() time.Duration {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return time.Since(r.reloadedAt)
+}
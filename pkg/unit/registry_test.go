@@ -0,0 +1,81 @@
+package unit
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aboglioli/big-brother/pkg/tests/assert"
+)
+
+type store struct {
+	mutex sync.Mutex
+	units []*Unit
+	calls int
+	delay time.Duration
+}
+
+func (s *store) FindAll() ([]*Unit, error) {
+	time.Sleep(s.delay)
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.calls++
+	return s.units, nil
+}
+
+func TestRegistry(t *testing.T) {
+	s := &store{
+		units: []*Unit{
+			&Unit{"time", "h", 3600},
+			&Unit{"mass", "kg", 1}, // Can't replace default units
+		},
+	}
+
+	r, err := NewRegistry(s, time.Hour)
+	assert.Ok(t, err)
+	assert.Equal(t, s.calls, 1)
+
+	assert.Assert(t, r.Exists("h"))
+	assert.Equal(t, r.FindByName("kg").Modifier, 1000.0)
+	assert.Equal(t, len(r.FindAll()), len(Defaults())+1)
+
+	t.Run("Reload missing units", func(t *testing.T) {
+		s.units = append(s.units, &Unit{"time", "min", 60})
+
+		// Recently reloaded
+		assert.Nil(t, r.FindByName("min"))
+		assert.Equal(t, s.calls, 1)
+
+		r.reloadedAt = time.Now().Add(-minReload)
+		assert.NotNil(t, r.FindByName("min"))
+		assert.Equal(t, s.calls, 2)
+		assert.Equal(t, len(r.FindByType("time")), 2)
+	})
+
+	t.Run("Reload expired units", func(t *testing.T) {
+		s.units = s.units[:1]
+		assert.NotNil(t, r.FindByName("min"))
+
+		r.reloadedAt = time.Now().Add(-time.Hour)
+		assert.Nil(t, r.FindByName("min"))
+		assert.Equal(t, s.calls, 3)
+	})
+
+	t.Run("Concurrent callers share a reload", func(t *testing.T) {
+		s.delay = 10 * time.Millisecond
+		defer func() { s.delay = 0 }()
+
+		r.reloadedAt = time.Now().Add(-time.Hour)
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				r.FindAll()
+				r.FindByName("min")
+			}()
+		}
+		wg.Wait()
+		assert.Equal(t, s.calls, 4)
+	})
+}
@@ -14,36 +14,94 @@ type repository struct {
 }
 
 var repo Repository
-var once sync.Once
+var mutex sync.RWMutex
 
+// GetRepository returns the repository used by quantities. It defaults to an
+// in-memory repository of the default units.
 func GetRepository() Repository {
+	mutex.RLock()
+	r := repo
+	mutex.RUnlock()
+	if r != nil {
+		return r
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
 	if repo == nil {
-		once.Do(func() {
-			repo = &repository{
-				units: map[string]*Unit{
-					"u": &Unit{"unit", "u", 1},
-
-					"mg": &Unit{"mass", "mg", 0.001},
-					"cg": &Unit{"mass", "cg", 0.01},
-					"g":  &Unit{"mass", "g", 1},
-					"kg": &Unit{"mass", "kg", 1000},
-
-					"ml": &Unit{"volume", "ml", 0.001},
-					"cl": &Unit{"volume", "cl", 0.01},
-					"l":  &Unit{"volume", "l", 1},
-					"kl": &Unit{"volume", "kl", 1000},
-
-					"mm": &Unit{"length", "mm", 0.001},
-					"cm": &Unit{"length", "cm", 0.01},
-					"m":  &Unit{"length", "m", 1},
-					"km": &Unit{"length", "km", 1000},
-				},
-			}
-		})
+		repo = NewRepository(Defaults())
 	}
 	return repo
 }
 
+// SetRepository replaces the repository used by quantities (see Registry).
+func SetRepository(r Repository) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	repo = r
+}
+
+// Defaults returns the built-in units. They are always available and cannot
+// be deleted.
+func Defaults() []*Unit {
+	return []*Unit{
+		&Unit{"unit", "u", 1},
+
+		&Unit{"mass", "mg", 0.001},
+		&Unit{"mass", "cg", 0.01},
+		&Unit{"mass", "g", 1},
+		&Unit{"mass", "kg", 1000},
+
+		&Unit{"volume", "ml", 0.001},
+		&Unit{"volume", "cl", 0.01},
+		&Unit{"volume", "l", 1},
+		&Unit{"volume", "kl", 1000},
+
+		&Unit{"length", "mm", 0.001},
+		&Unit{"length", "cm", 0.01},
+		&Unit{"length", "m", 1},
+		&Unit{"length", "km", 1000},
+
+		// Imperial and US customary units, exact by definition.
+		&Unit{"mass", "oz", 28.349523125},
+		&Unit{"mass", "lb", 453.59237},
+		&Unit{"volume", "fl oz", 0.0295735295625},
+		&Unit{"volume", "qt", 0.946352946},
+		&Unit{"volume", "gal", 3.785411784},
+		&Unit{"length", "in", 0.0254},
+		&Unit{"length", "ft", 0.3048},
+		&Unit{"length", "yd", 0.9144},
+
+		// Packaging units. Each one is its own type: how many units a box
+		// contains depends on the composition.
+		&Unit{"pack", "pack", 1},
+		&Unit{"box", "box", 1},
+		&Unit{"case", "case", 1},
+		&Unit{"pallet", "pallet", 1},
+	}
+}
+
+// IsDefault returns true if u is a built-in unit.
+func IsDefault(u string) bool {
+	for _, d := range Defaults() {
+		if d.Name == u {
+			return true
+		}
+	}
+	return false
+}
+
+// NewRepository creates an in-memory repository of units.
+func NewRepository(units []*Unit) Repository {
+	r := &repository{
+		units: make(map[string]*Unit, len(units)),
+	}
+	for _, u := range units {
+		r.units[u.Name] = u
+	}
+	return r
+}
+
 func (r *repository) FindAll() []*Unit {
 	units := make([]*Unit, 0, len(r.units))
 	for _, v := range r.units {
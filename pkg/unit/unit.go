@@ -1,9 +1,16 @@
 package unit
 
+import (
+	"math/big"
+	"strconv"
+
+	"github.com/aboglioli/big-brother/pkg/errors"
+)
+
 type Unit struct {
-	Type     string
-	Name     string
-	Modifier float64
+	Type     string  `json:"type" bson:"type"`
+	Name     string  `json:"name" bson:"name"`
+	Modifier float64 `json:"modifier" bson:"modifier"`
 }
 
 func (u1 *Unit) Equals(u2 *Unit) bool {
@@ -13,3 +20,39 @@ func (u1 *Unit) Equals(u2 *Unit) bool {
 func (u1 *Unit) SameType(u2 *Unit) bool {
 	return u1.Type == u2.Type
 }
+
+// Factor returns the modifier as an exact rational. It is the rational of the
+// shortest decimal representing the modifier, so 1 lb = 453.59237 g is
+// 45359237/100000 and not its binary approximation.
+func (u *Unit) Factor() *big.Rat {
+	return Rat(u.Modifier)
+}
+
+// Rat returns the rational of the shortest decimal representing f.
+func Rat(f float64) *big.Rat {
+	r, ok := new(big.Rat).SetString(strconv.FormatFloat(f, 'g', -1, 64))
+	if !ok {
+		return new(big.Rat)
+	}
+	return r
+}
+
+func (u *Unit) ValidateSchema() error {
+	err := errors.NewValidation("VALIDATE_SCHEMA")
+
+	if u.Type == "" {
+		err.Add("type", "REQUIRED")
+	}
+	if u.Name == "" {
+		err.Add("name", "REQUIRED")
+	}
+	if u.Modifier <= 0 {
+		err.Add("modifier", "INVALID")
+	}
+
+	if err.Size() > 0 {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,18 @@
+package pricing
+
+import (
+	"github.com/aboglioli/big-brother/pkg/events"
+)
+
+// EntriesFlaggedEvent is published when cost increases push the margin of
+// price entries below the configured floor
+type EntriesFlaggedEvent struct {
+	events.Event
+	Entries []*Analysis `json:"entries"`
+}
+
+func NewEntriesFlaggedEvent(entries []*Analysis) (*EntriesFlaggedEvent, *events.Options) {
+	event := &EntriesFlaggedEvent{events.NewEvent("PriceEntriesFlagged", ""), entries}
+	opts := &events.Options{"pricing", "topic", "pricing.margin", ""}
+	return event, opts
+}
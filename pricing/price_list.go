@@ -0,0 +1,217 @@
+package pricing
+
+import (
+	"math"
+	"time"
+
+	"github.com/aboglioli/big-brother/pkg/errors"
+	"github.com/aboglioli/big-brother/pkg/quantity"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Entry is the selling price of a composition for a quantity (e.g. $12 per
+// 500 g). MinQuantity defines a quantity break: the entry applies to sales of
+// at least that quantity. LastCost is the cost of Quantity when the entry was
+// last checked, and Flagged is set when a cost increase pushed the margin
+// below the configured floor.
+type Entry struct {
+	ID          primitive.ObjectID `json:"id" bson:"id"`
+	Composition primitive.ObjectID `json:"composition" bson:"composition"`
+	Quantity    quantity.Quantity  `json:"quantity" bson:"quantity"`
+	MinQuantity quantity.Quantity  `json:"minQuantity" bson:"minQuantity"`
+	Price       float64            `json:"price" bson:"price"`
+	LastCost    float64            `json:"lastCost" bson:"lastCost"`
+	Flagged     bool               `json:"flagged" bson:"flagged"`
+	FlaggedAt   *time.Time         `json:"flaggedAt" bson:"flaggedAt"`
+}
+
+// PriceFor returns the price of a quantity, with the same semantics than
+// Composition.CostFromQuantity.
+func (e *Entry) PriceFor(q quantity.Quantity) float64 {
+	ratio, err := q.Ratio(e.Quantity)
+	if err != nil {
+		return 0
+	}
+	return ratio * e.Price
+}
+
+// AppliesTo returns true if the entry quantity break applies to the quantity.
+func (e *Entry) AppliesTo(q quantity.Quantity) bool {
+	if !q.Compatible(e.Quantity) {
+		return false
+	}
+	if e.MinQuantity.IsEmpty() {
+		return true
+	}
+	less, err := q.Less(e.MinQuantity)
+	return err == nil && !less
+}
+
+// PriceList is a list of selling prices for a customer group. Lists without
+// group apply to every customer. ValidFrom and ValidTo are optional.
+type PriceList struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id"`
+	Name      string             `json:"name" bson:"name"`
+	Group     string             `json:"group" bson:"group"`
+	ValidFrom *time.Time         `json:"validFrom" bson:"validFrom"`
+	ValidTo   *time.Time         `json:"validTo" bson:"validTo"`
+	Entries   []Entry            `json:"entries" bson:"entries"`
+
+	Enabled   bool      `json:"-" bson:"enabled"`
+	CreatedAt time.Time `json:"createdAt" bson:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt" bson:"updatedAt"`
+}
+
+func NewPriceList() *PriceList {
+	return &PriceList{
+		ID:        primitive.NewObjectID(),
+		Entries:   make([]Entry, 0),
+		Enabled:   true,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+}
+
+// IsValidAt returns true if the price list is valid at the given time.
+func (pl *PriceList) IsValidAt(t time.Time) bool {
+	if pl.ValidFrom != nil && t.Before(*pl.ValidFrom) {
+		return false
+	}
+	if pl.ValidTo != nil && t.After(*pl.ValidTo) {
+		return false
+	}
+	return true
+}
+
+func (pl *PriceList) FindEntry(entryID string) *Entry {
+	for i := range pl.Entries {
+		if pl.Entries[i].ID.Hex() == entryID {
+			return &pl.Entries[i]
+		}
+	}
+	return nil
+}
+
+// UpsertEntry adds an entry or replaces the entry with the same composition
+// and quantity break.
+func (pl *PriceList) UpsertEntry(e Entry) *Entry {
+	for i := range pl.Entries {
+		existing := &pl.Entries[i]
+		if existing.Composition == e.Composition && sameBreak(existing.MinQuantity, e.MinQuantity) {
+			e.ID = existing.ID
+			*existing = e
+			return existing
+		}
+	}
+
+	if e.ID.IsZero() {
+		e.ID = primitive.NewObjectID()
+	}
+	pl.Entries = append(pl.Entries, e)
+
+	return &pl.Entries[len(pl.Entries)-1]
+}
+
+func (pl *PriceList) RemoveEntry(entryID string) error {
+	removed := false
+	entries := make([]Entry, 0, len(pl.Entries))
+	for _, e := range pl.Entries {
+		if e.ID.Hex() != entryID {
+			entries = append(entries, e)
+			continue
+		}
+		removed = true
+	}
+	pl.Entries = entries
+
+	if !removed {
+		return errors.NewStatus("ENTRY_DOES_NOT_EXIST").SetPath("pricing/price_list.RemoveEntry")
+	}
+
+	return nil
+}
+
+// BestEntry returns the entry of a composition with the highest quantity break
+// applying to the quantity.
+func (pl *PriceList) BestEntry(compID string, q quantity.Quantity) *Entry {
+	var best *Entry
+	for i := range pl.Entries {
+		e := &pl.Entries[i]
+		if e.Composition.Hex() != compID || !e.AppliesTo(q) {
+			continue
+		}
+
+		if best == nil || higherBreak(e, best) {
+			best = e
+		}
+	}
+	return best
+}
+
+func (pl *PriceList) ValidateSchema() error {
+	err := errors.NewValidation("VALIDATE_SCHEMA").SetPath("pricing/price_list.ValidateSchema")
+
+	if len(pl.Name) < 1 || len(pl.Name) > 128 {
+		err.AddWithMessage("name", "INVALID_LENGTH", "%d", len(pl.Name))
+	}
+
+	if pl.ValidFrom != nil && pl.ValidTo != nil && pl.ValidTo.Before(*pl.ValidFrom) {
+		err.Add("validTo", "BEFORE_VALID_FROM")
+	}
+
+	for i, e := range pl.Entries {
+		if !e.Quantity.IsValid() || e.Quantity.Quantity == 0 {
+			err.AddWithMessage("entries", "INVALID_QUANTITY", "entry %d", i)
+		}
+		if !e.MinQuantity.IsEmpty() && (!e.MinQuantity.IsValid() || !e.MinQuantity.Compatible(e.Quantity)) {
+			err.AddWithMessage("entries", "INVALID_MIN_QUANTITY", "entry %d", i)
+		}
+		if e.Price < 0 {
+			err.AddWithMessage("entries", "INVALID_PRICE", "entry %d", i)
+		}
+	}
+
+	if err.Size() > 0 {
+		return err
+	}
+
+	return nil
+}
+
+// Margin returns the gross margin of a price over a cost: (price - cost) /
+// price.
+func Margin(price, cost float64) float64 {
+	if price == 0 {
+		return 0
+	}
+	return math.Round((price-cost)/price*1000) / 1000
+}
+
+// Markup returns the markup of a price over a cost: (price - cost) / cost.
+func Markup(price, cost float64) float64 {
+	if cost == 0 {
+		return 0
+	}
+	return math.Round((price-cost)/cost*1000) / 1000
+}
+
+// higherBreak returns true if the quantity break of e1 is higher than the one
+// of e2. Entries without quantity break have the lowest one.
+func higherBreak(e1, e2 *Entry) bool {
+	if e1.MinQuantity.IsEmpty() {
+		return false
+	}
+	if e2.MinQuantity.IsEmpty() {
+		return true
+	}
+
+	greater, err := e1.MinQuantity.Greater(e2.MinQuantity)
+	return err == nil && greater
+}
+
+func sameBreak(q1, q2 quantity.Quantity) bool {
+	if q1.IsEmpty() || q2.IsEmpty() {
+		return q1.IsEmpty() && q2.IsEmpty()
+	}
+	return q1.Equals(q2)
+}
@@ -0,0 +1,59 @@
+package pricing
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aboglioli/big-brother/pkg/quantity"
+	"github.com/aboglioli/big-brother/pkg/tests/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestQuantityBreaks(t *testing.T) {
+	comp := primitive.NewObjectID()
+	pl := NewPriceList()
+	pl.UpsertEntry(Entry{Composition: comp, Quantity: quantity.Quantity{1, "kg"}, Price: 10})
+	pl.UpsertEntry(Entry{Composition: comp, Quantity: quantity.Quantity{1, "kg"}, MinQuantity: quantity.Quantity{10, "kg"}, Price: 9})
+	pl.UpsertEntry(Entry{Composition: comp, Quantity: quantity.Quantity{1, "kg"}, MinQuantity: quantity.Quantity{50, "kg"}, Price: 8})
+	pl.UpsertEntry(Entry{Composition: comp, Quantity: quantity.Quantity{1, "kg"}, MinQuantity: quantity.Quantity{10000, "g"}, Price: 8.5})
+	assert.Equal(t, len(pl.Entries), 3, "Same break should be replaced")
+
+	e := pl.BestEntry(comp.Hex(), quantity.Quantity{500, "g"})
+	assert.Equal(t, e.Price, 10.0)
+	e = pl.BestEntry(comp.Hex(), quantity.Quantity{10, "kg"})
+	assert.Equal(t, e.Price, 8.5)
+	assert.Equal(t, e.PriceFor(quantity.Quantity{10, "kg"}), 85.0)
+	e = pl.BestEntry(comp.Hex(), quantity.Quantity{60, "kg"})
+	assert.Equal(t, e.Price, 8.0)
+
+	assert.Nil(t, pl.BestEntry(comp.Hex(), quantity.Quantity{1, "l"}))
+	assert.Nil(t, pl.BestEntry(primitive.NewObjectID().Hex(), quantity.Quantity{1, "kg"}))
+
+	assert.Ok(t, pl.RemoveEntry(e.ID.Hex()))
+	assert.ErrCode(t, pl.RemoveEntry(e.ID.Hex()), "ENTRY_DOES_NOT_EXIST")
+}
+
+func TestValidity(t *testing.T) {
+	now := time.Now()
+	from, to := now.Add(-time.Hour), now.Add(time.Hour)
+
+	pl := NewPriceList()
+	pl.Name = "Summer"
+	assert.Assert(t, pl.IsValidAt(now))
+
+	pl.ValidFrom, pl.ValidTo = &from, &to
+	assert.Assert(t, pl.IsValidAt(now))
+	assert.Assert(t, !pl.IsValidAt(now.Add(-2*time.Hour)))
+	assert.Assert(t, !pl.IsValidAt(now.Add(2*time.Hour)))
+	assert.Ok(t, pl.ValidateSchema())
+
+	pl.ValidFrom, pl.ValidTo = &to, &from
+	assert.ErrValidation(t, pl.ValidateSchema(), "validTo", "BEFORE_VALID_FROM")
+}
+
+func TestMarginAndMarkup(t *testing.T) {
+	assert.Equal(t, Margin(100, 75), 0.25)
+	assert.Equal(t, Markup(100, 75), 0.333)
+	assert.Equal(t, Margin(0, 75), 0.0)
+	assert.Equal(t, Markup(100, 0), 0.0)
+}
@@ -0,0 +1,176 @@
+package pricing
+
+import (
+	"context"
+	"time"
+
+	"github.com/aboglioli/big-brother/infrastructure/db"
+	"github.com/aboglioli/big-brother/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type Repository interface {
+	FindAll() ([]*PriceList, error)
+	FindByID(id string) (*PriceList, error)
+	FindByComposition(compID string) ([]*PriceList, error)
+
+	Insert(*PriceList) error
+	Update(*PriceList) error
+	Delete(id string) error
+}
+
+type repository struct {
+	collection *mongo.Collection
+}
+
+func NewRepository() (Repository, error) {
+	db, err := db.Get("Pricing")
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &repository{
+		collection: db.Collection("priceList"),
+	}, nil
+}
+
+func (r *repository) FindAll() ([]*PriceList, error) {
+	return r.find("pricing/repository.FindAll", bson.M{
+		"enabled": true,
+	})
+}
+
+func (r *repository) FindByID(id string) (*PriceList, error) {
+	path := "pricing/repository.FindByID"
+	ctx := context.Background()
+
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errors.NewInternal("OBJECTID_FROM_HEX").SetPath(path).SetRef(err)
+	}
+
+	filter := bson.M{
+		"_id": objID,
+	}
+
+	res := r.collection.FindOne(ctx, filter)
+	if res.Err() != nil {
+		return nil, errors.NewInternal("FIND_ONE").SetPath(path).SetRef(res.Err())
+	}
+
+	var pl PriceList
+	if err := res.Decode(&pl); err != nil {
+		return nil, errors.NewInternal("DECODE").SetPath(path).SetRef(err)
+	}
+
+	return &pl, nil
+}
+
+func (r *repository) FindByComposition(compID string) ([]*PriceList, error) {
+	path := "pricing/repository.FindByComposition"
+
+	objID, err := primitive.ObjectIDFromHex(compID)
+	if err != nil {
+		return nil, errors.NewInternal("OBJECTID_FROM_HEX").SetPath(path).SetRef(err)
+	}
+
+	return r.find(path, bson.M{
+		"entries.composition": objID,
+		"enabled":             true,
+	})
+}
+
+func (r *repository) Insert(pl *PriceList) error {
+	ctx := context.Background()
+
+	_, err := r.collection.InsertOne(ctx, pl)
+	if err != nil {
+		return errors.NewInternal("INSERT_ONE").SetPath("pricing/repository.Insert").SetRef(err)
+	}
+
+	return nil
+}
+
+func (r *repository) Update(pl *PriceList) error {
+	path := "pricing/repository.Update"
+	ctx := context.Background()
+
+	if pl.ID.IsZero() {
+		return errors.NewInternal("INVALID_OBJECTID").SetPath(path)
+	}
+
+	pl.UpdatedAt = time.Now()
+
+	filter := bson.M{
+		"_id": pl.ID,
+	}
+
+	update := bson.M{
+		"$set": pl,
+	}
+
+	_, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return errors.NewInternal("UPDATE_ONE").SetPath(path).SetRef(err)
+	}
+
+	return nil
+}
+
+func (r *repository) Delete(id string) error {
+	path := "pricing/repository.Delete"
+	ctx := context.Background()
+
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return errors.NewInternal("OBJECTID_FROM_HEX").SetPath(path).SetRef(err)
+	}
+
+	filter := bson.M{
+		"_id": objID,
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"updatedAt": time.Now(),
+			"enabled":   false,
+		},
+	}
+
+	_, err = r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return errors.NewInternal("UPDATE_ONE").SetPath(path).SetRef(err)
+	}
+
+	return nil
+}
+
+func (r *repository) find(path string, filter bson.M) ([]*PriceList, error) {
+	ctx := context.Background()
+
+	cur, err := r.collection.Find(ctx, filter)
+	if err != nil {
+		return nil, errors.NewInternal("FIND").SetPath(path).SetRef(err)
+	}
+	defer cur.Close(ctx)
+
+	priceLists := make([]*PriceList, 0)
+	for cur.Next(ctx) {
+		var pl PriceList
+
+		if err := cur.Decode(&pl); err != nil {
+			return nil, errors.NewInternal("CUR_DECODE").SetPath(path).SetRef(err)
+		}
+
+		priceLists = append(priceLists, &pl)
+	}
+
+	if err := cur.Err(); err != nil {
+		return nil, errors.NewInternal("CUR").SetPath(path).SetRef(err)
+	}
+
+	return priceLists, nil
+}
@@ -0,0 +1,115 @@
+package pricing
+
+import (
+	"time"
+
+	"github.com/aboglioli/big-brother/pkg/errors"
+	"github.com/aboglioli/big-brother/pkg/tests/mock"
+)
+
+type mockRepository struct {
+	mock.Mock
+	priceLists []*PriceList
+}
+
+// NewMockRepository returns an in-memory Repository. It is exported to be
+// used by other packages' tests.
+func NewMockRepository() *mockRepository {
+	return &mockRepository{}
+}
+
+// Helpers
+func (r *mockRepository) Clean() {
+	r.priceLists = make([]*PriceList, 0)
+}
+
+// Implementation
+func (r *mockRepository) FindAll() ([]*PriceList, error) {
+	r.Called("FindAll")
+
+	priceLists := make([]*PriceList, 0)
+	for _, pl := range r.priceLists {
+		if pl.Enabled {
+			priceLists = append(priceLists, copyPriceList(pl))
+		}
+	}
+
+	return priceLists, nil
+}
+
+func (r *mockRepository) FindByID(id string) (*PriceList, error) {
+	r.Called("FindByID", id)
+
+	for _, pl := range r.priceLists {
+		if pl.ID.Hex() == id {
+			return copyPriceList(pl), nil
+		}
+	}
+
+	return nil, errors.NewInternal("NOT_FOUND").SetPath("pricing/repository_mock.FindByID")
+}
+
+func (r *mockRepository) FindByComposition(compID string) ([]*PriceList, error) {
+	r.Called("FindByComposition", compID)
+
+	priceLists := make([]*PriceList, 0)
+	for _, pl := range r.priceLists {
+		if pl.Enabled && hasComposition(pl, compID) {
+			priceLists = append(priceLists, copyPriceList(pl))
+		}
+	}
+
+	return priceLists, nil
+}
+
+func (r *mockRepository) Insert(pl *PriceList) error {
+	r.Called("Insert", pl)
+
+	pl.UpdatedAt = time.Now()
+	r.priceLists = append(r.priceLists, copyPriceList(pl))
+
+	return nil
+}
+
+func (r *mockRepository) Update(pl *PriceList) error {
+	r.Called("Update", pl)
+
+	for _, priceList := range r.priceLists {
+		if priceList.ID.Hex() == pl.ID.Hex() {
+			*priceList = *copyPriceList(pl)
+			priceList.UpdatedAt = time.Now()
+			break
+		}
+	}
+
+	return nil
+}
+
+func (r *mockRepository) Delete(id string) error {
+	r.Called("Delete", id)
+
+	for _, pl := range r.priceLists {
+		if pl.ID.Hex() == id {
+			pl.UpdatedAt = time.Now()
+			pl.Enabled = false
+			return nil
+		}
+	}
+
+	return errors.NewInternal("NOT_FOUND").SetPath("pricing/repository_mock.Delete")
+}
+
+func copyPriceList(pl *PriceList) *PriceList {
+	copy := *pl
+	copy.Entries = append([]Entry(nil), pl.Entries...)
+	return &copy
+}
+
+func hasComposition(pl *PriceList, compID string) bool {
+	for _, e := range pl.Entries {
+		if e.Composition.Hex() == compID {
+			return true
+		}
+	}
+	return false
+}
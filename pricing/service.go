@@ -0,0 +1,483 @@
+package pricing
+
+import (
+	"math"
+	"time"
+
+	"github.com/aboglioli/big-brother/composition"
+	"github.com/aboglioli/big-brother/pkg/errors"
+	"github.com/aboglioli/big-brother/pkg/events"
+	"github.com/aboglioli/big-brother/pkg/quantity"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Analysis is the margin of a price entry against the current composition
+// cost.
+type Analysis struct {
+	PriceList   primitive.ObjectID `json:"priceList"`
+	Entry       primitive.ObjectID `json:"entry"`
+	Composition primitive.ObjectID `json:"composition"`
+	Quantity    quantity.Quantity  `json:"quantity"`
+	MinQuantity quantity.Quantity  `json:"minQuantity"`
+	Price       float64            `json:"price"`
+	Cost        float64            `json:"cost"`
+	Margin      float64            `json:"margin"`
+	Markup      float64            `json:"markup"`
+	Flagged     bool               `json:"flagged"`
+}
+
+// Quote is the price of a quantity of a composition for a customer group.
+type Quote struct {
+	PriceList   primitive.ObjectID `json:"priceList"`
+	Entry       primitive.ObjectID `json:"entry"`
+	Composition primitive.ObjectID `json:"composition"`
+	Quantity    quantity.Quantity  `json:"quantity"`
+	Price       float64            `json:"price"`
+	Cost        float64            `json:"cost"`
+	Margin      float64            `json:"margin"`
+	Markup      float64            `json:"markup"`
+}
+
+type Service interface {
+	GetAll() ([]*PriceList, error)
+	GetByID(id string) (*PriceList, error)
+	Create(req *CreateRequest) (*PriceList, error)
+	Update(id string, req *UpdateRequest) (*PriceList, error)
+	Delete(id string) error
+
+	SetEntry(id string, req *EntryRequest) (*PriceList, error)
+	RemoveEntry(id string, entryID string) (*PriceList, error)
+
+	Analyze(id string) ([]*Analysis, error)
+	FindFlagged() ([]*Analysis, error)
+	Quote(req *QuoteRequest) (*Quote, error)
+	CheckMargins(comps []*composition.Composition) ([]*Analysis, error)
+}
+
+type service struct {
+	repository         Repository
+	compositionService composition.Service
+	eventMgr           events.Manager
+	marginFloor        float64
+}
+
+// NewService creates the pricing service. Entries whose margin falls below
+// marginFloor after a cost increase are flagged.
+func NewService(repo Repository, compServ composition.Service, eventMgr events.Manager, marginFloor float64) Service {
+	return &service{
+		repository:         repo,
+		compositionService: compServ,
+		eventMgr:           eventMgr,
+		marginFloor:        marginFloor,
+	}
+}
+
+func (s *service) GetAll() ([]*PriceList, error) {
+	priceLists, err := s.repository.FindAll()
+	if err != nil {
+		return nil, errors.NewStatus("FIND_ALL").SetPath("pricing/service.GetAll").SetRef(err)
+	}
+	return priceLists, nil
+}
+
+func (s *service) GetByID(id string) (*PriceList, error) {
+	pl, err := s.repository.FindByID(id)
+	if err != nil || !pl.Enabled {
+		return nil, errors.NewStatus("PRICE_LIST_NOT_FOUND").SetPath("pricing/service.GetByID").SetStatus(404).SetRef(err)
+	}
+	return pl, nil
+}
+
+type CreateRequest struct {
+	Name      string     `json:"name" binding:"required"`
+	Group     string     `json:"group"`
+	ValidFrom *time.Time `json:"validFrom"`
+	ValidTo   *time.Time `json:"validTo"`
+}
+
+func (s *service) Create(req *CreateRequest) (*PriceList, error) {
+	pl := NewPriceList()
+	pl.Name = req.Name
+	pl.Group = req.Group
+	pl.ValidFrom = req.ValidFrom
+	pl.ValidTo = req.ValidTo
+
+	if err := pl.ValidateSchema(); err != nil {
+		return nil, err
+	}
+
+	if err := s.repository.Insert(pl); err != nil {
+		return nil, errors.NewStatus("INSERT").SetPath("pricing/service.Create").SetRef(err)
+	}
+
+	return pl, nil
+}
+
+type UpdateRequest struct {
+	Name      *string    `json:"name"`
+	Group     *string    `json:"group"`
+	ValidFrom *time.Time `json:"validFrom"`
+	ValidTo   *time.Time `json:"validTo"`
+}
+
+func (s *service) Update(id string, req *UpdateRequest) (*PriceList, error) {
+	pl, err := s.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		pl.Name = *req.Name
+	}
+	if req.Group != nil {
+		pl.Group = *req.Group
+	}
+	if req.ValidFrom != nil {
+		pl.ValidFrom = req.ValidFrom
+	}
+	if req.ValidTo != nil {
+		pl.ValidTo = req.ValidTo
+	}
+
+	if err := pl.ValidateSchema(); err != nil {
+		return nil, err
+	}
+
+	if err := s.repository.Update(pl); err != nil {
+		return nil, errors.NewStatus("UPDATE").SetPath("pricing/service.Update").SetRef(err)
+	}
+
+	return pl, nil
+}
+
+func (s *service) Delete(id string) error {
+	if _, err := s.GetByID(id); err != nil {
+		return err
+	}
+
+	if err := s.repository.Delete(id); err != nil {
+		return errors.NewStatus("DELETE").SetPath("pricing/service.Delete").SetRef(err)
+	}
+
+	return nil
+}
+
+type EntryRequest struct {
+	Composition string            `json:"composition" binding:"required"`
+	Quantity    quantity.Quantity `json:"quantity" binding:"required"`
+	MinQuantity quantity.Quantity `json:"minQuantity"`
+	Price       float64           `json:"price"`
+}
+
+// SetEntry adds or replaces the price of a composition for a quantity break.
+// The current cost is stored as the entry last cost and the entry is
+// unflagged.
+func (s *service) SetEntry(id string, req *EntryRequest) (*PriceList, error) {
+	path := "pricing/service.SetEntry"
+
+	pl, err := s.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	comp, err := s.compositionService.GetByID(req.Composition)
+	if err != nil {
+		return nil, err
+	}
+
+	if !req.Quantity.Compatible(comp.Unit) {
+		return nil, errors.NewStatus("INCOMPATIBLE_QUANTITY").SetPath(path).SetMessage("%v != %v", req.Quantity, comp.Unit)
+	}
+
+	lastCost, err := costOf(comp, req.Quantity)
+	if err != nil {
+		return nil, err
+	}
+
+	pl.UpsertEntry(Entry{
+		Composition: comp.ID,
+		Quantity:    req.Quantity,
+		MinQuantity: req.MinQuantity,
+		Price:       req.Price,
+		LastCost:    lastCost,
+	})
+
+	if err := pl.ValidateSchema(); err != nil {
+		return nil, err
+	}
+
+	if err := s.repository.Update(pl); err != nil {
+		return nil, errors.NewStatus("UPDATE").SetPath(path).SetRef(err)
+	}
+
+	return pl, nil
+}
+
+func (s *service) RemoveEntry(id string, entryID string) (*PriceList, error) {
+	pl, err := s.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := pl.RemoveEntry(entryID); err != nil {
+		return nil, err
+	}
+
+	if err := s.repository.Update(pl); err != nil {
+		return nil, errors.NewStatus("UPDATE").SetPath("pricing/service.RemoveEntry").SetRef(err)
+	}
+
+	return pl, nil
+}
+
+// Analyze computes margin and markup of each entry against the current
+// composition cost.
+func (s *service) Analyze(id string) ([]*Analysis, error) {
+	pl, err := s.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	analysis := make([]*Analysis, 0, len(pl.Entries))
+	comps := make(map[primitive.ObjectID]*composition.Composition)
+	for i := range pl.Entries {
+		e := &pl.Entries[i]
+
+		comp, ok := comps[e.Composition]
+		if !ok {
+			comp, err = s.compositionService.GetByID(e.Composition.Hex())
+			if err != nil {
+				return nil, err
+			}
+			comps[e.Composition] = comp
+		}
+
+		a, err := analyze(pl, e, comp)
+		if err != nil {
+			return nil, err
+		}
+		analysis = append(analysis, a)
+	}
+
+	return analysis, nil
+}
+
+// FindFlagged returns the analysis of all flagged entries.
+func (s *service) FindFlagged() ([]*Analysis, error) {
+	priceLists, err := s.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	flagged := make([]*Analysis, 0)
+	for _, pl := range priceLists {
+		for i := range pl.Entries {
+			e := &pl.Entries[i]
+			if !e.Flagged {
+				continue
+			}
+
+			comp, err := s.compositionService.GetByID(e.Composition.Hex())
+			if err != nil {
+				return nil, err
+			}
+
+			a, err := analyze(pl, e, comp)
+			if err != nil {
+				return nil, err
+			}
+			flagged = append(flagged, a)
+		}
+	}
+
+	return flagged, nil
+}
+
+type QuoteRequest struct {
+	Composition string            `json:"composition" binding:"required"`
+	Quantity    quantity.Quantity `json:"quantity" binding:"required"`
+	Group       string            `json:"group"`
+	Date        *time.Time        `json:"date"`
+}
+
+// Quote prices a quantity of a composition for a customer group at a date.
+// Price lists of the group have precedence over price lists without group,
+// and within them the highest applicable quantity break is used.
+func (s *service) Quote(req *QuoteRequest) (*Quote, error) {
+	path := "pricing/service.Quote"
+
+	comp, err := s.compositionService.GetByID(req.Composition)
+	if err != nil {
+		return nil, err
+	}
+
+	if !req.Quantity.IsValid() || !req.Quantity.Compatible(comp.Unit) {
+		return nil, errors.NewStatus("INCOMPATIBLE_QUANTITY").SetPath(path).SetMessage("%v != %v", req.Quantity, comp.Unit)
+	}
+
+	date := time.Now()
+	if req.Date != nil {
+		date = *req.Date
+	}
+
+	priceLists, err := s.repository.FindByComposition(comp.ID.Hex())
+	if err != nil {
+		return nil, errors.NewStatus("FIND_BY_COMPOSITION").SetPath(path).SetRef(err)
+	}
+
+	var bestList *PriceList
+	var best *Entry
+	for _, pl := range priceLists {
+		if !pl.IsValidAt(date) || (pl.Group != "" && pl.Group != req.Group) {
+			continue
+		}
+
+		e := pl.BestEntry(comp.ID.Hex(), req.Quantity)
+		if e == nil {
+			continue
+		}
+
+		if best == nil || betterEntry(pl, e, bestList, best) {
+			bestList, best = pl, e
+		}
+	}
+
+	if best == nil {
+		return nil, errors.NewStatus("PRICE_NOT_FOUND").SetPath(path).SetStatus(404).SetMessage("%s", req.Composition)
+	}
+
+	price := round(best.PriceFor(req.Quantity))
+	cost, err := costOf(comp, req.Quantity)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Quote{
+		PriceList:   bestList.ID,
+		Entry:       best.ID,
+		Composition: comp.ID,
+		Quantity:    req.Quantity,
+		Price:       price,
+		Cost:        cost,
+		Margin:      Margin(price, cost),
+		Markup:      Markup(price, cost),
+	}, nil
+}
+
+// CheckMargins updates the last cost of the entries of the given
+// compositions. Entries whose cost increased and whose margin fell below the
+// margin floor are flagged. The event is published before saving the price
+// lists, so if saving fails the entries are flagged and published again on
+// the next check.
+/**
+* @api {topic} pricing.margin pricing.margin
+* @apiName PriceEntriesFlagged
+* @apiGroup RabbitMQ
+*
+* @apiDescription Emits a new event when cost increases push the margin of
+* price entries below the configured floor.
+*
+* @apiSuccessExample {json} Body
+* {
+* 	"type": "PriceEntriesFlagged",
+* 	"entries": list of entry analysis
+* }
+ */
+func (s *service) CheckMargins(comps []*composition.Composition) ([]*Analysis, error) {
+	path := "pricing/service.CheckMargins"
+
+	flagged := make([]*Analysis, 0)
+	changed := make([]*PriceList, 0)
+	for _, comp := range comps {
+		priceLists, err := s.repository.FindByComposition(comp.ID.Hex())
+		if err != nil {
+			return nil, errors.NewStatus("FIND_BY_COMPOSITION").SetPath(path).SetRef(err)
+		}
+
+		for _, pl := range priceLists {
+			for i := range pl.Entries {
+				e := &pl.Entries[i]
+				if e.Composition != comp.ID {
+					continue
+				}
+
+				cost, err := costOf(comp, e.Quantity)
+				if err != nil {
+					return nil, err
+				}
+
+				if cost > e.LastCost && !e.Flagged && Margin(e.Price, cost) < s.marginFloor {
+					now := time.Now()
+					e.Flagged = true
+					e.FlaggedAt = &now
+
+					a, err := analyze(pl, e, comp)
+					if err != nil {
+						return nil, err
+					}
+					flagged = append(flagged, a)
+				}
+				e.LastCost = cost
+			}
+
+			changed = append(changed, pl)
+		}
+	}
+
+	if len(flagged) > 0 {
+		event, opts := NewEntriesFlaggedEvent(flagged)
+		if err := s.eventMgr.Publish(event, opts); err != nil {
+			return nil, errors.NewStatus("FAILED_TO_PUBLISH").SetPath(path).SetRef(err)
+		}
+	}
+
+	for _, pl := range changed {
+		if err := s.repository.Update(pl); err != nil {
+			return nil, errors.NewStatus("UPDATE").SetPath(path).SetRef(err)
+		}
+	}
+
+	return flagged, nil
+}
+
+func analyze(pl *PriceList, e *Entry, comp *composition.Composition) (*Analysis, error) {
+	cost, err := costOf(comp, e.Quantity)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Analysis{
+		PriceList:   pl.ID,
+		Entry:       e.ID,
+		Composition: e.Composition,
+		Quantity:    e.Quantity,
+		MinQuantity: e.MinQuantity,
+		Price:       e.Price,
+		Cost:        cost,
+		Margin:      Margin(e.Price, cost),
+		Markup:      Markup(e.Price, cost),
+		Flagged:     e.Flagged,
+	}, nil
+}
+
+// costOf returns the rounded cost of a quantity of comp.
+func costOf(comp *composition.Composition, q quantity.Quantity) (float64, error) {
+	cost, err := comp.CostFromQuantity(q)
+	if err != nil {
+		return 0, err
+	}
+	return round(cost), nil
+}
+
+// betterEntry returns true if e1 of pl1 has precedence over e2 of pl2. Both
+// price lists are of the requested group or without group.
+func betterEntry(pl1 *PriceList, e1 *Entry, pl2 *PriceList, e2 *Entry) bool {
+	if (pl1.Group != "") != (pl2.Group != "") {
+		return pl1.Group != ""
+	}
+	return higherBreak(e1, e2)
+}
+
+func round(v float64) float64 {
+	return math.Round(v*1000) / 1000
+}
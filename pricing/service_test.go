@@ -0,0 +1,170 @@
+package pricing
+
+import (
+	"testing"
+
+	"github.com/aboglioli/big-brother/composition"
+	"github.com/aboglioli/big-brother/pkg/errors"
+	"github.com/aboglioli/big-brother/pkg/events"
+	"github.com/aboglioli/big-brother/pkg/quantity"
+	"github.com/aboglioli/big-brother/pkg/tests/assert"
+)
+
+func newComposition(unit quantity.Quantity, cost float64) *composition.Composition {
+	comp := composition.NewComposition()
+	comp.Cost = cost
+	comp.Unit = unit
+	comp.Stock = quantity.Quantity{0, unit.Unit}
+	comp.Validated = true
+	return comp
+}
+
+func TestQuote(t *testing.T) {
+	compRepo, eventMgr := composition.NewMockRepository(), events.GetMockManager()
+	serv := NewService(NewMockRepository(), composition.NewService(compRepo), eventMgr, 0.2)
+
+	cheese := newComposition(quantity.Quantity{1, "kg"}, 6)
+	compRepo.Insert(cheese)
+
+	general, err := serv.Create(&CreateRequest{Name: "General"})
+	assert.Ok(t, err)
+	wholesale, err := serv.Create(&CreateRequest{Name: "Wholesale", Group: "wholesale"})
+	assert.Ok(t, err)
+
+	_, err = serv.SetEntry(general.ID.Hex(), &EntryRequest{Composition: cheese.ID.Hex(), Quantity: quantity.Quantity{1, "l"}, Price: 10})
+	assert.ErrCode(t, err, "INCOMPATIBLE_QUANTITY")
+
+	serv.SetEntry(general.ID.Hex(), &EntryRequest{Composition: cheese.ID.Hex(), Quantity: quantity.Quantity{1, "kg"}, Price: 10})
+	serv.SetEntry(general.ID.Hex(), &EntryRequest{Composition: cheese.ID.Hex(), Quantity: quantity.Quantity{1, "kg"}, MinQuantity: quantity.Quantity{5, "kg"}, Price: 9})
+	serv.SetEntry(wholesale.ID.Hex(), &EntryRequest{Composition: cheese.ID.Hex(), Quantity: quantity.Quantity{1, "kg"}, Price: 8})
+
+	t.Run("Without group", func(t *testing.T) {
+		q, err := serv.Quote(&QuoteRequest{Composition: cheese.ID.Hex(), Quantity: quantity.Quantity{500, "g"}})
+		assert.Ok(t, err)
+		assert.Equal(t, q.PriceList, general.ID)
+		assert.Equal(t, q.Price, 5.0)
+		assert.Equal(t, q.Cost, 3.0)
+		assert.Equal(t, q.Margin, 0.4)
+		assert.Equal(t, q.Markup, 0.667)
+
+		q, err = serv.Quote(&QuoteRequest{Composition: cheese.ID.Hex(), Quantity: quantity.Quantity{10, "kg"}})
+		assert.Ok(t, err)
+		assert.Equal(t, q.Price, 90.0, "Quantity break")
+	})
+
+	t.Run("Group has precedence", func(t *testing.T) {
+		q, err := serv.Quote(&QuoteRequest{Composition: cheese.ID.Hex(), Quantity: quantity.Quantity{10, "kg"}, Group: "wholesale"})
+		assert.Ok(t, err)
+		assert.Equal(t, q.PriceList, wholesale.ID)
+		assert.Equal(t, q.Price, 80.0)
+	})
+
+	t.Run("Price not found", func(t *testing.T) {
+		milk := newComposition(quantity.Quantity{1, "l"}, 1)
+		compRepo.Insert(milk)
+
+		_, err := serv.Quote(&QuoteRequest{Composition: milk.ID.Hex(), Quantity: quantity.Quantity{1, "l"}})
+		assert.ErrCode(t, err, "PRICE_NOT_FOUND")
+	})
+}
+
+// downManager fails to publish every event.
+type downManager struct {
+	events.Manager
+}
+
+func (m *downManager) Publish(body interface{}, opts *events.Options) error {
+	return errors.NewInternal("UNAVAILABLE")
+}
+
+func TestCheckMargins(t *testing.T) {
+	compRepo, eventMgr := composition.NewMockRepository(), events.GetMockManager()
+	serv := NewService(NewMockRepository(), composition.NewService(compRepo), eventMgr, 0.2)
+
+	bread := newComposition(quantity.Quantity{1, "u"}, 5)
+	compRepo.Insert(bread)
+
+	pl, _ := serv.Create(&CreateRequest{Name: "General"})
+	pl, err := serv.SetEntry(pl.ID.Hex(), &EntryRequest{Composition: bread.ID.Hex(), Quantity: quantity.Quantity{1, "u"}, Price: 10})
+	assert.Ok(t, err)
+	assert.Equal(t, pl.Entries[0].LastCost, 5.0)
+
+	analysis, err := serv.Analyze(pl.ID.Hex())
+	assert.Ok(t, err)
+	assert.Equal(t, analysis[0].Margin, 0.5)
+	assert.Equal(t, analysis[0].Markup, 1.0)
+
+	t.Run("Margin above floor", func(t *testing.T) {
+		eventMgr.Clean()
+		bread.Cost = 7
+		flagged, err := serv.CheckMargins([]*composition.Composition{bread})
+		assert.Ok(t, err)
+		assert.Equal(t, len(flagged), 0)
+		assert.Equal(t, eventMgr.Count(), 0)
+	})
+
+	t.Run("Margin below floor", func(t *testing.T) {
+		eventMgr.Clean()
+		bread.Cost = 8.5
+		flagged, err := serv.CheckMargins([]*composition.Composition{bread})
+		assert.Ok(t, err)
+		assert.Equal(t, len(flagged), 1)
+		assert.Equal(t, flagged[0].Margin, 0.15)
+
+		msgs := eventMgr.Messages()
+		assert.Equal(t, len(msgs), 1)
+		assert.Equal(t, msgs[0].Type(), "PriceEntriesFlagged")
+		assert.Equal(t, msgs[0].Key, "pricing.margin")
+
+		saved, _ := serv.GetByID(pl.ID.Hex())
+		assert.Assert(t, saved.Entries[0].Flagged)
+		assert.Equal(t, saved.Entries[0].LastCost, 8.5)
+	})
+
+	t.Run("Cost decrease does not flag", func(t *testing.T) {
+		pl, _ := serv.SetEntry(pl.ID.Hex(), &EntryRequest{Composition: bread.ID.Hex(), Quantity: quantity.Quantity{1, "u"}, Price: 10})
+		assert.Assert(t, !pl.Entries[0].Flagged, "Setting price should unflag")
+
+		bread.Cost = 9
+		compRepo.Update(bread)
+		pl, _ = serv.SetEntry(pl.ID.Hex(), &EntryRequest{Composition: bread.ID.Hex(), Quantity: quantity.Quantity{1, "u"}, Price: 10})
+
+		bread.Cost = 8.6
+		flagged, err := serv.CheckMargins([]*composition.Composition{bread})
+		assert.Ok(t, err)
+		assert.Equal(t, len(flagged), 0)
+
+		flagged, err = serv.FindFlagged()
+		assert.Ok(t, err)
+		assert.Equal(t, len(flagged), 0)
+	})
+}
+
+func TestCheckMarginsFailedPublication(t *testing.T) {
+	repo, compRepo := NewMockRepository(), composition.NewMockRepository()
+	compServ := composition.NewService(compRepo)
+	serv := NewService(repo, compServ, &downManager{}, 0.2)
+
+	bread := newComposition(quantity.Quantity{1, "u"}, 5)
+	compRepo.Insert(bread)
+
+	pl, _ := serv.Create(&CreateRequest{Name: "General"})
+	_, err := serv.SetEntry(pl.ID.Hex(), &EntryRequest{Composition: bread.ID.Hex(), Quantity: quantity.Quantity{1, "u"}, Price: 10})
+	assert.Ok(t, err)
+
+	bread.Cost = 8.5
+	_, err = serv.CheckMargins([]*composition.Composition{bread})
+	assert.ErrCode(t, err, "FAILED_TO_PUBLISH")
+
+	saved, _ := serv.GetByID(pl.ID.Hex())
+	assert.Assert(t, !saved.Entries[0].Flagged, "Entries should not be saved before publishing")
+	assert.Equal(t, saved.Entries[0].LastCost, 5.0)
+
+	eventMgr := events.GetMockManager()
+	eventMgr.Clean()
+	serv = NewService(repo, compServ, eventMgr, 0.2)
+	flagged, err := serv.CheckMargins([]*composition.Composition{bread})
+	assert.Ok(t, err)
+	assert.Equal(t, len(flagged), 1, "Entries should be flagged again")
+	assert.Equal(t, eventMgr.Count(), 1)
+}
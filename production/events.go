@@ -0,0 +1,29 @@
+package production
+
+import (
+	"github.com/aboglioli/big-brother/pkg/events"
+)
+
+// OrderChangedEvent is published on each production order status change
+type OrderChangedEvent struct {
+	events.Event
+	Order *Order `json:"order"`
+}
+
+func NewOrderReleasedEvent(o *Order) (*OrderChangedEvent, *events.Options) {
+	event := &OrderChangedEvent{events.NewEvent("ProductionOrderReleased", o.ID.Hex()), o}
+	opts := &events.Options{"production", "topic", "production.order.released", ""}
+	return event, opts
+}
+
+func NewOrderCompletedEvent(o *Order) (*OrderChangedEvent, *events.Options) {
+	event := &OrderChangedEvent{events.NewEvent("ProductionOrderCompleted", o.ID.Hex()), o}
+	opts := &events.Options{"production", "topic", "production.order.completed", ""}
+	return event, opts
+}
+
+func NewOrderCancelledEvent(o *Order) (*OrderChangedEvent, *events.Options) {
+	event := &OrderChangedEvent{events.NewEvent("ProductionOrderCancelled", o.ID.Hex()), o}
+	opts := &events.Options{"production", "topic", "production.order.cancelled", ""}
+	return event, opts
+}
@@ -0,0 +1,103 @@
+package production
+
+import (
+	"time"
+
+	"github.com/aboglioli/big-brother/pkg/errors"
+	"github.com/aboglioli/big-brother/pkg/quantity"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	OrderPlanned   = "PLANNED"
+	OrderReleased  = "RELEASED"
+	OrderCompleted = "COMPLETED"
+	OrderCancelled = "CANCELLED"
+)
+
+// Order is a production order of a composition. Released orders are
+// completed producing a stock lot, which consumes the lots of the
+// composition dependencies. Operations are the routing operations scheduled
+// in work centers when the order is created.
+type Order struct {
+	ID          primitive.ObjectID  `json:"id" bson:"_id"`
+	Composition primitive.ObjectID  `json:"composition" bson:"composition"`
+	Quantity    quantity.Quantity   `json:"quantity" bson:"quantity"`
+	Status      string              `json:"status" bson:"status"`
+	DueDate     *time.Time          `json:"dueDate" bson:"dueDate"`
+	Lot         *primitive.ObjectID `json:"lot" bson:"lot"`
+	Reference   string              `json:"reference" bson:"reference"`
+	Operations  []Operation         `json:"operations" bson:"operations"`
+
+	ReleasedAt  *time.Time `json:"releasedAt" bson:"releasedAt"`
+	CompletedAt *time.Time `json:"completedAt" bson:"completedAt"`
+	CreatedAt   time.Time  `json:"createdAt" bson:"createdAt"`
+	UpdatedAt   time.Time  `json:"updatedAt" bson:"updatedAt"`
+}
+
+func NewOrder(compID primitive.ObjectID, q quantity.Quantity) *Order {
+	return &Order{
+		ID:          primitive.NewObjectID(),
+		Composition: compID,
+		Quantity:    q,
+		Status:      OrderPlanned,
+		Operations:  make([]Operation, 0),
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+}
+
+// IsOpen returns true if the order is planned or released.
+func (o *Order) IsOpen() bool {
+	return o.Status == OrderPlanned || o.Status == OrderReleased
+}
+
+func (o *Order) Release() error {
+	if o.Status != OrderPlanned {
+		return errors.NewStatus("ORDER_NOT_PLANNED").SetPath("production/order.Release")
+	}
+
+	now := time.Now()
+	o.Status = OrderReleased
+	o.ReleasedAt = &now
+
+	return nil
+}
+
+// Complete sets the lot produced by a released order.
+func (o *Order) Complete(lotID primitive.ObjectID) error {
+	if o.Status != OrderReleased {
+		return errors.NewStatus("ORDER_NOT_RELEASED").SetPath("production/order.Complete")
+	}
+
+	now := time.Now()
+	o.Status = OrderCompleted
+	o.Lot = &lotID
+	o.CompletedAt = &now
+
+	return nil
+}
+
+func (o *Order) Cancel() error {
+	if !o.IsOpen() {
+		return errors.NewStatus("ORDER_CANNOT_BE_CANCELLED").SetPath("production/order.Cancel")
+	}
+
+	o.Status = OrderCancelled
+
+	return nil
+}
+
+func (o *Order) ValidateSchema() error {
+	err := errors.NewValidation("VALIDATE_SCHEMA").SetPath("production/order.ValidateSchema")
+
+	if !o.Quantity.IsValid() || o.Quantity.Quantity == 0 {
+		err.Add("quantity", "INVALID")
+	}
+
+	if err.Size() > 0 {
+		return err
+	}
+
+	return nil
+}
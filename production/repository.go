@@ -0,0 +1,148 @@
+package production
+
+import (
+	"context"
+	"time"
+
+	"github.com/aboglioli/big-brother/infrastructure/db"
+	"github.com/aboglioli/big-brother/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type Repository interface {
+	FindByID(id string) (*Order, error)
+	FindByStatus(status ...string) ([]*Order, error)
+	FindByComposition(compID string) ([]*Order, error)
+
+	Insert(*Order) error
+	Update(*Order) error
+}
+
+type repository struct {
+	collection *mongo.Collection
+}
+
+func NewRepository() (Repository, error) {
+	db, err := db.Get("Production")
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &repository{
+		collection: db.Collection("order"),
+	}, nil
+}
+
+func (r *repository) FindByID(id string) (*Order, error) {
+	path := "production/repository.FindByID"
+	ctx := context.Background()
+
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errors.NewInternal("OBJECTID_FROM_HEX").SetPath(path).SetRef(err)
+	}
+
+	filter := bson.M{
+		"_id": objID,
+	}
+
+	res := r.collection.FindOne(ctx, filter)
+	if res.Err() != nil {
+		return nil, errors.NewInternal("FIND_ONE").SetPath(path).SetRef(res.Err())
+	}
+
+	var o Order
+	if err := res.Decode(&o); err != nil {
+		return nil, errors.NewInternal("DECODE").SetPath(path).SetRef(err)
+	}
+
+	return &o, nil
+}
+
+func (r *repository) FindByStatus(status ...string) ([]*Order, error) {
+	return r.find("production/repository.FindByStatus", bson.M{
+		"status": bson.M{
+			"$in": status,
+		},
+	})
+}
+
+func (r *repository) FindByComposition(compID string) ([]*Order, error) {
+	path := "production/repository.FindByComposition"
+
+	objID, err := primitive.ObjectIDFromHex(compID)
+	if err != nil {
+		return nil, errors.NewInternal("OBJECTID_FROM_HEX").SetPath(path).SetRef(err)
+	}
+
+	return r.find(path, bson.M{
+		"composition": objID,
+	})
+}
+
+func (r *repository) Insert(o *Order) error {
+	ctx := context.Background()
+
+	_, err := r.collection.InsertOne(ctx, o)
+	if err != nil {
+		return errors.NewInternal("INSERT_ONE").SetPath("production/repository.Insert").SetRef(err)
+	}
+
+	return nil
+}
+
+func (r *repository) Update(o *Order) error {
+	path := "production/repository.Update"
+	ctx := context.Background()
+
+	if o.ID.IsZero() {
+		return errors.NewInternal("INVALID_OBJECTID").SetPath(path)
+	}
+
+	o.UpdatedAt = time.Now()
+
+	filter := bson.M{
+		"_id": o.ID,
+	}
+
+	update := bson.M{
+		"$set": o,
+	}
+
+	_, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return errors.NewInternal("UPDATE_ONE").SetPath(path).SetRef(err)
+	}
+
+	return nil
+}
+
+func (r *repository) find(path string, filter bson.M) ([]*Order, error) {
+	ctx := context.Background()
+
+	cur, err := r.collection.Find(ctx, filter)
+	if err != nil {
+		return nil, errors.NewInternal("FIND").SetPath(path).SetRef(err)
+	}
+	defer cur.Close(ctx)
+
+	orders := make([]*Order, 0)
+	for cur.Next(ctx) {
+		var o Order
+
+		if err := cur.Decode(&o); err != nil {
+			return nil, errors.NewInternal("CUR_DECODE").SetPath(path).SetRef(err)
+		}
+
+		orders = append(orders, &o)
+	}
+
+	if err := cur.Err(); err != nil {
+		return nil, errors.NewInternal("CUR").SetPath(path).SetRef(err)
+	}
+
+	return orders, nil
+}
@@ -0,0 +1,98 @@
+package production
+
+import (
+	"time"
+
+	"github.com/aboglioli/big-brother/pkg/errors"
+	"github.com/aboglioli/big-brother/pkg/tests/mock"
+)
+
+type mockRepository struct {
+	mock.Mock
+	orders []*Order
+}
+
+// NewMockRepository returns an in-memory Repository. It is exported to be
+// used by other packages' tests.
+func NewMockRepository() *mockRepository {
+	return &mockRepository{}
+}
+
+// Helpers
+func (r *mockRepository) Clean() {
+	r.orders = make([]*Order, 0)
+}
+
+// Implementation
+func (r *mockRepository) FindByID(id string) (*Order, error) {
+	r.Called("FindByID", id)
+
+	for _, o := range r.orders {
+		if o.ID.Hex() == id {
+			return copyOrder(o), nil
+		}
+	}
+
+	return nil, errors.NewInternal("NOT_FOUND").SetPath("production/repository_mock.FindByID")
+}
+
+func (r *mockRepository) FindByStatus(status ...string) ([]*Order, error) {
+	r.Called("FindByStatus", status)
+
+	orders := make([]*Order, 0)
+	for _, o := range r.orders {
+		for _, s := range status {
+			if o.Status == s {
+				orders = append(orders, copyOrder(o))
+				break
+			}
+		}
+	}
+
+	return orders, nil
+}
+
+func (r *mockRepository) FindByComposition(compID string) ([]*Order, error) {
+	r.Called("FindByComposition", compID)
+
+	orders := make([]*Order, 0)
+	for _, o := range r.orders {
+		if o.Composition.Hex() == compID {
+			orders = append(orders, copyOrder(o))
+		}
+	}
+
+	return orders, nil
+}
+
+func (r *mockRepository) Insert(o *Order) error {
+	r.Called("Insert", o)
+
+	r.orders = append(r.orders, copyOrder(o))
+
+	return nil
+}
+
+func (r *mockRepository) Update(o *Order) error {
+	r.Called("Update", o)
+
+	for _, order := range r.orders {
+		if order.ID.Hex() == o.ID.Hex() {
+			*order = *copyOrder(o)
+			order.UpdatedAt = time.Now()
+			break
+		}
+	}
+
+	return nil
+}
+
+func copyOrder(o *Order) *Order {
+	copy := *o
+	copy.Operations = make([]Operation, len(o.Operations))
+	for i, op := range o.Operations {
+		op.Loads = append([]Load(nil), op.Loads...)
+		copy.Operations[i] = op
+	}
+	return &copy
+}
@@ -0,0 +1,121 @@
+package production
+
+import (
+	"math"
+	"time"
+
+	"github.com/aboglioli/big-brother/pkg/errors"
+	"github.com/aboglioli/big-brother/routing"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// horizon is the maximum number of days an operation can be scheduled ahead.
+const horizon = 365
+
+// Load is the time, in hours, scheduled in a work center on a day.
+type Load struct {
+	Date  time.Time `json:"date" bson:"date"`
+	Hours float64   `json:"hours" bson:"hours"`
+}
+
+// Operation is a routing operation scheduled for an order. Start and End are
+// the first and last days with load.
+type Operation struct {
+	Name       string             `json:"name" bson:"name"`
+	WorkCenter primitive.ObjectID `json:"workCenter" bson:"workCenter"`
+	Hours      float64            `json:"hours" bson:"hours"`
+	Start      time.Time          `json:"start" bson:"start"`
+	End        time.Time          `json:"end" bson:"end"`
+	Loads      []Load             `json:"loads" bson:"loads"`
+}
+
+// schedule is the load of each work center by day.
+type schedule map[string]map[string]float64
+
+func day(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func dayKey(t time.Time) string {
+	return day(t).Format("2006-01-02")
+}
+
+// newSchedule returns the load of the operations of open orders.
+func newSchedule(orders []*Order) schedule {
+	s := make(schedule)
+	for _, o := range orders {
+		if !o.IsOpen() {
+			continue
+		}
+		for _, op := range o.Operations {
+			for _, l := range op.Loads {
+				s.add(op.WorkCenter.Hex(), l.Date, l.Hours)
+			}
+		}
+	}
+	return s
+}
+
+func (s schedule) load(workCenterID string, date time.Time) float64 {
+	return s[workCenterID][dayKey(date)]
+}
+
+func (s schedule) add(workCenterID string, date time.Time, hours float64) {
+	if _, ok := s[workCenterID]; !ok {
+		s[workCenterID] = make(map[string]float64)
+	}
+	s[workCenterID][dayKey(date)] += hours
+}
+
+// plan schedules the operations of a routing in sequence, from the start day
+// on, using the capacity left in each work center. An operation starts the
+// day the previous one ends.
+func (s schedule) plan(start time.Time, units float64, r *routing.Routing, workCenters map[string]*routing.WorkCenter) ([]Operation, error) {
+	path := "production/schedule.plan"
+
+	operations := make([]Operation, 0, len(r.Operations))
+	date := day(start)
+	for _, ro := range r.Operations {
+		wc, ok := workCenters[ro.WorkCenter.Hex()]
+		if !ok {
+			return nil, errors.NewStatus("WORK_CENTER_NOT_FOUND").SetPath(path).SetMessage("%s", ro.WorkCenter.Hex())
+		}
+
+		op := Operation{
+			Name:       ro.Name,
+			WorkCenter: ro.WorkCenter,
+			Hours:      math.Round(ro.Hours(units)*1000) / 1000,
+			Start:      date,
+			End:        date,
+			Loads:      make([]Load, 0),
+		}
+
+		pending := op.Hours
+		for i := 0; pending > 0; i++ {
+			if i >= horizon {
+				return nil, errors.NewStatus("INSUFFICIENT_CAPACITY").SetPath(path).SetMessage("%s has no capacity in %d days", wc.Name, horizon)
+			}
+
+			available := wc.Capacity - s.load(wc.ID.Hex(), date)
+			if available > 0 {
+				hours := math.Min(available, pending)
+				if len(op.Loads) == 0 {
+					op.Start = date
+				}
+				op.End = date
+				op.Loads = append(op.Loads, Load{date, hours})
+				s.add(wc.ID.Hex(), date, hours)
+				pending = math.Round((pending-hours)*1000) / 1000
+			}
+
+			if pending > 0 {
+				date = date.AddDate(0, 0, 1)
+			}
+		}
+
+		operations = append(operations, op)
+	}
+
+	return operations, nil
+}
@@ -0,0 +1,304 @@
+package production
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/aboglioli/big-brother/composition"
+	"github.com/aboglioli/big-brother/pkg/errors"
+	"github.com/aboglioli/big-brother/pkg/events"
+	"github.com/aboglioli/big-brother/pkg/quantity"
+	"github.com/aboglioli/big-brother/routing"
+	"github.com/aboglioli/big-brother/stock"
+)
+
+type Service interface {
+	GetByID(id string) (*Order, error)
+	FindOpen() ([]*Order, error)
+	FindByComposition(compID string) ([]*Order, error)
+	FindLoad(workCenterID string) ([]Load, error)
+
+	Create(req *CreateRequest) (*Order, error)
+	Release(id string) (*Order, error)
+	Complete(id string, req *CompleteRequest) (*Order, error)
+	Cancel(id string) (*Order, error)
+}
+
+type service struct {
+	repository         Repository
+	compositionService composition.Service
+	stockService       stock.Service
+	routingService     routing.Service
+	eventMgr           events.Manager
+}
+
+func NewService(repo Repository, compServ composition.Service, stockServ stock.Service, routingServ routing.Service, e events.Manager) Service {
+	return &service{
+		repository:         repo,
+		compositionService: compServ,
+		stockService:       stockServ,
+		routingService:     routingServ,
+		eventMgr:           e,
+	}
+}
+
+func (s *service) GetByID(id string) (*Order, error) {
+	o, err := s.repository.FindByID(id)
+	if err != nil {
+		return nil, errors.NewStatus("ORDER_NOT_FOUND").SetPath("production/service.GetByID").SetStatus(404).SetRef(err)
+	}
+	return o, nil
+}
+
+func (s *service) FindOpen() ([]*Order, error) {
+	orders, err := s.repository.FindByStatus(OrderPlanned, OrderReleased)
+	if err != nil {
+		return nil, errors.NewStatus("FIND_ORDERS").SetPath("production/service.FindOpen").SetRef(err)
+	}
+	return orders, nil
+}
+
+func (s *service) FindByComposition(compID string) ([]*Order, error) {
+	orders, err := s.repository.FindByComposition(compID)
+	if err != nil {
+		return nil, errors.NewStatus("FIND_ORDERS").SetPath("production/service.FindByComposition").SetRef(err)
+	}
+	return orders, nil
+}
+
+// FindLoad returns the hours scheduled by day in a work center by open
+// orders.
+func (s *service) FindLoad(workCenterID string) ([]Load, error) {
+	orders, err := s.FindOpen()
+	if err != nil {
+		return nil, err
+	}
+
+	load := newSchedule(orders)[workCenterID]
+
+	loads := make([]Load, 0, len(load))
+	for key, hours := range load {
+		date, _ := time.Parse("2006-01-02", key)
+		loads = append(loads, Load{date, math.Round(hours*1000) / 1000})
+	}
+
+	sort.Slice(loads, func(i, j int) bool {
+		return loads[i].Date.Before(loads[j].Date)
+	})
+
+	return loads, nil
+}
+
+type CreateRequest struct {
+	Composition string            `json:"composition" binding:"required"`
+	Quantity    quantity.Quantity `json:"quantity" binding:"required"`
+	StartDate   *time.Time        `json:"startDate"`
+	DueDate     *time.Time        `json:"dueDate"`
+	Reference   string            `json:"reference"`
+}
+
+// Create creates a planned production order. Only compositions with
+// dependencies can be produced. If the composition has a routing, its
+// operations are scheduled from the start date (default: now) against the
+// capacity left by the other open orders.
+func (s *service) Create(req *CreateRequest) (*Order, error) {
+	path := "production/service.Create"
+
+	comp, err := s.compositionService.GetByID(req.Composition)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(comp.Dependencies) == 0 {
+		return nil, errors.NewStatus("COMPOSITION_CANNOT_BE_PRODUCED").SetPath(path).SetMessage("%s has no dependencies", comp.ID.Hex())
+	}
+
+	if !req.Quantity.IsValid() || !req.Quantity.Compatible(comp.Unit) {
+		return nil, errors.NewStatus("INCOMPATIBLE_QUANTITY").SetPath(path).SetMessage("%v != %v", req.Quantity, comp.Unit)
+	}
+
+	o := NewOrder(comp.ID, req.Quantity)
+	o.DueDate = req.DueDate
+	o.Reference = req.Reference
+
+	if err := o.ValidateSchema(); err != nil {
+		return nil, err
+	}
+
+	if r, err := s.routingService.GetRouting(comp.ID.Hex()); err == nil {
+		start := time.Now()
+		if req.StartDate != nil {
+			start = *req.StartDate
+		}
+
+		units, err := req.Quantity.Ratio(comp.Unit)
+		if err != nil {
+			return nil, errors.NewStatus("INVALID_QUANTITY").SetPath(path).SetRef(err)
+		}
+
+		if o.Operations, err = s.schedule(start, units, r); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.repository.Insert(o); err != nil {
+		return nil, errors.NewStatus("INSERT").SetPath(path).SetRef(err)
+	}
+
+	return o, nil
+}
+
+// Release releases a planned order to the shop floor.
+/**
+* @api {topic} production.order.released production.order.released
+* @apiName ProductionOrderReleased
+* @apiGroup RabbitMQ
+*
+* @apiDescription Emits a new event when a production order is released.
+*
+* @apiSuccessExample {json} Body
+* {
+* 	"type": "ProductionOrderReleased",
+* 	"order": order data
+* }
+ */
+func (s *service) Release(id string) (*Order, error) {
+	path := "production/service.Release"
+
+	o, err := s.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := o.Release(); err != nil {
+		return nil, err
+	}
+
+	if err := s.repository.Update(o); err != nil {
+		return nil, errors.NewStatus("UPDATE").SetPath(path).SetRef(err)
+	}
+
+	event, opts := NewOrderReleasedEvent(o)
+	if err := s.eventMgr.Publish(event, opts); err != nil {
+		return nil, errors.NewStatus("FAILED_TO_PUBLISH").SetPath(path).SetRef(err)
+	}
+
+	return o, nil
+}
+
+type CompleteRequest struct {
+	Code      string     `json:"code"`
+	ExpiresAt *time.Time `json:"expiresAt"`
+}
+
+// Complete produces the lot of a released order, consuming the lots of the
+// composition dependencies.
+/**
+* @api {topic} production.order.completed production.order.completed
+* @apiName ProductionOrderCompleted
+* @apiGroup RabbitMQ
+*
+* @apiDescription Emits a new event when a production order is completed.
+* The order includes the produced lot.
+*
+* @apiSuccessExample {json} Body
+* {
+* 	"type": "ProductionOrderCompleted",
+* 	"order": order data
+* }
+ */
+func (s *service) Complete(id string, req *CompleteRequest) (*Order, error) {
+	path := "production/service.Complete"
+
+	o, err := s.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if o.Status != OrderReleased {
+		return nil, errors.NewStatus("ORDER_NOT_RELEASED").SetPath(path)
+	}
+
+	lot, err := s.stockService.Produce(&stock.ProduceRequest{
+		Composition: o.Composition.Hex(),
+		Code:        req.Code,
+		Quantity:    o.Quantity,
+		ExpiresAt:   req.ExpiresAt,
+		Reference:   o.ID.Hex(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := o.Complete(lot.ID); err != nil {
+		return nil, err
+	}
+
+	if err := s.repository.Update(o); err != nil {
+		return nil, errors.NewStatus("UPDATE").SetPath(path).SetRef(err)
+	}
+
+	event, opts := NewOrderCompletedEvent(o)
+	if err := s.eventMgr.Publish(event, opts); err != nil {
+		return nil, errors.NewStatus("FAILED_TO_PUBLISH").SetPath(path).SetRef(err)
+	}
+
+	return o, nil
+}
+
+// Cancel cancels a planned or released order.
+/**
+* @api {topic} production.order.cancelled production.order.cancelled
+* @apiName ProductionOrderCancelled
+* @apiGroup RabbitMQ
+*
+* @apiDescription Emits a new event when a production order is cancelled.
+*
+* @apiSuccessExample {json} Body
+* {
+* 	"type": "ProductionOrderCancelled",
+* 	"order": order data
+* }
+ */
+func (s *service) Cancel(id string) (*Order, error) {
+	path := "production/service.Cancel"
+
+	o, err := s.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := o.Cancel(); err != nil {
+		return nil, err
+	}
+
+	if err := s.repository.Update(o); err != nil {
+		return nil, errors.NewStatus("UPDATE").SetPath(path).SetRef(err)
+	}
+
+	event, opts := NewOrderCancelledEvent(o)
+	if err := s.eventMgr.Publish(event, opts); err != nil {
+		return nil, errors.NewStatus("FAILED_TO_PUBLISH").SetPath(path).SetRef(err)
+	}
+
+	return o, nil
+}
+
+func (s *service) schedule(start time.Time, units float64, r *routing.Routing) ([]Operation, error) {
+	workCenters := make(map[string]*routing.WorkCenter)
+	for _, o := range r.Operations {
+		w, err := s.routingService.GetWorkCenter(o.WorkCenter.Hex())
+		if err != nil {
+			return nil, err
+		}
+		workCenters[w.ID.Hex()] = w
+	}
+
+	orders, err := s.FindOpen()
+	if err != nil {
+		return nil, err
+	}
+
+	return newSchedule(orders).plan(start, units, r, workCenters)
+}
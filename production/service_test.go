@@ -0,0 +1,148 @@
+package production
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aboglioli/big-brother/composition"
+	"github.com/aboglioli/big-brother/pkg/events"
+	"github.com/aboglioli/big-brother/pkg/quantity"
+	"github.com/aboglioli/big-brother/pkg/tests/assert"
+	"github.com/aboglioli/big-brother/routing"
+	"github.com/aboglioli/big-brother/stock"
+)
+
+func newComposition(unit quantity.Quantity, cost float64, deps ...composition.Dependency) *composition.Composition {
+	comp := composition.NewComposition()
+	comp.Cost = cost
+	comp.Unit = unit
+	comp.Stock = quantity.Quantity{0, unit.Unit}
+	comp.Dependencies = deps
+	comp.Validated = true
+	return comp
+}
+
+func TestProductionOrder(t *testing.T) {
+	compRepo, eventMgr := composition.NewMockRepository(), events.GetMockManager()
+	compServ := composition.NewService(compRepo)
+	stockServ := stock.NewService(stock.NewMockLotRepository(), stock.NewMockMovementRepository(), stock.NewMockCountRepository(), compServ)
+	routingServ := routing.NewService(routing.NewMockWorkCenterRepository(), routing.NewMockRoutingRepository(), compServ)
+	serv := NewService(NewMockRepository(), compServ, stockServ, routingServ, eventMgr)
+
+	flour := newComposition(quantity.Quantity{1, "kg"}, 10)
+	bread := newComposition(quantity.Quantity{1, "u"}, 5, composition.Dependency{On: flour.ID, Quantity: quantity.Quantity{500, "g"}})
+	compRepo.Insert(flour)
+	compRepo.Insert(bread)
+	_, err := stockServ.Receive(&stock.ReceiveRequest{Composition: flour.ID.Hex(), Quantity: quantity.Quantity{3, "kg"}})
+	assert.Ok(t, err)
+
+	t.Run("Invalid orders", func(t *testing.T) {
+		_, err := serv.Create(&CreateRequest{Composition: flour.ID.Hex(), Quantity: quantity.Quantity{1, "kg"}})
+		assert.ErrCode(t, err, "COMPOSITION_CANNOT_BE_PRODUCED")
+
+		_, err = serv.Create(&CreateRequest{Composition: bread.ID.Hex(), Quantity: quantity.Quantity{1, "kg"}})
+		assert.ErrCode(t, err, "INCOMPATIBLE_QUANTITY")
+	})
+
+	t.Run("Complete released order", func(t *testing.T) {
+		o, err := serv.Create(&CreateRequest{Composition: bread.ID.Hex(), Quantity: quantity.Quantity{4, "u"}})
+		assert.Ok(t, err)
+		assert.Equal(t, o.Status, OrderPlanned)
+
+		_, err = serv.Complete(o.ID.Hex(), &CompleteRequest{})
+		assert.ErrCode(t, err, "ORDER_NOT_RELEASED")
+
+		_, err = serv.Release(o.ID.Hex())
+		assert.Ok(t, err)
+
+		eventMgr.Clean()
+
+		o, err = serv.Complete(o.ID.Hex(), &CompleteRequest{Code: "B-1"})
+		assert.Ok(t, err)
+		assert.Equal(t, o.Status, OrderCompleted)
+		assert.NotNil(t, o.Lot)
+
+		comp, _ := compRepo.FindByID(flour.ID.Hex())
+		assert.Assert(t, comp.Stock.Equals(quantity.Quantity{1, "kg"}))
+		comp, _ = compRepo.FindByID(bread.ID.Hex())
+		assert.Assert(t, comp.Stock.Equals(quantity.Quantity{4, "u"}))
+
+		msgs := eventMgr.Messages()
+		assert.Equal(t, msgs[len(msgs)-1].Type(), "ProductionOrderCompleted")
+
+		_, err = serv.Cancel(o.ID.Hex())
+		assert.ErrCode(t, err, "ORDER_CANNOT_BE_CANCELLED")
+	})
+
+	t.Run("Insufficient lots", func(t *testing.T) {
+		o, _ := serv.Create(&CreateRequest{Composition: bread.ID.Hex(), Quantity: quantity.Quantity{4, "u"}})
+		serv.Release(o.ID.Hex())
+
+		_, err := serv.Complete(o.ID.Hex(), &CompleteRequest{})
+		assert.ErrCode(t, err, "INSUFFICIENT_LOTS")
+
+		o, err = serv.Cancel(o.ID.Hex())
+		assert.Ok(t, err)
+		assert.Equal(t, o.Status, OrderCancelled)
+
+		orders, _ := serv.FindOpen()
+		assert.Equal(t, len(orders), 0)
+	})
+}
+
+func TestScheduleOperations(t *testing.T) {
+	compRepo, eventMgr := composition.NewMockRepository(), events.GetMockManager()
+	compServ := composition.NewService(compRepo)
+	stockServ := stock.NewService(stock.NewMockLotRepository(), stock.NewMockMovementRepository(), stock.NewMockCountRepository(), compServ)
+	routingServ := routing.NewService(routing.NewMockWorkCenterRepository(), routing.NewMockRoutingRepository(), compServ)
+	serv := NewService(NewMockRepository(), compServ, stockServ, routingServ, eventMgr)
+
+	flour := newComposition(quantity.Quantity{1, "kg"}, 10)
+	bread := newComposition(quantity.Quantity{1, "u"}, 5, composition.Dependency{On: flour.ID, Quantity: quantity.Quantity{500, "g"}})
+	compRepo.Insert(flour)
+	compRepo.Insert(bread)
+
+	mixer, _ := routingServ.CreateWorkCenter(&routing.CreateWorkCenterRequest{Name: "Mixer", HourlyRate: 20, Capacity: 4})
+	oven, _ := routingServ.CreateWorkCenter(&routing.CreateWorkCenterRequest{Name: "Oven", HourlyRate: 30, Capacity: 8})
+	_, err := routingServ.SetRouting(bread.ID.Hex(), &routing.RoutingRequest{Operations: []routing.Operation{
+		routing.Operation{Name: "Mix", WorkCenter: mixer.ID, Setup: 30, Run: 6},
+		routing.Operation{Name: "Bake", WorkCenter: oven.ID, Run: 12},
+	}})
+	assert.Ok(t, err)
+
+	day1 := time.Date(2019, 12, 2, 0, 0, 0, 0, time.UTC)
+	day2, day3 := day1.AddDate(0, 0, 1), day1.AddDate(0, 0, 2)
+
+	// Mix: (30 + 6 * 40) / 60 = 4.5h, Bake: 12 * 40 / 60 = 8h
+	o1, err := serv.Create(&CreateRequest{Composition: bread.ID.Hex(), Quantity: quantity.Quantity{40, "u"}, StartDate: &day1})
+	assert.Ok(t, err)
+	assert.Equal(t, len(o1.Operations), 2)
+	assert.Equal(t, o1.Operations[0].Hours, 4.5)
+	assert.Equal(t, o1.Operations[0].Start, day1)
+	assert.Equal(t, o1.Operations[0].End, day2)
+	assert.Equal(t, len(o1.Operations[0].Loads), 2)
+	assert.Equal(t, o1.Operations[0].Loads[0], Load{day1, 4})
+	assert.Equal(t, o1.Operations[0].Loads[1], Load{day2, 0.5})
+	assert.Equal(t, o1.Operations[1].Start, day2)
+	assert.Equal(t, o1.Operations[1].End, day2)
+
+	t.Run("Capacity used by open orders", func(t *testing.T) {
+		// Mix: 1.5h, Bake: 2h
+		o2, err := serv.Create(&CreateRequest{Composition: bread.ID.Hex(), Quantity: quantity.Quantity{10, "u"}, StartDate: &day1})
+		assert.Ok(t, err)
+		assert.Equal(t, o2.Operations[0].Loads[0], Load{day2, 1.5})
+		assert.Equal(t, o2.Operations[1].Loads[0], Load{day3, 2})
+
+		loads, err := serv.FindLoad(oven.ID.Hex())
+		assert.Ok(t, err)
+		assert.Equal(t, len(loads), 2)
+		assert.Equal(t, loads[0], Load{day2, 8})
+		assert.Equal(t, loads[1], Load{day3, 2})
+
+		serv.Cancel(o1.ID.Hex())
+
+		loads, _ = serv.FindLoad(oven.ID.Hex())
+		assert.Equal(t, len(loads), 1)
+		assert.Equal(t, loads[0], Load{day3, 2})
+	})
+}
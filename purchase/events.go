@@ -0,0 +1,47 @@
+package purchase
+
+import (
+	"github.com/aboglioli/big-brother/pkg/events"
+)
+
+// OrderChangedEvent is published on each purchase order status change
+type OrderChangedEvent struct {
+	events.Event
+	Order *Order `json:"order"`
+}
+
+func NewOrderCreatedEvent(o *Order) (*OrderChangedEvent, *events.Options) {
+	event := &OrderChangedEvent{events.NewEvent("PurchaseOrderCreated", o.ID.Hex()), o}
+	opts := &events.Options{"purchase", "topic", "purchase.order.created", ""}
+	return event, opts
+}
+
+func NewOrderSentEvent(o *Order) (*OrderChangedEvent, *events.Options) {
+	event := &OrderChangedEvent{events.NewEvent("PurchaseOrderSent", o.ID.Hex()), o}
+	opts := &events.Options{"purchase", "topic", "purchase.order.sent", ""}
+	return event, opts
+}
+
+// NewOrderReceivedEvent is published for each receipt. The event type depends
+// on the order status: "PurchaseOrderPartiallyReceived" or
+// "PurchaseOrderReceived".
+func NewOrderReceivedEvent(o *Order) (*OrderChangedEvent, *events.Options) {
+	eventType := "PurchaseOrderReceived"
+	if o.Status == OrderPartiallyReceived {
+		eventType = "PurchaseOrderPartiallyReceived"
+	}
+	event := &OrderChangedEvent{events.NewEvent(eventType, o.ID.Hex()), o}
+	opts := &events.Options{"purchase", "topic", "purchase.order.received", ""}
+	return event, opts
+}
+
+func NewOrderCancelledEvent(o *Order) (*OrderChangedEvent, *events.Options) {
+	event := &OrderChangedEvent{events.NewEvent("PurchaseOrderCancelled", o.ID.Hex()), o}
+	opts := &events.Options{"purchase", "topic", "purchase.order.cancelled", ""}
+	return event, opts
+}
+
+// newOrderEntry returns the outbox entry of an order event.
+func newOrderEntry(event *OrderChangedEvent, opts *events.Options) (*events.Entry, error) {
+	return events.NewEntry(event.Order.ID.Hex(), event, opts)
+}
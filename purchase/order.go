@@ -0,0 +1,272 @@
+package purchase
+
+import (
+	"time"
+
+	"github.com/aboglioli/big-brother/pkg/errors"
+	"github.com/aboglioli/big-brother/pkg/quantity"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	OrderDraft             = "DRAFT"
+	OrderSent              = "SENT"
+	OrderPartiallyReceived = "PARTIALLY_RECEIVED"
+	OrderReceived          = "RECEIVED"
+	OrderCancelled         = "CANCELLED"
+)
+
+// Line is an ordered composition. Price is the price of the whole ordered
+// quantity.
+type Line struct {
+	Composition primitive.ObjectID `json:"composition" bson:"composition"`
+	Quantity    quantity.Quantity  `json:"quantity" bson:"quantity"`
+	Price       float64            `json:"price" bson:"price"`
+	Received    quantity.Quantity  `json:"received" bson:"received"`
+}
+
+// Pending returns the quantity not received yet.
+func (l *Line) Pending() quantity.Quantity {
+	pending, _ := l.Quantity.Subtract(l.Received)
+	if pending.Quantity < 0 {
+		pending.Quantity = 0
+	}
+	return pending
+}
+
+func (l *Line) IsReceived() bool {
+	less, err := l.Received.Less(l.Quantity)
+	return err == nil && !less
+}
+
+// PriceFor returns the price of a quantity of the line, with the same
+// semantics than Composition.CostFromQuantity.
+func (l *Line) PriceFor(q quantity.Quantity) float64 {
+	ratio, err := q.Ratio(l.Quantity)
+	if err != nil {
+		return 0
+	}
+	return ratio * l.Price
+}
+
+// ReceiptLine is a received quantity of a composition. Lot is the stock lot
+// created by the receipt, with the given code and expiration date.
+type ReceiptLine struct {
+	Composition primitive.ObjectID `json:"composition" bson:"composition"`
+	Quantity    quantity.Quantity  `json:"quantity" bson:"quantity"`
+	Code        string             `json:"code" bson:"code"`
+	ExpiresAt   *time.Time         `json:"expiresAt" bson:"expiresAt"`
+	Lot         primitive.ObjectID `json:"lot" bson:"lot"`
+}
+
+// IsStocked returns true if the lot of the line was created.
+func (l *ReceiptLine) IsStocked() bool {
+	return !l.Lot.IsZero()
+}
+
+// Receipt is a goods receipt of an order. An order can be received in many
+// receipts.
+type Receipt struct {
+	ID    primitive.ObjectID `json:"id" bson:"_id"`
+	Date  time.Time          `json:"date" bson:"date"`
+	Lines []ReceiptLine      `json:"lines" bson:"lines"`
+}
+
+// Order is a purchase order to a supplier.
+type Order struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id"`
+	Supplier  primitive.ObjectID `json:"supplier" bson:"supplier"`
+	Status    string             `json:"status" bson:"status"`
+	Lines     []Line             `json:"lines" bson:"lines"`
+	Receipts  []Receipt          `json:"receipts" bson:"receipts"`
+	Reference string             `json:"reference" bson:"reference"`
+	Suggested bool               `json:"suggested" bson:"suggested"`
+
+	SentAt     *time.Time `json:"sentAt" bson:"sentAt"`
+	ReceivedAt *time.Time `json:"receivedAt" bson:"receivedAt"`
+	CreatedAt  time.Time  `json:"createdAt" bson:"createdAt"`
+	UpdatedAt  time.Time  `json:"updatedAt" bson:"updatedAt"`
+}
+
+func NewOrder(supplierID primitive.ObjectID) *Order {
+	return &Order{
+		ID:        primitive.NewObjectID(),
+		Supplier:  supplierID,
+		Status:    OrderDraft,
+		Lines:     make([]Line, 0),
+		Receipts:  make([]Receipt, 0),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+}
+
+// IsOpen returns true if the order can still be received.
+func (o *Order) IsOpen() bool {
+	return o.Status == OrderDraft || o.Status == OrderSent || o.Status == OrderPartiallyReceived
+}
+
+// Total returns the price of the order.
+func (o *Order) Total() float64 {
+	total := 0.0
+	for _, l := range o.Lines {
+		total += l.Price
+	}
+	return total
+}
+
+func (o *Order) FindLine(compID string) *Line {
+	for i := range o.Lines {
+		if o.Lines[i].Composition.Hex() == compID {
+			return &o.Lines[i]
+		}
+	}
+	return nil
+}
+
+// AddLine adds a composition to the order. If the composition is already in
+// the order, quantity and price are added to the existing line.
+func (o *Order) AddLine(compID primitive.ObjectID, q quantity.Quantity, price float64) error {
+	path := "purchase/order.AddLine"
+
+	if line := o.FindLine(compID.Hex()); line != nil {
+		total, err := line.Quantity.Add(q)
+		if err != nil {
+			return errors.NewStatus("INCOMPATIBLE_QUANTITY").SetPath(path).SetRef(err)
+		}
+		line.Quantity = total
+		line.Price += price
+		return nil
+	}
+
+	o.Lines = append(o.Lines, Line{
+		Composition: compID,
+		Quantity:    q,
+		Price:       price,
+		Received:    quantity.Quantity{Quantity: 0, Unit: q.Unit},
+	})
+
+	return nil
+}
+
+// Send marks a draft order as sent to the supplier.
+func (o *Order) Send() error {
+	if o.Status != OrderDraft {
+		return errors.NewStatus("ORDER_NOT_DRAFT").SetPath("purchase/order.Send")
+	}
+
+	now := time.Now()
+	o.Status = OrderSent
+	o.SentAt = &now
+
+	return nil
+}
+
+// Cancel cancels an order without receipts.
+func (o *Order) Cancel() error {
+	path := "purchase/order.Cancel"
+
+	if o.Status != OrderDraft && o.Status != OrderSent {
+		return errors.NewStatus("ORDER_CANNOT_BE_CANCELLED").SetPath(path).SetMessage("%s", o.Status)
+	}
+
+	o.Status = OrderCancelled
+
+	return nil
+}
+
+// HasUnstockedReceipts returns true if any receipt line has no lot yet.
+func (o *Order) HasUnstockedReceipts() bool {
+	for _, r := range o.Receipts {
+		for i := range r.Lines {
+			if !r.Lines[i].IsStocked() {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Receive records a receipt. Receipts cannot exceed the pending quantity of
+// each line. Draft orders are received as sent.
+func (o *Order) Receive(r Receipt) error {
+	path := "purchase/order.Receive"
+
+	if !o.IsOpen() {
+		return errors.NewStatus("ORDER_NOT_OPEN").SetPath(path).SetMessage("%s", o.Status)
+	}
+
+	if len(r.Lines) == 0 {
+		return errors.NewStatus("EMPTY_RECEIPT").SetPath(path)
+	}
+
+	for _, rl := range r.Lines {
+		line := o.FindLine(rl.Composition.Hex())
+		if line == nil {
+			return errors.NewStatus("COMPOSITION_NOT_IN_ORDER").SetPath(path).SetMessage("%s", rl.Composition.Hex())
+		}
+
+		if !rl.Quantity.IsValid() || rl.Quantity.Quantity == 0 || !rl.Quantity.Compatible(line.Quantity) {
+			return errors.NewStatus("INVALID_QUANTITY").SetPath(path).SetMessage("%v", rl.Quantity)
+		}
+
+		received, err := line.Received.Add(rl.Quantity)
+		if err != nil {
+			return errors.NewStatus("INVALID_QUANTITY").SetPath(path).SetRef(err)
+		}
+
+		if greater, _ := received.Greater(line.Quantity); greater {
+			return errors.NewStatus("EXCEEDS_PENDING_QUANTITY").SetPath(path).SetMessage("%v > %v", rl.Quantity, line.Pending())
+		}
+
+		line.Received = received
+	}
+
+	o.Receipts = append(o.Receipts, r)
+
+	if o.SentAt == nil {
+		now := time.Now()
+		o.SentAt = &now
+	}
+
+	o.Status = OrderReceived
+	for i := range o.Lines {
+		if !o.Lines[i].IsReceived() {
+			o.Status = OrderPartiallyReceived
+			break
+		}
+	}
+
+	if o.Status == OrderReceived {
+		now := time.Now()
+		o.ReceivedAt = &now
+	}
+
+	return nil
+}
+
+func (o *Order) ValidateSchema() error {
+	err := errors.NewValidation("VALIDATE_SCHEMA").SetPath("purchase/order.ValidateSchema")
+
+	if o.Supplier.IsZero() {
+		err.Add("supplier", "REQUIRED")
+	}
+
+	if len(o.Lines) == 0 {
+		err.Add("lines", "EMPTY")
+	}
+
+	for i, l := range o.Lines {
+		if !l.Quantity.IsValid() || l.Quantity.Quantity == 0 {
+			err.AddWithMessage("lines", "INVALID_QUANTITY", "line %d", i)
+		}
+		if l.Price < 0 {
+			err.AddWithMessage("lines", "INVALID_PRICE", "line %d", i)
+		}
+	}
+
+	if err.Size() > 0 {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,64 @@
+package purchase
+
+import (
+	"testing"
+
+	"github.com/aboglioli/big-brother/pkg/quantity"
+	"github.com/aboglioli/big-brother/pkg/tests/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestReceiveOrder(t *testing.T) {
+	flour, sugar := primitive.NewObjectID(), primitive.NewObjectID()
+
+	o := NewOrder(primitive.NewObjectID())
+	assert.Ok(t, o.AddLine(flour, quantity.Quantity{25, "kg"}, 450))
+	assert.Ok(t, o.AddLine(sugar, quantity.Quantity{10, "kg"}, 100))
+	assert.Ok(t, o.AddLine(sugar, quantity.Quantity{10000, "g"}, 100))
+	assert.Equal(t, len(o.Lines), 2)
+	assert.Assert(t, o.FindLine(sugar.Hex()).Quantity.Equals(quantity.Quantity{20, "kg"}))
+	assert.Equal(t, o.Total(), 650.0)
+
+	receipt := func(comp primitive.ObjectID, q quantity.Quantity) Receipt {
+		return Receipt{ID: primitive.NewObjectID(), Lines: []ReceiptLine{ReceiptLine{Composition: comp, Quantity: q}}}
+	}
+
+	t.Run("Invalid receipts", func(t *testing.T) {
+		err := o.Receive(Receipt{})
+		assert.ErrCode(t, err, "EMPTY_RECEIPT")
+
+		err = o.Receive(receipt(primitive.NewObjectID(), quantity.Quantity{1, "kg"}))
+		assert.ErrCode(t, err, "COMPOSITION_NOT_IN_ORDER")
+
+		err = o.Receive(receipt(flour, quantity.Quantity{1, "l"}))
+		assert.ErrCode(t, err, "INVALID_QUANTITY")
+
+		err = o.Receive(receipt(flour, quantity.Quantity{26, "kg"}))
+		assert.ErrCode(t, err, "EXCEEDS_PENDING_QUANTITY")
+	})
+
+	t.Run("Partial receipt", func(t *testing.T) {
+		assert.Ok(t, o.Receive(receipt(flour, quantity.Quantity{25, "kg"})))
+		assert.Ok(t, o.Receive(receipt(sugar, quantity.Quantity{5000, "g"})))
+		assert.Equal(t, o.Status, OrderPartiallyReceived)
+		assert.NotNil(t, o.SentAt)
+		assert.Nil(t, o.ReceivedAt)
+		assert.Assert(t, o.FindLine(sugar.Hex()).Pending().Equals(quantity.Quantity{15, "kg"}))
+
+		err := o.Receive(receipt(flour, quantity.Quantity{1, "g"}))
+		assert.ErrCode(t, err, "EXCEEDS_PENDING_QUANTITY")
+
+		err = o.Cancel()
+		assert.ErrCode(t, err, "ORDER_CANNOT_BE_CANCELLED")
+	})
+
+	t.Run("Full receipt", func(t *testing.T) {
+		assert.Ok(t, o.Receive(receipt(sugar, quantity.Quantity{15, "kg"})))
+		assert.Equal(t, o.Status, OrderReceived)
+		assert.NotNil(t, o.ReceivedAt)
+		assert.Equal(t, len(o.Receipts), 3)
+
+		err := o.Receive(receipt(sugar, quantity.Quantity{1, "kg"}))
+		assert.ErrCode(t, err, "ORDER_NOT_OPEN")
+	})
+}
@@ -0,0 +1,156 @@
+package purchase
+
+import (
+	"context"
+	"time"
+
+	"github.com/aboglioli/big-brother/infrastructure/db"
+	"github.com/aboglioli/big-brother/pkg/errors"
+	"github.com/aboglioli/big-brother/pkg/events"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type Repository interface {
+	FindByID(id string) (*Order, error)
+	FindByStatus(status ...string) ([]*Order, error)
+	FindBySupplier(supplierID string) ([]*Order, error)
+
+	// Insert and Update store the outbox entries of the change in the same
+	// transaction.
+	Insert(o *Order, entries ...*events.Entry) error
+	Update(o *Order, entries ...*events.Entry) error
+}
+
+type repository struct {
+	collection *mongo.Collection
+	outbox     events.Outbox
+}
+
+// NewRepository returns the repository of the "Purchase" database. The
+// outbox must be of the same database.
+func NewRepository(outbox events.Outbox) (Repository, error) {
+	db, err := db.Get("Purchase")
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &repository{
+		collection: db.Collection("order"),
+		outbox:     outbox,
+	}, nil
+}
+
+func (r *repository) FindByID(id string) (*Order, error) {
+	path := "purchase/repository.FindByID"
+	ctx := context.Background()
+
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errors.NewInternal("OBJECTID_FROM_HEX").SetPath(path).SetRef(err)
+	}
+
+	filter := bson.M{
+		"_id": objID,
+	}
+
+	res := r.collection.FindOne(ctx, filter)
+	if res.Err() != nil {
+		return nil, errors.NewInternal("FIND_ONE").SetPath(path).SetRef(res.Err())
+	}
+
+	var o Order
+	if err := res.Decode(&o); err != nil {
+		return nil, errors.NewInternal("DECODE").SetPath(path).SetRef(err)
+	}
+
+	return &o, nil
+}
+
+func (r *repository) FindByStatus(status ...string) ([]*Order, error) {
+	return r.find("purchase/repository.FindByStatus", bson.M{
+		"status": bson.M{
+			"$in": status,
+		},
+	})
+}
+
+func (r *repository) FindBySupplier(supplierID string) ([]*Order, error) {
+	path := "purchase/repository.FindBySupplier"
+
+	objID, err := primitive.ObjectIDFromHex(supplierID)
+	if err != nil {
+		return nil, errors.NewInternal("OBJECTID_FROM_HEX").SetPath(path).SetRef(err)
+	}
+
+	return r.find(path, bson.M{
+		"supplier": objID,
+	})
+}
+
+func (r *repository) Insert(o *Order, entries ...*events.Entry) error {
+	return r.outbox.Write(func(ctx context.Context) error {
+		_, err := r.collection.InsertOne(ctx, o)
+		if err != nil {
+			return errors.NewInternal("INSERT_ONE").SetPath("purchase/repository.Insert").SetRef(err)
+		}
+
+		return nil
+	}, entries...)
+}
+
+func (r *repository) Update(o *Order, entries ...*events.Entry) error {
+	path := "purchase/repository.Update"
+
+	if o.ID.IsZero() {
+		return errors.NewInternal("INVALID_OBJECTID").SetPath(path)
+	}
+
+	o.UpdatedAt = time.Now()
+
+	filter := bson.M{
+		"_id": o.ID,
+	}
+
+	update := bson.M{
+		"$set": o,
+	}
+
+	return r.outbox.Write(func(ctx context.Context) error {
+		_, err := r.collection.UpdateOne(ctx, filter, update)
+		if err != nil {
+			return errors.NewInternal("UPDATE_ONE").SetPath(path).SetRef(err)
+		}
+
+		return nil
+	}, entries...)
+}
+
+func (r *repository) find(path string, filter bson.M) ([]*Order, error) {
+	ctx := context.Background()
+
+	cur, err := r.collection.Find(ctx, filter)
+	if err != nil {
+		return nil, errors.NewInternal("FIND").SetPath(path).SetRef(err)
+	}
+	defer cur.Close(ctx)
+
+	orders := make([]*Order, 0)
+	for cur.Next(ctx) {
+		var o Order
+
+		if err := cur.Decode(&o); err != nil {
+			return nil, errors.NewInternal("CUR_DECODE").SetPath(path).SetRef(err)
+		}
+
+		orders = append(orders, &o)
+	}
+
+	if err := cur.Err(); err != nil {
+		return nil, errors.NewInternal("CUR").SetPath(path).SetRef(err)
+	}
+
+	return orders, nil
+}
@@ -0,0 +1,109 @@
+package purchase
+
+import (
+	"time"
+
+	"github.com/aboglioli/big-brother/pkg/errors"
+	"github.com/aboglioli/big-brother/pkg/events"
+	"github.com/aboglioli/big-brother/pkg/tests/mock"
+)
+
+type mockRepository struct {
+	mock.Mock
+	orders  []*Order
+	entries []*events.Entry
+}
+
+// NewMockRepository returns an in-memory Repository. It is exported to be
+// used by other packages' tests.
+func NewMockRepository() *mockRepository {
+	return &mockRepository{}
+}
+
+// Helpers
+func (r *mockRepository) Clean() {
+	r.orders = make([]*Order, 0)
+	r.entries = make([]*events.Entry, 0)
+}
+
+// Entries returns the outbox entries stored with the changes.
+func (r *mockRepository) Entries() []*events.Entry {
+	return r.entries
+}
+
+// Implementation
+func (r *mockRepository) FindByID(id string) (*Order, error) {
+	r.Called("FindByID", id)
+
+	for _, o := range r.orders {
+		if o.ID.Hex() == id {
+			return copyOrder(o), nil
+		}
+	}
+
+	return nil, errors.NewInternal("NOT_FOUND").SetPath("purchase/repository_mock.FindByID")
+}
+
+func (r *mockRepository) FindByStatus(status ...string) ([]*Order, error) {
+	r.Called("FindByStatus", status)
+
+	orders := make([]*Order, 0)
+	for _, o := range r.orders {
+		for _, s := range status {
+			if o.Status == s {
+				orders = append(orders, copyOrder(o))
+				break
+			}
+		}
+	}
+
+	return orders, nil
+}
+
+func (r *mockRepository) FindBySupplier(supplierID string) ([]*Order, error) {
+	r.Called("FindBySupplier", supplierID)
+
+	orders := make([]*Order, 0)
+	for _, o := range r.orders {
+		if o.Supplier.Hex() == supplierID {
+			orders = append(orders, copyOrder(o))
+		}
+	}
+
+	return orders, nil
+}
+
+func (r *mockRepository) Insert(o *Order, entries ...*events.Entry) error {
+	r.Called("Insert", o)
+	r.entries = append(r.entries, entries...)
+
+	r.orders = append(r.orders, copyOrder(o))
+
+	return nil
+}
+
+func (r *mockRepository) Update(o *Order, entries ...*events.Entry) error {
+	r.Called("Update", o)
+	r.entries = append(r.entries, entries...)
+
+	for _, order := range r.orders {
+		if order.ID.Hex() == o.ID.Hex() {
+			*order = *copyOrder(o)
+			order.UpdatedAt = time.Now()
+			break
+		}
+	}
+
+	return nil
+}
+
+func copyOrder(o *Order) *Order {
+	copy := *o
+	copy.Lines = append([]Line(nil), o.Lines...)
+	copy.Receipts = make([]Receipt, len(o.Receipts))
+	for i, r := range o.Receipts {
+		r.Lines = append([]ReceiptLine(nil), r.Lines...)
+		copy.Receipts[i] = r
+	}
+	return &copy
+}
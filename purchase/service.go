@@ -0,0 +1,507 @@
+package purchase
+
+import (
+	"math"
+	"time"
+
+	"github.com/aboglioli/big-brother/composition"
+	"github.com/aboglioli/big-brother/pkg/errors"
+	"github.com/aboglioli/big-brother/pkg/events"
+	"github.com/aboglioli/big-brother/pkg/quantity"
+	"github.com/aboglioli/big-brother/stock"
+	"github.com/aboglioli/big-brother/supplier"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Service interface {
+	GetByID(id string) (*Order, error)
+	FindOpen() ([]*Order, error)
+	FindBySupplier(supplierID string) ([]*Order, error)
+
+	Create(req *CreateRequest) (*Order, error)
+	Send(id string) (*Order, error)
+	Cancel(id string) (*Order, error)
+	Receive(id string, req *ReceiveRequest) (*Order, error)
+
+	Suggest() ([]*Order, error)
+}
+
+type service struct {
+	repository         Repository
+	compositionService composition.Service
+	supplierService    supplier.Service
+	stockService       stock.Service
+}
+
+func NewService(repo Repository, compServ composition.Service, supplierServ supplier.Service, stockServ stock.Service) Service {
+	return &service{
+		repository:         repo,
+		compositionService: compServ,
+		supplierService:    supplierServ,
+		stockService:       stockServ,
+	}
+}
+
+func (s *service) GetByID(id string) (*Order, error) {
+	o, err := s.repository.FindByID(id)
+	if err != nil {
+		return nil, errors.NewStatus("ORDER_NOT_FOUND").SetPath("purchase/service.GetByID").SetStatus(404).SetRef(err)
+	}
+	return o, nil
+}
+
+func (s *service) FindOpen() ([]*Order, error) {
+	orders, err := s.repository.FindByStatus(OrderDraft, OrderSent, OrderPartiallyReceived)
+	if err != nil {
+		return nil, errors.NewStatus("FIND_ORDERS").SetPath("purchase/service.FindOpen").SetRef(err)
+	}
+	return orders, nil
+}
+
+func (s *service) FindBySupplier(supplierID string) ([]*Order, error) {
+	orders, err := s.repository.FindBySupplier(supplierID)
+	if err != nil {
+		return nil, errors.NewStatus("FIND_ORDERS").SetPath("purchase/service.FindBySupplier").SetRef(err)
+	}
+	return orders, nil
+}
+
+type LineRequest struct {
+	Composition string            `json:"composition" binding:"required"`
+	Quantity    quantity.Quantity `json:"quantity" binding:"required"`
+	Price       *float64          `json:"price"`
+}
+
+type CreateRequest struct {
+	Supplier  string        `json:"supplier" binding:"required"`
+	Lines     []LineRequest `json:"lines" binding:"required"`
+	Reference string        `json:"reference"`
+}
+
+// Create creates a draft purchase order. Line prices default to the supplier
+// price list.
+/**
+* @api {topic} purchase.order.created purchase.order.created
+* @apiName PurchaseOrderCreated
+* @apiGroup RabbitMQ
+*
+* @apiDescription Emits a new event when a purchase order is created, manually
+* or suggested.
+*
+* @apiSuccessExample {json} Body
+* {
+* 	"type": "PurchaseOrderCreated",
+* 	"order": order data
+* }
+ */
+func (s *service) Create(req *CreateRequest) (*Order, error) {
+	path := "purchase/service.Create"
+
+	sup, err := s.supplierService.GetByID(req.Supplier)
+	if err != nil {
+		return nil, err
+	}
+
+	o := NewOrder(sup.ID)
+	o.Reference = req.Reference
+
+	for i, l := range req.Lines {
+		comp, err := s.compositionService.GetByID(l.Composition)
+		if err != nil {
+			return nil, err
+		}
+
+		q, err := comp.ToUnit(l.Quantity)
+		if err != nil {
+			return nil, errors.NewStatus("INCOMPATIBLE_QUANTITY").SetPath(path).SetMessage("Line %d: %v != %v", i, l.Quantity, comp.Unit).SetRef(err)
+		}
+		l.Quantity = q
+
+		var price float64
+		if l.Price != nil {
+			price = *l.Price
+		} else if p := sup.FindPrice(comp.ID.Hex()); p != nil {
+			cost, err := p.CostFor(l.Quantity)
+			if err != nil {
+				return nil, errors.NewStatus("INCOMPATIBLE_PRICE").SetPath(path).SetMessage("Line %d: %s", i, l.Composition).SetRef(err)
+			}
+			price = math.Round(cost*1000) / 1000
+		} else {
+			return nil, errors.NewStatus("PRICE_REQUIRED").SetPath(path).SetMessage("Line %d: %s", i, l.Composition)
+		}
+
+		if err := o.AddLine(comp.ID, l.Quantity, price); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := o.ValidateSchema(); err != nil {
+		return nil, err
+	}
+
+	// Publish event through the outbox: purchase.order.created
+	entry, err := newOrderEntry(NewOrderCreatedEvent(o))
+	if err != nil {
+		return nil, errors.NewStatus("ENCODE_EVENT").SetPath(path).SetRef(err)
+	}
+
+	if err := s.repository.Insert(o, entry); err != nil {
+		return nil, errors.NewStatus("INSERT").SetPath(path).SetRef(err)
+	}
+
+	return o, nil
+}
+
+// Send marks a draft order as sent to the supplier.
+/**
+* @api {topic} purchase.order.sent purchase.order.sent
+* @apiName PurchaseOrderSent
+* @apiGroup RabbitMQ
+*
+* @apiDescription Emits a new event when a purchase order is sent to the
+* supplier.
+*
+* @apiSuccessExample {json} Body
+* {
+* 	"type": "PurchaseOrderSent",
+* 	"order": order data
+* }
+ */
+func (s *service) Send(id string) (*Order, error) {
+	path := "purchase/service.Send"
+
+	o, err := s.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := o.Send(); err != nil {
+		return nil, err
+	}
+
+	// Publish event through the outbox: purchase.order.sent
+	entry, err := newOrderEntry(NewOrderSentEvent(o))
+	if err != nil {
+		return nil, errors.NewStatus("ENCODE_EVENT").SetPath(path).SetRef(err)
+	}
+
+	if err := s.repository.Update(o, entry); err != nil {
+		return nil, errors.NewStatus("UPDATE").SetPath(path).SetRef(err)
+	}
+
+	return o, nil
+}
+
+// Cancel cancels an order without receipts.
+/**
+* @api {topic} purchase.order.cancelled purchase.order.cancelled
+* @apiName PurchaseOrderCancelled
+* @apiGroup RabbitMQ
+*
+* @apiDescription Emits a new event when a purchase order is cancelled.
+*
+* @apiSuccessExample {json} Body
+* {
+* 	"type": "PurchaseOrderCancelled",
+* 	"order": order data
+* }
+ */
+func (s *service) Cancel(id string) (*Order, error) {
+	path := "purchase/service.Cancel"
+
+	o, err := s.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := o.Cancel(); err != nil {
+		return nil, err
+	}
+
+	// Publish event through the outbox: purchase.order.cancelled
+	entry, err := newOrderEntry(NewOrderCancelledEvent(o))
+	if err != nil {
+		return nil, errors.NewStatus("ENCODE_EVENT").SetPath(path).SetRef(err)
+	}
+
+	if err := s.repository.Update(o, entry); err != nil {
+		return nil, errors.NewStatus("UPDATE").SetPath(path).SetRef(err)
+	}
+
+	return o, nil
+}
+
+type ReceiptLineRequest struct {
+	Composition string            `json:"composition" binding:"required"`
+	Quantity    quantity.Quantity `json:"quantity" binding:"required"`
+	Code        string            `json:"code"`
+	ExpiresAt   *time.Time        `json:"expiresAt"`
+}
+
+type ReceiveRequest struct {
+	Date  *time.Time           `json:"date"`
+	Lines []ReceiptLineRequest `json:"lines" binding:"required"`
+}
+
+// Receive records a goods receipt. Each received line creates a stock lot
+// and a receipt movement referencing the order. The purchase price of the
+// line becomes the cost of raw materials (last purchase price).
+//
+// The receipt is saved before creating the lots and the order is saved after
+// each lot, so stocked lines are marked. Lines of previous receipts without
+// lot (a failed receipt) are stocked first; a request without lines only
+// retries them. The event is stored with the last stocked line.
+/**
+* @api {topic} purchase.order.received purchase.order.received
+* @apiName PurchaseOrderReceived
+* @apiGroup RabbitMQ
+*
+* @apiDescription Emits a new event for each goods receipt. This event can be
+* of type "PurchaseOrderPartiallyReceived" or "PurchaseOrderReceived".
+*
+* @apiSuccessExample {json} Body
+* {
+* 	"type": "PurchaseOrderReceived",
+* 	"order": order data
+* }
+ */
+func (s *service) Receive(id string, req *ReceiveRequest) (*Order, error) {
+	path := "purchase/service.Receive"
+
+	o, err := s.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(req.Lines) > 0 || !o.HasUnstockedReceipts() {
+		if err := s.record(o, req); err != nil {
+			return nil, err
+		}
+	}
+
+	for i := range o.Receipts {
+		r := &o.Receipts[i]
+		for j := range r.Lines {
+			l := &r.Lines[j]
+			if l.IsStocked() {
+				continue
+			}
+
+			if err := s.updateCost(o.FindLine(l.Composition.Hex())); err != nil {
+				return nil, err
+			}
+
+			lot, err := s.stockService.Receive(&stock.ReceiveRequest{
+				Composition: l.Composition.Hex(),
+				Code:        l.Code,
+				Quantity:    l.Quantity,
+				Date:        &r.Date,
+				ExpiresAt:   l.ExpiresAt,
+				Reference:   o.ID.Hex(),
+			})
+			if err != nil {
+				return nil, err
+			}
+			l.Lot = lot.ID
+
+			// Publish event through the outbox with the last stocked line:
+			// purchase.order.received
+			entries := make([]*events.Entry, 0)
+			if !o.HasUnstockedReceipts() {
+				entry, err := newOrderEntry(NewOrderReceivedEvent(o))
+				if err != nil {
+					return nil, errors.NewStatus("ENCODE_EVENT").SetPath(path).SetRef(err)
+				}
+				entries = append(entries, entry)
+			}
+
+			if err := s.repository.Update(o, entries...); err != nil {
+				return nil, errors.NewStatus("UPDATE").SetPath(path).SetRef(err)
+			}
+		}
+	}
+
+	return o, nil
+}
+
+// record adds the receipt of the request to the order and saves it.
+func (s *service) record(o *Order, req *ReceiveRequest) error {
+	path := "purchase/service.record"
+
+	r := Receipt{
+		ID:    primitive.NewObjectID(),
+		Date:  time.Now(),
+		Lines: make([]ReceiptLine, 0, len(req.Lines)),
+	}
+	if req.Date != nil {
+		r.Date = *req.Date
+	}
+
+	for _, l := range req.Lines {
+		compID, err := primitive.ObjectIDFromHex(l.Composition)
+		if err != nil {
+			return errors.NewStatus("COMPOSITION_NOT_IN_ORDER").SetPath(path).SetRef(err)
+		}
+
+		// Received quantities are converted to the composition unit, so
+		// liquids ordered by volume can be received by weight
+		q := l.Quantity
+		if comp, err := s.compositionService.GetByID(l.Composition); err == nil && comp.Compatible(q) {
+			q, _ = comp.ToUnit(q)
+		}
+
+		r.Lines = append(r.Lines, ReceiptLine{
+			Composition: compID,
+			Quantity:    q,
+			Code:        l.Code,
+			ExpiresAt:   l.ExpiresAt,
+		})
+	}
+
+	// Validates receipt against pending quantities before touching stock
+	if err := o.Receive(r); err != nil {
+		return err
+	}
+
+	if err := s.repository.Update(o); err != nil {
+		return errors.NewStatus("UPDATE").SetPath(path).SetRef(err)
+	}
+
+	return nil
+}
+
+// Suggest creates draft orders for enabled compositions below their reorder
+// point. Each composition is ordered to its best supplier (see
+// supplier.Service.BestPrice) by its reorder quantity or, if there is no
+// reorder quantity, by the quantity needed for the available stock to reach
+// the reorder point.
+// Compositions already in open orders or without suppliers are skipped.
+func (s *service) Suggest() ([]*Order, error) {
+	path := "purchase/service.Suggest"
+
+	comps, err := s.compositionService.FindBelowReorderPoint()
+	if err != nil {
+		return nil, err
+	}
+
+	open, err := s.FindOpen()
+	if err != nil {
+		return nil, err
+	}
+
+	ordered := make(map[primitive.ObjectID]bool)
+	for _, o := range open {
+		for _, l := range o.Lines {
+			ordered[l.Composition] = true
+		}
+	}
+
+	orders := make([]*Order, 0)
+	bySupplier := make(map[primitive.ObjectID]*Order)
+	for _, comp := range comps {
+		if ordered[comp.ID] {
+			continue
+		}
+
+		q := suggestedQuantity(comp)
+		if q.Quantity <= 0 {
+			continue
+		}
+
+		sup, price, err := s.supplierService.BestPrice(comp.ID.Hex())
+		if err != nil {
+			continue
+		}
+
+		o, ok := bySupplier[sup.ID]
+		if !ok {
+			o = NewOrder(sup.ID)
+			o.Suggested = true
+			bySupplier[sup.ID] = o
+			orders = append(orders, o)
+		}
+
+		cost, err := price.CostFor(q)
+		if err != nil {
+			return nil, errors.NewStatus("INCOMPATIBLE_PRICE").SetPath(path).SetMessage("%s", comp.ID.Hex()).SetRef(err)
+		}
+
+		if err := o.AddLine(comp.ID, q, math.Round(cost*1000)/1000); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, o := range orders {
+		// Publish event through the outbox: purchase.order.created
+		entry, err := newOrderEntry(NewOrderCreatedEvent(o))
+		if err != nil {
+			return nil, errors.NewStatus("ENCODE_EVENT").SetPath(path).SetRef(err)
+		}
+
+		if err := s.repository.Insert(o, entry); err != nil {
+			return nil, errors.NewStatus("INSERT").SetPath(path).SetRef(err)
+		}
+	}
+
+	return orders, nil
+}
+
+// updateCost sets the cost of a raw material from the line price. The
+// composition is updated through the composition service, so the change is
+// propagated to the compositions using it.
+func (s *service) updateCost(line *Line) error {
+	comp, err := s.compositionService.GetByID(line.Composition.Hex())
+	if err != nil {
+		return err
+	}
+
+	if len(comp.Dependencies) > 0 || !comp.AutoupdateCost {
+		return nil
+	}
+
+	cost := math.Round(line.PriceFor(comp.Unit)*1000) / 1000
+	if cost == comp.Cost {
+		return nil
+	}
+
+	_, err = s.compositionService.Update(comp.ID.Hex(), &composition.UpdateRequest{
+		Cost:         &cost,
+		Dependencies: comp.Dependencies,
+		Confirm:      true,
+	})
+
+	return err
+}
+
+// suggestedQuantity returns the reorder quantity or, if there is none, the
+// quantity needed for the available stock to reach the reorder point (or the
+// minimum stock). If the available stock already reaches it (e.g. stock equal
+// to the reorder point), the lot size or a composition unit is ordered.
+func suggestedQuantity(comp *composition.Composition) quantity.Quantity {
+	if !comp.ReorderQuantity.IsEmpty() {
+		return comp.ReorderQuantity
+	}
+
+	target := comp.ReorderPoint
+	if target.IsEmpty() {
+		target = comp.MinimumStock
+	}
+
+	target, err := comp.ToUnit(target)
+	if err != nil {
+		return quantity.Quantity{}
+	}
+
+	q, err := target.Subtract(comp.Available())
+	if err != nil {
+		return quantity.Quantity{}
+	}
+
+	if q.Quantity > 0 {
+		return q
+	}
+	if !comp.LotSize.IsEmpty() {
+		return comp.LotSize
+	}
+	return comp.Unit
+}
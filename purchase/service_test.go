@@ -0,0 +1,209 @@
+package purchase
+
+import (
+	"testing"
+
+	"github.com/aboglioli/big-brother/composition"
+	"github.com/aboglioli/big-brother/pkg/events"
+	"github.com/aboglioli/big-brother/pkg/quantity"
+	"github.com/aboglioli/big-brother/pkg/tests/assert"
+	"github.com/aboglioli/big-brother/stock"
+	"github.com/aboglioli/big-brother/supplier"
+)
+
+func newComposition(unit quantity.Quantity, cost float64) *composition.Composition {
+	comp := composition.NewComposition()
+	comp.Cost = cost
+	comp.Unit = unit
+	comp.Stock = quantity.Quantity{0, unit.Unit}
+	comp.Validated = true
+	return comp
+}
+
+type serviceContext struct {
+	repo         *mockRepository
+	compRepo     composition.Repository
+	lotRepo      stock.LotRepository
+	supplierServ supplier.Service
+	serv         Service
+}
+
+func newServiceContext() *serviceContext {
+	repo, compRepo, eventMgr := NewMockRepository(), composition.NewMockRepository(), events.GetMockManager()
+	lotRepo := stock.NewMockLotRepository()
+	compServ := composition.NewService(compRepo)
+	supplierServ := supplier.NewService(supplier.NewMockRepository(), compServ, eventMgr)
+	stockServ := stock.NewService(lotRepo, stock.NewMockMovementRepository(), stock.NewMockCountRepository(), compServ)
+
+	return &serviceContext{
+		repo:         repo,
+		compRepo:     compRepo,
+		lotRepo:      lotRepo,
+		supplierServ: supplierServ,
+		serv:         NewService(repo, compServ, supplierServ, stockServ),
+	}
+}
+
+func TestPurchaseOrder(t *testing.T) {
+	ctx := newServiceContext()
+	flour := newComposition(quantity.Quantity{1, "kg"}, 10)
+	ctx.compRepo.Insert(flour)
+
+	mill, _ := ctx.supplierServ.Create(&supplier.CreateRequest{Name: "Mill"})
+	ctx.supplierServ.SetPrice(mill.ID.Hex(), &supplier.PriceRequest{
+		Composition: flour.ID.Hex(),
+		Price:       450,
+		Quantity:    quantity.Quantity{25, "kg"},
+	})
+
+	t.Run("Price required", func(t *testing.T) {
+		sugar := newComposition(quantity.Quantity{1, "kg"}, 5)
+		ctx.compRepo.Insert(sugar)
+
+		_, err := ctx.serv.Create(&CreateRequest{
+			Supplier: mill.ID.Hex(),
+			Lines:    []LineRequest{LineRequest{Composition: sugar.ID.Hex(), Quantity: quantity.Quantity{1, "kg"}}},
+		})
+		assert.ErrCode(t, err, "PRICE_REQUIRED")
+	})
+
+	o, err := ctx.serv.Create(&CreateRequest{
+		Supplier: mill.ID.Hex(),
+		Lines:    []LineRequest{LineRequest{Composition: flour.ID.Hex(), Quantity: quantity.Quantity{50, "kg"}}},
+	})
+	assert.Ok(t, err)
+	assert.Equal(t, o.Status, OrderDraft)
+	assert.Equal(t, o.Lines[0].Price, 900.0, "Price from supplier price list")
+
+	o, err = ctx.serv.Send(o.ID.Hex())
+	assert.Ok(t, err)
+	assert.Equal(t, o.Status, OrderSent)
+
+	t.Run("Partial receipt", func(t *testing.T) {
+		o, err := ctx.serv.Receive(o.ID.Hex(), &ReceiveRequest{
+			Lines: []ReceiptLineRequest{ReceiptLineRequest{Composition: flour.ID.Hex(), Quantity: quantity.Quantity{20, "kg"}, Code: "F-1"}},
+		})
+		assert.Ok(t, err)
+		assert.Equal(t, o.Status, OrderPartiallyReceived)
+
+		lot, err := ctx.lotRepo.FindByID(o.Receipts[0].Lines[0].Lot.Hex())
+		assert.Ok(t, err)
+		assert.Equal(t, lot.Code, "F-1")
+
+		comp, _ := ctx.compRepo.FindByID(flour.ID.Hex())
+		assert.Assert(t, comp.Stock.Equals(quantity.Quantity{20, "kg"}))
+
+		entries := ctx.repo.Entries()
+		assert.Equal(t, entries[len(entries)-1].Type(), "PurchaseOrderPartiallyReceived")
+	})
+
+	t.Run("Last purchase price", func(t *testing.T) {
+		// Price negotiated in the order: 27 kg for 540, 20 per kg
+		o, err := ctx.serv.Create(&CreateRequest{
+			Supplier: mill.ID.Hex(),
+			Lines:    []LineRequest{LineRequest{Composition: flour.ID.Hex(), Quantity: quantity.Quantity{27, "kg"}, Price: func(f float64) *float64 { return &f }(540)}},
+		})
+		assert.Ok(t, err)
+
+		o, err = ctx.serv.Receive(o.ID.Hex(), &ReceiveRequest{
+			Lines: []ReceiptLineRequest{ReceiptLineRequest{Composition: flour.ID.Hex(), Quantity: quantity.Quantity{27, "kg"}}},
+		})
+		assert.Ok(t, err)
+		assert.Equal(t, o.Status, OrderReceived)
+
+		comp, _ := ctx.compRepo.FindByID(flour.ID.Hex())
+		assert.Equal(t, comp.Cost, 20.0)
+		assert.Assert(t, comp.Stock.Equals(quantity.Quantity{47, "kg"}))
+	})
+
+	t.Run("Failed receipt is retried", func(t *testing.T) {
+		yeast := newComposition(quantity.Quantity{1, "kg"}, 8)
+		ctx.compRepo.Insert(yeast)
+
+		price := 10.0
+		o, err := ctx.serv.Create(&CreateRequest{
+			Supplier: mill.ID.Hex(),
+			Lines: []LineRequest{
+				LineRequest{Composition: flour.ID.Hex(), Quantity: quantity.Quantity{10, "kg"}, Price: &price},
+				LineRequest{Composition: yeast.ID.Hex(), Quantity: quantity.Quantity{1, "kg"}, Price: &price},
+			},
+		})
+		assert.Ok(t, err)
+
+		// Lots of yeast can't be created
+		ctx.compRepo.Delete(yeast.ID.Hex())
+
+		_, err = ctx.serv.Receive(o.ID.Hex(), &ReceiveRequest{
+			Lines: []ReceiptLineRequest{
+				ReceiptLineRequest{Composition: flour.ID.Hex(), Quantity: quantity.Quantity{10, "kg"}},
+				ReceiptLineRequest{Composition: yeast.ID.Hex(), Quantity: quantity.Quantity{1, "kg"}, Code: "Y-1"},
+			},
+		})
+		assert.ErrCode(t, err, "COMPOSITION_NOT_FOUND")
+
+		o, _ = ctx.serv.GetByID(o.ID.Hex())
+		assert.Equal(t, o.Status, OrderReceived, "Receipt should be saved")
+		assert.Assert(t, o.Receipts[0].Lines[0].IsStocked())
+		assert.Assert(t, !o.Receipts[0].Lines[1].IsStocked())
+
+		ctx.compRepo.Update(yeast)
+
+		o, err = ctx.serv.Receive(o.ID.Hex(), &ReceiveRequest{Lines: []ReceiptLineRequest{}})
+		assert.Ok(t, err)
+		assert.Equal(t, len(o.Receipts), 1)
+		assert.Assert(t, !o.HasUnstockedReceipts())
+
+		lot, err := ctx.lotRepo.FindByID(o.Receipts[0].Lines[1].Lot.Hex())
+		assert.Ok(t, err)
+		assert.Equal(t, lot.Code, "Y-1")
+
+		comp, _ := ctx.compRepo.FindByID(flour.ID.Hex())
+		assert.Assert(t, comp.Stock.Equals(quantity.Quantity{57, "kg"}), "Stocked lines are not received again")
+
+		_, err = ctx.serv.Receive(o.ID.Hex(), &ReceiveRequest{Lines: []ReceiptLineRequest{}})
+		assert.ErrCode(t, err, "ORDER_NOT_OPEN")
+	})
+}
+
+func TestSuggestOrders(t *testing.T) {
+	ctx := newServiceContext()
+	flour := newComposition(quantity.Quantity{1, "kg"}, 10)
+	flour.ReorderPoint = quantity.Quantity{10, "kg"}
+	flour.ReorderQuantity = quantity.Quantity{25, "kg"}
+	sugar := newComposition(quantity.Quantity{1, "kg"}, 5)
+	sugar.Stock = quantity.Quantity{2, "kg"}
+	sugar.MinimumStock = quantity.Quantity{5, "kg"}
+	sugar.Reserved = quantity.Quantity{1, "kg"}
+	yeast := newComposition(quantity.Quantity{1, "kg"}, 2)
+	yeast.Stock = quantity.Quantity{2, "kg"}
+	yeast.ReorderPoint = quantity.Quantity{2, "kg"}
+	yeast.LotSize = quantity.Quantity{5, "kg"}
+	salt := newComposition(quantity.Quantity{1, "kg"}, 1)
+	salt.ReorderPoint = quantity.Quantity{1, "kg"}
+	ctx.compRepo.Insert(flour)
+	ctx.compRepo.Insert(sugar)
+	ctx.compRepo.Insert(salt)
+	ctx.compRepo.Insert(yeast)
+
+	mill, _ := ctx.supplierServ.Create(&supplier.CreateRequest{Name: "Mill"})
+	ctx.supplierServ.SetPrice(mill.ID.Hex(), &supplier.PriceRequest{Composition: flour.ID.Hex(), Price: 450, Quantity: quantity.Quantity{25, "kg"}})
+	ctx.supplierServ.SetPrice(mill.ID.Hex(), &supplier.PriceRequest{Composition: sugar.ID.Hex(), Price: 5, Quantity: quantity.Quantity{1, "kg"}})
+	ctx.supplierServ.SetPrice(mill.ID.Hex(), &supplier.PriceRequest{Composition: yeast.ID.Hex(), Price: 2, Quantity: quantity.Quantity{1, "kg"}})
+
+	orders, err := ctx.serv.Suggest()
+	assert.Ok(t, err)
+	assert.Equal(t, len(orders), 1, "Salt has no supplier")
+
+	o := orders[0]
+	assert.Assert(t, o.Suggested)
+	assert.Equal(t, o.Supplier, mill.ID)
+	assert.Equal(t, len(o.Lines), 3)
+	assert.Assert(t, o.FindLine(flour.ID.Hex()).Quantity.Equals(quantity.Quantity{25, "kg"}))
+	assert.Assert(t, o.FindLine(sugar.ID.Hex()).Quantity.Equals(quantity.Quantity{4, "kg"}), "Available stock should reach minimum stock")
+	assert.Assert(t, o.FindLine(yeast.ID.Hex()).Quantity.Equals(quantity.Quantity{5, "kg"}), "Stock at reorder point should order a lot")
+	assert.Equal(t, o.Total(), 480.0)
+
+	orders, err = ctx.serv.Suggest()
+	assert.Ok(t, err)
+	assert.Equal(t, len(orders), 0, "Already ordered")
+}
@@ -0,0 +1,23 @@
+package quality
+
+import (
+	"github.com/aboglioli/big-brother/pkg/events"
+)
+
+// InspectionChangedEvent is a single inspection change
+type InspectionChangedEvent struct {
+	events.Event
+	Inspection *Inspection `json:"inspection"`
+}
+
+func NewInspectionPassedEvent(i *Inspection) (*InspectionChangedEvent, *events.Options) {
+	event := &InspectionChangedEvent{events.NewEvent("InspectionPassed", i.ID.Hex()), i}
+	opts := &events.Options{"quality", "topic", "quality.inspection.passed", ""}
+	return event, opts
+}
+
+func NewInspectionFailedEvent(i *Inspection) (*InspectionChangedEvent, *events.Options) {
+	event := &InspectionChangedEvent{events.NewEvent("InspectionFailed", i.ID.Hex()), i}
+	opts := &events.Options{"quality", "topic", "quality.inspection.failed", ""}
+	return event, opts
+}
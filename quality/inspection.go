@@ -0,0 +1,122 @@
+package quality
+
+import (
+	"time"
+
+	"github.com/aboglioli/big-brother/pkg/errors"
+	"github.com/aboglioli/big-brother/pkg/quantity"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	InspectionPending = "PENDING"
+	InspectionPassed  = "PASSED"
+	InspectionFailed  = "FAILED"
+)
+
+// Result is the value of a characteristic: Value for measures and Passed for
+// checks. Accepted is set when the inspection is recorded.
+type Result struct {
+	Characteristic string             `json:"characteristic" bson:"characteristic"`
+	Value          *quantity.Quantity `json:"value" bson:"value"`
+	Passed         *bool              `json:"passed" bson:"passed"`
+	Accepted       bool               `json:"accepted" bson:"accepted"`
+}
+
+// Inspection is the quality inspection of a lot at a stage. Characteristics
+// are copied from the plan, so later plan changes don't alter inspections.
+type Inspection struct {
+	ID              primitive.ObjectID `json:"id" bson:"_id"`
+	Plan            primitive.ObjectID `json:"plan" bson:"plan"`
+	Composition     primitive.ObjectID `json:"composition" bson:"composition"`
+	Lot             primitive.ObjectID `json:"lot" bson:"lot"`
+	Stage           string             `json:"stage" bson:"stage"`
+	Status          string             `json:"status" bson:"status"`
+	Characteristics []Characteristic   `json:"characteristics" bson:"characteristics"`
+	Results         []Result           `json:"results" bson:"results"`
+	Notes           string             `json:"notes" bson:"notes"`
+
+	InspectedAt *time.Time `json:"inspectedAt" bson:"inspectedAt"`
+	CreatedAt   time.Time  `json:"createdAt" bson:"createdAt"`
+	UpdatedAt   time.Time  `json:"updatedAt" bson:"updatedAt"`
+}
+
+func NewInspection(p *Plan, lotID primitive.ObjectID, stage string) *Inspection {
+	return &Inspection{
+		ID:              primitive.NewObjectID(),
+		Plan:            p.ID,
+		Composition:     p.Composition,
+		Lot:             lotID,
+		Stage:           stage,
+		Status:          InspectionPending,
+		Characteristics: append([]Characteristic(nil), p.Characteristics...),
+		Results:         make([]Result, 0),
+		CreatedAt:       time.Now(),
+		UpdatedAt:       time.Now(),
+	}
+}
+
+// Record evaluates a result for each characteristic. The inspection fails if
+// any result is out of tolerance.
+func (i *Inspection) Record(results []Result) error {
+	path := "quality/inspection.Record"
+
+	if i.Status != InspectionPending {
+		return errors.NewStatus("INSPECTION_ALREADY_RECORDED").SetPath(path)
+	}
+
+	byName := make(map[string]Result)
+	for _, r := range results {
+		byName[r.Characteristic] = r
+	}
+	for _, r := range results {
+		if !i.hasCharacteristic(r.Characteristic) {
+			return errors.NewStatus("UNKNOWN_CHARACTERISTIC").SetPath(path).SetMessage("%s", r.Characteristic)
+		}
+	}
+
+	evaluated := make([]Result, 0, len(i.Characteristics))
+	passed := true
+	for _, c := range i.Characteristics {
+		r, ok := byName[c.Name]
+		if !ok {
+			return errors.NewStatus("MISSING_RESULT").SetPath(path).SetMessage("%s", c.Name)
+		}
+
+		switch c.Type {
+		case CharacteristicMeasure:
+			if r.Value == nil || !r.Value.IsValid() {
+				return errors.NewStatus("INVALID_RESULT").SetPath(path).SetMessage("%s", c.Name)
+			}
+			r.Accepted = c.Accepts(*r.Value)
+		case CharacteristicCheck:
+			if r.Passed == nil {
+				return errors.NewStatus("INVALID_RESULT").SetPath(path).SetMessage("%s", c.Name)
+			}
+			r.Accepted = *r.Passed
+		}
+
+		passed = passed && r.Accepted
+		evaluated = append(evaluated, r)
+	}
+
+	now := time.Now()
+	i.Results = evaluated
+	i.InspectedAt = &now
+	if passed {
+		i.Status = InspectionPassed
+	} else {
+		i.Status = InspectionFailed
+	}
+
+	return nil
+}
+
+func (i *Inspection) hasCharacteristic(name string) bool {
+	for _, c := range i.Characteristics {
+		if c.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,149 @@
+package quality
+
+import (
+	"context"
+	"time"
+
+	"github.com/aboglioli/big-brother/infrastructure/db"
+	"github.com/aboglioli/big-brother/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type InspectionRepository interface {
+	FindByID(id string) (*Inspection, error)
+	FindByStatus(status string) ([]*Inspection, error)
+	FindByLot(lotID string) ([]*Inspection, error)
+
+	Insert(*Inspection) error
+	Update(*Inspection) error
+}
+
+type inspectionRepository struct {
+	collection *mongo.Collection
+}
+
+func NewInspectionRepository() (InspectionRepository, error) {
+	db, err := db.Get("Quality")
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &inspectionRepository{
+		collection: db.Collection("inspection"),
+	}, nil
+}
+
+func (r *inspectionRepository) FindByID(id string) (*Inspection, error) {
+	path := "quality/inspection_repository.FindByID"
+	ctx := context.Background()
+
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errors.NewInternal("OBJECTID_FROM_HEX").SetPath(path).SetRef(err)
+	}
+
+	filter := bson.M{
+		"_id": objID,
+	}
+
+	res := r.collection.FindOne(ctx, filter)
+	if res.Err() != nil {
+		return nil, errors.NewInternal("FIND_ONE").SetPath(path).SetRef(res.Err())
+	}
+
+	var i Inspection
+	if err := res.Decode(&i); err != nil {
+		return nil, errors.NewInternal("DECODE").SetPath(path).SetRef(err)
+	}
+
+	return &i, nil
+}
+
+func (r *inspectionRepository) FindByStatus(status string) ([]*Inspection, error) {
+	return r.find("quality/inspection_repository.FindByStatus", bson.M{
+		"status": status,
+	})
+}
+
+func (r *inspectionRepository) FindByLot(lotID string) ([]*Inspection, error) {
+	path := "quality/inspection_repository.FindByLot"
+
+	objID, err := primitive.ObjectIDFromHex(lotID)
+	if err != nil {
+		return nil, errors.NewInternal("OBJECTID_FROM_HEX").SetPath(path).SetRef(err)
+	}
+
+	return r.find(path, bson.M{
+		"lot": objID,
+	})
+}
+
+func (r *inspectionRepository) Insert(i *Inspection) error {
+	ctx := context.Background()
+
+	_, err := r.collection.InsertOne(ctx, i)
+	if err != nil {
+		return errors.NewInternal("INSERT_ONE").SetPath("quality/inspection_repository.Insert").SetRef(err)
+	}
+
+	return nil
+}
+
+func (r *inspectionRepository) Update(i *Inspection) error {
+	path := "quality/inspection_repository.Update"
+	ctx := context.Background()
+
+	if i.ID.IsZero() {
+		return errors.NewInternal("INVALID_OBJECTID").SetPath(path)
+	}
+
+	i.UpdatedAt = time.Now()
+
+	filter := bson.M{
+		"_id": i.ID,
+	}
+
+	update := bson.M{
+		"$set": i,
+	}
+
+	_, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return errors.NewInternal("UPDATE_ONE").SetPath(path).SetRef(err)
+	}
+
+	return nil
+}
+
+func (r *inspectionRepository) find(path string, filter bson.M) ([]*Inspection, error) {
+	ctx := context.Background()
+
+	opts := options.Find().SetSort(bson.M{"createdAt": 1})
+
+	cur, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, errors.NewInternal("FIND").SetPath(path).SetRef(err)
+	}
+	defer cur.Close(ctx)
+
+	inspections := make([]*Inspection, 0)
+	for cur.Next(ctx) {
+		var i Inspection
+
+		if err := cur.Decode(&i); err != nil {
+			return nil, errors.NewInternal("CUR_DECODE").SetPath(path).SetRef(err)
+		}
+
+		inspections = append(inspections, &i)
+	}
+
+	if err := cur.Err(); err != nil {
+		return nil, errors.NewInternal("CUR").SetPath(path).SetRef(err)
+	}
+
+	return inspections, nil
+}
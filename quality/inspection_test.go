@@ -0,0 +1,105 @@
+package quality
+
+import (
+	"testing"
+
+	"github.com/aboglioli/big-brother/pkg/quantity"
+	"github.com/aboglioli/big-brother/pkg/tests/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func newPlan() *Plan {
+	p := NewPlan(primitive.NewObjectID())
+	p.Stages = []string{StageReceipt}
+	p.Characteristics = []Characteristic{
+		Characteristic{Name: "weight", Type: CharacteristicMeasure, Min: &quantity.Quantity{990, "g"}, Max: &quantity.Quantity{1.01, "kg"}},
+		Characteristic{Name: "sealed", Type: CharacteristicCheck},
+	}
+	return p
+}
+
+func TestPlanValidateSchema(t *testing.T) {
+	p := newPlan()
+	assert.Ok(t, p.ValidateSchema())
+
+	p.Stages = []string{"SHIPPING"}
+	p.Characteristics = append(p.Characteristics,
+		Characteristic{Name: "sealed", Type: CharacteristicCheck},
+		Characteristic{Name: "length", Type: CharacteristicMeasure},
+		Characteristic{Name: "volume", Type: CharacteristicMeasure, Min: &quantity.Quantity{2, "l"}, Max: &quantity.Quantity{1, "l"}},
+		Characteristic{Name: "color", Type: "COLOR"},
+	)
+
+	err := p.ValidateSchema()
+	assert.ErrValidation(t, err, "stages", "INVALID")
+	assert.ErrValidation(t, err, "characteristic", "INVALID_NAME")
+	assert.ErrValidation(t, err, "characteristic", "TOLERANCE_REQUIRED")
+	assert.ErrValidation(t, err, "characteristic", "INVALID_TOLERANCE")
+	assert.ErrValidation(t, err, "characteristic", "INVALID_TYPE")
+}
+
+func TestCharacteristicAccepts(t *testing.T) {
+	c := newPlan().Characteristics[0]
+
+	assert.Assert(t, c.Accepts(quantity.Quantity{1, "kg"}))
+	assert.Assert(t, c.Accepts(quantity.Quantity{990, "g"}), "Limits are included")
+	assert.Assert(t, c.Accepts(quantity.Quantity{1010, "g"}), "Limits are included")
+	assert.Assert(t, !c.Accepts(quantity.Quantity{989, "g"}))
+	assert.Assert(t, !c.Accepts(quantity.Quantity{1, "l"}), "Incompatible units")
+
+	c.Min = nil
+	assert.Assert(t, c.Accepts(quantity.Quantity{1, "g"}), "Without lower limit")
+}
+
+func TestRecordInspection(t *testing.T) {
+	yes, no := true, false
+
+	t.Run("Invalid results", func(t *testing.T) {
+		i := NewInspection(newPlan(), primitive.NewObjectID(), StageReceipt)
+
+		err := i.Record([]Result{Result{Characteristic: "weight", Value: &quantity.Quantity{1, "kg"}}})
+		assert.ErrCode(t, err, "MISSING_RESULT")
+
+		err = i.Record([]Result{
+			Result{Characteristic: "weight", Value: &quantity.Quantity{1, "kg"}},
+			Result{Characteristic: "sealed", Passed: &yes},
+			Result{Characteristic: "color", Passed: &yes},
+		})
+		assert.ErrCode(t, err, "UNKNOWN_CHARACTERISTIC")
+
+		err = i.Record([]Result{
+			Result{Characteristic: "weight", Passed: &yes},
+			Result{Characteristic: "sealed", Passed: &yes},
+		})
+		assert.ErrCode(t, err, "INVALID_RESULT")
+		assert.Equal(t, i.Status, InspectionPending)
+	})
+
+	t.Run("Passed", func(t *testing.T) {
+		i := NewInspection(newPlan(), primitive.NewObjectID(), StageReceipt)
+
+		err := i.Record([]Result{
+			Result{Characteristic: "weight", Value: &quantity.Quantity{1005, "g"}},
+			Result{Characteristic: "sealed", Passed: &yes},
+		})
+		assert.Ok(t, err)
+		assert.Equal(t, i.Status, InspectionPassed)
+		assert.NotNil(t, i.InspectedAt)
+
+		err = i.Record(i.Results)
+		assert.ErrCode(t, err, "INSPECTION_ALREADY_RECORDED")
+	})
+
+	t.Run("Failed", func(t *testing.T) {
+		i := NewInspection(newPlan(), primitive.NewObjectID(), StageReceipt)
+
+		err := i.Record([]Result{
+			Result{Characteristic: "weight", Value: &quantity.Quantity{1005, "g"}},
+			Result{Characteristic: "sealed", Passed: &no},
+		})
+		assert.Ok(t, err)
+		assert.Equal(t, i.Status, InspectionFailed)
+		assert.Assert(t, i.Results[0].Accepted)
+		assert.Assert(t, !i.Results[1].Accepted)
+	})
+}
@@ -0,0 +1,125 @@
+package quality
+
+import (
+	"time"
+
+	"github.com/aboglioli/big-brother/pkg/errors"
+	"github.com/aboglioli/big-brother/pkg/quantity"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	StageReceipt    = "RECEIPT"
+	StageProduction = "PRODUCTION"
+
+	CharacteristicMeasure = "MEASURE"
+	CharacteristicCheck   = "CHECK"
+)
+
+// Characteristic is an attribute inspected in each lot. Measures are
+// quantities within the tolerance [Min, Max], where both limits are optional.
+// Checks are passed or failed by the inspector.
+type Characteristic struct {
+	Name string             `json:"name" bson:"name"`
+	Type string             `json:"type" bson:"type"`
+	Min  *quantity.Quantity `json:"min" bson:"min"`
+	Max  *quantity.Quantity `json:"max" bson:"max"`
+}
+
+// Accepts returns true if the measured value is within the tolerance.
+func (c *Characteristic) Accepts(v quantity.Quantity) bool {
+	if c.Min != nil {
+		if less, err := v.Less(*c.Min); err != nil || less {
+			return false
+		}
+	}
+	if c.Max != nil {
+		if greater, err := v.Greater(*c.Max); err != nil || greater {
+			return false
+		}
+	}
+	return true
+}
+
+// Plan is the quality control plan of a composition. Lots received or
+// produced, as set in Stages, are inspected against its characteristics.
+type Plan struct {
+	ID              primitive.ObjectID `json:"id" bson:"_id"`
+	Composition     primitive.ObjectID `json:"composition" bson:"composition"`
+	Stages          []string           `json:"stages" bson:"stages"`
+	Characteristics []Characteristic   `json:"characteristics" bson:"characteristics"`
+
+	CreatedAt time.Time `json:"createdAt" bson:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt" bson:"updatedAt"`
+}
+
+func NewPlan(compID primitive.ObjectID) *Plan {
+	return &Plan{
+		ID:              primitive.NewObjectID(),
+		Composition:     compID,
+		Stages:          make([]string, 0),
+		Characteristics: make([]Characteristic, 0),
+		CreatedAt:       time.Now(),
+		UpdatedAt:       time.Now(),
+	}
+}
+
+func (p *Plan) Inspects(stage string) bool {
+	for _, s := range p.Stages {
+		if s == stage {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Plan) ValidateSchema() error {
+	err := errors.NewValidation("VALIDATE_SCHEMA").SetPath("quality/plan.ValidateSchema")
+
+	if len(p.Stages) == 0 {
+		err.Add("stages", "REQUIRED")
+	}
+	for _, s := range p.Stages {
+		if s != StageReceipt && s != StageProduction {
+			err.AddWithMessage("stages", "INVALID", "%s", s)
+		}
+	}
+
+	if len(p.Characteristics) == 0 {
+		err.Add("characteristics", "REQUIRED")
+	}
+
+	names := make(map[string]bool)
+	for i, c := range p.Characteristics {
+		if c.Name == "" || names[c.Name] {
+			err.AddWithMessage("characteristic", "INVALID_NAME", "characteristic %d", i)
+		}
+		names[c.Name] = true
+
+		switch c.Type {
+		case CharacteristicCheck:
+			if c.Min != nil || c.Max != nil {
+				err.AddWithMessage("characteristic", "INVALID_TOLERANCE", "characteristic %d", i)
+			}
+		case CharacteristicMeasure:
+			if c.Min == nil && c.Max == nil {
+				err.AddWithMessage("characteristic", "TOLERANCE_REQUIRED", "characteristic %d", i)
+			}
+			if (c.Min != nil && !c.Min.IsValid()) || (c.Max != nil && !c.Max.IsValid()) {
+				err.AddWithMessage("characteristic", "INVALID_TOLERANCE", "characteristic %d", i)
+			} else if c.Min != nil && c.Max != nil {
+				if greater, cmpErr := c.Min.Greater(*c.Max); cmpErr != nil || greater {
+					err.AddWithMessage("characteristic", "INVALID_TOLERANCE", "characteristic %d", i)
+				}
+			}
+		default:
+			err.AddWithMessage("characteristic", "INVALID_TYPE", "characteristic %d", i)
+		}
+	}
+
+	if err.Size() > 0 {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,119 @@
+package quality
+
+import (
+	"context"
+	"time"
+
+	"github.com/aboglioli/big-brother/infrastructure/db"
+	"github.com/aboglioli/big-brother/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type PlanRepository interface {
+	FindByComposition(compID string) (*Plan, error)
+
+	Insert(*Plan) error
+	Update(*Plan) error
+	Delete(id string) error
+}
+
+type planRepository struct {
+	collection *mongo.Collection
+}
+
+func NewPlanRepository() (PlanRepository, error) {
+	db, err := db.Get("Quality")
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &planRepository{
+		collection: db.Collection("plan"),
+	}, nil
+}
+
+func (r *planRepository) FindByComposition(compID string) (*Plan, error) {
+	path := "quality/plan_repository.FindByComposition"
+	ctx := context.Background()
+
+	objID, err := primitive.ObjectIDFromHex(compID)
+	if err != nil {
+		return nil, errors.NewInternal("OBJECTID_FROM_HEX").SetPath(path).SetRef(err)
+	}
+
+	filter := bson.M{
+		"composition": objID,
+	}
+
+	res := r.collection.FindOne(ctx, filter)
+	if res.Err() != nil {
+		return nil, errors.NewInternal("FIND_ONE").SetPath(path).SetRef(res.Err())
+	}
+
+	var p Plan
+	if err := res.Decode(&p); err != nil {
+		return nil, errors.NewInternal("DECODE").SetPath(path).SetRef(err)
+	}
+
+	return &p, nil
+}
+
+func (r *planRepository) Insert(p *Plan) error {
+	ctx := context.Background()
+
+	_, err := r.collection.InsertOne(ctx, p)
+	if err != nil {
+		return errors.NewInternal("INSERT_ONE").SetPath("quality/plan_repository.Insert").SetRef(err)
+	}
+
+	return nil
+}
+
+func (r *planRepository) Update(p *Plan) error {
+	path := "quality/plan_repository.Update"
+	ctx := context.Background()
+
+	if p.ID.IsZero() {
+		return errors.NewInternal("INVALID_OBJECTID").SetPath(path)
+	}
+
+	p.UpdatedAt = time.Now()
+
+	filter := bson.M{
+		"_id": p.ID,
+	}
+
+	update := bson.M{
+		"$set": p,
+	}
+
+	_, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return errors.NewInternal("UPDATE_ONE").SetPath(path).SetRef(err)
+	}
+
+	return nil
+}
+
+func (r *planRepository) Delete(id string) error {
+	path := "quality/plan_repository.Delete"
+	ctx := context.Background()
+
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return errors.NewInternal("OBJECTID_FROM_HEX").SetPath(path).SetRef(err)
+	}
+
+	filter := bson.M{
+		"_id": objID,
+	}
+
+	if _, err := r.collection.DeleteOne(ctx, filter); err != nil {
+		return errors.NewInternal("DELETE_ONE").SetPath(path).SetRef(err)
+	}
+
+	return nil
+}
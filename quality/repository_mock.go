@@ -0,0 +1,159 @@
+package quality
+
+import (
+	"time"
+
+	"github.com/aboglioli/big-brother/pkg/errors"
+	"github.com/aboglioli/big-brother/pkg/tests/mock"
+)
+
+type mockPlanRepository struct {
+	mock.Mock
+	plans []*Plan
+}
+
+func newMockPlanRepository() *mockPlanRepository {
+	return &mockPlanRepository{}
+}
+
+// Helpers
+func (r *mockPlanRepository) Clean() {
+	r.plans = make([]*Plan, 0)
+}
+
+// Implementation
+func (r *mockPlanRepository) FindByComposition(compID string) (*Plan, error) {
+	r.Called("FindByComposition", compID)
+
+	for _, p := range r.plans {
+		if p.Composition.Hex() == compID {
+			return copyPlan(p), nil
+		}
+	}
+
+	return nil, errors.NewInternal("NOT_FOUND").SetPath("quality/repository_mock.FindByComposition")
+}
+
+func (r *mockPlanRepository) Insert(p *Plan) error {
+	r.Called("Insert", p)
+
+	r.plans = append(r.plans, copyPlan(p))
+
+	return nil
+}
+
+func (r *mockPlanRepository) Update(p *Plan) error {
+	r.Called("Update", p)
+
+	for _, plan := range r.plans {
+		if plan.ID.Hex() == p.ID.Hex() {
+			*plan = *copyPlan(p)
+			plan.UpdatedAt = time.Now()
+			break
+		}
+	}
+
+	return nil
+}
+
+func (r *mockPlanRepository) Delete(id string) error {
+	r.Called("Delete", id)
+
+	for i, p := range r.plans {
+		if p.ID.Hex() == id {
+			r.plans = append(r.plans[:i], r.plans[i+1:]...)
+			break
+		}
+	}
+
+	return nil
+}
+
+type mockInspectionRepository struct {
+	mock.Mock
+	inspections []*Inspection
+}
+
+func newMockInspectionRepository() *mockInspectionRepository {
+	return &mockInspectionRepository{}
+}
+
+// Helpers
+func (r *mockInspectionRepository) Clean() {
+	r.inspections = make([]*Inspection, 0)
+}
+
+// Implementation
+func (r *mockInspectionRepository) FindByID(id string) (*Inspection, error) {
+	r.Called("FindByID", id)
+
+	for _, i := range r.inspections {
+		if i.ID.Hex() == id {
+			return copyInspection(i), nil
+		}
+	}
+
+	return nil, errors.NewInternal("NOT_FOUND").SetPath("quality/repository_mock.FindByID")
+}
+
+func (r *mockInspectionRepository) FindByStatus(status string) ([]*Inspection, error) {
+	r.Called("FindByStatus", status)
+
+	inspections := make([]*Inspection, 0)
+	for _, i := range r.inspections {
+		if i.Status == status {
+			inspections = append(inspections, copyInspection(i))
+		}
+	}
+
+	return inspections, nil
+}
+
+func (r *mockInspectionRepository) FindByLot(lotID string) ([]*Inspection, error) {
+	r.Called("FindByLot", lotID)
+
+	inspections := make([]*Inspection, 0)
+	for _, i := range r.inspections {
+		if i.Lot.Hex() == lotID {
+			inspections = append(inspections, copyInspection(i))
+		}
+	}
+
+	return inspections, nil
+}
+
+func (r *mockInspectionRepository) Insert(i *Inspection) error {
+	r.Called("Insert", i)
+
+	r.inspections = append(r.inspections, copyInspection(i))
+
+	return nil
+}
+
+func (r *mockInspectionRepository) Update(i *Inspection) error {
+	r.Called("Update", i)
+
+	for _, inspection := range r.inspections {
+		if inspection.ID.Hex() == i.ID.Hex() {
+			*inspection = *copyInspection(i)
+			inspection.UpdatedAt = time.Now()
+			break
+		}
+	}
+
+	return nil
+}
+
+func copyPlan(p *Plan) *Plan {
+	copy := *p
+	copy.Stages = append([]string(nil), p.Stages...)
+	copy.Characteristics = append([]Characteristic(nil), p.Characteristics...)
+	return &copy
+}
+
+func copyInspection(i *Inspection) *Inspection {
+	copy := *i
+	copy.Characteristics = append([]Characteristic(nil), i.Characteristics...)
+	copy.Results = append([]Result(nil), i.Results...)
+	return &copy
+}
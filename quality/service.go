@@ -0,0 +1,243 @@
+package quality
+
+import (
+	"github.com/aboglioli/big-brother/composition"
+	"github.com/aboglioli/big-brother/pkg/errors"
+	"github.com/aboglioli/big-brother/pkg/events"
+	"github.com/aboglioli/big-brother/stock"
+)
+
+type Service interface {
+	GetPlan(compID string) (*Plan, error)
+	SetPlan(compID string, req *PlanRequest) (*Plan, error)
+	DeletePlan(compID string) error
+
+	GetInspection(id string) (*Inspection, error)
+	FindPending() ([]*Inspection, error)
+	FindByLot(lotID string) ([]*Inspection, error)
+	Open(lotID string) (*Inspection, error)
+	OpenForLot(lot *stock.Lot) (*Inspection, error)
+	Record(id string, req *RecordRequest) (*Inspection, error)
+}
+
+type service struct {
+	planRepository       PlanRepository
+	inspectionRepository InspectionRepository
+	compositionService   composition.Service
+	stockService         stock.Service
+	eventMgr             events.Manager
+}
+
+func NewService(planRepo PlanRepository, inspectionRepo InspectionRepository, compServ composition.Service, stockServ stock.Service, e events.Manager) Service {
+	return &service{
+		planRepository:       planRepo,
+		inspectionRepository: inspectionRepo,
+		compositionService:   compServ,
+		stockService:         stockServ,
+		eventMgr:             e,
+	}
+}
+
+func (s *service) GetPlan(compID string) (*Plan, error) {
+	p, err := s.planRepository.FindByComposition(compID)
+	if err != nil {
+		return nil, errors.NewStatus("PLAN_NOT_FOUND").SetPath("quality/service.GetPlan").SetStatus(404).SetRef(err)
+	}
+	return p, nil
+}
+
+type PlanRequest struct {
+	Stages          []string         `json:"stages" binding:"required"`
+	Characteristics []Characteristic `json:"characteristics" binding:"required"`
+}
+
+// SetPlan creates or replaces the quality control plan of a composition.
+func (s *service) SetPlan(compID string, req *PlanRequest) (*Plan, error) {
+	path := "quality/service.SetPlan"
+
+	comp, err := s.compositionService.GetByID(compID)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := s.planRepository.FindByComposition(compID)
+	isNew := err != nil
+	if isNew {
+		p = NewPlan(comp.ID)
+	}
+	p.Stages = append([]string(nil), req.Stages...)
+	p.Characteristics = append([]Characteristic(nil), req.Characteristics...)
+
+	if err := p.ValidateSchema(); err != nil {
+		return nil, err
+	}
+
+	if isNew {
+		err = s.planRepository.Insert(p)
+	} else {
+		err = s.planRepository.Update(p)
+	}
+	if err != nil {
+		return nil, errors.NewStatus("SAVE").SetPath(path).SetRef(err)
+	}
+
+	return p, nil
+}
+
+func (s *service) DeletePlan(compID string) error {
+	p, err := s.GetPlan(compID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.planRepository.Delete(p.ID.Hex()); err != nil {
+		return errors.NewStatus("DELETE").SetPath("quality/service.DeletePlan").SetRef(err)
+	}
+
+	return nil
+}
+
+func (s *service) GetInspection(id string) (*Inspection, error) {
+	i, err := s.inspectionRepository.FindByID(id)
+	if err != nil {
+		return nil, errors.NewStatus("INSPECTION_NOT_FOUND").SetPath("quality/service.GetInspection").SetStatus(404).SetRef(err)
+	}
+	return i, nil
+}
+
+func (s *service) FindPending() ([]*Inspection, error) {
+	inspections, err := s.inspectionRepository.FindByStatus(InspectionPending)
+	if err != nil {
+		return nil, errors.NewStatus("FIND_INSPECTIONS").SetPath("quality/service.FindPending").SetRef(err)
+	}
+	return inspections, nil
+}
+
+func (s *service) FindByLot(lotID string) ([]*Inspection, error) {
+	inspections, err := s.inspectionRepository.FindByLot(lotID)
+	if err != nil {
+		return nil, errors.NewStatus("FIND_INSPECTIONS").SetPath("quality/service.FindByLot").SetRef(err)
+	}
+	return inspections, nil
+}
+
+// Open opens an inspection of a lot at the stage of its origin: goods
+// receipt or production.
+func (s *service) Open(lotID string) (*Inspection, error) {
+	path := "quality/service.Open"
+
+	lot, err := s.stockService.GetLot(lotID)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.GetPlan(lot.Composition.Hex()); err != nil {
+		return nil, err
+	}
+
+	i, err := s.OpenForLot(lot)
+	if err != nil {
+		return nil, err
+	}
+
+	if i == nil {
+		return nil, errors.NewStatus("STAGE_NOT_INSPECTED").SetPath(path).SetMessage("%s", lot.Origin)
+	}
+
+	return i, nil
+}
+
+// OpenForLot opens an inspection of a new lot if the plan of its composition
+// inspects the stage of its origin. Otherwise, nil is returned.
+func (s *service) OpenForLot(lot *stock.Lot) (*Inspection, error) {
+	path := "quality/service.OpenForLot"
+
+	p, err := s.planRepository.FindByComposition(lot.Composition.Hex())
+	if err != nil {
+		return nil, nil
+	}
+
+	stage := StageReceipt
+	if lot.Origin == stock.OriginProduction {
+		stage = StageProduction
+	}
+
+	if !p.Inspects(stage) {
+		return nil, nil
+	}
+
+	inspections, err := s.FindByLot(lot.ID.Hex())
+	if err != nil {
+		return nil, err
+	}
+	for _, i := range inspections {
+		if i.Status == InspectionPending {
+			return nil, errors.NewStatus("INSPECTION_ALREADY_OPEN").SetPath(path).SetMessage("%s", i.ID.Hex())
+		}
+	}
+
+	i := NewInspection(p, lot.ID, stage)
+	if err := s.inspectionRepository.Insert(i); err != nil {
+		return nil, errors.NewStatus("INSERT").SetPath(path).SetRef(err)
+	}
+
+	return i, nil
+}
+
+type RecordRequest struct {
+	Results []Result `json:"results" binding:"required"`
+	Notes   string   `json:"notes"`
+}
+
+// Record records the results of a pending inspection. Lots failing the
+// inspection are quarantined: they are blocked, so they can't be consumed or
+// issued.
+/**
+* @api {topic} quality.inspection.failed quality.inspection.failed
+* @apiName InspectionFailed
+* @apiGroup RabbitMQ
+*
+* @apiDescription Emits a new event when a lot fails an inspection. The lot
+* is blocked. "quality.inspection.passed" is emitted with type
+* "InspectionPassed" otherwise.
+*
+* @apiSuccessExample {json} Body
+* {
+* 	"type": "InspectionFailed",
+* 	"inspection": inspection data
+* }
+ */
+func (s *service) Record(id string, req *RecordRequest) (*Inspection, error) {
+	path := "quality/service.Record"
+
+	i, err := s.GetInspection(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := i.Record(req.Results); err != nil {
+		return nil, err
+	}
+	i.Notes = req.Notes
+
+	// The lot is blocked before the inspection is saved, so a failure leaves
+	// the inspection pending and it can be recorded again.
+	event, opts := NewInspectionPassedEvent(i)
+	if i.Status == InspectionFailed {
+		if _, err := s.stockService.BlockLot(i.Lot.Hex()); err != nil {
+			return nil, err
+		}
+
+		event, opts = NewInspectionFailedEvent(i)
+	}
+
+	if err := s.inspectionRepository.Update(i); err != nil {
+		return nil, errors.NewStatus("UPDATE").SetPath(path).SetRef(err)
+	}
+
+	if err := s.eventMgr.Publish(event, opts); err != nil {
+		return nil, errors.NewStatus("FAILED_TO_PUBLISH").SetPath(path).SetRef(err)
+	}
+
+	return i, nil
+}
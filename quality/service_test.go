@@ -0,0 +1,118 @@
+package quality
+
+import (
+	"testing"
+
+	"github.com/aboglioli/big-brother/composition"
+	"github.com/aboglioli/big-brother/pkg/events"
+	"github.com/aboglioli/big-brother/pkg/quantity"
+	"github.com/aboglioli/big-brother/pkg/tests/assert"
+	"github.com/aboglioli/big-brother/stock"
+)
+
+func newComposition(unit quantity.Quantity) *composition.Composition {
+	comp := composition.NewComposition()
+	comp.Unit = unit
+	comp.Stock = quantity.Quantity{0, unit.Unit}
+	comp.Validated = true
+	return comp
+}
+
+func TestInspectLots(t *testing.T) {
+	compRepo, eventMgr := composition.NewMockRepository(), events.GetMockManager()
+	compServ := composition.NewService(compRepo)
+	stockServ := stock.NewService(stock.NewMockLotRepository(), stock.NewMockMovementRepository(), stock.NewMockCountRepository(), compServ)
+	serv := NewService(newMockPlanRepository(), newMockInspectionRepository(), compServ, stockServ, eventMgr)
+
+	flour := newComposition(quantity.Quantity{1, "kg"})
+	sugar := newComposition(quantity.Quantity{1, "kg"})
+	compRepo.Insert(flour)
+	compRepo.Insert(sugar)
+
+	_, err := serv.SetPlan(flour.ID.Hex(), &PlanRequest{Stages: []string{StageProduction}})
+	assert.ErrValidation(t, err, "characteristics", "REQUIRED")
+
+	p, err := serv.SetPlan(flour.ID.Hex(), &PlanRequest{
+		Stages: []string{StageReceipt},
+		Characteristics: []Characteristic{
+			Characteristic{Name: "moisture", Type: CharacteristicMeasure, Max: &quantity.Quantity{150, "g"}},
+		},
+	})
+	assert.Ok(t, err)
+	assert.Equal(t, p.Composition, flour.ID)
+
+	t.Run("Lots without plan are not inspected", func(t *testing.T) {
+		lot, err := stockServ.Receive(&stock.ReceiveRequest{Composition: sugar.ID.Hex(), Quantity: quantity.Quantity{5, "kg"}})
+		assert.Ok(t, err)
+
+		i, err := serv.OpenForLot(lot)
+		assert.Ok(t, err)
+		assert.Nil(t, i)
+
+		_, err = serv.Open(lot.ID.Hex())
+		assert.ErrCode(t, err, "PLAN_NOT_FOUND")
+	})
+
+	t.Run("Failed inspection blocks the lot", func(t *testing.T) {
+		lot, _ := stockServ.Receive(&stock.ReceiveRequest{Composition: flour.ID.Hex(), Quantity: quantity.Quantity{5, "kg"}})
+
+		i, err := serv.OpenForLot(lot)
+		assert.Ok(t, err)
+		assert.NotNil(t, i)
+		assert.Equal(t, i.Stage, StageReceipt)
+
+		_, err = serv.Open(lot.ID.Hex())
+		assert.ErrCode(t, err, "INSPECTION_ALREADY_OPEN")
+
+		pending, _ := serv.FindPending()
+		assert.Equal(t, len(pending), 1)
+
+		eventMgr.Clean()
+
+		i, err = serv.Record(i.ID.Hex(), &RecordRequest{Results: []Result{
+			Result{Characteristic: "moisture", Value: &quantity.Quantity{0.2, "kg"}},
+		}})
+		assert.Ok(t, err)
+		assert.Equal(t, i.Status, InspectionFailed)
+
+		lot, _ = stockServ.GetLot(lot.ID.Hex())
+		assert.Assert(t, lot.Blocked, "Lot should be quarantined")
+
+		comp, _ := compServ.GetByID(flour.ID.Hex())
+		assert.Assert(t, comp.Stock.Equals(quantity.Quantity{5, "kg"}))
+		assert.Assert(t, comp.Available().Equals(quantity.Quantity{0, "kg"}))
+		_, err = compServ.Reserve(flour.ID.Hex(), quantity.Quantity{1, "kg"})
+		assert.ErrCode(t, err, "INSUFFICIENT_AVAILABLE_STOCK", "Quarantined stock can't be reserved")
+
+		msgs := eventMgr.Messages()
+		assert.Equal(t, msgs[len(msgs)-1].Type(), "InspectionFailed")
+		assert.Equal(t, msgs[len(msgs)-1].Key, "quality.inspection.failed")
+
+		pending, _ = serv.FindPending()
+		assert.Equal(t, len(pending), 0)
+	})
+
+	t.Run("Passed inspection", func(t *testing.T) {
+		lot, _ := stockServ.Receive(&stock.ReceiveRequest{Composition: flour.ID.Hex(), Quantity: quantity.Quantity{5, "kg"}})
+
+		i, err := serv.Open(lot.ID.Hex())
+		assert.Ok(t, err)
+
+		eventMgr.Clean()
+
+		i, err = serv.Record(i.ID.Hex(), &RecordRequest{Results: []Result{
+			Result{Characteristic: "moisture", Value: &quantity.Quantity{120, "g"}},
+		}})
+		assert.Ok(t, err)
+		assert.Equal(t, i.Status, InspectionPassed)
+
+		lot, _ = stockServ.GetLot(lot.ID.Hex())
+		assert.Assert(t, !lot.Blocked)
+
+		msgs := eventMgr.Messages()
+		assert.Equal(t, msgs[len(msgs)-1].Type(), "InspectionPassed")
+
+		inspections, _ := serv.FindByLot(lot.ID.Hex())
+		assert.Equal(t, len(inspections), 1)
+	})
+}
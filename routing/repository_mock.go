@@ -0,0 +1,174 @@
+package routing
+
+import (
+	"sort"
+	"time"
+
+	"github.com/aboglioli/big-brother/pkg/errors"
+	"github.com/aboglioli/big-brother/pkg/tests/mock"
+)
+
+type mockWorkCenterRepository struct {
+	mock.Mock
+	workCenters []*WorkCenter
+}
+
+// NewMockWorkCenterRepository returns an in-memory WorkCenterRepository. It is
+// exported to be used by other packages' tests.
+func NewMockWorkCenterRepository() *mockWorkCenterRepository {
+	return &mockWorkCenterRepository{}
+}
+
+// Helpers
+func (r *mockWorkCenterRepository) Clean() {
+	r.workCenters = make([]*WorkCenter, 0)
+}
+
+// Implementation
+func (r *mockWorkCenterRepository) FindAll() ([]*WorkCenter, error) {
+	r.Called("FindAll")
+
+	workCenters := make([]*WorkCenter, 0, len(r.workCenters))
+	for _, w := range r.workCenters {
+		copy := *w
+		workCenters = append(workCenters, &copy)
+	}
+
+	sort.Slice(workCenters, func(i, j int) bool {
+		return workCenters[i].Name < workCenters[j].Name
+	})
+
+	return workCenters, nil
+}
+
+func (r *mockWorkCenterRepository) FindByID(id string) (*WorkCenter, error) {
+	r.Called("FindByID", id)
+
+	for _, w := range r.workCenters {
+		if w.ID.Hex() == id {
+			copy := *w
+			return &copy, nil
+		}
+	}
+
+	return nil, errors.NewInternal("NOT_FOUND").SetPath("routing/repository_mock.FindByID")
+}
+
+func (r *mockWorkCenterRepository) Insert(w *WorkCenter) error {
+	r.Called("Insert", w)
+
+	copy := *w
+	r.workCenters = append(r.workCenters, &copy)
+
+	return nil
+}
+
+func (r *mockWorkCenterRepository) Update(w *WorkCenter) error {
+	r.Called("Update", w)
+
+	for _, workCenter := range r.workCenters {
+		if workCenter.ID.Hex() == w.ID.Hex() {
+			*workCenter = *w
+			workCenter.UpdatedAt = time.Now()
+			break
+		}
+	}
+
+	return nil
+}
+
+func (r *mockWorkCenterRepository) Delete(id string) error {
+	r.Called("Delete", id)
+
+	for i, w := range r.workCenters {
+		if w.ID.Hex() == id {
+			r.workCenters = append(r.workCenters[:i], r.workCenters[i+1:]...)
+			break
+		}
+	}
+
+	return nil
+}
+
+type mockRoutingRepository struct {
+	mock.Mock
+	routings []*Routing
+}
+
+// NewMockRoutingRepository returns an in-memory RoutingRepository. It is
+// exported to be used by other packages' tests.
+func NewMockRoutingRepository() *mockRoutingRepository {
+	return &mockRoutingRepository{}
+}
+
+// Helpers
+func (r *mockRoutingRepository) Clean() {
+	r.routings = make([]*Routing, 0)
+}
+
+// Implementation
+func (r *mockRoutingRepository) FindByComposition(compID string) (*Routing, error) {
+	r.Called("FindByComposition", compID)
+
+	for _, routing := range r.routings {
+		if routing.Composition.Hex() == compID {
+			return copyRouting(routing), nil
+		}
+	}
+
+	return nil, errors.NewInternal("NOT_FOUND").SetPath("routing/repository_mock.FindByComposition")
+}
+
+func (r *mockRoutingRepository) FindByWorkCenter(workCenterID string) ([]*Routing, error) {
+	r.Called("FindByWorkCenter", workCenterID)
+
+	routings := make([]*Routing, 0)
+	for _, routing := range r.routings {
+		if routing.UsesWorkCenter(workCenterID) {
+			routings = append(routings, copyRouting(routing))
+		}
+	}
+
+	return routings, nil
+}
+
+func (r *mockRoutingRepository) Insert(routing *Routing) error {
+	r.Called("Insert", routing)
+
+	r.routings = append(r.routings, copyRouting(routing))
+
+	return nil
+}
+
+func (r *mockRoutingRepository) Update(routing *Routing) error {
+	r.Called("Update", routing)
+
+	for _, saved := range r.routings {
+		if saved.ID.Hex() == routing.ID.Hex() {
+			*saved = *copyRouting(routing)
+			saved.UpdatedAt = time.Now()
+			break
+		}
+	}
+
+	return nil
+}
+
+func (r *mockRoutingRepository) Delete(id string) error {
+	r.Called("Delete", id)
+
+	for i, routing := range r.routings {
+		if routing.ID.Hex() == id {
+			r.routings = append(r.routings[:i], r.routings[i+1:]...)
+			break
+		}
+	}
+
+	return nil
+}
+
+func copyRouting(r *Routing) *Routing {
+	copy := *r
+	copy.Operations = append([]Operation(nil), r.Operations...)
+	return &copy
+}
@@ -0,0 +1,104 @@
+package routing
+
+import (
+	"math"
+	"time"
+
+	"github.com/aboglioli/big-brother/pkg/errors"
+	"github.com/aboglioli/big-brother/pkg/quantity"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Operation is a step of a routing. Setup is the time, in minutes, needed to
+// prepare the work center once per order. Run is the time, in minutes, needed
+// for each unit of the composition.
+type Operation struct {
+	Name       string             `json:"name" bson:"name"`
+	WorkCenter primitive.ObjectID `json:"workCenter" bson:"workCenter"`
+	Setup      float64            `json:"setup" bson:"setup"`
+	Run        float64            `json:"run" bson:"run"`
+}
+
+// Hours returns the time needed to produce the given number of units.
+func (o *Operation) Hours(units float64) float64 {
+	return (o.Setup + o.Run*units) / 60
+}
+
+// Routing is the ordered list of operations needed to produce a composition.
+type Routing struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id"`
+	Composition primitive.ObjectID `json:"composition" bson:"composition"`
+	Operations  []Operation        `json:"operations" bson:"operations"`
+
+	CreatedAt time.Time `json:"createdAt" bson:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt" bson:"updatedAt"`
+}
+
+func NewRouting(compID primitive.ObjectID) *Routing {
+	return &Routing{
+		ID:          primitive.NewObjectID(),
+		Composition: compID,
+		Operations:  make([]Operation, 0),
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+}
+
+// UsesWorkCenter returns true if any operation is performed in the work
+// center.
+func (r *Routing) UsesWorkCenter(id string) bool {
+	for _, o := range r.Operations {
+		if o.WorkCenter.Hex() == id {
+			return true
+		}
+	}
+	return false
+}
+
+// Cost returns the labor cost per unit. Setup time is spread over the units of
+// a batch: the lot size of the composition, or one unit if it has no lot size.
+func (r *Routing) Cost(workCenters map[string]*WorkCenter, unit, lotSize quantity.Quantity) float64 {
+	batch := 1.0
+	if !lotSize.IsEmpty() {
+		if units, err := lotSize.Ratio(unit); err == nil && units > 0 {
+			batch = units
+		}
+	}
+
+	var cost float64
+	for _, o := range r.Operations {
+		wc, ok := workCenters[o.WorkCenter.Hex()]
+		if !ok {
+			continue
+		}
+		cost += o.Hours(batch) / batch * wc.HourlyRate
+	}
+
+	return math.Round(cost*1000) / 1000
+}
+
+func (r *Routing) ValidateSchema() error {
+	err := errors.NewValidation("VALIDATE_SCHEMA")
+
+	if len(r.Operations) == 0 {
+		err.Add("operations", "REQUIRED")
+	}
+
+	for i, o := range r.Operations {
+		if o.Name == "" {
+			err.AddWithMessage("operation", "NAME_REQUIRED", "operation %d", i)
+		}
+		if o.WorkCenter.IsZero() {
+			err.AddWithMessage("operation", "WORK_CENTER_REQUIRED", "operation %d", i)
+		}
+		if o.Setup < 0 || o.Run < 0 {
+			err.AddWithMessage("operation", "INVALID_TIME", "operation %d", i)
+		}
+	}
+
+	if err.Size() > 0 {
+		return err
+	}
+
+	return nil
+}
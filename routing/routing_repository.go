@@ -0,0 +1,160 @@
+package routing
+
+import (
+	"context"
+	"time"
+
+	"github.com/aboglioli/big-brother/infrastructure/db"
+	"github.com/aboglioli/big-brother/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type RoutingRepository interface {
+	FindByComposition(compID string) (*Routing, error)
+	FindByWorkCenter(workCenterID string) ([]*Routing, error)
+
+	Insert(*Routing) error
+	Update(*Routing) error
+	Delete(id string) error
+}
+
+type routingRepository struct {
+	collection *mongo.Collection
+}
+
+func NewRoutingRepository() (RoutingRepository, error) {
+	db, err := db.Get("Routing")
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &routingRepository{
+		collection: db.Collection("routing"),
+	}, nil
+}
+
+func (r *routingRepository) FindByComposition(compID string) (*Routing, error) {
+	path := "routing/routing_repository.FindByComposition"
+	ctx := context.Background()
+
+	objID, err := primitive.ObjectIDFromHex(compID)
+	if err != nil {
+		return nil, errors.NewInternal("OBJECTID_FROM_HEX").SetPath(path).SetRef(err)
+	}
+
+	filter := bson.M{
+		"composition": objID,
+	}
+
+	res := r.collection.FindOne(ctx, filter)
+	if res.Err() == mongo.ErrNoDocuments {
+		return nil, errors.NewInternal("NOT_FOUND").SetPath(path)
+	}
+	if res.Err() != nil {
+		return nil, errors.NewInternal("FIND_ONE").SetPath(path).SetRef(res.Err())
+	}
+
+	var routing Routing
+	if err := res.Decode(&routing); err != nil {
+		return nil, errors.NewInternal("DECODE").SetPath(path).SetRef(err)
+	}
+
+	return &routing, nil
+}
+
+func (r *routingRepository) FindByWorkCenter(workCenterID string) ([]*Routing, error) {
+	path := "routing/routing_repository.FindByWorkCenter"
+	ctx := context.Background()
+
+	objID, err := primitive.ObjectIDFromHex(workCenterID)
+	if err != nil {
+		return nil, errors.NewInternal("OBJECTID_FROM_HEX").SetPath(path).SetRef(err)
+	}
+
+	filter := bson.M{
+		"operations.workCenter": objID,
+	}
+
+	cur, err := r.collection.Find(ctx, filter)
+	if err != nil {
+		return nil, errors.NewInternal("FIND").SetPath(path).SetRef(err)
+	}
+	defer cur.Close(ctx)
+
+	routings := make([]*Routing, 0)
+	for cur.Next(ctx) {
+		var routing Routing
+
+		if err := cur.Decode(&routing); err != nil {
+			return nil, errors.NewInternal("CUR_DECODE").SetPath(path).SetRef(err)
+		}
+
+		routings = append(routings, &routing)
+	}
+
+	if err := cur.Err(); err != nil {
+		return nil, errors.NewInternal("CUR").SetPath(path).SetRef(err)
+	}
+
+	return routings, nil
+}
+
+func (r *routingRepository) Insert(routing *Routing) error {
+	ctx := context.Background()
+
+	_, err := r.collection.InsertOne(ctx, routing)
+	if err != nil {
+		return errors.NewInternal("INSERT_ONE").SetPath("routing/routing_repository.Insert").SetRef(err)
+	}
+
+	return nil
+}
+
+func (r *routingRepository) Update(routing *Routing) error {
+	path := "routing/routing_repository.Update"
+	ctx := context.Background()
+
+	if routing.ID.IsZero() {
+		return errors.NewInternal("INVALID_OBJECTID").SetPath(path)
+	}
+
+	routing.UpdatedAt = time.Now()
+
+	filter := bson.M{
+		"_id": routing.ID,
+	}
+
+	update := bson.M{
+		"$set": routing,
+	}
+
+	_, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return errors.NewInternal("UPDATE_ONE").SetPath(path).SetRef(err)
+	}
+
+	return nil
+}
+
+func (r *routingRepository) Delete(id string) error {
+	path := "routing/routing_repository.Delete"
+	ctx := context.Background()
+
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return errors.NewInternal("OBJECTID_FROM_HEX").SetPath(path).SetRef(err)
+	}
+
+	filter := bson.M{
+		"_id": objID,
+	}
+
+	if _, err := r.collection.DeleteOne(ctx, filter); err != nil {
+		return errors.NewInternal("DELETE_ONE").SetPath(path).SetRef(err)
+	}
+
+	return nil
+}
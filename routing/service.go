@@ -0,0 +1,284 @@
+package routing
+
+import (
+	"github.com/aboglioli/big-brother/composition"
+	"github.com/aboglioli/big-brother/pkg/errors"
+)
+
+type Service interface {
+	GetWorkCenter(id string) (*WorkCenter, error)
+	FindWorkCenters() ([]*WorkCenter, error)
+	CreateWorkCenter(req *CreateWorkCenterRequest) (*WorkCenter, error)
+	UpdateWorkCenter(id string, req *UpdateWorkCenterRequest) (*WorkCenter, error)
+	DeleteWorkCenter(id string) error
+
+	GetRouting(compID string) (*Routing, error)
+	SetRouting(compID string, req *RoutingRequest) (*Routing, error)
+	DeleteRouting(compID string) error
+
+	UpdateCompositionCost(compID string) (*composition.Composition, error)
+}
+
+type service struct {
+	workCenterRepository WorkCenterRepository
+	routingRepository    RoutingRepository
+	compositionService   composition.Service
+}
+
+func NewService(workCenterRepo WorkCenterRepository, routingRepo RoutingRepository, compServ composition.Service) Service {
+	return &service{
+		workCenterRepository: workCenterRepo,
+		routingRepository:    routingRepo,
+		compositionService:   compServ,
+	}
+}
+
+func (s *service) GetWorkCenter(id string) (*WorkCenter, error) {
+	w, err := s.workCenterRepository.FindByID(id)
+	if err != nil {
+		return nil, errors.NewStatus("WORK_CENTER_NOT_FOUND").SetPath("routing/service.GetWorkCenter").SetStatus(404).SetRef(err)
+	}
+	return w, nil
+}
+
+func (s *service) FindWorkCenters() ([]*WorkCenter, error) {
+	workCenters, err := s.workCenterRepository.FindAll()
+	if err != nil {
+		return nil, errors.NewStatus("WORK_CENTERS_NOT_FOUND").SetPath("routing/service.FindWorkCenters").SetStatus(404).SetRef(err)
+	}
+	return workCenters, nil
+}
+
+type CreateWorkCenterRequest struct {
+	Name       string  `json:"name" binding:"required"`
+	HourlyRate float64 `json:"hourlyRate"`
+	Capacity   float64 `json:"capacity" binding:"required"`
+}
+
+func (s *service) CreateWorkCenter(req *CreateWorkCenterRequest) (*WorkCenter, error) {
+	w := NewWorkCenter()
+	w.Name = req.Name
+	w.HourlyRate = req.HourlyRate
+	w.Capacity = req.Capacity
+
+	if err := w.ValidateSchema(); err != nil {
+		return nil, err
+	}
+
+	if err := s.workCenterRepository.Insert(w); err != nil {
+		return nil, errors.NewStatus("INSERT").SetPath("routing/service.CreateWorkCenter").SetRef(err)
+	}
+
+	return w, nil
+}
+
+type UpdateWorkCenterRequest struct {
+	Name       *string  `json:"name"`
+	HourlyRate *float64 `json:"hourlyRate"`
+	Capacity   *float64 `json:"capacity"`
+}
+
+// UpdateWorkCenter updates a work center. If the hourly rate changes, the cost
+// of the compositions whose routings use the work center is updated.
+func (s *service) UpdateWorkCenter(id string, req *UpdateWorkCenterRequest) (*WorkCenter, error) {
+	path := "routing/service.UpdateWorkCenter"
+
+	w, err := s.GetWorkCenter(id)
+	if err != nil {
+		return nil, err
+	}
+
+	rate := w.HourlyRate
+	if req.Name != nil {
+		w.Name = *req.Name
+	}
+	if req.HourlyRate != nil {
+		w.HourlyRate = *req.HourlyRate
+	}
+	if req.Capacity != nil {
+		w.Capacity = *req.Capacity
+	}
+
+	if err := w.ValidateSchema(); err != nil {
+		return nil, err
+	}
+
+	if err := s.workCenterRepository.Update(w); err != nil {
+		return nil, errors.NewStatus("UPDATE").SetPath(path).SetRef(err)
+	}
+
+	if rate != w.HourlyRate {
+		routings, err := s.routingRepository.FindByWorkCenter(id)
+		if err != nil {
+			return nil, errors.NewStatus("ROUTINGS_NOT_FOUND").SetPath(path).SetRef(err)
+		}
+
+		for _, r := range routings {
+			if _, err := s.UpdateCompositionCost(r.Composition.Hex()); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return w, nil
+}
+
+// DeleteWorkCenter deletes a work center not used by any routing.
+func (s *service) DeleteWorkCenter(id string) error {
+	path := "routing/service.DeleteWorkCenter"
+
+	if _, err := s.GetWorkCenter(id); err != nil {
+		return err
+	}
+
+	routings, err := s.routingRepository.FindByWorkCenter(id)
+	if err != nil {
+		return errors.NewStatus("ROUTINGS_NOT_FOUND").SetPath(path).SetRef(err)
+	}
+	if len(routings) > 0 {
+		return errors.NewStatus("WORK_CENTER_IN_USE").SetPath(path).SetMessage("Work center used in %d routings", len(routings))
+	}
+
+	if err := s.workCenterRepository.Delete(id); err != nil {
+		return errors.NewStatus("DELETE").SetPath(path).SetRef(err)
+	}
+
+	return nil
+}
+
+func (s *service) GetRouting(compID string) (*Routing, error) {
+	r, err := s.routingRepository.FindByComposition(compID)
+	if err != nil {
+		return nil, errors.NewStatus("ROUTING_NOT_FOUND").SetPath("routing/service.GetRouting").SetStatus(404).SetRef(err)
+	}
+	return r, nil
+}
+
+type RoutingRequest struct {
+	Operations []Operation `json:"operations" binding:"required"`
+}
+
+// SetRouting creates or replaces the routing of a composition. Only
+// compositions with dependencies can be produced, so only they have routings.
+// The routing cost is set in the composition.
+func (s *service) SetRouting(compID string, req *RoutingRequest) (*Routing, error) {
+	path := "routing/service.SetRouting"
+
+	comp, err := s.compositionService.GetByID(compID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(comp.Dependencies) == 0 {
+		return nil, errors.NewStatus("COMPOSITION_CANNOT_BE_PRODUCED").SetPath(path).SetMessage("%s has no dependencies", compID)
+	}
+
+	for i, o := range req.Operations {
+		if _, err := s.GetWorkCenter(o.WorkCenter.Hex()); err != nil {
+			return nil, errors.NewStatus("WORK_CENTER_NOT_FOUND").SetPath(path).SetMessage("operation %d", i).SetRef(err)
+		}
+	}
+
+	r, err := s.routingRepository.FindByComposition(compID)
+	isNew := err != nil
+	if isNew {
+		r = NewRouting(comp.ID)
+	}
+	r.Operations = append([]Operation(nil), req.Operations...)
+
+	if err := r.ValidateSchema(); err != nil {
+		return nil, err
+	}
+
+	if isNew {
+		err = s.routingRepository.Insert(r)
+	} else {
+		err = s.routingRepository.Update(r)
+	}
+	if err != nil {
+		return nil, errors.NewStatus("SAVE").SetPath(path).SetRef(err)
+	}
+
+	if _, err := s.UpdateCompositionCost(compID); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// DeleteRouting deletes the routing of a composition, removing its routing
+// cost.
+func (s *service) DeleteRouting(compID string) error {
+	path := "routing/service.DeleteRouting"
+
+	r, err := s.GetRouting(compID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.routingRepository.Delete(r.ID.Hex()); err != nil {
+		return errors.NewStatus("DELETE").SetPath(path).SetRef(err)
+	}
+
+	if _, err := s.UpdateCompositionCost(compID); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// UpdateCompositionCost sets the routing cost of a composition from its
+// routing, or zero if it has none. The composition is updated through the
+// composition service, so its cost is recalculated and propagated to the
+// compositions using it. Unchanged compositions are returned as they are.
+func (s *service) UpdateCompositionCost(compID string) (*composition.Composition, error) {
+	comp, err := s.compositionService.GetByID(compID)
+	if err != nil {
+		return nil, err
+	}
+
+	cost := 0.0
+	r, err := s.routingRepository.FindByComposition(compID)
+	if err != nil && !hasCode(err, "NOT_FOUND") {
+		return nil, err
+	}
+	if err == nil {
+		workCenters, err := s.workCenters(r.Operations)
+		if err != nil {
+			return nil, err
+		}
+		cost = r.Cost(workCenters, comp.Unit, comp.LotSize)
+	}
+
+	if cost == comp.RoutingCost {
+		return comp, nil
+	}
+
+	return s.compositionService.Update(compID, &composition.UpdateRequest{
+		RoutingCost:  &cost,
+		Dependencies: comp.Dependencies,
+		Confirm:      true,
+	})
+}
+
+func (s *service) workCenters(operations []Operation) (map[string]*WorkCenter, error) {
+	workCenters := make(map[string]*WorkCenter)
+	for _, o := range operations {
+		id := o.WorkCenter.Hex()
+		if _, ok := workCenters[id]; ok {
+			continue
+		}
+
+		w, err := s.GetWorkCenter(id)
+		if err != nil {
+			return nil, err
+		}
+		workCenters[id] = w
+	}
+	return workCenters, nil
+}
+
+func hasCode(err error, code string) bool {
+	c, ok := err.(errors.Code)
+	return ok && c.Code() == code
+}
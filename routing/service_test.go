@@ -0,0 +1,93 @@
+package routing
+
+import (
+	"testing"
+
+	"github.com/aboglioli/big-brother/composition"
+	"github.com/aboglioli/big-brother/pkg/quantity"
+	"github.com/aboglioli/big-brother/pkg/tests/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func newComposition(unit quantity.Quantity, cost float64, deps ...composition.Dependency) *composition.Composition {
+	comp := composition.NewComposition()
+	comp.Cost = cost
+	comp.Unit = unit
+	comp.Stock = quantity.Quantity{0, unit.Unit}
+	comp.Dependencies = deps
+	comp.Validated = true
+	return comp
+}
+
+func TestRoutingCost(t *testing.T) {
+	compRepo := composition.NewMockRepository()
+	compServ := composition.NewService(compRepo)
+	serv := NewService(NewMockWorkCenterRepository(), NewMockRoutingRepository(), compServ)
+
+	flour := newComposition(quantity.Quantity{1, "kg"}, 10)
+	bread := newComposition(quantity.Quantity{1, "u"}, 5, composition.Dependency{On: flour.ID, Quantity: quantity.Quantity{500, "g"}, Subvalue: 5})
+	bread.LotSize = quantity.Quantity{10, "u"}
+	compRepo.Insert(flour)
+	compRepo.Insert(bread)
+
+	_, err := serv.CreateWorkCenter(&CreateWorkCenterRequest{Name: "Oven", HourlyRate: 30})
+	assert.ErrValidation(t, err, "capacity", "INVALID")
+
+	mixer, err := serv.CreateWorkCenter(&CreateWorkCenterRequest{Name: "Mixer", HourlyRate: 20, Capacity: 4})
+	assert.Ok(t, err)
+	oven, err := serv.CreateWorkCenter(&CreateWorkCenterRequest{Name: "Oven", HourlyRate: 30, Capacity: 8})
+	assert.Ok(t, err)
+
+	t.Run("Invalid routings", func(t *testing.T) {
+		_, err := serv.SetRouting(flour.ID.Hex(), &RoutingRequest{Operations: []Operation{Operation{Name: "Mill", WorkCenter: mixer.ID}}})
+		assert.ErrCode(t, err, "COMPOSITION_CANNOT_BE_PRODUCED")
+
+		_, err = serv.SetRouting(bread.ID.Hex(), &RoutingRequest{Operations: []Operation{Operation{Name: "Mix", WorkCenter: primitive.NewObjectID()}}})
+		assert.ErrCode(t, err, "WORK_CENTER_NOT_FOUND")
+
+		_, err = serv.SetRouting(bread.ID.Hex(), &RoutingRequest{Operations: []Operation{Operation{Name: "Mix", WorkCenter: mixer.ID, Run: -1}}})
+		assert.ErrValidation(t, err, "operation", "INVALID_TIME")
+	})
+
+	t.Run("Routing cost rolls up into composition cost", func(t *testing.T) {
+		// Mix: (30 / 10 + 6) / 60 * 20 = 3, Bake: 12 / 60 * 30 = 6
+		r, err := serv.SetRouting(bread.ID.Hex(), &RoutingRequest{Operations: []Operation{
+			Operation{Name: "Mix", WorkCenter: mixer.ID, Setup: 30, Run: 6},
+			Operation{Name: "Bake", WorkCenter: oven.ID, Run: 12},
+		}})
+		assert.Ok(t, err)
+		assert.Equal(t, len(r.Operations), 2)
+
+		comp, _ := compRepo.FindByID(bread.ID.Hex())
+		assert.Equal(t, comp.RoutingCost, 9.0)
+		assert.Equal(t, comp.Cost, 14.0)
+	})
+
+	t.Run("Work center rate updates cost", func(t *testing.T) {
+		rate := 60.0
+		_, err := serv.UpdateWorkCenter(oven.ID.Hex(), &UpdateWorkCenterRequest{HourlyRate: &rate})
+		assert.Ok(t, err)
+
+		comp, _ := compRepo.FindByID(bread.ID.Hex())
+		assert.Equal(t, comp.RoutingCost, 15.0)
+		assert.Equal(t, comp.Cost, 20.0)
+
+		err = serv.DeleteWorkCenter(oven.ID.Hex())
+		assert.ErrCode(t, err, "WORK_CENTER_IN_USE")
+	})
+
+	t.Run("Delete routing", func(t *testing.T) {
+		err := serv.DeleteRouting(bread.ID.Hex())
+		assert.Ok(t, err)
+
+		comp, _ := compRepo.FindByID(bread.ID.Hex())
+		assert.Equal(t, comp.RoutingCost, 0.0)
+		assert.Equal(t, comp.Cost, 5.0)
+
+		_, err = serv.GetRouting(bread.ID.Hex())
+		assert.ErrCode(t, err, "ROUTING_NOT_FOUND")
+
+		err = serv.DeleteWorkCenter(oven.ID.Hex())
+		assert.Ok(t, err)
+	})
+}
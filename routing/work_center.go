@@ -0,0 +1,48 @@
+package routing
+
+import (
+	"time"
+
+	"github.com/aboglioli/big-brother/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// WorkCenter is a machine, line or group of workers where operations are
+// performed. Capacity is the number of hours available per day.
+type WorkCenter struct {
+	ID         primitive.ObjectID `json:"id" bson:"_id"`
+	Name       string             `json:"name" bson:"name"`
+	HourlyRate float64            `json:"hourlyRate" bson:"hourlyRate"`
+	Capacity   float64            `json:"capacity" bson:"capacity"`
+
+	CreatedAt time.Time `json:"createdAt" bson:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt" bson:"updatedAt"`
+}
+
+func NewWorkCenter() *WorkCenter {
+	return &WorkCenter{
+		ID:        primitive.NewObjectID(),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+}
+
+func (w *WorkCenter) ValidateSchema() error {
+	err := errors.NewValidation("VALIDATE_SCHEMA")
+
+	if w.Name == "" {
+		err.Add("name", "REQUIRED")
+	}
+	if w.HourlyRate < 0 {
+		err.Add("hourlyRate", "INVALID")
+	}
+	if w.Capacity <= 0 || w.Capacity > 24 {
+		err.Add("capacity", "INVALID")
+	}
+
+	if err.Size() > 0 {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,151 @@
+package routing
+
+import (
+	"context"
+	"time"
+
+	"github.com/aboglioli/big-brother/infrastructure/db"
+	"github.com/aboglioli/big-brother/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type WorkCenterRepository interface {
+	FindAll() ([]*WorkCenter, error)
+	FindByID(id string) (*WorkCenter, error)
+
+	Insert(*WorkCenter) error
+	Update(*WorkCenter) error
+	Delete(id string) error
+}
+
+type workCenterRepository struct {
+	collection *mongo.Collection
+}
+
+func NewWorkCenterRepository() (WorkCenterRepository, error) {
+	db, err := db.Get("Routing")
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &workCenterRepository{
+		collection: db.Collection("workCenter"),
+	}, nil
+}
+
+func (r *workCenterRepository) FindAll() ([]*WorkCenter, error) {
+	path := "routing/work_center_repository.FindAll"
+	ctx := context.Background()
+
+	opts := options.Find().SetSort(bson.M{"name": 1})
+
+	cur, err := r.collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, errors.NewInternal("FIND").SetPath(path).SetRef(err)
+	}
+	defer cur.Close(ctx)
+
+	workCenters := make([]*WorkCenter, 0)
+	for cur.Next(ctx) {
+		var w WorkCenter
+
+		if err := cur.Decode(&w); err != nil {
+			return nil, errors.NewInternal("CUR_DECODE").SetPath(path).SetRef(err)
+		}
+
+		workCenters = append(workCenters, &w)
+	}
+
+	if err := cur.Err(); err != nil {
+		return nil, errors.NewInternal("CUR").SetPath(path).SetRef(err)
+	}
+
+	return workCenters, nil
+}
+
+func (r *workCenterRepository) FindByID(id string) (*WorkCenter, error) {
+	path := "routing/work_center_repository.FindByID"
+	ctx := context.Background()
+
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errors.NewInternal("OBJECTID_FROM_HEX").SetPath(path).SetRef(err)
+	}
+
+	filter := bson.M{
+		"_id": objID,
+	}
+
+	res := r.collection.FindOne(ctx, filter)
+	if res.Err() != nil {
+		return nil, errors.NewInternal("FIND_ONE").SetPath(path).SetRef(res.Err())
+	}
+
+	var w WorkCenter
+	if err := res.Decode(&w); err != nil {
+		return nil, errors.NewInternal("DECODE").SetPath(path).SetRef(err)
+	}
+
+	return &w, nil
+}
+
+func (r *workCenterRepository) Insert(w *WorkCenter) error {
+	ctx := context.Background()
+
+	_, err := r.collection.InsertOne(ctx, w)
+	if err != nil {
+		return errors.NewInternal("INSERT_ONE").SetPath("routing/work_center_repository.Insert").SetRef(err)
+	}
+
+	return nil
+}
+
+func (r *workCenterRepository) Update(w *WorkCenter) error {
+	path := "routing/work_center_repository.Update"
+	ctx := context.Background()
+
+	if w.ID.IsZero() {
+		return errors.NewInternal("INVALID_OBJECTID").SetPath(path)
+	}
+
+	w.UpdatedAt = time.Now()
+
+	filter := bson.M{
+		"_id": w.ID,
+	}
+
+	update := bson.M{
+		"$set": w,
+	}
+
+	_, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return errors.NewInternal("UPDATE_ONE").SetPath(path).SetRef(err)
+	}
+
+	return nil
+}
+
+func (r *workCenterRepository) Delete(id string) error {
+	path := "routing/work_center_repository.Delete"
+	ctx := context.Background()
+
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return errors.NewInternal("OBJECTID_FROM_HEX").SetPath(path).SetRef(err)
+	}
+
+	filter := bson.M{
+		"_id": objID,
+	}
+
+	if _, err := r.collection.DeleteOne(ctx, filter); err != nil {
+		return errors.NewInternal("DELETE_ONE").SetPath(path).SetRef(err)
+	}
+
+	return nil
+}
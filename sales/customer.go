@@ -0,0 +1,58 @@
+package sales
+
+import (
+	"time"
+
+	"github.com/aboglioli/big-brother/pkg/contact"
+	"github.com/aboglioli/big-brother/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Customer struct {
+	ID    primitive.ObjectID `json:"id" bson:"_id"`
+	Name  string             `json:"name" bson:"name"`
+	Group string             `json:"group" bson:"group"`
+
+	Address contact.Address `json:"address" bson:"address"`
+	Contact contact.Contact `json:"contact" bson:"contact"`
+	Social  contact.Social  `json:"social" bson:"social"`
+
+	Enabled   bool      `json:"-" bson:"enabled"`
+	CreatedAt time.Time `json:"createdAt" bson:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt" bson:"updatedAt"`
+}
+
+func NewCustomer() *Customer {
+	return &Customer{
+		ID:        primitive.NewObjectID(),
+		Enabled:   true,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+}
+
+func (c *Customer) ValidateSchema() error {
+	err := errors.NewValidation("VALIDATE_SCHEMA").SetPath("sales/customer.ValidateSchema")
+
+	if len(c.Name) < 1 || len(c.Name) > 128 {
+		err.AddWithMessage("name", "INVALID_LENGTH", "%d", len(c.Name))
+	}
+
+	if !c.Address.IsValid() {
+		err.Add("address", "INVALID")
+	}
+
+	if !c.Contact.IsValid() {
+		err.Add("contact", "INVALID")
+	}
+
+	if !c.Social.IsValid() {
+		err.Add("social", "INVALID")
+	}
+
+	if err.Size() > 0 {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,161 @@
+package sales
+
+import (
+	"context"
+	"time"
+
+	"github.com/aboglioli/big-brother/infrastructure/db"
+	"github.com/aboglioli/big-brother/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type CustomerRepository interface {
+	FindAll() ([]*Customer, error)
+	FindByID(id string) (*Customer, error)
+
+	Insert(*Customer) error
+	Update(*Customer) error
+	Delete(id string) error
+}
+
+type customerRepository struct {
+	collection *mongo.Collection
+}
+
+func NewCustomerRepository() (CustomerRepository, error) {
+	db, err := db.Get("Sales")
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &customerRepository{
+		collection: db.Collection("customer"),
+	}, nil
+}
+
+func (r *customerRepository) FindAll() ([]*Customer, error) {
+	return r.find("sales/customer_repository.FindAll", bson.M{
+		"enabled": true,
+	})
+}
+
+func (r *customerRepository) FindByID(id string) (*Customer, error) {
+	path := "sales/customer_repository.FindByID"
+	ctx := context.Background()
+
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errors.NewInternal("OBJECTID_FROM_HEX").SetPath(path).SetRef(err)
+	}
+
+	filter := bson.M{
+		"_id": objID,
+	}
+
+	res := r.collection.FindOne(ctx, filter)
+	if res.Err() != nil {
+		return nil, errors.NewInternal("FIND_ONE").SetPath(path).SetRef(res.Err())
+	}
+
+	var c Customer
+	if err := res.Decode(&c); err != nil {
+		return nil, errors.NewInternal("DECODE").SetPath(path).SetRef(err)
+	}
+
+	return &c, nil
+}
+
+func (r *customerRepository) Insert(c *Customer) error {
+	ctx := context.Background()
+
+	_, err := r.collection.InsertOne(ctx, c)
+	if err != nil {
+		return errors.NewInternal("INSERT_ONE").SetPath("sales/customer_repository.Insert").SetRef(err)
+	}
+
+	return nil
+}
+
+func (r *customerRepository) Update(c *Customer) error {
+	path := "sales/customer_repository.Update"
+	ctx := context.Background()
+
+	if c.ID.IsZero() {
+		return errors.NewInternal("INVALID_OBJECTID").SetPath(path)
+	}
+
+	c.UpdatedAt = time.Now()
+
+	filter := bson.M{
+		"_id": c.ID,
+	}
+
+	update := bson.M{
+		"$set": c,
+	}
+
+	_, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return errors.NewInternal("UPDATE_ONE").SetPath(path).SetRef(err)
+	}
+
+	return nil
+}
+
+func (r *customerRepository) Delete(id string) error {
+	path := "sales/customer_repository.Delete"
+	ctx := context.Background()
+
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return errors.NewInternal("OBJECTID_FROM_HEX").SetPath(path).SetRef(err)
+	}
+
+	filter := bson.M{
+		"_id": objID,
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"updatedAt": time.Now(),
+			"enabled":   false,
+		},
+	}
+
+	_, err = r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return errors.NewInternal("UPDATE_ONE").SetPath(path).SetRef(err)
+	}
+
+	return nil
+}
+
+func (r *customerRepository) find(path string, filter bson.M) ([]*Customer, error) {
+	ctx := context.Background()
+
+	cur, err := r.collection.Find(ctx, filter)
+	if err != nil {
+		return nil, errors.NewInternal("FIND").SetPath(path).SetRef(err)
+	}
+	defer cur.Close(ctx)
+
+	customers := make([]*Customer, 0)
+	for cur.Next(ctx) {
+		var c Customer
+
+		if err := cur.Decode(&c); err != nil {
+			return nil, errors.NewInternal("CUR_DECODE").SetPath(path).SetRef(err)
+		}
+
+		customers = append(customers, &c)
+	}
+
+	if err := cur.Err(); err != nil {
+		return nil, errors.NewInternal("CUR").SetPath(path).SetRef(err)
+	}
+
+	return customers, nil
+}
@@ -0,0 +1,34 @@
+package sales
+
+import (
+	"github.com/aboglioli/big-brother/pkg/events"
+)
+
+// OrderChangedEvent is published on each sales order status change
+type OrderChangedEvent struct {
+	events.Event
+	Order *Order `json:"order"`
+}
+
+func NewOrderConfirmedEvent(o *Order) (*OrderChangedEvent, *events.Options) {
+	event := &OrderChangedEvent{events.NewEvent("SalesOrderConfirmed", o.ID.Hex()), o}
+	opts := &events.Options{"sales", "topic", "sales.order.confirmed", ""}
+	return event, opts
+}
+
+func NewOrderDeliveredEvent(o *Order) (*OrderChangedEvent, *events.Options) {
+	event := &OrderChangedEvent{events.NewEvent("SalesOrderDelivered", o.ID.Hex()), o}
+	opts := &events.Options{"sales", "topic", "sales.order.delivered", ""}
+	return event, opts
+}
+
+func NewOrderCancelledEvent(o *Order) (*OrderChangedEvent, *events.Options) {
+	event := &OrderChangedEvent{events.NewEvent("SalesOrderCancelled", o.ID.Hex()), o}
+	opts := &events.Options{"sales", "topic", "sales.order.cancelled", ""}
+	return event, opts
+}
+
+// newOrderEntry returns the outbox entry of an order event.
+func newOrderEntry(event *OrderChangedEvent, opts *events.Options) (*events.Entry, error) {
+	return events.NewEntry(event.Order.ID.Hex(), event, opts)
+}
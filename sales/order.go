@@ -0,0 +1,199 @@
+package sales
+
+import (
+	"time"
+
+	"github.com/aboglioli/big-brother/composition"
+	"github.com/aboglioli/big-brother/pkg/errors"
+	"github.com/aboglioli/big-brother/pkg/quantity"
+	"github.com/aboglioli/big-brother/stock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	OrderDraft     = "DRAFT"
+	OrderConfirmed = "CONFIRMED"
+	OrderDelivered = "DELIVERED"
+	OrderCancelled = "CANCELLED"
+
+	epsilon = 1e-9
+)
+
+// Line is a sold composition. Quantity can be expressed in any unit
+// compatible with the composition unit and Price is the price of the whole
+// quantity. Lots are the stock lots issued on delivery, saved as they are
+// consumed, and Issued is set once the whole quantity is issued.
+type Line struct {
+	Composition primitive.ObjectID   `json:"composition" bson:"composition"`
+	Quantity    quantity.Quantity    `json:"quantity" bson:"quantity"`
+	Price       float64              `json:"price" bson:"price"`
+	Lots        []stock.LotComponent `json:"lots" bson:"lots"`
+	Issued      bool                 `json:"issued" bson:"issued"`
+}
+
+// IsIssued returns true if the whole quantity of the line was issued from its
+// lots.
+func (l *Line) IsIssued() bool {
+	return l.Issued
+}
+
+// Remaining returns the quantity of the line not issued from lots yet, in the
+// unit of the composition.
+func (l *Line) Remaining(comp *composition.Composition) (quantity.Quantity, error) {
+	remaining, err := comp.ToUnit(l.Quantity)
+	if err != nil {
+		return quantity.Quantity{}, err
+	}
+
+	for _, a := range l.Lots {
+		if remaining, err = remaining.Subtract(a.Quantity); err != nil {
+			return quantity.Quantity{}, err
+		}
+	}
+
+	if remaining.Quantity <= epsilon {
+		remaining.Quantity = 0
+	}
+
+	return remaining, nil
+}
+
+// Order is a sales order of a customer.
+type Order struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id"`
+	Customer  primitive.ObjectID `json:"customer" bson:"customer"`
+	Status    string             `json:"status" bson:"status"`
+	Lines     []Line             `json:"lines" bson:"lines"`
+	Reference string             `json:"reference" bson:"reference"`
+
+	ConfirmedAt *time.Time `json:"confirmedAt" bson:"confirmedAt"`
+	DeliveredAt *time.Time `json:"deliveredAt" bson:"deliveredAt"`
+	CreatedAt   time.Time  `json:"createdAt" bson:"createdAt"`
+	UpdatedAt   time.Time  `json:"updatedAt" bson:"updatedAt"`
+}
+
+func NewOrder(customerID primitive.ObjectID) *Order {
+	return &Order{
+		ID:        primitive.NewObjectID(),
+		Customer:  customerID,
+		Status:    OrderDraft,
+		Lines:     make([]Line, 0),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+}
+
+// Total returns the price of the order.
+func (o *Order) Total() float64 {
+	total := 0.0
+	for _, l := range o.Lines {
+		total += l.Price
+	}
+	return total
+}
+
+func (o *Order) FindLine(compID string) *Line {
+	for i := range o.Lines {
+		if o.Lines[i].Composition.Hex() == compID {
+			return &o.Lines[i]
+		}
+	}
+	return nil
+}
+
+// AddLine adds a composition to a draft order. If the composition is already
+// in the order, quantity and price are added to the existing line.
+func (o *Order) AddLine(compID primitive.ObjectID, q quantity.Quantity, price float64) error {
+	path := "sales/order.AddLine"
+
+	if o.Status != OrderDraft {
+		return errors.NewStatus("ORDER_NOT_DRAFT").SetPath(path)
+	}
+
+	if line := o.FindLine(compID.Hex()); line != nil {
+		total, err := line.Quantity.Add(q)
+		if err != nil {
+			return errors.NewStatus("INCOMPATIBLE_QUANTITY").SetPath(path).SetRef(err)
+		}
+		line.Quantity = total
+		line.Price += price
+		return nil
+	}
+
+	o.Lines = append(o.Lines, Line{
+		Composition: compID,
+		Quantity:    q,
+		Price:       price,
+		Lots:        make([]stock.LotComponent, 0),
+	})
+
+	return nil
+}
+
+func (o *Order) Confirm() error {
+	if o.Status != OrderDraft {
+		return errors.NewStatus("ORDER_NOT_DRAFT").SetPath("sales/order.Confirm")
+	}
+
+	now := time.Now()
+	o.Status = OrderConfirmed
+	o.ConfirmedAt = &now
+
+	return nil
+}
+
+func (o *Order) Deliver() error {
+	if o.Status != OrderConfirmed {
+		return errors.NewStatus("ORDER_NOT_CONFIRMED").SetPath("sales/order.Deliver")
+	}
+
+	now := time.Now()
+	o.Status = OrderDelivered
+	o.DeliveredAt = &now
+
+	return nil
+}
+
+// Cancel cancels a draft or confirmed order. Delivered orders and orders being
+// delivered (with issued lines) cannot be cancelled.
+func (o *Order) Cancel() error {
+	if o.Status != OrderDraft && o.Status != OrderConfirmed {
+		return errors.NewStatus("ORDER_CANNOT_BE_CANCELLED").SetPath("sales/order.Cancel").SetMessage("%s", o.Status)
+	}
+	for i := range o.Lines {
+		if len(o.Lines[i].Lots) > 0 {
+			return errors.NewStatus("ORDER_CANNOT_BE_CANCELLED").SetPath("sales/order.Cancel").SetMessage("line %d issued", i)
+		}
+	}
+
+	o.Status = OrderCancelled
+
+	return nil
+}
+
+func (o *Order) ValidateSchema() error {
+	err := errors.NewValidation("VALIDATE_SCHEMA").SetPath("sales/order.ValidateSchema")
+
+	if o.Customer.IsZero() {
+		err.Add("customer", "REQUIRED")
+	}
+
+	if len(o.Lines) == 0 {
+		err.Add("lines", "EMPTY")
+	}
+
+	for i, l := range o.Lines {
+		if !l.Quantity.IsValid() || l.Quantity.Quantity == 0 {
+			err.AddWithMessage("lines", "INVALID_QUANTITY", "line %d", i)
+		}
+		if l.Price < 0 {
+			err.AddWithMessage("lines", "INVALID_PRICE", "line %d", i)
+		}
+	}
+
+	if err.Size() > 0 {
+		return err
+	}
+
+	return nil
+}
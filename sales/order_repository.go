@@ -0,0 +1,178 @@
+package sales
+
+import (
+	"context"
+	"time"
+
+	"github.com/aboglioli/big-brother/infrastructure/db"
+	"github.com/aboglioli/big-brother/pkg/errors"
+	"github.com/aboglioli/big-brother/pkg/events"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type OrderRepository interface {
+	FindByID(id string) (*Order, error)
+	FindByStatus(status ...string) ([]*Order, error)
+	FindByCustomer(customerID string) ([]*Order, error)
+	FindDelivered(compID string, from, to time.Time) ([]*Order, error)
+
+	Insert(*Order) error
+
+	// Update stores the outbox entries of the change in the same
+	// transaction.
+	Update(o *Order, entries ...*events.Entry) error
+}
+
+type orderRepository struct {
+	collection *mongo.Collection
+	outbox     events.Outbox
+}
+
+// NewOrderRepository returns the order repository of the "Sales" database.
+// The outbox must be of the same database.
+func NewOrderRepository(outbox events.Outbox) (OrderRepository, error) {
+	db, err := db.Get("Sales")
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &orderRepository{
+		collection: db.Collection("order"),
+		outbox:     outbox,
+	}, nil
+}
+
+func (r *orderRepository) FindByID(id string) (*Order, error) {
+	path := "sales/order_repository.FindByID"
+	ctx := context.Background()
+
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errors.NewInternal("OBJECTID_FROM_HEX").SetPath(path).SetRef(err)
+	}
+
+	filter := bson.M{
+		"_id": objID,
+	}
+
+	res := r.collection.FindOne(ctx, filter)
+	if res.Err() != nil {
+		return nil, errors.NewInternal("FIND_ONE").SetPath(path).SetRef(res.Err())
+	}
+
+	var o Order
+	if err := res.Decode(&o); err != nil {
+		return nil, errors.NewInternal("DECODE").SetPath(path).SetRef(err)
+	}
+
+	return &o, nil
+}
+
+func (r *orderRepository) FindByStatus(status ...string) ([]*Order, error) {
+	return r.find("sales/order_repository.FindByStatus", bson.M{
+		"status": bson.M{
+			"$in": status,
+		},
+	})
+}
+
+func (r *orderRepository) FindByCustomer(customerID string) ([]*Order, error) {
+	path := "sales/order_repository.FindByCustomer"
+
+	objID, err := primitive.ObjectIDFromHex(customerID)
+	if err != nil {
+		return nil, errors.NewInternal("OBJECTID_FROM_HEX").SetPath(path).SetRef(err)
+	}
+
+	return r.find(path, bson.M{
+		"customer": objID,
+	})
+}
+
+// FindDelivered finds the orders including a composition delivered in
+// [from, to).
+func (r *orderRepository) FindDelivered(compID string, from, to time.Time) ([]*Order, error) {
+	path := "sales/order_repository.FindDelivered"
+
+	objID, err := primitive.ObjectIDFromHex(compID)
+	if err != nil {
+		return nil, errors.NewInternal("OBJECTID_FROM_HEX").SetPath(path).SetRef(err)
+	}
+
+	return r.find(path, bson.M{
+		"status":            OrderDelivered,
+		"lines.composition": objID,
+		"deliveredAt": bson.M{
+			"$gte": from,
+			"$lt":  to,
+		},
+	})
+}
+
+func (r *orderRepository) Insert(o *Order) error {
+	ctx := context.Background()
+
+	_, err := r.collection.InsertOne(ctx, o)
+	if err != nil {
+		return errors.NewInternal("INSERT_ONE").SetPath("sales/order_repository.Insert").SetRef(err)
+	}
+
+	return nil
+}
+
+func (r *orderRepository) Update(o *Order, entries ...*events.Entry) error {
+	path := "sales/order_repository.Update"
+
+	if o.ID.IsZero() {
+		return errors.NewInternal("INVALID_OBJECTID").SetPath(path)
+	}
+
+	o.UpdatedAt = time.Now()
+
+	filter := bson.M{
+		"_id": o.ID,
+	}
+
+	update := bson.M{
+		"$set": o,
+	}
+
+	return r.outbox.Write(func(ctx context.Context) error {
+		_, err := r.collection.UpdateOne(ctx, filter, update)
+		if err != nil {
+			return errors.NewInternal("UPDATE_ONE").SetPath(path).SetRef(err)
+		}
+
+		return nil
+	}, entries...)
+}
+
+func (r *orderRepository) find(path string, filter bson.M) ([]*Order, error) {
+	ctx := context.Background()
+
+	cur, err := r.collection.Find(ctx, filter)
+	if err != nil {
+		return nil, errors.NewInternal("FIND").SetPath(path).SetRef(err)
+	}
+	defer cur.Close(ctx)
+
+	orders := make([]*Order, 0)
+	for cur.Next(ctx) {
+		var o Order
+
+		if err := cur.Decode(&o); err != nil {
+			return nil, errors.NewInternal("CUR_DECODE").SetPath(path).SetRef(err)
+		}
+
+		orders = append(orders, &o)
+	}
+
+	if err := cur.Err(); err != nil {
+		return nil, errors.NewInternal("CUR").SetPath(path).SetRef(err)
+	}
+
+	return orders, nil
+}
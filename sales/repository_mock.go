@@ -0,0 +1,204 @@
+package sales
+
+import (
+	"time"
+
+	"github.com/aboglioli/big-brother/pkg/errors"
+	"github.com/aboglioli/big-brother/pkg/events"
+	"github.com/aboglioli/big-brother/pkg/tests/mock"
+	"github.com/aboglioli/big-brother/stock"
+)
+
+// Customers
+type mockCustomerRepository struct {
+	mock.Mock
+	customers []*Customer
+}
+
+// NewMockCustomerRepository returns an in-memory CustomerRepository. It is
+// exported to be used by other packages' tests.
+func NewMockCustomerRepository() *mockCustomerRepository {
+	return &mockCustomerRepository{}
+}
+
+func (r *mockCustomerRepository) Clean() {
+	r.customers = make([]*Customer, 0)
+}
+
+func (r *mockCustomerRepository) FindAll() ([]*Customer, error) {
+	r.Called("FindAll")
+
+	customers := make([]*Customer, 0)
+	for _, c := range r.customers {
+		if c.Enabled {
+			copy := *c
+			customers = append(customers, &copy)
+		}
+	}
+
+	return customers, nil
+}
+
+func (r *mockCustomerRepository) FindByID(id string) (*Customer, error) {
+	r.Called("FindByID", id)
+
+	for _, c := range r.customers {
+		if c.ID.Hex() == id {
+			copy := *c
+			return &copy, nil
+		}
+	}
+
+	return nil, errors.NewInternal("NOT_FOUND").SetPath("sales/repository_mock.FindByID")
+}
+
+func (r *mockCustomerRepository) Insert(c *Customer) error {
+	r.Called("Insert", c)
+
+	copy := *c
+	r.customers = append(r.customers, &copy)
+
+	return nil
+}
+
+func (r *mockCustomerRepository) Update(c *Customer) error {
+	r.Called("Update", c)
+
+	for _, customer := range r.customers {
+		if customer.ID.Hex() == c.ID.Hex() {
+			*customer = *c
+			customer.UpdatedAt = time.Now()
+			break
+		}
+	}
+
+	return nil
+}
+
+func (r *mockCustomerRepository) Delete(id string) error {
+	r.Called("Delete", id)
+
+	for _, c := range r.customers {
+		if c.ID.Hex() == id {
+			c.UpdatedAt = time.Now()
+			c.Enabled = false
+			return nil
+		}
+	}
+
+	return errors.NewInternal("NOT_FOUND").SetPath("sales/repository_mock.Delete")
+}
+
+// Orders
+type mockOrderRepository struct {
+	mock.Mock
+	orders  []*Order
+	entries []*events.Entry
+}
+
+// NewMockOrderRepository returns an in-memory OrderRepository. It is exported
+// to be used by other packages' tests.
+func NewMockOrderRepository() *mockOrderRepository {
+	return &mockOrderRepository{}
+}
+
+func (r *mockOrderRepository) Clean() {
+	r.orders = make([]*Order, 0)
+	r.entries = make([]*events.Entry, 0)
+}
+
+// Entries returns the outbox entries stored with the changes.
+func (r *mockOrderRepository) Entries() []*events.Entry {
+	return r.entries
+}
+
+func (r *mockOrderRepository) FindByID(id string) (*Order, error) {
+	r.Called("FindByID", id)
+
+	for _, o := range r.orders {
+		if o.ID.Hex() == id {
+			return copyOrder(o), nil
+		}
+	}
+
+	return nil, errors.NewInternal("NOT_FOUND").SetPath("sales/repository_mock.FindByID")
+}
+
+func (r *mockOrderRepository) FindByStatus(status ...string) ([]*Order, error) {
+	r.Called("FindByStatus", status)
+
+	orders := make([]*Order, 0)
+	for _, o := range r.orders {
+		for _, s := range status {
+			if o.Status == s {
+				orders = append(orders, copyOrder(o))
+				break
+			}
+		}
+	}
+
+	return orders, nil
+}
+
+func (r *mockOrderRepository) FindByCustomer(customerID string) ([]*Order, error) {
+	r.Called("FindByCustomer", customerID)
+
+	orders := make([]*Order, 0)
+	for _, o := range r.orders {
+		if o.Customer.Hex() == customerID {
+			orders = append(orders, copyOrder(o))
+		}
+	}
+
+	return orders, nil
+}
+
+func (r *mockOrderRepository) FindDelivered(compID string, from, to time.Time) ([]*Order, error) {
+	r.Called("FindDelivered", compID, from, to)
+
+	orders := make([]*Order, 0)
+	for _, o := range r.orders {
+		if o.Status != OrderDelivered || o.FindLine(compID) == nil {
+			continue
+		}
+		if o.DeliveredAt.Before(from) || !o.DeliveredAt.Before(to) {
+			continue
+		}
+		orders = append(orders, copyOrder(o))
+	}
+
+	return orders, nil
+}
+
+func (r *mockOrderRepository) Insert(o *Order) error {
+	r.Called("Insert", o)
+
+	r.orders = append(r.orders, copyOrder(o))
+
+	return nil
+}
+
+func (r *mockOrderRepository) Update(o *Order, entries ...*events.Entry) error {
+	r.Called("Update", o)
+	r.entries = append(r.entries, entries...)
+
+	for _, order := range r.orders {
+		if order.ID.Hex() == o.ID.Hex() {
+			*order = *copyOrder(o)
+			order.UpdatedAt = time.Now()
+			break
+		}
+	}
+
+	return nil
+}
+
+func copyOrder(o *Order) *Order {
+	copy := *o
+	copy.Lines = make([]Line, len(o.Lines))
+	for i, l := range o.Lines {
+		l.Lots = append([]stock.LotComponent(nil), l.Lots...)
+		copy.Lines[i] = l
+	}
+	return &copy
+}
@@ -0,0 +1,450 @@
+package sales
+
+import (
+	"time"
+
+	"github.com/aboglioli/big-brother/composition"
+	"github.com/aboglioli/big-brother/pkg/contact"
+	"github.com/aboglioli/big-brother/pkg/errors"
+	"github.com/aboglioli/big-brother/pkg/quantity"
+	"github.com/aboglioli/big-brother/pricing"
+	"github.com/aboglioli/big-brother/stock"
+)
+
+type Service interface {
+	GetCustomer(id string) (*Customer, error)
+	FindCustomers() ([]*Customer, error)
+	CreateCustomer(req *CreateCustomerRequest) (*Customer, error)
+	UpdateCustomer(id string, req *UpdateCustomerRequest) (*Customer, error)
+	DeleteCustomer(id string) error
+
+	GetOrder(id string) (*Order, error)
+	FindOpenOrders() ([]*Order, error)
+	FindOrdersByCustomer(customerID string) ([]*Order, error)
+	FindDeliveredOrders(compID string, from, to time.Time) ([]*Order, error)
+	CreateOrder(req *CreateOrderRequest) (*Order, error)
+	ConfirmOrder(id string) (*Order, error)
+	DeliverOrder(id string) (*Order, error)
+	CancelOrder(id string) (*Order, error)
+}
+
+type service struct {
+	customerRepository CustomerRepository
+	orderRepository    OrderRepository
+	compositionService composition.Service
+	stockService       stock.Service
+	pricingService     pricing.Service
+}
+
+func NewService(customerRepo CustomerRepository, orderRepo OrderRepository, compServ composition.Service, stockServ stock.Service, pricingServ pricing.Service) Service {
+	return &service{
+		customerRepository: customerRepo,
+		orderRepository:    orderRepo,
+		compositionService: compServ,
+		stockService:       stockServ,
+		pricingService:     pricingServ,
+	}
+}
+
+func (s *service) GetCustomer(id string) (*Customer, error) {
+	c, err := s.customerRepository.FindByID(id)
+	if err != nil || !c.Enabled {
+		return nil, errors.NewStatus("CUSTOMER_NOT_FOUND").SetPath("sales/service.GetCustomer").SetStatus(404).SetRef(err)
+	}
+	return c, nil
+}
+
+func (s *service) FindCustomers() ([]*Customer, error) {
+	customers, err := s.customerRepository.FindAll()
+	if err != nil {
+		return nil, errors.NewStatus("FIND_ALL").SetPath("sales/service.FindCustomers").SetRef(err)
+	}
+	return customers, nil
+}
+
+type CreateCustomerRequest struct {
+	Name    string           `json:"name" binding:"required"`
+	Group   string           `json:"group"`
+	Address *contact.Address `json:"address"`
+	Contact *contact.Contact `json:"contact"`
+	Social  *contact.Social  `json:"social"`
+}
+
+func (s *service) CreateCustomer(req *CreateCustomerRequest) (*Customer, error) {
+	path := "sales/service.CreateCustomer"
+
+	c := NewCustomer()
+	c.Name = req.Name
+	c.Group = req.Group
+	if req.Address != nil {
+		c.Address = *req.Address
+	}
+	if req.Contact != nil {
+		c.Contact = *req.Contact
+	}
+	if req.Social != nil {
+		c.Social = *req.Social
+	}
+
+	if err := c.ValidateSchema(); err != nil {
+		return nil, err
+	}
+
+	if err := s.customerRepository.Insert(c); err != nil {
+		return nil, errors.NewStatus("INSERT").SetPath(path).SetRef(err)
+	}
+
+	return c, nil
+}
+
+type UpdateCustomerRequest struct {
+	Name    *string          `json:"name"`
+	Group   *string          `json:"group"`
+	Address *contact.Address `json:"address"`
+	Contact *contact.Contact `json:"contact"`
+	Social  *contact.Social  `json:"social"`
+}
+
+func (s *service) UpdateCustomer(id string, req *UpdateCustomerRequest) (*Customer, error) {
+	path := "sales/service.UpdateCustomer"
+
+	c, err := s.GetCustomer(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		c.Name = *req.Name
+	}
+	if req.Group != nil {
+		c.Group = *req.Group
+	}
+	if req.Address != nil {
+		c.Address = *req.Address
+	}
+	if req.Contact != nil {
+		c.Contact = *req.Contact
+	}
+	if req.Social != nil {
+		c.Social = *req.Social
+	}
+
+	if err := c.ValidateSchema(); err != nil {
+		return nil, err
+	}
+
+	if err := s.customerRepository.Update(c); err != nil {
+		return nil, errors.NewStatus("UPDATE").SetPath(path).SetRef(err)
+	}
+
+	return c, nil
+}
+
+func (s *service) DeleteCustomer(id string) error {
+	if _, err := s.GetCustomer(id); err != nil {
+		return err
+	}
+
+	if err := s.customerRepository.Delete(id); err != nil {
+		return errors.NewStatus("DELETE").SetPath("sales/service.DeleteCustomer").SetRef(err)
+	}
+
+	return nil
+}
+
+func (s *service) GetOrder(id string) (*Order, error) {
+	o, err := s.orderRepository.FindByID(id)
+	if err != nil {
+		return nil, errors.NewStatus("ORDER_NOT_FOUND").SetPath("sales/service.GetOrder").SetStatus(404).SetRef(err)
+	}
+	return o, nil
+}
+
+func (s *service) FindOpenOrders() ([]*Order, error) {
+	orders, err := s.orderRepository.FindByStatus(OrderDraft, OrderConfirmed)
+	if err != nil {
+		return nil, errors.NewStatus("FIND_ORDERS").SetPath("sales/service.FindOpenOrders").SetRef(err)
+	}
+	return orders, nil
+}
+
+func (s *service) FindOrdersByCustomer(customerID string) ([]*Order, error) {
+	orders, err := s.orderRepository.FindByCustomer(customerID)
+	if err != nil {
+		return nil, errors.NewStatus("FIND_ORDERS").SetPath("sales/service.FindOrdersByCustomer").SetRef(err)
+	}
+	return orders, nil
+}
+
+// FindDeliveredOrders finds the orders including a composition delivered in
+// [from, to).
+func (s *service) FindDeliveredOrders(compID string, from, to time.Time) ([]*Order, error) {
+	orders, err := s.orderRepository.FindDelivered(compID, from, to)
+	if err != nil {
+		return nil, errors.NewStatus("FIND_ORDERS").SetPath("sales/service.FindDeliveredOrders").SetRef(err)
+	}
+	return orders, nil
+}
+
+type LineRequest struct {
+	Composition string            `json:"composition" binding:"required"`
+	Quantity    quantity.Quantity `json:"quantity" binding:"required"`
+	Price       *float64          `json:"price"`
+}
+
+type CreateOrderRequest struct {
+	Customer  string        `json:"customer" binding:"required"`
+	Lines     []LineRequest `json:"lines" binding:"required"`
+	Reference string        `json:"reference"`
+}
+
+// CreateOrder creates a draft sales order. Line prices default to the price
+// lists of the customer group.
+func (s *service) CreateOrder(req *CreateOrderRequest) (*Order, error) {
+	path := "sales/service.CreateOrder"
+
+	c, err := s.GetCustomer(req.Customer)
+	if err != nil {
+		return nil, err
+	}
+
+	o := NewOrder(c.ID)
+	o.Reference = req.Reference
+
+	for i, l := range req.Lines {
+		comp, err := s.compositionService.GetByID(l.Composition)
+		if err != nil {
+			return nil, err
+		}
+
+		if !comp.Compatible(l.Quantity) {
+			return nil, errors.NewStatus("INCOMPATIBLE_QUANTITY").SetPath(path).SetMessage("Line %d: %v != %v", i, l.Quantity, comp.Unit)
+		}
+
+		var price float64
+		if l.Price != nil {
+			price = *l.Price
+		} else {
+			quote, err := s.pricingService.Quote(&pricing.QuoteRequest{
+				Composition: l.Composition,
+				Quantity:    l.Quantity,
+				Group:       c.Group,
+			})
+			if err != nil {
+				return nil, errors.NewStatus("PRICE_REQUIRED").SetPath(path).SetMessage("Line %d: %s", i, l.Composition).SetRef(err)
+			}
+			price = quote.Price
+		}
+
+		if err := o.AddLine(comp.ID, l.Quantity, price); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := o.ValidateSchema(); err != nil {
+		return nil, err
+	}
+
+	if err := s.orderRepository.Insert(o); err != nil {
+		return nil, errors.NewStatus("INSERT").SetPath(path).SetRef(err)
+	}
+
+	return o, nil
+}
+
+// ConfirmOrder confirms a draft order and reserves stock for each line. If
+// any line cannot be reserved, previous reservations are released.
+/**
+* @api {topic} sales.order.confirmed sales.order.confirmed
+* @apiName SalesOrderConfirmed
+* @apiGroup RabbitMQ
+*
+* @apiDescription Emits a new event when a sales order is confirmed and its
+* stock is reserved.
+*
+* @apiSuccessExample {json} Body
+* {
+* 	"type": "SalesOrderConfirmed",
+* 	"order": order data
+* }
+ */
+func (s *service) ConfirmOrder(id string) (*Order, error) {
+	path := "sales/service.ConfirmOrder"
+
+	o, err := s.GetOrder(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := o.Confirm(); err != nil {
+		return nil, err
+	}
+
+	for i, l := range o.Lines {
+		if _, err := s.compositionService.Reserve(l.Composition.Hex(), l.Quantity); err != nil {
+			s.release(o.Lines[:i])
+			return nil, err
+		}
+	}
+
+	// Publish event through the outbox: sales.order.confirmed
+	entry, err := newOrderEntry(NewOrderConfirmedEvent(o))
+	if err != nil {
+		s.release(o.Lines)
+		return nil, errors.NewStatus("ENCODE_EVENT").SetPath(path).SetRef(err)
+	}
+
+	if err := s.orderRepository.Update(o, entry); err != nil {
+		s.release(o.Lines)
+		return nil, errors.NewStatus("UPDATE").SetPath(path).SetRef(err)
+	}
+
+	return o, nil
+}
+
+// DeliverOrder issues the reserved stock of a confirmed order
+// (first-expired-first-out), releasing its reservations. The order is saved
+// after each issued line and issued lines are skipped, so a failed delivery
+// can be retried.
+/**
+* @api {topic} sales.order.delivered sales.order.delivered
+* @apiName SalesOrderDelivered
+* @apiGroup RabbitMQ
+*
+* @apiDescription Emits a new event when a sales order is delivered. Issued
+* lots are included in each line.
+*
+* @apiSuccessExample {json} Body
+* {
+* 	"type": "SalesOrderDelivered",
+* 	"order": order data
+* }
+ */
+func (s *service) DeliverOrder(id string) (*Order, error) {
+	path := "sales/service.DeliverOrder"
+
+	o, err := s.GetOrder(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if o.Status != OrderConfirmed {
+		return nil, errors.NewStatus("ORDER_NOT_CONFIRMED").SetPath(path)
+	}
+
+	for i := range o.Lines {
+		l := &o.Lines[i]
+		if l.IsIssued() {
+			continue
+		}
+
+		comp, err := s.compositionService.GetByID(l.Composition.Hex())
+		if err != nil {
+			return nil, err
+		}
+
+		remaining, err := l.Remaining(comp)
+		if err != nil {
+			return nil, errors.NewStatus("INCOMPATIBLE_QUANTITY").SetPath(path).SetMessage("%v != %v", l.Quantity, comp.Unit).SetRef(err)
+		}
+
+		// Each consumed lot is saved in the line, so a retried delivery only
+		// issues what remains
+		if remaining.Quantity > 0 {
+			_, err := s.stockService.Issue(&stock.IssueRequest{
+				Composition: l.Composition.Hex(),
+				Quantity:    remaining,
+				Reference:   o.ID.Hex(),
+				Reserved:    true,
+				Consumed: func(a stock.LotComponent) error {
+					l.Lots = append(l.Lots, a)
+					if err := s.orderRepository.Update(o); err != nil {
+						return errors.NewStatus("UPDATE").SetPath(path).SetRef(err)
+					}
+					return nil
+				},
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		l.Issued = true
+		if err := s.orderRepository.Update(o); err != nil {
+			return nil, errors.NewStatus("UPDATE").SetPath(path).SetRef(err)
+		}
+	}
+
+	if err := o.Deliver(); err != nil {
+		return nil, err
+	}
+
+	// Publish event through the outbox: sales.order.delivered
+	entry, err := newOrderEntry(NewOrderDeliveredEvent(o))
+	if err != nil {
+		return nil, errors.NewStatus("ENCODE_EVENT").SetPath(path).SetRef(err)
+	}
+
+	if err := s.orderRepository.Update(o, entry); err != nil {
+		return nil, errors.NewStatus("UPDATE").SetPath(path).SetRef(err)
+	}
+
+	return o, nil
+}
+
+// CancelOrder cancels a draft or confirmed order. Reservations of confirmed
+// orders are released.
+/**
+* @api {topic} sales.order.cancelled sales.order.cancelled
+* @apiName SalesOrderCancelled
+* @apiGroup RabbitMQ
+*
+* @apiDescription Emits a new event when a sales order is cancelled.
+*
+* @apiSuccessExample {json} Body
+* {
+* 	"type": "SalesOrderCancelled",
+* 	"order": order data
+* }
+ */
+func (s *service) CancelOrder(id string) (*Order, error) {
+	path := "sales/service.CancelOrder"
+
+	o, err := s.GetOrder(id)
+	if err != nil {
+		return nil, err
+	}
+
+	confirmed := o.Status == OrderConfirmed
+
+	if err := o.Cancel(); err != nil {
+		return nil, err
+	}
+
+	if confirmed {
+		if err := s.release(o.Lines); err != nil {
+			return nil, err
+		}
+	}
+
+	// Publish event through the outbox: sales.order.cancelled
+	entry, err := newOrderEntry(NewOrderCancelledEvent(o))
+	if err != nil {
+		return nil, errors.NewStatus("ENCODE_EVENT").SetPath(path).SetRef(err)
+	}
+
+	if err := s.orderRepository.Update(o, entry); err != nil {
+		return nil, errors.NewStatus("UPDATE").SetPath(path).SetRef(err)
+	}
+
+	return o, nil
+}
+
+func (s *service) release(lines []Line) error {
+	for _, l := range lines {
+		if _, err := s.compositionService.Release(l.Composition.Hex(), l.Quantity); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,268 @@
+package sales
+
+import (
+	"testing"
+
+	"github.com/aboglioli/big-brother/composition"
+	"github.com/aboglioli/big-brother/pkg/errors"
+	"github.com/aboglioli/big-brother/pkg/events"
+	"github.com/aboglioli/big-brother/pkg/quantity"
+	"github.com/aboglioli/big-brother/pkg/tests/assert"
+	"github.com/aboglioli/big-brother/pricing"
+	"github.com/aboglioli/big-brother/stock"
+)
+
+func newComposition(unit quantity.Quantity, cost float64) *composition.Composition {
+	comp := composition.NewComposition()
+	comp.Cost = cost
+	comp.Unit = unit
+	comp.Stock = quantity.Quantity{0, unit.Unit}
+	comp.Validated = true
+	return comp
+}
+
+func price(p float64) *float64 {
+	return &p
+}
+
+// failingLotRepository fails to update lots after a number of updates.
+type failingLotRepository struct {
+	stock.LotRepository
+	failing bool
+	allowed int
+}
+
+func (r *failingLotRepository) Update(l *stock.Lot, entries ...*events.Entry) error {
+	if r.failing {
+		if r.allowed == 0 {
+			return errors.NewInternal("UNAVAILABLE")
+		}
+		r.allowed--
+	}
+	return r.LotRepository.Update(l, entries...)
+}
+
+func TestSalesOrder(t *testing.T) {
+	compRepo, eventMgr := composition.NewMockRepository(), events.GetMockManager()
+	compServ := composition.NewService(compRepo)
+	lotRepo := &failingLotRepository{LotRepository: stock.NewMockLotRepository()}
+	stockServ := stock.NewService(lotRepo, stock.NewMockMovementRepository(), stock.NewMockCountRepository(), compServ)
+	pricingServ := pricing.NewService(pricing.NewMockRepository(), compServ, eventMgr, 0.2)
+	orderRepo := NewMockOrderRepository()
+	serv := NewService(NewMockCustomerRepository(), orderRepo, compServ, stockServ, pricingServ)
+
+	cheese := newComposition(quantity.Quantity{1, "kg"}, 100)
+	compRepo.Insert(cheese)
+	_, err := stockServ.Receive(&stock.ReceiveRequest{Composition: cheese.ID.Hex(), Quantity: quantity.Quantity{5, "kg"}})
+	assert.Ok(t, err)
+
+	customer, err := serv.CreateCustomer(&CreateCustomerRequest{Name: "Restaurant", Group: "restaurants"})
+	assert.Ok(t, err)
+
+	newOrder := func(q quantity.Quantity) *Order {
+		o, err := serv.CreateOrder(&CreateOrderRequest{
+			Customer: customer.ID.Hex(),
+			Lines:    []LineRequest{LineRequest{Composition: cheese.ID.Hex(), Quantity: q, Price: price(q.Normalize() / 10)}},
+		})
+		assert.Ok(t, err)
+		return o
+	}
+
+	t.Run("Invalid lines", func(t *testing.T) {
+		_, err := serv.CreateOrder(&CreateOrderRequest{
+			Customer: customer.ID.Hex(),
+			Lines:    []LineRequest{LineRequest{Composition: cheese.ID.Hex(), Quantity: quantity.Quantity{1, "l"}, Price: price(1)}},
+		})
+		assert.ErrCode(t, err, "INCOMPATIBLE_QUANTITY")
+
+		_, err = serv.CreateOrder(&CreateOrderRequest{
+			Customer: customer.ID.Hex(),
+			Lines:    []LineRequest{LineRequest{Composition: cheese.ID.Hex(), Quantity: quantity.Quantity{1, "kg"}}},
+		})
+		assert.ErrCode(t, err, "PRICE_REQUIRED")
+	})
+
+	t.Run("Price from price list", func(t *testing.T) {
+		pl, _ := pricingServ.Create(&pricing.CreateRequest{Name: "Restaurants", Group: "restaurants"})
+		pricingServ.SetEntry(pl.ID.Hex(), &pricing.EntryRequest{Composition: cheese.ID.Hex(), Quantity: quantity.Quantity{1, "kg"}, Price: 150})
+
+		o, err := serv.CreateOrder(&CreateOrderRequest{
+			Customer: customer.ID.Hex(),
+			Lines:    []LineRequest{LineRequest{Composition: cheese.ID.Hex(), Quantity: quantity.Quantity{500, "g"}}},
+		})
+		assert.Ok(t, err)
+		assert.Equal(t, o.Total(), 75.0)
+	})
+
+	o1 := newOrder(quantity.Quantity{3000, "g"})
+	o2 := newOrder(quantity.Quantity{2500, "g"})
+	assert.Equal(t, o1.Status, OrderDraft)
+	assert.Equal(t, o1.Total(), 300.0)
+
+	t.Run("Confirm reserves stock", func(t *testing.T) {
+		o, err := serv.ConfirmOrder(o1.ID.Hex())
+		assert.Ok(t, err)
+		assert.Equal(t, o.Status, OrderConfirmed)
+
+		comp, _ := compRepo.FindByID(cheese.ID.Hex())
+		assert.Assert(t, comp.Reserved.Equals(quantity.Quantity{3, "kg"}))
+		assert.Assert(t, comp.Stock.Equals(quantity.Quantity{5, "kg"}))
+
+		entries := orderRepo.Entries()
+		assert.Equal(t, entries[len(entries)-1].Type(), "SalesOrderConfirmed")
+		assert.Equal(t, entries[len(entries)-1].Key, "sales.order.confirmed")
+
+		_, err = serv.ConfirmOrder(o2.ID.Hex())
+		assert.ErrCode(t, err, "INSUFFICIENT_AVAILABLE_STOCK")
+
+		o, _ = serv.GetOrder(o2.ID.Hex())
+		assert.Equal(t, o.Status, OrderDraft)
+	})
+
+	t.Run("Deliver issues stock", func(t *testing.T) {
+		_, err := serv.DeliverOrder(o2.ID.Hex())
+		assert.ErrCode(t, err, "ORDER_NOT_CONFIRMED")
+
+		o, err := serv.DeliverOrder(o1.ID.Hex())
+		assert.Ok(t, err)
+		assert.Equal(t, o.Status, OrderDelivered)
+		assert.Equal(t, len(o.Lines[0].Lots), 1)
+
+		comp, _ := compRepo.FindByID(cheese.ID.Hex())
+		assert.Assert(t, comp.Reserved.Equals(quantity.Quantity{0, "kg"}))
+		assert.Assert(t, comp.Stock.Equals(quantity.Quantity{2, "kg"}))
+
+		entries := orderRepo.Entries()
+		assert.Equal(t, entries[len(entries)-1].Type(), "SalesOrderDelivered")
+
+		_, err = serv.CancelOrder(o1.ID.Hex())
+		assert.ErrCode(t, err, "ORDER_CANNOT_BE_CANCELLED")
+	})
+
+	t.Run("Cancel releases stock", func(t *testing.T) {
+		o3 := newOrder(quantity.Quantity{2, "kg"})
+		_, err := serv.ConfirmOrder(o3.ID.Hex())
+		assert.Ok(t, err)
+
+		o, err := serv.CancelOrder(o3.ID.Hex())
+		assert.Ok(t, err)
+		assert.Equal(t, o.Status, OrderCancelled)
+
+		comp, _ := compRepo.FindByID(cheese.ID.Hex())
+		assert.Assert(t, comp.Available().Equals(quantity.Quantity{2, "kg"}))
+	})
+
+	t.Run("Failed delivery can be retried", func(t *testing.T) {
+		ham := newComposition(quantity.Quantity{1, "kg"}, 50)
+		compRepo.Insert(ham)
+		hamLot, err := stockServ.Receive(&stock.ReceiveRequest{Composition: ham.ID.Hex(), Quantity: quantity.Quantity{1, "kg"}})
+		assert.Ok(t, err)
+
+		o4, err := serv.CreateOrder(&CreateOrderRequest{
+			Customer: customer.ID.Hex(),
+			Lines: []LineRequest{
+				LineRequest{Composition: cheese.ID.Hex(), Quantity: quantity.Quantity{1, "kg"}, Price: price(10)},
+				LineRequest{Composition: ham.ID.Hex(), Quantity: quantity.Quantity{1, "kg"}, Price: price(5)},
+			},
+		})
+		assert.Ok(t, err)
+		_, err = serv.ConfirmOrder(o4.ID.Hex())
+		assert.Ok(t, err)
+
+		// The reserved ham is quarantined before delivery
+		_, err = stockServ.BlockLot(hamLot.ID.Hex())
+		assert.Ok(t, err)
+
+		_, err = serv.DeliverOrder(o4.ID.Hex())
+		assert.ErrCode(t, err, "INSUFFICIENT_LOTS")
+
+		o, _ := serv.GetOrder(o4.ID.Hex())
+		assert.Equal(t, o.Status, OrderConfirmed)
+		assert.Assert(t, o.Lines[0].IsIssued())
+		assert.Assert(t, !o.Lines[1].IsIssued())
+
+		_, err = serv.CancelOrder(o4.ID.Hex())
+		assert.ErrCode(t, err, "ORDER_CANNOT_BE_CANCELLED")
+
+		_, err = stockServ.Receive(&stock.ReceiveRequest{Composition: ham.ID.Hex(), Quantity: quantity.Quantity{1, "kg"}})
+		assert.Ok(t, err)
+
+		o, err = serv.DeliverOrder(o4.ID.Hex())
+		assert.Ok(t, err)
+		assert.Equal(t, o.Status, OrderDelivered)
+
+		comp, _ := compRepo.FindByID(cheese.ID.Hex())
+		assert.Assert(t, comp.Stock.Equals(quantity.Quantity{1, "kg"}), "Issued lines are not issued again")
+		assert.Assert(t, comp.Reserved.Equals(quantity.Quantity{0, "kg"}))
+		comp, _ = compRepo.FindByID(ham.ID.Hex())
+		assert.Assert(t, comp.Stock.Equals(quantity.Quantity{1, "kg"}))
+		assert.Assert(t, comp.Reserved.Equals(quantity.Quantity{0, "kg"}))
+	})
+
+	t.Run("Failed delivery resumes from the issued lots", func(t *testing.T) {
+		wine := newComposition(quantity.Quantity{1, "l"}, 20)
+		compRepo.Insert(wine)
+		for i := 0; i < 2; i++ {
+			_, err := stockServ.Receive(&stock.ReceiveRequest{Composition: wine.ID.Hex(), Quantity: quantity.Quantity{1, "l"}})
+			assert.Ok(t, err)
+		}
+
+		o5, err := serv.CreateOrder(&CreateOrderRequest{
+			Customer: customer.ID.Hex(),
+			Lines:    []LineRequest{LineRequest{Composition: wine.ID.Hex(), Quantity: quantity.Quantity{2, "l"}, Price: price(40)}},
+		})
+		assert.Ok(t, err)
+		_, err = serv.ConfirmOrder(o5.ID.Hex())
+		assert.Ok(t, err)
+
+		// The second lot can't be consumed
+		lotRepo.failing, lotRepo.allowed = true, 1
+		_, err = serv.DeliverOrder(o5.ID.Hex())
+		assert.Err(t, err)
+		lotRepo.failing = false
+
+		o, _ := serv.GetOrder(o5.ID.Hex())
+		assert.Equal(t, len(o.Lines[0].Lots), 1)
+		assert.Assert(t, !o.Lines[0].IsIssued())
+		comp, _ := compRepo.FindByID(wine.ID.Hex())
+		remaining, err := o.Lines[0].Remaining(comp)
+		assert.Ok(t, err)
+		assert.Assert(t, remaining.Equals(quantity.Quantity{1, "l"}))
+
+		o, err = serv.DeliverOrder(o5.ID.Hex())
+		assert.Ok(t, err)
+		assert.Equal(t, o.Status, OrderDelivered)
+		assert.Equal(t, len(o.Lines[0].Lots), 2)
+		assert.Assert(t, o.Lines[0].IsIssued())
+
+		comp, _ = compRepo.FindByID(wine.ID.Hex())
+		assert.Assert(t, comp.Stock.Equals(quantity.Quantity{0, "l"}), "Consumed lots are not issued again")
+		assert.Assert(t, comp.Reserved.Equals(quantity.Quantity{0, "l"}))
+	})
+
+	t.Run("Lines in converted units", func(t *testing.T) {
+		beer := newComposition(quantity.Quantity{1, "u"}, 2)
+		beer.Conversions = []quantity.Conversion{
+			quantity.Conversion{From: quantity.Quantity{1, "box"}, To: quantity.Quantity{6, "u"}},
+		}
+		compRepo.Insert(beer)
+		_, err := stockServ.Receive(&stock.ReceiveRequest{Composition: beer.ID.Hex(), Quantity: quantity.Quantity{1, "box"}})
+		assert.Ok(t, err)
+
+		o6, err := serv.CreateOrder(&CreateOrderRequest{
+			Customer: customer.ID.Hex(),
+			Lines:    []LineRequest{LineRequest{Composition: beer.ID.Hex(), Quantity: quantity.Quantity{1, "box"}, Price: price(15)}},
+		})
+		assert.Ok(t, err)
+		_, err = serv.ConfirmOrder(o6.ID.Hex())
+		assert.Ok(t, err)
+
+		o, err := serv.DeliverOrder(o6.ID.Hex())
+		assert.Ok(t, err)
+		assert.Assert(t, o.Lines[0].IsIssued())
+
+		comp, _ := compRepo.FindByID(beer.ID.Hex())
+		assert.Assert(t, comp.Stock.Equals(quantity.Quantity{0, "u"}))
+		assert.Assert(t, comp.Reserved.Equals(quantity.Quantity{0, "u"}))
+	})
+}
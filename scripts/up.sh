@@ -1,2 +1,11 @@
 #!/bin/bash
 docker-compose up -d mongo-express redis-commander rabbitmq
+
+# Initiate the replica set needed by transactions, once mongo accepts
+# connections
+until docker-compose exec -T mongo mongosh --quiet \
+  -u "${MONGO_USER:-admin}" -p "${MONGO_PASSWORD:-admin}" \
+  --eval 'try { rs.status().ok } catch (e) { rs.initiate({_id: "rs0", members: [{_id: 0, host: "localhost:27017"}]}).ok }' \
+  > /dev/null 2>&1; do
+  sleep 1
+done
@@ -0,0 +1,138 @@
+package stock
+
+import (
+	"time"
+
+	"github.com/aboglioli/big-brother/pkg/errors"
+	"github.com/aboglioli/big-brother/pkg/quantity"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	CountOpen      = "OPEN"
+	CountApproved  = "APPROVED"
+	CountCancelled = "CANCELLED"
+)
+
+// LocationCount is the quantity counted in a location
+type LocationCount struct {
+	Location string            `json:"location" bson:"location"`
+	Quantity quantity.Quantity `json:"quantity" bson:"quantity"`
+}
+
+// CountLine compares the counted quantity of a composition with the system
+// stock when the count was opened. Variance is counted minus system stock
+// and Cost is its cost impact.
+type CountLine struct {
+	Composition primitive.ObjectID  `json:"composition" bson:"composition"`
+	System      quantity.Quantity   `json:"system" bson:"system"`
+	Counts      []LocationCount     `json:"counts" bson:"counts"`
+	Counted     quantity.Quantity   `json:"counted" bson:"counted"`
+	Variance    quantity.Quantity   `json:"variance" bson:"variance"`
+	Cost        float64             `json:"cost" bson:"cost"`
+	Movement    *primitive.ObjectID `json:"movement" bson:"movement"`
+}
+
+func (l *CountLine) IsCounted() bool {
+	return len(l.Counts) > 0
+}
+
+// Count is a physical stock count session for a set of compositions, and
+// optionally a set of locations.
+type Count struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id"`
+	Status    string             `json:"status" bson:"status"`
+	Locations []string           `json:"locations" bson:"locations"`
+	Lines     []CountLine        `json:"lines" bson:"lines"`
+
+	ApprovedAt *time.Time `json:"approvedAt" bson:"approvedAt"`
+	CreatedAt  time.Time  `json:"createdAt" bson:"createdAt"`
+	UpdatedAt  time.Time  `json:"updatedAt" bson:"updatedAt"`
+}
+
+func NewCount() *Count {
+	return &Count{
+		ID:        primitive.NewObjectID(),
+		Status:    CountOpen,
+		Locations: make([]string, 0),
+		Lines:     make([]CountLine, 0),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+}
+
+func (c *Count) IsOpen() bool {
+	return c.Status == CountOpen
+}
+
+// AddLine adds a composition to the count with its current system stock.
+func (c *Count) AddLine(compID primitive.ObjectID, system quantity.Quantity) {
+	c.Lines = append(c.Lines, CountLine{
+		Composition: compID,
+		System:      system,
+		Counts:      make([]LocationCount, 0),
+	})
+}
+
+func (c *Count) FindLine(compID string) *CountLine {
+	for i := range c.Lines {
+		if c.Lines[i].Composition.Hex() == compID {
+			return &c.Lines[i]
+		}
+	}
+	return nil
+}
+
+// Record sets the counted quantity of a composition in a location. Counting a
+// location again replaces the previous quantity. Quantity can be expressed in
+// any unit compatible with the system stock.
+func (c *Count) Record(compID string, location string, q quantity.Quantity) (*CountLine, error) {
+	path := "stock/count.Record"
+
+	if !c.IsOpen() {
+		return nil, errors.NewStatus("COUNT_NOT_OPEN").SetPath(path)
+	}
+
+	line := c.FindLine(compID)
+	if line == nil {
+		return nil, errors.NewStatus("COMPOSITION_NOT_IN_COUNT").SetPath(path).SetMessage("%s", compID)
+	}
+
+	if len(c.Locations) > 0 && !c.hasLocation(location) {
+		return nil, errors.NewStatus("LOCATION_NOT_IN_COUNT").SetPath(path).SetMessage("%s", location)
+	}
+
+	if !q.IsValid() || !q.Compatible(line.System) {
+		return nil, errors.NewStatus("INCOMPATIBLE_QUANTITY").SetPath(path).SetMessage("%v != %v", q, line.System)
+	}
+
+	recorded := false
+	for i, lc := range line.Counts {
+		if lc.Location == location {
+			line.Counts[i].Quantity = q
+			recorded = true
+			break
+		}
+	}
+	if !recorded {
+		line.Counts = append(line.Counts, LocationCount{location, q})
+	}
+
+	counted := quantity.Quantity{Quantity: 0, Unit: line.System.Unit}
+	for _, lc := range line.Counts {
+		counted, _ = counted.Add(lc.Quantity)
+	}
+	line.Counted = counted
+	line.Variance, _ = counted.Subtract(line.System)
+
+	return line, nil
+}
+
+func (c *Count) hasLocation(location string) bool {
+	for _, l := range c.Locations {
+		if l == location {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,131 @@
+package stock
+
+import (
+	"context"
+	"time"
+
+	"github.com/aboglioli/big-brother/infrastructure/db"
+	"github.com/aboglioli/big-brother/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type CountRepository interface {
+	FindByID(id string) (*Count, error)
+	FindByStatus(status string) ([]*Count, error)
+
+	Insert(*Count) error
+	Update(*Count) error
+}
+
+type countRepository struct {
+	collection *mongo.Collection
+}
+
+func NewCountRepository() (CountRepository, error) {
+	db, err := db.Get("Stock")
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &countRepository{
+		collection: db.Collection("count"),
+	}, nil
+}
+
+func (r *countRepository) FindByID(id string) (*Count, error) {
+	path := "stock/count_repository.FindByID"
+	ctx := context.Background()
+
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errors.NewInternal("OBJECTID_FROM_HEX").SetPath(path).SetRef(err)
+	}
+
+	filter := bson.M{
+		"_id": objID,
+	}
+
+	res := r.collection.FindOne(ctx, filter)
+	if res.Err() != nil {
+		return nil, errors.NewInternal("FIND_ONE").SetPath(path).SetRef(res.Err())
+	}
+
+	var count Count
+	if err := res.Decode(&count); err != nil {
+		return nil, errors.NewInternal("DECODE").SetPath(path).SetRef(err)
+	}
+
+	return &count, nil
+}
+
+func (r *countRepository) FindByStatus(status string) ([]*Count, error) {
+	path := "stock/count_repository.FindByStatus"
+	ctx := context.Background()
+
+	filter := bson.M{
+		"status": status,
+	}
+
+	cur, err := r.collection.Find(ctx, filter)
+	if err != nil {
+		return nil, errors.NewInternal("FIND").SetPath(path).SetRef(err)
+	}
+	defer cur.Close(ctx)
+
+	counts := make([]*Count, 0)
+	for cur.Next(ctx) {
+		var count Count
+
+		if err := cur.Decode(&count); err != nil {
+			return nil, errors.NewInternal("CUR_DECODE").SetPath(path).SetRef(err)
+		}
+
+		counts = append(counts, &count)
+	}
+
+	if err := cur.Err(); err != nil {
+		return nil, errors.NewInternal("CUR").SetPath(path).SetRef(err)
+	}
+
+	return counts, nil
+}
+
+func (r *countRepository) Insert(c *Count) error {
+	ctx := context.Background()
+
+	_, err := r.collection.InsertOne(ctx, c)
+	if err != nil {
+		return errors.NewInternal("INSERT_ONE").SetPath("stock/count_repository.Insert").SetRef(err)
+	}
+
+	return nil
+}
+
+func (r *countRepository) Update(c *Count) error {
+	path := "stock/count_repository.Update"
+	ctx := context.Background()
+
+	if c.ID.IsZero() {
+		return errors.NewInternal("INVALID_OBJECTID").SetPath(path)
+	}
+
+	c.UpdatedAt = time.Now()
+
+	filter := bson.M{
+		"_id": c.ID,
+	}
+
+	update := bson.M{
+		"$set": c,
+	}
+
+	_, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return errors.NewInternal("UPDATE_ONE").SetPath(path).SetRef(err)
+	}
+
+	return nil
+}
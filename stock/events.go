@@ -0,0 +1,40 @@
+package stock
+
+import (
+	"github.com/aboglioli/big-brother/pkg/events"
+)
+
+// LotChangedEvent is a single lot change
+type LotChangedEvent struct {
+	events.Event
+	Lot *Lot `json:"lot"`
+}
+
+// MovementPostedEvent is published for each stock movement
+type MovementPostedEvent struct {
+	events.Event
+	Movement *Movement `json:"movement"`
+}
+
+func NewLotCreatedEvent(l *Lot) (*LotChangedEvent, *events.Options) {
+	event := &LotChangedEvent{events.NewEvent("LotCreated", l.ID.Hex()), l}
+	opts := &events.Options{"stock", "topic", "stock.lot.created", ""}
+	return event, opts
+}
+
+func NewLotBlockedEvent(l *Lot) (*LotChangedEvent, *events.Options) {
+	event := &LotChangedEvent{events.NewEvent("LotBlocked", l.ID.Hex()), l}
+	opts := &events.Options{"stock", "topic", "stock.lot.blocked", ""}
+	return event, opts
+}
+
+func NewMovementPostedEvent(m *Movement) (*MovementPostedEvent, *events.Options) {
+	event := &MovementPostedEvent{events.NewEvent("StockMovementPosted", m.ID.Hex()), m}
+	opts := &events.Options{"stock", "topic", "stock.movement", ""}
+	return event, opts
+}
+
+// newLotEntry returns the outbox entry of a lot event.
+func newLotEntry(event *LotChangedEvent, opts *events.Options) (*events.Entry, error) {
+	return events.NewEntry(event.Lot.ID.Hex(), event, opts)
+}
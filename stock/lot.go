@@ -0,0 +1,178 @@
+package stock
+
+import (
+	"sort"
+	"time"
+
+	"github.com/aboglioli/big-brother/pkg/errors"
+	"github.com/aboglioli/big-brother/pkg/quantity"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	OriginReceipt    = "RECEIPT"
+	OriginProduction = "PRODUCTION"
+	OriginAdjustment = "ADJUSTMENT"
+)
+
+// epsilon is the tolerance used to compare normalized quantities
+const epsilon = 1e-9
+
+// LotComponent is a quantity taken from a lot. It's used to know which lots
+// were consumed to produce another lot, and which lots were issued.
+type LotComponent struct {
+	Lot         primitive.ObjectID `json:"lot" bson:"lot"`
+	Composition primitive.ObjectID `json:"composition" bson:"composition"`
+	Quantity    quantity.Quantity  `json:"quantity" bson:"quantity"`
+}
+
+// Lot is a batch of a composition received from a supplier or produced.
+type Lot struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id"`
+	Code        string             `json:"code" bson:"code"`
+	Composition primitive.ObjectID `json:"composition" bson:"composition"`
+	Origin      string             `json:"origin" bson:"origin"`
+	Quantity    quantity.Quantity  `json:"quantity" bson:"quantity"`
+	Remaining   quantity.Quantity  `json:"remaining" bson:"remaining"`
+	Components  []LotComponent     `json:"components" bson:"components"`
+
+	Date      time.Time  `json:"date" bson:"date"`
+	ExpiresAt *time.Time `json:"expiresAt" bson:"expiresAt"`
+	Blocked   bool       `json:"blocked" bson:"blocked"`
+
+	CreatedAt time.Time `json:"createdAt" bson:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt" bson:"updatedAt"`
+}
+
+func NewLot(compID primitive.ObjectID, origin string) *Lot {
+	return &Lot{
+		ID:          primitive.NewObjectID(),
+		Composition: compID,
+		Origin:      origin,
+		Components:  make([]LotComponent, 0),
+		Date:        time.Now(),
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+}
+
+func (l *Lot) IsExpired(t time.Time) bool {
+	return l.ExpiresAt != nil && !l.ExpiresAt.After(t)
+}
+
+// IsAvailable returns true if the lot can be consumed at the given time.
+func (l *Lot) IsAvailable(t time.Time) bool {
+	return !l.Blocked && !l.IsExpired(t) && l.Remaining.Quantity > epsilon
+}
+
+func (l *Lot) Consume(q quantity.Quantity) error {
+	path := "stock/lot.Consume"
+
+	if greater, err := q.Greater(l.Remaining); err != nil || greater {
+		return errors.NewStatus("INSUFFICIENT_LOT_QUANTITY").SetPath(path).SetMessage("%v < %v", l.Remaining, q)
+	}
+
+	remaining, err := l.Remaining.Subtract(q)
+	if err != nil {
+		return errors.NewStatus("INVALID_QUANTITY").SetPath(path).SetRef(err)
+	}
+	if remaining.Quantity < 0 {
+		remaining.Quantity = 0
+	}
+	l.Remaining = remaining
+
+	return nil
+}
+
+func (l *Lot) ValidateSchema() error {
+	err := errors.NewValidation("VALIDATE_SCHEMA").SetPath("stock/lot.ValidateSchema")
+
+	if l.Composition.IsZero() {
+		err.Add("composition", "INVALID")
+	}
+	if l.Origin != OriginReceipt && l.Origin != OriginProduction && l.Origin != OriginAdjustment {
+		err.Add("origin", "INVALID")
+	}
+	if !l.Quantity.IsValid() || l.Quantity.Quantity == 0 {
+		err.Add("quantity", "INVALID")
+	}
+	if !l.Remaining.IsValid() || !l.Remaining.Compatible(l.Quantity) {
+		err.Add("remaining", "INVALID")
+	}
+	if l.ExpiresAt != nil && l.ExpiresAt.Before(l.Date) {
+		err.Add("expiresAt", "BEFORE_DATE")
+	}
+
+	if err.Size() > 0 {
+		return err
+	}
+
+	return nil
+}
+
+// SortFEFO sorts lots first-expired-first-out. Lots without expiration date
+// go last, and ties are resolved by date (first-in-first-out).
+func SortFEFO(lots []*Lot) {
+	sort.SliceStable(lots, func(i, j int) bool {
+		l1, l2 := lots[i], lots[j]
+
+		switch {
+		case l1.ExpiresAt != nil && l2.ExpiresAt == nil:
+			return true
+		case l1.ExpiresAt == nil && l2.ExpiresAt != nil:
+			return false
+		case l1.ExpiresAt != nil && !l1.ExpiresAt.Equal(*l2.ExpiresAt):
+			return l1.ExpiresAt.Before(*l2.ExpiresAt)
+		}
+
+		return l1.Date.Before(l2.Date)
+	})
+}
+
+// AllocateFEFO selects the quantities to take from available lots to satisfy
+// q, following FEFO. Lots are not modified.
+func AllocateFEFO(lots []*Lot, q quantity.Quantity, t time.Time) ([]LotComponent, error) {
+	path := "stock/lot.AllocateFEFO"
+
+	available := make([]*Lot, 0, len(lots))
+	for _, l := range lots {
+		if l.IsAvailable(t) {
+			available = append(available, l)
+		}
+	}
+	SortFEFO(available)
+
+	allocations := make([]LotComponent, 0)
+	zero := quantity.Quantity{Quantity: 0, Unit: q.Unit}
+	pending := q
+	for _, l := range available {
+		if more, _ := pending.Greater(zero); !more {
+			break
+		}
+
+		if !l.Remaining.Compatible(pending) {
+			return nil, errors.NewStatus("INCOMPATIBLE_LOT_QUANTITY").SetPath(path).SetMessage("%v != %v", l.Remaining, pending)
+		}
+
+		take, err := quantity.Min(pending, l.Remaining)
+		if err != nil {
+			return nil, errors.NewStatus("INVALID_QUANTITY").SetPath(path).SetRef(err)
+		}
+
+		if pending, err = pending.Subtract(take); err != nil {
+			return nil, errors.NewStatus("INVALID_QUANTITY").SetPath(path).SetRef(err)
+		}
+
+		allocations = append(allocations, LotComponent{
+			Lot:         l.ID,
+			Composition: l.Composition,
+			Quantity:    take,
+		})
+	}
+
+	if missing, _ := pending.Greater(zero); missing {
+		return nil, errors.NewStatus("INSUFFICIENT_LOTS").SetPath(path).SetMessage("%v missing", pending)
+	}
+
+	return allocations, nil
+}
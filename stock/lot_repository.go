@@ -0,0 +1,169 @@
+package stock
+
+import (
+	"context"
+	"time"
+
+	"github.com/aboglioli/big-brother/infrastructure/db"
+	"github.com/aboglioli/big-brother/pkg/errors"
+	"github.com/aboglioli/big-brother/pkg/events"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type LotRepository interface {
+	FindByID(id string) (*Lot, error)
+	FindByComposition(compID string) ([]*Lot, error)
+	FindByComponent(lotID string) ([]*Lot, error)
+	FindExpired(t time.Time) ([]*Lot, error)
+
+	// Insert and Update store the outbox entries of the change in the same
+	// transaction.
+	Insert(l *Lot, entries ...*events.Entry) error
+	Update(l *Lot, entries ...*events.Entry) error
+}
+
+type lotRepository struct {
+	collection *mongo.Collection
+	outbox     events.Outbox
+}
+
+// NewLotRepository returns the lot repository of the "Stock" database. The
+// outbox must be of the same database.
+func NewLotRepository(outbox events.Outbox) (LotRepository, error) {
+	db, err := db.Get("Stock")
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &lotRepository{
+		collection: db.Collection("lot"),
+		outbox:     outbox,
+	}, nil
+}
+
+func (r *lotRepository) FindByID(id string) (*Lot, error) {
+	path := "stock/lot_repository.FindByID"
+	ctx := context.Background()
+
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errors.NewInternal("OBJECTID_FROM_HEX").SetPath(path).SetRef(err)
+	}
+
+	filter := bson.M{
+		"_id": objID,
+	}
+
+	res := r.collection.FindOne(ctx, filter)
+	if res.Err() != nil {
+		return nil, errors.NewInternal("FIND_ONE").SetPath(path).SetRef(res.Err())
+	}
+
+	var lot Lot
+	if err := res.Decode(&lot); err != nil {
+		return nil, errors.NewInternal("DECODE").SetPath(path).SetRef(err)
+	}
+
+	return &lot, nil
+}
+
+func (r *lotRepository) FindByComposition(compID string) ([]*Lot, error) {
+	path := "stock/lot_repository.FindByComposition"
+
+	objID, err := primitive.ObjectIDFromHex(compID)
+	if err != nil {
+		return nil, errors.NewInternal("OBJECTID_FROM_HEX").SetPath(path).SetRef(err)
+	}
+
+	return r.find(path, bson.M{
+		"composition": objID,
+	})
+}
+
+func (r *lotRepository) FindByComponent(lotID string) ([]*Lot, error) {
+	path := "stock/lot_repository.FindByComponent"
+
+	objID, err := primitive.ObjectIDFromHex(lotID)
+	if err != nil {
+		return nil, errors.NewInternal("OBJECTID_FROM_HEX").SetPath(path).SetRef(err)
+	}
+
+	return r.find(path, bson.M{
+		"components.lot": objID,
+	})
+}
+
+func (r *lotRepository) FindExpired(t time.Time) ([]*Lot, error) {
+	return r.find("stock/lot_repository.FindExpired", bson.M{
+		"expiresAt": bson.M{"$lte": t},
+		"blocked":   false,
+	})
+}
+
+func (r *lotRepository) Insert(l *Lot, entries ...*events.Entry) error {
+	return r.outbox.Write(func(ctx context.Context) error {
+		_, err := r.collection.InsertOne(ctx, l)
+		if err != nil {
+			return errors.NewInternal("INSERT_ONE").SetPath("stock/lot_repository.Insert").SetRef(err)
+		}
+
+		return nil
+	}, entries...)
+}
+
+func (r *lotRepository) Update(l *Lot, entries ...*events.Entry) error {
+	path := "stock/lot_repository.Update"
+
+	if l.ID.IsZero() {
+		return errors.NewInternal("INVALID_OBJECTID").SetPath(path)
+	}
+
+	l.UpdatedAt = time.Now()
+
+	filter := bson.M{
+		"_id": l.ID,
+	}
+
+	update := bson.M{
+		"$set": l,
+	}
+
+	return r.outbox.Write(func(ctx context.Context) error {
+		_, err := r.collection.UpdateOne(ctx, filter, update)
+		if err != nil {
+			return errors.NewInternal("UPDATE_ONE").SetPath(path).SetRef(err)
+		}
+
+		return nil
+	}, entries...)
+}
+
+func (r *lotRepository) find(path string, filter bson.M) ([]*Lot, error) {
+	ctx := context.Background()
+
+	cur, err := r.collection.Find(ctx, filter)
+	if err != nil {
+		return nil, errors.NewInternal("FIND").SetPath(path).SetRef(err)
+	}
+	defer cur.Close(ctx)
+
+	lots := make([]*Lot, 0)
+	for cur.Next(ctx) {
+		var lot Lot
+
+		if err := cur.Decode(&lot); err != nil {
+			return nil, errors.NewInternal("CUR_DECODE").SetPath(path).SetRef(err)
+		}
+
+		lots = append(lots, &lot)
+	}
+
+	if err := cur.Err(); err != nil {
+		return nil, errors.NewInternal("CUR").SetPath(path).SetRef(err)
+	}
+
+	return lots, nil
+}
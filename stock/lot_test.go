@@ -0,0 +1,76 @@
+package stock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aboglioli/big-brother/pkg/quantity"
+	"github.com/aboglioli/big-brother/pkg/tests/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func newLot(q quantity.Quantity, expiresIn time.Duration) *Lot {
+	lot := NewLot(primitive.NewObjectID(), OriginReceipt)
+	lot.Quantity = q
+	lot.Remaining = q
+	if expiresIn != 0 {
+		expiresAt := time.Now().Add(expiresIn)
+		lot.ExpiresAt = &expiresAt
+	}
+	return lot
+}
+
+func TestSortFEFO(t *testing.T) {
+	l1 := newLot(quantity.Quantity{1, "kg"}, 0)
+	l2 := newLot(quantity.Quantity{1, "kg"}, 48*time.Hour)
+	l3 := newLot(quantity.Quantity{1, "kg"}, 24*time.Hour)
+	l4 := newLot(quantity.Quantity{1, "kg"}, 0)
+	l4.Date = l1.Date.Add(-time.Hour)
+
+	lots := []*Lot{l1, l2, l3, l4}
+	SortFEFO(lots)
+
+	assert.Equal(t, lots[0].ID, l3.ID, "First expired should be first")
+	assert.Equal(t, lots[1].ID, l2.ID)
+	assert.Equal(t, lots[2].ID, l4.ID, "Lots without expiration sorted by date")
+	assert.Equal(t, lots[3].ID, l1.ID)
+}
+
+func TestAllocateFEFO(t *testing.T) {
+	l1 := newLot(quantity.Quantity{2, "kg"}, 48*time.Hour)
+	l2 := newLot(quantity.Quantity{500, "g"}, 24*time.Hour)
+	expired := newLot(quantity.Quantity{10, "kg"}, -time.Hour)
+	blocked := newLot(quantity.Quantity{10, "kg"}, time.Hour)
+	blocked.Blocked = true
+	lots := []*Lot{l1, l2, expired, blocked}
+
+	t.Run("Take from first expiring lots", func(t *testing.T) {
+		allocations, err := AllocateFEFO(lots, quantity.Quantity{1.5, "kg"}, time.Now())
+		assert.Ok(t, err)
+		assert.Equal(t, len(allocations), 2)
+		assert.Equal(t, allocations[0].Lot, l2.ID)
+		assert.Assert(t, allocations[0].Quantity.Equals(quantity.Quantity{500, "g"}))
+		assert.Equal(t, allocations[1].Lot, l1.ID)
+		assert.Assert(t, allocations[1].Quantity.Equals(quantity.Quantity{1, "kg"}))
+	})
+
+	t.Run("Expired and blocked lots are not available", func(t *testing.T) {
+		_, err := AllocateFEFO(lots, quantity.Quantity{3, "kg"}, time.Now())
+		assert.ErrCode(t, err, "INSUFFICIENT_LOTS")
+	})
+
+	t.Run("Incompatible quantity", func(t *testing.T) {
+		_, err := AllocateFEFO(lots, quantity.Quantity{1, "l"}, time.Now())
+		assert.ErrCode(t, err, "INCOMPATIBLE_LOT_QUANTITY")
+	})
+}
+
+func TestConsumeLot(t *testing.T) {
+	lot := newLot(quantity.Quantity{1, "kg"}, 0)
+
+	assert.Ok(t, lot.Consume(quantity.Quantity{400, "g"}))
+	assert.Assert(t, lot.Remaining.Equals(quantity.Quantity{0.6, "kg"}))
+	assert.ErrCode(t, lot.Consume(quantity.Quantity{1, "kg"}), "INSUFFICIENT_LOT_QUANTITY")
+	assert.Ok(t, lot.Consume(quantity.Quantity{600, "g"}))
+	assert.Assert(t, !lot.IsAvailable(time.Now()), "Empty lot should not be available")
+}
@@ -0,0 +1,52 @@
+package stock
+
+import (
+	"time"
+
+	"github.com/aboglioli/big-brother/pkg/quantity"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	MovementReceipt     = "RECEIPT"
+	MovementProduction  = "PRODUCTION"
+	MovementConsumption = "CONSUMPTION"
+	MovementIssue       = "ISSUE"
+	MovementAdjustment  = "ADJUSTMENT"
+)
+
+// Movement is an entry or an exit of stock of a composition. Cost is the cost
+// impact of the movement valued at the composition cost. Reserved exits issue
+// reserved stock and release its reservation.
+type Movement struct {
+	ID          primitive.ObjectID  `json:"id" bson:"_id"`
+	Type        string              `json:"type" bson:"type"`
+	Composition primitive.ObjectID  `json:"composition" bson:"composition"`
+	Lot         *primitive.ObjectID `json:"lot" bson:"lot"`
+	Quantity    quantity.Quantity   `json:"quantity" bson:"quantity"`
+	Incoming    bool                `json:"incoming" bson:"incoming"`
+	Reserved    bool                `json:"reserved" bson:"reserved"`
+	Cost        float64             `json:"cost" bson:"cost"`
+	Reference   string              `json:"reference" bson:"reference"`
+	CreatedAt   time.Time           `json:"createdAt" bson:"createdAt"`
+}
+
+func NewMovement(mType string, compID primitive.ObjectID, q quantity.Quantity, incoming bool) *Movement {
+	return &Movement{
+		ID:          primitive.NewObjectID(),
+		Type:        mType,
+		Composition: compID,
+		Quantity:    q,
+		Incoming:    incoming,
+		CreatedAt:   time.Now(),
+	}
+}
+
+// SignedCost returns the cost impact of the movement: positive for incoming
+// movements and negative for outgoing ones.
+func (m *Movement) SignedCost() float64 {
+	if m.Incoming {
+		return m.Cost
+	}
+	return -m.Cost
+}
@@ -0,0 +1,88 @@
+package stock
+
+import (
+	"context"
+
+	"github.com/aboglioli/big-brother/infrastructure/db"
+	"github.com/aboglioli/big-brother/pkg/errors"
+	"github.com/aboglioli/big-brother/pkg/events"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type MovementRepository interface {
+	FindByComposition(compID string) ([]*Movement, error)
+
+	// Insert stores the outbox entries of the movement in the same
+	// transaction.
+	Insert(m *Movement, entries ...*events.Entry) error
+}
+
+type movementRepository struct {
+	collection *mongo.Collection
+	outbox     events.Outbox
+}
+
+// NewMovementRepository returns the movement repository of the "Stock"
+// database. The outbox must be of the same database.
+func NewMovementRepository(outbox events.Outbox) (MovementRepository, error) {
+	db, err := db.Get("Stock")
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &movementRepository{
+		collection: db.Collection("movement"),
+		outbox:     outbox,
+	}, nil
+}
+
+func (r *movementRepository) FindByComposition(compID string) ([]*Movement, error) {
+	path := "stock/movement_repository.FindByComposition"
+	ctx := context.Background()
+
+	objID, err := primitive.ObjectIDFromHex(compID)
+	if err != nil {
+		return nil, errors.NewInternal("OBJECTID_FROM_HEX").SetPath(path).SetRef(err)
+	}
+
+	filter := bson.M{
+		"composition": objID,
+	}
+
+	cur, err := r.collection.Find(ctx, filter)
+	if err != nil {
+		return nil, errors.NewInternal("FIND").SetPath(path).SetRef(err)
+	}
+	defer cur.Close(ctx)
+
+	movements := make([]*Movement, 0)
+	for cur.Next(ctx) {
+		var m Movement
+
+		if err := cur.Decode(&m); err != nil {
+			return nil, errors.NewInternal("CUR_DECODE").SetPath(path).SetRef(err)
+		}
+
+		movements = append(movements, &m)
+	}
+
+	if err := cur.Err(); err != nil {
+		return nil, errors.NewInternal("CUR").SetPath(path).SetRef(err)
+	}
+
+	return movements, nil
+}
+
+func (r *movementRepository) Insert(m *Movement, entries ...*events.Entry) error {
+	return r.outbox.Write(func(ctx context.Context) error {
+		_, err := r.collection.InsertOne(ctx, m)
+		if err != nil {
+			return errors.NewInternal("INSERT_ONE").SetPath("stock/movement_repository.Insert").SetRef(err)
+		}
+
+		return nil
+	}, entries...)
+}
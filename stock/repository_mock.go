@@ -0,0 +1,232 @@
+package stock
+
+import (
+	"time"
+
+	"github.com/aboglioli/big-brother/pkg/errors"
+	"github.com/aboglioli/big-brother/pkg/events"
+	"github.com/aboglioli/big-brother/pkg/tests/mock"
+)
+
+// Lots
+type mockLotRepository struct {
+	mock.Mock
+	lots    []*Lot
+	entries []*events.Entry
+}
+
+// NewMockLotRepository returns an in-memory LotRepository. Mock repositories
+// are exported to be used by other packages' tests.
+func NewMockLotRepository() *mockLotRepository {
+	return &mockLotRepository{}
+}
+
+func (r *mockLotRepository) Clean() {
+	r.lots = make([]*Lot, 0)
+	r.entries = make([]*events.Entry, 0)
+}
+
+// Entries returns the outbox entries stored with the changes.
+func (r *mockLotRepository) Entries() []*events.Entry {
+	return r.entries
+}
+
+func (r *mockLotRepository) FindByID(id string) (*Lot, error) {
+	r.Called("FindByID", id)
+
+	for _, l := range r.lots {
+		if l.ID.Hex() == id {
+			return copyLot(l), nil
+		}
+	}
+
+	return nil, errors.NewInternal("NOT_FOUND").SetPath("stock/repository_mock.FindByID")
+}
+
+func (r *mockLotRepository) FindByComposition(compID string) ([]*Lot, error) {
+	r.Called("FindByComposition", compID)
+
+	lots := make([]*Lot, 0)
+	for _, l := range r.lots {
+		if l.Composition.Hex() == compID {
+			lots = append(lots, copyLot(l))
+		}
+	}
+
+	return lots, nil
+}
+
+func (r *mockLotRepository) FindByComponent(lotID string) ([]*Lot, error) {
+	r.Called("FindByComponent", lotID)
+
+	lots := make([]*Lot, 0)
+	for _, l := range r.lots {
+		for _, c := range l.Components {
+			if c.Lot.Hex() == lotID {
+				lots = append(lots, copyLot(l))
+				break
+			}
+		}
+	}
+
+	return lots, nil
+}
+
+func (r *mockLotRepository) FindExpired(t time.Time) ([]*Lot, error) {
+	r.Called("FindExpired", t)
+
+	lots := make([]*Lot, 0)
+	for _, l := range r.lots {
+		if !l.Blocked && l.IsExpired(t) {
+			lots = append(lots, copyLot(l))
+		}
+	}
+
+	return lots, nil
+}
+
+func (r *mockLotRepository) Insert(l *Lot, entries ...*events.Entry) error {
+	r.Called("Insert", l)
+	r.entries = append(r.entries, entries...)
+
+	l.UpdatedAt = time.Now()
+	r.lots = append(r.lots, copyLot(l))
+
+	return nil
+}
+
+func (r *mockLotRepository) Update(l *Lot, entries ...*events.Entry) error {
+	r.Called("Update", l)
+	r.entries = append(r.entries, entries...)
+
+	for _, lot := range r.lots {
+		if lot.ID.Hex() == l.ID.Hex() {
+			*lot = *copyLot(l)
+			lot.UpdatedAt = time.Now()
+			break
+		}
+	}
+
+	return nil
+}
+
+func copyLot(l *Lot) *Lot {
+	copy := *l
+	return &copy
+}
+
+// Movements
+type mockMovementRepository struct {
+	mock.Mock
+	movements []*Movement
+	entries   []*events.Entry
+}
+
+func NewMockMovementRepository() *mockMovementRepository {
+	return &mockMovementRepository{}
+}
+
+func (r *mockMovementRepository) Clean() {
+	r.movements = make([]*Movement, 0)
+	r.entries = make([]*events.Entry, 0)
+}
+
+// Entries returns the outbox entries stored with the movements.
+func (r *mockMovementRepository) Entries() []*events.Entry {
+	return r.entries
+}
+
+func (r *mockMovementRepository) FindByComposition(compID string) ([]*Movement, error) {
+	r.Called("FindByComposition", compID)
+
+	movements := make([]*Movement, 0)
+	for _, m := range r.movements {
+		if m.Composition.Hex() == compID {
+			copy := *m
+			movements = append(movements, &copy)
+		}
+	}
+
+	return movements, nil
+}
+
+func (r *mockMovementRepository) Insert(m *Movement, entries ...*events.Entry) error {
+	r.Called("Insert", m)
+	r.entries = append(r.entries, entries...)
+
+	copy := *m
+	r.movements = append(r.movements, &copy)
+
+	return nil
+}
+
+// Counts
+type mockCountRepository struct {
+	mock.Mock
+	counts []*Count
+}
+
+func NewMockCountRepository() *mockCountRepository {
+	return &mockCountRepository{}
+}
+
+func (r *mockCountRepository) Clean() {
+	r.counts = make([]*Count, 0)
+}
+
+func (r *mockCountRepository) FindByID(id string) (*Count, error) {
+	r.Called("FindByID", id)
+
+	for _, c := range r.counts {
+		if c.ID.Hex() == id {
+			return copyCount(c), nil
+		}
+	}
+
+	return nil, errors.NewInternal("NOT_FOUND").SetPath("stock/repository_mock.FindByID")
+}
+
+func (r *mockCountRepository) FindByStatus(status string) ([]*Count, error) {
+	r.Called("FindByStatus", status)
+
+	counts := make([]*Count, 0)
+	for _, c := range r.counts {
+		if c.Status == status {
+			counts = append(counts, copyCount(c))
+		}
+	}
+
+	return counts, nil
+}
+
+func (r *mockCountRepository) Insert(c *Count) error {
+	r.Called("Insert", c)
+
+	r.counts = append(r.counts, copyCount(c))
+
+	return nil
+}
+
+func (r *mockCountRepository) Update(c *Count) error {
+	r.Called("Update", c)
+
+	for _, count := range r.counts {
+		if count.ID.Hex() == c.ID.Hex() {
+			*count = *copyCount(c)
+			count.UpdatedAt = time.Now()
+			break
+		}
+	}
+
+	return nil
+}
+
+func copyCount(c *Count) *Count {
+	copy := *c
+	copy.Lines = make([]CountLine, len(c.Lines))
+	for i, l := range c.Lines {
+		l.Counts = append([]LocationCount(nil), l.Counts...)
+		copy.Lines[i] = l
+	}
+	return &copy
+}
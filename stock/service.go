@@ -0,0 +1,771 @@
+package stock
+
+import (
+	"math"
+	"time"
+
+	"github.com/aboglioli/big-brother/composition"
+	"github.com/aboglioli/big-brother/pkg/errors"
+	"github.com/aboglioli/big-brother/pkg/events"
+	"github.com/aboglioli/big-brother/pkg/quantity"
+)
+
+type Service interface {
+	GetLot(id string) (*Lot, error)
+	FindLotsByComposition(compID string) ([]*Lot, error)
+	FindMovementsByComposition(compID string) ([]*Movement, error)
+
+	Receive(req *ReceiveRequest) (*Lot, error)
+	Produce(req *ProduceRequest) (*Lot, error)
+	Issue(req *IssueRequest) ([]LotComponent, error)
+	BlockLot(id string) (*Lot, error)
+	BlockExpired() ([]*Lot, error)
+
+	FindLotsContaining(lotID string) ([]*Lot, error)
+	FindLotComponents(lotID string) ([]*Lot, error)
+
+	GetCount(id string) (*Count, error)
+	FindOpenCounts() ([]*Count, error)
+	OpenCount(req *OpenCountRequest) (*Count, error)
+	RecordCount(id string, req *RecordCountRequest) (*Count, error)
+	ApproveCount(id string) (*Count, error)
+	CancelCount(id string) (*Count, error)
+}
+
+type service struct {
+	lotRepository      LotRepository
+	movementRepository MovementRepository
+	countRepository    CountRepository
+	compositionService composition.Service
+}
+
+func NewService(lotRepo LotRepository, movementRepo MovementRepository, countRepo CountRepository, compServ composition.Service) Service {
+	return &service{
+		lotRepository:      lotRepo,
+		movementRepository: movementRepo,
+		countRepository:    countRepo,
+		compositionService: compServ,
+	}
+}
+
+func (s *service) GetLot(id string) (*Lot, error) {
+	lot, err := s.lotRepository.FindByID(id)
+	if err != nil {
+		return nil, errors.NewStatus("LOT_NOT_FOUND").SetPath("stock/service.GetLot").SetStatus(404).SetRef(err)
+	}
+	return lot, nil
+}
+
+func (s *service) FindLotsByComposition(compID string) ([]*Lot, error) {
+	lots, err := s.lotRepository.FindByComposition(compID)
+	if err != nil {
+		return nil, errors.NewStatus("FIND_LOTS").SetPath("stock/service.FindLotsByComposition").SetRef(err)
+	}
+	SortFEFO(lots)
+	return lots, nil
+}
+
+func (s *service) FindMovementsByComposition(compID string) ([]*Movement, error) {
+	movements, err := s.movementRepository.FindByComposition(compID)
+	if err != nil {
+		return nil, errors.NewStatus("FIND_MOVEMENTS").SetPath("stock/service.FindMovementsByComposition").SetRef(err)
+	}
+	return movements, nil
+}
+
+type ReceiveRequest struct {
+	Composition string            `json:"composition" binding:"required"`
+	Code        string            `json:"code"`
+	Quantity    quantity.Quantity `json:"quantity" binding:"required"`
+	Date        *time.Time        `json:"date"`
+	ExpiresAt   *time.Time        `json:"expiresAt"`
+	Reference   string            `json:"reference"`
+}
+
+// Receive creates a new lot from a receipt and increases the composition
+// stock.
+/**
+* @api {topic} stock.lot.created stock.lot.created
+* @apiName LotCreated
+* @apiGroup RabbitMQ
+*
+* @apiDescription Emits a new event when a lot is received or produced.
+*
+* @apiSuccessExample {json} Body
+* {
+* 	"type": "LotCreated",
+* 	"lot": lot data
+* }
+ */
+func (s *service) Receive(req *ReceiveRequest) (*Lot, error) {
+	path := "stock/service.Receive"
+
+	comp, err := s.compositionService.GetByID(req.Composition)
+	if err != nil {
+		return nil, err
+	}
+
+	q, err := comp.ToUnit(req.Quantity)
+	if err != nil {
+		return nil, errors.NewStatus("INCOMPATIBLE_QUANTITY").SetPath(path).SetMessage("%v != %v", req.Quantity, comp.Unit).SetRef(err)
+	}
+
+	lot := NewLot(comp.ID, OriginReceipt)
+	lot.Code = req.Code
+	lot.Quantity = q
+	lot.Remaining = q
+	lot.ExpiresAt = req.ExpiresAt
+	if req.Date != nil {
+		lot.Date = *req.Date
+	}
+
+	if err := lot.ValidateSchema(); err != nil {
+		return nil, err
+	}
+
+	if err := s.lotRepository.Insert(lot); err != nil {
+		return nil, errors.NewStatus("INSERT").SetPath(path).SetRef(err)
+	}
+
+	// Publish event through the outbox with the movement: stock.lot.created
+	entry, err := newLotEntry(NewLotCreatedEvent(lot))
+	if err != nil {
+		return nil, errors.NewStatus("ENCODE_EVENT").SetPath(path).SetRef(err)
+	}
+
+	m := NewMovement(MovementReceipt, comp.ID, lot.Quantity, true)
+	m.Lot = &lot.ID
+	m.Reference = req.Reference
+	if err := s.post(m, entry); err != nil {
+		return nil, err
+	}
+
+	return lot, nil
+}
+
+type ProduceRequest struct {
+	Composition string            `json:"composition" binding:"required"`
+	Code        string            `json:"code"`
+	Quantity    quantity.Quantity `json:"quantity" binding:"required"`
+	ExpiresAt   *time.Time        `json:"expiresAt"`
+	Reference   string            `json:"reference"`
+}
+
+// Produce creates a new lot of a composition consuming the lots of its
+// dependencies following FEFO. Lots of every dependency are allocated and its
+// available stock checked before consuming any of them. Lots are consumed one
+// by one, so a failure while consuming leaves the previous ones consumed.
+func (s *service) Produce(req *ProduceRequest) (*Lot, error) {
+	path := "stock/service.Produce"
+	now := time.Now()
+
+	comp, err := s.compositionService.GetByID(req.Composition)
+	if err != nil {
+		return nil, err
+	}
+
+	q, err := comp.ToUnit(req.Quantity)
+	if !req.Quantity.IsValid() || err != nil {
+		return nil, errors.NewStatus("INCOMPATIBLE_QUANTITY").SetPath(path).SetMessage("%v != %v", req.Quantity, comp.Unit).SetRef(err)
+	}
+
+	factor, err := q.Ratio(comp.Unit)
+	if len(comp.Dependencies) == 0 || err != nil {
+		return nil, errors.NewStatus("COMPOSITION_CANNOT_BE_PRODUCED").SetPath(path).SetMessage("%s has no dependencies", comp.ID.Hex()).SetRef(err)
+	}
+
+	// Allocate lots of every dependency before consuming them
+	lots := make(map[string]*Lot)
+	allocations := make([]LotComponent, 0)
+	for _, dep := range comp.Dependencies {
+		required := dep.Quantity.Multiply(factor)
+
+		depLots, err := s.lotRepository.FindByComposition(dep.On.Hex())
+		if err != nil {
+			return nil, errors.NewStatus("FIND_LOTS").SetPath(path).SetRef(err)
+		}
+
+		depAllocations, err := AllocateFEFO(depLots, required, now)
+		if err != nil {
+			return nil, errors.NewStatus("INSUFFICIENT_LOTS").SetPath(path).SetMessage("dependency %s", dep.On.Hex()).SetRef(err)
+		}
+
+		depComp, err := s.compositionService.GetByID(dep.On.Hex())
+		if err != nil {
+			return nil, err
+		}
+		if err := checkAvailable(depComp, required, path); err != nil {
+			return nil, err
+		}
+
+		for _, l := range depLots {
+			lots[l.ID.Hex()] = l
+		}
+		allocations = append(allocations, depAllocations...)
+	}
+
+	lot := NewLot(comp.ID, OriginProduction)
+	lot.Code = req.Code
+	lot.Quantity = q
+	lot.Remaining = q
+	lot.ExpiresAt = req.ExpiresAt
+	lot.Components = allocations
+
+	if err := lot.ValidateSchema(); err != nil {
+		return nil, err
+	}
+
+	for _, a := range allocations {
+		if err := s.consume(lots[a.Lot.Hex()], a, MovementConsumption, lot.ID.Hex(), false); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.lotRepository.Insert(lot); err != nil {
+		return nil, errors.NewStatus("INSERT").SetPath(path).SetRef(err)
+	}
+
+	// Publish event through the outbox with the movement: stock.lot.created
+	entry, err := newLotEntry(NewLotCreatedEvent(lot))
+	if err != nil {
+		return nil, errors.NewStatus("ENCODE_EVENT").SetPath(path).SetRef(err)
+	}
+
+	m := NewMovement(MovementProduction, comp.ID, lot.Quantity, true)
+	m.Lot = &lot.ID
+	m.Reference = req.Reference
+	if err := s.post(m, entry); err != nil {
+		return nil, err
+	}
+
+	return lot, nil
+}
+
+// IssueRequest issues available stock. Reserved issues reserved stock (e.g.
+// the delivery of a confirmed sales order) releasing its reservation. It's
+// only set by other services.
+type IssueRequest struct {
+	Composition string            `json:"composition" binding:"required"`
+	Quantity    quantity.Quantity `json:"quantity" binding:"required"`
+	Reference   string            `json:"reference"`
+	Reserved    bool              `json:"-"`
+	// Consumed is called after each lot is consumed so callers can record
+	// the progress of the issue.
+	Consumed func(LotComponent) error `json:"-"`
+}
+
+// Issue takes stock out of a composition (e.g. sales) consuming its lots
+// following FEFO. It returns the consumed quantity of each lot. Stock reserved
+// by others can't be issued. Lots are consumed one by one, so a failure while
+// consuming leaves the previous ones consumed; callers that need to resume
+// the issue record each consumed lot through Consumed.
+func (s *service) Issue(req *IssueRequest) ([]LotComponent, error) {
+	path := "stock/service.Issue"
+
+	comp, err := s.compositionService.GetByID(req.Composition)
+	if err != nil {
+		return nil, err
+	}
+
+	q, err := comp.ToUnit(req.Quantity)
+	if !req.Quantity.IsValid() || err != nil {
+		return nil, errors.NewStatus("INCOMPATIBLE_QUANTITY").SetPath(path).SetMessage("%v != %v", req.Quantity, comp.Unit).SetRef(err)
+	}
+
+	if req.Reserved {
+		if less, err := comp.Reserved.Less(q); comp.Reserved.IsEmpty() || err != nil || less {
+			return nil, errors.NewStatus("INSUFFICIENT_RESERVED_STOCK").SetPath(path).SetMessage("%v < %v", comp.Reserved, q)
+		}
+	} else if err := checkAvailable(comp, q, path); err != nil {
+		return nil, err
+	}
+
+	lots, err := s.lotRepository.FindByComposition(comp.ID.Hex())
+	if err != nil {
+		return nil, errors.NewStatus("FIND_LOTS").SetPath(path).SetRef(err)
+	}
+
+	allocations, err := AllocateFEFO(lots, q, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	lotsByID := make(map[string]*Lot)
+	for _, l := range lots {
+		lotsByID[l.ID.Hex()] = l
+	}
+
+	for _, a := range allocations {
+		if err := s.consume(lotsByID[a.Lot.Hex()], a, MovementIssue, req.Reference, req.Reserved); err != nil {
+			return nil, err
+		}
+		if req.Consumed != nil {
+			if err := req.Consumed(a); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return allocations, nil
+}
+
+// BlockLot blocks a lot. Blocked lots cannot be consumed and their remaining
+// quantity can't be reserved.
+/**
+* @api {topic} stock.lot.blocked stock.lot.blocked
+* @apiName LotBlocked
+* @apiGroup RabbitMQ
+*
+* @apiDescription Emits a new event when a lot is blocked manually or because
+* it expired.
+*
+* @apiSuccessExample {json} Body
+* {
+* 	"type": "LotBlocked",
+* 	"lot": lot data
+* }
+ */
+func (s *service) BlockLot(id string) (*Lot, error) {
+	lot, err := s.GetLot(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.block(lot); err != nil {
+		return nil, err
+	}
+
+	return lot, nil
+}
+
+// BlockExpired blocks all expired lots that are not blocked yet.
+func (s *service) BlockExpired() ([]*Lot, error) {
+	path := "stock/service.BlockExpired"
+
+	lots, err := s.lotRepository.FindExpired(time.Now())
+	if err != nil {
+		return nil, errors.NewStatus("FIND_EXPIRED").SetPath(path).SetRef(err)
+	}
+
+	for _, lot := range lots {
+		if err := s.block(lot); err != nil {
+			return nil, err
+		}
+	}
+
+	return lots, nil
+}
+
+// FindLotsContaining returns all lots produced using the given lot, directly
+// or through intermediate lots.
+func (s *service) FindLotsContaining(lotID string) ([]*Lot, error) {
+	path := "stock/service.FindLotsContaining"
+
+	if _, err := s.GetLot(lotID); err != nil {
+		return nil, err
+	}
+
+	visited := map[string]bool{lotID: true}
+	pending := []string{lotID}
+	lots := make([]*Lot, 0)
+	for len(pending) > 0 {
+		id := pending[0]
+		pending = pending[1:]
+
+		uses, err := s.lotRepository.FindByComponent(id)
+		if err != nil {
+			return nil, errors.NewStatus("FIND_BY_COMPONENT").SetPath(path).SetRef(err)
+		}
+
+		for _, u := range uses {
+			if visited[u.ID.Hex()] {
+				continue
+			}
+			visited[u.ID.Hex()] = true
+			pending = append(pending, u.ID.Hex())
+			lots = append(lots, u)
+		}
+	}
+
+	return lots, nil
+}
+
+// FindLotComponents returns all lots consumed to produce the given lot,
+// directly or through intermediate lots.
+func (s *service) FindLotComponents(lotID string) ([]*Lot, error) {
+	lot, err := s.GetLot(lotID)
+	if err != nil {
+		return nil, err
+	}
+
+	visited := map[string]bool{lotID: true}
+	pending := []*Lot{lot}
+	lots := make([]*Lot, 0)
+	for len(pending) > 0 {
+		l := pending[0]
+		pending = pending[1:]
+
+		for _, c := range l.Components {
+			if visited[c.Lot.Hex()] {
+				continue
+			}
+			visited[c.Lot.Hex()] = true
+
+			component, err := s.GetLot(c.Lot.Hex())
+			if err != nil {
+				return nil, err
+			}
+			pending = append(pending, component)
+			lots = append(lots, component)
+		}
+	}
+
+	return lots, nil
+}
+
+func (s *service) GetCount(id string) (*Count, error) {
+	count, err := s.countRepository.FindByID(id)
+	if err != nil {
+		return nil, errors.NewStatus("COUNT_NOT_FOUND").SetPath("stock/service.GetCount").SetStatus(404).SetRef(err)
+	}
+	return count, nil
+}
+
+func (s *service) FindOpenCounts() ([]*Count, error) {
+	counts, err := s.countRepository.FindByStatus(CountOpen)
+	if err != nil {
+		return nil, errors.NewStatus("FIND_COUNTS").SetPath("stock/service.FindOpenCounts").SetRef(err)
+	}
+	return counts, nil
+}
+
+type OpenCountRequest struct {
+	Compositions []string `json:"compositions" binding:"required"`
+	Locations    []string `json:"locations"`
+}
+
+// OpenCount opens a stock count session. System stock of every composition is
+// taken when the session is opened.
+func (s *service) OpenCount(req *OpenCountRequest) (*Count, error) {
+	path := "stock/service.OpenCount"
+
+	if len(req.Compositions) == 0 {
+		return nil, errors.NewStatus("EMPTY_COUNT").SetPath(path)
+	}
+
+	count := NewCount()
+	if req.Locations != nil {
+		count.Locations = req.Locations
+	}
+
+	for _, compID := range req.Compositions {
+		if count.FindLine(compID) != nil {
+			return nil, errors.NewStatus("DUPLICATED_COMPOSITION").SetPath(path).SetMessage("%s", compID)
+		}
+
+		comp, err := s.compositionService.GetByID(compID)
+		if err != nil {
+			return nil, err
+		}
+
+		count.AddLine(comp.ID, comp.Stock)
+	}
+
+	if err := s.countRepository.Insert(count); err != nil {
+		return nil, errors.NewStatus("INSERT").SetPath(path).SetRef(err)
+	}
+
+	return count, nil
+}
+
+type RecordCountRequest struct {
+	Composition string            `json:"composition" binding:"required"`
+	Location    string            `json:"location"`
+	Quantity    quantity.Quantity `json:"quantity" binding:"required"`
+}
+
+// RecordCount records the counted quantity of a composition in a location and
+// calculates its variance against the system stock.
+func (s *service) RecordCount(id string, req *RecordCountRequest) (*Count, error) {
+	path := "stock/service.RecordCount"
+
+	count, err := s.GetCount(id)
+	if err != nil {
+		return nil, err
+	}
+
+	line, err := count.Record(req.Composition, req.Location, req.Quantity)
+	if err != nil {
+		return nil, err
+	}
+
+	comp, err := s.compositionService.GetByID(req.Composition)
+	if err != nil {
+		return nil, err
+	}
+	cost, err := comp.CostFromQuantity(line.Variance)
+	if err != nil {
+		return nil, err
+	}
+	line.Cost = math.Round(cost*1000) / 1000
+
+	if err := s.countRepository.Update(count); err != nil {
+		return nil, errors.NewStatus("UPDATE").SetPath(path).SetRef(err)
+	}
+
+	return count, nil
+}
+
+// ApproveCount posts the variance of every counted line as an adjustment
+// movement. Lines not counted are ignored. The count is saved after each
+// posted line, so approving it again after a failure skips the lines already
+// posted.
+func (s *service) ApproveCount(id string) (*Count, error) {
+	path := "stock/service.ApproveCount"
+
+	count, err := s.GetCount(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !count.IsOpen() {
+		return nil, errors.NewStatus("COUNT_NOT_OPEN").SetPath(path)
+	}
+
+	for i := range count.Lines {
+		line := &count.Lines[i]
+		if !line.IsCounted() || line.Variance.Quantity == 0 || line.Movement != nil {
+			continue
+		}
+
+		m, err := s.adjust(line, count.ID.Hex())
+		if err != nil {
+			return nil, err
+		}
+
+		line.Movement = &m.ID
+		line.Cost = m.SignedCost()
+		if err := s.countRepository.Update(count); err != nil {
+			return nil, errors.NewStatus("UPDATE").SetPath(path).SetRef(err)
+		}
+	}
+
+	now := time.Now()
+	count.Status = CountApproved
+	count.ApprovedAt = &now
+
+	if err := s.countRepository.Update(count); err != nil {
+		return nil, errors.NewStatus("UPDATE").SetPath(path).SetRef(err)
+	}
+
+	return count, nil
+}
+
+func (s *service) CancelCount(id string) (*Count, error) {
+	path := "stock/service.CancelCount"
+
+	count, err := s.GetCount(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !count.IsOpen() {
+		return nil, errors.NewStatus("COUNT_NOT_OPEN").SetPath(path)
+	}
+
+	count.Status = CountCancelled
+
+	if err := s.countRepository.Update(count); err != nil {
+		return nil, errors.NewStatus("UPDATE").SetPath(path).SetRef(err)
+	}
+
+	return count, nil
+}
+
+// adjust applies the variance of a count line to the lots of the composition
+// and posts it. A surplus creates an adjustment lot and a shortage consumes
+// available lots following FEFO. A shortage greater than the available lots
+// is rejected, so composition stock and lots don't drift apart.
+func (s *service) adjust(line *CountLine, reference string) (*Movement, error) {
+	path := "stock/service.adjust"
+
+	if line.Variance.Quantity > 0 {
+		lot := NewLot(line.Composition, OriginAdjustment)
+		lot.Quantity = line.Variance
+		lot.Remaining = line.Variance
+
+		if err := lot.ValidateSchema(); err != nil {
+			return nil, err
+		}
+
+		if err := s.lotRepository.Insert(lot); err != nil {
+			return nil, errors.NewStatus("INSERT").SetPath(path).SetRef(err)
+		}
+
+		entry, err := newLotEntry(NewLotCreatedEvent(lot))
+		if err != nil {
+			return nil, errors.NewStatus("ENCODE_EVENT").SetPath(path).SetRef(err)
+		}
+
+		m := NewMovement(MovementAdjustment, line.Composition, line.Variance, true)
+		m.Lot = &lot.ID
+		m.Reference = reference
+		if err := s.post(m, entry); err != nil {
+			return nil, err
+		}
+
+		return m, nil
+	}
+
+	shortage := line.Variance.Multiply(-1)
+
+	lots, err := s.lotRepository.FindByComposition(line.Composition.Hex())
+	if err != nil {
+		return nil, errors.NewStatus("FIND_LOTS").SetPath(path).SetRef(err)
+	}
+
+	allocations, err := AllocateFEFO(lots, shortage, time.Now())
+	if err != nil {
+		return nil, errors.NewStatus("INSUFFICIENT_LOTS").SetPath(path).SetMessage("composition %s: shortage of %v", line.Composition.Hex(), shortage).SetRef(err)
+	}
+
+	lotsByID := make(map[string]*Lot)
+	for _, l := range lots {
+		lotsByID[l.ID.Hex()] = l
+	}
+
+	for _, a := range allocations {
+		lot := lotsByID[a.Lot.Hex()]
+		if err := lot.Consume(a.Quantity); err != nil {
+			return nil, err
+		}
+
+		if err := s.lotRepository.Update(lot); err != nil {
+			return nil, errors.NewStatus("UPDATE").SetPath(path).SetRef(err)
+		}
+	}
+
+	m := NewMovement(MovementAdjustment, line.Composition, shortage, false)
+	m.Reference = reference
+	if err := s.post(m); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+func (s *service) consume(lot *Lot, a LotComponent, mType string, reference string, reserved bool) error {
+	path := "stock/service.consume"
+
+	if err := lot.Consume(a.Quantity); err != nil {
+		return err
+	}
+
+	if err := s.lotRepository.Update(lot); err != nil {
+		return errors.NewStatus("UPDATE").SetPath(path).SetRef(err)
+	}
+
+	m := NewMovement(mType, lot.Composition, a.Quantity, false)
+	m.Lot = &lot.ID
+	m.Reference = reference
+	m.Reserved = reserved
+
+	return s.post(m)
+}
+
+// block blocks a lot and its remaining quantity in the composition stock, so
+// it can't be reserved. Blocked lots are left as they are.
+func (s *service) block(lot *Lot) error {
+	path := "stock/service.block"
+
+	if lot.Blocked {
+		return nil
+	}
+
+	if lot.Remaining.Quantity > epsilon {
+		if _, err := s.compositionService.Block(lot.Composition.Hex(), lot.Remaining); err != nil {
+			return err
+		}
+	}
+
+	lot.Blocked = true
+
+	// Publish event through the outbox: stock.lot.blocked
+	entry, err := newLotEntry(NewLotBlockedEvent(lot))
+	if err != nil {
+		return errors.NewStatus("ENCODE_EVENT").SetPath(path).SetRef(err)
+	}
+
+	if err := s.lotRepository.Update(lot, entry); err != nil {
+		return errors.NewStatus("UPDATE").SetPath(path).SetRef(err)
+	}
+
+	return nil
+}
+
+// post applies a movement to the composition stock, values it at the current
+// composition cost and saves it. Its event and the given entries are stored
+// in the outbox with the movement.
+/**
+* @api {topic} stock.movement stock.movement
+* @apiName StockMovementPosted
+* @apiGroup RabbitMQ
+*
+* @apiDescription Emits a new event for every stock movement.
+*
+* @apiSuccessExample {json} Body
+* {
+* 	"type": "StockMovementPosted",
+* 	"movement": movement data
+* }
+ */
+func (s *service) post(m *Movement, entries ...*events.Entry) error {
+	path := "stock/service.post"
+
+	var comp *composition.Composition
+	var err error
+	if m.Incoming {
+		comp, err = s.compositionService.AddStock(m.Composition.Hex(), m.Quantity)
+	} else if m.Reserved {
+		comp, err = s.compositionService.SubtractReserved(m.Composition.Hex(), m.Quantity)
+	} else {
+		comp, err = s.compositionService.SubtractStock(m.Composition.Hex(), m.Quantity)
+	}
+	if err != nil {
+		return err
+	}
+
+	cost, err := comp.CostFromQuantity(m.Quantity)
+	if err != nil {
+		return err
+	}
+	m.Cost = math.Round(cost*1000) / 1000
+
+	// Publish event through the outbox: stock.movement
+	event, opts := NewMovementPostedEvent(m)
+	entry, err := events.NewEntry(m.Composition.Hex(), event, opts)
+	if err != nil {
+		return errors.NewStatus("ENCODE_EVENT").SetPath(path).SetRef(err)
+	}
+
+	if err := s.movementRepository.Insert(m, append(entries, entry)...); err != nil {
+		return errors.NewStatus("INSERT_MOVEMENT").SetPath(path).SetRef(err)
+	}
+
+	return nil
+}
+
+// checkAvailable returns an error if q is more than the available stock of
+// comp, i.e. it would take stock reserved by others or blocked.
+func checkAvailable(comp *composition.Composition, q quantity.Quantity, path string) error {
+	converted, err := comp.ToUnit(q)
+	if err != nil {
+		return errors.NewStatus("INCOMPATIBLE_QUANTITY").SetPath(path).SetMessage("%v != %v", q, comp.Unit).SetRef(err)
+	}
+
+	available := comp.Available()
+	if less, err := available.Less(converted); err != nil || less {
+		return errors.NewStatus("INSUFFICIENT_AVAILABLE_STOCK").SetPath(path).SetMessage("%s: %v < %v", comp.ID.Hex(), available, converted)
+	}
+	return nil
+}
@@ -0,0 +1,353 @@
+package stock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aboglioli/big-brother/composition"
+	"github.com/aboglioli/big-brother/pkg/quantity"
+	"github.com/aboglioli/big-brother/pkg/tests/assert"
+)
+
+func newComposition(unit quantity.Quantity, cost float64) *composition.Composition {
+	comp := composition.NewComposition()
+	comp.Cost = cost
+	comp.Unit = unit
+	comp.Stock = quantity.Quantity{0, unit.Unit}
+	comp.Validated = true
+	return comp
+}
+
+type serviceContext struct {
+	lotRepo      *mockLotRepository
+	movementRepo *mockMovementRepository
+	countRepo    *mockCountRepository
+	compRepo     composition.Repository
+	compServ     composition.Service
+	serv         Service
+}
+
+func newServiceContext() *serviceContext {
+	lotRepo, movementRepo, countRepo := NewMockLotRepository(), NewMockMovementRepository(), NewMockCountRepository()
+	compRepo := composition.NewMockRepository()
+	compServ := composition.NewService(compRepo)
+
+	return &serviceContext{
+		lotRepo:      lotRepo,
+		movementRepo: movementRepo,
+		countRepo:    countRepo,
+		compRepo:     compRepo,
+		compServ:     compServ,
+		serv:         NewService(lotRepo, movementRepo, countRepo, compServ),
+	}
+}
+
+func TestReceive(t *testing.T) {
+	ctx := newServiceContext()
+	flour := newComposition(quantity.Quantity{1, "kg"}, 10)
+	ctx.compRepo.Insert(flour)
+
+	t.Run("Incompatible quantity", func(t *testing.T) {
+		_, err := ctx.serv.Receive(&ReceiveRequest{
+			Composition: flour.ID.Hex(),
+			Quantity:    quantity.Quantity{1, "l"},
+		})
+		assert.ErrCode(t, err, "INCOMPATIBLE_QUANTITY")
+	})
+
+	t.Run("Expiration before date", func(t *testing.T) {
+		expiresAt := time.Now().Add(-time.Hour)
+		_, err := ctx.serv.Receive(&ReceiveRequest{
+			Composition: flour.ID.Hex(),
+			Quantity:    quantity.Quantity{1, "kg"},
+			ExpiresAt:   &expiresAt,
+		})
+		assert.ErrValidation(t, err, "expiresAt", "BEFORE_DATE")
+	})
+
+	t.Run("Lot created and stock increased", func(t *testing.T) {
+		lot, err := ctx.serv.Receive(&ReceiveRequest{
+			Composition: flour.ID.Hex(),
+			Code:        "F-001",
+			Quantity:    quantity.Quantity{25, "kg"},
+		})
+		assert.Ok(t, err)
+		assert.Equal(t, lot.Origin, OriginReceipt)
+
+		comp, _ := ctx.compRepo.FindByID(flour.ID.Hex())
+		assert.Assert(t, comp.Stock.Equals(quantity.Quantity{25, "kg"}), "Stock should be increased")
+
+		movements, _ := ctx.serv.FindMovementsByComposition(flour.ID.Hex())
+		assert.Equal(t, len(movements), 1)
+		assert.Equal(t, movements[0].Type, MovementReceipt)
+		assert.Equal(t, movements[0].Cost, 250.0)
+
+		entries := ctx.movementRepo.Entries()
+		assert.Equal(t, len(entries), 2, "Events should be stored with the movement")
+		assert.Equal(t, entries[0].Type(), "LotCreated")
+		assert.Equal(t, entries[1].Type(), "StockMovementPosted")
+		assert.Equal(t, entries[1].Aggregate, flour.ID.Hex())
+	})
+}
+
+func TestProduceAndTrace(t *testing.T) {
+	ctx := newServiceContext()
+	flour := newComposition(quantity.Quantity{1, "kg"}, 10)
+	water := newComposition(quantity.Quantity{1, "l"}, 1)
+	bread := newComposition(quantity.Quantity{1, "u"}, 0)
+	bread.Dependencies = []composition.Dependency{
+		{On: flour.ID, Quantity: quantity.Quantity{500, "g"}, Subvalue: 5},
+		{On: water.ID, Quantity: quantity.Quantity{300, "ml"}, Subvalue: 0.3},
+	}
+	bread.Cost = 5.3
+	ctx.compRepo.Insert(flour)
+	ctx.compRepo.Insert(water)
+	ctx.compRepo.Insert(bread)
+
+	soon, later := time.Now().Add(24*time.Hour), time.Now().Add(72*time.Hour)
+	flour1, _ := ctx.serv.Receive(&ReceiveRequest{Composition: flour.ID.Hex(), Quantity: quantity.Quantity{2, "kg"}, ExpiresAt: &later})
+	flour2, _ := ctx.serv.Receive(&ReceiveRequest{Composition: flour.ID.Hex(), Quantity: quantity.Quantity{1, "kg"}, ExpiresAt: &soon})
+	water1, _ := ctx.serv.Receive(&ReceiveRequest{Composition: water.ID.Hex(), Quantity: quantity.Quantity{2, "l"}})
+
+	t.Run("Insufficient lots", func(t *testing.T) {
+		_, err := ctx.serv.Produce(&ProduceRequest{Composition: bread.ID.Hex(), Quantity: quantity.Quantity{10, "u"}})
+		assert.ErrCode(t, err, "INSUFFICIENT_LOTS")
+
+		lot, _ := ctx.lotRepo.FindByID(flour2.ID.Hex())
+		assert.Assert(t, lot.Remaining.Equals(lot.Quantity), "Nothing should be consumed")
+	})
+
+	var breadLot *Lot
+	t.Run("Consume first expired lots", func(t *testing.T) {
+		var err error
+		breadLot, err = ctx.serv.Produce(&ProduceRequest{Composition: bread.ID.Hex(), Quantity: quantity.Quantity{3, "u"}})
+		assert.Ok(t, err)
+		assert.Equal(t, len(breadLot.Components), 3)
+
+		lot, _ := ctx.lotRepo.FindByID(flour2.ID.Hex())
+		assert.Equal(t, lot.Remaining.Quantity, 0.0, "First expiring lot should be consumed completely")
+		lot, _ = ctx.lotRepo.FindByID(flour1.ID.Hex())
+		assert.Assert(t, lot.Remaining.Equals(quantity.Quantity{1.5, "kg"}))
+		lot, _ = ctx.lotRepo.FindByID(water1.ID.Hex())
+		assert.Assert(t, lot.Remaining.Equals(quantity.Quantity{1.1, "l"}))
+
+		comp, _ := ctx.compRepo.FindByID(flour.ID.Hex())
+		assert.Assert(t, comp.Stock.Equals(quantity.Quantity{1.5, "kg"}))
+		comp, _ = ctx.compRepo.FindByID(bread.ID.Hex())
+		assert.Assert(t, comp.Stock.Equals(quantity.Quantity{3, "u"}))
+	})
+
+	t.Run("Traceability", func(t *testing.T) {
+		lots, err := ctx.serv.FindLotsContaining(flour2.ID.Hex())
+		assert.Ok(t, err)
+		assert.Equal(t, len(lots), 1)
+		assert.Equal(t, lots[0].ID, breadLot.ID)
+
+		lots, err = ctx.serv.FindLotComponents(breadLot.ID.Hex())
+		assert.Ok(t, err)
+		assert.Equal(t, len(lots), 3)
+	})
+
+	t.Run("Issue", func(t *testing.T) {
+		allocations, err := ctx.serv.Issue(&IssueRequest{Composition: bread.ID.Hex(), Quantity: quantity.Quantity{2, "u"}, Reference: "order"})
+		assert.Ok(t, err)
+		assert.Equal(t, len(allocations), 1)
+		assert.Equal(t, allocations[0].Lot, breadLot.ID)
+
+		comp, _ := ctx.compRepo.FindByID(bread.ID.Hex())
+		assert.Assert(t, comp.Stock.Equals(quantity.Quantity{1, "u"}))
+	})
+
+	t.Run("Reserved stock", func(t *testing.T) {
+		_, err := ctx.compServ.Reserve(flour.ID.Hex(), quantity.Quantity{1, "kg"})
+		assert.Ok(t, err)
+
+		_, err = ctx.serv.Issue(&IssueRequest{Composition: flour.ID.Hex(), Quantity: quantity.Quantity{1, "kg"}})
+		assert.ErrCode(t, err, "INSUFFICIENT_AVAILABLE_STOCK")
+		_, err = ctx.serv.Produce(&ProduceRequest{Composition: bread.ID.Hex(), Quantity: quantity.Quantity{2, "u"}})
+		assert.ErrCode(t, err, "INSUFFICIENT_AVAILABLE_STOCK")
+
+		lot, _ := ctx.lotRepo.FindByID(flour1.ID.Hex())
+		assert.Assert(t, lot.Remaining.Equals(quantity.Quantity{1.5, "kg"}), "Nothing should be consumed")
+
+		_, err = ctx.serv.Issue(&IssueRequest{Composition: flour.ID.Hex(), Quantity: quantity.Quantity{500, "g"}})
+		assert.Ok(t, err)
+		_, err = ctx.serv.Issue(&IssueRequest{Composition: flour.ID.Hex(), Quantity: quantity.Quantity{1, "kg"}, Reserved: true})
+		assert.Ok(t, err)
+
+		comp, _ := ctx.compRepo.FindByID(flour.ID.Hex())
+		assert.Assert(t, comp.Stock.Equals(quantity.Quantity{0, "kg"}))
+		assert.Assert(t, comp.Reserved.Equals(quantity.Quantity{0, "kg"}))
+	})
+}
+
+func TestConvertedQuantities(t *testing.T) {
+	ctx := newServiceContext()
+	beer := newComposition(quantity.Quantity{1, "u"}, 2)
+	pack := newComposition(quantity.Quantity{1, "u"}, 2)
+	pack.Conversions = []quantity.Conversion{
+		quantity.Conversion{From: quantity.Quantity{1, "box"}, To: quantity.Quantity{6, "u"}},
+	}
+	pack.Dependencies = []composition.Dependency{{On: beer.ID, Quantity: quantity.Quantity{1, "u"}, Subvalue: 2}}
+	ctx.compRepo.Insert(beer)
+	ctx.compRepo.Insert(pack)
+
+	_, err := ctx.serv.Receive(&ReceiveRequest{Composition: beer.ID.Hex(), Quantity: quantity.Quantity{12, "u"}})
+	assert.Ok(t, err)
+
+	lot, err := ctx.serv.Produce(&ProduceRequest{Composition: pack.ID.Hex(), Quantity: quantity.Quantity{1, "box"}})
+	assert.Ok(t, err)
+	assert.Equal(t, lot.Quantity, quantity.Quantity{6, "u"})
+
+	comp, _ := ctx.compRepo.FindByID(beer.ID.Hex())
+	assert.Assert(t, comp.Stock.Equals(quantity.Quantity{6, "u"}))
+
+	_, err = ctx.serv.Issue(&IssueRequest{Composition: pack.ID.Hex(), Quantity: quantity.Quantity{0.5, "box"}})
+	assert.Ok(t, err)
+	_, err = ctx.serv.Issue(&IssueRequest{Composition: pack.ID.Hex(), Quantity: quantity.Quantity{1, "kg"}})
+	assert.ErrCode(t, err, "INCOMPATIBLE_QUANTITY")
+
+	comp, _ = ctx.compRepo.FindByID(pack.ID.Hex())
+	assert.Assert(t, comp.Stock.Equals(quantity.Quantity{3, "u"}))
+}
+
+func TestBlockExpired(t *testing.T) {
+	ctx := newServiceContext()
+	milk := newComposition(quantity.Quantity{1, "l"}, 2)
+	milk.Stock = quantity.Quantity{1, "l"}
+	ctx.compRepo.Insert(milk)
+
+	expired := NewLot(milk.ID, OriginReceipt)
+	expired.Quantity = quantity.Quantity{1, "l"}
+	expired.Remaining = expired.Quantity
+	expiresAt := time.Now().Add(-time.Hour)
+	expired.ExpiresAt = &expiresAt
+	ctx.lotRepo.Insert(expired)
+	lot, err := ctx.serv.Receive(&ReceiveRequest{Composition: milk.ID.Hex(), Quantity: quantity.Quantity{1, "l"}})
+	assert.Ok(t, err)
+
+	blocked, err := ctx.serv.BlockExpired()
+	assert.Ok(t, err)
+	assert.Equal(t, len(blocked), 1)
+	assert.Equal(t, blocked[0].ID, expired.ID)
+
+	saved, _ := ctx.serv.GetLot(expired.ID.Hex())
+	assert.Assert(t, saved.Blocked)
+	entries := ctx.lotRepo.Entries()
+	assert.Equal(t, entries[len(entries)-1].Type(), "LotBlocked")
+	saved, _ = ctx.serv.GetLot(lot.ID.Hex())
+	assert.Assert(t, !saved.Blocked)
+
+	comp, _ := ctx.compRepo.FindByID(milk.ID.Hex())
+	assert.Assert(t, comp.Available().Equals(quantity.Quantity{1, "l"}))
+
+	_, err = ctx.serv.BlockLot(expired.ID.Hex())
+	assert.Ok(t, err)
+	comp, _ = ctx.compRepo.FindByID(milk.ID.Hex())
+	assert.Assert(t, comp.Blocked.Equals(quantity.Quantity{1, "l"}), "Blocked lots are blocked once")
+}
+
+func TestStockCount(t *testing.T) {
+	ctx := newServiceContext()
+	flour := newComposition(quantity.Quantity{1, "kg"}, 10)
+	sugar := newComposition(quantity.Quantity{1, "kg"}, 5)
+	sugar.Stock = quantity.Quantity{2, "kg"}
+	ctx.compRepo.Insert(flour)
+	ctx.compRepo.Insert(sugar)
+
+	expiresAt := time.Now().AddDate(0, 1, 0)
+	first, err := ctx.serv.Receive(&ReceiveRequest{Composition: flour.ID.Hex(), Quantity: quantity.Quantity{4, "kg"}, ExpiresAt: &expiresAt})
+	assert.Ok(t, err)
+	second, err := ctx.serv.Receive(&ReceiveRequest{Composition: flour.ID.Hex(), Quantity: quantity.Quantity{6, "kg"}})
+	assert.Ok(t, err)
+	ctx.movementRepo.Clean()
+
+	count, err := ctx.serv.OpenCount(&OpenCountRequest{
+		Compositions: []string{flour.ID.Hex(), sugar.ID.Hex()},
+		Locations:    []string{"A", "B"},
+	})
+	assert.Ok(t, err)
+	assert.Equal(t, len(count.Lines), 2)
+
+	t.Run("Invalid records", func(t *testing.T) {
+		_, err := ctx.serv.RecordCount(count.ID.Hex(), &RecordCountRequest{Composition: flour.ID.Hex(), Location: "C", Quantity: quantity.Quantity{1, "kg"}})
+		assert.ErrCode(t, err, "LOCATION_NOT_IN_COUNT")
+
+		_, err = ctx.serv.RecordCount(count.ID.Hex(), &RecordCountRequest{Composition: flour.ID.Hex(), Location: "A", Quantity: quantity.Quantity{1, "l"}})
+		assert.ErrCode(t, err, "INCOMPATIBLE_QUANTITY")
+	})
+
+	t.Run("Variance from counts in different locations and units", func(t *testing.T) {
+		_, err := ctx.serv.RecordCount(count.ID.Hex(), &RecordCountRequest{Composition: flour.ID.Hex(), Location: "A", Quantity: quantity.Quantity{3, "kg"}})
+		assert.Ok(t, err)
+		// Recount replaces the previous quantity
+		_, err = ctx.serv.RecordCount(count.ID.Hex(), &RecordCountRequest{Composition: flour.ID.Hex(), Location: "A", Quantity: quantity.Quantity{4, "kg"}})
+		assert.Ok(t, err)
+		count, err = ctx.serv.RecordCount(count.ID.Hex(), &RecordCountRequest{Composition: flour.ID.Hex(), Location: "B", Quantity: quantity.Quantity{5500, "g"}})
+		assert.Ok(t, err)
+
+		line := count.FindLine(flour.ID.Hex())
+		assert.Assert(t, line.Counted.Equals(quantity.Quantity{9.5, "kg"}))
+		assert.Assert(t, line.Variance.Equals(quantity.Quantity{-0.5, "kg"}))
+		assert.Equal(t, line.Cost, -5.0)
+	})
+
+	t.Run("Approve posts adjustments", func(t *testing.T) {
+		count, err := ctx.serv.ApproveCount(count.ID.Hex())
+		assert.Ok(t, err)
+		assert.Equal(t, count.Status, CountApproved)
+		assert.NotNil(t, count.FindLine(flour.ID.Hex()).Movement)
+		assert.Nil(t, count.FindLine(sugar.ID.Hex()).Movement, "Not counted lines should be ignored")
+
+		comp, _ := ctx.compRepo.FindByID(flour.ID.Hex())
+		assert.Assert(t, comp.Stock.Equals(quantity.Quantity{9.5, "kg"}))
+		comp, _ = ctx.compRepo.FindByID(sugar.ID.Hex())
+		assert.Assert(t, comp.Stock.Equals(quantity.Quantity{2, "kg"}))
+
+		movements, _ := ctx.serv.FindMovementsByComposition(flour.ID.Hex())
+		assert.Equal(t, len(movements), 1)
+		assert.Assert(t, movements[0].Type == MovementAdjustment && !movements[0].Incoming)
+		assert.Equal(t, movements[0].SignedCost(), -5.0)
+
+		// The shortage is taken from the lot expiring first
+		lot, _ := ctx.serv.GetLot(first.ID.Hex())
+		assert.Assert(t, lot.Remaining.Equals(quantity.Quantity{3.5, "kg"}))
+		lot, _ = ctx.serv.GetLot(second.ID.Hex())
+		assert.Assert(t, lot.Remaining.Equals(quantity.Quantity{6, "kg"}))
+
+		_, err = ctx.serv.ApproveCount(count.ID.Hex())
+		assert.ErrCode(t, err, "COUNT_NOT_OPEN")
+	})
+
+	t.Run("Surplus creates an adjustment lot", func(t *testing.T) {
+		count, err := ctx.serv.OpenCount(&OpenCountRequest{Compositions: []string{flour.ID.Hex()}, Locations: []string{"A"}})
+		assert.Ok(t, err)
+		_, err = ctx.serv.RecordCount(count.ID.Hex(), &RecordCountRequest{Composition: flour.ID.Hex(), Location: "A", Quantity: quantity.Quantity{10, "kg"}})
+		assert.Ok(t, err)
+
+		count, err = ctx.serv.ApproveCount(count.ID.Hex())
+		assert.Ok(t, err)
+
+		lots, _ := ctx.serv.FindLotsByComposition(flour.ID.Hex())
+		assert.Equal(t, len(lots), 3)
+		adjustment := lots[2]
+		assert.Equal(t, adjustment.Origin, OriginAdjustment)
+		assert.Assert(t, adjustment.Remaining.Equals(quantity.Quantity{0.5, "kg"}))
+	})
+
+	t.Run("Shortage greater than lots is rejected", func(t *testing.T) {
+		count, err := ctx.serv.OpenCount(&OpenCountRequest{Compositions: []string{sugar.ID.Hex()}, Locations: []string{"A"}})
+		assert.Ok(t, err)
+		_, err = ctx.serv.RecordCount(count.ID.Hex(), &RecordCountRequest{Composition: sugar.ID.Hex(), Location: "A", Quantity: quantity.Quantity{1, "kg"}})
+		assert.Ok(t, err)
+
+		_, err = ctx.serv.ApproveCount(count.ID.Hex())
+		assert.ErrCode(t, err, "INSUFFICIENT_LOTS")
+
+		count, _ = ctx.serv.GetCount(count.ID.Hex())
+		assert.Assert(t, count.IsOpen())
+		assert.Nil(t, count.FindLine(sugar.ID.Hex()).Movement)
+		comp, _ := ctx.compRepo.FindByID(sugar.ID.Hex())
+		assert.Assert(t, comp.Stock.Equals(quantity.Quantity{2, "kg"}))
+	})
+}
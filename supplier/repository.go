@@ -0,0 +1,176 @@
+package supplier
+
+import (
+	"context"
+	"time"
+
+	"github.com/aboglioli/big-brother/infrastructure/db"
+	"github.com/aboglioli/big-brother/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type Repository interface {
+	FindAll() ([]*Supplier, error)
+	FindByID(id string) (*Supplier, error)
+	FindByComposition(compID string) ([]*Supplier, error)
+
+	Insert(*Supplier) error
+	Update(*Supplier) error
+	Delete(id string) error
+}
+
+type repository struct {
+	collection *mongo.Collection
+}
+
+func NewRepository() (Repository, error) {
+	db, err := db.Get("Supplier")
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &repository{
+		collection: db.Collection("supplier"),
+	}, nil
+}
+
+func (r *repository) FindAll() ([]*Supplier, error) {
+	return r.find("supplier/repository.FindAll", bson.M{
+		"enabled": true,
+	})
+}
+
+func (r *repository) FindByID(id string) (*Supplier, error) {
+	path := "supplier/repository.FindByID"
+	ctx := context.Background()
+
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errors.NewInternal("OBJECTID_FROM_HEX").SetPath(path).SetRef(err)
+	}
+
+	filter := bson.M{
+		"_id": objID,
+	}
+
+	res := r.collection.FindOne(ctx, filter)
+	if res.Err() != nil {
+		return nil, errors.NewInternal("FIND_ONE").SetPath(path).SetRef(res.Err())
+	}
+
+	var s Supplier
+	if err := res.Decode(&s); err != nil {
+		return nil, errors.NewInternal("DECODE").SetPath(path).SetRef(err)
+	}
+
+	return &s, nil
+}
+
+func (r *repository) FindByComposition(compID string) ([]*Supplier, error) {
+	path := "supplier/repository.FindByComposition"
+
+	objID, err := primitive.ObjectIDFromHex(compID)
+	if err != nil {
+		return nil, errors.NewInternal("OBJECTID_FROM_HEX").SetPath(path).SetRef(err)
+	}
+
+	return r.find(path, bson.M{
+		"prices.composition": objID,
+		"enabled":            true,
+	})
+}
+
+func (r *repository) Insert(s *Supplier) error {
+	ctx := context.Background()
+
+	_, err := r.collection.InsertOne(ctx, s)
+	if err != nil {
+		return errors.NewInternal("INSERT_ONE").SetPath("supplier/repository.Insert").SetRef(err)
+	}
+
+	return nil
+}
+
+func (r *repository) Update(s *Supplier) error {
+	path := "supplier/repository.Update"
+	ctx := context.Background()
+
+	if s.ID.IsZero() {
+		return errors.NewInternal("INVALID_OBJECTID").SetPath(path)
+	}
+
+	s.UpdatedAt = time.Now()
+
+	filter := bson.M{
+		"_id": s.ID,
+	}
+
+	update := bson.M{
+		"$set": s,
+	}
+
+	_, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return errors.NewInternal("UPDATE_ONE").SetPath(path).SetRef(err)
+	}
+
+	return nil
+}
+
+func (r *repository) Delete(id string) error {
+	path := "supplier/repository.Delete"
+	ctx := context.Background()
+
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return errors.NewInternal("OBJECTID_FROM_HEX").SetPath(path).SetRef(err)
+	}
+
+	filter := bson.M{
+		"_id": objID,
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"updatedAt": time.Now(),
+			"enabled":   false,
+		},
+	}
+
+	_, err = r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return errors.NewInternal("UPDATE_ONE").SetPath(path).SetRef(err)
+	}
+
+	return nil
+}
+
+func (r *repository) find(path string, filter bson.M) ([]*Supplier, error) {
+	ctx := context.Background()
+
+	cur, err := r.collection.Find(ctx, filter)
+	if err != nil {
+		return nil, errors.NewInternal("FIND").SetPath(path).SetRef(err)
+	}
+	defer cur.Close(ctx)
+
+	suppliers := make([]*Supplier, 0)
+	for cur.Next(ctx) {
+		var s Supplier
+
+		if err := cur.Decode(&s); err != nil {
+			return nil, errors.NewInternal("CUR_DECODE").SetPath(path).SetRef(err)
+		}
+
+		suppliers = append(suppliers, &s)
+	}
+
+	if err := cur.Err(); err != nil {
+		return nil, errors.NewInternal("CUR").SetPath(path).SetRef(err)
+	}
+
+	return suppliers, nil
+}
@@ -0,0 +1,106 @@
+package supplier
+
+import (
+	"time"
+
+	"github.com/aboglioli/big-brother/pkg/errors"
+	"github.com/aboglioli/big-brother/pkg/tests/mock"
+)
+
+type mockRepository struct {
+	mock.Mock
+	suppliers []*Supplier
+}
+
+// NewMockRepository returns an in-memory Repository. It is exported to be
+// used by other packages' tests.
+func NewMockRepository() *mockRepository {
+	return &mockRepository{}
+}
+
+// Helpers
+func (r *mockRepository) Clean() {
+	r.suppliers = make([]*Supplier, 0)
+}
+
+// Implementation
+func (r *mockRepository) FindAll() ([]*Supplier, error) {
+	r.Called("FindAll")
+
+	suppliers := make([]*Supplier, 0)
+	for _, s := range r.suppliers {
+		if s.Enabled {
+			suppliers = append(suppliers, copySupplier(s))
+		}
+	}
+
+	return suppliers, nil
+}
+
+func (r *mockRepository) FindByID(id string) (*Supplier, error) {
+	r.Called("FindByID", id)
+
+	for _, s := range r.suppliers {
+		if s.ID.Hex() == id {
+			return copySupplier(s), nil
+		}
+	}
+
+	return nil, errors.NewInternal("NOT_FOUND").SetPath("supplier/repository_mock.FindByID")
+}
+
+func (r *mockRepository) FindByComposition(compID string) ([]*Supplier, error) {
+	r.Called("FindByComposition", compID)
+
+	suppliers := make([]*Supplier, 0)
+	for _, s := range r.suppliers {
+		if s.Enabled && s.FindPrice(compID) != nil {
+			suppliers = append(suppliers, copySupplier(s))
+		}
+	}
+
+	return suppliers, nil
+}
+
+func (r *mockRepository) Insert(s *Supplier) error {
+	r.Called("Insert", s)
+
+	s.UpdatedAt = time.Now()
+	r.suppliers = append(r.suppliers, copySupplier(s))
+
+	return nil
+}
+
+func (r *mockRepository) Update(s *Supplier) error {
+	r.Called("Update", s)
+
+	for _, supplier := range r.suppliers {
+		if supplier.ID.Hex() == s.ID.Hex() {
+			*supplier = *copySupplier(s)
+			supplier.UpdatedAt = time.Now()
+			break
+		}
+	}
+
+	return nil
+}
+
+func (r *mockRepository) Delete(id string) error {
+	r.Called("Delete", id)
+
+	for _, s := range r.suppliers {
+		if s.ID.Hex() == id {
+			s.UpdatedAt = time.Now()
+			s.Enabled = false
+			return nil
+		}
+	}
+
+	return errors.NewInternal("NOT_FOUND").SetPath("supplier/repository_mock.Delete")
+}
+
+func copySupplier(s *Supplier) *Supplier {
+	copy := *s
+	copy.Prices = append([]Price(nil), s.Prices...)
+	return &copy
+}
@@ -0,0 +1,356 @@
+package supplier
+
+import (
+	"math"
+
+	"github.com/aboglioli/big-brother/composition"
+	"github.com/aboglioli/big-brother/pkg/contact"
+	"github.com/aboglioli/big-brother/pkg/errors"
+	"github.com/aboglioli/big-brother/pkg/events"
+	"github.com/aboglioli/big-brother/pkg/quantity"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Service interface {
+	GetAll() ([]*Supplier, error)
+	GetByID(id string) (*Supplier, error)
+	Create(req *CreateRequest) (*Supplier, error)
+	Update(id string, req *UpdateRequest) (*Supplier, error)
+	Delete(id string) error
+
+	SetPrice(id string, req *PriceRequest) (*Supplier, error)
+	RemovePrice(id string, compID string) (*Supplier, error)
+	FindByComposition(compID string) ([]*Supplier, error)
+	BestPrice(compID string) (*Supplier, *Price, error)
+	UpdateCompositionCost(compID string) (*composition.Composition, error)
+}
+
+type service struct {
+	repository         Repository
+	compositionService composition.Service
+	eventMgr           events.Manager
+}
+
+func NewService(repo Repository, compServ composition.Service, eventMgr events.Manager) Service {
+	return &service{
+		repository:         repo,
+		compositionService: compServ,
+		eventMgr:           eventMgr,
+	}
+}
+
+func (s *service) GetAll() ([]*Supplier, error) {
+	suppliers, err := s.repository.FindAll()
+	if err != nil {
+		return nil, errors.NewStatus("FIND_ALL").SetPath("supplier/service.GetAll").SetRef(err)
+	}
+	return suppliers, nil
+}
+
+func (s *service) GetByID(id string) (*Supplier, error) {
+	supplier, err := s.repository.FindByID(id)
+	if err != nil || !supplier.Enabled {
+		return nil, errors.NewStatus("SUPPLIER_NOT_FOUND").SetPath("supplier/service.GetByID").SetStatus(404).SetRef(err)
+	}
+	return supplier, nil
+}
+
+type CreateRequest struct {
+	Name    string           `json:"name" binding:"required"`
+	Address *contact.Address `json:"address"`
+	Contact *contact.Contact `json:"contact"`
+	Social  *contact.Social  `json:"social"`
+}
+
+func (s *service) Create(req *CreateRequest) (*Supplier, error) {
+	path := "supplier/service.Create"
+
+	supplier := NewSupplier()
+	supplier.Name = req.Name
+	if req.Address != nil {
+		supplier.Address = *req.Address
+	}
+	if req.Contact != nil {
+		supplier.Contact = *req.Contact
+	}
+	if req.Social != nil {
+		supplier.Social = *req.Social
+	}
+
+	if err := supplier.ValidateSchema(); err != nil {
+		return nil, err
+	}
+
+	if err := s.repository.Insert(supplier); err != nil {
+		return nil, errors.NewStatus("INSERT").SetPath(path).SetRef(err)
+	}
+
+	return supplier, nil
+}
+
+type UpdateRequest struct {
+	Name    *string          `json:"name"`
+	Address *contact.Address `json:"address"`
+	Contact *contact.Contact `json:"contact"`
+	Social  *contact.Social  `json:"social"`
+}
+
+func (s *service) Update(id string, req *UpdateRequest) (*Supplier, error) {
+	path := "supplier/service.Update"
+
+	supplier, err := s.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		supplier.Name = *req.Name
+	}
+	if req.Address != nil {
+		supplier.Address = *req.Address
+	}
+	if req.Contact != nil {
+		supplier.Contact = *req.Contact
+	}
+	if req.Social != nil {
+		supplier.Social = *req.Social
+	}
+
+	if err := supplier.ValidateSchema(); err != nil {
+		return nil, err
+	}
+
+	if err := s.repository.Update(supplier); err != nil {
+		return nil, errors.NewStatus("UPDATE").SetPath(path).SetRef(err)
+	}
+
+	return supplier, nil
+}
+
+// Delete disables a supplier. Costs of the compositions it was supplying are
+// recalculated from the remaining suppliers.
+func (s *service) Delete(id string) error {
+	path := "supplier/service.Delete"
+
+	supplier, err := s.GetByID(id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repository.Delete(id); err != nil {
+		return errors.NewStatus("DELETE").SetPath(path).SetRef(err)
+	}
+
+	for _, p := range supplier.Prices {
+		if _, err := s.UpdateCompositionCost(p.Composition.Hex()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type PriceRequest struct {
+	Composition string            `json:"composition" binding:"required"`
+	Price       float64           `json:"price"`
+	Quantity    quantity.Quantity `json:"quantity" binding:"required"`
+	Preferred   bool              `json:"preferred"`
+}
+
+// SetPrice adds or replaces the price of a composition in the supplier price
+// list. Only one supplier can be preferred for each composition, so setting a
+// preferred price unsets the preferred flag in the other suppliers.
+func (s *service) SetPrice(id string, req *PriceRequest) (*Supplier, error) {
+	path := "supplier/service.SetPrice"
+
+	supplier, err := s.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	comp, err := s.compositionService.GetByID(req.Composition)
+	if err != nil {
+		return nil, err
+	}
+
+	// Prices can be in other unit types (liquids bought by weight) if the
+	// composition can convert them
+	q, err := comp.ToUnit(req.Quantity)
+	if err != nil {
+		return nil, errors.NewStatus("INCOMPATIBLE_QUANTITY").SetPath(path).SetMessage("%v != %v", req.Quantity, comp.Unit).SetRef(err)
+	}
+
+	supplier.UpsertPrice(Price{
+		Composition: comp.ID,
+		Price:       req.Price,
+		Quantity:    q,
+		Preferred:   req.Preferred,
+	})
+
+	if err := supplier.ValidateSchema(); err != nil {
+		return nil, err
+	}
+
+	if req.Preferred {
+		if err := s.unsetPreferred(comp.ID, supplier.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.repository.Update(supplier); err != nil {
+		return nil, errors.NewStatus("UPDATE").SetPath(path).SetRef(err)
+	}
+
+	if _, err := s.UpdateCompositionCost(comp.ID.Hex()); err != nil {
+		return nil, err
+	}
+
+	return supplier, nil
+}
+
+func (s *service) RemovePrice(id string, compID string) (*Supplier, error) {
+	path := "supplier/service.RemovePrice"
+
+	supplier, err := s.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := supplier.RemovePrice(compID); err != nil {
+		return nil, err
+	}
+
+	if err := s.repository.Update(supplier); err != nil {
+		return nil, errors.NewStatus("UPDATE").SetPath(path).SetRef(err)
+	}
+
+	if _, err := s.UpdateCompositionCost(compID); err != nil {
+		return nil, err
+	}
+
+	return supplier, nil
+}
+
+func (s *service) FindByComposition(compID string) ([]*Supplier, error) {
+	suppliers, err := s.repository.FindByComposition(compID)
+	if err != nil {
+		return nil, errors.NewStatus("FIND_BY_COMPOSITION").SetPath("supplier/service.FindByComposition").SetRef(err)
+	}
+	return suppliers, nil
+}
+
+// BestPrice returns the preferred supplier price of a composition or, if there
+// is no preferred one, the cheapest price per normalized unit.
+func (s *service) BestPrice(compID string) (*Supplier, *Price, error) {
+	supplier, price, err := s.bestPrice(compID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if price == nil {
+		return nil, nil, errors.NewStatus("PRICE_NOT_FOUND").SetPath("supplier/service.BestPrice").SetStatus(404)
+	}
+
+	return supplier, price, nil
+}
+
+// UpdateCompositionCost sets the cost of a raw material (a composition without
+// dependencies and with cost autoupdate enabled) from its best supplier price.
+// The composition is updated through the composition service, so the change is
+// propagated to the compositions using it. Other compositions are returned
+// unchanged.
+func (s *service) UpdateCompositionCost(compID string) (*composition.Composition, error) {
+	comp, err := s.compositionService.GetByID(compID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(comp.Dependencies) > 0 || !comp.AutoupdateCost {
+		return comp, nil
+	}
+
+	_, price, err := s.bestPrice(compID)
+	if err != nil {
+		return nil, err
+	}
+
+	if price == nil {
+		return comp, nil
+	}
+
+	cost, err := price.CostFor(comp.Unit)
+	if err != nil {
+		return nil, err
+	}
+
+	cost = math.Round(cost*1000) / 1000
+	if cost == comp.Cost {
+		return comp, nil
+	}
+
+	return s.compositionService.Update(compID, &composition.UpdateRequest{
+		Cost:         &cost,
+		Dependencies: comp.Dependencies,
+		Confirm:      true,
+	})
+}
+
+func (s *service) bestPrice(compID string) (*Supplier, *Price, error) {
+	suppliers, err := s.FindByComposition(compID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var bestSupplier *Supplier
+	var bestPrice *Price
+	for _, supplier := range suppliers {
+		p := supplier.FindPrice(compID)
+		if p == nil {
+			continue
+		}
+
+		if p.Preferred {
+			return supplier, p, nil
+		}
+
+		if bestPrice == nil || cheaper(p, bestPrice) {
+			bestSupplier, bestPrice = supplier, p
+		}
+	}
+
+	return bestSupplier, bestPrice, nil
+}
+
+func (s *service) unsetPreferred(compID primitive.ObjectID, except primitive.ObjectID) error {
+	suppliers, err := s.FindByComposition(compID.Hex())
+	if err != nil {
+		return err
+	}
+
+	for _, supplier := range suppliers {
+		if supplier.ID == except {
+			continue
+		}
+
+		p := supplier.FindPrice(compID.Hex())
+		if p == nil || !p.Preferred {
+			continue
+		}
+
+		p.Preferred = false
+		if err := s.repository.Update(supplier); err != nil {
+			return errors.NewStatus("UPDATE").SetPath("supplier/service.unsetPreferred").SetRef(err)
+		}
+	}
+
+	return nil
+}
+
+// cheaper returns true if the same quantity costs less with p1 than with p2.
+func cheaper(p1, p2 *Price) bool {
+	ratio, err := p2.Quantity.Ratio(p1.Quantity)
+	if err != nil {
+		return false
+	}
+	return p1.Price*ratio < p2.Price
+}
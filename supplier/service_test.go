@@ -0,0 +1,141 @@
+package supplier
+
+import (
+	"testing"
+
+	"github.com/aboglioli/big-brother/composition"
+	"github.com/aboglioli/big-brother/pkg/events"
+	"github.com/aboglioli/big-brother/pkg/quantity"
+	"github.com/aboglioli/big-brother/pkg/tests/assert"
+)
+
+func newComposition(unit quantity.Quantity, cost float64) *composition.Composition {
+	comp := composition.NewComposition()
+	comp.Cost = cost
+	comp.Unit = unit
+	comp.Stock = quantity.Quantity{0, unit.Unit}
+	comp.Validated = true
+	return comp
+}
+
+func TestSupplierPrices(t *testing.T) {
+	repo, compRepo, eventMgr := NewMockRepository(), composition.NewMockRepository(), events.GetMockManager()
+	serv := NewService(repo, composition.NewService(compRepo), eventMgr)
+
+	flour := newComposition(quantity.Quantity{1, "kg"}, 10)
+	compRepo.Insert(flour)
+
+	s1, err := serv.Create(&CreateRequest{Name: "Mill"})
+	assert.Ok(t, err)
+	s2, err := serv.Create(&CreateRequest{Name: "Market"})
+	assert.Ok(t, err)
+
+	t.Run("Incompatible quantity", func(t *testing.T) {
+		_, err := serv.SetPrice(s1.ID.Hex(), &PriceRequest{
+			Composition: flour.ID.Hex(),
+			Price:       100,
+			Quantity:    quantity.Quantity{1, "l"},
+		})
+		assert.ErrCode(t, err, "INCOMPATIBLE_QUANTITY")
+	})
+
+	t.Run("Cheapest price", func(t *testing.T) {
+		compRepo.Reset()
+
+		// 450 per 25 kg bag: 18 per kg
+		_, err := serv.SetPrice(s1.ID.Hex(), &PriceRequest{
+			Composition: flour.ID.Hex(),
+			Price:       450,
+			Quantity:    quantity.Quantity{25, "kg"},
+		})
+		assert.Ok(t, err)
+
+		// 10 per 500 g: 20 per kg
+		_, err = serv.SetPrice(s2.ID.Hex(), &PriceRequest{
+			Composition: flour.ID.Hex(),
+			Price:       10,
+			Quantity:    quantity.Quantity{500, "g"},
+		})
+		assert.Ok(t, err)
+
+		supplier, price, err := serv.BestPrice(flour.ID.Hex())
+		assert.Ok(t, err)
+		assert.Equal(t, supplier.ID, s1.ID)
+		assert.Equal(t, price.Price, 450.0)
+
+		comp, _ := compRepo.FindByID(flour.ID.Hex())
+		assert.Equal(t, comp.Cost, 18.0)
+
+		entries := compRepo.Entries()
+		assert.Equal(t, len(entries), 1, "Cost changed only once")
+		assert.Equal(t, entries[0].Type(), "CompositionUpdatedManually")
+	})
+
+	t.Run("Preferred price", func(t *testing.T) {
+		_, err := serv.SetPrice(s2.ID.Hex(), &PriceRequest{
+			Composition: flour.ID.Hex(),
+			Price:       10,
+			Quantity:    quantity.Quantity{500, "g"},
+			Preferred:   true,
+		})
+		assert.Ok(t, err)
+
+		comp, _ := compRepo.FindByID(flour.ID.Hex())
+		assert.Equal(t, comp.Cost, 20.0)
+
+		// Only one preferred supplier
+		_, err = serv.SetPrice(s1.ID.Hex(), &PriceRequest{
+			Composition: flour.ID.Hex(),
+			Price:       450,
+			Quantity:    quantity.Quantity{25, "kg"},
+			Preferred:   true,
+		})
+		assert.Ok(t, err)
+
+		saved, _ := serv.GetByID(s2.ID.Hex())
+		assert.Assert(t, !saved.FindPrice(flour.ID.Hex()).Preferred)
+
+		comp, _ = compRepo.FindByID(flour.ID.Hex())
+		assert.Equal(t, comp.Cost, 18.0)
+	})
+
+	t.Run("Remove price", func(t *testing.T) {
+		_, err := serv.RemovePrice(s1.ID.Hex(), flour.ID.Hex())
+		assert.Ok(t, err)
+
+		comp, _ := compRepo.FindByID(flour.ID.Hex())
+		assert.Equal(t, comp.Cost, 20.0)
+
+		_, err = serv.RemovePrice(s1.ID.Hex(), flour.ID.Hex())
+		assert.ErrCode(t, err, "PRICE_DOES_NOT_EXIST")
+	})
+
+	t.Run("Compositions with dependencies are not updated", func(t *testing.T) {
+		bread := newComposition(quantity.Quantity{1, "u"}, 5)
+		bread.Dependencies = []composition.Dependency{
+			composition.Dependency{On: flour.ID, Quantity: quantity.Quantity{250, "g"}, Subvalue: 5},
+		}
+		compRepo.Insert(bread)
+
+		_, err := serv.SetPrice(s1.ID.Hex(), &PriceRequest{
+			Composition: bread.ID.Hex(),
+			Price:       100,
+			Quantity:    quantity.Quantity{1, "u"},
+		})
+		assert.Ok(t, err)
+
+		comp, _ := compRepo.FindByID(bread.ID.Hex())
+		assert.Equal(t, comp.Cost, 5.0)
+	})
+}
+
+func TestPriceCostFor(t *testing.T) {
+	p := &Price{Price: 450, Quantity: quantity.Quantity{25, "kg"}}
+
+	cost, err := p.CostFor(quantity.Quantity{500, "g"})
+	assert.Ok(t, err)
+	assert.Equal(t, cost, 9.0)
+
+	_, err = p.CostFor(quantity.Quantity{1, "l"})
+	assert.ErrCode(t, err, "INCOMPATIBLE_QUANTITY")
+}
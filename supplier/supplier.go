@@ -0,0 +1,130 @@
+package supplier
+
+import (
+	"time"
+
+	"github.com/aboglioli/big-brother/pkg/contact"
+	"github.com/aboglioli/big-brother/pkg/errors"
+	"github.com/aboglioli/big-brother/pkg/quantity"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Price is the price of a composition for a quantity expressed in the
+// supplier's purchase unit (e.g. $450 per 25 kg bag).
+type Price struct {
+	Composition primitive.ObjectID `json:"composition" bson:"composition"`
+	Price       float64            `json:"price" bson:"price"`
+	Quantity    quantity.Quantity  `json:"quantity" bson:"quantity"`
+	Preferred   bool               `json:"preferred" bson:"preferred"`
+	UpdatedAt   time.Time          `json:"updatedAt" bson:"updatedAt"`
+}
+
+// CostFor returns the cost of the given quantity. It has the same semantics
+// than Composition.CostFromQuantity: it fails if the quantity can't be
+// compared with the price quantity.
+func (p *Price) CostFor(q quantity.Quantity) (float64, error) {
+	ratio, err := q.Ratio(p.Quantity)
+	if err != nil {
+		return 0, errors.NewStatus("INCOMPATIBLE_QUANTITY").SetPath("supplier/supplier.CostFor").SetMessage("%v != %v", q, p.Quantity).SetRef(err)
+	}
+
+	return ratio * p.Price, nil
+}
+
+type Supplier struct {
+	ID     primitive.ObjectID `json:"id" bson:"_id"`
+	Name   string             `json:"name" bson:"name"`
+	Prices []Price            `json:"prices" bson:"prices"`
+
+	Address contact.Address `json:"address" bson:"address"`
+	Contact contact.Contact `json:"contact" bson:"contact"`
+	Social  contact.Social  `json:"social" bson:"social"`
+
+	Enabled   bool      `json:"-" bson:"enabled"`
+	CreatedAt time.Time `json:"createdAt" bson:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt" bson:"updatedAt"`
+}
+
+func NewSupplier() *Supplier {
+	return &Supplier{
+		ID:        primitive.NewObjectID(),
+		Prices:    make([]Price, 0),
+		Enabled:   true,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+}
+
+func (s *Supplier) FindPrice(compID string) *Price {
+	for i := range s.Prices {
+		if s.Prices[i].Composition.Hex() == compID {
+			return &s.Prices[i]
+		}
+	}
+	return nil
+}
+
+func (s *Supplier) UpsertPrice(p Price) {
+	p.UpdatedAt = time.Now()
+
+	if price := s.FindPrice(p.Composition.Hex()); price != nil {
+		*price = p
+		return
+	}
+
+	s.Prices = append(s.Prices, p)
+}
+
+func (s *Supplier) RemovePrice(compID string) error {
+	removed := false
+	prices := make([]Price, 0, len(s.Prices))
+	for _, p := range s.Prices {
+		if p.Composition.Hex() != compID {
+			prices = append(prices, p)
+			continue
+		}
+		removed = true
+	}
+	s.Prices = prices
+
+	if !removed {
+		return errors.NewValidation("PRICE_DOES_NOT_EXIST")
+	}
+
+	return nil
+}
+
+func (s *Supplier) ValidateSchema() error {
+	err := errors.NewValidation("VALIDATE_SCHEMA").SetPath("supplier/supplier.ValidateSchema")
+
+	if len(s.Name) < 1 || len(s.Name) > 128 {
+		err.AddWithMessage("name", "INVALID_LENGTH", "%d", len(s.Name))
+	}
+
+	if !s.Address.IsValid() {
+		err.Add("address", "INVALID")
+	}
+
+	if !s.Contact.IsValid() {
+		err.Add("contact", "INVALID")
+	}
+
+	if !s.Social.IsValid() {
+		err.Add("social", "INVALID")
+	}
+
+	for i, p := range s.Prices {
+		if p.Price < 0 {
+			err.AddWithMessage("price", "INVALID_PRICE", "price %d", i)
+		}
+		if !p.Quantity.IsValid() || p.Quantity.Quantity == 0 {
+			err.AddWithMessage("price", "INVALID_QUANTITY", "price %d", i)
+		}
+	}
+
+	if err.Size() > 0 {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,107 @@
+package units
+
+import (
+	"context"
+
+	"github.com/aboglioli/big-brother/infrastructure/db"
+	"github.com/aboglioli/big-brother/pkg/errors"
+	"github.com/aboglioli/big-brother/pkg/unit"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Repository stores the units added to the default ones. It is a unit.Store.
+type Repository interface {
+	FindAll() ([]*unit.Unit, error)
+	FindByName(name string) (*unit.Unit, error)
+
+	Insert(*unit.Unit) error
+	Delete(name string) error
+}
+
+type repository struct {
+	collection *mongo.Collection
+}
+
+func NewRepository() (Repository, error) {
+	db, err := db.Get("Unit")
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &repository{
+		collection: db.Collection("unit"),
+	}, nil
+}
+
+func (r *repository) FindAll() ([]*unit.Unit, error) {
+	path := "units/repository.FindAll"
+	ctx := context.Background()
+
+	cur, err := r.collection.Find(ctx, bson.D{})
+	if err != nil {
+		return nil, errors.NewInternal("FIND_ALL").SetPath(path).SetRef(err)
+	}
+	defer cur.Close(ctx)
+
+	var units []*unit.Unit
+	for cur.Next(ctx) {
+		var u unit.Unit
+
+		if err := cur.Decode(&u); err != nil {
+			return nil, errors.NewInternal("CUR_DECODE").SetPath(path).SetRef(err)
+		}
+
+		units = append(units, &u)
+	}
+
+	if err := cur.Err(); err != nil {
+		return nil, errors.NewInternal("CUR").SetPath(path).SetRef(err)
+	}
+
+	return units, nil
+}
+
+func (r *repository) FindByName(name string) (*unit.Unit, error) {
+	path := "units/repository.FindByName"
+	ctx := context.Background()
+
+	res := r.collection.FindOne(ctx, bson.M{"name": name})
+	if res.Err() != nil {
+		return nil, errors.NewInternal("FIND_ONE").SetPath(path).SetRef(res.Err())
+	}
+
+	var u unit.Unit
+	if err := res.Decode(&u); err != nil {
+		return nil, errors.NewInternal("DECODE").SetPath(path).SetRef(err)
+	}
+
+	return &u, nil
+}
+
+func (r *repository) Insert(u *unit.Unit) error {
+	ctx := context.Background()
+
+	_, err := r.collection.InsertOne(ctx, u)
+	if err != nil {
+		return errors.NewInternal("INSERT_ONE").SetPath("units/repository.Insert").SetRef(err)
+	}
+
+	return nil
+}
+
+func (r *repository) Delete(name string) error {
+	path := "units/repository.Delete"
+	ctx := context.Background()
+
+	res, err := r.collection.DeleteOne(ctx, bson.M{"name": name})
+	if err != nil {
+		return errors.NewInternal("DELETE_ONE").SetPath(path).SetRef(err)
+	}
+	if res.DeletedCount == 0 {
+		return errors.NewInternal("NOT_FOUND").SetPath(path)
+	}
+
+	return nil
+}
@@ -0,0 +1,69 @@
+package units
+
+import (
+	"github.com/aboglioli/big-brother/pkg/errors"
+	"github.com/aboglioli/big-brother/pkg/tests/mock"
+	"github.com/aboglioli/big-brother/pkg/unit"
+)
+
+type mockRepository struct {
+	mock.Mock
+	units []*unit.Unit
+}
+
+func newMockRepository() *mockRepository {
+	return &mockRepository{}
+}
+
+// Helpers
+func (r *mockRepository) Clean() {
+	r.units = make([]*unit.Unit, 0)
+}
+
+// Implementation
+func (r *mockRepository) FindAll() ([]*unit.Unit, error) {
+	r.Called("FindAll")
+
+	units := make([]*unit.Unit, 0, len(r.units))
+	for _, u := range r.units {
+		copy := *u
+		units = append(units, &copy)
+	}
+
+	return units, nil
+}
+
+func (r *mockRepository) FindByName(name string) (*unit.Unit, error) {
+	r.Called("FindByName", name)
+
+	for _, u := range r.units {
+		if u.Name == name {
+			copy := *u
+			return &copy, nil
+		}
+	}
+
+	return nil, errors.NewInternal("NOT_FOUND").SetPath("units/repository_mock.FindByName")
+}
+
+func (r *mockRepository) Insert(u *unit.Unit) error {
+	r.Called("Insert", u)
+
+	copy := *u
+	r.units = append(r.units, &copy)
+
+	return nil
+}
+
+func (r *mockRepository) Delete(name string) error {
+	r.Called("Delete", name)
+
+	for i, u := range r.units {
+		if u.Name == name {
+			r.units = append(r.units[:i], r.units[i+1:]...)
+			return nil
+		}
+	}
+
+	return errors.NewInternal("NOT_FOUND").SetPath("units/repository_mock.Delete")
+}
@@ -0,0 +1,138 @@
+package units
+
+import (
+	"sort"
+
+	"github.com/aboglioli/big-brother/composition"
+	"github.com/aboglioli/big-brother/pkg/errors"
+	"github.com/aboglioli/big-brother/pkg/unit"
+)
+
+type Service interface {
+	FindAll(unitType string) []*unit.Unit
+	FindTypes() []string
+	Create(req *CreateRequest) (*unit.Unit, error)
+	Delete(name string) error
+}
+
+type service struct {
+	repository         Repository
+	registry           *unit.Registry
+	compositionService composition.Service
+}
+
+// NewService creates the unit administration service. The registry is
+// reloaded after each change.
+func NewService(r Repository, registry *unit.Registry, compServ composition.Service) Service {
+	return &service{
+		repository:         r,
+		registry:           registry,
+		compositionService: compServ,
+	}
+}
+
+// FindAll returns the units sorted by type and modifier, or only the units of
+// a type if it is not empty.
+func (s *service) FindAll(unitType string) []*unit.Unit {
+	var units []*unit.Unit
+	if unitType != "" {
+		units = s.registry.FindByType(unitType)
+	} else {
+		units = s.registry.FindAll()
+	}
+
+	sort.Slice(units, func(i, j int) bool {
+		if units[i].Type != units[j].Type {
+			return units[i].Type < units[j].Type
+		}
+		return units[i].Modifier < units[j].Modifier
+	})
+
+	return units
+}
+
+// FindTypes returns the sorted unit types.
+func (s *service) FindTypes() []string {
+	types := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, u := range s.registry.FindAll() {
+		if !seen[u.Type] {
+			seen[u.Type] = true
+			types = append(types, u.Type)
+		}
+	}
+
+	sort.Strings(types)
+	return types
+}
+
+// CreateRequest adds a unit. Its modifier is relative to the other units of
+// its type. A unit of a new type creates the type.
+type CreateRequest struct {
+	Type     string  `json:"type" binding:"required"`
+	Name     string  `json:"name" binding:"required"`
+	Modifier float64 `json:"modifier" binding:"required"`
+}
+
+func (s *service) Create(req *CreateRequest) (*unit.Unit, error) {
+	path := "units/service.Create"
+
+	u := &unit.Unit{
+		Type:     req.Type,
+		Name:     req.Name,
+		Modifier: req.Modifier,
+	}
+
+	if err := u.ValidateSchema(); err != nil {
+		return nil, err
+	}
+
+	if unit.IsDefault(u.Name) {
+		return nil, errors.NewStatus("UNIT_ALREADY_EXISTS").SetPath(path).SetMessage("%s", u.Name)
+	}
+	if _, err := s.repository.FindByName(u.Name); err == nil {
+		return nil, errors.NewStatus("UNIT_ALREADY_EXISTS").SetPath(path).SetMessage("%s", u.Name)
+	}
+
+	if err := s.repository.Insert(u); err != nil {
+		return nil, errors.NewStatus("INSERT").SetPath(path).SetRef(err)
+	}
+
+	if err := s.registry.Reload(); err != nil {
+		return nil, errors.NewStatus("RELOAD").SetPath(path).SetRef(err)
+	}
+
+	return u, nil
+}
+
+// Delete deletes a unit not used by any composition. Default units can't be
+// deleted.
+func (s *service) Delete(name string) error {
+	path := "units/service.Delete"
+
+	if unit.IsDefault(name) {
+		return errors.NewStatus("DEFAULT_UNIT").SetPath(path).SetMessage("%s can't be deleted", name)
+	}
+
+	if _, err := s.repository.FindByName(name); err != nil {
+		return errors.NewStatus("UNIT_NOT_FOUND").SetPath(path).SetStatus(404).SetRef(err)
+	}
+
+	comps, err := s.compositionService.FindByUnit(name)
+	if err != nil {
+		return err
+	}
+	if len(comps) > 0 {
+		return errors.NewStatus("UNIT_IN_USE").SetPath(path).SetMessage("Unit used in %d compositions", len(comps))
+	}
+
+	if err := s.repository.Delete(name); err != nil {
+		return errors.NewStatus("DELETE").SetPath(path).SetRef(err)
+	}
+
+	if err := s.registry.Reload(); err != nil {
+		return errors.NewStatus("RELOAD").SetPath(path).SetRef(err)
+	}
+
+	return nil
+}
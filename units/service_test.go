@@ -0,0 +1,63 @@
+package units
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aboglioli/big-brother/composition"
+	"github.com/aboglioli/big-brother/pkg/quantity"
+	"github.com/aboglioli/big-brother/pkg/tests/assert"
+	"github.com/aboglioli/big-brother/pkg/unit"
+)
+
+func TestUnits(t *testing.T) {
+	repo, compRepo := newMockRepository(), composition.NewMockRepository()
+	registry, err := unit.NewRegistry(repo, time.Minute)
+	assert.Ok(t, err)
+	unit.SetRepository(registry)
+	defer unit.SetRepository(nil)
+
+	compServ := composition.NewService(compRepo)
+	serv := NewService(repo, registry, compServ)
+
+	assert.Assert(t, !quantity.Quantity{1, "h"}.IsValid())
+
+	h, err := serv.Create(&CreateRequest{Type: "time", Name: "h", Modifier: 3600})
+	assert.Ok(t, err)
+	assert.Equal(t, h.Type, "time")
+	_, err = serv.Create(&CreateRequest{Type: "time", Name: "min", Modifier: 60})
+	assert.Ok(t, err)
+
+	assert.Assert(t, quantity.Quantity{1, "h"}.IsValid())
+	assert.Assert(t, quantity.Quantity{1, "h"}.Equals(quantity.Quantity{60, "min"}))
+	assert.Equal(t, len(serv.FindAll("time")), 2)
+	assert.Equal(t, serv.FindAll("time")[0].Name, "min")
+
+	types := serv.FindTypes()
+	assert.Equal(t, len(types), 9)
+	assert.Equal(t, types[len(types)-1], "volume")
+
+	t.Run("Invalid units", func(t *testing.T) {
+		_, err := serv.Create(&CreateRequest{Type: "mass", Name: "kg", Modifier: 1000})
+		assert.ErrCode(t, err, "UNIT_ALREADY_EXISTS")
+		_, err = serv.Create(&CreateRequest{Type: "time", Name: "h", Modifier: 3600})
+		assert.ErrCode(t, err, "UNIT_ALREADY_EXISTS")
+		_, err = serv.Create(&CreateRequest{Type: "time", Name: "s"})
+		assert.ErrValidation(t, err, "modifier", "INVALID")
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		comp := composition.NewComposition()
+		comp.Unit = quantity.Quantity{1, "h"}
+		comp.Stock = quantity.Quantity{0, "h"}
+		compRepo.Insert(comp)
+
+		assert.ErrCode(t, serv.Delete("h"), "UNIT_IN_USE")
+		assert.ErrCode(t, serv.Delete("kg"), "DEFAULT_UNIT")
+		assert.ErrCode(t, serv.Delete("s"), "UNIT_NOT_FOUND")
+
+		assert.Ok(t, serv.Delete("min"))
+		assert.Assert(t, !quantity.Quantity{1, "min"}.IsValid())
+		assert.Equal(t, len(repo.units), 1)
+	})
+}